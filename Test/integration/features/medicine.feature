@@ -8,24 +8,45 @@ Feature: Medicine Management
 
   Scenario: Create a new medicine successfully
     Given I generate a unique alias as "medName"
+    And I generate a unique EAN code as "medEAN"
     When I send a POST request to "/v1/medicine" with body:
       """
       {
         "name": "${medName}",
         "description": "Test medicine",
-        "eanCode": "${medName}-EAN",
+        "eanCode": "${medEAN}",
         "laboratory": "TestLab"
       }
       """
     Then the response code should be 200
     And the JSON response should contain key "id"
     And I save the JSON response key "id" as "medicineID"
+    And I save the JSON response key "laboratoryId" as "laboratoryID"
 
   Scenario: Retrieve created medicine
     When I send a GET request to "/v1/medicine/${medicineID}"
     Then the response code should be 200
     And the JSON response should contain "id" with numeric value  ${medicineID}
 
+  Scenario: Look up medicine by barcode
+    When I send a GET request to "/v1/medicine/barcode/${medEAN}"
+    Then the response code should be 200
+    And the JSON response should contain key "gtin"
+    And the JSON response should contain key "medicine"
+
+  Scenario: Reject barcode with invalid check digit
+    When I send a GET request to "/v1/medicine/barcode/4006381333932"
+    Then the response code should be 400
+
+  Scenario: Medicine is linked to its laboratory
+    When I send a GET request to "/v1/laboratory/${laboratoryID}"
+    Then the response code should be 200
+    And the JSON response should contain "name" with value "TestLab"
+
+  Scenario: Laboratory with medicines cannot be deleted
+    When I send a DELETE request to "/v1/laboratory/${laboratoryID}"
+    Then the response code should be 400
+
   Scenario: Update medicine description
     When I send a PUT request to "/v1/medicine/${medicineID}" with body:
       """
@@ -41,6 +62,18 @@ Feature: Medicine Management
     Then the response code should be 200
     And the JSON response should contain "message": "resource deleted successfully"
 
+  Scenario: Deleted medicine is listed in the trash and can be restored
+    When I send a GET request to "/v1/medicine/${medicineID}"
+    Then the response code should be 404
+    When I send a GET request to "/v1/medicine/trash?id_match=${medicineID}"
+    Then the response code should be 200
+    And the JSON response should contain "total": 1
+    When I send a POST request to "/v1/medicine/${medicineID}/restore"
+    Then the response code should be 200
+    And the JSON response should contain "id" with numeric value  ${medicineID}
+    When I send a DELETE request to "/v1/medicine/${medicineID}"
+    Then the response code should be 200
+
   Scenario: Search medicines paginated
     When I send a GET request to "/v1/medicine/search?page=1&pageSize=10"
     Then the response code should be 200
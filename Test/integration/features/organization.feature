@@ -0,0 +1,33 @@
+Feature: Organization Management
+  As an API consumer
+  I want to register organizations with their owner
+  So that each organization keeps its own users and medicines
+
+  Background:
+    # Authentication handled globally
+
+  Scenario: Retrieve the organization of the authenticated user
+    When I send a GET request to "/v1/organization/current"
+    Then the response code should be 200
+    And the JSON response should contain key "id"
+    And the JSON response should contain key "name"
+
+  Scenario: Create a new organization with its owner
+    Given I generate a unique alias as "orgName"
+    When I send a POST request to "/v1/organization" with body:
+      """
+      {
+        "name": "${orgName}",
+        "owner": {
+          "user": "${orgName}",
+          "email": "${orgName}@example.com",
+          "firstName": "Owner",
+          "lastName": "Test",
+          "password": "Secret123"
+        }
+      }
+      """
+    Then the response code should be 200
+    And the JSON response should contain "organization.name" with value "${orgName}"
+    And the JSON response should contain "owner.email" with value "${orgName}@example.com"
+
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"strconv"
@@ -57,6 +58,21 @@ func generateUniqueValue(prefix string) string {
 	return fmt.Sprintf("%s-%s", prefix, generateUUID())
 }
 
+// generateUniqueEAN generates a random EAN-13 with a valid check digit
+func generateUniqueEAN() string {
+	digits := make([]byte, 12)
+	sum := 0
+	for i := range digits {
+		d := rand.IntN(10)
+		digits[i] = byte('0' + d)
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return fmt.Sprintf("%s%d", digits, (10-sum%10)%10)
+}
+
 // generateUniqueRFC generates a unique RFC
 func generateUniqueRFC() string {
 	return generateUniqueValue("RFC")
@@ -532,7 +548,7 @@ func removeResourceFromTracking(fullURL string) {
 }
 
 func iGenerateAUniqueEANCodeAs(varName string) error {
-	uniqueEAN := generateUniqueValue("EAN")
+	uniqueEAN := generateUniqueEAN()
 	savedVars[varName] = uniqueEAN
 	logger.Printf("Generated unique EAN code: %s", uniqueEAN)
 	return nil
@@ -1019,7 +1035,7 @@ func createUniqueMedicine(prefix string) (int, error) {
 	medicineData := map[string]interface{}{
 		"name":        fmt.Sprintf("%s Medicine", prefix),
 		"description": fmt.Sprintf("Description for %s medicine", prefix),
-		"eanCode":     generateUniqueValue("EAN"),
+		"eanCode":     generateUniqueEAN(),
 		"laboratory":  "TestLab",
 	}
 
@@ -0,0 +1,52 @@
+// Command reencrypt brings the encrypted personal data of users up to date with the
+// PII keys configured in the environment: values written before encryption was enabled
+// are encrypted, values under a retired master key are rewrapped with the active one
+// and blind indexes are recomputed. Run it after enabling encryption or changing the
+// active key, and before removing a retired key from PII_MASTER_KEYS.
+//
+//	go run ./cmd/reencrypt -batch-size 500
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/di"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/encryption"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", encryption.DefaultBatchSize, "number of rows read at a time")
+	flag.Parse()
+
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		panic(fmt.Errorf("error initializing logger: %w", err))
+	}
+
+	var db *gorm.DB
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "", di.DriverPostgres:
+		db, err = psql.InitPSQLDB(loggerInstance)
+	case di.DriverSQLite:
+		db, err = psql.InitSQLiteDB(loggerInstance)
+	default:
+		loggerInstance.Fatal("DB_DRIVER has no data at rest to re-encrypt", zap.String("driver", driver))
+	}
+	if err != nil {
+		loggerInstance.Fatal("Error opening the database", zap.Error(err))
+	}
+
+	updated, err := user.Reencrypt(context.Background(), db, *batchSize)
+	if err != nil {
+		loggerInstance.Fatal("Error re-encrypting user personal data", zap.Error(err), zap.Int64("updated", updated))
+	}
+	loggerInstance.Info("Re-encrypted user personal data", zap.Int64("updated", updated))
+}
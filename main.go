@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/infrastructure/di"
@@ -17,13 +22,15 @@ import (
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Port string
+	Port            string
+	ShutdownTimeout time.Duration
 }
 
 // loadServerConfig loads server configuration from environment variables
 func loadServerConfig() ServerConfig {
 	return ServerConfig{
-		Port: getEnvOrDefault("SERVER_PORT", "8080"),
+		Port:            getEnvOrDefault("SERVER_PORT", "8080"),
+		ShutdownTimeout: time.Duration(getEnvAsIntOrDefault("SERVER_SHUTDOWN_TIMEOUT_SECONDS", 30)) * time.Second,
 	}
 }
 
@@ -59,17 +66,50 @@ func main() {
 		loggerInstance.Panic("Error initializing application context", zap.Error(err))
 	}
 
+	// Background workers stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background workers
+	appContext.OutboxRelay.Start(ctx)
+	appContext.OutboxListener.Start(ctx)
+	appContext.WebhookDispatcher.Start(ctx)
+	appContext.Replicas.Start(ctx)
+	appContext.JobRunner.Start(ctx)
+	appContext.Scheduler.Start(ctx)
+
 	// Setup router
 	router := setupRouter(appContext, loggerInstance)
 
 	// Setup server
 	server := setupServer(router, serverConfig.Port)
+	// Streaming responses never finish by themselves
+	server.RegisterOnShutdown(appContext.ChangeFeed.DisconnectAll)
 
 	// Start server
-	loggerInstance.Info("Server starting", zap.String("port", serverConfig.Port))
-	if err := server.ListenAndServe(); err != nil {
-		loggerInstance.Panic("Server failed to start", zap.Error(err))
+	go func() {
+		loggerInstance.Info("Server starting", zap.String("port", serverConfig.Port))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			loggerInstance.Panic("Server failed to start", zap.Error(err))
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	loggerInstance.Info("Shutting down", zap.Duration("timeout", serverConfig.ShutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		loggerInstance.Error("Error shutting down server", zap.Error(err))
+	}
+	// Running jobs finish before the process exits; jobs interrupted by the timeout run again later
+	if err := appContext.JobRunner.Shutdown(shutdownCtx); err != nil {
+		loggerInstance.Warn("Job runner did not drain in time", zap.Error(err))
+	}
+	if err := appContext.EventBus.Wait(shutdownCtx); err != nil {
+		loggerInstance.Warn("Event handlers did not finish in time", zap.Error(err))
 	}
+	loggerInstance.Info("Server stopped")
 }
 
 func setupRouter(appContext *di.ApplicationContext, logger *logger.Logger) *gin.Engine {
@@ -111,10 +151,19 @@ func setupServer(router *gin.Engine, port string) *http.Server {
 	}
 }
 
-// Helper function
+// Helper functions
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
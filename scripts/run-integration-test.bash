@@ -58,13 +58,15 @@ error_handler() {
 validate_required_env_vars() {
   local missing_vars=()
   
-  # Database variables
-  [[ -z "${DB_HOST:-}" ]] && missing_vars+=("DB_HOST")
-  [[ -z "${DB_PORT:-}" ]] && missing_vars+=("DB_PORT")
-  [[ -z "${DB_USER:-}" ]] && missing_vars+=("DB_USER")
-  [[ -z "${DB_PASSWORD:-}" ]] && missing_vars+=("DB_PASSWORD")
-  [[ -z "${DB_NAME:-}" ]] && missing_vars+=("DB_NAME")
-  [[ -z "${DB_SSLMODE:-}" ]] && missing_vars+=("DB_SSLMODE")
+  # Database variables (only PostgreSQL needs a server)
+  if [[ "${DB_DRIVER:-postgres}" == "postgres" ]]; then
+    [[ -z "${DB_HOST:-}" ]] && missing_vars+=("DB_HOST")
+    [[ -z "${DB_PORT:-}" ]] && missing_vars+=("DB_PORT")
+    [[ -z "${DB_USER:-}" ]] && missing_vars+=("DB_USER")
+    [[ -z "${DB_PASSWORD:-}" ]] && missing_vars+=("DB_PASSWORD")
+    [[ -z "${DB_NAME:-}" ]] && missing_vars+=("DB_NAME")
+    [[ -z "${DB_SSLMODE:-}" ]] && missing_vars+=("DB_SSLMODE")
+  fi
   
   # JWT variables
   [[ -z "${JWT_ACCESS_SECRET_KEY:-}" ]] && missing_vars+=("JWT_ACCESS_SECRET_KEY")
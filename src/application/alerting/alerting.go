@@ -0,0 +1,27 @@
+// Package alerting evaluates the stock alerts as the stock changes and delivers the
+// alerts raised. Both run asynchronously: an adjustment neither waits for nor fails
+// because of them, and the periodic evaluation catches up on anything missed.
+package alerting
+
+import (
+	"context"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+)
+
+// Evaluator raises or resolves the low stock alert of a stock level
+type Evaluator interface {
+	EvaluateLevel(ctx context.Context, level stockDomain.Level) error
+}
+
+// Listen evaluates every adjusted stock level with evaluator and delivers every
+// alert raised through notifier
+func Listen(bus *eventbus.Bus, evaluator Evaluator, notifier stockDomain.Notifier) {
+	eventbus.SubscribeAsync(bus, "alerting", func(ctx context.Context, e stockDomain.Adjusted) error {
+		return evaluator.EvaluateLevel(ctx, e.Level)
+	})
+	eventbus.SubscribeAsync(bus, "alerting", func(ctx context.Context, e stockDomain.AlertRaised) error {
+		return notifier.Notify(ctx, e.Alert)
+	})
+}
@@ -0,0 +1,51 @@
+package alerting
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	levels []stockDomain.Level
+	alerts []stockDomain.Alert
+}
+
+func (r *recorder) EvaluateLevel(_ context.Context, level stockDomain.Level) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels = append(r.levels, level)
+	return nil
+}
+
+func (r *recorder) Notify(_ context.Context, alert stockDomain.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func TestListen(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	bus := eventbus.New(loggerInstance)
+	r := &recorder{}
+	Listen(bus, r, r)
+	ctx := context.Background()
+
+	eventbus.Publish(ctx, bus, stockDomain.Adjusted{Level: stockDomain.Level{MedicineID: 1, Location: "main", Quantity: 3}, Delta: -2})
+	eventbus.Publish(ctx, bus, stockDomain.AlertRaised{Alert: stockDomain.Alert{ID: 4}})
+	require.NoError(t, bus.Wait(ctx))
+
+	require.Len(t, r.levels, 1)
+	assert.Equal(t, 3, r.levels[0].Quantity)
+	require.Len(t, r.alerts, 1)
+	assert.Equal(t, 4, r.alerts[0].ID)
+}
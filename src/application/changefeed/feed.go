@@ -0,0 +1,275 @@
+// Package changefeed turns the events of the use cases into notifications streamed to
+// connected clients. A bounded log of recent notifications lets a client that
+// reconnects resume where it stopped.
+package changefeed
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"github.com/gbrayhan/microservices-go/src/domain/history"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// Resources clients may subscribe to
+var Resources = []string{event.AggregateMedicine, event.AggregateUser}
+
+// Config holds the change feed settings
+type Config struct {
+	LogSize      int
+	ClientBuffer int
+	Heartbeat    time.Duration
+}
+
+// LoadConfig loads change feed configuration from environment variables
+func LoadConfig() Config {
+	return Config{
+		LogSize:      config.EnvAsIntOrDefault("STREAM_LOG_SIZE", 1000),
+		ClientBuffer: config.EnvAsIntOrDefault("STREAM_CLIENT_BUFFER", 64),
+		Heartbeat:    time.Duration(config.EnvAsIntOrDefault("STREAM_HEARTBEAT_SECONDS", 15)) * time.Second,
+	}
+}
+
+// Notification tells that a resource changed. It carries no data of the resource:
+// clients fetch it through the API, which applies the usual permissions.
+type Notification struct {
+	ID         string
+	Type       string
+	Resource   string
+	ResourceID int
+	Fields     []string
+	OccurredAt time.Time
+	TenantID   int
+	seq        uint64
+	eventID    string
+}
+
+// Filter selects the notifications a client receives: those of its organization,
+// about the subscribed resources
+type Filter struct {
+	TenantID  int
+	Resources []string
+}
+
+func (f Filter) matches(n *Notification) bool {
+	return n.TenantID == f.TenantID && slices.Contains(f.Resources, n.Resource)
+}
+
+// Subscription receives the notifications matching its filter on C. C is closed when
+// the client falls so far behind that its buffer is full; it resumes by subscribing
+// again from the last notification it got.
+type Subscription struct {
+	C      <-chan Notification
+	c      chan Notification
+	filter Filter
+}
+
+// Feed keeps the last LogSize notifications and fans new ones out to subscriptions.
+// IDs are "<boot>-<sequence>", where boot identifies the running process: the log
+// lives in memory, so an ID from before a restart cannot be resumed.
+type Feed struct {
+	config Config
+	Logger *logger.Logger
+
+	mu            sync.Mutex
+	boot          string
+	seq           uint64
+	log           []Notification
+	head          int
+	subscriptions map[*Subscription]struct{}
+}
+
+func New(config Config, loggerInstance *logger.Logger) *Feed {
+	return &Feed{
+		config:        config,
+		Logger:        loggerInstance,
+		boot:          strconv.FormatInt(time.Now().UnixNano(), 36),
+		log:           make([]Notification, 0, max(config.LogSize, 0)),
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Listen feeds the medicine and user events published on bus into f. Only the
+// in-process events of this instance reach it, so it serves the memory driver, which
+// has no outbox; the SQL drivers feed f as a publisher of the outbox relay instead.
+func (f *Feed) Listen(bus *eventbus.Bus) {
+	eventbus.Subscribe(bus, "changefeed", func(ctx context.Context, e medicineDomain.Created) error {
+		f.record(e.Medicine.TenantID, event.MedicineCreated, e.Medicine.ID, nil, "")
+		return nil
+	})
+	eventbus.Subscribe(bus, "changefeed", func(ctx context.Context, e medicineDomain.Updated) error {
+		f.record(e.Medicine.TenantID, event.MedicineUpdated, e.Medicine.ID, e.Fields, "")
+		return nil
+	})
+	eventbus.Subscribe(bus, "changefeed", func(ctx context.Context, e medicineDomain.Deleted) error {
+		tenantID, _ := security.TenantID(ctx)
+		f.record(tenantID, event.MedicineDeleted, e.ID, nil, "")
+		return nil
+	})
+	eventbus.Subscribe(bus, "changefeed", func(ctx context.Context, e medicineDomain.Restored) error {
+		f.record(e.Medicine.TenantID, event.MedicineRestored, e.Medicine.ID, nil, "")
+		return nil
+	})
+	eventbus.Subscribe(bus, "changefeed", func(ctx context.Context, e userDomain.Created) error {
+		f.record(e.User.TenantID, event.UserCreated, e.User.ID, nil, "")
+		return nil
+	})
+	eventbus.Subscribe(bus, "changefeed", func(ctx context.Context, e userDomain.Updated) error {
+		f.record(e.User.TenantID, event.UserUpdated, e.User.ID, e.Fields, "")
+		return nil
+	})
+	eventbus.Subscribe(bus, "changefeed", func(ctx context.Context, e userDomain.Deleted) error {
+		tenantID, _ := security.TenantID(ctx)
+		f.record(tenantID, event.UserDeleted, e.ID, nil, "")
+		return nil
+	})
+	eventbus.Subscribe(bus, "changefeed", func(ctx context.Context, e userDomain.Restored) error {
+		f.record(e.User.TenantID, event.UserRestored, e.User.ID, nil, "")
+		return nil
+	})
+}
+
+// Publish records the change announced by an event of the outbox. The outbox holds the
+// committed changes of every instance, so fed by it each instance streams all of them.
+// An event delivered again, as the outbox retries do, is recorded once while it is
+// still in the log.
+func (f *Feed) Publish(_ context.Context, e event.Event) error {
+	if !slices.Contains(Resources, e.AggregateType) {
+		return nil
+	}
+	var fields []string
+	if e.Type == event.TypeOf(e.AggregateType, history.ActionUpdate) {
+		var data struct {
+			Changes map[string]json.RawMessage `json:"changes"`
+		}
+		if err := json.Unmarshal(e.Data, &data); err == nil {
+			fields = slices.Sorted(maps.Keys(data.Changes))
+		}
+	}
+	f.record(e.TenantID, e.Type, e.AggregateID, fields, e.ID)
+	return nil
+}
+
+// Subscribe returns the logged notifications after lastEventID that match filter and
+// a subscription to the next ones. complete is false when the client may have missed
+// notifications, because lastEventID fell out of the log or was issued before a
+// restart: it should then reload what it shows. An empty lastEventID starts from now.
+func (f *Feed) Subscribe(filter Filter, lastEventID string) (backlog []Notification, subscription *Subscription, complete bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	complete = true
+	if lastEventID != "" {
+		var after uint64
+		after, complete = f.resumable(lastEventID)
+		if complete {
+			for i := range f.log {
+				n := &f.log[(f.head+i)%len(f.log)]
+				if n.seq > after && filter.matches(n) {
+					backlog = append(backlog, *n)
+				}
+			}
+		}
+	}
+
+	c := make(chan Notification, max(f.config.ClientBuffer, 1))
+	subscription = &Subscription{C: c, c: c, filter: filter}
+	f.subscriptions[subscription] = struct{}{}
+	return backlog, subscription, complete
+}
+
+// Unsubscribe stops the notifications of subscription. It is safe to call more than once.
+func (f *Feed) Unsubscribe(subscription *Subscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subscriptions[subscription]; ok {
+		delete(f.subscriptions, subscription)
+		close(subscription.c)
+	}
+}
+
+// DisconnectAll ends every subscription, as if the clients were too slow: they
+// reconnect, to another instance when this one is shutting down
+func (f *Feed) DisconnectAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for subscription := range f.subscriptions {
+		delete(f.subscriptions, subscription)
+		close(subscription.c)
+	}
+}
+
+// resumable returns the sequence lastEventID stands for and whether every notification
+// after it is still in the log
+func (f *Feed) resumable(lastEventID string) (uint64, bool) {
+	boot, rawSeq, found := strings.Cut(lastEventID, "-")
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if !found || err != nil || boot != f.boot || seq > f.seq {
+		return 0, false
+	}
+	oldest := f.seq + 1
+	if len(f.log) > 0 {
+		oldest = f.log[f.head].seq
+	}
+	return seq, seq+1 >= oldest
+}
+
+func (f *Feed) record(tenantID int, eventType string, resourceID int, fields []string, eventID string) {
+	if tenantID == 0 {
+		// changes made outside a request belong to no client
+		return
+	}
+	resource, _, _ := strings.Cut(eventType, ".")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if eventID != "" && slices.ContainsFunc(f.log, func(n Notification) bool { return n.eventID == eventID }) {
+		return
+	}
+	f.seq++
+	n := Notification{
+		ID:         f.boot + "-" + strconv.FormatUint(f.seq, 10),
+		Type:       eventType,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Fields:     fields,
+		OccurredAt: time.Now(),
+		TenantID:   tenantID,
+		seq:        f.seq,
+		eventID:    eventID,
+	}
+	if f.config.LogSize > 0 {
+		if len(f.log) < f.config.LogSize {
+			f.log = append(f.log, n)
+		} else {
+			f.log[f.head] = n
+			f.head = (f.head + 1) % len(f.log)
+		}
+	}
+
+	for subscription := range f.subscriptions {
+		if !subscription.filter.matches(&n) {
+			continue
+		}
+		select {
+		case subscription.c <- n:
+		default:
+			f.Logger.Warn("Dropping slow change feed client", zap.Int("tenantId", subscription.filter.TenantID))
+			delete(f.subscriptions, subscription)
+			close(subscription.c)
+		}
+	}
+}
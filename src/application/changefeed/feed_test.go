@@ -0,0 +1,132 @@
+package changefeed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFeed(t *testing.T, config Config) (*Feed, *eventbus.Bus) {
+	t.Helper()
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	bus := eventbus.New(loggerInstance)
+	feed := New(config, loggerInstance)
+	feed.Listen(bus)
+	return feed, bus
+}
+
+func tenantContext(tenantID int) context.Context {
+	return security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: tenantID})
+}
+
+func ids(notifications []Notification) []int {
+	result := make([]int, 0, len(notifications))
+	for _, n := range notifications {
+		result = append(result, n.ResourceID)
+	}
+	return result
+}
+
+func TestFeed_FiltersByTenantAndResource(t *testing.T) {
+	feed, bus := setupFeed(t, Config{LogSize: 10, ClientBuffer: 10})
+	_, medicines, complete := feed.Subscribe(Filter{TenantID: 1, Resources: []string{event.AggregateMedicine}}, "")
+	assert.True(t, complete)
+	_, everything, _ := feed.Subscribe(Filter{TenantID: 1, Resources: Resources}, "")
+
+	ctx := tenantContext(1)
+	eventbus.Publish(ctx, bus, medicineDomain.Created{Medicine: medicineDomain.Medicine{ID: 1, TenantID: 1}})
+	eventbus.Publish(ctx, bus, medicineDomain.Updated{Medicine: medicineDomain.Medicine{ID: 1, TenantID: 1}, Fields: []string{"name"}})
+	eventbus.Publish(tenantContext(2), bus, medicineDomain.Created{Medicine: medicineDomain.Medicine{ID: 2, TenantID: 2}})
+	eventbus.Publish(ctx, bus, userDomain.Deleted{ID: 9})
+
+	require.Len(t, medicines.C, 2)
+	created, updated := <-medicines.C, <-medicines.C
+	assert.Equal(t, event.MedicineCreated, created.Type)
+	assert.Equal(t, event.AggregateMedicine, created.Resource)
+	assert.Equal(t, []string{"name"}, updated.Fields)
+	assert.NotEqual(t, created.ID, updated.ID)
+
+	require.Len(t, everything.C, 3)
+	<-everything.C
+	<-everything.C
+	deleted := <-everything.C
+	assert.Equal(t, event.UserDeleted, deleted.Type)
+	assert.Equal(t, 9, deleted.ResourceID)
+}
+
+func TestFeed_Resume(t *testing.T) {
+	feed, bus := setupFeed(t, Config{LogSize: 2, ClientBuffer: 10})
+	filter := Filter{TenantID: 1, Resources: Resources}
+	_, live, _ := feed.Subscribe(filter, "")
+	ctx := tenantContext(1)
+	for id := 1; id <= 4; id++ {
+		eventbus.Publish(ctx, bus, medicineDomain.Created{Medicine: medicineDomain.Medicine{ID: id, TenantID: 1}})
+	}
+	var received []Notification
+	for range 4 {
+		received = append(received, <-live.C)
+	}
+
+	backlog, _, complete := feed.Subscribe(filter, received[1].ID)
+	assert.True(t, complete)
+	assert.Equal(t, []int{3, 4}, ids(backlog))
+
+	backlog, _, complete = feed.Subscribe(filter, received[3].ID)
+	assert.True(t, complete)
+	assert.Empty(t, backlog)
+
+	_, _, complete = feed.Subscribe(filter, received[0].ID)
+	assert.False(t, complete, "the second notification fell out of the log")
+
+	for _, lastEventID := range []string{"other-2", "garbage", feed.boot + "-99"} {
+		backlog, _, complete = feed.Subscribe(filter, lastEventID)
+		assert.False(t, complete, lastEventID)
+		assert.Empty(t, backlog)
+	}
+}
+
+func TestFeed_DropsSlowClients(t *testing.T) {
+	feed, bus := setupFeed(t, Config{LogSize: 10, ClientBuffer: 1})
+	_, slow, _ := feed.Subscribe(Filter{TenantID: 1, Resources: Resources}, "")
+	ctx := tenantContext(1)
+
+	eventbus.Publish(ctx, bus, medicineDomain.Created{Medicine: medicineDomain.Medicine{ID: 1, TenantID: 1}})
+	eventbus.Publish(ctx, bus, medicineDomain.Created{Medicine: medicineDomain.Medicine{ID: 2, TenantID: 1}})
+
+	first, ok := <-slow.C
+	require.True(t, ok)
+	assert.Equal(t, 1, first.ResourceID)
+	_, ok = <-slow.C
+	assert.False(t, ok, "the subscription is closed once its buffer overflows")
+
+	backlog, _, complete := feed.Subscribe(Filter{TenantID: 1, Resources: Resources}, first.ID)
+	assert.True(t, complete)
+	assert.Equal(t, []int{2}, ids(backlog))
+	assert.NotPanics(t, func() { feed.Unsubscribe(slow) })
+}
+
+func TestFeed_DisconnectAll(t *testing.T) {
+	feed, _ := setupFeed(t, Config{LogSize: 10, ClientBuffer: 10})
+	_, subscription, _ := feed.Subscribe(Filter{TenantID: 1, Resources: Resources}, "")
+
+	feed.DisconnectAll()
+
+	_, ok := <-subscription.C
+	assert.False(t, ok)
+	assert.NotPanics(t, func() { feed.Unsubscribe(subscription) })
+}
+
+func TestFeed_IgnoresChangesWithoutTenant(t *testing.T) {
+	feed, bus := setupFeed(t, Config{LogSize: 10, ClientBuffer: 10})
+	eventbus.Publish(context.Background(), bus, medicineDomain.Deleted{ID: 1})
+	assert.Empty(t, feed.log)
+}
@@ -0,0 +1,123 @@
+// Package eventbus dispatches in-process events from the use cases to the modules
+// reacting to them, so the use cases do not need to know who listens.
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// Handler reacts to events of type E
+type Handler[E any] func(ctx context.Context, event E) error
+
+type subscription struct {
+	name  string
+	async bool
+	call  func(ctx context.Context, event any) error
+}
+
+// Bus routes every published event to the handlers subscribed to its Go type. Handlers
+// are isolated from each other and from the publisher: an error or a panic is logged
+// and never reaches the use case, whose operation already succeeded.
+//
+// Events are only delivered to the handlers of the running instance and are lost if it
+// stops; reactions that must not be lost belong on the outbox events.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions map[reflect.Type][]subscription
+	pending       sync.WaitGroup
+	Logger        *logger.Logger
+}
+
+func New(loggerInstance *logger.Logger) *Bus {
+	return &Bus{
+		subscriptions: make(map[reflect.Type][]subscription),
+		Logger:        loggerInstance,
+	}
+}
+
+// Subscribe registers a handler run by Publish before it returns, in subscription
+// order. Keep synchronous handlers short: the request waits for them.
+func Subscribe[E any](bus *Bus, name string, handler Handler[E]) {
+	bus.subscribe(reflect.TypeFor[E](), subscription{name: name, call: adapt(handler)})
+}
+
+// SubscribeAsync registers a handler run in its own goroutine, with a context that
+// keeps the values of the publishing one but is not cancelled with it
+func SubscribeAsync[E any](bus *Bus, name string, handler Handler[E]) {
+	bus.subscribe(reflect.TypeFor[E](), subscription{name: name, async: true, call: adapt(handler)})
+}
+
+// Publish delivers event to the handlers subscribed to E. A nil bus discards it.
+func Publish[E any](ctx context.Context, bus *Bus, event E) {
+	if bus == nil {
+		return
+	}
+	bus.mu.RLock()
+	subscriptions := bus.subscriptions[reflect.TypeFor[E]()]
+	bus.mu.RUnlock()
+
+	for _, s := range subscriptions {
+		if !s.async {
+			bus.dispatch(ctx, s, event)
+			continue
+		}
+		bus.pending.Add(1)
+		go func() {
+			defer bus.pending.Done()
+			bus.dispatch(context.WithoutCancel(ctx), s, event)
+		}()
+	}
+}
+
+// Wait blocks until the asynchronous handlers started so far have finished or ctx is done
+func (b *Bus) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bus) subscribe(eventType reflect.Type, s subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// copy on write, so Publish can iterate without holding the lock
+	subscriptions := make([]subscription, 0, len(b.subscriptions[eventType])+1)
+	subscriptions = append(subscriptions, b.subscriptions[eventType]...)
+	b.subscriptions[eventType] = append(subscriptions, s)
+}
+
+func (b *Bus) dispatch(ctx context.Context, s subscription, event any) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			b.Logger.Error("Event handler panicked",
+				zap.String("handler", s.name),
+				zap.String("event", fmt.Sprintf("%T", event)),
+				zap.Any("panic", recovered))
+		}
+	}()
+	if err := s.call(ctx, event); err != nil {
+		b.Logger.Error("Event handler failed",
+			zap.Error(err),
+			zap.String("handler", s.name),
+			zap.String("event", fmt.Sprintf("%T", event)))
+	}
+}
+
+func adapt[E any](handler Handler[E]) func(ctx context.Context, event any) error {
+	return func(ctx context.Context, event any) error {
+		return handler(ctx, event.(E))
+	}
+}
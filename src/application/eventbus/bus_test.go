@@ -0,0 +1,100 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type created struct{ ID int }
+
+type deleted struct{ ID int }
+
+type principalKey struct{}
+
+func newBus(t *testing.T) *Bus {
+	t.Helper()
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return New(loggerInstance)
+}
+
+func TestPublish_RoutesByType(t *testing.T) {
+	bus := newBus(t)
+	var calls []string
+	Subscribe(bus, "first", func(_ context.Context, e created) error {
+		calls = append(calls, "first")
+		assert.Equal(t, 7, e.ID)
+		return nil
+	})
+	Subscribe(bus, "second", func(context.Context, created) error {
+		calls = append(calls, "second")
+		return nil
+	})
+	Subscribe(bus, "other", func(context.Context, deleted) error {
+		calls = append(calls, "other")
+		return nil
+	})
+
+	Publish(context.Background(), bus, created{ID: 7})
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestPublish_IsolatesFailures(t *testing.T) {
+	bus := newBus(t)
+	var calls []string
+	Subscribe(bus, "failing", func(context.Context, created) error {
+		calls = append(calls, "failing")
+		return errors.New("index unavailable")
+	})
+	Subscribe(bus, "panicking", func(context.Context, created) error {
+		calls = append(calls, "panicking")
+		panic("boom")
+	})
+	Subscribe(bus, "healthy", func(context.Context, created) error {
+		calls = append(calls, "healthy")
+		return nil
+	})
+
+	assert.NotPanics(t, func() { Publish(context.Background(), bus, created{ID: 1}) })
+	assert.Equal(t, []string{"failing", "panicking", "healthy"}, calls)
+}
+
+func TestPublish_Async(t *testing.T) {
+	bus := newBus(t)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var received []any
+	SubscribeAsync(bus, "slow", func(ctx context.Context, e created) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, ctx.Value(principalKey{}), ctx.Err())
+		return nil
+	})
+	SubscribeAsync(bus, "panicking", func(context.Context, created) error {
+		panic("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), principalKey{}, "admin"))
+	Publish(ctx, bus, created{ID: 1})
+	cancel()
+
+	waitCtx, stop := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer stop()
+	assert.ErrorIs(t, bus.Wait(waitCtx), context.DeadlineExceeded, "Publish does not wait for async handlers")
+
+	close(release)
+	require.NoError(t, bus.Wait(context.Background()))
+	assert.Equal(t, []any{"admin", nil}, received, "values are kept but the cancellation is not")
+}
+
+func TestPublish_NilBus(t *testing.T) {
+	assert.NotPanics(t, func() { Publish(context.Background(), nil, created{ID: 1}) })
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -14,8 +15,8 @@ import (
 )
 
 type IAuthUseCase interface {
-	Login(email, password string) (*domainUser.User, *AuthTokens, error)
-	AccessTokenByRefreshToken(refreshToken string) (*domainUser.User, *AuthTokens, error)
+	Login(ctx context.Context, email, password string) (*domainUser.User, *AuthTokens, error)
+	AccessTokenByRefreshToken(ctx context.Context, refreshToken string) (*domainUser.User, *AuthTokens, error)
 }
 
 type AuthUseCase struct {
@@ -39,9 +40,9 @@ type AuthTokens struct {
 	ExpirationRefreshDateTime time.Time
 }
 
-func (s *AuthUseCase) Login(email, password string) (*domainUser.User, *AuthTokens, error) {
+func (s *AuthUseCase) Login(ctx context.Context, email, password string) (*domainUser.User, *AuthTokens, error) {
 	s.Logger.Info("User login attempt", zap.String("email", email))
-	user, err := s.UserRepository.GetByEmail(email)
+	user, err := s.UserRepository.GetByEmail(ctx, email)
 	if err != nil {
 		s.Logger.Error("Error getting user for login", zap.Error(err), zap.String("email", email))
 		return nil, nil, err
@@ -57,12 +58,12 @@ func (s *AuthUseCase) Login(email, password string) (*domainUser.User, *AuthToke
 		return nil, nil, domainErrors.NewAppError(errors.New("email or password does not match"), domainErrors.NotAuthenticated)
 	}
 
-	accessTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, "access")
+	accessTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, user.TenantID, "access")
 	if err != nil {
 		s.Logger.Error("Error generating access token", zap.Error(err), zap.Int("userID", user.ID))
 		return nil, nil, err
 	}
-	refreshTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, "refresh")
+	refreshTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, user.TenantID, "refresh")
 	if err != nil {
 		s.Logger.Error("Error generating refresh token", zap.Error(err), zap.Int("userID", user.ID))
 		return nil, nil, err
@@ -79,7 +80,7 @@ func (s *AuthUseCase) Login(email, password string) (*domainUser.User, *AuthToke
 	return user, authTokens, nil
 }
 
-func (s *AuthUseCase) AccessTokenByRefreshToken(refreshToken string) (*domainUser.User, *AuthTokens, error) {
+func (s *AuthUseCase) AccessTokenByRefreshToken(ctx context.Context, refreshToken string) (*domainUser.User, *AuthTokens, error) {
 	s.Logger.Info("Refreshing access token")
 	claimsMap, err := s.JWTService.GetClaimsAndVerifyToken(refreshToken, "refresh")
 	if err != nil {
@@ -87,13 +88,13 @@ func (s *AuthUseCase) AccessTokenByRefreshToken(refreshToken string) (*domainUse
 		return nil, nil, err
 	}
 	userID := int(claimsMap["id"].(float64))
-	user, err := s.UserRepository.GetByID(userID)
+	user, err := s.UserRepository.GetByID(ctx, userID)
 	if err != nil {
 		s.Logger.Error("Error getting user for token refresh", zap.Error(err), zap.Int("userID", userID))
 		return nil, nil, err
 	}
 
-	accessTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, "access")
+	accessTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, user.TenantID, "access")
 	if err != nil {
 		s.Logger.Error("Error generating new access token", zap.Error(err), zap.Int("userID", user.ID))
 		return nil, nil, err
@@ -1,12 +1,14 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
@@ -21,30 +23,45 @@ type mockUserService struct {
 	callGetByIDCalled    bool
 }
 
-func (m *mockUserService) GetAll() (*[]domainUser.User, error) {
+func (m *mockUserService) GetAll(_ context.Context) (*[]domainUser.User, error) {
 	return nil, nil
 }
-func (m *mockUserService) GetByID(id int) (*domainUser.User, error) {
+func (m *mockUserService) GetByID(_ context.Context, id int) (*domainUser.User, error) {
 	m.callGetByIDCalled = true
 	return m.getByIDFn(id)
 }
-func (m *mockUserService) GetByEmail(email string) (*domainUser.User, error) {
+func (m *mockUserService) GetByEmail(_ context.Context, email string) (*domainUser.User, error) {
 	m.callGetByEmailCalled = true
 	return m.getByEmailFn(email)
 }
-func (m *mockUserService) Create(newUser *domainUser.User) (*domainUser.User, error) {
+func (m *mockUserService) Create(_ context.Context, newUser *domainUser.User) (*domainUser.User, error) {
 	return nil, nil
 }
-func (m *mockUserService) Delete(id int) error {
+func (m *mockUserService) Delete(_ context.Context, id int) error {
 	return nil
 }
-func (m *mockUserService) Update(id int, userMap map[string]interface{}) (*domainUser.User, error) {
+func (m *mockUserService) Update(_ context.Context, id int, userMap map[string]interface{}) (*domainUser.User, error) {
 	return nil, nil
 }
-func (m *mockUserService) SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+func (m *mockUserService) SearchPaginated(_ context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
 	return nil, nil
 }
-func (m *mockUserService) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (m *mockUserService) SearchByProperty(_ context.Context, property string, searchText string) (*[]string, error) {
+	return nil, nil
+}
+func (m *mockUserService) GetTrash(_ context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	return nil, nil
+}
+func (m *mockUserService) Restore(_ context.Context, id int) (*domainUser.User, error) {
+	return nil, nil
+}
+func (m *mockUserService) Purge(_ context.Context, olderThan time.Time) (int64, error) {
+	return 0, nil
+}
+func (m *mockUserService) GetHistory(_ context.Context, id int) (*[]domainHistory.Entry, error) {
+	return nil, nil
+}
+func (m *mockUserService) GetAsOf(_ context.Context, id int, at time.Time) (*domainUser.User, error) {
 	return nil, nil
 }
 
@@ -53,7 +70,7 @@ type mockJWTService struct {
 	verifyTokenFn   func(string, string) (jwt.MapClaims, error)
 }
 
-func (m *mockJWTService) GenerateJWTToken(userID int, tokenType string) (*security.AppToken, error) {
+func (m *mockJWTService) GenerateJWTToken(userID int, _ int, tokenType string) (*security.AppToken, error) {
 	return m.generateTokenFn(userID, tokenType)
 }
 
@@ -194,7 +211,7 @@ func TestAuthUseCase_Login(t *testing.T) {
 			logger := setupLogger(t)
 			uc := NewAuthUseCase(userRepoMock, jwtMock, logger)
 
-			user, authTokens, err := uc.Login(tt.inputEmail, tt.inputPassword)
+			user, authTokens, err := uc.Login(context.Background(), tt.inputEmail, tt.inputPassword)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("[%s] got err = %v, wantErr = %v", tt.name, err, tt.wantErr)
 			}
@@ -335,7 +352,7 @@ func TestAuthUseCase_AccessTokenByRefreshToken(t *testing.T) {
 			logger := setupLogger(t)
 			uc := NewAuthUseCase(userRepoMock, jwtMock, logger)
 
-			user, authTokens, err := uc.AccessTokenByRefreshToken(tt.inputRefreshToken)
+			user, authTokens, err := uc.AccessTokenByRefreshToken(context.Background(), tt.inputRefreshToken)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("[%s] got err = %v, wantErr = %v", tt.name, err, tt.wantErr)
 			}
@@ -0,0 +1,70 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	jobDomain "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/job"
+	"go.uber.org/zap"
+)
+
+// statuses are the job statuses accepted as filters
+var statuses = []string{
+	jobDomain.StatusPending,
+	jobDomain.StatusRunning,
+	jobDomain.StatusSucceeded,
+	jobDomain.StatusFailed,
+	jobDomain.StatusCancelled,
+}
+
+type IJobUseCase interface {
+	GetAll(ctx context.Context, filters domain.DataFilters) (*jobDomain.SearchResultJob, error)
+	GetByID(ctx context.Context, id int64) (*jobDomain.Job, error)
+	Retry(ctx context.Context, id int64) (*jobDomain.Job, error)
+	Cancel(ctx context.Context, id int64) (*jobDomain.Job, error)
+}
+
+// JobUseCase administers the background jobs of the current organization
+type JobUseCase struct {
+	jobRepository job.JobRepositoryInterface
+	Logger        *logger.Logger
+}
+
+func NewJobUseCase(jobRepository job.JobRepositoryInterface, loggerInstance *logger.Logger) IJobUseCase {
+	return &JobUseCase{
+		jobRepository: jobRepository,
+		Logger:        loggerInstance,
+	}
+}
+
+func (s *JobUseCase) GetAll(ctx context.Context, filters domain.DataFilters) (*jobDomain.SearchResultJob, error) {
+	s.Logger.Info("Getting jobs", zap.Int("page", filters.Page), zap.Int("pageSize", filters.PageSize))
+	for _, status := range filters.Matches["status"] {
+		if !slices.Contains(statuses, status) {
+			return nil, domainErrors.NewAppError(fmt.Errorf("unknown status %q", status), domainErrors.ValidationError)
+		}
+	}
+	return s.jobRepository.GetAll(ctx, filters)
+}
+
+func (s *JobUseCase) GetByID(ctx context.Context, id int64) (*jobDomain.Job, error) {
+	s.Logger.Info("Getting job by ID", zap.Int64("id", id))
+	return s.jobRepository.GetByID(ctx, id)
+}
+
+// Retry runs a failed or cancelled job again, with all its attempts
+func (s *JobUseCase) Retry(ctx context.Context, id int64) (*jobDomain.Job, error) {
+	s.Logger.Info("Retrying job", zap.Int64("id", id))
+	return s.jobRepository.Retry(ctx, id)
+}
+
+// Cancel keeps a pending or scheduled job from running
+func (s *JobUseCase) Cancel(ctx context.Context, id int64) (*jobDomain.Job, error) {
+	s.Logger.Info("Cancelling job", zap.Int64("id", id))
+	return s.jobRepository.Cancel(ctx, id)
+}
@@ -0,0 +1,74 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	jobDomain "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	memoryJob "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/job"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupUseCase(t *testing.T) (IJobUseCase, *memoryJob.Repository) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	repository := memoryJob.NewJobRepository(loggerInstance)
+	return NewJobUseCase(repository, loggerInstance), repository
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func tenantContext(tenantID int) context.Context {
+	return security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: tenantID})
+}
+
+func TestJobUseCase_GetAll(t *testing.T) {
+	useCase, repository := setupUseCase(t)
+	for _, tenantID := range []int{1, 1, 2} {
+		_, err := repository.Enqueue(tenantContext(tenantID), &jobDomain.Job{
+			Queue: jobDomain.DefaultQueue, Type: "report", Status: jobDomain.StatusPending, MaxAttempts: 1, RunAt: time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	result, err := useCase.GetAll(tenantContext(1), domain.DataFilters{Matches: map[string][]string{"status": {jobDomain.StatusPending}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total, "jobs of other organizations are hidden")
+
+	_, err = useCase.GetAll(tenantContext(1), domain.DataFilters{Matches: map[string][]string{"status": {"done"}}})
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestJobUseCase_RetryAndCancel(t *testing.T) {
+	useCase, repository := setupUseCase(t)
+	job, err := repository.Enqueue(tenantContext(1), &jobDomain.Job{
+		Queue: jobDomain.DefaultQueue, Type: "report", Status: jobDomain.StatusPending, MaxAttempts: 1, RunAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = useCase.Cancel(tenantContext(2), job.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+	_, err = useCase.Retry(tenantContext(1), job.ID)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	cancelled, err := useCase.Cancel(tenantContext(1), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, jobDomain.StatusCancelled, cancelled.Status)
+	assert.Equal(t, 1, *cancelled.UpdatedBy)
+
+	retried, err := useCase.Retry(tenantContext(1), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, jobDomain.StatusPending, retried.Status)
+	assert.Nil(t, retried.FinishedAt)
+}
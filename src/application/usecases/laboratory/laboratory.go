@@ -0,0 +1,109 @@
+package laboratory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	laboratoryDomain "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"go.uber.org/zap"
+)
+
+type ILaboratoryUseCase interface {
+	GetAll(ctx context.Context) (*[]laboratoryDomain.Laboratory, error)
+	GetByID(ctx context.Context, id int) (*laboratoryDomain.Laboratory, error)
+	Create(ctx context.Context, laboratory *laboratoryDomain.Laboratory) (*laboratoryDomain.Laboratory, error)
+	Update(ctx context.Context, id int, laboratoryMap map[string]any) (*laboratoryDomain.Laboratory, error)
+	Delete(ctx context.Context, id int) error
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*laboratoryDomain.SearchResultLaboratory, error)
+}
+
+// LaboratoryUseCase renames a laboratory along with its medicines through the medicine
+// repository and raises their medicineDomain.Updated events on bus
+type LaboratoryUseCase struct {
+	laboratoryRepository laboratory.LaboratoryRepositoryInterface
+	medicineRepository   medicine.MedicineRepositoryInterface
+	bus                  *eventbus.Bus
+	Logger               *logger.Logger
+}
+
+func NewLaboratoryUseCase(laboratoryRepository laboratory.LaboratoryRepositoryInterface, medicineRepository medicine.MedicineRepositoryInterface, bus *eventbus.Bus, loggerInstance *logger.Logger) ILaboratoryUseCase {
+	return &LaboratoryUseCase{
+		laboratoryRepository: laboratoryRepository,
+		medicineRepository:   medicineRepository,
+		bus:                  bus,
+		Logger:               loggerInstance,
+	}
+}
+
+func (s *LaboratoryUseCase) GetAll(ctx context.Context) (*[]laboratoryDomain.Laboratory, error) {
+	s.Logger.Info("Getting all laboratories")
+	return s.laboratoryRepository.GetAll(ctx)
+}
+
+func (s *LaboratoryUseCase) GetByID(ctx context.Context, id int) (*laboratoryDomain.Laboratory, error) {
+	s.Logger.Info("Getting laboratory by ID", zap.Int("id", id))
+	return s.laboratoryRepository.GetByID(ctx, id)
+}
+
+// Create registers a laboratory unless another spelling of its name already is
+func (s *LaboratoryUseCase) Create(ctx context.Context, newLaboratory *laboratoryDomain.Laboratory) (*laboratoryDomain.Laboratory, error) {
+	name, err := cleanName(newLaboratory.Name)
+	if err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Creating new laboratory", zap.String("name", name))
+	return s.laboratoryRepository.Create(ctx, &laboratoryDomain.Laboratory{Name: name})
+}
+
+// Update renames a laboratory; its medicines take the new name
+func (s *LaboratoryUseCase) Update(ctx context.Context, id int, laboratoryMap map[string]any) (*laboratoryDomain.Laboratory, error) {
+	s.Logger.Info("Updating laboratory", zap.Int("id", id))
+	updates := map[string]any{}
+	if value, ok := laboratoryMap["name"]; ok {
+		text, _ := value.(string)
+		name, err := cleanName(text)
+		if err != nil {
+			return nil, err
+		}
+		updates["name"] = name
+	}
+	name, renaming := updates["name"].(string)
+	if !renaming {
+		return s.laboratoryRepository.Update(ctx, id, updates)
+	}
+	updated, renamed, err := s.medicineRepository.RenameLaboratory(ctx, id, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, medicine := range *renamed {
+		eventbus.Publish(ctx, s.bus, medicineDomain.Updated{Medicine: medicine, Fields: []string{"laboratory"}})
+	}
+	return updated, nil
+}
+
+func (s *LaboratoryUseCase) Delete(ctx context.Context, id int) error {
+	s.Logger.Info("Deleting laboratory", zap.Int("id", id))
+	return s.laboratoryRepository.Delete(ctx, id)
+}
+
+func (s *LaboratoryUseCase) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*laboratoryDomain.SearchResultLaboratory, error) {
+	s.Logger.Info("Searching laboratories with pagination",
+		zap.Int("page", filters.Page),
+		zap.Int("pageSize", filters.PageSize))
+	return s.laboratoryRepository.SearchPaginated(ctx, filters)
+}
+
+func cleanName(name string) (string, error) {
+	name = laboratoryDomain.CleanName(name)
+	if err := laboratoryDomain.ValidateName(name); err != nil {
+		return "", domainErrors.NewAppError(fmt.Errorf("laboratory %w", err), domainErrors.ValidationError)
+	}
+	return name, nil
+}
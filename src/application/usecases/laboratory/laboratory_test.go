@@ -0,0 +1,95 @@
+package laboratory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	laboratoryDomain "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	memoryLaboratory "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/laboratory"
+	memoryMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/medicine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupUseCase(t *testing.T) ILaboratoryUseCase {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	medicines := memoryMedicine.NewMedicineRepository(loggerInstance)
+	return NewLaboratoryUseCase(memoryLaboratory.NewLaboratoryRepository(loggerInstance, medicines.(memoryLaboratory.Medicines)), medicines, eventbus.New(loggerInstance), loggerInstance)
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestLaboratoryUseCase_Create(t *testing.T) {
+	useCase := setupUseCase(t)
+	ctx := context.Background()
+
+	created, err := useCase.Create(ctx, &laboratoryDomain.Laboratory{Name: "  Bayer   AG "})
+	require.NoError(t, err)
+	assert.Equal(t, "Bayer AG", created.Name)
+
+	_, err = useCase.Create(ctx, &laboratoryDomain.Laboratory{Name: "BAYER"})
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+	_, err = useCase.Create(ctx, &laboratoryDomain.Laboratory{Name: " - "})
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestLaboratoryUseCase_Update(t *testing.T) {
+	useCase := setupUseCase(t)
+	ctx := context.Background()
+	created, err := useCase.Create(ctx, &laboratoryDomain.Laboratory{Name: "Pfizer"})
+	require.NoError(t, err)
+
+	updated, err := useCase.Update(ctx, created.ID, map[string]any{"name": " Pfizer  Inc. ", "tenant_id": float64(9)})
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Inc.", updated.Name)
+	assert.Equal(t, created.TenantID, updated.TenantID)
+
+	_, err = useCase.Update(ctx, created.ID, map[string]any{"name": ""})
+	assertErrorType(t, err, domainErrors.ValidationError)
+	_, err = useCase.Update(ctx, created.ID+1, map[string]any{"name": "Roche"})
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestLaboratoryUseCase_UpdateRenamesMedicines(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	medicines := memoryMedicine.NewMedicineRepository(loggerInstance)
+	bus := eventbus.New(loggerInstance)
+	var updates []medicineDomain.Updated
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e medicineDomain.Updated) error {
+		updates = append(updates, e)
+		return nil
+	})
+	useCase := NewLaboratoryUseCase(memoryLaboratory.NewLaboratoryRepository(loggerInstance, medicines.(memoryLaboratory.Medicines)), medicines, bus, loggerInstance)
+	ctx := context.Background()
+
+	pfizer, err := useCase.Create(ctx, &laboratoryDomain.Laboratory{Name: "Pfizer"})
+	require.NoError(t, err)
+	aspirin, err := medicines.Create(ctx, &medicineDomain.Medicine{Name: "Aspirin", EanCode: "04006381333931", LaboratoryID: &pfizer.ID, Laboratory: "Pfizer"})
+	require.NoError(t, err)
+
+	_, err = useCase.Update(ctx, pfizer.ID, map[string]any{"name": "Pfizer Inc."})
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, aspirin.ID, updates[0].Medicine.ID)
+	assert.Equal(t, "Pfizer Inc.", updates[0].Medicine.Laboratory)
+	assert.Equal(t, []string{"laboratory"}, updates[0].Fields)
+	entries, err := medicines.GetHistory(ctx, aspirin.ID)
+	require.NoError(t, err)
+	require.Len(t, *entries, 2)
+	assert.Equal(t, "Pfizer Inc.", (*entries)[1].Changes["laboratory"].After)
+
+	_, err = useCase.Update(ctx, pfizer.ID, map[string]any{})
+	require.NoError(t, err)
+	assert.Len(t, updates, 1, "updates without a new name leave medicines alone")
+}
@@ -0,0 +1,112 @@
+package medicine
+
+import (
+	"errors"
+	"fmt"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+)
+
+// normalizeComposition checks the active ingredients, dosage form, route and package
+// size of a new medicine, giving their units and names their canonical spelling
+func normalizeComposition(medicine *medicineDomain.Medicine) error {
+	ingredients, err := medicineDomain.NormalizeIngredients(medicine.Ingredients)
+	if err != nil {
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	form, err := medicineDomain.NormalizeDosageForm(medicine.DosageForm)
+	if err != nil {
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	route, err := medicineDomain.NormalizeRoute(medicine.Route)
+	if err != nil {
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	size, err := medicineDomain.NormalizePackageSize(medicine.PackageSize)
+	if err != nil {
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	medicine.Ingredients, medicine.DosageForm, medicine.Route, medicine.PackageSize = ingredients, form, route, size
+	return nil
+}
+
+// normalizeCompositionUpdate replaces the ingredients, dosage_form, route and
+// package_size of an update, as decoded from JSON, with their checked domain values.
+// A null dosage form, route or package size clears it.
+func normalizeCompositionUpdate(medicineMap map[string]any) error {
+	if value, ok := medicineMap["ingredients"]; ok {
+		ingredients, err := decodeIngredients(value)
+		if err == nil {
+			ingredients, err = medicineDomain.NormalizeIngredients(ingredients)
+		}
+		if err != nil {
+			return domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
+		medicineMap["ingredients"] = ingredients
+	}
+	listed := map[string]func(string) (string, error){
+		"dosage_form": medicineDomain.NormalizeDosageForm,
+		"route":       medicineDomain.NormalizeRoute,
+	}
+	for key, normalize := range listed {
+		value, ok := medicineMap[key]
+		if !ok {
+			continue
+		}
+		text, isText := value.(string)
+		if value != nil && !isText {
+			return domainErrors.NewAppError(fmt.Errorf("%s must be a string", key), domainErrors.ValidationError)
+		}
+		normalized, err := normalize(text)
+		if err != nil {
+			return domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
+		medicineMap[key] = normalized
+	}
+	if value, ok := medicineMap["package_size"]; ok {
+		size, err := decodePackageSize(value)
+		if err == nil {
+			size, err = medicineDomain.NormalizePackageSize(size)
+		}
+		if err != nil {
+			return domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
+		medicineMap["package_size"] = size
+	}
+	return nil
+}
+
+// decodeIngredients reads a JSON list of {name, strength, unit} objects
+func decodeIngredients(value any) ([]medicineDomain.Ingredient, error) {
+	items, ok := value.([]any)
+	if !ok {
+		return nil, errors.New("ingredients must be a list")
+	}
+	ingredients := make([]medicineDomain.Ingredient, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, errors.New("ingredients must be objects with a name, strength and unit")
+		}
+		name, _ := fields["name"].(string)
+		strength, _ := fields["strength"].(float64)
+		unit, _ := fields["unit"].(string)
+		ingredients[i] = medicineDomain.Ingredient{Name: name, Strength: strength, Unit: unit}
+	}
+	return ingredients, nil
+}
+
+// decodePackageSize reads a JSON {quantity, unit} object; null stands for an unknown size
+func decodePackageSize(value any) (*medicineDomain.PackageSize, error) {
+	if value == nil {
+		return nil, nil
+	}
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("packageSize must be an object with a quantity and unit")
+	}
+	quantity, _ := fields["quantity"].(float64)
+	unit, _ := fields["unit"].(string)
+	return &medicineDomain.PackageSize{Quantity: quantity, Unit: unit}, nil
+}
@@ -0,0 +1,85 @@
+package medicine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"go.uber.org/zap"
+)
+
+// ImportInteractions adds interactions to the knowledge base; a pair listed more than
+// once keeps its last severity and description
+func (s *MedicineUseCase) ImportInteractions(ctx context.Context, interactions []medicineDomain.Interaction) (int, error) {
+	s.Logger.Info("Importing medicine interactions", zap.Int("count", len(interactions)))
+	unique := make([]medicineDomain.Interaction, 0, len(interactions))
+	positions := make(map[[2]medicineDomain.InteractionSubject]int, len(interactions))
+	for _, interaction := range interactions {
+		pair := [2]medicineDomain.InteractionSubject{
+			{Name: interaction.First.Key(), Class: interaction.First.Class},
+			{Name: interaction.Second.Key(), Class: interaction.Second.Class},
+		}
+		if position, ok := positions[pair]; ok {
+			unique[position] = interaction
+			continue
+		}
+		positions[pair] = len(unique)
+		unique = append(unique, interaction)
+	}
+	return s.medicineRepository.SaveInteractions(ctx, unique)
+}
+
+// ImportIngredientClasses adds ingredients to the classes interactions may name
+func (s *MedicineUseCase) ImportIngredientClasses(ctx context.Context, classes []medicineDomain.IngredientClass) (int, error) {
+	s.Logger.Info("Importing ingredient classes", zap.Int("count", len(classes)))
+	unique := make([]medicineDomain.IngredientClass, 0, len(classes))
+	seen := make(map[[2]string]bool, len(classes))
+	for _, class := range classes {
+		member := [2]string{medicineDomain.IngredientKey(class.Class), medicineDomain.IngredientKey(class.Ingredient)}
+		if !seen[member] {
+			seen[member] = true
+			unique = append(unique, class)
+		}
+	}
+	return s.medicineRepository.SaveIngredientClasses(ctx, unique)
+}
+
+// CheckInteractions returns the interactions the knowledge base has between every two
+// of the live medicines with ids, the most serious first
+func (s *MedicineUseCase) CheckInteractions(ctx context.Context, ids []int) (*[]medicineDomain.InteractionWarning, error) {
+	s.Logger.Info("Checking medicine interactions", zap.Ints("ids", ids))
+	medicines := make([]medicineDomain.Medicine, 0, len(ids))
+	seen := make(map[int]bool, len(ids))
+	var ingredients []string
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		medicine, err := s.medicineRepository.GetByID(ctx, id)
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return nil, domainErrors.NewAppError(fmt.Errorf("medicine %d does not exist", id), domainErrors.ValidationError)
+		}
+		if err != nil {
+			return nil, err
+		}
+		medicines = append(medicines, *medicine)
+		for _, ingredient := range medicine.Ingredients {
+			ingredients = append(ingredients, ingredient.Name)
+		}
+	}
+	classes, err := s.medicineRepository.GetIngredientClasses(ctx, ingredients)
+	if err != nil {
+		return nil, err
+	}
+	interactions, err := s.medicineRepository.GetInteractions(ctx, medicineDomain.InteractionSubjects(medicines, *classes))
+	if err != nil {
+		return nil, err
+	}
+	warnings := medicineDomain.FindInteractions(medicines, *classes, *interactions)
+	s.Logger.Info("Checked medicine interactions", zap.Ints("ids", ids), zap.Int("count", len(warnings)))
+	return &warnings, nil
+}
@@ -1,70 +1,278 @@
 package medicine
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
 	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	historyDomain "github.com/gbrayhan/microservices-go/src/domain/history"
+	laboratoryDomain "github.com/gbrayhan/microservices-go/src/domain/laboratory"
 	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
 	"go.uber.org/zap"
 )
 
 type IMedicineUseCase interface {
-	GetByID(id int) (*medicineDomain.Medicine, error)
-	Create(medicine *medicineDomain.Medicine) (*medicineDomain.Medicine, error)
-	Delete(id int) error
-	Update(id int, medicineMap map[string]any) (*medicineDomain.Medicine, error)
-	GetAll() (*[]medicineDomain.Medicine, error)
-	SearchPaginated(filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error)
-	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetByID(ctx context.Context, id int) (*medicineDomain.Medicine, error)
+	GetByBarcode(ctx context.Context, code string) (*medicineDomain.Medicine, *medicineDomain.Barcode, error)
+	Create(ctx context.Context, medicine *medicineDomain.Medicine) (*medicineDomain.Medicine, error)
+	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, medicineMap map[string]any) (*medicineDomain.Medicine, error)
+	GetAll(ctx context.Context) (*[]medicineDomain.Medicine, error)
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error)
+	SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error)
+	GetTrash(ctx context.Context, filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error)
+	Restore(ctx context.Context, id int) (*medicineDomain.Medicine, error)
+	Purge(ctx context.Context, olderThan time.Time) (int64, error)
+	GetHistory(ctx context.Context, id int) (*[]historyDomain.Entry, error)
+	GetAsOf(ctx context.Context, id int, at time.Time) (*medicineDomain.Medicine, error)
+	GetSubstitutes(ctx context.Context, id int) (*[]medicineDomain.Substitute, error)
+	GetEquivalences(ctx context.Context, id int) (*[]medicineDomain.Equivalence, error)
+	SetEquivalence(ctx context.Context, equivalence *medicineDomain.Equivalence) (*medicineDomain.Equivalence, error)
+	DeleteEquivalence(ctx context.Context, id int) error
+	ImportInteractions(ctx context.Context, interactions []medicineDomain.Interaction) (int, error)
+	ImportIngredientClasses(ctx context.Context, classes []medicineDomain.IngredientClass) (int, error)
+	CheckInteractions(ctx context.Context, ids []int) (*[]medicineDomain.InteractionWarning, error)
 }
 
+// MedicineUseCase raises the events of medicineDomain on bus after every successful change
 type MedicineUseCase struct {
-	medicineRepository medicine.MedicineRepositoryInterface
-	Logger             *logger.Logger
+	medicineRepository   medicine.MedicineRepositoryInterface
+	laboratoryRepository laboratory.LaboratoryRepositoryInterface
+	bus                  *eventbus.Bus
+	Logger               *logger.Logger
 }
 
-func NewMedicineUseCase(medicineRepository medicine.MedicineRepositoryInterface, loggerInstance *logger.Logger) IMedicineUseCase {
+func NewMedicineUseCase(medicineRepository medicine.MedicineRepositoryInterface, laboratoryRepository laboratory.LaboratoryRepositoryInterface, bus *eventbus.Bus, loggerInstance *logger.Logger) IMedicineUseCase {
 	return &MedicineUseCase{
-		medicineRepository: medicineRepository,
-		Logger:             loggerInstance,
+		medicineRepository:   medicineRepository,
+		laboratoryRepository: laboratoryRepository,
+		bus:                  bus,
+		Logger:               loggerInstance,
 	}
 }
 
-func (s *MedicineUseCase) GetByID(id int) (*medicineDomain.Medicine, error) {
+func (s *MedicineUseCase) GetByID(ctx context.Context, id int) (*medicineDomain.Medicine, error) {
 	s.Logger.Info("Getting medicine by ID", zap.Int("id", id))
-	return s.medicineRepository.GetByID(id)
+	return s.medicineRepository.GetByID(ctx, id)
 }
 
-func (s *MedicineUseCase) Create(medicine *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+// GetByBarcode returns the medicine identified by a scanned code, which may be any
+// form of GTIN or a GS1 element string also giving the lot and expiry of the package.
+// Medicines stored before EAN codes were normalized are found by their shorter codes.
+func (s *MedicineUseCase) GetByBarcode(ctx context.Context, code string) (*medicineDomain.Medicine, *medicineDomain.Barcode, error) {
+	s.Logger.Info("Getting medicine by barcode", zap.String("code", code))
+	barcode, err := medicineDomain.ParseBarcode(code)
+	if err != nil {
+		return nil, nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	medicine, err := s.medicineRepository.GetByEanCode(ctx, medicineDomain.GTINForms(barcode.GTIN))
+	if err != nil {
+		return nil, nil, err
+	}
+	return medicine, barcode, nil
+}
+
+// Create stores the EAN code of medicine as a GTIN-14, checks its composition and price
+// and links it to its laboratory
+func (s *MedicineUseCase) Create(ctx context.Context, medicine *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
 	s.Logger.Info("Creating new medicine", zap.String("name", medicine.Name))
-	return s.medicineRepository.Create(medicine)
+	eanCode, err := normalizeEanCode(medicine.EanCode)
+	if err != nil {
+		return nil, err
+	}
+	medicine.EanCode = eanCode
+	if err := normalizeComposition(medicine); err != nil {
+		return nil, err
+	}
+	if err := medicineDomain.ValidatePrice(medicine.Price); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	lab, err := s.linkLaboratory(ctx, medicine.LaboratoryID, medicine.Laboratory)
+	if err != nil {
+		return nil, err
+	}
+	medicine.LaboratoryID, medicine.Laboratory = &lab.ID, lab.Name
+	created, err := s.medicineRepository.Create(ctx, medicine)
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, medicineDomain.Created{Medicine: *created})
+	return created, nil
 }
 
-func (s *MedicineUseCase) Delete(id int) error {
+func (s *MedicineUseCase) Delete(ctx context.Context, id int) error {
 	s.Logger.Info("Deleting medicine", zap.Int("id", id))
-	return s.medicineRepository.Delete(id)
+	if err := s.medicineRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	eventbus.Publish(ctx, s.bus, medicineDomain.Deleted{ID: id})
+	return nil
 }
 
-func (s *MedicineUseCase) Update(id int, medicineMap map[string]any) (*medicineDomain.Medicine, error) {
+func (s *MedicineUseCase) Update(ctx context.Context, id int, medicineMap map[string]any) (*medicineDomain.Medicine, error) {
 	s.Logger.Info("Updating medicine", zap.Int("id", id))
-	return s.medicineRepository.Update(id, medicineMap)
+	if value, ok := medicineMap["ean_code"]; ok {
+		code, _ := value.(string)
+		eanCode, err := normalizeEanCode(code)
+		if err != nil {
+			return nil, err
+		}
+		medicineMap["ean_code"] = eanCode
+	}
+	if err := normalizeCompositionUpdate(medicineMap); err != nil {
+		return nil, err
+	}
+	if err := checkPriceUpdate(medicineMap); err != nil {
+		return nil, err
+	}
+	if err := s.relinkLaboratory(ctx, medicineMap); err != nil {
+		return nil, err
+	}
+	updated, err := s.medicineRepository.Update(ctx, id, medicineMap)
+	if err != nil {
+		return nil, err
+	}
+	fields := slices.Sorted(maps.Keys(medicineMap))
+	eventbus.Publish(ctx, s.bus, medicineDomain.Updated{Medicine: *updated, Fields: fields})
+	return updated, nil
 }
 
-func (s *MedicineUseCase) GetAll() (*[]medicineDomain.Medicine, error) {
+func (s *MedicineUseCase) GetAll(ctx context.Context) (*[]medicineDomain.Medicine, error) {
 	s.Logger.Info("Getting all medicines")
-	return s.medicineRepository.GetAll()
+	return s.medicineRepository.GetAll(ctx)
 }
 
-func (s *MedicineUseCase) SearchPaginated(filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+func (s *MedicineUseCase) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
 	s.Logger.Info("Searching medicines with pagination",
 		zap.Int("page", filters.Page),
 		zap.Int("pageSize", filters.PageSize))
-	return s.medicineRepository.SearchPaginated(filters)
+	return s.medicineRepository.SearchPaginated(ctx, filters)
 }
 
-func (s *MedicineUseCase) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (s *MedicineUseCase) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
 	s.Logger.Info("Searching medicines by property",
 		zap.String("property", property),
 		zap.String("searchText", searchText))
-	return s.medicineRepository.SearchByProperty(property, searchText)
+	return s.medicineRepository.SearchByProperty(ctx, property, searchText)
+}
+
+func (s *MedicineUseCase) GetTrash(ctx context.Context, filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+	s.Logger.Info("Listing deleted medicines",
+		zap.Int("page", filters.Page),
+		zap.Int("pageSize", filters.PageSize))
+	return s.medicineRepository.GetTrash(ctx, filters)
+}
+
+func (s *MedicineUseCase) Restore(ctx context.Context, id int) (*medicineDomain.Medicine, error) {
+	s.Logger.Info("Restoring medicine", zap.Int("id", id))
+	restored, err := s.medicineRepository.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, medicineDomain.Restored{Medicine: *restored})
+	return restored, nil
+}
+
+func (s *MedicineUseCase) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	s.Logger.Info("Purging deleted medicines", zap.Time("olderThan", olderThan))
+	return s.medicineRepository.Purge(ctx, olderThan)
+}
+
+func (s *MedicineUseCase) GetHistory(ctx context.Context, id int) (*[]historyDomain.Entry, error) {
+	s.Logger.Info("Getting medicine history", zap.Int("id", id))
+	return s.medicineRepository.GetHistory(ctx, id)
+}
+
+func (s *MedicineUseCase) GetAsOf(ctx context.Context, id int, at time.Time) (*medicineDomain.Medicine, error) {
+	s.Logger.Info("Getting medicine as of time", zap.Int("id", id), zap.Time("asOf", at))
+	return s.medicineRepository.GetAsOf(ctx, id, at)
+}
+
+// linkLaboratory returns the laboratory with id or, without one, the laboratory known
+// by any spelling of name, which is registered when there is none yet
+func (s *MedicineUseCase) linkLaboratory(ctx context.Context, id *int, name string) (*laboratoryDomain.Laboratory, error) {
+	var appErr *domainErrors.AppError
+	if id != nil {
+		lab, err := s.laboratoryRepository.GetByID(ctx, *id)
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return nil, domainErrors.NewAppError(fmt.Errorf("laboratory %d does not exist", *id), domainErrors.ValidationError)
+		}
+		return lab, err
+	}
+
+	name = laboratoryDomain.CleanName(name)
+	if err := laboratoryDomain.ValidateName(name); err != nil {
+		return nil, domainErrors.NewAppError(fmt.Errorf("laboratory %w", err), domainErrors.ValidationError)
+	}
+	lab, err := s.laboratoryRepository.GetByName(ctx, name)
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.NotFound {
+		return lab, err
+	}
+	lab, err = s.laboratoryRepository.Create(ctx, &laboratoryDomain.Laboratory{Name: name})
+	if errors.As(err, &appErr) && appErr.Type == domainErrors.ResourceAlreadyExists {
+		// Registered meanwhile by a concurrent request
+		return s.laboratoryRepository.GetByName(ctx, name)
+	}
+	return lab, err
+}
+
+// relinkLaboratory replaces the laboratory_id or laboratory of an update, by ID or by
+// name, with both the ID and the name of the laboratory they refer to
+func (s *MedicineUseCase) relinkLaboratory(ctx context.Context, medicineMap map[string]any) error {
+	value, hasID := medicineMap["laboratory_id"]
+	name, hasName := medicineMap["laboratory"]
+	if !hasID && !hasName {
+		return nil
+	}
+	var id *int
+	if hasID {
+		number, ok := value.(float64)
+		if !ok || number < 1 || number != float64(int(number)) {
+			return domainErrors.NewAppError(errors.New("laboratoryId must be a positive integer"), domainErrors.ValidationError)
+		}
+		laboratoryID := int(number)
+		id = &laboratoryID
+	}
+	text, _ := name.(string)
+	lab, err := s.linkLaboratory(ctx, id, text)
+	if err != nil {
+		return err
+	}
+	medicineMap["laboratory_id"], medicineMap["laboratory"] = lab.ID, lab.Name
+	return nil
+}
+
+// checkPriceUpdate checks the price of an update, a JSON number or null to clear it
+func checkPriceUpdate(medicineMap map[string]any) error {
+	value, ok := medicineMap["price"]
+	if !ok || value == nil {
+		return nil
+	}
+	price, isNumber := value.(float64)
+	if !isNumber {
+		return domainErrors.NewAppError(errors.New("price must be a number"), domainErrors.ValidationError)
+	}
+	if err := medicineDomain.ValidatePrice(&price); err != nil {
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// normalizeEanCode returns the GTIN-14 of an EAN code, which may also be given as the
+// GS1 element string of a scanned package
+func normalizeEanCode(code string) (string, error) {
+	barcode, err := medicineDomain.ParseBarcode(code)
+	if err != nil {
+		return "", domainErrors.NewAppError(fmt.Errorf("eanCode is invalid: %w", err), domainErrors.ValidationError)
+	}
+	return barcode.GTIN, nil
 }
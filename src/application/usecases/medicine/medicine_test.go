@@ -1,51 +1,137 @@
 package medicine
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
 	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	laboratoryDomain "github.com/gbrayhan/microservices-go/src/domain/laboratory"
 	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	memoryLaboratory "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/laboratory"
+	memoryMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
 )
 
 type mockMedicineService struct {
-	getByIDFn func(id int) (*medicineDomain.Medicine, error)
-	createFn  func(m *medicineDomain.Medicine) (*medicineDomain.Medicine, error)
-	deleteFn  func(id int) error
-	updateFn  func(id int, m map[string]any) (*medicineDomain.Medicine, error)
-	getAllFn  func() (*[]medicineDomain.Medicine, error)
+	getByIDFn  func(id int) (*medicineDomain.Medicine, error)
+	getByEanFn func(eanCodes []string) (*medicineDomain.Medicine, error)
+	createFn   func(m *medicineDomain.Medicine) (*medicineDomain.Medicine, error)
+	deleteFn   func(id int) error
+	updateFn   func(id int, m map[string]any) (*medicineDomain.Medicine, error)
+	getAllFn   func() (*[]medicineDomain.Medicine, error)
+	getTrashFn func(filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error)
+	restoreFn  func(id int) (*medicineDomain.Medicine, error)
+	historyFn  func(id int) (*[]domainHistory.Entry, error)
+	asOfFn     func(id int, at time.Time) (*medicineDomain.Medicine, error)
+	purgeFn    func(olderThan time.Time) (int64, error)
 }
 
-func (m *mockMedicineService) GetByID(id int) (*medicineDomain.Medicine, error) {
+func (m *mockMedicineService) GetByID(_ context.Context, id int) (*medicineDomain.Medicine, error) {
 	return m.getByIDFn(id)
 }
 
-func (m *mockMedicineService) Create(med *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+func (m *mockMedicineService) GetByEanCode(_ context.Context, eanCodes []string) (*medicineDomain.Medicine, error) {
+	return m.getByEanFn(eanCodes)
+}
+
+func (m *mockMedicineService) Create(_ context.Context, med *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
 	return m.createFn(med)
 }
 
-func (m *mockMedicineService) Delete(id int) error {
+func (m *mockMedicineService) Delete(_ context.Context, id int) error {
 	return m.deleteFn(id)
 }
 
-func (m *mockMedicineService) Update(id int, med map[string]any) (*medicineDomain.Medicine, error) {
+func (m *mockMedicineService) Update(_ context.Context, id int, med map[string]any) (*medicineDomain.Medicine, error) {
 	return m.updateFn(id, med)
 }
 
-func (m *mockMedicineService) GetAll() (*[]medicineDomain.Medicine, error) {
+func (m *mockMedicineService) GetAll(_ context.Context) (*[]medicineDomain.Medicine, error) {
 	return m.getAllFn()
 }
 
-func (m *mockMedicineService) SearchPaginated(filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+func (m *mockMedicineService) SearchPaginated(_ context.Context, filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
 	return nil, nil
 }
 
-func (m *mockMedicineService) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (m *mockMedicineService) SearchByProperty(_ context.Context, property string, searchText string) (*[]string, error) {
 	return nil, nil
 }
 
+func (m *mockMedicineService) GetTrash(_ context.Context, filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+	return m.getTrashFn(filters)
+}
+
+func (m *mockMedicineService) Restore(_ context.Context, id int) (*medicineDomain.Medicine, error) {
+	return m.restoreFn(id)
+}
+
+func (m *mockMedicineService) Purge(_ context.Context, olderThan time.Time) (int64, error) {
+	return m.purgeFn(olderThan)
+}
+
+func (m *mockMedicineService) GetHistory(_ context.Context, id int) (*[]domainHistory.Entry, error) {
+	return m.historyFn(id)
+}
+
+func (m *mockMedicineService) GetAsOf(_ context.Context, id int, at time.Time) (*medicineDomain.Medicine, error) {
+	return m.asOfFn(id, at)
+}
+
+func (m *mockMedicineService) RenameLaboratory(_ context.Context, laboratoryID int, name string) (*laboratoryDomain.Laboratory, *[]medicineDomain.Medicine, error) {
+	return &laboratoryDomain.Laboratory{ID: laboratoryID, Name: name}, &[]medicineDomain.Medicine{}, nil
+}
+
+func (m *mockMedicineService) LinkLaboratories(_ context.Context) (*[]medicineDomain.Medicine, error) {
+	return &[]medicineDomain.Medicine{}, nil
+}
+
+func (m *mockMedicineService) GetSubstituteCandidates(_ context.Context, medicine *medicineDomain.Medicine, ids []int) (*[]medicineDomain.Medicine, error) {
+	return &[]medicineDomain.Medicine{}, nil
+}
+
+func (m *mockMedicineService) GetEquivalences(_ context.Context, medicineID int) (*[]medicineDomain.Equivalence, error) {
+	return &[]medicineDomain.Equivalence{}, nil
+}
+
+func (m *mockMedicineService) SaveEquivalence(_ context.Context, equivalence *medicineDomain.Equivalence) (*medicineDomain.Equivalence, error) {
+	return equivalence, nil
+}
+
+func (m *mockMedicineService) DeleteEquivalence(_ context.Context, id int) error {
+	return nil
+}
+
+func (m *mockMedicineService) SaveInteractions(_ context.Context, interactions []medicineDomain.Interaction) (int, error) {
+	return len(interactions), nil
+}
+
+func (m *mockMedicineService) SaveIngredientClasses(_ context.Context, classes []medicineDomain.IngredientClass) (int, error) {
+	return len(classes), nil
+}
+
+func (m *mockMedicineService) GetIngredientClasses(_ context.Context, ingredients []string) (*[]medicineDomain.IngredientClass, error) {
+	return &[]medicineDomain.IngredientClass{}, nil
+}
+
+func (m *mockMedicineService) GetInteractions(_ context.Context, subjects []medicineDomain.InteractionSubject) (*[]medicineDomain.Interaction, error) {
+	return &[]medicineDomain.Interaction{}, nil
+}
+
+// stockTotals reports fixed units on hand to the memory medicine repository
+type stockTotals map[int]int
+
+func (s stockTotals) Totals(context.Context) map[int]int {
+	return s
+}
+
 func setupLogger(t *testing.T) *logger.Logger {
 	loggerInstance, err := logger.NewLogger()
 	if err != nil {
@@ -54,10 +140,14 @@ func setupLogger(t *testing.T) *logger.Logger {
 	return loggerInstance
 }
 
+func newLaboratoryRepository(t *testing.T) laboratory.LaboratoryRepositoryInterface {
+	return memoryLaboratory.NewLaboratoryRepository(setupLogger(t), nil)
+}
+
 func TestMedicineUseCase(t *testing.T) {
 	mockRepo := &mockMedicineService{}
 	loggerInstance := setupLogger(t)
-	useCase := NewMedicineUseCase(mockRepo, loggerInstance)
+	useCase := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), nil, loggerInstance)
 
 	mockRepo.getByIDFn = func(id int) (*medicineDomain.Medicine, error) {
 		if id == 123 {
@@ -65,11 +155,11 @@ func TestMedicineUseCase(t *testing.T) {
 		}
 		return nil, errors.New("not found")
 	}
-	_, err := useCase.GetByID(999)
+	_, err := useCase.GetByID(context.Background(), 999)
 	if err == nil {
 		t.Error("expected error for not found, got nil")
 	}
-	med, err := useCase.GetByID(123)
+	med, err := useCase.GetByID(context.Background(), 123)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -84,11 +174,11 @@ func TestMedicineUseCase(t *testing.T) {
 		m.ID = 999
 		return m, nil
 	}
-	_, err = useCase.Create(&medicineDomain.Medicine{Name: ""})
+	_, err = useCase.Create(context.Background(), &medicineDomain.Medicine{Name: ""})
 	if err == nil {
 		t.Error("expected create error on empty name")
 	}
-	newMed, err := useCase.Create(&medicineDomain.Medicine{Name: "Aspirin"})
+	newMed, err := useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Aspirin", Laboratory: "Bayer", EanCode: "4006381333931"})
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -102,11 +192,11 @@ func TestMedicineUseCase(t *testing.T) {
 		}
 		return errors.New("cannot delete")
 	}
-	err = useCase.Delete(100)
+	err = useCase.Delete(context.Background(), 100)
 	if err == nil {
 		t.Error("expected error, got nil")
 	}
-	err = useCase.Delete(1010)
+	err = useCase.Delete(context.Background(), 1010)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -117,11 +207,11 @@ func TestMedicineUseCase(t *testing.T) {
 		}
 		return &medicineDomain.Medicine{ID: 1000, Name: "UpdatedName"}, nil
 	}
-	_, err = useCase.Update(999, map[string]any{"name": "whatever"})
+	_, err = useCase.Update(context.Background(), 999, map[string]any{"name": "whatever"})
 	if err == nil {
 		t.Error("expected error, got nil")
 	}
-	updated, err := useCase.Update(1000, map[string]any{"name": "NewName"})
+	updated, err := useCase.Update(context.Background(), 1000, map[string]any{"name": "NewName"})
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -134,7 +224,7 @@ func TestMedicineUseCase(t *testing.T) {
 			{ID: 1, Name: "M1"}, {ID: 2, Name: "M2"},
 		}, nil
 	}
-	meds, err := useCase.GetAll()
+	meds, err := useCase.GetAll(context.Background())
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -143,10 +233,521 @@ func TestMedicineUseCase(t *testing.T) {
 	}
 }
 
+func TestMedicineUseCase_NormalizesEanCode(t *testing.T) {
+	mockRepo := &mockMedicineService{}
+	useCase := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), nil, setupLogger(t))
+	mockRepo.createFn = func(m *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+		return m, nil
+	}
+	var updates map[string]any
+	mockRepo.updateFn = func(id int, m map[string]any) (*medicineDomain.Medicine, error) {
+		updates = m
+		return &medicineDomain.Medicine{ID: id}, nil
+	}
+
+	created, err := useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Aspirin", Laboratory: "Bayer", EanCode: "036000291452"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.EanCode != "00036000291452" {
+		t.Errorf("expected the UPC-A stored as a GTIN-14, got %s", created.EanCode)
+	}
+	created, err = useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Ibuprofen", Laboratory: "Bayer", EanCode: "(01)09506000134352(10)L1"})
+	if err != nil || created.EanCode != "09506000134352" {
+		t.Errorf("expected the GTIN of a GS1 element string, got %v, %v", created, err)
+	}
+	for _, code := range []string{"4006381333932", "ABC-EAN", ""} {
+		_, err := useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Aspirin", Laboratory: "Bayer", EanCode: code})
+		var appErr *domainErrors.AppError
+		if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+			t.Errorf("expected a validation error for %q, got %v", code, err)
+		}
+	}
+
+	if _, err := useCase.Update(context.Background(), 1, map[string]any{"ean_code": "96385074"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updates["ean_code"] != "00000096385074" {
+		t.Errorf("expected the EAN-8 stored as a GTIN-14, got %v", updates["ean_code"])
+	}
+	if _, err := useCase.Update(context.Background(), 1, map[string]any{"ean_code": 4006381333931}); err == nil {
+		t.Error("expected an error for a numeric EAN code")
+	}
+}
+
+func TestMedicineUseCase_LinksLaboratory(t *testing.T) {
+	mockRepo := &mockMedicineService{}
+	laboratories := newLaboratoryRepository(t)
+	useCase := NewMedicineUseCase(mockRepo, laboratories, nil, setupLogger(t))
+	mockRepo.createFn = func(m *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+		return m, nil
+	}
+	var updates map[string]any
+	mockRepo.updateFn = func(id int, m map[string]any) (*medicineDomain.Medicine, error) {
+		updates = m
+		return &medicineDomain.Medicine{ID: id}, nil
+	}
+
+	first, err := useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Aspirin", Laboratory: "  Pfizer ", EanCode: "4006381333931"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Ibuprofen", Laboratory: "PFIZER INC.", EanCode: "4006381333931"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.LaboratoryID == nil || second.LaboratoryID == nil || *first.LaboratoryID != *second.LaboratoryID {
+		t.Fatalf("expected both medicines linked to one laboratory, got %v and %v", first.LaboratoryID, second.LaboratoryID)
+	}
+	if second.Laboratory != "Pfizer" {
+		t.Errorf("expected the laboratory name Pfizer, got %s", second.Laboratory)
+	}
+	all, _ := laboratories.GetAll(context.Background())
+	if len(*all) != 1 {
+		t.Errorf("expected one laboratory, got %d", len(*all))
+	}
+
+	bayer, _ := laboratories.Create(context.Background(), &laboratoryDomain.Laboratory{Name: "Bayer"})
+	if _, err := useCase.Update(context.Background(), 1, map[string]any{"laboratory_id": float64(bayer.ID)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updates["laboratory_id"] != bayer.ID || updates["laboratory"] != "Bayer" {
+		t.Errorf("expected the update linked to Bayer, got %v", updates)
+	}
+
+	for _, medicineMap := range []map[string]any{
+		{"laboratory_id": float64(99)},
+		{"laboratory_id": 1.5},
+		{"laboratory_id": "1"},
+		{"laboratory": " . "},
+	} {
+		_, err := useCase.Update(context.Background(), 1, medicineMap)
+		var appErr *domainErrors.AppError
+		if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+			t.Errorf("expected a validation error for %v, got %v", medicineMap, err)
+		}
+	}
+	if _, err := useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Aspirin", EanCode: "4006381333931"}); err == nil {
+		t.Error("expected an error for a medicine without laboratory")
+	}
+}
+
+func TestMedicineUseCase_NormalizesComposition(t *testing.T) {
+	mockRepo := &mockMedicineService{}
+	useCase := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), nil, setupLogger(t))
+	mockRepo.createFn = func(m *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+		return m, nil
+	}
+	var updates map[string]any
+	mockRepo.updateFn = func(id int, m map[string]any) (*medicineDomain.Medicine, error) {
+		updates = m
+		return &medicineDomain.Medicine{ID: id}, nil
+	}
+
+	created, err := useCase.Create(context.Background(), &medicineDomain.Medicine{
+		Name: "Amoxil", Laboratory: "GSK", EanCode: "4006381333931", DosageForm: "Suspension", Route: "ORAL",
+		Ingredients: []medicineDomain.Ingredient{{Name: " amoxicillin ", Strength: 50, Unit: "MG/ml"}},
+		PackageSize: &medicineDomain.PackageSize{Quantity: 100, Unit: "ml"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.DosageForm != "suspension" || created.Route != "oral" || created.PackageSize.Unit != "mL" {
+		t.Errorf("expected the listed spellings, got %q, %q, %+v", created.DosageForm, created.Route, created.PackageSize)
+	}
+	_, err = useCase.Create(context.Background(), &medicineDomain.Medicine{
+		Name: "Amoxil", Laboratory: "GSK", EanCode: "4006381333931",
+		Ingredients: []medicineDomain.Ingredient{{Name: "Amoxicillin", Strength: 250, Unit: "mg/5mL"}},
+	})
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error for a strength per 5 mL, got %v", err)
+	}
+
+	_, err = useCase.Update(context.Background(), 1, map[string]any{
+		"ingredients":  []any{map[string]any{"name": "Ibuprofen", "strength": float64(400), "unit": "mg"}},
+		"dosage_form":  "TABLET",
+		"route":        nil,
+		"package_size": map[string]any{"quantity": float64(20), "unit": "units"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]any{
+		"ingredients":  []medicineDomain.Ingredient{{Name: "Ibuprofen", Strength: 400, Unit: "mg"}},
+		"dosage_form":  "tablet",
+		"route":        "",
+		"package_size": &medicineDomain.PackageSize{Quantity: 20, Unit: "unit"},
+	}
+	if !reflect.DeepEqual(updates, expected) {
+		t.Errorf("expected %v, got %v", expected, updates)
+	}
+	if _, err := useCase.Update(context.Background(), 1, map[string]any{"package_size": nil}); err != nil || updates["package_size"] != (*medicineDomain.PackageSize)(nil) {
+		t.Errorf("expected a null package size to clear it, got %v, %v", updates["package_size"], err)
+	}
+
+	for _, medicineMap := range []map[string]any{
+		{"ingredients": "ibuprofen"},
+		{"ingredients": []any{"ibuprofen"}},
+		{"ingredients": []any{map[string]any{"name": "Ibuprofen", "strength": "400", "unit": "mg"}}},
+		{"dosage_form": "elixir"},
+		{"route": float64(1)},
+		{"package_size": map[string]any{"quantity": float64(-1), "unit": "unit"}},
+		{"package_size": "20 tablets"},
+	} {
+		_, err := useCase.Update(context.Background(), 1, medicineMap)
+		if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+			t.Errorf("expected a validation error for %v, got %v", medicineMap, err)
+		}
+	}
+}
+
+func TestMedicineUseCase_Substitutes(t *testing.T) {
+	stock := stockTotals{}
+	repository := memoryMedicine.NewMedicineRepositoryWithStock(setupLogger(t), stock)
+	useCase := NewMedicineUseCase(repository, newLaboratoryRepository(t), nil, setupLogger(t))
+	ctx := context.Background()
+	create := func(name, eanCode, form string, price float64, ingredients ...medicineDomain.Ingredient) *medicineDomain.Medicine {
+		created, err := useCase.Create(ctx, &medicineDomain.Medicine{
+			Name: name, Laboratory: "Generics", EanCode: eanCode, DosageForm: form, Ingredients: ingredients,
+			PackageSize: &medicineDomain.PackageSize{Quantity: 20, Unit: "unit"}, Price: &price,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating %s: %v", name, err)
+		}
+		return created
+	}
+	ibuprofen := medicineDomain.Ingredient{Name: "Ibuprofen", Strength: 400, Unit: "mg"}
+	advil := create("Advil", "4006381333931", "tablet", 9, ibuprofen)
+	create("Ibuprofen Generic", "5901234123457", "tablet", 3, medicineDomain.Ingredient{Name: "ibuprofen", Strength: 0.4, Unit: "g"})
+	stocked := create("Motrin", "96385074", "tablet", 6, ibuprofen)
+	excluded := create("Brufen", "036000291452", "tablet", 1, ibuprofen)
+	create("Ibuprofen 600", "012345678905", "tablet", 2, medicineDomain.Ingredient{Name: "Ibuprofen", Strength: 600, Unit: "mg"})
+	create("Ibuprofen Gel", "4012345678901", "gel", 2, ibuprofen)
+	naproxen := create("Naproxen", "8712345678906", "tablet", 4, medicineDomain.Ingredient{Name: "Naproxen", Strength: 250, Unit: "mg"})
+	stock[stocked.ID] = 12
+	stock[naproxen.ID] = 3
+
+	if _, err := useCase.SetEquivalence(ctx, &medicineDomain.Equivalence{MedicineID: advil.ID, SubstituteID: excluded.ID, Kind: medicineDomain.EquivalenceExcluded}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	marked, err := useCase.SetEquivalence(ctx, &medicineDomain.Equivalence{MedicineID: advil.ID, SubstituteID: naproxen.ID, Kind: medicineDomain.EquivalenceMarked, Note: "same class"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	substitutes, err := useCase.GetSubstitutes(ctx, advil.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, substitute := range *substitutes {
+		names = append(names, substitute.Medicine.Name+" "+string(substitute.Match))
+	}
+	expected := []string{"Naproxen manual", "Motrin generic", "Ibuprofen Generic generic"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	if (*substitutes)[0].Note != "same class" {
+		t.Errorf("expected the note of the equivalence, got %q", (*substitutes)[0].Note)
+	}
+
+	invalid := []medicineDomain.Equivalence{
+		{MedicineID: advil.ID, SubstituteID: naproxen.ID, Kind: "similar"},
+		{MedicineID: advil.ID, SubstituteID: advil.ID, Kind: medicineDomain.EquivalenceMarked},
+		{MedicineID: advil.ID, SubstituteID: 999, Kind: medicineDomain.EquivalenceMarked},
+	}
+	for _, equivalence := range invalid {
+		_, err := useCase.SetEquivalence(ctx, &equivalence)
+		var appErr *domainErrors.AppError
+		if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+			t.Errorf("expected a validation error for %+v, got %v", equivalence, err)
+		}
+	}
+
+	if err := useCase.DeleteEquivalence(ctx, marked.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	equivalences, err := useCase.GetEquivalences(ctx, advil.ID)
+	if err != nil || len(*equivalences) != 1 {
+		t.Fatalf("expected the exclusion to be left, got %v, %v", equivalences, err)
+	}
+	substitutes, _ = useCase.GetSubstitutes(ctx, advil.ID)
+	if len(*substitutes) != 2 {
+		t.Errorf("expected the generic substitutes only, got %d", len(*substitutes))
+	}
+}
+
+func TestMedicineUseCase_Interactions(t *testing.T) {
+	repository := memoryMedicine.NewMedicineRepository(setupLogger(t))
+	useCase := NewMedicineUseCase(repository, newLaboratoryRepository(t), nil, setupLogger(t))
+	ctx := context.Background()
+	create := func(name, eanCode string, ingredients ...string) *medicineDomain.Medicine {
+		medicine := &medicineDomain.Medicine{Name: name, Laboratory: "Generics", EanCode: eanCode}
+		for _, ingredient := range ingredients {
+			medicine.Ingredients = append(medicine.Ingredients, medicineDomain.Ingredient{Name: ingredient, Strength: 100, Unit: "mg"})
+		}
+		created, err := useCase.Create(ctx, medicine)
+		if err != nil {
+			t.Fatalf("unexpected error creating %s: %v", name, err)
+		}
+		return created
+	}
+	interaction := func(first, second, severity, description string) medicineDomain.Interaction {
+		interaction, err := medicineDomain.NewInteraction(first, second, severity, description)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return *interaction
+	}
+	coumadin := create("Coumadin", "4006381333931", "Warfarin")
+	advil := create("Advil", "5901234123457", "Ibuprofen")
+	tylenol := create("Tylenol", "96385074", "Paracetamol")
+
+	imported, err := useCase.ImportInteractions(ctx, []medicineDomain.Interaction{
+		interaction("class:NSAIDs", "Warfarin", "moderate", "first version"),
+		interaction("warfarin", "class:nsaids", "major", "Increases the risk of bleeding"),
+		interaction("Warfarin", "Paracetamol", "minor", "May raise the INR"),
+	})
+	if err != nil || imported != 2 {
+		t.Fatalf("expected the repeated pair to be imported once, got %d, %v", imported, err)
+	}
+	if _, err := useCase.ImportIngredientClasses(ctx, []medicineDomain.IngredientClass{
+		{Class: "NSAIDs", Ingredient: "Ibuprofen"},
+		{Class: "nsaids", Ingredient: "IBUPROFEN"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	warnings, err := useCase.CheckInteractions(ctx, []int{advil.ID, tylenol.ID, coumadin.ID, advil.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*warnings) != 2 {
+		t.Fatalf("expected two interactions, got %+v", *warnings)
+	}
+	major := (*warnings)[0]
+	if major.Interaction.Severity != medicineDomain.SeverityMajor || major.Interaction.Description != "Increases the risk of bleeding" ||
+		major.First.MedicineID != coumadin.ID || major.Second.MedicineID != advil.ID {
+		t.Errorf("expected the last version of the warfarin and NSAIDs interaction first, got %+v", major)
+	}
+	if (*warnings)[1].Interaction.Severity != medicineDomain.SeverityMinor {
+		t.Errorf("expected the minor interaction last, got %+v", (*warnings)[1])
+	}
+
+	_, err = useCase.CheckInteractions(ctx, []int{advil.ID, 999})
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error for an unknown medicine, got %v", err)
+	}
+}
+
+func TestMedicineUseCase_ValidatesPrice(t *testing.T) {
+	mockRepo := &mockMedicineService{}
+	useCase := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), nil, setupLogger(t))
+	mockRepo.updateFn = func(id int, m map[string]any) (*medicineDomain.Medicine, error) {
+		return &medicineDomain.Medicine{ID: id}, nil
+	}
+
+	negative := -2.5
+	_, err := useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Aspirin", Laboratory: "Bayer", EanCode: "4006381333931", Price: &negative})
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error for a negative price, got %v", err)
+	}
+	for _, price := range []any{"cheap", float64(-1)} {
+		_, err := useCase.Update(context.Background(), 1, map[string]any{"price": price})
+		if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+			t.Errorf("expected a validation error for a price of %v, got %v", price, err)
+		}
+	}
+	for _, price := range []any{nil, float64(0), 4.75} {
+		if _, err := useCase.Update(context.Background(), 1, map[string]any{"price": price}); err != nil {
+			t.Errorf("unexpected error for a price of %v: %v", price, err)
+		}
+	}
+}
+
+func TestMedicineUseCase_GetByBarcode(t *testing.T) {
+	mockRepo := &mockMedicineService{}
+	useCase := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), nil, setupLogger(t))
+	mockRepo.getByEanFn = func(eanCodes []string) (*medicineDomain.Medicine, error) {
+		if !reflect.DeepEqual(eanCodes, []string{"04006381333931", "4006381333931"}) {
+			return nil, errors.New("not found")
+		}
+		return &medicineDomain.Medicine{ID: 7, EanCode: "4006381333931"}, nil
+	}
+
+	medicine, barcode, err := useCase.GetByBarcode(context.Background(), "]d2010400638133393117270600"+"10LOT-9")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if medicine.ID != 7 || barcode.Lot != "LOT-9" {
+		t.Errorf("unexpected medicine %+v and barcode %+v", medicine, barcode)
+	}
+	if expected := time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC); !barcode.ExpiresAt.Equal(expected) {
+		t.Errorf("expected expiry %v, got %v", expected, barcode.ExpiresAt)
+	}
+	if _, _, err := useCase.GetByBarcode(context.Background(), "4006381333930"); err == nil {
+		t.Error("expected an error for a wrong check digit")
+	}
+}
+
+func TestMedicineUseCase_SoftDelete(t *testing.T) {
+	mockRepo := &mockMedicineService{}
+	useCase := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), nil, setupLogger(t))
+
+	mockRepo.getTrashFn = func(filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+		deletedAt := time.Now()
+		return &medicineDomain.SearchResultMedicine{
+			Data:  &[]medicineDomain.Medicine{{ID: 3, DeletedAt: &deletedAt}},
+			Total: 1,
+			Page:  filters.Page,
+		}, nil
+	}
+	trash, err := useCase.GetTrash(context.Background(), domain.DataFilters{Page: 1})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if trash.Total != 1 || (*trash.Data)[0].DeletedAt == nil {
+		t.Error("expected one deleted medicine in trash")
+	}
+
+	mockRepo.restoreFn = func(id int) (*medicineDomain.Medicine, error) {
+		if id != 3 {
+			return nil, errors.New("not found")
+		}
+		return &medicineDomain.Medicine{ID: 3}, nil
+	}
+	if _, err = useCase.Restore(context.Background(), 4); err == nil {
+		t.Error("expected error, got nil")
+	}
+	restored, err := useCase.Restore(context.Background(), 3)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Error("expected restored medicine without deletedAt")
+	}
+
+	cutoff := time.Now().Add(-time.Hour)
+	mockRepo.purgeFn = func(olderThan time.Time) (int64, error) {
+		if !olderThan.Equal(cutoff) {
+			t.Error("expected cutoff to be passed through")
+		}
+		return 2, nil
+	}
+	purged, err := useCase.Purge(context.Background(), cutoff)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("expected 2 purged medicines, got %d", purged)
+	}
+}
+
+func TestMedicineUseCase_History(t *testing.T) {
+	mockRepo := &mockMedicineService{}
+	useCase := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), nil, setupLogger(t))
+
+	mockRepo.historyFn = func(id int) (*[]domainHistory.Entry, error) {
+		return &[]domainHistory.Entry{{ID: 1, EntityID: id, Action: domainHistory.ActionCreate}}, nil
+	}
+	entries, err := useCase.GetHistory(context.Background(), 5)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(*entries) != 1 || (*entries)[0].EntityID != 5 {
+		t.Error("expected one history entry for medicine 5")
+	}
+
+	at := time.Now().Add(-time.Hour)
+	mockRepo.asOfFn = func(id int, asOf time.Time) (*medicineDomain.Medicine, error) {
+		if !asOf.Equal(at) {
+			t.Error("expected asOf to be passed through")
+		}
+		return &medicineDomain.Medicine{ID: id, Name: "Old Name"}, nil
+	}
+	medicine, err := useCase.GetAsOf(context.Background(), 5, at)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if medicine.Name != "Old Name" {
+		t.Errorf("expected Old Name, got %s", medicine.Name)
+	}
+}
+
+func TestMedicineUseCase_RaisesEvents(t *testing.T) {
+	mockRepo := &mockMedicineService{}
+	loggerInstance := setupLogger(t)
+	bus := eventbus.New(loggerInstance)
+	useCase := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), bus, loggerInstance)
+	var raised []any
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e medicineDomain.Created) error {
+		raised = append(raised, e)
+		return nil
+	})
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e medicineDomain.Updated) error {
+		raised = append(raised, e)
+		return nil
+	})
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e medicineDomain.Deleted) error {
+		raised = append(raised, e)
+		return errors.New("handler errors do not reach the caller")
+	})
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e medicineDomain.Restored) error {
+		raised = append(raised, e)
+		return nil
+	})
+
+	mockRepo.createFn = func(m *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+		if m.Name == "" {
+			return nil, errors.New("name is required")
+		}
+		return &medicineDomain.Medicine{ID: 1, Name: m.Name}, nil
+	}
+	mockRepo.updateFn = func(id int, m map[string]any) (*medicineDomain.Medicine, error) {
+		return &medicineDomain.Medicine{ID: id, Name: "Aspirin 500"}, nil
+	}
+	mockRepo.deleteFn = func(id int) error { return nil }
+	mockRepo.restoreFn = func(id int) (*medicineDomain.Medicine, error) {
+		return &medicineDomain.Medicine{ID: id}, nil
+	}
+
+	if _, err := useCase.Create(context.Background(), &medicineDomain.Medicine{EanCode: "4006381333931"}); err == nil {
+		t.Error("expected error, got nil")
+	}
+	if len(raised) != 0 {
+		t.Fatal("expected no event for a failed operation")
+	}
+	if _, err := useCase.Create(context.Background(), &medicineDomain.Medicine{Name: "Aspirin", Laboratory: "Bayer", EanCode: "4006381333931"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := useCase.Update(context.Background(), 1, map[string]any{"name": "Aspirin 500", "description": "Tablets"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := useCase.Delete(context.Background(), 1); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := useCase.Restore(context.Background(), 1); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := []any{
+		medicineDomain.Created{Medicine: medicineDomain.Medicine{ID: 1, Name: "Aspirin"}},
+		medicineDomain.Updated{Medicine: medicineDomain.Medicine{ID: 1, Name: "Aspirin 500"}, Fields: []string{"description", "name"}},
+		medicineDomain.Deleted{ID: 1},
+		medicineDomain.Restored{Medicine: medicineDomain.Medicine{ID: 1}},
+	}
+	if !reflect.DeepEqual(raised, expected) {
+		t.Errorf("expected events %+v, got %+v", expected, raised)
+	}
+}
+
 func TestNewMedicineUseCase(t *testing.T) {
 	mockRepo := &mockMedicineService{}
 	loggerInstance := setupLogger(t)
-	uc := NewMedicineUseCase(mockRepo, loggerInstance)
+	uc := NewMedicineUseCase(mockRepo, newLaboratoryRepository(t), nil, loggerInstance)
 	if reflect.TypeOf(uc).String() != "*medicine.MedicineUseCase" {
 		t.Error("expected *medicine.MedicineUseCase type")
 	}
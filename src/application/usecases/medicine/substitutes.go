@@ -0,0 +1,100 @@
+package medicine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"go.uber.org/zap"
+)
+
+// GetSubstitutes returns the medicines that can be dispensed instead of a live one,
+// ranked by availability and price: the products with its ingredients, strengths and
+// form, and those marked as equivalent, leaving out the excluded ones
+func (s *MedicineUseCase) GetSubstitutes(ctx context.Context, id int) (*[]medicineDomain.Substitute, error) {
+	s.Logger.Info("Getting medicine substitutes", zap.Int("id", id))
+	medicine, err := s.medicineRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	equivalences, err := s.medicineRepository.GetEquivalences(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[int]medicineDomain.Equivalence, len(*equivalences))
+	var marked []int
+	for _, equivalence := range *equivalences {
+		overrides[equivalence.SubstituteID] = equivalence
+		if equivalence.Kind == medicineDomain.EquivalenceMarked {
+			marked = append(marked, equivalence.SubstituteID)
+		}
+	}
+	candidates, err := s.medicineRepository.GetSubstituteCandidates(ctx, medicine, marked)
+	if err != nil {
+		return nil, err
+	}
+
+	substitutes := []medicineDomain.Substitute{}
+	for _, candidate := range *candidates {
+		override, overridden := overrides[candidate.ID]
+		switch {
+		case overridden && override.Kind == medicineDomain.EquivalenceMarked:
+			substitutes = append(substitutes, medicineDomain.Substitute{Medicine: candidate, Match: medicineDomain.SubstituteManual, Note: override.Note})
+		case !overridden && medicineDomain.Equivalent(medicine, &candidate):
+			substitutes = append(substitutes, medicineDomain.Substitute{Medicine: candidate, Match: medicineDomain.SubstituteGeneric})
+		}
+	}
+	medicineDomain.RankSubstitutes(substitutes)
+	return &substitutes, nil
+}
+
+// GetEquivalences returns the manual equivalences of a live medicine
+func (s *MedicineUseCase) GetEquivalences(ctx context.Context, id int) (*[]medicineDomain.Equivalence, error) {
+	s.Logger.Info("Getting medicine equivalences", zap.Int("id", id))
+	if _, err := s.medicineRepository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.medicineRepository.GetEquivalences(ctx, id)
+}
+
+// SetEquivalence marks a live medicine as a substitute of another, or excludes it from
+// its substitutes, replacing the equivalence the two had
+func (s *MedicineUseCase) SetEquivalence(ctx context.Context, equivalence *medicineDomain.Equivalence) (*medicineDomain.Equivalence, error) {
+	s.Logger.Info("Setting medicine equivalence",
+		zap.Int("medicineId", equivalence.MedicineID),
+		zap.Int("substituteId", equivalence.SubstituteID))
+	switch {
+	case !equivalence.Kind.IsValid():
+		return nil, domainErrors.NewAppError(errors.New("kind must be equivalent or excluded"), domainErrors.ValidationError)
+	case equivalence.SubstituteID == equivalence.MedicineID:
+		return nil, domainErrors.NewAppError(errors.New("a medicine is no substitute of itself"), domainErrors.ValidationError)
+	}
+	medicine, err := s.medicineRepository.GetByID(ctx, equivalence.MedicineID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.medicineRepository.GetByID(ctx, equivalence.SubstituteID)
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+		return nil, domainErrors.NewAppError(fmt.Errorf("medicine %d does not exist", equivalence.SubstituteID), domainErrors.ValidationError)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.medicineRepository.SaveEquivalence(ctx, &medicineDomain.Equivalence{
+		TenantID:     medicine.TenantID,
+		MedicineID:   equivalence.MedicineID,
+		SubstituteID: equivalence.SubstituteID,
+		Kind:         equivalence.Kind,
+		Note:         equivalence.Note,
+	})
+}
+
+// DeleteEquivalence removes a manual equivalence, so its substitute is found by its
+// composition again
+func (s *MedicineUseCase) DeleteEquivalence(ctx context.Context, id int) error {
+	s.Logger.Info("Deleting medicine equivalence", zap.Int("id", id))
+	return s.medicineRepository.DeleteEquivalence(ctx, id)
+}
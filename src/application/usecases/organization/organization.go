@@ -0,0 +1,82 @@
+package organization
+
+import (
+	"context"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	organizationDomain "github.com/gbrayhan/microservices-go/src/domain/organization"
+	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/organization"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type IOrganizationUseCase interface {
+	Create(ctx context.Context, newOrganization *organizationDomain.Organization, owner *userDomain.User) (*organizationDomain.Organization, *userDomain.User, error)
+	GetByID(ctx context.Context, id int) (*organizationDomain.Organization, error)
+	GetCurrent(ctx context.Context) (*organizationDomain.Organization, error)
+}
+
+type OrganizationUseCase struct {
+	organizationRepository organization.OrganizationRepositoryInterface
+	userRepository         user.UserRepositoryInterface
+	Logger                 *logger.Logger
+}
+
+func NewOrganizationUseCase(organizationRepository organization.OrganizationRepositoryInterface, userRepository user.UserRepositoryInterface, loggerInstance *logger.Logger) IOrganizationUseCase {
+	return &OrganizationUseCase{
+		organizationRepository: organizationRepository,
+		userRepository:         userRepository,
+		Logger:                 loggerInstance,
+	}
+}
+
+// Create registers a new organization together with its first user, who can then log
+// in and manage it. The organization is removed again if its user cannot be created.
+func (s *OrganizationUseCase) Create(ctx context.Context, newOrganization *organizationDomain.Organization, owner *userDomain.User) (*organizationDomain.Organization, *userDomain.User, error) {
+	s.Logger.Info("Creating new organization", zap.String("name", newOrganization.Name))
+	hash, err := bcrypt.GenerateFromPassword([]byte(owner.Password), bcrypt.DefaultCost)
+	if err != nil {
+		s.Logger.Error("Error hashing password", zap.Error(err))
+		return nil, nil, err
+	}
+
+	created, err := s.organizationRepository.Create(ctx, newOrganization)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The owner belongs to the new organization, not to the caller's
+	principal, _ := security.PrincipalFromContext(ctx)
+	principal.TenantID = created.ID
+	owner.TenantID = created.ID
+	owner.HashPassword = string(hash)
+	owner.Status = true
+	createdOwner, err := s.userRepository.Create(security.WithPrincipal(ctx, principal), owner)
+	if err != nil {
+		s.Logger.Error("Error creating organization owner", zap.Error(err), zap.Int("organizationID", created.ID))
+		if deleteErr := s.organizationRepository.Delete(ctx, created.ID); deleteErr != nil {
+			s.Logger.Error("Error removing organization without owner", zap.Error(deleteErr), zap.Int("organizationID", created.ID))
+		}
+		return nil, nil, err
+	}
+	return created, createdOwner, nil
+}
+
+func (s *OrganizationUseCase) GetByID(ctx context.Context, id int) (*organizationDomain.Organization, error) {
+	s.Logger.Info("Getting organization by ID", zap.Int("id", id))
+	return s.organizationRepository.GetByID(ctx, id)
+}
+
+// GetCurrent returns the organization of the authenticated principal
+func (s *OrganizationUseCase) GetCurrent(ctx context.Context) (*organizationDomain.Organization, error) {
+	tenantID, ok := security.TenantID(ctx)
+	if !ok {
+		s.Logger.Warn("No organization in request context")
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated)
+	}
+	return s.GetByID(ctx, tenantID)
+}
@@ -0,0 +1,76 @@
+package organization
+
+import (
+	"context"
+	"testing"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	organizationDomain "github.com/gbrayhan/microservices-go/src/domain/organization"
+	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	memoryOrganization "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/organization"
+	memoryUser "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/organization"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupUseCase(t *testing.T) (IOrganizationUseCase, organization.OrganizationRepositoryInterface, user.UserRepositoryInterface) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	organizationRepo := memoryOrganization.NewOrganizationRepository(loggerInstance)
+	userRepo := memoryUser.NewUserRepository(loggerInstance)
+	return NewOrganizationUseCase(organizationRepo, userRepo, loggerInstance), organizationRepo, userRepo
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestOrganizationUseCase_Create(t *testing.T) {
+	useCase, _, userRepo := setupUseCase(t)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: memoryOrganization.DefaultID})
+
+	created, owner, err := useCase.Create(ctx,
+		&organizationDomain.Organization{Name: "Acme Pharma"},
+		&userDomain.User{UserName: "owner", Email: "owner@acme.com", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, owner.TenantID)
+	assert.True(t, owner.Status)
+	assert.NotEqual(t, "secret", owner.HashPassword)
+	assert.Equal(t, 1, *owner.CreatedBy)
+
+	_, err = userRepo.GetByID(ctx, owner.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestOrganizationUseCase_CreateRemovesOrganizationWithoutOwner(t *testing.T) {
+	useCase, organizationRepo, userRepo := setupUseCase(t)
+	ctx := context.Background()
+	_, err := userRepo.Create(ctx, &userDomain.User{UserName: "taken", Email: "taken@acme.com"})
+	require.NoError(t, err)
+
+	_, _, err = useCase.Create(ctx,
+		&organizationDomain.Organization{Name: "Acme Pharma"},
+		&userDomain.User{UserName: "taken", Email: "taken@acme.com", Password: "secret"})
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+
+	_, err = organizationRepo.GetByID(ctx, memoryOrganization.DefaultID+1)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestOrganizationUseCase_GetCurrent(t *testing.T) {
+	useCase, _, _ := setupUseCase(t)
+
+	current, err := useCase.GetCurrent(security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: memoryOrganization.DefaultID}))
+	require.NoError(t, err)
+	assert.Equal(t, "Default", current.Name)
+
+	_, err = useCase.GetCurrent(context.Background())
+	assertErrorType(t, err, domainErrors.NotAuthenticated)
+}
@@ -0,0 +1,327 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"go.uber.org/zap"
+)
+
+// GetThresholds returns the alert thresholds of a live medicine at each location
+func (s *StockUseCase) GetThresholds(ctx context.Context, medicineID int) (*[]stockDomain.Threshold, error) {
+	s.Logger.Info("Getting stock thresholds", zap.Int("medicineId", medicineID))
+	if _, err := s.medicineRepository.GetByID(ctx, medicineID); err != nil {
+		return nil, err
+	}
+	return s.stockRepository.GetThresholds(ctx, medicineID)
+}
+
+// SetThreshold sets the levels of a live medicine at a registered location, the default
+// one when none is given, that raise low stock alerts, and checks its stock against
+// them right away
+func (s *StockUseCase) SetThreshold(ctx context.Context, threshold *stockDomain.Threshold) (*stockDomain.Threshold, error) {
+	threshold.Location = locationCode(threshold.Location)
+	s.Logger.Info("Setting stock threshold",
+		zap.Int("medicineId", threshold.MedicineID),
+		zap.String("location", threshold.Location))
+	switch {
+	case threshold.MinLevel < 0:
+		return nil, validationError("minLevel must not be negative")
+	case threshold.ReorderPoint < threshold.MinLevel:
+		return nil, validationError("reorderPoint must not be below minLevel")
+	case threshold.MaxLevel <= threshold.ReorderPoint:
+		return nil, validationError("maxLevel must be above reorderPoint")
+	}
+	if _, err := s.resolve(ctx, threshold.Location); err != nil {
+		return nil, err
+	}
+	medicine, err := s.medicineRepository.GetByID(ctx, threshold.MedicineID)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.stockRepository.SaveThreshold(ctx, &stockDomain.Threshold{
+		TenantID:     medicine.TenantID,
+		MedicineID:   threshold.MedicineID,
+		Location:     threshold.Location,
+		MinLevel:     threshold.MinLevel,
+		ReorderPoint: threshold.ReorderPoint,
+		MaxLevel:     threshold.MaxLevel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.stockRepository.GetByMedicine(ctx, saved.MedicineID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLevel(ctx, saved, quantityAt(levels, saved.Location), medicine.Name); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// DeleteThreshold stops alerting on the stock of a medicine at a location and
+// resolves its low stock alert
+func (s *StockUseCase) DeleteThreshold(ctx context.Context, id int) error {
+	s.Logger.Info("Deleting stock threshold", zap.Int("id", id))
+	deleted, err := s.stockRepository.DeleteThreshold(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.stockRepository.ResolveAlert(ctx, lowStockAlert(deleted).Key(), s.now())
+}
+
+func (s *StockUseCase) GetAlerts(ctx context.Context, filters domain.DataFilters) (*stockDomain.SearchResultAlert, error) {
+	s.Logger.Info("Getting stock alerts", zap.Int("page", filters.Page))
+	return s.stockRepository.GetAlerts(ctx, filters)
+}
+
+func (s *StockUseCase) GetAlert(ctx context.Context, id int) (*stockDomain.Alert, error) {
+	s.Logger.Info("Getting stock alert by ID", zap.Int("id", id))
+	return s.stockRepository.GetAlertByID(ctx, id)
+}
+
+// AcknowledgeAlert records that the user saw an open alert. It stays unresolved until
+// its condition clears, and is reopened if it becomes more severe.
+func (s *StockUseCase) AcknowledgeAlert(ctx context.Context, id int) (*stockDomain.Alert, error) {
+	s.Logger.Info("Acknowledging stock alert", zap.Int("id", id))
+	return s.stockRepository.AcknowledgeAlert(ctx, id, s.now())
+}
+
+// EvaluateLevel raises or resolves the low stock alert of a stock level that changed
+func (s *StockUseCase) EvaluateLevel(ctx context.Context, level stockDomain.Level) error {
+	threshold, err := s.stockRepository.GetThreshold(ctx, level.MedicineID, level.Location)
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.checkLevel(ctx, threshold, level.Quantity, s.medicineName(ctx, level.MedicineID))
+}
+
+// EvaluateAlerts checks the stock of every threshold and the lots that expire within
+// expiryDays days, raising the alerts due and resolving those whose condition cleared.
+// It returns the number of alerts raised. A threshold or lot that cannot be evaluated
+// is logged and skipped so the others of every organization still are; the error then
+// returned counts them.
+func (s *StockUseCase) EvaluateAlerts(ctx context.Context, expiryDays int) (int, error) {
+	s.Logger.Info("Evaluating stock alerts", zap.Int("expiryDays", expiryDays))
+	raised := 0
+	var failures []error
+	skip := func(err error, message string, fields ...zap.Field) {
+		s.Logger.Error(message, append(fields, zap.Error(err))...)
+		failures = append(failures, err)
+	}
+	thresholds, err := s.stockRepository.GetAllThresholds(ctx)
+	if err != nil {
+		return raised, err
+	}
+	levels := map[int]*[]stockDomain.Level{}
+	names := map[int]string{}
+	for i := range *thresholds {
+		threshold := &(*thresholds)[i]
+		if levels[threshold.MedicineID] == nil {
+			medicineLevels, err := s.stockRepository.GetByMedicine(ctx, threshold.MedicineID)
+			if err != nil {
+				skip(err, "Error reading the stock of a threshold", zap.Int("thresholdId", threshold.ID))
+				continue
+			}
+			levels[threshold.MedicineID] = medicineLevels
+			names[threshold.MedicineID] = s.medicineName(ctx, threshold.MedicineID)
+		}
+		alert := lowStockAlert(threshold)
+		quantity := quantityAt(levels[threshold.MedicineID], threshold.Location)
+		if alert.Severity = threshold.Severity(quantity); alert.Severity == "" {
+			if err := s.stockRepository.ResolveAlert(ctx, alert.Key(), s.now()); err != nil {
+				skip(err, "Error resolving a low stock alert", zap.Int("thresholdId", threshold.ID))
+			}
+			continue
+		}
+		fillLowStock(alert, threshold, quantity, names[threshold.MedicineID])
+		if ok, err := s.raise(ctx, alert); err != nil {
+			skip(err, "Error raising a low stock alert", zap.Int("thresholdId", threshold.ID))
+		} else if ok {
+			raised++
+		}
+	}
+
+	today := stockDomain.Day(s.now())
+	lots, err := s.stockRepository.GetExpiringLots(ctx, today.AddDate(0, 0, expiryDays))
+	if err != nil {
+		return raised, err
+	}
+	expiring := map[string]bool{}
+	for i := range *lots {
+		lot := &(*lots)[i]
+		if names[lot.MedicineID] == "" {
+			names[lot.MedicineID] = s.medicineName(ctx, lot.MedicineID)
+		}
+		alert := expiryAlert(lot, today, names[lot.MedicineID])
+		expiring[alert.Key()] = true
+		if ok, err := s.raise(ctx, alert); err != nil {
+			skip(err, "Error raising an expiry alert", zap.Int("lotId", lot.ID))
+		} else if ok {
+			raised++
+		}
+	}
+	unresolved, err := s.stockRepository.GetUnresolvedAlerts(ctx, stockDomain.AlertExpiry)
+	if err != nil {
+		return raised, err
+	}
+	for i := range *unresolved {
+		if key := (*unresolved)[i].Key(); !expiring[key] {
+			if err := s.stockRepository.ResolveAlert(ctx, key, s.now()); err != nil {
+				skip(err, "Error resolving an expiry alert", zap.Int("alertId", (*unresolved)[i].ID))
+			}
+		}
+	}
+	s.Logger.Info("Evaluated stock alerts", zap.Int("raised", raised), zap.Int("failed", len(failures)))
+	if len(failures) > 0 {
+		return raised, fmt.Errorf("%d stock alerts could not be evaluated: %w", len(failures), errors.Join(failures...))
+	}
+	return raised, nil
+}
+
+// Report summarizes the unresolved alerts and the lots expiring within expiryDays
+// days of every organization visible in ctx that has any, ordered by organization
+func (s *StockUseCase) Report(ctx context.Context, expiryDays int) (*[]stockDomain.Report, error) {
+	today := stockDomain.Day(s.now())
+	s.Logger.Info("Building stock report", zap.Time("day", today), zap.Int("expiryDays", expiryDays))
+	reports := map[int]*stockDomain.Report{}
+	report := func(tenantID int) *stockDomain.Report {
+		if reports[tenantID] == nil {
+			reports[tenantID] = &stockDomain.Report{TenantID: tenantID, Day: today, ExpiryDays: expiryDays}
+		}
+		return reports[tenantID]
+	}
+	for _, alertType := range []stockDomain.AlertType{stockDomain.AlertLowStock, stockDomain.AlertExpiry} {
+		alerts, err := s.stockRepository.GetUnresolvedAlerts(ctx, alertType)
+		if err != nil {
+			return nil, err
+		}
+		for _, alert := range *alerts {
+			summary := report(alert.TenantID)
+			if alertType == stockDomain.AlertLowStock {
+				summary.LowStockAlerts++
+			} else {
+				summary.ExpiryAlerts++
+			}
+			if alert.Severity == stockDomain.SeverityCritical {
+				summary.CriticalAlerts++
+			}
+			if alert.Status == stockDomain.AlertAcknowledged {
+				summary.AcknowledgedAlerts++
+			}
+		}
+	}
+	lots, err := s.stockRepository.GetExpiringLots(ctx, today.AddDate(0, 0, expiryDays))
+	if err != nil {
+		return nil, err
+	}
+	for _, lot := range *lots {
+		summary := report(lot.TenantID)
+		summary.ExpiringLots++
+		summary.ExpiringUnits += lot.Quantity
+	}
+
+	tenantIDs := slices.Sorted(maps.Keys(reports))
+	result := make([]stockDomain.Report, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		result = append(result, *reports[tenantID])
+	}
+	return &result, nil
+}
+
+// checkLevel raises the low stock alert of threshold for quantity units on hand, or
+// resolves it when the stock is not low
+func (s *StockUseCase) checkLevel(ctx context.Context, threshold *stockDomain.Threshold, quantity int, medicineName string) error {
+	alert := lowStockAlert(threshold)
+	if alert.Severity = threshold.Severity(quantity); alert.Severity == "" {
+		return s.stockRepository.ResolveAlert(ctx, alert.Key(), s.now())
+	}
+	fillLowStock(alert, threshold, quantity, medicineName)
+	_, err := s.raise(ctx, alert)
+	return err
+}
+
+// raise stores alert and publishes it when it is new or more severe than before
+func (s *StockUseCase) raise(ctx context.Context, alert *stockDomain.Alert) (bool, error) {
+	stored, raised, err := s.stockRepository.RaiseAlert(ctx, alert)
+	if err != nil || !raised {
+		return false, err
+	}
+	s.Logger.Info("Raised stock alert",
+		zap.Int("id", stored.ID),
+		zap.String("type", string(stored.Type)),
+		zap.String("severity", string(stored.Severity)))
+	eventbus.Publish(ctx, s.bus, stockDomain.AlertRaised{Alert: *stored})
+	return true, nil
+}
+
+// medicineName names a medicine in alert messages, falling back to its ID when it
+// cannot be read
+func (s *StockUseCase) medicineName(ctx context.Context, medicineID int) string {
+	medicine, err := s.medicineRepository.GetByID(ctx, medicineID)
+	if err != nil {
+		return fmt.Sprintf("medicine %d", medicineID)
+	}
+	return medicine.Name
+}
+
+func lowStockAlert(threshold *stockDomain.Threshold) *stockDomain.Alert {
+	return &stockDomain.Alert{
+		TenantID:   threshold.TenantID,
+		MedicineID: threshold.MedicineID,
+		Location:   threshold.Location,
+		Type:       stockDomain.AlertLowStock,
+	}
+}
+
+func fillLowStock(alert *stockDomain.Alert, threshold *stockDomain.Threshold, quantity int, medicineName string) {
+	alert.Quantity = quantity
+	alert.Threshold = threshold.ReorderPoint
+	alert.Message = fmt.Sprintf("%s at %s: %d units on hand, reorder point %d, minimum %d; reorder %d units",
+		medicineName, threshold.Location, quantity, threshold.ReorderPoint, threshold.MinLevel, threshold.ReorderQuantity(quantity))
+}
+
+// expiryAlert reports lot, which is critical once expired
+func expiryAlert(lot *stockDomain.MedicineLot, today time.Time, medicineName string) *stockDomain.Alert {
+	severity, verb := stockDomain.SeverityWarning, "expires"
+	if lot.Expired(today) {
+		severity, verb = stockDomain.SeverityCritical, "expired"
+	}
+	lotID, expiresAt := lot.ID, lot.ExpiresAt
+	return &stockDomain.Alert{
+		TenantID:   lot.TenantID,
+		MedicineID: lot.MedicineID,
+		Location:   lot.Location,
+		LotID:      &lotID,
+		Type:       stockDomain.AlertExpiry,
+		Severity:   severity,
+		Quantity:   lot.Quantity,
+		ExpiresAt:  &expiresAt,
+		Message: fmt.Sprintf("Lot %s of %s at %s %s on %s with %d units left",
+			lot.LotNumber, medicineName, lot.Location, verb, lot.ExpiresAt.Format(time.DateOnly), lot.Quantity),
+	}
+}
+
+// quantityAt returns the units on hand at location among levels; a location without
+// a level holds none
+func quantityAt(levels *[]stockDomain.Level, location string) int {
+	for _, level := range *levels {
+		if level.Location == location {
+			return level.Quantity
+		}
+	}
+	return 0
+}
@@ -0,0 +1,214 @@
+package stock
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openAlerts(t *testing.T, useCase IStockUseCase, ctx context.Context) []stockDomain.Alert {
+	t.Helper()
+	result, err := useCase.GetAlerts(ctx, domain.DataFilters{Page: 1, PageSize: 10, Matches: map[string][]string{"status": {"open", "acknowledged"}}})
+	require.NoError(t, err)
+	return *result.Data
+}
+
+func TestStockUseCase_LowStockAlerts(t *testing.T) {
+	useCase, bus, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	var raised []stockDomain.Alert
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e stockDomain.AlertRaised) error {
+		raised = append(raised, e.Alert)
+		return nil
+	})
+	_, err := useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: 30})
+	require.NoError(t, err)
+
+	threshold, err := useCase.SetThreshold(ctx, &stockDomain.Threshold{MedicineID: medicineID, MinLevel: 5, ReorderPoint: 10, MaxLevel: 50})
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.DefaultLocation, threshold.Location)
+	assert.Empty(t, openAlerts(t, useCase, ctx), "30 units are above the reorder point")
+
+	level, err := useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: -22})
+	require.NoError(t, err)
+	require.NoError(t, useCase.EvaluateLevel(ctx, *level))
+	alerts := openAlerts(t, useCase, ctx)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, stockDomain.SeverityWarning, alerts[0].Severity)
+	assert.Equal(t, 8, alerts[0].Quantity)
+	assert.Equal(t, "Aspirin at main: 8 units on hand, reorder point 10, minimum 5; reorder 42 units", alerts[0].Message)
+
+	acknowledged, err := useCase.AcknowledgeAlert(ctx, alerts[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.AlertAcknowledged, acknowledged.Status)
+
+	level, err = useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: -6})
+	require.NoError(t, err)
+	require.NoError(t, useCase.EvaluateLevel(ctx, *level))
+	alert, err := useCase.GetAlert(ctx, alerts[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.SeverityCritical, alert.Severity)
+	assert.Equal(t, stockDomain.AlertOpen, alert.Status, "a critical alert needs acknowledging again")
+
+	level, err = useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: 40})
+	require.NoError(t, err)
+	require.NoError(t, useCase.EvaluateLevel(ctx, *level))
+	alert, err = useCase.GetAlert(ctx, alerts[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.AlertResolved, alert.Status)
+	assert.Empty(t, openAlerts(t, useCase, ctx))
+
+	require.Len(t, raised, 2, "the warning and its escalation are raised")
+	assert.Equal(t, stockDomain.SeverityCritical, raised[1].Severity)
+}
+
+func TestStockUseCase_SetThresholdValidation(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+
+	for name, threshold := range map[string]stockDomain.Threshold{
+		"negative minimum":      {MedicineID: medicineID, MinLevel: -1, ReorderPoint: 5, MaxLevel: 10},
+		"reorder below minimum": {MedicineID: medicineID, MinLevel: 5, ReorderPoint: 4, MaxLevel: 10},
+		"maximum at reorder":    {MedicineID: medicineID, MinLevel: 0, ReorderPoint: 10, MaxLevel: 10},
+		"unregistered location": {MedicineID: medicineID, Location: "back", ReorderPoint: 5, MaxLevel: 10},
+		"missing medicine":      {MedicineID: 999, ReorderPoint: 5, MaxLevel: 10},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := useCase.SetThreshold(ctx, &threshold)
+			require.Error(t, err)
+		})
+	}
+	_, err := useCase.SetThreshold(ctx, &stockDomain.Threshold{MedicineID: medicineID, Location: "back", ReorderPoint: 5, MaxLevel: 10})
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestStockUseCase_DeleteThresholdResolvesAlert(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+
+	threshold, err := useCase.SetThreshold(ctx, &stockDomain.Threshold{MedicineID: medicineID, ReorderPoint: 5, MaxLevel: 20})
+	require.NoError(t, err)
+	alerts := openAlerts(t, useCase, ctx)
+	require.Len(t, alerts, 1, "a medicine without stock needs reordering")
+	assert.Equal(t, stockDomain.SeverityWarning, alerts[0].Severity)
+
+	require.NoError(t, useCase.DeleteThreshold(ctx, threshold.ID))
+	assert.Empty(t, openAlerts(t, useCase, ctx))
+	thresholds, err := useCase.GetThresholds(ctx, medicineID)
+	require.NoError(t, err)
+	assert.Empty(t, *thresholds)
+}
+
+func TestStockUseCase_EvaluateAlerts(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	soon, err := useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: "L-SOON", ExpiresAt: now.AddDate(0, 0, 10), Quantity: 4})
+	require.NoError(t, err)
+	_, err = useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: "L-LATE", ExpiresAt: now.AddDate(1, 0, 0), Quantity: 20})
+	require.NoError(t, err)
+	_, err = useCase.SetThreshold(ctx, &stockDomain.Threshold{MedicineID: medicineID, MinLevel: 5, ReorderPoint: 30, MaxLevel: 60})
+	require.NoError(t, err)
+
+	// the background job has no user, so it evaluates every organization
+	raised, err := useCase.EvaluateAlerts(context.Background(), 30)
+	require.NoError(t, err)
+	assert.Equal(t, 1, raised, "the low stock alert was raised when the threshold was set")
+	alerts := openAlerts(t, useCase, ctx)
+	require.Len(t, alerts, 2)
+	expiry := alerts[0]
+	assert.Equal(t, stockDomain.AlertExpiry, expiry.Type)
+	assert.Equal(t, soon.ID, *expiry.LotID)
+	assert.Equal(t, stockDomain.SeverityWarning, expiry.Severity)
+	assert.Equal(t, "Lot L-SOON of Aspirin at main expires on 2026-05-20 with 4 units left", expiry.Message)
+
+	raised, err = useCase.EvaluateAlerts(context.Background(), 30)
+	require.NoError(t, err)
+	assert.Zero(t, raised, "alerts already open are not raised again")
+
+	_, err = useCase.Dispense(ctx, medicineID, "", 4, "")
+	require.NoError(t, err)
+	_, err = useCase.EvaluateAlerts(context.Background(), 30)
+	require.NoError(t, err)
+	alert, err := useCase.GetAlert(ctx, expiry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.AlertResolved, alert.Status, "an empty lot no longer expires")
+}
+
+// failingAlertRepository fails to store the alerts of one medicine
+type failingAlertRepository struct {
+	stock.StockRepositoryInterface
+	medicineID int
+}
+
+func (r *failingAlertRepository) RaiseAlert(ctx context.Context, alert *stockDomain.Alert) (*stockDomain.Alert, bool, error) {
+	if alert.MedicineID == r.medicineID {
+		return nil, false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return r.StockRepositoryInterface.RaiseAlert(ctx, alert)
+}
+
+func TestStockUseCase_EvaluateAlertsSkipsFailures(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	other, err := useCase.medicineRepository.Create(ctx, &medicineDomain.Medicine{Name: strings.Repeat("Ibuprofen ", 40), EanCode: "7502"})
+	require.NoError(t, err)
+	useCase.stockRepository = &failingAlertRepository{StockRepositoryInterface: useCase.stockRepository, medicineID: medicineID}
+	for _, id := range []int{medicineID, other.ID} {
+		_, err = useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: id, LotNumber: "L-SOON", ExpiresAt: now.AddDate(0, 0, 10), Quantity: 4})
+		require.NoError(t, err)
+	}
+
+	raised, err := useCase.EvaluateAlerts(context.Background(), 30)
+	assert.ErrorContains(t, err, "1 stock alerts could not be evaluated")
+	assert.Equal(t, 1, raised, "the lot after the failing one is still evaluated")
+	alerts := openAlerts(t, useCase, ctx)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, other.ID, alerts[0].MedicineID)
+	assert.Greater(t, len(alerts[0].Message), 255)
+}
+
+func TestStockUseCase_Report(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	_, err := useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: "L-SOON", ExpiresAt: now.AddDate(0, 0, 10), Quantity: 4})
+	require.NoError(t, err)
+	_, err = useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: "L-LATE", ExpiresAt: now.AddDate(1, 0, 0), Quantity: 20})
+	require.NoError(t, err)
+	_, err = useCase.SetThreshold(ctx, &stockDomain.Threshold{MedicineID: medicineID, MinLevel: 25, ReorderPoint: 30, MaxLevel: 60})
+	require.NoError(t, err)
+	_, err = useCase.EvaluateAlerts(context.Background(), 30)
+	require.NoError(t, err)
+	alerts := openAlerts(t, useCase, ctx)
+	require.Len(t, alerts, 2)
+	_, err = useCase.AcknowledgeAlert(ctx, alerts[0].ID)
+	require.NoError(t, err)
+
+	// the background job has no user, so it reports on every organization
+	reports, err := useCase.Report(context.Background(), 30)
+	require.NoError(t, err)
+	require.Len(t, *reports, 1)
+	assert.Equal(t, stockDomain.Report{
+		TenantID:           1,
+		Day:                stockDomain.Day(now),
+		ExpiryDays:         30,
+		LowStockAlerts:     1,
+		CriticalAlerts:     1,
+		ExpiryAlerts:       1,
+		AcknowledgedAlerts: 1,
+		ExpiringLots:       1,
+		ExpiringUnits:      4,
+	}, (*reports)[0])
+
+	reports, err = useCase.Report(tenantContext(2), 30)
+	require.NoError(t, err)
+	assert.Empty(t, *reports, "organizations with nothing to report are left out")
+}
@@ -0,0 +1,196 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxLocationCodeLength is the size of the location columns
+	maxLocationCodeLength = 100
+	// maxLocationNameLength is the size of the location name column
+	maxLocationNameLength = 255
+)
+
+func (s *StockUseCase) GetLocations(ctx context.Context) (*[]stockDomain.Location, error) {
+	s.Logger.Info("Getting all locations")
+	return s.stockRepository.GetLocations(ctx)
+}
+
+func (s *StockUseCase) GetLocation(ctx context.Context, id int) (*stockDomain.Location, error) {
+	s.Logger.Info("Getting location by ID", zap.Int("id", id))
+	return s.stockRepository.GetLocationByID(ctx, id)
+}
+
+// CreateLocation registers an active location. Without UserIDs every user of the
+// organization may change its stock.
+func (s *StockUseCase) CreateLocation(ctx context.Context, location *stockDomain.Location) (*stockDomain.Location, error) {
+	location.Code = strings.TrimSpace(location.Code)
+	location.Name = strings.TrimSpace(location.Name)
+	s.Logger.Info("Creating location", zap.String("code", location.Code))
+	switch {
+	case location.Code == "":
+		return nil, validationError("code is required")
+	case len(location.Code) > maxLocationCodeLength:
+		return nil, validationError("code is too long")
+	case location.Name == "":
+		return nil, validationError("name is required")
+	case len(location.Name) > maxLocationNameLength:
+		return nil, validationError("name is too long")
+	case !location.Type.IsValid():
+		return nil, validationError("type must be warehouse, store or cabinet")
+	}
+	userIDs, err := validateUserIDs(location.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+	return s.stockRepository.CreateLocation(ctx, &stockDomain.Location{
+		Code:    location.Code,
+		Name:    location.Name,
+		Type:    location.Type,
+		Active:  true,
+		UserIDs: userIDs,
+	})
+}
+
+// UpdateLocation changes the name, type, active or userIds fields of a location. Its
+// code cannot change, as the stock held there refers to it.
+func (s *StockUseCase) UpdateLocation(ctx context.Context, id int, locationMap map[string]any) (*stockDomain.Location, error) {
+	s.Logger.Info("Updating location", zap.Int("id", id))
+	updates := make(map[string]any, len(locationMap))
+	for key, value := range locationMap {
+		switch key {
+		case "name":
+			name, ok := value.(string)
+			name = strings.TrimSpace(name)
+			if !ok || name == "" || len(name) > maxLocationNameLength {
+				return nil, validationError("name must be a string of 1 to 255 characters")
+			}
+			value = name
+		case "type":
+			text, _ := value.(string)
+			locationType := stockDomain.LocationType(text)
+			if !locationType.IsValid() {
+				return nil, validationError("type must be warehouse, store or cabinet")
+			}
+			value = locationType
+		case "active":
+			if _, ok := value.(bool); !ok {
+				return nil, validationError("active must be a boolean")
+			}
+		case "userIds":
+			ids, err := toIDs(value)
+			if err != nil {
+				return nil, err
+			}
+			if value, err = validateUserIDs(ids); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, validationError(fmt.Sprintf("%s cannot be updated", key))
+		}
+		updates[key] = value
+	}
+	return s.stockRepository.UpdateLocation(ctx, id, updates)
+}
+
+// authorize checks that the stock at the location with code can be changed by the
+// user in ctx: the location must be active and, when restricted, assigned to them
+func (s *StockUseCase) authorize(ctx context.Context, code string) error {
+	location, err := s.resolve(ctx, code)
+	if err != nil || location == nil {
+		return err
+	}
+	if !location.Active {
+		return validationError(fmt.Sprintf("location %s is inactive", code))
+	}
+	return permit(ctx, location)
+}
+
+// allowed checks that the user in ctx is not kept from the location with code, active or not
+func (s *StockUseCase) allowed(ctx context.Context, code string) error {
+	location, err := s.resolve(ctx, code)
+	if err != nil || location == nil {
+		return err
+	}
+	return permit(ctx, location)
+}
+
+// resolve returns the location with code. The default location needs no record, for
+// which it returns nil; any other code must be registered.
+func (s *StockUseCase) resolve(ctx context.Context, code string) (*stockDomain.Location, error) {
+	location, err := s.stockRepository.GetLocationByCode(ctx, code)
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+		if code == stockDomain.DefaultLocation {
+			return nil, nil
+		}
+		return nil, validationError(fmt.Sprintf("location %s does not exist", code))
+	}
+	return location, err
+}
+
+// permit checks the user restrictions of location. Operations without a user, such
+// as background jobs, are not restricted.
+func permit(ctx context.Context, location *stockDomain.Location) error {
+	actorID := security.ActorID(ctx)
+	if actorID == nil || location.Allows(*actorID) {
+		return nil
+	}
+	return domainErrors.NewAppError(fmt.Errorf("not allowed to change the stock at location %s", location.Code), domainErrors.NotAuthorized)
+}
+
+// locationCode normalizes the location code of a stock change; empty means the default location
+func locationCode(code string) string {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return stockDomain.DefaultLocation
+	}
+	return code
+}
+
+// validateUserIDs returns the positive user IDs of ids without duplicates
+func validateUserIDs(ids []int) ([]int, error) {
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, validationError("userIds must be positive user IDs")
+		}
+		if !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// toIDs converts a decoded JSON array of integers
+func toIDs(value any) ([]int, error) {
+	switch values := value.(type) {
+	case []int:
+		return values, nil
+	case []any:
+		result := make([]int, 0, len(values))
+		for _, item := range values {
+			number, ok := item.(float64)
+			if !ok || number != float64(int(number)) {
+				return nil, validationError("userIds must be a list of user IDs")
+			}
+			result = append(result, int(number))
+		}
+		return result, nil
+	default:
+		return nil, validationError("userIds must be a list of user IDs")
+	}
+}
+
+func validationError(message string) error {
+	return domainErrors.NewAppError(errors.New(message), domainErrors.ValidationError)
+}
@@ -0,0 +1,128 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxLotTextLength is the size of the lot number column
+	maxLotTextLength = 100
+	// maxSupplierLength is the size of the supplier and quarantine reason columns
+	maxSupplierLength = 255
+	// maxExpiringDays bounds the look-ahead of GetExpiringLots
+	maxExpiringDays = 3650
+)
+
+// ReceiveLot records a lot of a live medicine and adds its units to the stock of its
+// location, the default one when none is given
+func (s *StockUseCase) ReceiveLot(ctx context.Context, lot *stockDomain.MedicineLot) (*stockDomain.MedicineLot, error) {
+	lot.LotNumber = strings.TrimSpace(lot.LotNumber)
+	lot.Supplier = strings.TrimSpace(lot.Supplier)
+	lot.Location = locationCode(lot.Location)
+	s.Logger.Info("Receiving medicine lot", zap.Int("medicineId", lot.MedicineID), zap.String("lotNumber", lot.LotNumber))
+	if err := validateLot(lot); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, lot.Location); err != nil {
+		return nil, err
+	}
+	medicine, err := s.medicineRepository.GetByID(ctx, lot.MedicineID)
+	if err != nil {
+		return nil, err
+	}
+	lot.TenantID = medicine.TenantID
+	created, level, err := s.stockRepository.CreateLot(ctx, lot)
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, stockDomain.Adjusted{Level: *level, Delta: lot.Quantity})
+	return created, nil
+}
+
+// GetLots returns the lots of a medicine, first expiring first
+func (s *StockUseCase) GetLots(ctx context.Context, medicineID int) (*[]stockDomain.MedicineLot, error) {
+	s.Logger.Info("Getting medicine lots", zap.Int("medicineId", medicineID))
+	if _, err := s.medicineRepository.GetByID(ctx, medicineID); err != nil {
+		return nil, err
+	}
+	return s.stockRepository.GetLotsByMedicine(ctx, medicineID)
+}
+
+// GetExpiringLots returns the lots with units left and not in quarantine that expire
+// within the given number of days, including those already expired
+func (s *StockUseCase) GetExpiringLots(ctx context.Context, days int) (*[]stockDomain.MedicineLot, error) {
+	s.Logger.Info("Getting expiring lots", zap.Int("days", days))
+	if days < 0 || days > maxExpiringDays {
+		return nil, domainErrors.NewAppError(errors.New("days must be between 0 and 3650"), domainErrors.ValidationError)
+	}
+	return s.stockRepository.GetExpiringLots(ctx, stockDomain.Day(s.now()).AddDate(0, 0, days))
+}
+
+// QuarantineLot keeps the units of a lot from being dispensed. They stay in stock.
+func (s *StockUseCase) QuarantineLot(ctx context.Context, lotID int, reason string) (*stockDomain.MedicineLot, error) {
+	reason = strings.TrimSpace(reason)
+	s.Logger.Info("Quarantining lot", zap.Int("id", lotID))
+	if len(reason) > maxSupplierLength {
+		return nil, domainErrors.NewAppError(errors.New("reason is too long"), domainErrors.ValidationError)
+	}
+	lot, err := s.stockRepository.GetLotByID(ctx, lotID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.allowed(ctx, lot.Location); err != nil {
+		return nil, err
+	}
+	return s.stockRepository.QuarantineLot(ctx, lotID, reason, s.now())
+}
+
+// Dispense takes quantity units of a live medicine from its lots at a location, the
+// default one when none is given, first expired first out. Expired and quarantined
+// lots are never dispensed.
+func (s *StockUseCase) Dispense(ctx context.Context, medicineID int, location string, quantity int, reason string) (*stockDomain.Dispensation, error) {
+	location = locationCode(location)
+	s.Logger.Info("Dispensing medicine", zap.Int("medicineId", medicineID), zap.String("location", location), zap.Int("quantity", quantity))
+	if quantity <= 0 {
+		return nil, domainErrors.NewAppError(errors.New("quantity must be positive"), domainErrors.ValidationError)
+	}
+	reason, err := reasonCode(reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, location); err != nil {
+		return nil, err
+	}
+	if _, err := s.medicineRepository.GetByID(ctx, medicineID); err != nil {
+		return nil, err
+	}
+	dispensation, err := s.stockRepository.Dispense(ctx, medicineID, location, quantity, reason, stockDomain.Day(s.now()))
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, stockDomain.Adjusted{Level: dispensation.Level, Delta: -quantity})
+	return dispensation, nil
+}
+
+func validateLot(lot *stockDomain.MedicineLot) error {
+	switch {
+	case lot.LotNumber == "":
+		return domainErrors.NewAppError(errors.New("lotNumber is required"), domainErrors.ValidationError)
+	case len(lot.LotNumber) > maxLotTextLength:
+		return domainErrors.NewAppError(errors.New("lotNumber is too long"), domainErrors.ValidationError)
+	case len(lot.Supplier) > maxSupplierLength:
+		return domainErrors.NewAppError(errors.New("supplier is too long"), domainErrors.ValidationError)
+	case lot.Quantity <= 0:
+		return domainErrors.NewAppError(errors.New("quantity must be positive"), domainErrors.ValidationError)
+	case lot.ExpiresAt.IsZero():
+		return domainErrors.NewAppError(errors.New("expiresAt is required"), domainErrors.ValidationError)
+	case lot.ManufacturedAt != nil && lot.ManufacturedAt.After(lot.ExpiresAt):
+		return domainErrors.NewAppError(errors.New("manufacturedAt must not be after expiresAt"), domainErrors.ValidationError)
+	}
+	return nil
+}
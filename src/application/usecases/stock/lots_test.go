@@ -0,0 +1,120 @@
+package stock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2026, 5, 10, 15, 0, 0, 0, time.UTC)
+
+func setupLotUseCase(t *testing.T) (*StockUseCase, *eventbus.Bus, int) {
+	t.Helper()
+	useCase, bus, medicineID := setupUseCase(t)
+	stockUseCase := useCase.(*StockUseCase)
+	stockUseCase.now = func() time.Time { return now }
+	return stockUseCase, bus, medicineID
+}
+
+func TestStockUseCase_ReceiveAndDispense(t *testing.T) {
+	useCase, bus, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	var adjusted []stockDomain.Adjusted
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e stockDomain.Adjusted) error {
+		adjusted = append(adjusted, e)
+		return nil
+	})
+
+	late, err := useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: " L-LATE ", ExpiresAt: now.AddDate(1, 0, 0), Quantity: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "L-LATE", late.LotNumber)
+	assert.Equal(t, 1, late.TenantID)
+	_, err = useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: "L-SOON", ExpiresAt: now.AddDate(0, 0, 2), Quantity: 4})
+	require.NoError(t, err)
+
+	dispensation, err := useCase.Dispense(ctx, medicineID, "", 6, "")
+	require.NoError(t, err)
+	require.Len(t, dispensation.Allocations, 2)
+	assert.Equal(t, "L-SOON", dispensation.Allocations[0].LotNumber)
+	assert.Equal(t, 4, dispensation.Allocations[0].Quantity)
+	assert.Equal(t, 8, dispensation.Level.Quantity)
+
+	stock, err := useCase.GetByMedicine(ctx, medicineID)
+	require.NoError(t, err)
+	assert.Equal(t, 8, stock.Total)
+
+	require.NoError(t, bus.Wait(context.Background()))
+	require.Len(t, adjusted, 3)
+	assert.Equal(t, 10, adjusted[0].Delta)
+	assert.Equal(t, -6, adjusted[2].Delta)
+}
+
+func TestStockUseCase_DispenseSkipsExpiredAndQuarantinedLots(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	for _, lot := range []stockDomain.MedicineLot{
+		{MedicineID: medicineID, LotNumber: "L-EXPIRED", ExpiresAt: now.AddDate(0, 0, -1), Quantity: 5},
+		{MedicineID: medicineID, LotNumber: "L-TODAY", ExpiresAt: now, Quantity: 5},
+	} {
+		_, err := useCase.ReceiveLot(ctx, &lot)
+		require.NoError(t, err)
+	}
+
+	lots, err := useCase.GetLots(ctx, medicineID)
+	require.NoError(t, err)
+	require.Len(t, *lots, 2)
+	_, err = useCase.QuarantineLot(ctx, (*lots)[1].ID, " recall ")
+	require.NoError(t, err)
+
+	_, err = useCase.Dispense(ctx, medicineID, "", 1, "")
+	assertErrorType(t, err, domainErrors.ValidationError)
+	assert.EqualError(t, err, "insufficient stock: 0 units in dispensable lots at main")
+
+	_, err = useCase.QuarantineLot(ctx, (*lots)[1].ID, "again")
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestStockUseCase_GetExpiringLots(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	for days, lotNumber := range map[int]string{0: "L-0", 30: "L-30", 31: "L-31"} {
+		_, err := useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: lotNumber, ExpiresAt: now.AddDate(0, 0, days), Quantity: 1})
+		require.NoError(t, err)
+	}
+
+	lots, err := useCase.GetExpiringLots(ctx, 30)
+	require.NoError(t, err)
+	require.Len(t, *lots, 2)
+	assert.Equal(t, "L-0", (*lots)[0].LotNumber)
+	assert.Equal(t, "L-30", (*lots)[1].LotNumber)
+
+	_, err = useCase.GetExpiringLots(ctx, -1)
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestStockUseCase_ReceiveLotValidation(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	manufacturedAt := now.AddDate(0, 0, 1)
+	for name, lot := range map[string]stockDomain.MedicineLot{
+		"no lot number":         {MedicineID: medicineID, ExpiresAt: now, Quantity: 1},
+		"no expiry":             {MedicineID: medicineID, LotNumber: "L-1", Quantity: 1},
+		"no units":              {MedicineID: medicineID, LotNumber: "L-1", ExpiresAt: now},
+		"made after it expires": {MedicineID: medicineID, LotNumber: "L-1", ExpiresAt: now, ManufacturedAt: &manufacturedAt, Quantity: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := useCase.ReceiveLot(tenantContext(1), &lot)
+			assertErrorType(t, err, domainErrors.ValidationError)
+		})
+	}
+
+	_, err := useCase.ReceiveLot(tenantContext(2), &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: "L-1", ExpiresAt: now, Quantity: 1})
+	assertErrorType(t, err, domainErrors.NotFound)
+	_, err = useCase.Dispense(tenantContext(1), medicineID, "", 0, "")
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
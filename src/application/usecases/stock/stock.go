@@ -0,0 +1,157 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
+	"go.uber.org/zap"
+)
+
+// reasonCodePattern is the form of the reason codes of movements, e.g. "damaged" or
+// "count-correction"; the column holds 50 characters
+var reasonCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+type IStockUseCase interface {
+	GetByMedicine(ctx context.Context, medicineID int) (*stockDomain.Stock, error)
+	Adjust(ctx context.Context, adjustment stockDomain.Adjustment) (*stockDomain.Level, error)
+	ReceiveLot(ctx context.Context, lot *stockDomain.MedicineLot) (*stockDomain.MedicineLot, error)
+	GetLots(ctx context.Context, medicineID int) (*[]stockDomain.MedicineLot, error)
+	GetExpiringLots(ctx context.Context, days int) (*[]stockDomain.MedicineLot, error)
+	QuarantineLot(ctx context.Context, lotID int, reason string) (*stockDomain.MedicineLot, error)
+	Dispense(ctx context.Context, medicineID int, location string, quantity int, reason string) (*stockDomain.Dispensation, error)
+	GetMovements(ctx context.Context, medicineID int, filters domain.DataFilters) (*stockDomain.SearchResultMovement, error)
+	GetLocations(ctx context.Context) (*[]stockDomain.Location, error)
+	GetLocation(ctx context.Context, id int) (*stockDomain.Location, error)
+	CreateLocation(ctx context.Context, location *stockDomain.Location) (*stockDomain.Location, error)
+	UpdateLocation(ctx context.Context, id int, locationMap map[string]any) (*stockDomain.Location, error)
+	RequestTransfer(ctx context.Context, transfer *stockDomain.Transfer) (*stockDomain.Transfer, error)
+	GetTransfer(ctx context.Context, id int) (*stockDomain.Transfer, error)
+	GetTransfers(ctx context.Context, filters domain.DataFilters) (*stockDomain.SearchResultTransfer, error)
+	ShipTransfer(ctx context.Context, id int) (*stockDomain.Transfer, error)
+	ReceiveTransfer(ctx context.Context, id int) (*stockDomain.Transfer, error)
+	CancelTransfer(ctx context.Context, id int) (*stockDomain.Transfer, error)
+	GetThresholds(ctx context.Context, medicineID int) (*[]stockDomain.Threshold, error)
+	SetThreshold(ctx context.Context, threshold *stockDomain.Threshold) (*stockDomain.Threshold, error)
+	DeleteThreshold(ctx context.Context, id int) error
+	GetAlerts(ctx context.Context, filters domain.DataFilters) (*stockDomain.SearchResultAlert, error)
+	GetAlert(ctx context.Context, id int) (*stockDomain.Alert, error)
+	AcknowledgeAlert(ctx context.Context, id int) (*stockDomain.Alert, error)
+	EvaluateLevel(ctx context.Context, level stockDomain.Level) error
+	EvaluateAlerts(ctx context.Context, expiryDays int) (int, error)
+	Report(ctx context.Context, expiryDays int) (*[]stockDomain.Report, error)
+}
+
+// StockUseCase keeps the stock of the medicines of the current organization and raises
+// the events of stockDomain on bus after every adjustment
+type StockUseCase struct {
+	stockRepository    stock.StockRepositoryInterface
+	medicineRepository medicine.MedicineRepositoryInterface
+	bus                *eventbus.Bus
+	Logger             *logger.Logger
+	// now is the clock lot expiry is checked against
+	now func() time.Time
+}
+
+func NewStockUseCase(stockRepository stock.StockRepositoryInterface, medicineRepository medicine.MedicineRepositoryInterface, bus *eventbus.Bus, loggerInstance *logger.Logger) IStockUseCase {
+	return &StockUseCase{
+		stockRepository:    stockRepository,
+		medicineRepository: medicineRepository,
+		bus:                bus,
+		Logger:             loggerInstance,
+		now:                time.Now,
+	}
+}
+
+// GetByMedicine returns the stock of a medicine at every location holding a level and
+// the units of it in transit between locations
+func (s *StockUseCase) GetByMedicine(ctx context.Context, medicineID int) (*stockDomain.Stock, error) {
+	s.Logger.Info("Getting medicine stock", zap.Int("medicineId", medicineID))
+	if _, err := s.medicineRepository.GetByID(ctx, medicineID); err != nil {
+		return nil, err
+	}
+	levels, err := s.stockRepository.GetByMedicine(ctx, medicineID)
+	if err != nil {
+		return nil, err
+	}
+	inTransit, err := s.stockRepository.InTransit(ctx, medicineID)
+	if err != nil {
+		return nil, err
+	}
+	result := &stockDomain.Stock{MedicineID: medicineID, InTransit: inTransit, Levels: *levels}
+	for _, level := range *levels {
+		result.Total += level.Quantity
+	}
+	return result, nil
+}
+
+// Adjust records a change of the stock of a live medicine at a location, the default
+// one when none is given, as an adjustment, a return of units or a write-off. Removing
+// more units than are on hand fails.
+func (s *StockUseCase) Adjust(ctx context.Context, adjustment stockDomain.Adjustment) (*stockDomain.Level, error) {
+	adjustment.Location = locationCode(adjustment.Location)
+	if adjustment.Type == "" {
+		adjustment.Type = stockDomain.MovementAdjustment
+	}
+	s.Logger.Info("Adjusting medicine stock",
+		zap.Int("medicineId", adjustment.MedicineID),
+		zap.String("location", adjustment.Location),
+		zap.Int("delta", adjustment.Delta))
+	if adjustment.Delta == 0 {
+		return nil, domainErrors.NewAppError(errors.New("delta must not be zero"), domainErrors.ValidationError)
+	}
+	switch {
+	case adjustment.Type == stockDomain.MovementReturn && adjustment.Delta < 0:
+		return nil, domainErrors.NewAppError(errors.New("a return must add units"), domainErrors.ValidationError)
+	case adjustment.Type == stockDomain.MovementWriteOff && adjustment.Delta > 0:
+		return nil, domainErrors.NewAppError(errors.New("a write-off must remove units"), domainErrors.ValidationError)
+	case adjustment.Type != stockDomain.MovementAdjustment && adjustment.Type != stockDomain.MovementReturn && adjustment.Type != stockDomain.MovementWriteOff:
+		return nil, domainErrors.NewAppError(errors.New("type must be adjustment, return or write-off"), domainErrors.ValidationError)
+	}
+	var err error
+	if adjustment.Reason, err = reasonCode(adjustment.Reason); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, adjustment.Location); err != nil {
+		return nil, err
+	}
+	medicine, err := s.medicineRepository.GetByID(ctx, adjustment.MedicineID)
+	if err != nil {
+		return nil, err
+	}
+	adjustment.TenantID = medicine.TenantID
+	level, err := s.stockRepository.Adjust(ctx, adjustment)
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, stockDomain.Adjusted{Level: *level, Delta: adjustment.Delta})
+	return level, nil
+}
+
+// GetMovements returns a page of the stock ledger of a live medicine
+func (s *StockUseCase) GetMovements(ctx context.Context, medicineID int, filters domain.DataFilters) (*stockDomain.SearchResultMovement, error) {
+	s.Logger.Info("Getting stock movements", zap.Int("medicineId", medicineID))
+	if _, err := s.medicineRepository.GetByID(ctx, medicineID); err != nil {
+		return nil, err
+	}
+	return s.stockRepository.GetMovements(ctx, medicineID, filters)
+}
+
+// reasonCode normalizes the reason code of a movement, which may be empty
+func reasonCode(reason string) (string, error) {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	if reason != "" && !reasonCodePattern.MatchString(reason) {
+		return "", domainErrors.NewAppError(
+			errors.New("reason must be a code of up to 50 lowercase letters, digits, dashes or underscores"), domainErrors.ValidationError)
+	}
+	return reason, nil
+}
@@ -0,0 +1,165 @@
+package stock
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	memoryMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/medicine"
+	memoryStock "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupUseCase(t *testing.T) (IStockUseCase, *eventbus.Bus, int) {
+	t.Helper()
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	stockRepository := memoryStock.NewStockRepository(loggerInstance)
+	medicineRepository := memoryMedicine.NewMedicineRepositoryWithStock(loggerInstance, stockRepository)
+	medicine, err := medicineRepository.Create(tenantContext(1), &medicineDomain.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	bus := eventbus.New(loggerInstance)
+	return NewStockUseCase(stockRepository, medicineRepository, bus, loggerInstance), bus, medicine.ID
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func tenantContext(tenantID int) context.Context {
+	return security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: tenantID})
+}
+
+func registerLocation(t *testing.T, useCase IStockUseCase, ctx context.Context, code string, userIDs ...int) *stockDomain.Location {
+	t.Helper()
+	location, err := useCase.CreateLocation(ctx, &stockDomain.Location{Code: code, Name: code, Type: stockDomain.LocationStore, UserIDs: userIDs})
+	require.NoError(t, err)
+	return location
+}
+
+func TestStockUseCase_Adjust(t *testing.T) {
+	useCase, bus, medicineID := setupUseCase(t)
+	ctx := tenantContext(1)
+	var adjusted []stockDomain.Adjusted
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e stockDomain.Adjusted) error {
+		adjusted = append(adjusted, e)
+		return nil
+	})
+
+	level, err := useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: 10})
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.DefaultLocation, level.Location)
+	assert.Equal(t, 10, level.Quantity)
+	assert.Equal(t, 1, level.TenantID)
+
+	_, err = useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Location: "front", Delta: 4})
+	assertErrorType(t, err, domainErrors.ValidationError)
+	registerLocation(t, useCase, ctx, "front")
+	_, err = useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Location: "front", Delta: 4})
+	require.NoError(t, err)
+	level, err = useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: -3})
+	require.NoError(t, err)
+	assert.Equal(t, 7, level.Quantity)
+
+	_, err = useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Location: "front", Delta: -5})
+	assertErrorType(t, err, domainErrors.ValidationError)
+	assert.Contains(t, err.Error(), "4 on hand")
+
+	require.NoError(t, bus.Wait(context.Background()))
+	require.Len(t, adjusted, 3, "a refused adjustment raises no event")
+	assert.Equal(t, -3, adjusted[2].Delta)
+
+	stock, err := useCase.GetByMedicine(ctx, medicineID)
+	require.NoError(t, err)
+	assert.Equal(t, 11, stock.Total)
+	require.Len(t, stock.Levels, 2)
+	assert.Equal(t, "front", stock.Levels[0].Location)
+}
+
+func TestStockUseCase_AdjustValidation(t *testing.T) {
+	useCase, _, medicineID := setupUseCase(t)
+
+	_, err := useCase.Adjust(tenantContext(1), stockDomain.Adjustment{MedicineID: medicineID})
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	_, err = useCase.Adjust(tenantContext(2), stockDomain.Adjustment{MedicineID: medicineID, Delta: 1})
+	assertErrorType(t, err, domainErrors.NotFound)
+
+	_, err = useCase.GetByMedicine(tenantContext(2), medicineID)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestStockUseCase_AdjustTypesAndReasons(t *testing.T) {
+	useCase, _, medicineID := setupUseCase(t)
+	ctx := tenantContext(1)
+
+	for name, adjustment := range map[string]stockDomain.Adjustment{
+		"unknown type":        {MedicineID: medicineID, Delta: 1, Type: "theft"},
+		"dispense by hand":    {MedicineID: medicineID, Delta: -1, Type: stockDomain.MovementDispense},
+		"removing return":     {MedicineID: medicineID, Delta: -1, Type: stockDomain.MovementReturn},
+		"adding write-off":    {MedicineID: medicineID, Delta: 1, Type: stockDomain.MovementWriteOff},
+		"free text reason":    {MedicineID: medicineID, Delta: 1, Reason: "found it in the back"},
+		"too long reason":     {MedicineID: medicineID, Delta: 1, Reason: strings.Repeat("x", 51)},
+		"leading dash reason": {MedicineID: medicineID, Delta: 1, Reason: "-count"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := useCase.Adjust(ctx, adjustment)
+			assertErrorType(t, err, domainErrors.ValidationError)
+		})
+	}
+
+	_, err := useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: 5, Type: stockDomain.MovementReturn, Reason: " Customer_Return "})
+	require.NoError(t, err)
+	level, err := useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: -2, Type: stockDomain.MovementWriteOff, Reason: "damaged"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, level.Quantity)
+
+	movements, err := useCase.GetMovements(ctx, medicineID, domain.DataFilters{})
+	require.NoError(t, err)
+	require.Len(t, *movements.Data, 2)
+	assert.Equal(t, stockDomain.MovementWriteOff, (*movements.Data)[0].Type)
+	assert.Equal(t, "customer_return", (*movements.Data)[1].Reason)
+
+	_, err = useCase.GetMovements(tenantContext(2), medicineID, domain.DataFilters{})
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestStockUseCase_ConcurrentRemovalsNeverGoNegative(t *testing.T) {
+	useCase, _, medicineID := setupUseCase(t)
+	ctx := tenantContext(1)
+	_, err := useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: 5})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: -1}); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, succeeded)
+	stock, err := useCase.GetByMedicine(ctx, medicineID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stock.Total)
+}
@@ -0,0 +1,146 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"go.uber.org/zap"
+)
+
+// RequestTransfer asks for units of a live medicine, of one of its lots when LotID is
+// set, to be moved between two active locations. The user must be allowed at one of
+// them. Nothing moves until the transfer is shipped.
+func (s *StockUseCase) RequestTransfer(ctx context.Context, transfer *stockDomain.Transfer) (*stockDomain.Transfer, error) {
+	transfer.FromLocation = strings.TrimSpace(transfer.FromLocation)
+	transfer.ToLocation = strings.TrimSpace(transfer.ToLocation)
+	s.Logger.Info("Requesting stock transfer",
+		zap.Int("medicineId", transfer.MedicineID),
+		zap.String("from", transfer.FromLocation),
+		zap.String("to", transfer.ToLocation))
+	switch {
+	case transfer.FromLocation == "" || transfer.ToLocation == "":
+		return nil, validationError("fromLocation and toLocation are required")
+	case transfer.FromLocation == transfer.ToLocation:
+		return nil, validationError("fromLocation and toLocation must differ")
+	case transfer.Quantity <= 0:
+		return nil, validationError("quantity must be positive")
+	}
+	reason, err := reasonCode(transfer.Reason)
+	if err != nil {
+		return nil, err
+	}
+	medicine, err := s.medicineRepository.GetByID(ctx, transfer.MedicineID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.LotID != nil {
+		lot, err := s.stockRepository.GetLotByID(ctx, *transfer.LotID)
+		if err != nil {
+			return nil, err
+		}
+		if lot.MedicineID != transfer.MedicineID || lot.Location != transfer.FromLocation {
+			return nil, validationError(fmt.Sprintf("lot %s of this medicine is not held at %s", lot.LotNumber, transfer.FromLocation))
+		}
+	}
+	fromErr := s.authorize(ctx, transfer.FromLocation)
+	toErr := s.authorize(ctx, transfer.ToLocation)
+	if err := eitherAllowed(fromErr, toErr); err != nil {
+		return nil, err
+	}
+	return s.stockRepository.CreateTransfer(ctx, &stockDomain.Transfer{
+		TenantID:     medicine.TenantID,
+		MedicineID:   transfer.MedicineID,
+		LotID:        transfer.LotID,
+		FromLocation: transfer.FromLocation,
+		ToLocation:   transfer.ToLocation,
+		Quantity:     transfer.Quantity,
+		Reason:       reason,
+	})
+}
+
+func (s *StockUseCase) GetTransfer(ctx context.Context, id int) (*stockDomain.Transfer, error) {
+	s.Logger.Info("Getting transfer by ID", zap.Int("id", id))
+	return s.stockRepository.GetTransferByID(ctx, id)
+}
+
+func (s *StockUseCase) GetTransfers(ctx context.Context, filters domain.DataFilters) (*stockDomain.SearchResultTransfer, error) {
+	s.Logger.Info("Getting transfers", zap.Int("page", filters.Page))
+	return s.stockRepository.GetTransfers(ctx, filters)
+}
+
+// ShipTransfer takes the units of a requested transfer from its source location,
+// which the user must be allowed at. They are in transit until received.
+func (s *StockUseCase) ShipTransfer(ctx context.Context, id int) (*stockDomain.Transfer, error) {
+	s.Logger.Info("Shipping transfer", zap.Int("id", id))
+	transfer, err := s.stockRepository.GetTransferByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, transfer.FromLocation); err != nil {
+		return nil, err
+	}
+	shipped, level, err := s.stockRepository.ShipTransfer(ctx, id, s.now())
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, stockDomain.Adjusted{Level: *level, Delta: -shipped.Quantity})
+	return shipped, nil
+}
+
+// ReceiveTransfer adds the units of a shipped transfer to its destination location,
+// which the user must be allowed at
+func (s *StockUseCase) ReceiveTransfer(ctx context.Context, id int) (*stockDomain.Transfer, error) {
+	s.Logger.Info("Receiving transfer", zap.Int("id", id))
+	transfer, err := s.stockRepository.GetTransferByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, transfer.ToLocation); err != nil {
+		return nil, err
+	}
+	received, level, err := s.stockRepository.ReceiveTransfer(ctx, id, s.now())
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, stockDomain.Adjusted{Level: *level, Delta: received.Quantity})
+	return received, nil
+}
+
+// CancelTransfer withdraws a requested transfer. The user must be allowed at one of
+// its locations.
+func (s *StockUseCase) CancelTransfer(ctx context.Context, id int) (*stockDomain.Transfer, error) {
+	s.Logger.Info("Cancelling transfer", zap.Int("id", id))
+	transfer, err := s.stockRepository.GetTransferByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := eitherAllowed(s.allowed(ctx, transfer.FromLocation), s.allowed(ctx, transfer.ToLocation)); err != nil {
+		return nil, err
+	}
+	return s.stockRepository.CancelTransfer(ctx, id, s.now())
+}
+
+// eitherAllowed combines the checks of the two locations of a transfer: other
+// failures are returned as they are, and a user kept from one location is only
+// refused when kept from the other as well
+func eitherAllowed(fromErr, toErr error) error {
+	for _, err := range []error{fromErr, toErr} {
+		if err != nil && !isNotAuthorized(err) {
+			return err
+		}
+	}
+	if fromErr != nil && toErr != nil {
+		return fromErr
+	}
+	return nil
+}
+
+func isNotAuthorized(err error) bool {
+	appErr, ok := err.(*domainErrors.AppError)
+	return ok && appErr.Type == domainErrors.NotAuthorized
+}
@@ -0,0 +1,195 @@
+package stock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userContext(userID int) context.Context {
+	return security.WithPrincipal(context.Background(), security.Principal{UserID: userID, TenantID: 1})
+}
+
+func TestStockUseCase_TransferWorkflow(t *testing.T) {
+	useCase, bus, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	var adjusted []stockDomain.Adjusted
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e stockDomain.Adjusted) error {
+		adjusted = append(adjusted, e)
+		return nil
+	})
+	registerLocation(t, useCase, ctx, "front")
+	lot, err := useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: "L-1", ExpiresAt: now.AddDate(1, 0, 0), Quantity: 10})
+	require.NoError(t, err)
+
+	transfer, err := useCase.RequestTransfer(ctx, &stockDomain.Transfer{MedicineID: medicineID, LotID: &lot.ID, FromLocation: "main", ToLocation: " front ", Quantity: 4, Reason: "Restock"})
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.TransferRequested, transfer.Status)
+	assert.Equal(t, "front", transfer.ToLocation)
+	assert.Equal(t, "restock", transfer.Reason)
+
+	_, err = useCase.ReceiveTransfer(ctx, transfer.ID)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	shipped, err := useCase.ShipTransfer(ctx, transfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.TransferShipped, shipped.Status)
+	stock, err := useCase.GetByMedicine(ctx, medicineID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, stock.Total)
+	assert.Equal(t, 4, stock.InTransit)
+
+	_, err = useCase.CancelTransfer(ctx, transfer.ID)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	received, err := useCase.ReceiveTransfer(ctx, transfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.TransferReceived, received.Status)
+	stock, err = useCase.GetByMedicine(ctx, medicineID)
+	require.NoError(t, err)
+	assert.Equal(t, 10, stock.Total)
+	assert.Zero(t, stock.InTransit)
+
+	dispensation, err := useCase.Dispense(ctx, medicineID, "front", 4, "")
+	require.NoError(t, err)
+	require.Len(t, dispensation.Allocations, 1)
+	assert.Equal(t, "L-1", dispensation.Allocations[0].LotNumber)
+	_, err = useCase.Dispense(ctx, medicineID, "front", 1, "")
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	require.NoError(t, bus.Wait(context.Background()))
+	require.Len(t, adjusted, 4)
+	assert.Equal(t, -4, adjusted[1].Delta)
+	assert.Equal(t, "main", adjusted[1].Level.Location)
+	assert.Equal(t, 4, adjusted[2].Delta)
+	assert.Equal(t, "front", adjusted[2].Level.Location)
+
+	transfers, err := useCase.GetTransfers(ctx, domain.DataFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), transfers.Total)
+}
+
+func TestStockUseCase_RequestTransferValidation(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	registerLocation(t, useCase, ctx, "front")
+	lot, err := useCase.ReceiveLot(ctx, &stockDomain.MedicineLot{MedicineID: medicineID, LotNumber: "L-1", ExpiresAt: now.AddDate(1, 0, 0), Quantity: 10})
+	require.NoError(t, err)
+
+	for name, transfer := range map[string]stockDomain.Transfer{
+		"no source":        {MedicineID: medicineID, ToLocation: "front", Quantity: 1},
+		"same location":    {MedicineID: medicineID, FromLocation: "front", ToLocation: "front", Quantity: 1},
+		"no quantity":      {MedicineID: medicineID, FromLocation: "main", ToLocation: "front"},
+		"unknown location": {MedicineID: medicineID, FromLocation: "main", ToLocation: "back", Quantity: 1},
+		"lot elsewhere":    {MedicineID: medicineID, LotID: &lot.ID, FromLocation: "front", ToLocation: "main", Quantity: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := useCase.RequestTransfer(ctx, &transfer)
+			assertErrorType(t, err, domainErrors.ValidationError)
+		})
+	}
+
+	_, err = useCase.RequestTransfer(tenantContext(2), &stockDomain.Transfer{MedicineID: medicineID, FromLocation: "main", ToLocation: "front", Quantity: 1})
+	assertErrorType(t, err, domainErrors.NotFound)
+
+	transfer, err := useCase.RequestTransfer(ctx, &stockDomain.Transfer{MedicineID: medicineID, FromLocation: "main", ToLocation: "front", Quantity: 20})
+	require.NoError(t, err)
+	_, err = useCase.ShipTransfer(ctx, transfer.ID)
+	assertErrorType(t, err, domainErrors.ValidationError)
+	cancelled, err := useCase.CancelTransfer(ctx, transfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stockDomain.TransferCancelled, cancelled.Status)
+}
+
+func TestStockUseCase_LocationAccess(t *testing.T) {
+	useCase, _, medicineID := setupLotUseCase(t)
+	ctx := tenantContext(1)
+	front := registerLocation(t, useCase, ctx, "front", 2)
+	_, err := useCase.Adjust(ctx, stockDomain.Adjustment{MedicineID: medicineID, Delta: 5})
+	require.NoError(t, err)
+
+	_, err = useCase.Adjust(userContext(3), stockDomain.Adjustment{MedicineID: medicineID, Location: "front", Delta: 1})
+	assertErrorType(t, err, domainErrors.NotAuthorized)
+	_, err = useCase.Adjust(userContext(2), stockDomain.Adjustment{MedicineID: medicineID, Location: "front", Delta: 1})
+	require.NoError(t, err)
+	_, err = useCase.Adjust(context.Background(), stockDomain.Adjustment{MedicineID: medicineID, Location: "front", Delta: 1})
+	require.NoError(t, err, "background work is not restricted")
+
+	transfer, err := useCase.RequestTransfer(userContext(3), &stockDomain.Transfer{MedicineID: medicineID, FromLocation: "main", ToLocation: "front", Quantity: 2})
+	require.NoError(t, err, "a user allowed at the source may request")
+	_, err = useCase.ShipTransfer(userContext(3), transfer.ID)
+	require.NoError(t, err)
+	_, err = useCase.ReceiveTransfer(userContext(3), transfer.ID)
+	assertErrorType(t, err, domainErrors.NotAuthorized)
+	_, err = useCase.ReceiveTransfer(userContext(2), transfer.ID)
+	require.NoError(t, err)
+
+	main := registerLocation(t, useCase, ctx, "main", 2)
+	_, err = useCase.RequestTransfer(userContext(3), &stockDomain.Transfer{MedicineID: medicineID, FromLocation: "main", ToLocation: "front", Quantity: 1})
+	assertErrorType(t, err, domainErrors.NotAuthorized)
+	_, err = useCase.Dispense(userContext(3), medicineID, "", 1, "")
+	assertErrorType(t, err, domainErrors.NotAuthorized)
+
+	_, err = useCase.UpdateLocation(ctx, main.ID, map[string]any{"userIds": []any{float64(2), float64(3)}})
+	require.NoError(t, err)
+	_, err = useCase.Adjust(userContext(3), stockDomain.Adjustment{MedicineID: medicineID, Delta: 1})
+	require.NoError(t, err)
+
+	updated, err := useCase.UpdateLocation(ctx, front.ID, map[string]any{"active": false})
+	require.NoError(t, err)
+	assert.False(t, updated.Active)
+	_, err = useCase.Adjust(userContext(2), stockDomain.Adjustment{MedicineID: medicineID, Location: "front", Delta: 1})
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestStockUseCase_LocationValidation(t *testing.T) {
+	useCase, _, _ := setupUseCase(t)
+	ctx := tenantContext(1)
+
+	for name, location := range map[string]stockDomain.Location{
+		"no code":      {Name: "Front", Type: stockDomain.LocationStore},
+		"no name":      {Code: "front", Type: stockDomain.LocationStore},
+		"unknown type": {Code: "front", Name: "Front", Type: "shelf"},
+		"bad user":     {Code: "front", Name: "Front", Type: stockDomain.LocationStore, UserIDs: []int{0}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := useCase.CreateLocation(ctx, &location)
+			assertErrorType(t, err, domainErrors.ValidationError)
+		})
+	}
+
+	location := registerLocation(t, useCase, ctx, "front", 2, 2)
+	assert.Equal(t, []int{2}, location.UserIDs)
+	assert.True(t, location.Active)
+	_, err := useCase.CreateLocation(ctx, &stockDomain.Location{Code: "front", Name: "Again", Type: stockDomain.LocationCabinet})
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+
+	for name, update := range map[string]map[string]any{
+		"code":       {"code": "back"},
+		"empty name": {"name": " "},
+		"bad type":   {"type": "shelf"},
+		"bad active": {"active": "no"},
+		"bad users":  {"userIds": []any{1.5}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := useCase.UpdateLocation(ctx, location.ID, update)
+			assertErrorType(t, err, domainErrors.ValidationError)
+		})
+	}
+
+	updated, err := useCase.UpdateLocation(ctx, location.ID, map[string]any{"name": "Front desk", "type": "cabinet", "userIds": []any{}})
+	require.NoError(t, err)
+	assert.Equal(t, "Front desk", updated.Name)
+	assert.Equal(t, stockDomain.LocationCabinet, updated.Type)
+	assert.Empty(t, updated.UserIDs)
+
+	_, err = useCase.GetLocation(tenantContext(2), location.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
@@ -1,7 +1,14 @@
 package user
 
 import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
 	"github.com/gbrayhan/microservices-go/src/domain"
+	historyDomain "github.com/gbrayhan/microservices-go/src/domain/history"
 	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
@@ -10,44 +17,52 @@ import (
 )
 
 type IUserUseCase interface {
-	GetAll() (*[]userDomain.User, error)
-	GetByID(id int) (*userDomain.User, error)
-	GetByEmail(email string) (*userDomain.User, error)
-	Create(newUser *userDomain.User) (*userDomain.User, error)
-	Delete(id int) error
-	Update(id int, userMap map[string]interface{}) (*userDomain.User, error)
-	SearchPaginated(filters domain.DataFilters) (*userDomain.SearchResultUser, error)
-	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetAll(ctx context.Context) (*[]userDomain.User, error)
+	GetByID(ctx context.Context, id int) (*userDomain.User, error)
+	GetByEmail(ctx context.Context, email string) (*userDomain.User, error)
+	Create(ctx context.Context, newUser *userDomain.User) (*userDomain.User, error)
+	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, userMap map[string]interface{}) (*userDomain.User, error)
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*userDomain.SearchResultUser, error)
+	SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error)
+	GetTrash(ctx context.Context, filters domain.DataFilters) (*userDomain.SearchResultUser, error)
+	Restore(ctx context.Context, id int) (*userDomain.User, error)
+	Purge(ctx context.Context, olderThan time.Time) (int64, error)
+	GetHistory(ctx context.Context, id int) (*[]historyDomain.Entry, error)
+	GetAsOf(ctx context.Context, id int, at time.Time) (*userDomain.User, error)
 }
 
+// UserUseCase raises the events of userDomain on bus after every successful change
 type UserUseCase struct {
 	userRepository user.UserRepositoryInterface
+	bus            *eventbus.Bus
 	Logger         *logger.Logger
 }
 
-func NewUserUseCase(userRepository user.UserRepositoryInterface, logger *logger.Logger) IUserUseCase {
+func NewUserUseCase(userRepository user.UserRepositoryInterface, bus *eventbus.Bus, logger *logger.Logger) IUserUseCase {
 	return &UserUseCase{
 		userRepository: userRepository,
+		bus:            bus,
 		Logger:         logger,
 	}
 }
 
-func (s *UserUseCase) GetAll() (*[]userDomain.User, error) {
+func (s *UserUseCase) GetAll(ctx context.Context) (*[]userDomain.User, error) {
 	s.Logger.Info("Getting all users")
-	return s.userRepository.GetAll()
+	return s.userRepository.GetAll(ctx)
 }
 
-func (s *UserUseCase) GetByID(id int) (*userDomain.User, error) {
+func (s *UserUseCase) GetByID(ctx context.Context, id int) (*userDomain.User, error) {
 	s.Logger.Info("Getting user by ID", zap.Int("id", id))
-	return s.userRepository.GetByID(id)
+	return s.userRepository.GetByID(ctx, id)
 }
 
-func (s *UserUseCase) GetByEmail(email string) (*userDomain.User, error) {
+func (s *UserUseCase) GetByEmail(ctx context.Context, email string) (*userDomain.User, error) {
 	s.Logger.Info("Getting user by email", zap.String("email", email))
-	return s.userRepository.GetByEmail(email)
+	return s.userRepository.GetByEmail(ctx, email)
 }
 
-func (s *UserUseCase) Create(newUser *userDomain.User) (*userDomain.User, error) {
+func (s *UserUseCase) Create(ctx context.Context, newUser *userDomain.User) (*userDomain.User, error) {
 	s.Logger.Info("Creating new user", zap.String("email", newUser.Email))
 	hash, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -57,29 +72,76 @@ func (s *UserUseCase) Create(newUser *userDomain.User) (*userDomain.User, error)
 	newUser.HashPassword = string(hash)
 	newUser.Status = true
 
-	return s.userRepository.Create(newUser)
+	created, err := s.userRepository.Create(ctx, newUser)
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, userDomain.Created{User: *created})
+	return created, nil
 }
 
-func (s *UserUseCase) Delete(id int) error {
+func (s *UserUseCase) Delete(ctx context.Context, id int) error {
 	s.Logger.Info("Deleting user", zap.Int("id", id))
-	return s.userRepository.Delete(id)
+	if err := s.userRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	eventbus.Publish(ctx, s.bus, userDomain.Deleted{ID: id})
+	return nil
 }
 
-func (s *UserUseCase) Update(id int, userMap map[string]interface{}) (*userDomain.User, error) {
+func (s *UserUseCase) Update(ctx context.Context, id int, userMap map[string]interface{}) (*userDomain.User, error) {
 	s.Logger.Info("Updating user", zap.Int("id", id))
-	return s.userRepository.Update(id, userMap)
+	updated, err := s.userRepository.Update(ctx, id, userMap)
+	if err != nil {
+		return nil, err
+	}
+	fields := slices.Sorted(maps.Keys(userMap))
+	eventbus.Publish(ctx, s.bus, userDomain.Updated{User: *updated, Fields: fields})
+	return updated, nil
 }
 
-func (s *UserUseCase) SearchPaginated(filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
+func (s *UserUseCase) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
 	s.Logger.Info("Searching users with pagination",
 		zap.Int("page", filters.Page),
 		zap.Int("pageSize", filters.PageSize))
-	return s.userRepository.SearchPaginated(filters)
+	return s.userRepository.SearchPaginated(ctx, filters)
 }
 
-func (s *UserUseCase) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (s *UserUseCase) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
 	s.Logger.Info("Searching users by property",
 		zap.String("property", property),
 		zap.String("searchText", searchText))
-	return s.userRepository.SearchByProperty(property, searchText)
+	return s.userRepository.SearchByProperty(ctx, property, searchText)
+}
+
+func (s *UserUseCase) GetTrash(ctx context.Context, filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
+	s.Logger.Info("Listing deleted users",
+		zap.Int("page", filters.Page),
+		zap.Int("pageSize", filters.PageSize))
+	return s.userRepository.GetTrash(ctx, filters)
+}
+
+func (s *UserUseCase) Restore(ctx context.Context, id int) (*userDomain.User, error) {
+	s.Logger.Info("Restoring user", zap.Int("id", id))
+	restored, err := s.userRepository.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(ctx, s.bus, userDomain.Restored{User: *restored})
+	return restored, nil
+}
+
+func (s *UserUseCase) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	s.Logger.Info("Purging deleted users", zap.Time("olderThan", olderThan))
+	return s.userRepository.Purge(ctx, olderThan)
+}
+
+func (s *UserUseCase) GetHistory(ctx context.Context, id int) (*[]historyDomain.Entry, error) {
+	s.Logger.Info("Getting user history", zap.Int("id", id))
+	return s.userRepository.GetHistory(ctx, id)
+}
+
+func (s *UserUseCase) GetAsOf(ctx context.Context, id int, at time.Time) (*userDomain.User, error) {
+	s.Logger.Info("Getting user as of time", zap.Int("id", id), zap.Time("asOf", at))
+	return s.userRepository.GetAsOf(ctx, id, at)
 }
@@ -1,11 +1,15 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
 	"github.com/gbrayhan/microservices-go/src/domain"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
 	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 )
@@ -17,32 +21,52 @@ type mockUserService struct {
 	createFn     func(u *userDomain.User) (*userDomain.User, error)
 	deleteFn     func(id int) error
 	updateFn     func(id int, m map[string]interface{}) (*userDomain.User, error)
+	getTrashFn   func(filters domain.DataFilters) (*userDomain.SearchResultUser, error)
+	restoreFn    func(id int) (*userDomain.User, error)
+	historyFn    func(id int) (*[]domainHistory.Entry, error)
+	asOfFn       func(id int, at time.Time) (*userDomain.User, error)
+	purgeFn      func(olderThan time.Time) (int64, error)
 }
 
-func (m *mockUserService) GetAll() (*[]userDomain.User, error) {
+func (m *mockUserService) GetAll(_ context.Context) (*[]userDomain.User, error) {
 	return m.getAllFn()
 }
-func (m *mockUserService) GetByID(id int) (*userDomain.User, error) {
+func (m *mockUserService) GetByID(_ context.Context, id int) (*userDomain.User, error) {
 	return m.getByIDFn(id)
 }
-func (m *mockUserService) GetByEmail(email string) (*userDomain.User, error) {
+func (m *mockUserService) GetByEmail(_ context.Context, email string) (*userDomain.User, error) {
 	return m.getByEmailFn(email)
 }
-func (m *mockUserService) Create(newUser *userDomain.User) (*userDomain.User, error) {
+func (m *mockUserService) Create(_ context.Context, newUser *userDomain.User) (*userDomain.User, error) {
 	return m.createFn(newUser)
 }
-func (m *mockUserService) Delete(id int) error {
+func (m *mockUserService) Delete(_ context.Context, id int) error {
 	return m.deleteFn(id)
 }
-func (m *mockUserService) Update(id int, userMap map[string]interface{}) (*userDomain.User, error) {
+func (m *mockUserService) Update(_ context.Context, id int, userMap map[string]interface{}) (*userDomain.User, error) {
 	return m.updateFn(id, userMap)
 }
-func (m *mockUserService) SearchPaginated(filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
+func (m *mockUserService) SearchPaginated(_ context.Context, filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
 	return nil, nil
 }
-func (m *mockUserService) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (m *mockUserService) SearchByProperty(_ context.Context, property string, searchText string) (*[]string, error) {
 	return nil, nil
 }
+func (m *mockUserService) GetTrash(_ context.Context, filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
+	return m.getTrashFn(filters)
+}
+func (m *mockUserService) Restore(_ context.Context, id int) (*userDomain.User, error) {
+	return m.restoreFn(id)
+}
+func (m *mockUserService) Purge(_ context.Context, olderThan time.Time) (int64, error) {
+	return m.purgeFn(olderThan)
+}
+func (m *mockUserService) GetHistory(_ context.Context, id int) (*[]domainHistory.Entry, error) {
+	return m.historyFn(id)
+}
+func (m *mockUserService) GetAsOf(_ context.Context, id int, at time.Time) (*userDomain.User, error) {
+	return m.asOfFn(id, at)
+}
 
 func setupLogger(t *testing.T) *logger.Logger {
 	loggerInstance, err := logger.NewLogger()
@@ -56,13 +80,13 @@ func TestUserUseCase(t *testing.T) {
 
 	mockRepo := &mockUserService{}
 	logger := setupLogger(t)
-	useCase := NewUserUseCase(mockRepo, logger)
+	useCase := NewUserUseCase(mockRepo, nil, logger)
 
 	t.Run("Test GetAll", func(t *testing.T) {
 		mockRepo.getAllFn = func() (*[]userDomain.User, error) {
 			return &[]userDomain.User{{ID: 1}}, nil
 		}
-		us, err := useCase.GetAll()
+		us, err := useCase.GetAll(context.Background())
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -78,11 +102,11 @@ func TestUserUseCase(t *testing.T) {
 			}
 			return &userDomain.User{ID: id}, nil
 		}
-		_, err := useCase.GetByID(999)
+		_, err := useCase.GetByID(context.Background(), 999)
 		if err == nil {
 			t.Error("expected error, got nil")
 		}
-		u, err := useCase.GetByID(10)
+		u, err := useCase.GetByID(context.Background(), 10)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -98,11 +122,11 @@ func TestUserUseCase(t *testing.T) {
 			}
 			return &userDomain.User{ID: 123, Email: email}, nil
 		}
-		_, err := useCase.GetByEmail("notfound@example.com")
+		_, err := useCase.GetByEmail(context.Background(), "notfound@example.com")
 		if err == nil {
 			t.Error("expected error, got nil")
 		}
-		u, err := useCase.GetByEmail("test@example.com")
+		u, err := useCase.GetByEmail(context.Background(), "test@example.com")
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -128,7 +152,7 @@ func TestUserUseCase(t *testing.T) {
 			newU.ID = 555
 			return newU, nil
 		}
-		created, err := useCase.Create(&userDomain.User{Email: "test@mail.com", Password: "abc"})
+		created, err := useCase.Create(context.Background(), &userDomain.User{Email: "test@mail.com", Password: "abc"})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -138,7 +162,7 @@ func TestUserUseCase(t *testing.T) {
 	})
 
 	t.Run("Test Create (Error empty email)", func(t *testing.T) {
-		_, err := useCase.Create(&userDomain.User{Email: "", Password: "abc"})
+		_, err := useCase.Create(context.Background(), &userDomain.User{Email: "", Password: "abc"})
 		if err == nil {
 			t.Error("expected error on create user with empty email")
 		}
@@ -151,11 +175,11 @@ func TestUserUseCase(t *testing.T) {
 			}
 			return errors.New("cannot delete")
 		}
-		err := useCase.Delete(999)
+		err := useCase.Delete(context.Background(), 999)
 		if err == nil {
 			t.Error("expected error for cannot delete")
 		}
-		err = useCase.Delete(101)
+		err = useCase.Delete(context.Background(), 101)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -168,11 +192,11 @@ func TestUserUseCase(t *testing.T) {
 			}
 			return &userDomain.User{ID: id, UserName: "Updated"}, nil
 		}
-		_, err := useCase.Update(999, map[string]interface{}{"userName": "any"})
+		_, err := useCase.Update(context.Background(), 999, map[string]interface{}{"userName": "any"})
 		if err == nil {
 			t.Error("expected error, got nil")
 		}
-		updated, err := useCase.Update(1001, map[string]interface{}{"userName": "whatever"})
+		updated, err := useCase.Update(context.Background(), 1001, map[string]interface{}{"userName": "whatever"})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -180,12 +204,152 @@ func TestUserUseCase(t *testing.T) {
 			t.Error("expected userName=Updated")
 		}
 	})
+
+	t.Run("Test GetTrash", func(t *testing.T) {
+		mockRepo.getTrashFn = func(filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
+			deletedAt := time.Now()
+			return &userDomain.SearchResultUser{
+				Data:  &[]userDomain.User{{ID: 7, DeletedAt: &deletedAt}},
+				Total: 1,
+				Page:  filters.Page,
+			}, nil
+		}
+		result, err := useCase.GetTrash(context.Background(), domain.DataFilters{Page: 2})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if result.Total != 1 || result.Page != 2 {
+			t.Error("expected one deleted user on page 2")
+		}
+	})
+
+	t.Run("Test GetHistory and GetAsOf", func(t *testing.T) {
+		mockRepo.historyFn = func(id int) (*[]domainHistory.Entry, error) {
+			return &[]domainHistory.Entry{{ID: 1, EntityID: id, Action: domainHistory.ActionUpdate}}, nil
+		}
+		entries, err := useCase.GetHistory(context.Background(), 7)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if len(*entries) != 1 || (*entries)[0].EntityID != 7 {
+			t.Error("expected one history entry for user 7")
+		}
+
+		mockRepo.asOfFn = func(id int, at time.Time) (*userDomain.User, error) {
+			return &userDomain.User{ID: id, Email: "old@example.com"}, nil
+		}
+		user, err := useCase.GetAsOf(context.Background(), 7, time.Now())
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if user.Email != "old@example.com" {
+			t.Errorf("expected old@example.com, got %s", user.Email)
+		}
+	})
+
+	t.Run("Test Restore", func(t *testing.T) {
+		mockRepo.restoreFn = func(id int) (*userDomain.User, error) {
+			if id != 7 {
+				return nil, errors.New("not found")
+			}
+			return &userDomain.User{ID: id}, nil
+		}
+		_, err := useCase.Restore(context.Background(), 8)
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+		restored, err := useCase.Restore(context.Background(), 7)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if restored.ID != 7 || restored.DeletedAt != nil {
+			t.Error("expected restored user ID=7 without deletedAt")
+		}
+	})
+
+	t.Run("Test Purge", func(t *testing.T) {
+		cutoff := time.Now().Add(-24 * time.Hour)
+		mockRepo.purgeFn = func(olderThan time.Time) (int64, error) {
+			if !olderThan.Equal(cutoff) {
+				t.Error("expected cutoff to be passed through")
+			}
+			return 4, nil
+		}
+		purged, err := useCase.Purge(context.Background(), cutoff)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if purged != 4 {
+			t.Errorf("expected 4 purged users, got %d", purged)
+		}
+	})
+}
+
+func TestUserUseCase_RaisesEvents(t *testing.T) {
+	mockRepo := &mockUserService{}
+	loggerInstance := setupLogger(t)
+	bus := eventbus.New(loggerInstance)
+	useCase := NewUserUseCase(mockRepo, bus, loggerInstance)
+	var raised []any
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e userDomain.Created) error {
+		raised = append(raised, e.User.ID)
+		return nil
+	})
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e userDomain.Updated) error {
+		raised = append(raised, e.Fields)
+		return nil
+	})
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e userDomain.Deleted) error {
+		raised = append(raised, e)
+		panic("handler panics do not reach the caller")
+	})
+	eventbus.Subscribe(bus, "test", func(_ context.Context, e userDomain.Restored) error {
+		raised = append(raised, e.User.ID)
+		return nil
+	})
+
+	mockRepo.createFn = func(u *userDomain.User) (*userDomain.User, error) {
+		return &userDomain.User{ID: 4, Email: u.Email}, nil
+	}
+	mockRepo.updateFn = func(id int, m map[string]interface{}) (*userDomain.User, error) {
+		return &userDomain.User{ID: id}, nil
+	}
+	mockRepo.deleteFn = func(id int) error {
+		if id != 4 {
+			return errors.New("not found")
+		}
+		return nil
+	}
+	mockRepo.restoreFn = func(id int) (*userDomain.User, error) {
+		return &userDomain.User{ID: id}, nil
+	}
+
+	if _, err := useCase.Create(context.Background(), &userDomain.User{Email: "ana@example.com", Password: "secret"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := useCase.Update(context.Background(), 4, map[string]interface{}{"lastName": "Lee", "firstName": "Ana"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := useCase.Delete(context.Background(), 5); err == nil {
+		t.Error("expected error, got nil")
+	}
+	if err := useCase.Delete(context.Background(), 4); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := useCase.Restore(context.Background(), 4); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := []any{4, []string{"firstName", "lastName"}, userDomain.Deleted{ID: 4}, 4}
+	if !reflect.DeepEqual(raised, expected) {
+		t.Errorf("expected events %+v, got %+v", expected, raised)
+	}
 }
 
 func TestNewUserUseCase(t *testing.T) {
 	mockRepo := &mockUserService{}
 	loggerInstance := setupLogger(t)
-	useCase := NewUserUseCase(mockRepo, loggerInstance)
+	useCase := NewUserUseCase(mockRepo, nil, loggerInstance)
 	if reflect.TypeOf(useCase).String() != "*user.UserUseCase" {
 		t.Error("expected *user.UserUseCase type")
 	}
@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	webhookDomain "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
+	"go.uber.org/zap"
+)
+
+// minSecretLength is the shortest signing secret a client may choose
+const minSecretLength = 16
+
+type IWebhookUseCase interface {
+	GetAll(ctx context.Context) (*[]webhookDomain.Webhook, error)
+	GetByID(ctx context.Context, id int) (*webhookDomain.Webhook, error)
+	Create(ctx context.Context, webhook *webhookDomain.Webhook) (*webhookDomain.Webhook, error)
+	Update(ctx context.Context, id int, webhookMap map[string]any) (*webhookDomain.Webhook, error)
+	Delete(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, id int, filters domain.DataFilters) (*webhookDomain.SearchResultDelivery, error)
+}
+
+// WebhookUseCase validates the webhooks of the current organization before storing them
+type WebhookUseCase struct {
+	webhookRepository webhook.WebhookRepositoryInterface
+	Logger            *logger.Logger
+}
+
+func NewWebhookUseCase(webhookRepository webhook.WebhookRepositoryInterface, loggerInstance *logger.Logger) IWebhookUseCase {
+	return &WebhookUseCase{
+		webhookRepository: webhookRepository,
+		Logger:            loggerInstance,
+	}
+}
+
+func (s *WebhookUseCase) GetAll(ctx context.Context) (*[]webhookDomain.Webhook, error) {
+	s.Logger.Info("Getting all webhooks")
+	return s.webhookRepository.GetAll(ctx)
+}
+
+func (s *WebhookUseCase) GetByID(ctx context.Context, id int) (*webhookDomain.Webhook, error) {
+	s.Logger.Info("Getting webhook by ID", zap.Int("id", id))
+	return s.webhookRepository.GetByID(ctx, id)
+}
+
+// Create registers an active webhook. A signing secret is generated unless one is given.
+func (s *WebhookUseCase) Create(ctx context.Context, newWebhook *webhookDomain.Webhook) (*webhookDomain.Webhook, error) {
+	s.Logger.Info("Creating new webhook", zap.String("url", newWebhook.URL))
+	if err := validateURL(newWebhook.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := validateEventTypes(newWebhook.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret := newWebhook.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			s.Logger.Error("Error generating webhook secret", zap.Error(err))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+	} else if err := validateSecret(secret); err != nil {
+		return nil, err
+	}
+	return s.webhookRepository.Create(ctx, &webhookDomain.Webhook{
+		URL:        newWebhook.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     true,
+	})
+}
+
+// Update changes the url, eventTypes, secret or active fields of a webhook
+func (s *WebhookUseCase) Update(ctx context.Context, id int, webhookMap map[string]any) (*webhookDomain.Webhook, error) {
+	s.Logger.Info("Updating webhook", zap.Int("id", id))
+	updates := make(map[string]any, len(webhookMap))
+	for key, value := range webhookMap {
+		var err error
+		switch key {
+		case "url":
+			rawURL, ok := value.(string)
+			if !ok {
+				return nil, validationError("url must be a string")
+			}
+			err = validateURL(rawURL)
+		case "eventTypes":
+			var eventTypes []string
+			if eventTypes, err = toStrings(value); err == nil {
+				value, err = validateEventTypes(eventTypes)
+			}
+		case "secret":
+			secret, ok := value.(string)
+			if !ok {
+				return nil, validationError("secret must be a string")
+			}
+			err = validateSecret(secret)
+		case "active":
+			if _, ok := value.(bool); !ok {
+				return nil, validationError("active must be a boolean")
+			}
+		default:
+			return nil, validationError(fmt.Sprintf("%s cannot be updated", key))
+		}
+		if err != nil {
+			return nil, err
+		}
+		updates[key] = value
+	}
+	return s.webhookRepository.Update(ctx, id, updates)
+}
+
+func (s *WebhookUseCase) Delete(ctx context.Context, id int) error {
+	s.Logger.Info("Deleting webhook", zap.Int("id", id))
+	return s.webhookRepository.Delete(ctx, id)
+}
+
+func (s *WebhookUseCase) GetDeliveries(ctx context.Context, id int, filters domain.DataFilters) (*webhookDomain.SearchResultDelivery, error) {
+	s.Logger.Info("Getting webhook deliveries", zap.Int("id", id), zap.Int("page", filters.Page))
+	return s.webhookRepository.GetDeliveries(ctx, id, filters)
+}
+
+// validateURL accepts absolute http and https URLs
+func validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return validationError("url must be an absolute http or https URL")
+	}
+	if parsed.User != nil {
+		return validationError("url cannot contain credentials")
+	}
+	return nil
+}
+
+// validateEventTypes returns the known event types of eventTypes without duplicates
+func validateEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		return nil, validationError("eventTypes cannot be empty")
+	}
+	result := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !slices.Contains(event.Types, eventType) {
+			return nil, validationError(fmt.Sprintf("unknown event type %q", eventType))
+		}
+		if !slices.Contains(result, eventType) {
+			result = append(result, eventType)
+		}
+	}
+	return result, nil
+}
+
+func validateSecret(secret string) error {
+	if len(secret) < minSecretLength {
+		return validationError(fmt.Sprintf("secret must have at least %d characters", minSecretLength))
+	}
+	return nil
+}
+
+// toStrings converts a decoded JSON array of strings
+func toStrings(value any) ([]string, error) {
+	switch values := value.(type) {
+	case []string:
+		return values, nil
+	case []any:
+		result := make([]string, 0, len(values))
+		for _, item := range values {
+			text, ok := item.(string)
+			if !ok {
+				return nil, validationError("eventTypes must be a list of strings")
+			}
+			result = append(result, text)
+		}
+		return result, nil
+	default:
+		return nil, validationError("eventTypes must be a list of strings")
+	}
+}
+
+func generateSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+func validationError(message string) error {
+	return domainErrors.NewAppError(errors.New(message), domainErrors.ValidationError)
+}
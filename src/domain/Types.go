@@ -8,6 +8,14 @@ type DateRangeFilter struct {
 	End   *time.Time `json:"end"`
 }
 
+// NumberRangeFilter keeps the records whose numeric Field lies between Min and Max,
+// both inclusive and optional
+type NumberRangeFilter struct {
+	Field string `json:"field"`
+	Min   *int   `json:"min"`
+	Max   *int   `json:"max"`
+}
+
 type SortDirection string
 
 const (
@@ -20,11 +28,12 @@ func (sd SortDirection) IsValid() bool {
 }
 
 type DataFilters struct {
-	LikeFilters      map[string][]string `json:"likeFilters"`
-	Matches          map[string][]string `json:"matches"`
-	DateRangeFilters []DateRangeFilter   `json:"dateRanges"`
-	SortBy           []string            `json:"sortBy"`
-	SortDirection    SortDirection       `json:"sortDirection"`
-	Page             int                 `json:"page"`
-	PageSize         int                 `json:"pageSize"`
+	LikeFilters        map[string][]string `json:"likeFilters"`
+	Matches            map[string][]string `json:"matches"`
+	DateRangeFilters   []DateRangeFilter   `json:"dateRanges"`
+	NumberRangeFilters []NumberRangeFilter `json:"numberRanges"`
+	SortBy             []string            `json:"sortBy"`
+	SortDirection      SortDirection       `json:"sortDirection"`
+	Page               int                 `json:"page"`
+	PageSize           int                 `json:"pageSize"`
 }
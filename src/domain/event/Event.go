@@ -0,0 +1,63 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/history"
+)
+
+// Aggregate types raising events
+const (
+	AggregateMedicine = "medicine"
+	AggregateUser     = "user"
+)
+
+// Event types, named <aggregate>.<action>
+const (
+	MedicineCreated  = "medicine.created"
+	MedicineUpdated  = "medicine.updated"
+	MedicineDeleted  = "medicine.deleted"
+	MedicineRestored = "medicine.restored"
+	UserCreated      = "user.created"
+	UserUpdated      = "user.updated"
+	UserDeleted      = "user.deleted"
+	UserRestored     = "user.restored"
+)
+
+// Types lists every event type, in the order they are documented
+var Types = []string{
+	MedicineCreated, MedicineUpdated, MedicineDeleted, MedicineRestored,
+	UserCreated, UserUpdated, UserDeleted, UserRestored,
+}
+
+var actionSuffixes = map[history.Action]string{
+	history.ActionCreate:  "created",
+	history.ActionUpdate:  "updated",
+	history.ActionDelete:  "deleted",
+	history.ActionRestore: "restored",
+}
+
+// Event is a change of an aggregate that other services may react to. ID is unique
+// per event so consumers can discard the duplicates of an at-least-once delivery.
+type Event struct {
+	ID            string
+	Type          string
+	AggregateType string
+	AggregateID   int
+	TenantID      int
+	ActorID       *int
+	OccurredAt    time.Time
+	Data          json.RawMessage
+}
+
+// Publisher delivers events to other services
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// TypeOf returns the type of the event raised when action is recorded on an aggregate
+func TypeOf(aggregateType string, action history.Action) string {
+	return aggregateType + "." + actionSuffixes[action]
+}
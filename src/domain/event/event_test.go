@@ -0,0 +1,25 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain/history"
+)
+
+func TestTypeOf(t *testing.T) {
+	cases := map[string]string{
+		TypeOf(AggregateMedicine, history.ActionCreate):  MedicineCreated,
+		TypeOf(AggregateMedicine, history.ActionUpdate):  MedicineUpdated,
+		TypeOf(AggregateMedicine, history.ActionDelete):  MedicineDeleted,
+		TypeOf(AggregateMedicine, history.ActionRestore): MedicineRestored,
+		TypeOf(AggregateUser, history.ActionCreate):      UserCreated,
+		TypeOf(AggregateUser, history.ActionUpdate):      UserUpdated,
+		TypeOf(AggregateUser, history.ActionDelete):      UserDeleted,
+		TypeOf(AggregateUser, history.ActionRestore):     UserRestored,
+	}
+	for actual, expected := range cases {
+		if actual != expected {
+			t.Errorf("Expected event type %s, got %s", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package history
+
+import "time"
+
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+)
+
+// FieldChange holds the value of a field before and after a change
+type FieldChange struct {
+	Before any
+	After  any
+}
+
+// Entry is a single recorded change of an entity
+type Entry struct {
+	ID        int
+	EntityID  int
+	Action    Action
+	Changes   map[string]FieldChange
+	ActorID   *int
+	CreatedAt time.Time
+}
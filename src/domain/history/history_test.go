@@ -0,0 +1,45 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActionConstants(t *testing.T) {
+	actions := map[Action]string{
+		ActionCreate:  "create",
+		ActionUpdate:  "update",
+		ActionDelete:  "delete",
+		ActionRestore: "restore",
+	}
+	for action, expected := range actions {
+		if string(action) != expected {
+			t.Errorf("Expected action %s, got %s", expected, action)
+		}
+	}
+}
+
+func TestEntry_Fields(t *testing.T) {
+	actorID := 7
+	now := time.Now()
+	entry := Entry{
+		ID:       1,
+		EntityID: 10,
+		Action:   ActionUpdate,
+		Changes: map[string]FieldChange{
+			"name": {Before: "Old", After: "New"},
+		},
+		ActorID:   &actorID,
+		CreatedAt: now,
+	}
+
+	if entry.Changes["name"].Before != "Old" || entry.Changes["name"].After != "New" {
+		t.Errorf("Unexpected change: %+v", entry.Changes["name"])
+	}
+	if *entry.ActorID != 7 {
+		t.Errorf("Expected actor 7, got %d", *entry.ActorID)
+	}
+	if !entry.CreatedAt.Equal(now) {
+		t.Errorf("Expected CreatedAt to be %v, got %v", now, entry.CreatedAt)
+	}
+}
@@ -0,0 +1,72 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+)
+
+// Job statuses. A pending job whose RunAt is in the future is scheduled.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// LeaseExpired is the LastError of a job failed because its last attempt outlived its
+// lease. The handler may still be at work, so the result of that attempt replaces it.
+const LeaseExpired = "the last attempt outlived its lease"
+
+// DefaultQueue runs the jobs of types registered without a queue
+const DefaultQueue = "default"
+
+// Job is a piece of slow work run in the background by the handler registered for
+// its Type. Payload holds the JSON arguments of the handler. A failed run is retried
+// until MaxAttempts runs have failed.
+type Job struct {
+	ID          int64
+	TenantID    int
+	Queue       string
+	Type        string
+	Payload     string
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LockedUntil *time.Time
+	LastError   string
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CreatedBy   *int
+	UpdatedBy   *int
+}
+
+// Result is the outcome of a run. A pending Status runs the job again at RunAt;
+// Released does not count the run as an attempt, for runs interrupted by a shutdown.
+type Result struct {
+	Status   string
+	At       time.Time
+	Error    string
+	RunAt    time.Time
+	Released bool
+}
+
+type SearchResultJob struct {
+	Data       *[]Job
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+type IJobService interface {
+	GetAll(ctx context.Context, filters domain.DataFilters) (*SearchResultJob, error)
+	GetByID(ctx context.Context, id int64) (*Job, error)
+	Retry(ctx context.Context, id int64) (*Job, error)
+	Cancel(ctx context.Context, id int64) (*Job, error)
+}
@@ -0,0 +1,120 @@
+package laboratory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+)
+
+// Laboratory is a manufacturer medicines are linked to. Its names are unique per
+// organization by their Key, so "Pfizer", "pfizer inc" and "PFIZER" are one laboratory.
+type Laboratory struct {
+	ID        int
+	TenantID  int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy *int
+	UpdatedBy *int
+}
+
+type SearchResultLaboratory struct {
+	Data       *[]Laboratory
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+type ILaboratoryService interface {
+	GetAll(ctx context.Context) (*[]Laboratory, error)
+	GetByID(ctx context.Context, id int) (*Laboratory, error)
+	Create(ctx context.Context, laboratory *Laboratory) (*Laboratory, error)
+	Update(ctx context.Context, id int, laboratoryMap map[string]any) (*Laboratory, error)
+	Delete(ctx context.Context, id int) error
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*SearchResultLaboratory, error)
+}
+
+// MaxNameLength is the size of the laboratory name column
+const MaxNameLength = 255
+
+// legalSuffixes are the company forms dropped from the end of a name by Key
+var legalSuffixes = [][]string{
+	{"sa", "de", "cv"},
+	{"inc"}, {"incorporated"}, {"corp"}, {"corporation"}, {"co"}, {"company"},
+	{"ltd"}, {"limited"}, {"llc"}, {"plc"}, {"gmbh"}, {"ag"}, {"sa"}, {"spa"}, {"bv"}, {"nv"},
+}
+
+// CleanName trims a laboratory name and collapses the spaces inside it
+func CleanName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// ValidateName checks a laboratory name cleaned by CleanName
+func ValidateName(name string) error {
+	switch {
+	case Key(name) == "":
+		return errors.New("name must contain letters or digits")
+	case len(name) > MaxNameLength:
+		return errors.New("name is too long")
+	}
+	return nil
+}
+
+// Key returns the form of a laboratory name that spellings of the same laboratory
+// share: lower case, without punctuation and without a trailing company form such as
+// "Inc." or "S.A. de C.V.". It is empty when name has no letters or digits.
+func Key(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, ".", ""))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, suffix := range legalSuffixes {
+			if len(words) > len(suffix) && slices.Equal(words[len(words)-len(suffix):], suffix) {
+				words = words[:len(words)-len(suffix)]
+				trimmed = true
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// PreferredName picks the name of a laboratory among the spellings found for it and
+// the number of times each is used: the most used wins, then one written in mixed
+// case, then the shortest, then the first in alphabetical order
+func PreferredName(spellings map[string]int) string {
+	names := make([]string, 0, len(spellings))
+	for name := range spellings {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if spellings[a] != spellings[b] {
+			return spellings[b] - spellings[a]
+		}
+		if mixedA, mixedB := isMixedCase(a), isMixedCase(b); mixedA != mixedB {
+			if mixedA {
+				return -1
+			}
+			return 1
+		}
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	})
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+func isMixedCase(name string) bool {
+	return name != strings.ToUpper(name) && name != strings.ToLower(name)
+}
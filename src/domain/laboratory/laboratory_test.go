@@ -0,0 +1,65 @@
+package laboratory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := map[string]string{
+		"Pfizer":                           "pfizer",
+		"pfizer inc":                       "pfizer",
+		"PFIZER":                           "pfizer",
+		"  Pfizer,   Inc. ":                "pfizer",
+		"Laboratorios Sophia S.A. de C.V.": "laboratorios sophia",
+		"Johnson & Johnson":                "johnson johnson",
+		"Bayer AG":                         "bayer",
+		"Inc":                              "inc",
+		"3M Company":                       "3m",
+		"...":                              "",
+	}
+	for name, want := range tests {
+		if got := Key(name); got != want {
+			t.Errorf("Key(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCleanName(t *testing.T) {
+	if got := CleanName("  Bayer   AG "); got != "Bayer AG" {
+		t.Errorf("CleanName() = %q, want %q", got, "Bayer AG")
+	}
+}
+
+func TestPreferredName(t *testing.T) {
+	tests := []struct {
+		name      string
+		spellings map[string]int
+		want      string
+	}{
+		{"most used", map[string]int{"PFIZER": 3, "Pfizer": 1, "pfizer inc": 1}, "PFIZER"},
+		{"mixed case on a tie", map[string]int{"PFIZER": 1, "Pfizer": 1, "pfizer inc": 1}, "Pfizer"},
+		{"shortest on a tie", map[string]int{"Pfizer Inc": 1, "Pfizer": 1}, "Pfizer"},
+		{"alphabetical on a tie", map[string]int{"Bayer": 1, "Bayor": 1}, "Bayer"},
+		{"none", map[string]int{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PreferredName(tt.spellings); got != tt.want {
+				t.Errorf("PreferredName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	if err := ValidateName("Bayer AG"); err != nil {
+		t.Errorf("ValidateName() error = %v", err)
+	}
+	if err := ValidateName("-"); err == nil {
+		t.Error("ValidateName() accepted a name without letters or digits")
+	}
+	if err := ValidateName(strings.Repeat("a", MaxNameLength+1)); err == nil {
+		t.Error("ValidateName() accepted a name longer than the column")
+	}
+}
@@ -0,0 +1,245 @@
+package medicine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Barcode is what a scanned code identifies: a trade item, and for GS1 element strings
+// such as those of a DataMatrix also the lot, serial number and expiry of the package
+type Barcode struct {
+	// GTIN is the GTIN-14 of the trade item, the form EAN codes are stored in
+	GTIN      string
+	Lot       string
+	Serial    string
+	ExpiresAt *time.Time
+}
+
+// groupSeparator ends a variable length element of a GS1 element string (FNC1)
+const groupSeparator = '\x1d'
+
+// symbologyIdentifiers are the prefixes scanners add to tell the symbology of a code
+var symbologyIdentifiers = []string{"]d2", "]C1", "]Q3", "]e0"}
+
+// application identifiers of a GS1 element string, by the length of their data; 0 is a
+// variable length, up to maxVariableLength characters
+const maxVariableLength = 20
+
+var applicationIdentifiers = map[string]int{
+	"01": 14, // GTIN
+	"10": 0,  // lot number
+	"11": 6,  // production date, ignored
+	"17": 6,  // expiry date
+	"21": 0,  // serial number
+}
+
+// ParseBarcode reads a scanned code: an EAN-8, UPC-A, EAN-13 or GTIN-14, or a GS1
+// element string carrying a GTIN, either raw as encoded in a DataMatrix, with group
+// separators after variable length elements, or in its human readable form with the
+// application identifiers in parentheses.
+func ParseBarcode(code string) (*Barcode, error) {
+	code = strings.TrimSpace(code)
+	for _, identifier := range symbologyIdentifiers {
+		code = strings.TrimPrefix(code, identifier)
+	}
+	if code == "" {
+		return nil, errors.New("barcode is empty")
+	}
+	if isDigits(code) && len(code) <= 14 {
+		gtin, err := NormalizeGTIN(code)
+		if err != nil {
+			return nil, err
+		}
+		return &Barcode{GTIN: gtin}, nil
+	}
+	elements, err := splitElements(code)
+	if err != nil {
+		return nil, err
+	}
+	barcode := &Barcode{}
+	for _, element := range elements {
+		switch element.ai {
+		case "01":
+			if barcode.GTIN, err = NormalizeGTIN(element.data); err != nil {
+				return nil, err
+			}
+		case "10":
+			barcode.Lot = element.data
+		case "21":
+			barcode.Serial = element.data
+		case "17":
+			expiresAt, err := parseGS1Date(element.data)
+			if err != nil {
+				return nil, err
+			}
+			barcode.ExpiresAt = &expiresAt
+		}
+	}
+	if barcode.GTIN == "" {
+		return nil, fmt.Errorf("barcode %q has no GTIN", code)
+	}
+	return barcode, nil
+}
+
+// NormalizeGTIN checks the check digit of an EAN-8, UPC-A, EAN-13 or GTIN-14 and
+// returns it as a GTIN-14, padded with leading zeros
+func NormalizeGTIN(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	switch {
+	case !isDigits(code):
+		return "", fmt.Errorf("GTIN %q must only have digits", code)
+	case len(code) != 8 && len(code) != 12 && len(code) != 13 && len(code) != 14:
+		return "", fmt.Errorf("GTIN %q must have 8, 12, 13 or 14 digits", code)
+	case checkDigit(code[:len(code)-1]) != code[len(code)-1]:
+		return "", fmt.Errorf("GTIN %q has a wrong check digit", code)
+	}
+	return strings.Repeat("0", 14-len(code)) + code, nil
+}
+
+// GTINForms returns the codes a GTIN-14 may have been stored as before EAN codes were
+// normalized: itself, and the EAN-13, UPC-A and EAN-8 its leading zeros pad
+func GTINForms(gtin string) []string {
+	forms := []string{gtin}
+	for _, length := range []int{13, 12, 8} {
+		padding := gtin[:len(gtin)-length]
+		if strings.Trim(padding, "0") != "" {
+			break
+		}
+		forms = append(forms, gtin[len(gtin)-length:])
+	}
+	return forms
+}
+
+// checkDigit returns the GS1 check digit of digits: their sum weighted 3 and 1
+// alternately from the right, subtracted from the next multiple of 10
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := range len(digits) {
+		digit := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+type element struct {
+	ai   string
+	data string
+}
+
+// splitElements splits a GS1 element string into its elements
+func splitElements(code string) ([]element, error) {
+	if strings.HasPrefix(code, "(") {
+		return splitHumanReadable(code)
+	}
+	code = strings.TrimPrefix(code, string(groupSeparator))
+	var elements []element
+	for code != "" {
+		if len(code) < 2 {
+			return nil, fmt.Errorf("barcode ends in an incomplete application identifier %q", code)
+		}
+		ai := code[:2]
+		length, ok := applicationIdentifiers[ai]
+		if !ok {
+			return nil, fmt.Errorf("barcode has an unsupported application identifier %q", ai)
+		}
+		code = code[2:]
+		var data string
+		if length > 0 {
+			if len(code) < length {
+				return nil, fmt.Errorf("application identifier %s needs %d characters", ai, length)
+			}
+			data, code = code[:length], code[length:]
+		} else if end := strings.IndexByte(code, groupSeparator); end >= 0 {
+			data, code = code[:end], code[end:]
+		} else {
+			data, code = code, ""
+		}
+		code = strings.TrimPrefix(code, string(groupSeparator))
+		if err := checkElement(ai, data, length); err != nil {
+			return nil, err
+		}
+		elements = append(elements, element{ai: ai, data: data})
+	}
+	return elements, nil
+}
+
+// splitHumanReadable splits an element string such as (01)09506000134352(10)ABC1
+func splitHumanReadable(code string) ([]element, error) {
+	var elements []element
+	for code != "" {
+		end := strings.IndexByte(code, ')')
+		if !strings.HasPrefix(code, "(") || end < 0 {
+			return nil, fmt.Errorf("barcode %q is not a GS1 element string", code)
+		}
+		ai := code[1:end]
+		length, ok := applicationIdentifiers[ai]
+		if !ok {
+			return nil, fmt.Errorf("barcode has an unsupported application identifier %q", ai)
+		}
+		code = code[end+1:]
+		next := strings.IndexByte(code, '(')
+		if next < 0 {
+			next = len(code)
+		}
+		data := code[:next]
+		code = code[next:]
+		if err := checkElement(ai, data, length); err != nil {
+			return nil, err
+		}
+		elements = append(elements, element{ai: ai, data: data})
+	}
+	return elements, nil
+}
+
+func checkElement(ai, data string, length int) error {
+	switch {
+	case length > 0 && (len(data) != length || !isDigits(data)):
+		return fmt.Errorf("application identifier %s needs %d digits, got %q", ai, length, data)
+	case length == 0 && (data == "" || len(data) > maxVariableLength):
+		return fmt.Errorf("application identifier %s needs 1 to %d characters, got %q", ai, maxVariableLength, data)
+	}
+	return nil
+}
+
+// parseGS1Date parses a YYMMDD date of this century. A day of 00 stands for the last
+// day of the month.
+func parseGS1Date(value string) (time.Time, error) {
+	year, month, day := 2000+atoi(value[:2]), time.Month(atoi(value[2:4])), atoi(value[4:])
+	if month < 1 || month > 12 || day > 31 {
+		return time.Time{}, fmt.Errorf("expiry date %q is not a YYMMDD date", value)
+	}
+	if day == 0 {
+		return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC), nil
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return time.Time{}, fmt.Errorf("expiry date %q is not a YYMMDD date", value)
+	}
+	return date, nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// atoi converts a string checked by isDigits
+func atoi(digits string) int {
+	n := 0
+	for _, r := range digits {
+		n = n*10 + int(r-'0')
+	}
+	return n
+}
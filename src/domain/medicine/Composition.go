@@ -0,0 +1,153 @@
+package medicine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Ingredient is an active ingredient of a medicine with its strength in it, per dose
+// unit of the dosage form (500 mg per tablet) or per basis of the unit (250 mg/5 mL is
+// 50 mg/mL). Medicines containing the same compound share the ingredient ID.
+type Ingredient struct {
+	ID       int
+	Name     string
+	Strength float64
+	Unit     string
+}
+
+// PackageSize is the quantity in one package: a number of dose units such as tablets
+// or ampoules, or the volume or mass of a bottle or tube
+type PackageSize struct {
+	Quantity float64
+	Unit     string
+}
+
+// MaxIngredientNameLength is the size of the ingredient name column
+const MaxIngredientNameLength = 255
+
+// DosageForms are the pharmaceutical forms a medicine is made in
+var DosageForms = []string{
+	"tablet", "capsule", "lozenge", "powder", "granules", "syrup", "solution", "suspension",
+	"emulsion", "drops", "injection", "infusion", "cream", "ointment", "gel", "lotion",
+	"patch", "spray", "inhaler", "suppository", "pessary",
+}
+
+// Routes are the routes of administration of a medicine
+var Routes = []string{
+	"oral", "sublingual", "buccal", "intravenous", "intramuscular", "subcutaneous",
+	"intradermal", "topical", "transdermal", "ophthalmic", "otic", "nasal", "inhalation",
+	"rectal", "vaginal",
+}
+
+// amountUnits are the units an amount of ingredient is given in, by their lower-case
+// spellings
+var amountUnits = map[string]string{
+	"mcg": "mcg", "µg": "mcg", "μg": "mcg", "ug": "mcg",
+	"mg": "mg", "g": "g", "mmol": "mmol",
+	"iu": "IU", "ui": "IU",
+	"ml": "mL", "l": "L", "%": "%",
+}
+
+// basisUnits are the units a strength may be given per, as in mg/mL
+var basisUnits = map[string]string{"ml": "mL", "l": "L", "g": "g", "dose": "dose"}
+
+// packageUnits are the units a package size is given in, by their lower-case spellings;
+// a unit is one dose unit of the dosage form, such as a tablet
+var packageUnits = map[string]string{
+	"unit": "unit", "units": "unit",
+	"dose": "dose", "doses": "dose",
+	"ml": "mL", "l": "L", "g": "g",
+}
+
+// IngredientKey returns the form of an ingredient name its spellings share
+func IngredientKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// ParseStrengthUnit returns the canonical spelling of the unit of a strength: an amount
+// unit (mcg, mg, g, IU, mmol, mL, L or %) optionally per a basis (mL, L, g or dose)
+func ParseStrengthUnit(unit string) (string, error) {
+	amount, basis, perBasis := strings.Cut(strings.ToLower(strings.ReplaceAll(unit, " ", "")), "/")
+	canonical, ok := amountUnits[amount]
+	if !ok {
+		return "", fmt.Errorf("unit %q is not one of mcg, mg, g, IU, mmol, mL, L or %%, optionally per mL, L, g or dose", unit)
+	}
+	if !perBasis {
+		return canonical, nil
+	}
+	canonicalBasis, ok := basisUnits[basis]
+	if !ok || canonical == "%" {
+		return "", fmt.Errorf("unit %q is not one of mcg, mg, g, IU, mmol, mL, L or %%, optionally per mL, L, g or dose", unit)
+	}
+	return canonical + "/" + canonicalBasis, nil
+}
+
+// NormalizeIngredients returns ingredients with their names tidied and their units
+// canonical, checking that each is named once and has a positive strength
+func NormalizeIngredients(ingredients []Ingredient) ([]Ingredient, error) {
+	normalized := make([]Ingredient, len(ingredients))
+	seen := make(map[string]bool, len(ingredients))
+	for i, ingredient := range ingredients {
+		name := strings.Join(strings.Fields(ingredient.Name), " ")
+		switch {
+		case name == "":
+			return nil, errors.New("ingredient name is required")
+		case len(name) > MaxIngredientNameLength:
+			return nil, fmt.Errorf("ingredient %q has a name that is too long", name)
+		case seen[IngredientKey(name)]:
+			return nil, fmt.Errorf("ingredient %q is listed more than once", name)
+		case !isPositive(ingredient.Strength):
+			return nil, fmt.Errorf("ingredient %q must have a positive strength", name)
+		}
+		unit, err := ParseStrengthUnit(ingredient.Unit)
+		if err != nil {
+			return nil, fmt.Errorf("ingredient %q: %w", name, err)
+		}
+		seen[IngredientKey(name)] = true
+		normalized[i] = Ingredient{ID: ingredient.ID, Name: name, Strength: ingredient.Strength, Unit: unit}
+	}
+	return normalized, nil
+}
+
+// NormalizePackageSize returns size with its unit canonical, checking its quantity is
+// positive; nil stands for an unknown size
+func NormalizePackageSize(size *PackageSize) (*PackageSize, error) {
+	if size == nil {
+		return nil, nil
+	}
+	if !isPositive(size.Quantity) {
+		return nil, errors.New("package size must have a positive quantity")
+	}
+	unit, ok := packageUnits[strings.ToLower(strings.TrimSpace(size.Unit))]
+	if !ok {
+		return nil, fmt.Errorf("package unit %q is not one of unit, dose, mL, L or g", size.Unit)
+	}
+	return &PackageSize{Quantity: size.Quantity, Unit: unit}, nil
+}
+
+// NormalizeDosageForm returns the listed spelling of a dosage form; empty stands for
+// an unknown form
+func NormalizeDosageForm(form string) (string, error) {
+	return normalizeListed("dosage form", form, DosageForms)
+}
+
+// NormalizeRoute returns the listed spelling of a route of administration; empty
+// stands for an unknown route
+func NormalizeRoute(route string) (string, error) {
+	return normalizeListed("route", route, Routes)
+}
+
+func normalizeListed(kind, value string, listed []string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value != "" && !slices.Contains(listed, value) {
+		return "", fmt.Errorf("%s %q is not one of %s", kind, value, strings.Join(listed, ", "))
+	}
+	return value, nil
+}
+
+func isPositive(number float64) bool {
+	return number > 0 && !math.IsInf(number, 1)
+}
@@ -0,0 +1,24 @@
+package medicine
+
+// Events raised in process by the medicine use case once an operation succeeded
+
+// Created is raised after a medicine was created
+type Created struct {
+	Medicine Medicine
+}
+
+// Updated is raised after a medicine was updated; Fields names the updated fields
+type Updated struct {
+	Medicine Medicine
+	Fields   []string
+}
+
+// Deleted is raised after a medicine was moved to the trash
+type Deleted struct {
+	ID int
+}
+
+// Restored is raised after a medicine was restored from the trash
+type Restored struct {
+	Medicine Medicine
+}
@@ -0,0 +1,262 @@
+package medicine
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Severity is how serious an interaction between two medicines is
+type Severity string
+
+const (
+	SeverityMinor           Severity = "minor"
+	SeverityModerate        Severity = "moderate"
+	SeverityMajor           Severity = "major"
+	SeverityContraindicated Severity = "contraindicated"
+)
+
+// Severities are the severities of an interaction, from the least serious
+var Severities = []Severity{SeverityMinor, SeverityModerate, SeverityMajor, SeverityContraindicated}
+
+func (s Severity) IsValid() bool {
+	return slices.Contains(Severities, s)
+}
+
+// ClassPrefix marks the name of a class of ingredients in an interaction, as in
+// class:NSAIDs
+const ClassPrefix = "class:"
+
+// MaxInteractionDescriptionLength is the size of the interaction description column
+const MaxInteractionDescriptionLength = 1000
+
+// InteractionSubject is a side of an interaction: an active ingredient or, when Class
+// is set, every ingredient of a class
+type InteractionSubject struct {
+	Name  string
+	Class bool
+}
+
+// ParseInteractionSubject reads the name of an ingredient, or of a class when it has
+// ClassPrefix
+func ParseInteractionSubject(text string) (InteractionSubject, error) {
+	name := strings.TrimSpace(text)
+	class := len(name) >= len(ClassPrefix) && strings.EqualFold(name[:len(ClassPrefix)], ClassPrefix)
+	if class {
+		name = name[len(ClassPrefix):]
+	}
+	name = strings.Join(strings.Fields(name), " ")
+	switch {
+	case name == "":
+		return InteractionSubject{}, errors.New("interacting ingredient or class is required")
+	case len(name) > MaxIngredientNameLength:
+		return InteractionSubject{}, fmt.Errorf("%q has a name that is too long", name)
+	}
+	return InteractionSubject{Name: name, Class: class}, nil
+}
+
+// Key returns the form of the subject its spellings share
+func (s InteractionSubject) Key() string {
+	return IngredientKey(s.Name)
+}
+
+func (s InteractionSubject) String() string {
+	if s.Class {
+		return ClassPrefix + s.Name
+	}
+	return s.Name
+}
+
+// Interaction is an entry of the knowledge base: medicines with First taken with
+// medicines with Second may cause what Description tells. The subjects are kept in
+// order, so a pair has one interaction whichever way it is written.
+type Interaction struct {
+	ID          int
+	TenantID    int
+	First       InteractionSubject
+	Second      InteractionSubject
+	Severity    Severity
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CreatedBy   *int
+	UpdatedBy   *int
+}
+
+// NewInteraction returns the interaction of two subjects, as ParseInteractionSubject
+// reads them; a subject may interact with itself, as two NSAIDs do
+func NewInteraction(first, second, severity, description string) (*Interaction, error) {
+	firstSubject, err := ParseInteractionSubject(first)
+	if err != nil {
+		return nil, err
+	}
+	secondSubject, err := ParseInteractionSubject(second)
+	if err != nil {
+		return nil, err
+	}
+	interaction := &Interaction{
+		First:       firstSubject,
+		Second:      secondSubject,
+		Severity:    Severity(strings.ToLower(strings.TrimSpace(severity))),
+		Description: strings.TrimSpace(description),
+	}
+	switch {
+	case !interaction.Severity.IsValid():
+		return nil, fmt.Errorf("severity %q is not one of minor, moderate, major or contraindicated", severity)
+	case len(interaction.Description) > MaxInteractionDescriptionLength:
+		return nil, errors.New("description is too long")
+	}
+	if compareSubjects(interaction.Second, interaction.First) < 0 {
+		interaction.First, interaction.Second = interaction.Second, interaction.First
+	}
+	return interaction, nil
+}
+
+// IngredientClass makes an active ingredient a member of a class, so interactions of
+// the class apply to the medicines containing it
+type IngredientClass struct {
+	ID         int
+	TenantID   int
+	Class      string
+	Ingredient string
+	CreatedAt  time.Time
+	CreatedBy  *int
+}
+
+// NewIngredientClass returns the membership of an ingredient in a class, which may be
+// named with or without ClassPrefix
+func NewIngredientClass(class, ingredient string) (*IngredientClass, error) {
+	classSubject, err := ParseInteractionSubject(class)
+	if err != nil {
+		return nil, err
+	}
+	ingredientSubject, err := ParseInteractionSubject(ingredient)
+	if err != nil {
+		return nil, err
+	}
+	if ingredientSubject.Class {
+		return nil, fmt.Errorf("%q is a class, not an ingredient", ingredientSubject.Name)
+	}
+	return &IngredientClass{Class: classSubject.Name, Ingredient: ingredientSubject.Name}, nil
+}
+
+// InteractionParty is a medicine taking part in an interaction, with its ingredients
+// matching the subject of the interaction
+type InteractionParty struct {
+	MedicineID  int
+	Medicine    string
+	Ingredients []string
+}
+
+// InteractionWarning is an interaction found between two medicines; First has the
+// first subject of the interaction and Second the second one
+type InteractionWarning struct {
+	Interaction Interaction
+	First       InteractionParty
+	Second      InteractionParty
+}
+
+// FindInteractions returns the interactions between every two of medicines, the most
+// serious first. classes are the memberships of their ingredients; an interaction
+// within the ingredients of a single medicine is not reported.
+func FindInteractions(medicines []Medicine, classes []IngredientClass, interactions []Interaction) []InteractionWarning {
+	ingredientClasses := make(map[string][]string)
+	for _, class := range classes {
+		key := IngredientKey(class.Ingredient)
+		ingredientClasses[key] = append(ingredientClasses[key], IngredientKey(class.Class))
+	}
+	matches := make([]map[InteractionSubject][]string, len(medicines))
+	for i, medicine := range medicines {
+		matches[i] = subjectsOf(&medicine, ingredientClasses)
+	}
+
+	warnings := []InteractionWarning{}
+	for i := range medicines {
+		for j := i + 1; j < len(medicines); j++ {
+			for _, interaction := range interactions {
+				first, second := interaction.First.keyed(), interaction.Second.keyed()
+				switch {
+				case matches[i][first] != nil && matches[j][second] != nil:
+					warnings = append(warnings, InteractionWarning{
+						Interaction: interaction,
+						First:       party(&medicines[i], matches[i][first]),
+						Second:      party(&medicines[j], matches[j][second]),
+					})
+				case matches[j][first] != nil && matches[i][second] != nil:
+					warnings = append(warnings, InteractionWarning{
+						Interaction: interaction,
+						First:       party(&medicines[j], matches[j][first]),
+						Second:      party(&medicines[i], matches[i][second]),
+					})
+				}
+			}
+		}
+	}
+	slices.SortStableFunc(warnings, func(a, b InteractionWarning) int {
+		return cmp.Compare(slices.Index(Severities, b.Interaction.Severity), slices.Index(Severities, a.Interaction.Severity))
+	})
+	return warnings
+}
+
+// InteractionSubjects returns the subjects the medicines may interact through: their
+// ingredients and the classes listed for them
+func InteractionSubjects(medicines []Medicine, classes []IngredientClass) []InteractionSubject {
+	subjects := []InteractionSubject{}
+	seen := make(map[InteractionSubject]bool)
+	add := func(subject InteractionSubject) {
+		if !seen[subject.keyed()] {
+			seen[subject.keyed()] = true
+			subjects = append(subjects, subject)
+		}
+	}
+	for _, medicine := range medicines {
+		for _, ingredient := range medicine.Ingredients {
+			add(InteractionSubject{Name: ingredient.Name})
+		}
+	}
+	for _, class := range classes {
+		add(InteractionSubject{Name: class.Class, Class: true})
+	}
+	return subjects
+}
+
+// subjectsOf maps the subjects a medicine contains, by their keyed form, to the names
+// of its ingredients matching them
+func subjectsOf(medicine *Medicine, ingredientClasses map[string][]string) map[InteractionSubject][]string {
+	subjects := make(map[InteractionSubject][]string)
+	for _, ingredient := range medicine.Ingredients {
+		key := IngredientKey(ingredient.Name)
+		subject := InteractionSubject{Name: key}
+		subjects[subject] = append(subjects[subject], ingredient.Name)
+		for _, class := range ingredientClasses[key] {
+			subject := InteractionSubject{Name: class, Class: true}
+			if !slices.Contains(subjects[subject], ingredient.Name) {
+				subjects[subject] = append(subjects[subject], ingredient.Name)
+			}
+		}
+	}
+	return subjects
+}
+
+func party(medicine *Medicine, ingredients []string) InteractionParty {
+	return InteractionParty{MedicineID: medicine.ID, Medicine: medicine.Name, Ingredients: ingredients}
+}
+
+// keyed returns the subject with its name in the form its spellings share
+func (s InteractionSubject) keyed() InteractionSubject {
+	return InteractionSubject{Name: s.Key(), Class: s.Class}
+}
+
+// compareSubjects orders ingredients before classes, then by name
+func compareSubjects(a, b InteractionSubject) int {
+	if a.Class != b.Class {
+		if a.Class {
+			return 1
+		}
+		return -1
+	}
+	return strings.Compare(a.Key(), b.Key())
+}
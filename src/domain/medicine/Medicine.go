@@ -1,19 +1,37 @@
 package medicine
 
 import (
+	"context"
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
+	"github.com/gbrayhan/microservices-go/src/domain/history"
 )
 
 type Medicine struct {
 	ID          int
+	TenantID    int
 	Name        string
 	Description string
 	EanCode     string
-	Laboratory  string
+	// LaboratoryID links the medicine to its laboratory; Laboratory is that laboratory's name
+	LaboratoryID *int
+	Laboratory   string
+	// Ingredients are the active ingredients of the medicine. DosageForm and Route are
+	// empty, and PackageSize nil, while unknown. Price is the price of one package, nil
+	// while unknown.
+	Ingredients []Ingredient
+	DosageForm  string
+	Route       string
+	PackageSize *PackageSize
+	Price       *float64
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+	CreatedBy   *int
+	UpdatedBy   *int
+	// StockQuantity is the number of units on hand over all locations, set by listings only
+	StockQuantity *int
 }
 
 type DataMedicine struct {
@@ -30,11 +48,24 @@ type SearchResultMedicine struct {
 }
 
 type IMedicineService interface {
-	GetAll() (*[]Medicine, error)
-	GetByID(id int) (*Medicine, error)
-	Create(medicine *Medicine) (*Medicine, error)
-	Delete(id int) error
-	Update(id int, medicineMap map[string]any) (*Medicine, error)
-	SearchPaginated(filters domain.DataFilters) (*SearchResultMedicine, error)
-	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetAll(ctx context.Context) (*[]Medicine, error)
+	GetByID(ctx context.Context, id int) (*Medicine, error)
+	GetByBarcode(ctx context.Context, code string) (*Medicine, *Barcode, error)
+	Create(ctx context.Context, medicine *Medicine) (*Medicine, error)
+	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, medicineMap map[string]any) (*Medicine, error)
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*SearchResultMedicine, error)
+	SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error)
+	GetTrash(ctx context.Context, filters domain.DataFilters) (*SearchResultMedicine, error)
+	Restore(ctx context.Context, id int) (*Medicine, error)
+	Purge(ctx context.Context, olderThan time.Time) (int64, error)
+	GetHistory(ctx context.Context, id int) (*[]history.Entry, error)
+	GetAsOf(ctx context.Context, id int, at time.Time) (*Medicine, error)
+	GetSubstitutes(ctx context.Context, id int) (*[]Substitute, error)
+	GetEquivalences(ctx context.Context, id int) (*[]Equivalence, error)
+	SetEquivalence(ctx context.Context, equivalence *Equivalence) (*Equivalence, error)
+	DeleteEquivalence(ctx context.Context, id int) error
+	ImportInteractions(ctx context.Context, interactions []Interaction) (int, error)
+	ImportIngredientClasses(ctx context.Context, classes []IngredientClass) (int, error)
+	CheckInteractions(ctx context.Context, ids []int) (*[]InteractionWarning, error)
 }
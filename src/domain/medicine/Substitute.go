@@ -0,0 +1,202 @@
+package medicine
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EquivalenceKind is how a manual equivalence overrides the substitutes of a medicine
+type EquivalenceKind string
+
+const (
+	// EquivalenceMarked makes a medicine a substitute even though its composition
+	// differs, as for a therapeutic alternative
+	EquivalenceMarked EquivalenceKind = "equivalent"
+	// EquivalenceExcluded keeps an equivalent product out of the substitutes
+	EquivalenceExcluded EquivalenceKind = "excluded"
+)
+
+func (k EquivalenceKind) IsValid() bool {
+	return k == EquivalenceMarked || k == EquivalenceExcluded
+}
+
+// Equivalence marks SubstituteID as a substitute of MedicineID, or excludes it. It
+// applies one way only: the reverse substitution needs an equivalence of its own.
+type Equivalence struct {
+	ID           int
+	TenantID     int
+	MedicineID   int
+	SubstituteID int
+	Kind         EquivalenceKind
+	Note         string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CreatedBy    *int
+	UpdatedBy    *int
+}
+
+// SubstituteMatch is why a medicine is a substitute of another
+type SubstituteMatch string
+
+const (
+	// SubstituteGeneric is a product with the same ingredients, strengths and form
+	SubstituteGeneric SubstituteMatch = "generic"
+	// SubstituteManual is a medicine marked as equivalent by an equivalence
+	SubstituteManual SubstituteMatch = "manual"
+)
+
+// Substitute is a medicine that can be dispensed instead of another; Note is the note
+// of the equivalence that marked it
+type Substitute struct {
+	Medicine Medicine
+	Match    SubstituteMatch
+	Note     string
+}
+
+// strengthUnits convert the amount and basis of a strength to one unit per dimension,
+// so 0.5 g and 500 mg, or 50 mg/mL and 50 g/L, are the same strength
+var strengthUnits = map[string]struct {
+	unit   string
+	factor float64
+}{
+	"mcg": {"mg", 0.001},
+	"g":   {"mg", 1000},
+	"L":   {"mL", 1000},
+}
+
+// packageUnitConversions convert a package size to one unit per dimension, as
+// strengthUnits do for strengths, so a 1 L bottle is priced per mL like a 100 mL one
+var packageUnitConversions = map[string]struct {
+	unit   string
+	factor float64
+}{
+	"L": {"mL", 1000},
+}
+
+// ValidatePrice checks the price of a package is not negative; nil stands for an
+// unknown price
+func ValidatePrice(price *float64) error {
+	if price != nil && (!(*price >= 0) || math.IsInf(*price, 1)) {
+		return errors.New("price must be a number that is not negative")
+	}
+	return nil
+}
+
+// UnitPrice returns the price of one unit of the package, such as a tablet or a mL, or
+// nil when the price or the package size is unknown. Packages sized in L are priced
+// per mL.
+func (m *Medicine) UnitPrice() *float64 {
+	price, _ := m.unitPrice()
+	return price
+}
+
+// unitPrice returns UnitPrice and the unit it is the price of
+func (m *Medicine) unitPrice() (*float64, string) {
+	if m.Price == nil || m.PackageSize == nil {
+		return nil, ""
+	}
+	quantity, unit := m.PackageSize.Quantity, m.PackageSize.Unit
+	if converted, ok := packageUnitConversions[unit]; ok {
+		quantity, unit = quantity*converted.factor, converted.unit
+	}
+	price := *m.Price / quantity
+	return &price, unit
+}
+
+// Equivalent reports whether two medicines have the same active ingredients at the
+// same strengths in the same dosage form. Medicines whose form or ingredients are
+// unknown are equivalent to none.
+func Equivalent(a, b *Medicine) bool {
+	if a.DosageForm == "" || a.DosageForm != b.DosageForm ||
+		len(a.Ingredients) == 0 || len(a.Ingredients) != len(b.Ingredients) {
+		return false
+	}
+	strengths := make(map[string]Ingredient, len(a.Ingredients))
+	for _, ingredient := range a.Ingredients {
+		strengths[IngredientKey(ingredient.Name)] = ingredient
+	}
+	for _, ingredient := range b.Ingredients {
+		other, ok := strengths[IngredientKey(ingredient.Name)]
+		if !ok || !sameStrength(ingredient, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// RankSubstitutes orders substitutes by availability and then price: those in stock
+// first, the cheapest first, then those with more units on hand. Prices are compared
+// per unit when both packages are sized in the same unit, and per package otherwise.
+func RankSubstitutes(substitutes []Substitute) {
+	slices.SortStableFunc(substitutes, func(a, b Substitute) int {
+		if inStock(&a.Medicine) != inStock(&b.Medicine) {
+			if inStock(&a.Medicine) {
+				return -1
+			}
+			return 1
+		}
+		unitPriceA, unitA := a.Medicine.unitPrice()
+		unitPriceB, unitB := b.Medicine.unitPrice()
+		if unitA == unitB {
+			if result := comparePrices(unitPriceA, unitPriceB); result != 0 {
+				return result
+			}
+		}
+		if result := comparePrices(a.Medicine.Price, b.Medicine.Price); result != 0 {
+			return result
+		}
+		if result := cmp.Compare(onHand(&b.Medicine), onHand(&a.Medicine)); result != 0 {
+			return result
+		}
+		return strings.Compare(a.Medicine.Name, b.Medicine.Name)
+	})
+}
+
+func sameStrength(a, b Ingredient) bool {
+	strengthA, unitA := comparableStrength(a)
+	strengthB, unitB := comparableStrength(b)
+	return unitA == unitB && math.Abs(strengthA-strengthB) <= 1e-9*math.Max(strengthA, strengthB)
+}
+
+func comparableStrength(ingredient Ingredient) (float64, string) {
+	strength := ingredient.Strength
+	amount, basis, perBasis := strings.Cut(ingredient.Unit, "/")
+	if converted, ok := strengthUnits[amount]; ok {
+		amount, strength = converted.unit, strength*converted.factor
+	}
+	if !perBasis {
+		return strength, amount
+	}
+	if converted, ok := strengthUnits[basis]; ok {
+		basis, strength = converted.unit, strength/converted.factor
+	}
+	return strength, amount + "/" + basis
+}
+
+// comparePrices orders known prices from the cheapest, before unknown ones
+func comparePrices(a, b *float64) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return cmp.Compare(*a, *b)
+}
+
+func inStock(medicine *Medicine) bool {
+	return onHand(medicine) > 0
+}
+
+func onHand(medicine *Medicine) int {
+	if medicine.StockQuantity == nil {
+		return 0
+	}
+	return *medicine.StockQuantity
+}
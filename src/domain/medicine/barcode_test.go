@@ -0,0 +1,88 @@
+package medicine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeGTIN(t *testing.T) {
+	valid := map[string]string{
+		"96385074":       "00000096385074", // EAN-8
+		"036000291452":   "00036000291452", // UPC-A
+		"4006381333931":  "04006381333931", // EAN-13
+		"09506000134352": "09506000134352", // GTIN-14
+		" 4006381333931": "04006381333931",
+	}
+	for code, expected := range valid {
+		gtin, err := NormalizeGTIN(code)
+		if err != nil {
+			t.Errorf("NormalizeGTIN(%q): unexpected error %v", code, err)
+		} else if gtin != expected {
+			t.Errorf("NormalizeGTIN(%q) = %q, expected %q", code, gtin, expected)
+		}
+	}
+	for _, code := range []string{"4006381333932", "400638133393", "40063813339A1", "", "7501"} {
+		if _, err := NormalizeGTIN(code); err == nil {
+			t.Errorf("NormalizeGTIN(%q): expected an error", code)
+		}
+	}
+}
+
+func TestGTINForms(t *testing.T) {
+	forms := GTINForms("00000096385074")
+	expected := []string{"00000096385074", "0000096385074", "000096385074", "96385074"}
+	if len(forms) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, forms)
+	}
+	for i := range forms {
+		if forms[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, forms)
+		}
+	}
+	if forms := GTINForms("04006381333931"); len(forms) != 2 || forms[1] != "4006381333931" {
+		t.Errorf("expected the GTIN-14 and its EAN-13, got %v", forms)
+	}
+}
+
+func TestParseBarcode(t *testing.T) {
+	expiresAt := time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC)
+	for name, code := range map[string]string{
+		"raw DataMatrix":        "]d201095060001343521727123110ABC123\x1d21SN42",
+		"group separator first": "\x1d01095060001343521727120010ABC123\x1d21SN42",
+		"human readable":        "(01)09506000134352(17)271231(10)ABC123(21)SN42",
+	} {
+		t.Run(name, func(t *testing.T) {
+			barcode, err := ParseBarcode(code)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if barcode.GTIN != "09506000134352" || barcode.Lot != "ABC123" || barcode.Serial != "SN42" {
+				t.Errorf("unexpected barcode %+v", barcode)
+			}
+			if barcode.ExpiresAt == nil || !barcode.ExpiresAt.Equal(expiresAt) {
+				t.Errorf("expected expiry %v, got %v", expiresAt, barcode.ExpiresAt)
+			}
+		})
+	}
+
+	barcode, err := ParseBarcode("4006381333931")
+	if err != nil || barcode.GTIN != "04006381333931" || barcode.Lot != "" {
+		t.Errorf("expected a plain EAN-13, got %+v, %v", barcode, err)
+	}
+
+	for name, code := range map[string]string{
+		"wrong check digit":   "(01)09506000134353(10)ABC",
+		"no GTIN":             "(10)ABC123(17)271231",
+		"unsupported AI":      "(01)09506000134352(99)X",
+		"invalid expiry":      "(01)09506000134352(17)271331",
+		"truncated GTIN":      "010950600013",
+		"lot too long":        "(01)09506000134352(10)ABCDEFGHIJKLMNOPQRSTU",
+		"not an element list": "ABC-EAN",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseBarcode(code); err == nil {
+				t.Errorf("ParseBarcode(%q): expected an error", code)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package medicine
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseStrengthUnit(t *testing.T) {
+	valid := map[string]string{
+		"mg":       "mg",
+		"MG":       "mg",
+		"µg":       "mcg",
+		"ug":       "mcg",
+		"iu":       "IU",
+		"ml":       "mL",
+		"%":        "%",
+		"mg/ml":    "mg/mL",
+		"IU / mL":  "IU/mL",
+		"mcg/dose": "mcg/dose",
+	}
+	for unit, expected := range valid {
+		canonical, err := ParseStrengthUnit(unit)
+		if err != nil || canonical != expected {
+			t.Errorf("expected %q for %q, got %q, %v", expected, unit, canonical, err)
+		}
+	}
+	for _, unit := range []string{"", "kg", "tablet", "mg/", "mg/tablet", "%/mL", "mg/mL/h"} {
+		if _, err := ParseStrengthUnit(unit); err == nil {
+			t.Errorf("expected an error for %q", unit)
+		}
+	}
+}
+
+func TestNormalizeIngredients(t *testing.T) {
+	normalized, err := NormalizeIngredients([]Ingredient{
+		{Name: "  Amoxicillin ", Strength: 500, Unit: "MG"},
+		{Name: "clavulanic   acid", Strength: 125, Unit: "mg"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Ingredient{
+		{Name: "Amoxicillin", Strength: 500, Unit: "mg"},
+		{Name: "clavulanic acid", Strength: 125, Unit: "mg"},
+	}
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Errorf("expected %+v, got %+v", expected, normalized)
+	}
+
+	invalid := [][]Ingredient{
+		{{Name: " ", Strength: 1, Unit: "mg"}},
+		{{Name: "Ibuprofen", Strength: 0, Unit: "mg"}},
+		{{Name: "Ibuprofen", Strength: math.NaN(), Unit: "mg"}},
+		{{Name: "Ibuprofen", Strength: math.Inf(1), Unit: "mg"}},
+		{{Name: "Ibuprofen", Strength: 200, Unit: "tablets"}},
+		{{Name: "Ibuprofen", Strength: 200, Unit: "mg"}, {Name: "IBUPROFEN", Strength: 400, Unit: "mg"}},
+	}
+	for _, ingredients := range invalid {
+		if _, err := NormalizeIngredients(ingredients); err == nil {
+			t.Errorf("expected an error for %+v", ingredients)
+		}
+	}
+}
+
+func TestNormalizePackageSize(t *testing.T) {
+	size, err := NormalizePackageSize(&PackageSize{Quantity: 100, Unit: "ml"})
+	if err != nil || *size != (PackageSize{Quantity: 100, Unit: "mL"}) {
+		t.Errorf("expected 100 mL, got %+v, %v", size, err)
+	}
+	if size, err := NormalizePackageSize(nil); size != nil || err != nil {
+		t.Errorf("expected no size, got %+v, %v", size, err)
+	}
+	for _, invalid := range []PackageSize{{Quantity: 0, Unit: "unit"}, {Quantity: 30, Unit: "boxes"}} {
+		if _, err := NormalizePackageSize(&invalid); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
+
+func TestNormalizeDosageFormAndRoute(t *testing.T) {
+	if form, err := NormalizeDosageForm(" Tablet "); err != nil || form != "tablet" {
+		t.Errorf("expected tablet, got %q, %v", form, err)
+	}
+	if form, err := NormalizeDosageForm(""); err != nil || form != "" {
+		t.Errorf("expected an unknown form, got %q, %v", form, err)
+	}
+	if _, err := NormalizeDosageForm("elixir of life"); err == nil {
+		t.Error("expected an error for an unknown dosage form")
+	}
+	if route, err := NormalizeRoute("ORAL"); err != nil || route != "oral" {
+		t.Errorf("expected oral, got %q, %v", route, err)
+	}
+	if _, err := NormalizeRoute("by mouth"); err == nil {
+		t.Error("expected an error for an unknown route")
+	}
+}
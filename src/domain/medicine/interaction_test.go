@@ -0,0 +1,114 @@
+package medicine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewInteraction(t *testing.T) {
+	interaction, err := NewInteraction(" Class: NSAIDs ", "warfarin", "MAJOR", " Increases the risk of bleeding ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Interaction{
+		First:       InteractionSubject{Name: "warfarin"},
+		Second:      InteractionSubject{Name: "NSAIDs", Class: true},
+		Severity:    SeverityMajor,
+		Description: "Increases the risk of bleeding",
+	}
+	if *interaction != expected {
+		t.Errorf("expected %+v, got %+v", expected, *interaction)
+	}
+	if interaction.Second.String() != "class:NSAIDs" {
+		t.Errorf("expected the class to be written with its prefix, got %q", interaction.Second.String())
+	}
+
+	invalid := map[string][4]string{
+		"an empty ingredient":  {"", "warfarin", "major", ""},
+		"an empty class":       {"class: ", "warfarin", "major", ""},
+		"an unknown severity":  {"aspirin", "warfarin", "severe", ""},
+		"a long description":   {"aspirin", "warfarin", "major", strings.Repeat("a", MaxInteractionDescriptionLength+1)},
+		"a long name":          {strings.Repeat("a", MaxIngredientNameLength+1), "warfarin", "major", ""},
+		"no severity at all":   {"aspirin", "warfarin", "", ""},
+		"an empty second side": {"aspirin", "  ", "minor", ""},
+	}
+	for name, fields := range invalid {
+		if _, err := NewInteraction(fields[0], fields[1], fields[2], fields[3]); err == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+	}
+}
+
+func TestNewIngredientClass(t *testing.T) {
+	for _, class := range []string{"NSAIDs", "class:NSAIDs"} {
+		membership, err := NewIngredientClass(class, " Ibuprofen ")
+		if err != nil || membership.Class != "NSAIDs" || membership.Ingredient != "Ibuprofen" {
+			t.Errorf("expected Ibuprofen in NSAIDs for %q, got %+v, %v", class, membership, err)
+		}
+	}
+	if _, err := NewIngredientClass("NSAIDs", "class:Salicylates"); err == nil {
+		t.Error("expected a class not to be a member of a class")
+	}
+}
+
+func TestFindInteractions(t *testing.T) {
+	medicine := func(id int, name string, ingredients ...string) Medicine {
+		medicine := Medicine{ID: id, Name: name}
+		for _, ingredient := range ingredients {
+			medicine.Ingredients = append(medicine.Ingredients, Ingredient{Name: ingredient})
+		}
+		return medicine
+	}
+	interaction := func(first, second, severity string) Interaction {
+		interaction, err := NewInteraction(first, second, severity, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return *interaction
+	}
+	medicines := []Medicine{
+		medicine(1, "Aspirin", "Acetylsalicylic acid"),
+		medicine(2, "Coumadin", "Warfarin"),
+		medicine(3, "Advil", "Ibuprofen"),
+		medicine(4, "Combination", "Paracetamol", "Codeine"),
+	}
+	classes := []IngredientClass{
+		{Class: "NSAIDs", Ingredient: "acetylsalicylic acid"},
+		{Class: "NSAIDs", Ingredient: "Ibuprofen"},
+	}
+	knowledge := []Interaction{
+		interaction("class:nsaids", "WARFARIN", "major"),
+		interaction("class:NSAIDs", "class:NSAIDs", "moderate"),
+		interaction("Paracetamol", "Codeine", "minor"),
+		interaction("Warfarin", "Amiodarone", "contraindicated"),
+	}
+
+	warnings := FindInteractions(medicines, classes, knowledge)
+	type found struct {
+		severity      Severity
+		first, second int
+		ingredients   string
+	}
+	expected := []found{
+		{SeverityMajor, 2, 1, "Acetylsalicylic acid"},
+		{SeverityMajor, 2, 3, "Ibuprofen"},
+		{SeverityModerate, 1, 3, "Ibuprofen"},
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("expected %d interactions, got %+v", len(expected), warnings)
+	}
+	for i, warning := range warnings {
+		got := found{warning.Interaction.Severity, warning.First.MedicineID, warning.Second.MedicineID, strings.Join(warning.Second.Ingredients, ",")}
+		if got != expected[i] {
+			t.Errorf("expected %+v at %d, got %+v", expected[i], i, got)
+		}
+	}
+	if warnings[0].First.Medicine != "Coumadin" || warnings[0].First.Ingredients[0] != "Warfarin" {
+		t.Errorf("expected the first party to hold the first subject, got %+v", warnings[0].First)
+	}
+
+	subjects := InteractionSubjects(medicines[:3], classes)
+	if len(subjects) != 4 || subjects[3] != (InteractionSubject{Name: "NSAIDs", Class: true}) {
+		t.Errorf("expected three ingredients and the class, got %+v", subjects)
+	}
+}
@@ -0,0 +1,124 @@
+package medicine
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEquivalent(t *testing.T) {
+	tablet := func(ingredients ...Ingredient) *Medicine {
+		return &Medicine{DosageForm: "tablet", Ingredients: ingredients}
+	}
+	amoxicillin := Ingredient{Name: "Amoxicillin", Strength: 500, Unit: "mg"}
+	clavulanate := Ingredient{Name: "Clavulanic acid", Strength: 125, Unit: "mg"}
+
+	if !Equivalent(tablet(amoxicillin, clavulanate), tablet(clavulanate, amoxicillin)) {
+		t.Error("expected the order of the ingredients not to matter")
+	}
+	if !Equivalent(tablet(amoxicillin), tablet(Ingredient{Name: "AMOXICILLIN", Strength: 0.5, Unit: "g"})) {
+		t.Error("expected 500 mg to be 0.5 g of the same ingredient")
+	}
+	syrup := &Medicine{DosageForm: "syrup", Ingredients: []Ingredient{{Name: "Paracetamol", Strength: 24, Unit: "mg/mL"}}}
+	if !Equivalent(syrup, &Medicine{DosageForm: "syrup", Ingredients: []Ingredient{{Name: "Paracetamol", Strength: 24, Unit: "g/L"}}}) {
+		t.Error("expected 24 mg/mL to be 24 g/L")
+	}
+
+	different := map[string]*Medicine{
+		"another strength":       tablet(Ingredient{Name: "Amoxicillin", Strength: 875, Unit: "mg"}),
+		"another form":           {DosageForm: "capsule", Ingredients: []Ingredient{amoxicillin}},
+		"an unknown form":        {Ingredients: []Ingredient{amoxicillin}},
+		"an extra ingredient":    tablet(amoxicillin, clavulanate),
+		"another ingredient":     tablet(Ingredient{Name: "Ampicillin", Strength: 500, Unit: "mg"}),
+		"an incomparable unit":   tablet(Ingredient{Name: "Amoxicillin", Strength: 500, Unit: "IU"}),
+		"no ingredients at all":  tablet(),
+		"a strength per a basis": tablet(Ingredient{Name: "Amoxicillin", Strength: 500, Unit: "mg/dose"}),
+	}
+	for name, medicine := range different {
+		if Equivalent(tablet(amoxicillin), medicine) {
+			t.Errorf("expected a medicine with %s not to be equivalent", name)
+		}
+	}
+	if Equivalent(tablet(), tablet()) {
+		t.Error("expected medicines of unknown composition to be equivalent to none")
+	}
+}
+
+func TestRankSubstitutes(t *testing.T) {
+	price := func(value float64) *float64 { return &value }
+	stock := func(quantity int) *int { return &quantity }
+	box := &PackageSize{Quantity: 10, Unit: "unit"}
+	substitutes := []Substitute{
+		{Medicine: Medicine{Name: "Unpriced", StockQuantity: stock(50)}},
+		{Medicine: Medicine{Name: "Out of stock", Price: price(1), PackageSize: box, StockQuantity: stock(0)}},
+		{Medicine: Medicine{Name: "Large box", Price: price(15), PackageSize: &PackageSize{Quantity: 30, Unit: "unit"}, StockQuantity: stock(2)}},
+		{Medicine: Medicine{Name: "Small box", Price: price(8), PackageSize: box, StockQuantity: stock(40)}},
+		{Medicine: Medicine{Name: "Same price", Price: price(8), PackageSize: box, StockQuantity: stock(90)}},
+		{Medicine: Medicine{Name: "No stock levels"}},
+	}
+	RankSubstitutes(substitutes)
+
+	expected := []string{"Large box", "Same price", "Small box", "Unpriced", "Out of stock", "No stock levels"}
+	for i, name := range expected {
+		if substitutes[i].Medicine.Name != name {
+			t.Fatalf("expected %q at %d, got %q", name, i, substitutes[i].Medicine.Name)
+		}
+	}
+}
+
+func TestRankSubstitutes_ComparesPricesPerUnitOfOneDimension(t *testing.T) {
+	price := func(value float64) *float64 { return &value }
+	stock := func(quantity int) *int { return &quantity }
+	substitutes := []Substitute{
+		{Medicine: Medicine{Name: "Small bottle", Price: price(2), PackageSize: &PackageSize{Quantity: 100, Unit: "mL"}, StockQuantity: stock(5)}},
+		{Medicine: Medicine{Name: "Large bottle", Price: price(10), PackageSize: &PackageSize{Quantity: 1, Unit: "L"}, StockQuantity: stock(5)}},
+	}
+	RankSubstitutes(substitutes)
+	if substitutes[0].Medicine.Name != "Large bottle" {
+		t.Errorf("expected 1 L at 10 to be cheaper per mL than 100 mL at 2, got %q first", substitutes[0].Medicine.Name)
+	}
+
+	substitutes = []Substitute{
+		{Medicine: Medicine{Name: "Tablets", Price: price(5), PackageSize: &PackageSize{Quantity: 20, Unit: "unit"}, StockQuantity: stock(5)}},
+		{Medicine: Medicine{Name: "Syrup", Price: price(4), PackageSize: &PackageSize{Quantity: 100, Unit: "mL"}, StockQuantity: stock(5)}},
+	}
+	RankSubstitutes(substitutes)
+	if substitutes[0].Medicine.Name != "Syrup" {
+		t.Errorf("expected packages sized in different units to be compared by package price, got %q first", substitutes[0].Medicine.Name)
+	}
+}
+
+func TestUnitPriceAndValidatePrice(t *testing.T) {
+	price := 12.0
+	medicine := Medicine{Price: &price, PackageSize: &PackageSize{Quantity: 24, Unit: "unit"}}
+	if unitPrice := medicine.UnitPrice(); unitPrice == nil || *unitPrice != 0.5 {
+		t.Errorf("expected a unit price of 0.5, got %v", unitPrice)
+	}
+	medicine.PackageSize = &PackageSize{Quantity: 0.5, Unit: "L"}
+	if unitPrice := medicine.UnitPrice(); unitPrice == nil || *unitPrice != 0.024 {
+		t.Errorf("expected a price of 0.024 per mL, got %v", unitPrice)
+	}
+	medicine.PackageSize = nil
+	if medicine.UnitPrice() != nil {
+		t.Error("expected no unit price without a package size")
+	}
+
+	for _, valid := range []*float64{nil, new(float64), &price} {
+		if err := ValidatePrice(valid); err != nil {
+			t.Errorf("expected %v to be a valid price, got %v", valid, err)
+		}
+	}
+	for _, invalid := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if err := ValidatePrice(&invalid); err == nil {
+			t.Errorf("expected an error for a price of %v", invalid)
+		}
+	}
+}
+
+func TestEquivalenceKind_IsValid(t *testing.T) {
+	if !EquivalenceMarked.IsValid() || !EquivalenceExcluded.IsValid() {
+		t.Error("expected the listed kinds to be valid")
+	}
+	if EquivalenceKind("similar").IsValid() {
+		t.Error("expected an unlisted kind to be invalid")
+	}
+}
@@ -0,0 +1,22 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+)
+
+// Organization is a tenant: users and medicines belong to exactly one and never see another's data
+type Organization struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type IOrganizationService interface {
+	Create(ctx context.Context, organization *Organization, owner *domainUser.User) (*Organization, *domainUser.User, error)
+	GetByID(ctx context.Context, id int) (*Organization, error)
+	GetCurrent(ctx context.Context) (*Organization, error)
+}
@@ -0,0 +1,19 @@
+package organization
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrganization_Fields(t *testing.T) {
+	now := time.Now()
+	organization := Organization{ID: 1, Name: "Acme Pharma", CreatedAt: now, UpdatedAt: now}
+
+	if organization.ID != 1 {
+		t.Errorf("Expected ID to be 1, got %d", organization.ID)
+	}
+
+	if organization.Name != "Acme Pharma" {
+		t.Errorf("Expected Name to be 'Acme Pharma', got %s", organization.Name)
+	}
+}
@@ -0,0 +1,120 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Threshold sets when the stock of a medicine at a location is low. The stock needs
+// reordering at or below ReorderPoint and is critical below MinLevel; a reorder fills
+// it up to MaxLevel.
+type Threshold struct {
+	ID           int
+	TenantID     int
+	MedicineID   int
+	Location     string
+	MinLevel     int
+	ReorderPoint int
+	MaxLevel     int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CreatedBy    *int
+	UpdatedBy    *int
+}
+
+// Severity returns the severity of a low stock alert for quantity units on hand, or
+// "" when the stock is not low
+func (t *Threshold) Severity(quantity int) AlertSeverity {
+	switch {
+	case quantity < t.MinLevel:
+		return SeverityCritical
+	case quantity <= t.ReorderPoint:
+		return SeverityWarning
+	}
+	return ""
+}
+
+// ReorderQuantity returns the units that bring quantity up to MaxLevel
+func (t *Threshold) ReorderQuantity(quantity int) int {
+	return max(t.MaxLevel-quantity, 0)
+}
+
+// AlertType is the condition an alert reports
+type AlertType string
+
+const (
+	// AlertLowStock reports a stock level at or below its reorder point
+	AlertLowStock AlertType = "low-stock"
+	// AlertExpiry reports a lot with units left that expires soon or has expired
+	AlertExpiry AlertType = "expiry"
+)
+
+// AlertSeverity ranks how urgent an alert is
+type AlertSeverity string
+
+const (
+	SeverityWarning  AlertSeverity = "warning"
+	SeverityCritical AlertSeverity = "critical"
+)
+
+// Exceeds reports whether s is more urgent than other
+func (s AlertSeverity) Exceeds(other AlertSeverity) bool {
+	return s == SeverityCritical && other != SeverityCritical
+}
+
+// AlertStatus is the step of its life an alert is at. An open alert is waiting for
+// someone to acknowledge it; either is resolved once its condition clears.
+type AlertStatus string
+
+const (
+	AlertOpen         AlertStatus = "open"
+	AlertAcknowledged AlertStatus = "acknowledged"
+	AlertResolved     AlertStatus = "resolved"
+)
+
+// Alert reports a stock condition that needs attention. While its condition lasts a
+// single alert is kept per medicine and location, or per lot for expiry, and updated
+// as the stock changes; it is raised again, and reopened, when its severity rises.
+// Quantity is the stock level, or the units left in the lot, when last evaluated, and
+// Threshold the reorder point of a low stock alert.
+type Alert struct {
+	ID             int
+	TenantID       int
+	MedicineID     int
+	Location       string
+	LotID          *int
+	Type           AlertType
+	Severity       AlertSeverity
+	Status         AlertStatus
+	Quantity       int
+	Threshold      int
+	ExpiresAt      *time.Time
+	Message        string
+	AcknowledgedAt *time.Time
+	AcknowledgedBy *int
+	ResolvedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Key identifies the condition of the alert among those of its organization
+func (a *Alert) Key() string {
+	if a.Type == AlertExpiry && a.LotID != nil {
+		return fmt.Sprintf("%s/%d", a.Type, *a.LotID)
+	}
+	return fmt.Sprintf("%s/%d/%s", a.Type, a.MedicineID, a.Location)
+}
+
+// Notifier delivers raised alerts to the people watching the stock
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+type SearchResultAlert struct {
+	Data       *[]Alert
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
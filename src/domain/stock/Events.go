@@ -0,0 +1,15 @@
+package stock
+
+// Events raised in process by the stock use case once an operation succeeded
+
+// Adjusted is raised after the stock of a medicine at a location changed by Delta
+type Adjusted struct {
+	Level Level
+	Delta int
+}
+
+// AlertRaised is raised after an alert was opened or its severity rose, for it to be
+// delivered to the people watching the stock
+type AlertRaised struct {
+	Alert Alert
+}
@@ -0,0 +1,47 @@
+package stock
+
+import (
+	"slices"
+	"time"
+)
+
+// LocationType is the kind of place a location is
+type LocationType string
+
+const (
+	LocationWarehouse LocationType = "warehouse"
+	LocationStore     LocationType = "store"
+	LocationCabinet   LocationType = "cabinet"
+)
+
+// IsValid reports whether t is one of the known location types
+func (t LocationType) IsValid() bool {
+	switch t {
+	case LocationWarehouse, LocationStore, LocationCabinet:
+		return true
+	}
+	return false
+}
+
+// Location is a place an organization keeps stock at. Stock levels, lots, movements
+// and transfers refer to it by Code, which never changes. When UserIDs is not empty
+// only those users may change the stock held there. An inactive location keeps its
+// stock but takes no further changes.
+type Location struct {
+	ID        int
+	TenantID  int
+	Code      string
+	Name      string
+	Type      LocationType
+	Active    bool
+	UserIDs   []int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy *int
+	UpdatedBy *int
+}
+
+// Allows reports whether userID may change the stock at the location
+func (l *Location) Allows(userID int) bool {
+	return len(l.UserIDs) == 0 || slices.Contains(l.UserIDs, userID)
+}
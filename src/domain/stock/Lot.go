@@ -0,0 +1,92 @@
+package stock
+
+import (
+	"slices"
+	"time"
+)
+
+// MedicineLot is the part of a batch of a medicine received from a supplier that is
+// held at one location. Quantity is the number of its units still on hand there; a
+// batch transferred elsewhere has a lot with the same number at each location.
+type MedicineLot struct {
+	ID               int
+	TenantID         int
+	MedicineID       int
+	LotNumber        string
+	Location         string
+	ManufacturedAt   *time.Time
+	ExpiresAt        time.Time
+	Quantity         int
+	Supplier         string
+	QuarantinedAt    *time.Time
+	QuarantineReason string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	CreatedBy        *int
+	UpdatedBy        *int
+}
+
+// Expired reports whether the lot expired before today. A lot can be used through
+// its expiry date.
+func (l *MedicineLot) Expired(today time.Time) bool {
+	return l.ExpiresAt.Before(today)
+}
+
+// Dispensable reports whether units of the lot can be dispensed today
+func (l *MedicineLot) Dispensable(today time.Time) bool {
+	return l.QuarantinedAt == nil && l.Quantity > 0 && !l.Expired(today)
+}
+
+// Allocation is the part of a dispensation taken from one lot
+type Allocation struct {
+	LotID     int
+	LotNumber string
+	ExpiresAt time.Time
+	Quantity  int
+}
+
+// Dispensation is the outcome of dispensing Quantity units of a medicine at a
+// location: the lots they came from and the stock level left there
+type Dispensation struct {
+	MedicineID  int
+	Quantity    int
+	Allocations []Allocation
+	Level       Level
+}
+
+// Day returns the calendar day of t in UTC, the precision of lot dates
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// AllocateFEFO takes quantity units from the lots dispensable today, first expired
+// first out; lots expiring the same day are used in the order they were received.
+// It reports false when the lots do not hold enough units.
+func AllocateFEFO(lots []MedicineLot, quantity int, today time.Time) ([]Allocation, bool) {
+	ordered := slices.Clone(lots)
+	slices.SortFunc(ordered, func(a, b MedicineLot) int {
+		if c := a.ExpiresAt.Compare(b.ExpiresAt); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+	var allocations []Allocation
+	for _, lot := range ordered {
+		if quantity == 0 {
+			break
+		}
+		if !lot.Dispensable(today) {
+			continue
+		}
+		taken := min(lot.Quantity, quantity)
+		allocations = append(allocations, Allocation{
+			LotID:     lot.ID,
+			LotNumber: lot.LotNumber,
+			ExpiresAt: lot.ExpiresAt,
+			Quantity:  taken,
+		})
+		quantity -= taken
+	}
+	return allocations, quantity == 0
+}
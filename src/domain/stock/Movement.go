@@ -0,0 +1,49 @@
+package stock
+
+import "time"
+
+// MovementType is the kind of stock change a ledger entry records
+type MovementType string
+
+const (
+	MovementReceipt    MovementType = "receipt"
+	MovementDispense   MovementType = "dispense"
+	MovementTransfer   MovementType = "transfer"
+	MovementAdjustment MovementType = "adjustment"
+	MovementReturn     MovementType = "return"
+	MovementWriteOff   MovementType = "write-off"
+)
+
+// IsValid reports whether t is one of the known movement types
+func (t MovementType) IsValid() bool {
+	switch t {
+	case MovementReceipt, MovementDispense, MovementTransfer, MovementAdjustment, MovementReturn, MovementWriteOff:
+		return true
+	}
+	return false
+}
+
+// Movement is an entry of the stock ledger: Quantity units entering the stock of a
+// medicine at a location, or leaving it when negative. Entries are never changed;
+// stock levels are derived from them.
+type Movement struct {
+	ID         int64
+	TenantID   int
+	MedicineID int
+	Location   string
+	LotID      *int
+	TransferID *int
+	Type       MovementType
+	Quantity   int
+	Reason     string
+	CreatedAt  time.Time
+	CreatedBy  *int
+}
+
+type SearchResultMovement struct {
+	Data       *[]Movement
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
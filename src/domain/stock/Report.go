@@ -0,0 +1,17 @@
+package stock
+
+import "time"
+
+// Report summarizes what needs attention in the stock of an organization on Day:
+// its unresolved alerts and the units in lots expiring within ExpiryDays days
+type Report struct {
+	TenantID           int
+	Day                time.Time
+	ExpiryDays         int
+	LowStockAlerts     int
+	CriticalAlerts     int
+	ExpiryAlerts       int
+	AcknowledgedAlerts int
+	ExpiringLots       int
+	ExpiringUnits      int
+}
@@ -0,0 +1,73 @@
+package stock
+
+import (
+	"context"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+)
+
+// DefaultLocation holds the stock of changes that name no location. It needs no
+// Location record; one with its code restricts it like any other.
+const DefaultLocation = "main"
+
+// Level is the on-hand quantity of a medicine at one location. It is never negative.
+type Level struct {
+	ID         int
+	TenantID   int
+	MedicineID int
+	Location   string
+	Quantity   int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	CreatedBy  *int
+	UpdatedBy  *int
+}
+
+// Stock is the on-hand quantity of a medicine over all its locations. InTransit
+// counts the units of shipped transfers not received yet, which are in no level.
+type Stock struct {
+	MedicineID int
+	Total      int
+	InTransit  int
+	Levels     []Level
+}
+
+// Adjustment adds Delta units, or removes them when negative, to the stock of a
+// medicine at a location. It is recorded in the ledger as a movement of Type, an
+// adjustment by default, with the Reason code given.
+type Adjustment struct {
+	TenantID   int
+	MedicineID int
+	Location   string
+	Delta      int
+	Type       MovementType
+	Reason     string
+}
+
+type IStockService interface {
+	GetByMedicine(ctx context.Context, medicineID int) (*Stock, error)
+	Adjust(ctx context.Context, adjustment Adjustment) (*Level, error)
+	ReceiveLot(ctx context.Context, lot *MedicineLot) (*MedicineLot, error)
+	GetLots(ctx context.Context, medicineID int) (*[]MedicineLot, error)
+	GetExpiringLots(ctx context.Context, days int) (*[]MedicineLot, error)
+	QuarantineLot(ctx context.Context, lotID int, reason string) (*MedicineLot, error)
+	Dispense(ctx context.Context, medicineID int, location string, quantity int, reason string) (*Dispensation, error)
+	GetMovements(ctx context.Context, medicineID int, filters domain.DataFilters) (*SearchResultMovement, error)
+	GetLocations(ctx context.Context) (*[]Location, error)
+	GetLocation(ctx context.Context, id int) (*Location, error)
+	CreateLocation(ctx context.Context, location *Location) (*Location, error)
+	UpdateLocation(ctx context.Context, id int, locationMap map[string]any) (*Location, error)
+	RequestTransfer(ctx context.Context, transfer *Transfer) (*Transfer, error)
+	GetTransfer(ctx context.Context, id int) (*Transfer, error)
+	GetTransfers(ctx context.Context, filters domain.DataFilters) (*SearchResultTransfer, error)
+	ShipTransfer(ctx context.Context, id int) (*Transfer, error)
+	ReceiveTransfer(ctx context.Context, id int) (*Transfer, error)
+	CancelTransfer(ctx context.Context, id int) (*Transfer, error)
+	GetThresholds(ctx context.Context, medicineID int) (*[]Threshold, error)
+	SetThreshold(ctx context.Context, threshold *Threshold) (*Threshold, error)
+	DeleteThreshold(ctx context.Context, id int) error
+	GetAlerts(ctx context.Context, filters domain.DataFilters) (*SearchResultAlert, error)
+	GetAlert(ctx context.Context, id int) (*Alert, error)
+	AcknowledgeAlert(ctx context.Context, id int) (*Alert, error)
+}
@@ -0,0 +1,55 @@
+package stock
+
+import "time"
+
+// TransferStatus is the step of its workflow a transfer is at
+type TransferStatus string
+
+const (
+	TransferRequested TransferStatus = "requested"
+	TransferShipped   TransferStatus = "shipped"
+	TransferReceived  TransferStatus = "received"
+	TransferCancelled TransferStatus = "cancelled"
+)
+
+// Previous returns the status a transfer must be in to move to s
+func (s TransferStatus) Previous() TransferStatus {
+	switch s {
+	case TransferShipped, TransferCancelled:
+		return TransferRequested
+	case TransferReceived:
+		return TransferShipped
+	}
+	return ""
+}
+
+// Transfer moves Quantity units of a medicine, all of one lot when LotID is set, from
+// one location to another. Requesting it moves nothing; shipping takes the units from
+// FromLocation, and they are in transit until receiving adds them to ToLocation. Only
+// a requested transfer can be cancelled.
+type Transfer struct {
+	ID           int
+	TenantID     int
+	MedicineID   int
+	LotID        *int
+	FromLocation string
+	ToLocation   string
+	Quantity     int
+	Status       TransferStatus
+	Reason       string
+	ShippedAt    *time.Time
+	ReceivedAt   *time.Time
+	CancelledAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CreatedBy    *int
+	UpdatedBy    *int
+}
+
+type SearchResultTransfer struct {
+	Data       *[]Transfer
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
@@ -0,0 +1,39 @@
+package stock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocateFEFO(t *testing.T) {
+	today := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	quarantinedAt := today
+	lots := []MedicineLot{
+		{ID: 1, LotNumber: "L-LATE", ExpiresAt: today.AddDate(1, 0, 0), Quantity: 10},
+		{ID: 2, LotNumber: "L-EXPIRED", ExpiresAt: today.AddDate(0, 0, -1), Quantity: 10},
+		{ID: 3, LotNumber: "L-QUARANTINED", ExpiresAt: today, Quantity: 10, QuarantinedAt: &quarantinedAt},
+		{ID: 4, LotNumber: "L-TODAY", ExpiresAt: today, Quantity: 2},
+		{ID: 5, LotNumber: "L-SOON", ExpiresAt: today.AddDate(0, 1, 0), Quantity: 3},
+		{ID: 6, LotNumber: "L-SOON-2", ExpiresAt: today.AddDate(0, 1, 0), Quantity: 3},
+	}
+
+	allocations, ok := AllocateFEFO(lots, 7, today)
+	require.True(t, ok)
+	require.Len(t, allocations, 3)
+	assert.Equal(t, Allocation{LotID: 4, LotNumber: "L-TODAY", ExpiresAt: today, Quantity: 2}, allocations[0])
+	assert.Equal(t, 5, allocations[1].LotID, "lots expiring the same day go in the order they were received")
+	assert.Equal(t, 3, allocations[1].Quantity)
+	assert.Equal(t, 6, allocations[2].LotID)
+	assert.Equal(t, 2, allocations[2].Quantity)
+
+	_, ok = AllocateFEFO(lots, 19, today)
+	assert.False(t, ok, "expired and quarantined lots are not dispensable")
+}
+
+func TestDay(t *testing.T) {
+	at := time.Date(2026, 5, 10, 23, 30, 0, 0, time.FixedZone("UTC-3", -3*3600))
+	assert.Equal(t, time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC), Day(at))
+}
@@ -0,0 +1,25 @@
+package user
+
+// Events raised in process by the user use case once an operation succeeded. The user
+// carries its password hash, so handlers must not expose it.
+
+// Created is raised after a user was created
+type Created struct {
+	User User
+}
+
+// Updated is raised after a user was updated; Fields names the updated fields
+type Updated struct {
+	User   User
+	Fields []string
+}
+
+// Deleted is raised after a user was moved to the trash
+type Deleted struct {
+	ID int
+}
+
+// Restored is raised after a user was restored from the trash
+type Restored struct {
+	User User
+}
@@ -1,13 +1,16 @@
 package user
 
 import (
+	"context"
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
+	"github.com/gbrayhan/microservices-go/src/domain/history"
 )
 
 type User struct {
 	ID           int
+	TenantID     int
 	UserName     string
 	Email        string
 	FirstName    string
@@ -17,6 +20,9 @@ type User struct {
 	Password     string
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	DeletedAt    *time.Time
+	CreatedBy    *int
+	UpdatedBy    *int
 }
 
 type SearchResultUser struct {
@@ -28,11 +34,16 @@ type SearchResultUser struct {
 }
 
 type IUserService interface {
-	GetAll() (*[]User, error)
-	GetByID(id int) (*User, error)
-	Create(newUser *User) (*User, error)
-	Delete(id int) error
-	Update(id int, userMap map[string]interface{}) (*User, error)
-	SearchPaginated(filters domain.DataFilters) (*SearchResultUser, error)
-	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetAll(ctx context.Context) (*[]User, error)
+	GetByID(ctx context.Context, id int) (*User, error)
+	Create(ctx context.Context, newUser *User) (*User, error)
+	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, userMap map[string]interface{}) (*User, error)
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*SearchResultUser, error)
+	SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error)
+	GetTrash(ctx context.Context, filters domain.DataFilters) (*SearchResultUser, error)
+	Restore(ctx context.Context, id int) (*User, error)
+	Purge(ctx context.Context, olderThan time.Time) (int64, error)
+	GetHistory(ctx context.Context, id int) (*[]history.Entry, error)
+	GetAsOf(ctx context.Context, id int, at time.Time) (*User, error)
 }
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Webhook is an HTTP endpoint of a partner system receiving the events of its
+// organization. It is disabled automatically after too many consecutive failed
+// deliveries and enabled again by setting Active.
+type Webhook struct {
+	ID                  int
+	TenantID            int
+	URL                 string
+	EventTypes          []string
+	Secret              string
+	Active              bool
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+	DisabledReason      string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	CreatedBy           *int
+	UpdatedBy           *int
+}
+
+// Subscribes reports whether the webhook receives events of eventType
+func (w *Webhook) Subscribes(eventType string) bool {
+	return slices.Contains(w.EventTypes, eventType)
+}
+
+// Delivery is one attempt to deliver an event to a webhook. A failed attempt schedules
+// the next one as a new pending delivery.
+type Delivery struct {
+	ID             int64
+	WebhookID      int
+	EventID        string
+	EventType      string
+	Attempt        int
+	Status         string
+	ScheduledAt    time.Time
+	AttemptedAt    *time.Time
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	DurationMs     int64
+	CreatedAt      time.Time
+}
+
+type SearchResultDelivery struct {
+	Data       *[]Delivery
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+type IWebhookService interface {
+	GetAll(ctx context.Context) (*[]Webhook, error)
+	GetByID(ctx context.Context, id int) (*Webhook, error)
+	Create(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	Update(ctx context.Context, id int, webhookMap map[string]any) (*Webhook, error)
+	Delete(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, id int, filters domain.DataFilters) (*SearchResultDelivery, error)
+}
@@ -0,0 +1,16 @@
+package webhook
+
+import (
+	"testing"
+)
+
+func TestWebhook_Subscribes(t *testing.T) {
+	webhook := Webhook{EventTypes: []string{"medicine.created", "medicine.updated"}}
+
+	if !webhook.Subscribes("medicine.updated") {
+		t.Error("expected the webhook to receive medicine.updated")
+	}
+	if webhook.Subscribes("medicine.deleted") {
+		t.Error("expected the webhook not to receive medicine.deleted")
+	}
+}
@@ -0,0 +1,103 @@
+// Package alerts delivers raised stock alerts to the people watching the stock
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+)
+
+// Notifiers accepted by ALERTS_NOTIFIER
+const (
+	NotifierLog     = "log"
+	NotifierEmail   = "email"
+	NotifierWebhook = "webhook"
+)
+
+// Config holds the alert delivery settings
+type Config struct {
+	Notifiers      []string
+	ExpiryDays     int
+	EmailFrom      string
+	EmailTo        []string
+	SMTPHost       string
+	SMTPPort       int
+	SMTPUsername   string
+	SMTPPassword   string
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+}
+
+// LoadConfig loads alert delivery configuration from environment variables
+func LoadConfig() Config {
+	return Config{
+		Notifiers:      splitList(config.EnvOrDefault("ALERTS_NOTIFIER", NotifierLog)),
+		ExpiryDays:     config.EnvAsIntOrDefault("ALERTS_EXPIRY_DAYS", 30),
+		EmailFrom:      os.Getenv("ALERTS_EMAIL_FROM"),
+		EmailTo:        splitList(os.Getenv("ALERTS_EMAIL_TO")),
+		SMTPHost:       config.EnvOrDefault("SMTP_HOST", "localhost"),
+		SMTPPort:       config.EnvAsIntOrDefault("SMTP_PORT", 587),
+		SMTPUsername:   os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:   os.Getenv("SMTP_PASSWORD"),
+		WebhookURL:     os.Getenv("ALERTS_WEBHOOK_URL"),
+		WebhookSecret:  os.Getenv("ALERTS_WEBHOOK_SECRET"),
+		WebhookTimeout: time.Duration(config.EnvAsIntOrDefault("ALERTS_WEBHOOK_TIMEOUT_MS", 5000)) * time.Millisecond,
+	}
+}
+
+// NewNotifier returns the notifiers listed in config, each delivering every alert
+func NewNotifier(config Config, loggerInstance *logger.Logger) (Notifiers, error) {
+	notifiers := make(Notifiers, 0, len(config.Notifiers))
+	for _, name := range config.Notifiers {
+		switch name {
+		case NotifierLog:
+			notifiers = append(notifiers, NewLogNotifier(loggerInstance))
+		case NotifierEmail:
+			if config.EmailFrom == "" || len(config.EmailTo) == 0 {
+				return nil, errors.New("the email alert notifier needs ALERTS_EMAIL_FROM and ALERTS_EMAIL_TO")
+			}
+			notifiers = append(notifiers, NewEmailNotifier(config))
+		case NotifierWebhook:
+			if config.WebhookURL == "" {
+				return nil, errors.New("the webhook alert notifier needs ALERTS_WEBHOOK_URL")
+			}
+			notifiers = append(notifiers, NewWebhookNotifier(config))
+		default:
+			return nil, fmt.Errorf("unknown ALERTS_NOTIFIER %q", name)
+		}
+	}
+	return notifiers, nil
+}
+
+// Notifiers delivers every alert through each of its notifiers. It fails when any of
+// them failed, after trying all the others.
+type Notifiers []stockDomain.Notifier
+
+func (n Notifiers) Notify(ctx context.Context, alert stockDomain.Alert) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,151 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strconv"
+	"testing"
+	"time"
+
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAlert() stockDomain.Alert {
+	return stockDomain.Alert{
+		ID:         7,
+		TenantID:   1,
+		MedicineID: 3,
+		Location:   "main",
+		Type:       stockDomain.AlertLowStock,
+		Severity:   stockDomain.SeverityCritical,
+		Status:     stockDomain.AlertOpen,
+		Quantity:   2,
+		Threshold:  10,
+		Message:    "Paracetamol at main: 2 units on hand",
+	}
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return loggerInstance
+}
+
+type failingNotifier struct{ calls int }
+
+func (n *failingNotifier) Notify(context.Context, stockDomain.Alert) error {
+	n.calls++
+	return errors.New("unreachable")
+}
+
+func TestNewNotifier(t *testing.T) {
+	loggerInstance := setupLogger(t)
+
+	notifiers, err := NewNotifier(Config{Notifiers: []string{NotifierLog}}, loggerInstance)
+	require.NoError(t, err)
+	assert.Len(t, notifiers, 1)
+
+	_, err = NewNotifier(Config{Notifiers: []string{NotifierEmail}}, loggerInstance)
+	assert.ErrorContains(t, err, "ALERTS_EMAIL_FROM")
+	_, err = NewNotifier(Config{Notifiers: []string{NotifierWebhook}}, loggerInstance)
+	assert.ErrorContains(t, err, "ALERTS_WEBHOOK_URL")
+	_, err = NewNotifier(Config{Notifiers: []string{"pager"}}, loggerInstance)
+	assert.ErrorContains(t, err, "unknown ALERTS_NOTIFIER")
+
+	notifiers, err = NewNotifier(Config{
+		Notifiers:  []string{NotifierLog, NotifierEmail, NotifierWebhook},
+		EmailFrom:  "stock@example.com",
+		EmailTo:    []string{"pharmacy@example.com"},
+		SMTPHost:   "smtp.example.com",
+		SMTPPort:   587,
+		WebhookURL: "https://example.com/alerts",
+	}, loggerInstance)
+	require.NoError(t, err)
+	assert.Len(t, notifiers, 3)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("ALERTS_NOTIFIER", "log, webhook")
+	t.Setenv("ALERTS_EMAIL_TO", "a@example.com,,b@example.com")
+
+	config := LoadConfig()
+	assert.Equal(t, []string{"log", "webhook"}, config.Notifiers)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, config.EmailTo)
+	assert.Equal(t, 30, config.ExpiryDays)
+}
+
+func TestNotifiers_TriesEveryNotifier(t *testing.T) {
+	first, second := &failingNotifier{}, &failingNotifier{}
+	notifiers := Notifiers{first, NewLogNotifier(setupLogger(t)), second}
+
+	assert.Error(t, notifiers.Notify(context.Background(), testAlert()))
+	assert.Equal(t, 1, first.calls)
+	assert.Equal(t, 1, second.calls)
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	notifier := NewEmailNotifier(Config{
+		EmailFrom:    "stock@example.com",
+		EmailTo:      []string{"pharmacy@example.com", "buyer@example.com"},
+		SMTPHost:     "smtp.example.com",
+		SMTPPort:     587,
+		SMTPUsername: "stock",
+		SMTPPassword: "secret",
+	})
+	var address string
+	var recipients []string
+	var message []byte
+	notifier.send = func(addr string, auth smtp.Auth, _ string, to []string, msg []byte) error {
+		address, recipients, message = addr, to, msg
+		assert.NotNil(t, auth)
+		return nil
+	}
+
+	require.NoError(t, notifier.Notify(context.Background(), testAlert()))
+	assert.Equal(t, "smtp.example.com:587", address)
+	assert.Equal(t, []string{"pharmacy@example.com", "buyer@example.com"}, recipients)
+	assert.Contains(t, string(message), "Subject: [critical] low-stock alert at main\r\n")
+	assert.Contains(t, string(message), "Paracetamol at main: 2 units on hand")
+
+	notifier.send = func(string, smtp.Auth, string, []string, []byte) error {
+		return errors.New("connection refused")
+	}
+	assert.ErrorContains(t, notifier.Notify(context.Background(), testAlert()), "mailing alert 7")
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(Config{WebhookURL: server.URL, WebhookSecret: "secret", WebhookTimeout: time.Second})
+	now := time.Unix(1700000000, 0)
+	notifier.now = func() time.Time { return now }
+
+	require.NoError(t, notifier.Notify(context.Background(), testAlert()))
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.JSONEq(t, `{"id":7,"tenantId":1,"medicineId":3,"location":"main","type":"low-stock",
+		"severity":"critical","status":"open","quantity":2,"threshold":10,
+		"message":"Paracetamol at main: 2 units on hand",
+		"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`, string(body))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), received.Header.Get(webhooks.HeaderTimestamp))
+	assert.Equal(t, webhooks.Sign("secret", now.Unix(), body), received.Header.Get(webhooks.HeaderSignature))
+
+	status = http.StatusBadGateway
+	assert.ErrorContains(t, notifier.Notify(context.Background(), testAlert()), "502")
+}
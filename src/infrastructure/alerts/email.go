@@ -0,0 +1,60 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+)
+
+// sendMail has the signature of smtp.SendMail, which tests replace
+type sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailNotifier mails every alert to a fixed list of recipients through an SMTP
+// server, authenticating when a username is configured
+type EmailNotifier struct {
+	address string
+	auth    smtp.Auth
+	from    string
+	to      []string
+	send    sendMail
+}
+
+func NewEmailNotifier(config Config) *EmailNotifier {
+	notifier := &EmailNotifier{
+		address: net.JoinHostPort(config.SMTPHost, strconv.Itoa(config.SMTPPort)),
+		from:    config.EmailFrom,
+		to:      config.EmailTo,
+		send:    smtp.SendMail,
+	}
+	if config.SMTPUsername != "" {
+		notifier.auth = smtp.PlainAuth("", config.SMTPUsername, config.SMTPPassword, config.SMTPHost)
+	}
+	return notifier
+}
+
+// Notify sends the alert. net/smtp takes no context, so a slow server is bounded by
+// the timeouts of the connection only.
+func (n *EmailNotifier) Notify(_ context.Context, alert stockDomain.Alert) error {
+	if err := n.send(n.address, n.auth, n.from, n.to, n.message(alert)); err != nil {
+		return fmt.Errorf("mailing alert %d: %w", alert.ID, err)
+	}
+	return nil
+}
+
+func (n *EmailNotifier) message(alert stockDomain.Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&b, "Subject: [%s] %s alert at %s\r\n", alert.Severity, alert.Type, alert.Location)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\n", alert.Message)
+	fmt.Fprintf(&b, "Alert: %d\r\nMedicine: %d\r\nLocation: %s\r\nQuantity: %d\r\n",
+		alert.ID, alert.MedicineID, alert.Location, alert.Quantity)
+	return []byte(b.String())
+}
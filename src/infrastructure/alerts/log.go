@@ -0,0 +1,31 @@
+package alerts
+
+import (
+	"context"
+
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// LogNotifier writes every alert to the log. It is the notifier used while no other
+// is configured.
+type LogNotifier struct {
+	Logger *logger.Logger
+}
+
+func NewLogNotifier(loggerInstance *logger.Logger) *LogNotifier {
+	return &LogNotifier{Logger: loggerInstance}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, alert stockDomain.Alert) error {
+	n.Logger.Warn("Stock alert",
+		zap.Int("alertId", alert.ID),
+		zap.String("type", string(alert.Type)),
+		zap.String("severity", string(alert.Severity)),
+		zap.Int("medicineId", alert.MedicineID),
+		zap.String("location", alert.Location),
+		zap.Int("tenantId", alert.TenantID),
+		zap.String("message", alert.Message))
+	return nil
+}
@@ -0,0 +1,94 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/webhooks"
+)
+
+// WebhookNotifier posts every alert as JSON to a fixed URL. When a secret is
+// configured the request is signed like the event webhooks, so receivers verify both
+// the same way.
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookNotifier(config Config) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    config.WebhookURL,
+		secret: config.WebhookSecret,
+		client: &http.Client{Timeout: config.WebhookTimeout},
+		now:    time.Now,
+	}
+}
+
+// alertPayload is the body of an alert webhook
+type alertPayload struct {
+	ID         int        `json:"id"`
+	TenantID   int        `json:"tenantId"`
+	MedicineID int        `json:"medicineId"`
+	Location   string     `json:"location"`
+	LotID      *int       `json:"lotId,omitempty"`
+	Type       string     `json:"type"`
+	Severity   string     `json:"severity"`
+	Status     string     `json:"status"`
+	Quantity   int        `json:"quantity"`
+	Threshold  int        `json:"threshold,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	Message    string     `json:"message"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert stockDomain.Alert) error {
+	body, err := json.Marshal(alertPayload{
+		ID:         alert.ID,
+		TenantID:   alert.TenantID,
+		MedicineID: alert.MedicineID,
+		Location:   alert.Location,
+		LotID:      alert.LotID,
+		Type:       string(alert.Type),
+		Severity:   string(alert.Severity),
+		Status:     string(alert.Status),
+		Quantity:   alert.Quantity,
+		Threshold:  alert.Threshold,
+		ExpiresAt:  alert.ExpiresAt,
+		Message:    alert.Message,
+		CreatedAt:  alert.CreatedAt,
+		UpdatedAt:  alert.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		timestamp := n.now().Unix()
+		request.Header.Set(webhooks.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		request.Header.Set(webhooks.HeaderSignature, webhooks.Sign(n.secret, timestamp, body))
+	}
+	response, err := n.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("posting alert %d: %w", alert.ID, err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("posting alert %d: %s", alert.ID, response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvOrDefault(t *testing.T) {
+	t.Setenv("TEST_KEY", "test_value")
+	assert.Equal(t, "test_value", EnvOrDefault("TEST_KEY", "default_value"))
+	assert.Equal(t, "default_value", EnvOrDefault("NONEXISTENT_KEY", "default_value"))
+	t.Setenv("TEST_EMPTY", "")
+	assert.Equal(t, "default_value", EnvOrDefault("TEST_EMPTY", "default_value"))
+}
+
+func TestEnvAsIntOrDefault(t *testing.T) {
+	t.Setenv("TEST_INT", "123")
+	assert.Equal(t, 123, EnvAsIntOrDefault("TEST_INT", 456))
+	t.Setenv("TEST_INVALID", "not_a_number")
+	assert.Equal(t, 456, EnvAsIntOrDefault("TEST_INVALID", 456))
+	assert.Equal(t, 789, EnvAsIntOrDefault("NONEXISTENT_INT", 789))
+}
+
+func TestEnvAsInt64OrDefault(t *testing.T) {
+	t.Setenv("TEST_INT", "123")
+	assert.Equal(t, int64(123), EnvAsInt64OrDefault("TEST_INT", 456))
+	t.Setenv("TEST_INVALID", "not_a_number")
+	assert.Equal(t, int64(456), EnvAsInt64OrDefault("TEST_INVALID", 456))
+	assert.Equal(t, int64(789), EnvAsInt64OrDefault("NONEXISTENT_INT", 789))
+}
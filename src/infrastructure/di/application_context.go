@@ -1,37 +1,126 @@
 package di
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"sync"
 
+	"github.com/gbrayhan/microservices-go/src/application/alerting"
+	"github.com/gbrayhan/microservices-go/src/application/changefeed"
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
 	authUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/auth"
+	jobUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/job"
+	laboratoryUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/laboratory"
 	medicineUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/medicine"
+	organizationUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/organization"
+	stockUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/stock"
 	userUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/user"
+	webhookUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/webhook"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/alerts"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/events"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/jobs"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/cache"
+	memoryJob "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/job"
+	memoryLaboratory "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/laboratory"
+	memoryMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/medicine"
+	memoryOrganization "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/organization"
+	memoryStock "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/stock"
+	memoryUser "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/user"
+	memoryWebhook "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/webhook"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/job"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/organization"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/outbox"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/replica"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
 	authController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/auth"
+	jobController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/job"
+	laboratoryController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/laboratory"
 	medicineController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/medicine"
+	organizationController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/organization"
+	stockController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/stock"
+	streamController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/stream"
 	userController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/user"
+	webhookController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/webhook"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/scheduler"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/webhooks"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/workers"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // ApplicationContext holds all application dependencies and services
 type ApplicationContext struct {
-	DB                 *gorm.DB
-	Logger             *logger.Logger
-	AuthController     authController.IAuthController
-	UserController     userController.IUserController
-	MedicineController medicineController.IMedicineController
-	JWTService         security.IJWTService
-	UserRepository     user.UserRepositoryInterface
-	MedicineRepository medicine.MedicineRepositoryInterface
-	AuthUseCase        authUseCase.IAuthUseCase
-	UserUseCase        userUseCase.IUserUseCase
-	MedicineUseCase    medicineUseCase.IMedicineUseCase
+	DB                     *gorm.DB
+	Replicas               *replica.Set
+	Logger                 *logger.Logger
+	AuthController         authController.IAuthController
+	UserController         userController.IUserController
+	MedicineController     medicineController.IMedicineController
+	LaboratoryController   laboratoryController.ILaboratoryController
+	OrganizationController organizationController.IOrganizationController
+	WebhookController      webhookController.IWebhookController
+	StreamController       streamController.IStreamController
+	JobController          jobController.IJobController
+	StockController        stockController.IStockController
+	JWTService             security.IJWTService
+	UserRepository         user.UserRepositoryInterface
+	MedicineRepository     medicine.MedicineRepositoryInterface
+	LaboratoryRepository   laboratory.LaboratoryRepositoryInterface
+	OrganizationRepository organization.OrganizationRepositoryInterface
+	WebhookRepository      webhook.WebhookRepositoryInterface
+	JobRepository          job.JobRepositoryInterface
+	StockRepository        stock.StockRepositoryInterface
+	AuthUseCase            authUseCase.IAuthUseCase
+	UserUseCase            userUseCase.IUserUseCase
+	MedicineUseCase        medicineUseCase.IMedicineUseCase
+	LaboratoryUseCase      laboratoryUseCase.ILaboratoryUseCase
+	OrganizationUseCase    organizationUseCase.IOrganizationUseCase
+	WebhookUseCase         webhookUseCase.IWebhookUseCase
+	JobUseCase             jobUseCase.IJobUseCase
+	StockUseCase           stockUseCase.IStockUseCase
+	EventBus               *eventbus.Bus
+	ChangeFeed             *changefeed.Feed
+	PurgeWorker            *workers.PurgeWorker
+	OutboxRelay            *outbox.Relay
+	OutboxListener         *outbox.Listener
+	WebhookDispatcher      *webhooks.Dispatcher
+	JobRunner              *jobs.Runner
+	Scheduler              *scheduler.Scheduler
+	CacheMetrics           map[string]*cache.Metrics
 }
 
+// repositories are the storage-backed dependencies built by setupRepositories
+type repositories struct {
+	db           *gorm.DB
+	replicas     *replica.Set
+	user         user.UserRepositoryInterface
+	medicine     medicine.MedicineRepositoryInterface
+	laboratory   laboratory.LaboratoryRepositoryInterface
+	organization organization.OrganizationRepositoryInterface
+	webhook      webhook.WebhookRepositoryInterface
+	// webhookStore is the delivery log of the SQL drivers; nil for "memory"
+	webhookStore *webhook.Repository
+	job          job.JobRepositoryInterface
+	jobStore     jobs.Store
+	stock        stock.StockRepositoryInterface
+}
+
+// Storage drivers accepted by DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
 var (
 	loggerInstance *logger.Logger
 	loggerOnce     sync.Once
@@ -46,41 +135,244 @@ func GetLogger() *logger.Logger {
 
 // SetupDependencies creates a new application context with all dependencies
 func SetupDependencies(loggerInstance *logger.Logger) (*ApplicationContext, error) {
-	// Initialize database with logger
-	db, err := psql.InitPSQLDB(loggerInstance)
+	// Initialize database and repositories with logger
+	repos, err := setupRepositories(loggerInstance)
 	if err != nil {
 		return nil, err
 	}
+	cacheMetrics, err := setupCache(repos, loggerInstance)
+	if err != nil {
+		return nil, err
+	}
+	// medicines created before laboratories existed are linked through the repository,
+	// so each link is recorded, published and dropped from the cache as any update
+	if _, err := repos.medicine.LinkLaboratories(context.Background()); err != nil {
+		return nil, err
+	}
+	userRepo, medicineRepo := repos.user, repos.medicine
 
 	// Initialize JWT service (manages its own configuration)
 	jwtService := security.NewJWTService()
 
-	// Initialize repositories with logger
-	userRepo := user.NewUserRepository(db, loggerInstance)
-	medicineRepo := medicine.NewMedicineRepository(db, loggerInstance)
+	// Modules react to the use cases by subscribing to the event bus
+	eventBus := eventbus.New(loggerInstance)
+	feedConfig := changefeed.LoadConfig()
+	changeFeed := changefeed.New(feedConfig, loggerInstance)
+	if repos.db == nil {
+		changeFeed.Listen(eventBus)
+	}
 
 	// Initialize use cases with logger
 	authUC := authUseCase.NewAuthUseCase(userRepo, jwtService, loggerInstance)
-	userUC := userUseCase.NewUserUseCase(userRepo, loggerInstance)
-	medicineUC := medicineUseCase.NewMedicineUseCase(medicineRepo, loggerInstance)
+	userUC := userUseCase.NewUserUseCase(userRepo, eventBus, loggerInstance)
+	medicineUC := medicineUseCase.NewMedicineUseCase(medicineRepo, repos.laboratory, eventBus, loggerInstance)
+	laboratoryUC := laboratoryUseCase.NewLaboratoryUseCase(repos.laboratory, medicineRepo, eventBus, loggerInstance)
+	organizationUC := organizationUseCase.NewOrganizationUseCase(repos.organization, userRepo, loggerInstance)
+	webhookUC := webhookUseCase.NewWebhookUseCase(repos.webhook, loggerInstance)
+	jobUC := jobUseCase.NewJobUseCase(repos.job, loggerInstance)
+	stockUC := stockUseCase.NewStockUseCase(repos.stock, medicineRepo, eventBus, loggerInstance)
+	alertsConfig := alerts.LoadConfig()
+	alertNotifier, err := alerts.NewNotifier(alertsConfig, loggerInstance)
+	if err != nil {
+		return nil, err
+	}
+	alerting.Listen(eventBus, stockUC, alertNotifier)
 
 	// Initialize controllers with logger
 	authController := authController.NewAuthController(authUC, loggerInstance)
 	userController := userController.NewUserController(userUC, loggerInstance)
 	medicineController := medicineController.NewMedicineController(medicineUC, loggerInstance)
+	laboratoryController := laboratoryController.NewLaboratoryController(laboratoryUC, loggerInstance)
+	organizationController := organizationController.NewOrganizationController(organizationUC, loggerInstance)
+	webhookController := webhookController.NewWebhookController(webhookUC, loggerInstance)
+	streamController := streamController.NewStreamController(changeFeed, feedConfig.Heartbeat, loggerInstance)
+	jobController := jobController.NewJobController(jobUC, loggerInstance)
+	stockController := stockController.NewStockController(stockUC, loggerInstance)
+
+	// Initialize background workers
+	purgeWorker := workers.NewPurgeWorker(workers.LoadPurgeConfig(), map[string]workers.Purger{
+		"users":     userUC,
+		"medicines": medicineUC,
+	}, loggerInstance)
+	snapshotWorker := workers.NewSnapshotWorker(repos.stock, loggerInstance)
+	alertsWorker := workers.NewAlertsWorker(stockUC, alertsConfig.ExpiryDays, loggerInstance)
+	reportWorker := workers.NewReportWorker(stockUC, alertsConfig.ExpiryDays, loggerInstance)
+	// Job handlers are registered before the runner starts
+	jobRunner := jobs.NewRunner(repos.jobStore, jobs.LoadConfig(), loggerInstance)
+	jobs.Register(jobRunner, workers.PurgeJob, purgeWorker.HandlePurgeJob)
+	jobs.Register(jobRunner, workers.SnapshotJob, snapshotWorker.HandleSnapshotJob)
+	jobs.Register(jobRunner, workers.AlertsJob, alertsWorker.HandleAlertsJob)
+	jobs.Register(jobRunner, workers.ReportJob, reportWorker.HandleReportJob)
+	recurring, err := setupScheduler(repos.db, jobRunner, loggerInstance)
+	if err != nil {
+		return nil, err
+	}
+	// The outbox is written by the SQL repositories only, so the memory driver has no
+	// relay and delivers no webhooks. On PostgreSQL the relay of one instance announces
+	// each event to the change feeds of all instances.
+	var outboxRelay *outbox.Relay
+	var outboxListener *outbox.Listener
+	var webhookDispatcher *webhooks.Dispatcher
+	if repos.db != nil {
+		eventsConfig := events.LoadConfig()
+		publisher, err := events.NewPublisher(eventsConfig, loggerInstance)
+		if err != nil {
+			return nil, err
+		}
+		webhookConfig := webhooks.LoadConfig()
+		webhookConfig.Source = eventsConfig.Source
+		webhookDispatcher = webhooks.NewDispatcher(repos.webhookStore, webhookConfig, loggerInstance)
+		var feedPublisher event.Publisher = changeFeed
+		if repos.db.Dialector.Name() == DriverPostgres {
+			feedPublisher = outbox.NewNotifier(repos.db)
+			outboxListener = outbox.NewListener(repos.db, changeFeed, loggerInstance)
+		}
+		outboxRelay = outbox.NewRelay(repos.db, events.Fanout{publisher, webhookDispatcher, feedPublisher}, outbox.LoadRelayConfig(), loggerInstance)
+	}
 
 	return &ApplicationContext{
-		DB:                 db,
-		Logger:             loggerInstance,
-		AuthController:     authController,
-		UserController:     userController,
-		MedicineController: medicineController,
-		JWTService:         jwtService,
-		UserRepository:     userRepo,
-		MedicineRepository: medicineRepo,
-		AuthUseCase:        authUC,
-		UserUseCase:        userUC,
-		MedicineUseCase:    medicineUC,
+		DB:                     repos.db,
+		Replicas:               repos.replicas,
+		Logger:                 loggerInstance,
+		AuthController:         authController,
+		UserController:         userController,
+		MedicineController:     medicineController,
+		LaboratoryController:   laboratoryController,
+		OrganizationController: organizationController,
+		WebhookController:      webhookController,
+		StreamController:       streamController,
+		JobController:          jobController,
+		StockController:        stockController,
+		JWTService:             jwtService,
+		UserRepository:         userRepo,
+		MedicineRepository:     medicineRepo,
+		LaboratoryRepository:   repos.laboratory,
+		OrganizationRepository: repos.organization,
+		WebhookRepository:      repos.webhook,
+		JobRepository:          repos.job,
+		StockRepository:        repos.stock,
+		AuthUseCase:            authUC,
+		UserUseCase:            userUC,
+		MedicineUseCase:        medicineUC,
+		LaboratoryUseCase:      laboratoryUC,
+		OrganizationUseCase:    organizationUC,
+		WebhookUseCase:         webhookUC,
+		JobUseCase:             jobUC,
+		StockUseCase:           stockUC,
+		EventBus:               eventBus,
+		ChangeFeed:             changeFeed,
+		PurgeWorker:            purgeWorker,
+		OutboxRelay:            outboxRelay,
+		OutboxListener:         outboxListener,
+		WebhookDispatcher:      webhookDispatcher,
+		JobRunner:              jobRunner,
+		Scheduler:              recurring,
+		CacheMetrics:           cacheMetrics,
+	}, nil
+}
+
+// setupRepositories builds the repositories on the storage selected by DB_DRIVER:
+// "postgres" (default), "sqlite" or "memory". The returned DB is nil for "memory",
+// and only PostgreSQL has read replicas.
+func setupRepositories(loggerInstance *logger.Logger) (*repositories, error) {
+	var db *gorm.DB
+	var replicas *replica.Set
+	var err error
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "", DriverPostgres:
+		db, replicas, err = psql.InitPSQLDBWithReplicas(loggerInstance)
+	case DriverSQLite:
+		db, err = psql.InitSQLiteDB(loggerInstance)
+	case DriverMemory:
+		userRepo := memoryUser.NewUserRepository(loggerInstance)
+		if err := memoryUser.SeedInitialUser(userRepo, memoryOrganization.DefaultID, loggerInstance); err != nil {
+			return nil, err
+		}
+		loggerInstance.Info("Using in-memory repositories; data is lost on exit")
+		jobRepo := memoryJob.NewJobRepository(loggerInstance)
+		stockRepo := memoryStock.NewStockRepository(loggerInstance)
+		medicineRepo := memoryMedicine.NewMedicineRepositoryWithStock(loggerInstance, stockRepo)
+		return &repositories{
+			user:         userRepo,
+			medicine:     medicineRepo,
+			laboratory:   memoryLaboratory.NewLaboratoryRepository(loggerInstance, medicineRepo.(memoryLaboratory.Medicines)),
+			organization: memoryOrganization.NewOrganizationRepository(loggerInstance),
+			webhook:      memoryWebhook.NewWebhookRepository(loggerInstance),
+			job:          jobRepo,
+			jobStore:     jobRepo,
+			stock:        stockRepo,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q: expected %s, %s or %s", driver, DriverPostgres, DriverSQLite, DriverMemory)
+	}
+	if err != nil {
+		return nil, err
+	}
+	webhookRepo := webhook.NewWebhookRepository(db, loggerInstance)
+	jobRepo := job.NewJobRepository(db, loggerInstance)
+	return &repositories{
+		db:           db,
+		replicas:     replicas,
+		user:         user.NewUserRepositoryWithReplicas(db, replicas, loggerInstance),
+		medicine:     medicine.NewMedicineRepositoryWithReplicas(db, replicas, loggerInstance),
+		laboratory:   laboratory.NewLaboratoryRepository(db, loggerInstance),
+		organization: organization.NewOrganizationRepository(db, loggerInstance),
+		webhook:      webhookRepo,
+		webhookStore: webhookRepo,
+		job:          jobRepo,
+		jobStore:     jobRepo,
+		stock:        stock.NewStockRepository(db, loggerInstance),
+	}, nil
+}
+
+// setupScheduler schedules the recurring maintenance jobs. Replicas sharing a
+// PostgreSQL database elect the one that runs each schedule; with the other drivers
+// there is a single replica, which runs them all.
+func setupScheduler(db *gorm.DB, runner *jobs.Runner, loggerInstance *logger.Logger) (*scheduler.Scheduler, error) {
+	var elector scheduler.Elector = scheduler.Local{}
+	if db != nil && db.Dialector.Name() == DriverPostgres {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		elector = scheduler.NewAdvisoryLock(sqlDB)
+	}
+	recurring := scheduler.New(scheduler.LoadConfig(), elector, loggerInstance)
+	if err := recurring.Add(workers.PurgeJob.Name, "0 3 * * *",
+		scheduler.EnqueueTask(runner, workers.PurgeJob, workers.PurgeArgs{})); err != nil {
+		return nil, err
+	}
+	if err := recurring.Add(workers.SnapshotJob.Name, "*/15 * * * *",
+		scheduler.EnqueueTask(runner, workers.SnapshotJob, workers.SnapshotArgs{})); err != nil {
+		return nil, err
+	}
+	if err := recurring.Add(workers.AlertsJob.Name, "0 * * * *",
+		scheduler.EnqueueTask(runner, workers.AlertsJob, workers.AlertsArgs{})); err != nil {
+		return nil, err
+	}
+	if err := recurring.Add(workers.ReportJob.Name, "0 2 * * *",
+		scheduler.EnqueueTask(runner, workers.ReportJob, workers.ReportArgs{})); err != nil {
+		return nil, err
+	}
+	return recurring, nil
+}
+
+// setupCache wraps the user and medicine repositories with the read-through cache
+// selected by CACHE_DRIVER and returns their metrics; it leaves them untouched when
+// caching is disabled.
+func setupCache(repos *repositories, loggerInstance *logger.Logger) (map[string]*cache.Metrics, error) {
+	config := cache.LoadConfig()
+	backend, err := cache.NewBackend(config)
+	if err != nil || backend == nil {
+		return nil, err
+	}
+	users := cache.NewUserRepository(repos.user, backend, config.TTL, loggerInstance)
+	medicines := cache.NewMedicineRepository(repos.medicine, backend, config.TTL, loggerInstance)
+	repos.user, repos.medicine = users, medicines
+	loggerInstance.Info("Caching repository reads", zap.String("driver", config.Driver), zap.Duration("ttl", config.TTL))
+	return map[string]*cache.Metrics{
+		"users":     users.Metrics,
+		"medicines": medicines.Metrics,
 	}, nil
 }
 
@@ -88,29 +380,66 @@ func SetupDependencies(loggerInstance *logger.Logger) (*ApplicationContext, erro
 func NewTestApplicationContext(
 	mockUserRepo user.UserRepositoryInterface,
 	mockMedicineRepo medicine.MedicineRepositoryInterface,
+	mockOrganizationRepo organization.OrganizationRepositoryInterface,
 	mockJWTService security.IJWTService,
 	loggerInstance *logger.Logger,
 ) *ApplicationContext {
 	// Initialize use cases with mocked repositories and logger
+	eventBus := eventbus.New(loggerInstance)
+	changeFeed := changefeed.New(changefeed.LoadConfig(), loggerInstance)
+	changeFeed.Listen(eventBus)
 	authUC := authUseCase.NewAuthUseCase(mockUserRepo, mockJWTService, loggerInstance)
-	userUC := userUseCase.NewUserUseCase(mockUserRepo, loggerInstance)
-	medicineUC := medicineUseCase.NewMedicineUseCase(mockMedicineRepo, loggerInstance)
+	userUC := userUseCase.NewUserUseCase(mockUserRepo, eventBus, loggerInstance)
+	laboratoryRepo := memoryLaboratory.NewLaboratoryRepository(loggerInstance, nil)
+	medicineUC := medicineUseCase.NewMedicineUseCase(mockMedicineRepo, laboratoryRepo, eventBus, loggerInstance)
+	laboratoryUC := laboratoryUseCase.NewLaboratoryUseCase(laboratoryRepo, mockMedicineRepo, eventBus, loggerInstance)
+	organizationUC := organizationUseCase.NewOrganizationUseCase(mockOrganizationRepo, mockUserRepo, loggerInstance)
+	webhookRepo := memoryWebhook.NewWebhookRepository(loggerInstance)
+	webhookUC := webhookUseCase.NewWebhookUseCase(webhookRepo, loggerInstance)
+	jobRepo := memoryJob.NewJobRepository(loggerInstance)
+	jobUC := jobUseCase.NewJobUseCase(jobRepo, loggerInstance)
+	stockRepo := memoryStock.NewStockRepository(loggerInstance)
+	stockUC := stockUseCase.NewStockUseCase(stockRepo, mockMedicineRepo, eventBus, loggerInstance)
 
 	// Initialize controllers with logger
 	authController := authController.NewAuthController(authUC, loggerInstance)
 	userController := userController.NewUserController(userUC, loggerInstance)
 	medicineController := medicineController.NewMedicineController(medicineUC, loggerInstance)
+	laboratoryController := laboratoryController.NewLaboratoryController(laboratoryUC, loggerInstance)
+	organizationController := organizationController.NewOrganizationController(organizationUC, loggerInstance)
+	webhookController := webhookController.NewWebhookController(webhookUC, loggerInstance)
+	streamController := streamController.NewStreamController(changeFeed, 0, loggerInstance)
+	jobController := jobController.NewJobController(jobUC, loggerInstance)
+	stockController := stockController.NewStockController(stockUC, loggerInstance)
 
 	return &ApplicationContext{
-		Logger:             loggerInstance,
-		AuthController:     authController,
-		UserController:     userController,
-		MedicineController: medicineController,
-		JWTService:         mockJWTService,
-		UserRepository:     mockUserRepo,
-		MedicineRepository: mockMedicineRepo,
-		AuthUseCase:        authUC,
-		UserUseCase:        userUC,
-		MedicineUseCase:    medicineUC,
+		Logger:                 loggerInstance,
+		AuthController:         authController,
+		UserController:         userController,
+		MedicineController:     medicineController,
+		LaboratoryController:   laboratoryController,
+		OrganizationController: organizationController,
+		WebhookController:      webhookController,
+		StreamController:       streamController,
+		JobController:          jobController,
+		StockController:        stockController,
+		JWTService:             mockJWTService,
+		UserRepository:         mockUserRepo,
+		MedicineRepository:     mockMedicineRepo,
+		LaboratoryRepository:   laboratoryRepo,
+		OrganizationRepository: mockOrganizationRepo,
+		WebhookRepository:      webhookRepo,
+		JobRepository:          jobRepo,
+		StockRepository:        stockRepo,
+		AuthUseCase:            authUC,
+		UserUseCase:            userUC,
+		MedicineUseCase:        medicineUC,
+		LaboratoryUseCase:      laboratoryUC,
+		OrganizationUseCase:    organizationUC,
+		WebhookUseCase:         webhookUC,
+		JobUseCase:             jobUC,
+		StockUseCase:           stockUC,
+		EventBus:               eventBus,
+		ChangeFeed:             changeFeed,
 	}
 }
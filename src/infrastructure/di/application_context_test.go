@@ -1,17 +1,27 @@
 package di
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/gbrayhan/microservices-go/src/application/changefeed"
 	"github.com/gbrayhan/microservices-go/src/domain"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
 	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	domainOrganization "github.com/gbrayhan/microservices-go/src/domain/organization"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/cache"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/outbox"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock repositories and services
@@ -19,51 +29,76 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) GetAll() (*[]domainUser.User, error) {
+func (m *MockUserRepository) GetAll(_ context.Context) (*[]domainUser.User, error) {
 	args := m.Called()
 	return args.Get(0).(*[]domainUser.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByID(id int) (*domainUser.User, error) {
+func (m *MockUserRepository) GetByID(_ context.Context, id int) (*domainUser.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Create(user *domainUser.User) (*domainUser.User, error) {
+func (m *MockUserRepository) Create(_ context.Context, user *domainUser.User) (*domainUser.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*domainUser.User, error) {
+func (m *MockUserRepository) GetByEmail(_ context.Context, email string) (*domainUser.User, error) {
 	args := m.Called(email)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Delete(id int) error {
+func (m *MockUserRepository) Delete(_ context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Update(id int, userMap map[string]interface{}) (*domainUser.User, error) {
+func (m *MockUserRepository) Update(_ context.Context, id int, userMap map[string]interface{}) (*domainUser.User, error) {
 	args := m.Called(id, userMap)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserRepository) SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+func (m *MockUserRepository) SearchPaginated(_ context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
 	args := m.Called(filters)
 	return args.Get(0).(*domainUser.SearchResultUser), args.Error(1)
 }
 
-func (m *MockUserRepository) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (m *MockUserRepository) SearchByProperty(_ context.Context, property string, searchText string) (*[]string, error) {
 	args := m.Called(property, searchText)
 	return args.Get(0).(*[]string), args.Error(1)
 }
 
+func (m *MockUserRepository) GetTrash(_ context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	args := m.Called(filters)
+	return args.Get(0).(*domainUser.SearchResultUser), args.Error(1)
+}
+
+func (m *MockUserRepository) Restore(_ context.Context, id int) (*domainUser.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domainUser.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Purge(_ context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) GetHistory(_ context.Context, id int) (*[]domainHistory.Entry, error) {
+	args := m.Called(id)
+	return args.Get(0).(*[]domainHistory.Entry), args.Error(1)
+}
+
+func (m *MockUserRepository) GetAsOf(_ context.Context, id int, at time.Time) (*domainUser.User, error) {
+	args := m.Called(id, at)
+	return args.Get(0).(*domainUser.User), args.Error(1)
+}
+
 type MockMedicineRepository struct {
 	mock.Mock
 }
 
-func (m *MockMedicineRepository) GetAll() (*[]domainMedicine.Medicine, error) {
+func (m *MockMedicineRepository) GetAll(_ context.Context) (*[]domainMedicine.Medicine, error) {
 	args := m.Called()
 	return args.Get(0).(*[]domainMedicine.Medicine), args.Error(1)
 }
@@ -73,12 +108,17 @@ func (m *MockMedicineRepository) GetData(page int64, limit int64, sortBy string,
 	return args.Get(0).(*domainMedicine.DataMedicine), args.Error(1)
 }
 
-func (m *MockMedicineRepository) GetByID(id int) (*domainMedicine.Medicine, error) {
+func (m *MockMedicineRepository) GetByID(_ context.Context, id int) (*domainMedicine.Medicine, error) {
 	args := m.Called(id)
 	return args.Get(0).(*domainMedicine.Medicine), args.Error(1)
 }
 
-func (m *MockMedicineRepository) Create(medicine *domainMedicine.Medicine) (*domainMedicine.Medicine, error) {
+func (m *MockMedicineRepository) GetByEanCode(_ context.Context, eanCodes []string) (*domainMedicine.Medicine, error) {
+	args := m.Called(eanCodes)
+	return args.Get(0).(*domainMedicine.Medicine), args.Error(1)
+}
+
+func (m *MockMedicineRepository) Create(_ context.Context, medicine *domainMedicine.Medicine) (*domainMedicine.Medicine, error) {
 	args := m.Called(medicine)
 	return args.Get(0).(*domainMedicine.Medicine), args.Error(1)
 }
@@ -88,31 +128,125 @@ func (m *MockMedicineRepository) GetByMap(medicineMap map[string]any) (*domainMe
 	return args.Get(0).(*domainMedicine.Medicine), args.Error(1)
 }
 
-func (m *MockMedicineRepository) Delete(id int) error {
+func (m *MockMedicineRepository) Delete(_ context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockMedicineRepository) Update(id int, medicineMap map[string]any) (*domainMedicine.Medicine, error) {
+func (m *MockMedicineRepository) Update(_ context.Context, id int, medicineMap map[string]any) (*domainMedicine.Medicine, error) {
 	args := m.Called(id, medicineMap)
 	return args.Get(0).(*domainMedicine.Medicine), args.Error(1)
 }
 
-func (m *MockMedicineRepository) SearchPaginated(filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
+func (m *MockMedicineRepository) SearchPaginated(_ context.Context, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
 	args := m.Called(filters)
 	return args.Get(0).(*domainMedicine.SearchResultMedicine), args.Error(1)
 }
 
-func (m *MockMedicineRepository) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (m *MockMedicineRepository) SearchByProperty(_ context.Context, property string, searchText string) (*[]string, error) {
 	args := m.Called(property, searchText)
 	return args.Get(0).(*[]string), args.Error(1)
 }
 
+func (m *MockMedicineRepository) GetTrash(_ context.Context, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
+	args := m.Called(filters)
+	return args.Get(0).(*domainMedicine.SearchResultMedicine), args.Error(1)
+}
+
+func (m *MockMedicineRepository) Restore(_ context.Context, id int) (*domainMedicine.Medicine, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domainMedicine.Medicine), args.Error(1)
+}
+
+func (m *MockMedicineRepository) Purge(_ context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMedicineRepository) GetHistory(_ context.Context, id int) (*[]domainHistory.Entry, error) {
+	args := m.Called(id)
+	return args.Get(0).(*[]domainHistory.Entry), args.Error(1)
+}
+
+func (m *MockMedicineRepository) GetAsOf(_ context.Context, id int, at time.Time) (*domainMedicine.Medicine, error) {
+	args := m.Called(id, at)
+	return args.Get(0).(*domainMedicine.Medicine), args.Error(1)
+}
+
+func (m *MockMedicineRepository) RenameLaboratory(_ context.Context, laboratoryID int, name string) (*domainLaboratory.Laboratory, *[]domainMedicine.Medicine, error) {
+	args := m.Called(laboratoryID, name)
+	return args.Get(0).(*domainLaboratory.Laboratory), args.Get(1).(*[]domainMedicine.Medicine), args.Error(2)
+}
+
+func (m *MockMedicineRepository) LinkLaboratories(_ context.Context) (*[]domainMedicine.Medicine, error) {
+	args := m.Called()
+	return args.Get(0).(*[]domainMedicine.Medicine), args.Error(1)
+}
+
+func (m *MockMedicineRepository) GetSubstituteCandidates(_ context.Context, medicine *domainMedicine.Medicine, ids []int) (*[]domainMedicine.Medicine, error) {
+	args := m.Called(medicine, ids)
+	return args.Get(0).(*[]domainMedicine.Medicine), args.Error(1)
+}
+
+func (m *MockMedicineRepository) GetEquivalences(_ context.Context, medicineID int) (*[]domainMedicine.Equivalence, error) {
+	args := m.Called(medicineID)
+	return args.Get(0).(*[]domainMedicine.Equivalence), args.Error(1)
+}
+
+func (m *MockMedicineRepository) SaveEquivalence(_ context.Context, equivalence *domainMedicine.Equivalence) (*domainMedicine.Equivalence, error) {
+	args := m.Called(equivalence)
+	return args.Get(0).(*domainMedicine.Equivalence), args.Error(1)
+}
+
+func (m *MockMedicineRepository) DeleteEquivalence(_ context.Context, id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockMedicineRepository) SaveInteractions(_ context.Context, interactions []domainMedicine.Interaction) (int, error) {
+	args := m.Called(interactions)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockMedicineRepository) SaveIngredientClasses(_ context.Context, classes []domainMedicine.IngredientClass) (int, error) {
+	args := m.Called(classes)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockMedicineRepository) GetIngredientClasses(_ context.Context, ingredients []string) (*[]domainMedicine.IngredientClass, error) {
+	args := m.Called(ingredients)
+	return args.Get(0).(*[]domainMedicine.IngredientClass), args.Error(1)
+}
+
+func (m *MockMedicineRepository) GetInteractions(_ context.Context, subjects []domainMedicine.InteractionSubject) (*[]domainMedicine.Interaction, error) {
+	args := m.Called(subjects)
+	return args.Get(0).(*[]domainMedicine.Interaction), args.Error(1)
+}
+
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationRepository) Create(_ context.Context, organization *domainOrganization.Organization) (*domainOrganization.Organization, error) {
+	args := m.Called(organization)
+	return args.Get(0).(*domainOrganization.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) GetByID(_ context.Context, id int) (*domainOrganization.Organization, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domainOrganization.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) Delete(_ context.Context, id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 type MockJWTService struct {
 	mock.Mock
 }
 
-func (m *MockJWTService) GenerateJWTToken(userID int, tokenType string) (*security.AppToken, error) {
+func (m *MockJWTService) GenerateJWTToken(userID int, _ int, tokenType string) (*security.AppToken, error) {
 	args := m.Called(userID, tokenType)
 	return args.Get(0).(*security.AppToken), args.Error(1)
 }
@@ -133,25 +267,29 @@ func setupLogger(t *testing.T) *logger.Logger {
 func TestNewTestApplicationContext(t *testing.T) {
 	mockUserRepo := &MockUserRepository{}
 	mockMedicineRepo := &MockMedicineRepository{}
+	mockOrganizationRepo := &MockOrganizationRepository{}
 	mockJWTService := &MockJWTService{}
 	logger := setupLogger(t)
 
-	appContext := NewTestApplicationContext(mockUserRepo, mockMedicineRepo, mockJWTService, logger)
+	appContext := NewTestApplicationContext(mockUserRepo, mockMedicineRepo, mockOrganizationRepo, mockJWTService, logger)
 
 	assert.NotNil(t, appContext)
 	assert.Equal(t, mockUserRepo, appContext.UserRepository)
 	assert.Equal(t, mockMedicineRepo, appContext.MedicineRepository)
+	assert.Equal(t, mockOrganizationRepo, appContext.OrganizationRepository)
 	assert.Equal(t, mockJWTService, appContext.JWTService)
 
 	// Test that controllers are created
 	assert.NotNil(t, appContext.AuthController)
 	assert.NotNil(t, appContext.UserController)
 	assert.NotNil(t, appContext.MedicineController)
+	assert.NotNil(t, appContext.OrganizationController)
 
 	// Test that use cases are created
 	assert.NotNil(t, appContext.AuthUseCase)
 	assert.NotNil(t, appContext.UserUseCase)
 	assert.NotNil(t, appContext.MedicineUseCase)
+	assert.NotNil(t, appContext.OrganizationUseCase)
 }
 
 func TestSetupDependencies(t *testing.T) {
@@ -168,13 +306,130 @@ func TestSetupDependencies(t *testing.T) {
 	assert.Nil(t, appContext)
 }
 
+func TestSetupDependencies_MemoryDriver(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverMemory)
+	t.Setenv("START_USER_EMAIL", "admin@example.com")
+	t.Setenv("START_USER_PW", "secret")
+
+	appContext, err := SetupDependencies(setupLogger(t))
+
+	require.NoError(t, err)
+	assert.Nil(t, appContext.DB)
+	assert.Nil(t, appContext.OutboxRelay)
+	assert.Nil(t, appContext.WebhookDispatcher)
+	assert.NotNil(t, appContext.JobRunner, "jobs run from memory")
+	assert.NotNil(t, appContext.Scheduler, "the purge is scheduled")
+	seeded, err := appContext.UserRepository.GetByEmail(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	assert.NotEmpty(t, seeded.HashPassword)
+	organization, err := appContext.OrganizationRepository.GetByID(context.Background(), seeded.TenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "Default", organization.Name)
+}
+
+func TestSetupDependencies_MemoryCache(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverMemory)
+	t.Setenv("CACHE_DRIVER", cache.DriverMemory)
+	t.Setenv("START_USER_EMAIL", "admin@example.com")
+	t.Setenv("START_USER_PW", "secret")
+
+	appContext, err := SetupDependencies(setupLogger(t))
+
+	require.NoError(t, err)
+	assert.IsType(t, &cache.UserRepository{}, appContext.UserRepository)
+	assert.IsType(t, &cache.MedicineRepository{}, appContext.MedicineRepository)
+	assert.Contains(t, appContext.CacheMetrics, "medicines")
+	assert.Contains(t, appContext.CacheMetrics, "users")
+}
+
+func TestSetupDependencies_LaboratoryRenameReachesCachedMedicines(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("SQLITE_PATH", ":memory:")
+	t.Setenv("CACHE_DRIVER", cache.DriverMemory)
+
+	appContext, err := SetupDependencies(setupLogger(t))
+	require.NoError(t, err)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 7, TenantID: 1})
+	created, err := appContext.MedicineUseCase.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "4006381333931", Laboratory: "Pfizer"})
+	require.NoError(t, err)
+	cached, err := appContext.MedicineUseCase.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Pfizer", cached.Laboratory)
+	coincidences, err := appContext.MedicineUseCase.SearchByProperty(ctx, "laboratory", "pfi")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Pfizer"}, *coincidences)
+	// the change feed is fed by the outbox relay
+	_, err = appContext.OutboxRelay.RunOnce(ctx)
+	require.NoError(t, err)
+	_, feed, _ := appContext.ChangeFeed.Subscribe(changefeed.Filter{TenantID: 1, Resources: []string{event.AggregateMedicine}}, "")
+	defer appContext.ChangeFeed.Unsubscribe(feed)
+
+	_, err = appContext.LaboratoryUseCase.Update(ctx, *created.LaboratoryID, map[string]any{"name": "Pfizer Inc."})
+	require.NoError(t, err)
+
+	renamed, err := appContext.MedicineUseCase.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Inc.", renamed.Laboratory)
+	assert.Equal(t, 7, *renamed.UpdatedBy)
+	coincidences, err = appContext.MedicineUseCase.SearchByProperty(ctx, "laboratory", "pfi")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pfizer Inc."}, *coincidences)
+	entries, err := appContext.MedicineUseCase.GetHistory(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainHistory.ActionUpdate, (*entries)[len(*entries)-1].Action)
+	var messages []outbox.Message
+	require.NoError(t, appContext.DB.Where("event_type = ?", event.MedicineUpdated).Find(&messages).Error)
+	require.Len(t, messages, 1)
+	assert.Equal(t, created.ID, messages[0].AggregateID)
+	_, err = appContext.OutboxRelay.RunOnce(ctx)
+	require.NoError(t, err)
+	select {
+	case notification := <-feed.C:
+		assert.Equal(t, created.ID, notification.ResourceID)
+		assert.Equal(t, []string{"laboratory"}, notification.Fields)
+	case <-time.After(time.Second):
+		t.Fatal("the rename reached no change feed subscriber")
+	}
+}
+
+func TestSetupDependencies_UnknownCacheDriver(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverMemory)
+	t.Setenv("CACHE_DRIVER", "memcached")
+
+	appContext, err := SetupDependencies(setupLogger(t))
+
+	assert.ErrorContains(t, err, "unknown CACHE_DRIVER")
+	assert.Nil(t, appContext)
+}
+
+func TestSetupDependencies_UnknownEventsBroker(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("SQLITE_PATH", ":memory:")
+	t.Setenv("EVENTS_BROKER", "carrier-pigeon")
+
+	appContext, err := SetupDependencies(setupLogger(t))
+
+	assert.ErrorContains(t, err, "unknown EVENTS_BROKER")
+	assert.Nil(t, appContext)
+}
+
+func TestSetupDependencies_UnknownDriver(t *testing.T) {
+	t.Setenv("DB_DRIVER", "oracle")
+
+	appContext, err := SetupDependencies(setupLogger(t))
+
+	assert.ErrorContains(t, err, "unknown DB_DRIVER")
+	assert.Nil(t, appContext)
+}
+
 func TestApplicationContextStructure(t *testing.T) {
 	mockUserRepo := &MockUserRepository{}
 	mockMedicineRepo := &MockMedicineRepository{}
+	mockOrganizationRepo := &MockOrganizationRepository{}
 	mockJWTService := &MockJWTService{}
 	logger := setupLogger(t)
 
-	appContext := NewTestApplicationContext(mockUserRepo, mockMedicineRepo, mockJWTService, logger)
+	appContext := NewTestApplicationContext(mockUserRepo, mockMedicineRepo, mockOrganizationRepo, mockJWTService, logger)
 
 	// Test that all fields are properly set
 	assert.NotNil(t, appContext.AuthController)
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var errNotConfirmed = errors.New("amqp: the broker did not confirm the message")
+
+// amqpPermanentCodes are the reply codes of the broker that retrying cannot fix
+var amqpPermanentCodes = []int{amqp.ContentTooLarge, amqp.AccessRefused, amqp.NotFound}
+
+// amqpChannel publishes on an AMQP channel and waits for the broker confirmation
+type amqpChannel interface {
+	Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error
+	IsClosed() bool
+	Close() error
+}
+
+// AMQPSender publishes messages to a durable topic exchange of a RabbitMQ (AMQP 0-9-1)
+// broker, using the topic as routing key. Messages are persistent and every publish
+// waits for the publisher confirmation. Messages no queue is bound for are dropped
+// by the broker.
+type AMQPSender struct {
+	url      string
+	exchange string
+	dial     func(url, exchange string) (amqpChannel, error)
+
+	mu      sync.Mutex
+	channel amqpChannel
+}
+
+// NewAMQPSender returns a sender publishing to exchange on the broker at url. The
+// connection is opened on the first send and reopened after it is lost.
+func NewAMQPSender(url, exchange string) *AMQPSender {
+	return &AMQPSender{url: url, exchange: exchange, dial: dialAMQP}
+}
+
+func (s *AMQPSender) Send(ctx context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.channel == nil || s.channel.IsClosed() {
+		channel, err := s.dial(s.url, s.exchange)
+		if err != nil {
+			return err
+		}
+		s.channel = channel
+	}
+	err := s.channel.Publish(ctx, s.exchange, message.Topic, amqp.Publishing{
+		ContentType:  ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    message.ID,
+		Body:         message.Payload,
+	})
+	if err != nil {
+		// a fresh connection is opened on the next send
+		_ = s.channel.Close()
+		s.channel = nil
+	}
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) && slices.Contains(amqpPermanentCodes, amqpErr.Code) {
+		return permanent(err)
+	}
+	return err
+}
+
+func (s *AMQPSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.channel == nil {
+		return nil
+	}
+	err := s.channel.Close()
+	s.channel = nil
+	return err
+}
+
+// amqpConnection is an AMQP connection with a single channel in confirm mode
+type amqpConnection struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+func dialAMQP(url, exchange string) (amqpChannel, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	channel, err := conn.Channel()
+	if err == nil {
+		err = channel.ExchangeDeclare(exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+	}
+	if err == nil {
+		err = channel.Confirm(false)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &amqpConnection{conn: conn, channel: channel}, nil
+}
+
+func (c *amqpConnection) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	confirmation, err := c.channel.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, msg)
+	if err != nil {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errNotConfirmed
+	}
+	return nil
+}
+
+func (c *amqpConnection) IsClosed() bool {
+	return c.channel.IsClosed() || c.conn.IsClosed()
+}
+
+func (c *amqpConnection) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// BrokerPublisher publishes events as CloudEvents through a Sender. A send is retried
+// up to Attempts times with a doubling back-off and the error is then returned, so the
+// outbox retries the event later and holds back the events of its aggregate meanwhile.
+// Only an event the broker rejects for good, as too large or for a topic it refuses,
+// is moved to the dead-letter topic at once: it no longer holds back the events after
+// it. When the dead-letter topic cannot be reached either the error is returned.
+type BrokerPublisher struct {
+	sender Sender
+	config Config
+	Logger *logger.Logger
+}
+
+func NewBrokerPublisher(sender Sender, config Config, loggerInstance *logger.Logger) *BrokerPublisher {
+	return &BrokerPublisher{
+		sender: sender,
+		config: config,
+		Logger: loggerInstance,
+	}
+}
+
+func (p *BrokerPublisher) Publish(ctx context.Context, e event.Event) error {
+	envelope := NewCloudEvent(e, p.config.Source)
+	message := Message{ID: e.ID, Topic: p.config.Topics.Topic(e.Type), Key: subjectOf(e)}
+	var err error
+	if message.Payload, err = json.Marshal(envelope); err != nil {
+		return err
+	}
+	sendErr := p.send(ctx, message)
+	if sendErr == nil {
+		return nil
+	}
+	if !isPermanent(sendErr) || p.config.DeadLetterTopic == "" || ctx.Err() != nil {
+		return sendErr
+	}
+
+	envelope.DeadLetterReason = sendErr.Error()
+	deadLetter := Message{ID: e.ID, Topic: p.config.DeadLetterTopic, Key: message.Key}
+	if deadLetter.Payload, err = json.Marshal(envelope); err != nil {
+		return err
+	}
+	if err := p.send(ctx, deadLetter); err != nil {
+		return fmt.Errorf("publishing to %s: %w; dead-lettering: %v", message.Topic, sendErr, err)
+	}
+	p.Logger.Warn("Moved event to the dead-letter topic",
+		zap.Error(sendErr),
+		zap.String("eventId", e.ID),
+		zap.String("type", e.Type),
+		zap.String("topic", message.Topic),
+		zap.String("deadLetterTopic", p.config.DeadLetterTopic))
+	return nil
+}
+
+// Close releases the broker connection
+func (p *BrokerPublisher) Close() error {
+	return p.sender.Close()
+}
+
+func (p *BrokerPublisher) send(ctx context.Context, message Message) error {
+	attempts := max(p.config.Attempts, 1)
+	delay := p.config.RetryBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = p.sendOnce(ctx, message); err == nil {
+			return nil
+		}
+		if attempt == attempts || isPermanent(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (p *BrokerPublisher) sendOnce(ctx context.Context, message Message) error {
+	if p.config.SendTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.SendTimeout)
+		defer cancel()
+	}
+	return p.sender.Send(ctx, message)
+}
+
+// permanentError is a send failure that retrying cannot fix
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// permanent marks err as a failure that retrying cannot fix. Senders mark the errors
+// they know to be permanent; any other error is taken as transient.
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+func isPermanent(err error) bool {
+	var permanentErr permanentError
+	return errors.As(err, &permanentErr)
+}
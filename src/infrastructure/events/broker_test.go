@@ -0,0 +1,169 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSender records the messages it accepted and fails the sends to the topics in
+// failures, with failure or else a transient error
+type fakeSender struct {
+	sent     []Message
+	attempts map[string]int
+	failures map[string]int
+	failure  error
+	closed   bool
+}
+
+func (s *fakeSender) Send(_ context.Context, message Message) error {
+	if s.attempts == nil {
+		s.attempts = make(map[string]int)
+	}
+	s.attempts[message.Topic]++
+	if s.failures[message.Topic] != 0 {
+		s.failures[message.Topic]--
+		if s.failure != nil {
+			return s.failure
+		}
+		return errors.New("broker unavailable")
+	}
+	s.sent = append(s.sent, message)
+	return nil
+}
+
+func (s *fakeSender) Close() error {
+	s.closed = true
+	return nil
+}
+
+func newTestPublisher(t *testing.T, sender Sender) *BrokerPublisher {
+	t.Helper()
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewBrokerPublisher(sender, Config{
+		Source:          "/test",
+		Topics:          Topics{Template: "events.{type}"},
+		DeadLetterTopic: "events.dead-letter",
+		Attempts:        3,
+		RetryBackoff:    time.Millisecond,
+	}, loggerInstance)
+}
+
+func TestBrokerPublisher_Publish(t *testing.T) {
+	sender := &fakeSender{}
+	publisher := newTestPublisher(t, sender)
+
+	require.NoError(t, publisher.Publish(context.Background(), testEvent()))
+	require.Len(t, sender.sent, 1)
+	message := sender.sent[0]
+	assert.Equal(t, "events.medicine.updated", message.Topic)
+	assert.Equal(t, "medicine/12", message.Key)
+	assert.Equal(t, testEvent().ID, message.ID)
+	decoded, err := Decode(message.Payload)
+	require.NoError(t, err)
+	assert.Equal(t, testEvent(), decoded)
+
+	require.NoError(t, publisher.Close())
+	assert.True(t, sender.closed)
+}
+
+func TestBrokerPublisher_RetriesTransientFailures(t *testing.T) {
+	sender := &fakeSender{failures: map[string]int{"events.medicine.updated": 2}}
+	publisher := newTestPublisher(t, sender)
+
+	require.NoError(t, publisher.Publish(context.Background(), testEvent()))
+	assert.Equal(t, 3, sender.attempts["events.medicine.updated"])
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "events.medicine.updated", sender.sent[0].Topic)
+}
+
+func TestBrokerPublisher_ReturnsTransientFailures(t *testing.T) {
+	sender := &fakeSender{failures: map[string]int{"events.medicine.updated": 10}}
+	publisher := newTestPublisher(t, sender)
+
+	err := publisher.Publish(context.Background(), testEvent())
+	assert.EqualError(t, err, "broker unavailable", "the outbox retries the event")
+	assert.Equal(t, 3, sender.attempts["events.medicine.updated"])
+	assert.Empty(t, sender.sent, "a transient failure is not dead-lettered")
+}
+
+func TestBrokerPublisher_DeadLetters(t *testing.T) {
+	sender := &fakeSender{failures: map[string]int{"events.medicine.updated": 10}, failure: permanent(errors.New("message too large"))}
+	publisher := newTestPublisher(t, sender)
+
+	require.NoError(t, publisher.Publish(context.Background(), testEvent()))
+	assert.Equal(t, 1, sender.attempts["events.medicine.updated"], "a permanent failure is not retried")
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "events.dead-letter", sender.sent[0].Topic)
+
+	var envelope CloudEvent
+	require.NoError(t, json.Unmarshal(sender.sent[0].Payload, &envelope))
+	assert.Equal(t, "message too large", envelope.DeadLetterReason)
+	assert.Equal(t, testEvent().ID, envelope.ID)
+}
+
+func TestBrokerPublisher_DeadLetterUnavailable(t *testing.T) {
+	sender := &fakeSender{failures: map[string]int{"events.medicine.updated": 10, "events.dead-letter": 10}, failure: permanent(errors.New("message too large"))}
+	publisher := newTestPublisher(t, sender)
+
+	err := publisher.Publish(context.Background(), testEvent())
+	assert.ErrorContains(t, err, "dead-lettering")
+	assert.Empty(t, sender.sent)
+
+	publisher.config.DeadLetterTopic = ""
+	err = publisher.Publish(context.Background(), testEvent())
+	assert.EqualError(t, err, "message too large")
+}
+
+func TestNewPublisher(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+
+	publisher, err := NewPublisher(Config{}, loggerInstance)
+	require.NoError(t, err)
+	assert.IsType(t, &LogPublisher{}, publisher)
+	assert.NoError(t, publisher.Publish(context.Background(), testEvent()))
+
+	publisher, err = NewPublisher(Config{Broker: BrokerKafka, KafkaBrokers: []string{"localhost:9092"}}, loggerInstance)
+	require.NoError(t, err)
+	assert.IsType(t, &BrokerPublisher{}, publisher)
+
+	_, err = NewPublisher(Config{Broker: "carrier-pigeon"}, loggerInstance)
+	assert.ErrorContains(t, err, "unknown EVENTS_BROKER")
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("EVENTS_BROKER", BrokerNATS)
+	t.Setenv("EVENTS_TOPIC_OVERRIDES", "user.*=identity.users")
+	t.Setenv("KAFKA_BROKERS", "k1:9092,k2:9092")
+
+	config := LoadConfig()
+	assert.Equal(t, BrokerNATS, config.Broker)
+	assert.Equal(t, "{type}", config.Topics.Template)
+	assert.Equal(t, map[string]string{"user.*": "identity.users"}, config.Topics.Overrides)
+	assert.Equal(t, []string{"k1:9092", "k2:9092"}, config.KafkaBrokers)
+	assert.Equal(t, 3, config.Attempts)
+}
+
+// publisherFunc adapts a function to event.Publisher
+type publisherFunc func(ctx context.Context, e event.Event) error
+
+func (f publisherFunc) Publish(ctx context.Context, e event.Event) error { return f(ctx, e) }
+
+func TestFanout_PublishesToAll(t *testing.T) {
+	sender := &fakeSender{}
+	failing := publisherFunc(func(context.Context, event.Event) error { return errors.New("store unavailable") })
+	fanout := Fanout{failing, newTestPublisher(t, sender)}
+
+	err := fanout.Publish(context.Background(), testEvent())
+	assert.EqualError(t, err, "store unavailable")
+	assert.Len(t, sender.sent, 1, "a failing publisher does not stop the others")
+}
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	// ContentType is the media type of an event encoded as a structured CloudEvent
+	ContentType     = "application/cloudevents+json"
+	dataContentType = "application/json"
+)
+
+// CloudEvent is the CloudEvents 1.0 envelope events are published in. The tenant and
+// the actor travel as extension attributes; deadletterreason is only set on events
+// moved to the dead-letter topic.
+type CloudEvent struct {
+	SpecVersion      string          `json:"specversion"`
+	ID               string          `json:"id"`
+	Source           string          `json:"source"`
+	Type             string          `json:"type"`
+	Subject          string          `json:"subject,omitempty"`
+	Time             time.Time       `json:"time"`
+	DataContentType  string          `json:"datacontenttype"`
+	Data             json.RawMessage `json:"data,omitempty"`
+	TenantID         int             `json:"tenantid"`
+	ActorID          *int            `json:"actorid,omitempty"`
+	DeadLetterReason string          `json:"deadletterreason,omitempty"`
+}
+
+// NewCloudEvent wraps e in a CloudEvent emitted by source. The subject names the
+// changed record as <aggregate type>/<aggregate id>.
+func NewCloudEvent(e event.Event, source string) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              e.ID,
+		Source:          source,
+		Type:            e.Type,
+		Subject:         subjectOf(e),
+		Time:            e.OccurredAt.UTC(),
+		DataContentType: dataContentType,
+		Data:            e.Data,
+		TenantID:        e.TenantID,
+		ActorID:         e.ActorID,
+	}
+}
+
+// ToEvent returns the event carried by the envelope
+func (c CloudEvent) ToEvent() (event.Event, error) {
+	if c.SpecVersion != cloudEventsSpecVersion {
+		return event.Event{}, fmt.Errorf("unsupported CloudEvents specversion %q", c.SpecVersion)
+	}
+	aggregateType, aggregateID, found := strings.Cut(c.Subject, "/")
+	if !found {
+		return event.Event{}, fmt.Errorf("invalid CloudEvent subject %q", c.Subject)
+	}
+	id, err := strconv.Atoi(aggregateID)
+	if err != nil {
+		return event.Event{}, fmt.Errorf("invalid CloudEvent subject %q", c.Subject)
+	}
+	return event.Event{
+		ID:            c.ID,
+		Type:          c.Type,
+		AggregateType: aggregateType,
+		AggregateID:   id,
+		TenantID:      c.TenantID,
+		ActorID:       c.ActorID,
+		OccurredAt:    c.Time,
+		Data:          c.Data,
+	}, nil
+}
+
+// Decode parses a JSON encoded CloudEvent back into the event it carries
+func Decode(payload []byte) (event.Event, error) {
+	var envelope CloudEvent
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return event.Event{}, err
+	}
+	return envelope.ToEvent()
+}
+
+func subjectOf(e event.Event) string {
+	return e.AggregateType + "/" + strconv.Itoa(e.AggregateID)
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent() event.Event {
+	actor := 5
+	return event.Event{
+		ID:            "0b9b7c1e-2f33-4a57-9d7e-8f7a4e3c2a10",
+		Type:          event.MedicineUpdated,
+		AggregateType: event.AggregateMedicine,
+		AggregateID:   12,
+		TenantID:      3,
+		ActorID:       &actor,
+		OccurredAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:          json.RawMessage(`{"changes":{"name":{"before":"A","after":"B"}}}`),
+	}
+}
+
+func TestCloudEvent_Encoding(t *testing.T) {
+	payload, err := json.Marshal(NewCloudEvent(testEvent(), "/pharmacy"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "0b9b7c1e-2f33-4a57-9d7e-8f7a4e3c2a10",
+		"source": "/pharmacy",
+		"type": "medicine.updated",
+		"subject": "medicine/12",
+		"time": "2026-01-02T03:04:05Z",
+		"datacontenttype": "application/json",
+		"data": {"changes": {"name": {"before": "A", "after": "B"}}},
+		"tenantid": 3,
+		"actorid": 5
+	}`, string(payload))
+
+	decoded, err := Decode(payload)
+	require.NoError(t, err)
+	assert.Equal(t, testEvent(), decoded)
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	_, err := Decode([]byte(`{"specversion":"0.3","subject":"medicine/1"}`))
+	assert.ErrorContains(t, err, "specversion")
+	_, err = Decode([]byte(`{"specversion":"1.0","subject":"medicine"}`))
+	assert.ErrorContains(t, err, "subject")
+	_, err = Decode([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestTopics(t *testing.T) {
+	topics := Topics{
+		Template:  "pharmacy.{aggregate}.{action}",
+		Overrides: ParseTopicOverrides(" user.* = identity.users , medicine.deleted=pharmacy.removals,broken"),
+	}
+	assert.Equal(t, "pharmacy.medicine.created", topics.Topic(event.MedicineCreated))
+	assert.Equal(t, "pharmacy.removals", topics.Topic(event.MedicineDeleted))
+	assert.Equal(t, "identity.users", topics.Topic(event.UserRestored))
+	assert.Len(t, topics.Overrides, 2)
+
+	assert.Equal(t, "medicine.created", Topics{}.Topic(event.MedicineCreated))
+	assert.Equal(t, "events.medicine.created", Topics{Template: "events.{type}"}.Topic(event.MedicineCreated))
+}
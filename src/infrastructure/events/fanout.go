@@ -0,0 +1,23 @@
+package events
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+)
+
+// Fanout publishes every event to each of its publishers. It fails when any of them
+// failed, so the outbox retries the event with all of them: publishers must tolerate
+// receiving an event again.
+type Fanout []event.Publisher
+
+func (f Fanout) Publish(ctx context.Context, e event.Event) error {
+	var errs []error
+	for _, publisher := range f {
+		if err := publisher.Publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
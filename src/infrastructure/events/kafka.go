@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaWriter is the part of *kafka.Writer the sender uses
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// KafkaSender produces messages to the Kafka topic named after their topic. Messages
+// are keyed by the changed record, so the events of one record share a partition and
+// keep their order.
+type KafkaSender struct {
+	writer kafkaWriter
+}
+
+// NewKafkaSender returns a sender producing to the cluster reachable through brokers.
+// Connections are opened on the first send.
+func NewKafkaSender(brokers []string) *KafkaSender {
+	return &KafkaSender{writer: &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+		// the publisher retries on its own and sends one event at a time
+		MaxAttempts:  1,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+// kafkaPermanentErrors are the produce errors that retrying cannot fix. An unknown
+// topic is not one of them: topics are created on their first message and reported
+// unknown until they are.
+var kafkaPermanentErrors = []kafka.Error{
+	kafka.MessageSizeTooLarge,
+	kafka.RecordListTooLarge,
+	kafka.InvalidRecord,
+	kafka.InvalidTopic,
+	kafka.TopicAuthorizationFailed,
+	kafka.ClusterAuthorizationFailed,
+	kafka.SASLAuthenticationFailed,
+}
+
+// Send produces message and waits until every in-sync replica acknowledged it
+func (s *KafkaSender) Send(ctx context.Context, message Message) error {
+	err := s.writer.WriteMessages(ctx, kafka.Message{
+		Topic: message.Topic,
+		Key:   []byte(message.Key),
+		Value: message.Payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte(ContentType)},
+		},
+	})
+	// the error of the single message written may come wrapped in WriteErrors
+	var writeErrors kafka.WriteErrors
+	if errors.As(err, &writeErrors) {
+		for _, writeErr := range writeErrors {
+			if writeErr != nil {
+				err = writeErr
+			}
+		}
+	}
+	var kafkaErr kafka.Error
+	if errors.As(err, &kafkaErr) && slices.Contains(kafkaPermanentErrors, kafkaErr) {
+		return permanent(err)
+	}
+	return err
+}
+
+func (s *KafkaSender) Close() error {
+	return s.writer.Close()
+}
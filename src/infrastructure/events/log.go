@@ -0,0 +1,30 @@
+// Package events delivers domain events to other services
+package events
+
+import (
+	"context"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// LogPublisher writes every event to the log instead of sending it anywhere. It is the
+// publisher used while no message broker is configured.
+type LogPublisher struct {
+	Logger *logger.Logger
+}
+
+func NewLogPublisher(loggerInstance *logger.Logger) *LogPublisher {
+	return &LogPublisher{Logger: loggerInstance}
+}
+
+func (p *LogPublisher) Publish(_ context.Context, e event.Event) error {
+	p.Logger.Info("Published event",
+		zap.String("eventId", e.ID),
+		zap.String("type", e.Type),
+		zap.String("aggregateType", e.AggregateType),
+		zap.Int("aggregateId", e.AggregateID),
+		zap.Int("tenantId", e.TenantID))
+	return nil
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsConn is the part of *nats.Conn the sender uses
+type natsConn interface {
+	PublishMsg(msg *nats.Msg) error
+	FlushWithContext(ctx context.Context) error
+	Close()
+}
+
+// NATSSender publishes messages on NATS subjects named after their topic. The event ID
+// goes in the Nats-Msg-Id header so a JetStream stream capturing the subjects drops
+// redelivered duplicates.
+type NATSSender struct {
+	conn natsConn
+}
+
+// NewNATSSender connects to the NATS server at url. The connection is retried in the
+// background, so the server does not have to be up yet.
+func NewNATSSender(url string) (*NATSSender, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("microservices-go"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &NATSSender{conn: conn}, nil
+}
+
+// Send publishes message and waits until the server processed it
+func (s *NATSSender) Send(ctx context.Context, message Message) error {
+	msg := nats.NewMsg(message.Topic)
+	msg.Data = message.Payload
+	msg.Header.Set(nats.MsgIdHdr, message.ID)
+	msg.Header.Set("Content-Type", ContentType)
+	if err := s.conn.PublishMsg(msg); err != nil {
+		if errors.Is(err, nats.ErrMaxPayload) || errors.Is(err, nats.ErrBadSubject) {
+			return permanent(err)
+		}
+		return err
+	}
+	return s.conn.FlushWithContext(ctx)
+}
+
+func (s *NATSSender) Close() error {
+	s.conn.Close()
+	return nil
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = Message{ID: "evt-1", Topic: "medicine.created", Key: "medicine/1", Payload: []byte(`{"specversion":"1.0"}`)}
+
+type fakeNATSConn struct {
+	published []*nats.Msg
+	flushes   int
+	fail      error
+	closed    bool
+}
+
+func (c *fakeNATSConn) PublishMsg(msg *nats.Msg) error {
+	if c.fail != nil {
+		return c.fail
+	}
+	c.published = append(c.published, msg)
+	return nil
+}
+
+func (c *fakeNATSConn) FlushWithContext(context.Context) error {
+	c.flushes++
+	return nil
+}
+
+func (c *fakeNATSConn) Close() { c.closed = true }
+
+func TestNATSSender(t *testing.T) {
+	conn := &fakeNATSConn{}
+	sender := &NATSSender{conn: conn}
+
+	require.NoError(t, sender.Send(context.Background(), testMessage))
+	require.Len(t, conn.published, 1)
+	msg := conn.published[0]
+	assert.Equal(t, "medicine.created", msg.Subject)
+	assert.Equal(t, testMessage.Payload, msg.Data)
+	assert.Equal(t, "evt-1", msg.Header.Get(nats.MsgIdHdr))
+	assert.Equal(t, ContentType, msg.Header.Get("Content-Type"))
+	assert.Equal(t, 1, conn.flushes, "a send waits for the server")
+
+	require.NoError(t, sender.Close())
+	assert.True(t, conn.closed)
+}
+
+func TestNATSSender_PermanentErrors(t *testing.T) {
+	conn := &fakeNATSConn{fail: nats.ErrMaxPayload}
+	sender := &NATSSender{conn: conn}
+	assert.True(t, isPermanent(sender.Send(context.Background(), testMessage)))
+
+	conn.fail = nats.ErrConnectionClosed
+	assert.False(t, isPermanent(sender.Send(context.Background(), testMessage)))
+}
+
+type fakeKafkaWriter struct {
+	written []kafka.Message
+	fail    error
+}
+
+func (w *fakeKafkaWriter) WriteMessages(_ context.Context, messages ...kafka.Message) error {
+	if w.fail != nil {
+		return w.fail
+	}
+	w.written = append(w.written, messages...)
+	return nil
+}
+
+func (w *fakeKafkaWriter) Close() error { return nil }
+
+func TestKafkaSender(t *testing.T) {
+	writer := &fakeKafkaWriter{}
+	sender := &KafkaSender{writer: writer}
+
+	require.NoError(t, sender.Send(context.Background(), testMessage))
+	require.Len(t, writer.written, 1)
+	message := writer.written[0]
+	assert.Equal(t, "medicine.created", message.Topic)
+	assert.Equal(t, []byte("medicine/1"), message.Key, "events of a record share a partition")
+	assert.Equal(t, testMessage.Payload, message.Value)
+	assert.Equal(t, []kafka.Header{{Key: "content-type", Value: []byte(ContentType)}}, message.Headers)
+}
+
+func TestKafkaSender_PermanentErrors(t *testing.T) {
+	writer := &fakeKafkaWriter{}
+	sender := &KafkaSender{writer: writer}
+	cases := []struct {
+		err       error
+		permanent bool
+	}{
+		{kafka.MessageSizeTooLarge, true},
+		{kafka.WriteErrors{kafka.TopicAuthorizationFailed}, true},
+		{kafka.UnknownTopicOrPartition, false},
+		{kafka.WriteErrors{kafka.LeaderNotAvailable}, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, c := range cases {
+		writer.fail = c.err
+		assert.Equal(t, c.permanent, isPermanent(sender.Send(context.Background(), testMessage)), c.err.Error())
+	}
+}
+
+type fakeAMQPChannel struct {
+	published []amqp.Publishing
+	keys      []string
+	fail      error
+	closed    bool
+}
+
+func (c *fakeAMQPChannel) Publish(_ context.Context, exchange, key string, msg amqp.Publishing) error {
+	if c.fail != nil {
+		return c.fail
+	}
+	c.keys = append(c.keys, exchange+"/"+key)
+	c.published = append(c.published, msg)
+	return nil
+}
+
+func (c *fakeAMQPChannel) IsClosed() bool { return c.closed }
+
+func (c *fakeAMQPChannel) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestAMQPSender(t *testing.T) {
+	var channels []*fakeAMQPChannel
+	sender := NewAMQPSender("amqp://localhost", "events")
+	sender.dial = func(url, exchange string) (amqpChannel, error) {
+		channel := &fakeAMQPChannel{}
+		channels = append(channels, channel)
+		return channel, nil
+	}
+
+	require.NoError(t, sender.Send(context.Background(), testMessage))
+	require.NoError(t, sender.Send(context.Background(), testMessage))
+	require.Len(t, channels, 1, "the connection is reused")
+	assert.Equal(t, []string{"events/medicine.created", "events/medicine.created"}, channels[0].keys)
+	published := channels[0].published[0]
+	assert.Equal(t, "evt-1", published.MessageId)
+	assert.Equal(t, amqp.Persistent, published.DeliveryMode)
+	assert.Equal(t, ContentType, published.ContentType)
+
+	channels[0].fail = errors.New("connection reset")
+	assert.Error(t, sender.Send(context.Background(), testMessage))
+	assert.True(t, channels[0].closed)
+	require.NoError(t, sender.Send(context.Background(), testMessage))
+	assert.Len(t, channels, 2, "a failed connection is replaced")
+
+	require.NoError(t, sender.Close())
+	assert.True(t, channels[1].closed)
+}
+
+func TestAMQPSender_PermanentErrors(t *testing.T) {
+	channel := &fakeAMQPChannel{fail: &amqp.Error{Code: amqp.ContentTooLarge, Reason: "message too large"}}
+	sender := NewAMQPSender("amqp://localhost", "events")
+	sender.dial = func(string, string) (amqpChannel, error) { return channel, nil }
+	assert.True(t, isPermanent(sender.Send(context.Background(), testMessage)))
+
+	channel.fail = errNotConfirmed
+	assert.False(t, isPermanent(sender.Send(context.Background(), testMessage)))
+}
+
+func TestAMQPSender_DialError(t *testing.T) {
+	sender := NewAMQPSender("amqp://localhost", "events")
+	sender.dial = func(string, string) (amqpChannel, error) {
+		return nil, errors.New("connection refused")
+	}
+	assert.EqualError(t, sender.Send(context.Background(), testMessage), "connection refused")
+	assert.NoError(t, sender.Close())
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"strings"
+)
+
+// Topics names the topic, subject or routing key each event type is published to.
+// Template may use the {type}, {aggregate} and {action} placeholders, so with the
+// template "pharmacy.{aggregate}" a medicine.updated event goes to pharmacy.medicine.
+// Overrides take precedence and are keyed by event type or by "<aggregate>.*".
+type Topics struct {
+	Template  string
+	Overrides map[string]string
+}
+
+// Topic returns the topic events of eventType are published to
+func (t Topics) Topic(eventType string) string {
+	aggregate, action, _ := strings.Cut(eventType, ".")
+	if topic, ok := t.Overrides[eventType]; ok {
+		return topic
+	}
+	if topic, ok := t.Overrides[aggregate+".*"]; ok {
+		return topic
+	}
+	if t.Template == "" {
+		return eventType
+	}
+	return strings.NewReplacer(
+		"{type}", eventType,
+		"{aggregate}", aggregate,
+		"{action}", action,
+	).Replace(t.Template)
+}
+
+// ParseTopicOverrides parses overrides written as "type=topic,aggregate.*=topic"
+func ParseTopicOverrides(value string) map[string]string {
+	overrides := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		eventType, topic, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || eventType == "" || topic == "" {
+			continue
+		}
+		overrides[strings.TrimSpace(eventType)] = strings.TrimSpace(topic)
+	}
+	return overrides
+}
@@ -0,0 +1,113 @@
+// Package jobs runs slow work in the background. Handlers are registered at startup
+// for each Kind of job; Enqueue stores a job that one runner of the deployment claims
+// once it is due and passes to its handler, retrying failures with exponential back-off.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
+)
+
+// Config holds the settings of the job runner
+type Config struct {
+	Interval    time.Duration
+	Queues      map[string]int
+	Lease       time.Duration
+	MaxAttempts int
+	RetryBase   time.Duration
+	MaxBackoff  time.Duration
+}
+
+// LoadConfig loads job runner configuration from environment variables
+func LoadConfig() Config {
+	queues := os.Getenv("JOBS_QUEUES")
+	if queues == "" {
+		queues = "default=4,maintenance=1"
+	}
+	return Config{
+		Interval:    time.Duration(config.EnvAsIntOrDefault("JOBS_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+		Queues:      ParseQueues(queues),
+		Lease:       time.Duration(config.EnvAsIntOrDefault("JOBS_LEASE_SECONDS", 300)) * time.Second,
+		MaxAttempts: config.EnvAsIntOrDefault("JOBS_MAX_ATTEMPTS", 5),
+		RetryBase:   time.Duration(config.EnvAsIntOrDefault("JOBS_RETRY_BASE_SECONDS", 10)) * time.Second,
+		MaxBackoff:  time.Duration(config.EnvAsIntOrDefault("JOBS_MAX_BACKOFF_SECONDS", 3600)) * time.Second,
+	}
+}
+
+// ParseQueues parses comma-separated queue=concurrency pairs such as
+// "default=4,maintenance=1", ignoring malformed ones
+func ParseQueues(value string) map[string]int {
+	queues := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		queue, rawConcurrency, found := strings.Cut(strings.TrimSpace(pair), "=")
+		concurrency, err := strconv.Atoi(strings.TrimSpace(rawConcurrency))
+		if !found || queue == "" || err != nil || concurrency < 1 {
+			continue
+		}
+		queues[strings.TrimSpace(queue)] = concurrency
+	}
+	return queues
+}
+
+// Store keeps the queue the runner works from
+type Store interface {
+	Enqueue(ctx context.Context, job *domainJob.Job) (*domainJob.Job, error)
+	ClaimDue(ctx context.Context, queue string, types []string, now time.Time, lease time.Duration, limit int) ([]domainJob.Job, error)
+	// Complete records the result of attempt, ignoring it when another runner claimed
+	// the job again after the lease of attempt expired. On the last attempt, whose
+	// expired lease failed the job, the result still counts.
+	Complete(ctx context.Context, id int64, attempt int, result domainJob.Result) error
+}
+
+// Kind names a type of job and the Go type A of its arguments, stored as JSON
+type Kind[A any] struct {
+	Name string
+	// Queue runs the jobs of the kind, domainJob.DefaultQueue when empty
+	Queue string
+	// MaxAttempts overrides Config.MaxAttempts when positive
+	MaxAttempts int
+}
+
+func (k Kind[A]) queue() string {
+	if k.Queue == "" {
+		return domainJob.DefaultQueue
+	}
+	return k.Queue
+}
+
+func (k Kind[A]) maxAttempts(config Config) int {
+	if k.MaxAttempts > 0 {
+		return k.MaxAttempts
+	}
+	return max(config.MaxAttempts, 1)
+}
+
+// Handler runs a job with its arguments. ctx is cancelled when the runner stops before
+// the handler returns; the job then runs again later.
+type Handler[A any] func(ctx context.Context, args A) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error that running the job again cannot fix, such as
+// invalid arguments: the job fails without further attempts
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,315 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// releaseWait is how long Shutdown lets interrupted handlers return their jobs to the queue
+const releaseWait = 5 * time.Second
+
+type registration struct {
+	queue string
+	call  func(ctx context.Context, payload []byte) error
+}
+
+// Runner claims due jobs and runs them with their registered handler. Each queue runs
+// at most its configured number of jobs at a time per instance. Jobs run at least once:
+// a job whose runner dies is claimed again once its lease expires, so handlers must be
+// safe to repeat.
+type Runner struct {
+	store  Store
+	config Config
+	Logger *logger.Logger
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]registration
+	wake     map[string]chan struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	loops    sync.WaitGroup
+	running  sync.WaitGroup
+	// interrupt cancels the running handlers when draining takes too long
+	interrupted context.Context
+	interrupt   context.CancelFunc
+}
+
+func NewRunner(store Store, config Config, loggerInstance *logger.Logger) *Runner {
+	interrupted, interrupt := context.WithCancel(context.Background())
+	return &Runner{
+		store:       store,
+		config:      config,
+		Logger:      loggerInstance,
+		now:         time.Now,
+		handlers:    make(map[string]registration),
+		wake:        make(map[string]chan struct{}),
+		stop:        make(chan struct{}),
+		interrupted: interrupted,
+		interrupt:   interrupt,
+	}
+}
+
+// Register makes runner run the jobs of kind with handler. Register every kind before
+// Start; registering a kind again replaces its handler.
+func Register[A any](runner *Runner, kind Kind[A], handler Handler[A]) {
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	runner.handlers[kind.Name] = registration{
+		queue: kind.queue(),
+		call: func(ctx context.Context, payload []byte) error {
+			var args A
+			if err := json.Unmarshal(payload, &args); err != nil {
+				return Permanent(fmt.Errorf("decoding arguments: %w", err))
+			}
+			return handler(ctx, args)
+		},
+	}
+	if runner.wake[kind.queue()] == nil {
+		runner.wake[kind.queue()] = make(chan struct{}, 1)
+	}
+}
+
+// Enqueue queues a job of kind to run as soon as possible
+func Enqueue[A any](ctx context.Context, runner *Runner, kind Kind[A], args A) (*domainJob.Job, error) {
+	return EnqueueAt(ctx, runner, kind, args, runner.now())
+}
+
+// EnqueueAt queues a job of kind to run at runAt. Inside a request the job belongs to
+// the caller's organization and its handler runs on behalf of the caller.
+func EnqueueAt[A any](ctx context.Context, runner *Runner, kind Kind[A], args A, runAt time.Time) (*domainJob.Job, error) {
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("encoding arguments of job %s: %w", kind.Name, err)
+	}
+	job, err := runner.store.Enqueue(ctx, &domainJob.Job{
+		Queue:       kind.queue(),
+		Type:        kind.Name,
+		Payload:     string(payload),
+		Status:      domainJob.StatusPending,
+		MaxAttempts: kind.maxAttempts(runner.config),
+		RunAt:       runAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("enqueueing job %s: %w", kind.Name, err)
+	}
+	runner.Logger.Info("Enqueued job",
+		zap.Int64("id", job.ID),
+		zap.String("type", job.Type),
+		zap.String("queue", job.Queue),
+		zap.Time("runAt", job.RunAt))
+	if !runAt.After(runner.now()) {
+		runner.notify(job.Queue)
+	}
+	return job, nil
+}
+
+// Start runs the queues that have registered kinds in the background until ctx is
+// cancelled or Shutdown is called. A nil runner does nothing.
+func (r *Runner) Start(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	if r.config.Interval <= 0 {
+		r.Logger.Info("Job runner disabled: interval is not positive")
+		return
+	}
+	for queue, types := range r.queues() {
+		concurrency := max(r.config.Queues[queue], 1)
+		r.Logger.Info("Starting job queue",
+			zap.String("queue", queue),
+			zap.Strings("types", types),
+			zap.Int("concurrency", concurrency))
+		r.loops.Add(1)
+		go r.work(ctx, queue, types, concurrency)
+	}
+}
+
+// Shutdown stops claiming jobs and waits for the running ones to finish. When ctx is
+// done first, the handlers still running are cancelled and their jobs run again later;
+// Shutdown then returns ctx.Err(). A nil runner does nothing.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.stopOnce.Do(func() { close(r.stop) })
+	r.loops.Wait()
+
+	drained := make(chan struct{})
+	go func() {
+		r.running.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		r.Logger.Info("Job runner drained")
+		return nil
+	case <-ctx.Done():
+	}
+	r.Logger.Warn("Interrupting running jobs: drain timed out")
+	r.interrupt()
+	select {
+	case <-drained:
+	case <-time.After(releaseWait):
+	}
+	return ctx.Err()
+}
+
+// queues returns the registered kinds of each queue
+func (r *Runner) queues() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	queues := make(map[string][]string)
+	for _, name := range slices.Sorted(maps.Keys(r.handlers)) {
+		queue := r.handlers[name].queue
+		queues[queue] = append(queues[queue], name)
+	}
+	return queues
+}
+
+func (r *Runner) work(ctx context.Context, queue string, types []string, concurrency int) {
+	defer r.loops.Done()
+	slots := make(chan struct{}, concurrency)
+	r.mu.RLock()
+	wake := r.wake[queue]
+	r.mu.RUnlock()
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.claim(ctx, queue, types, slots); err != nil {
+			r.Logger.Error("Error claiming jobs", zap.Error(err), zap.String("queue", queue))
+		}
+		select {
+		case <-ctx.Done():
+			r.Logger.Info("Job queue stopped", zap.String("queue", queue))
+			return
+		case <-r.stop:
+			r.Logger.Info("Job queue stopped", zap.String("queue", queue))
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// claim fills the free slots of queue with due jobs and runs them
+func (r *Runner) claim(ctx context.Context, queue string, types []string, slots chan struct{}) error {
+	free := cap(slots) - len(slots)
+	if free == 0 {
+		return nil
+	}
+	jobs, err := r.store.ClaimDue(ctx, queue, types, r.now(), r.config.Lease, free)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		slots <- struct{}{}
+		r.running.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				r.running.Done()
+				r.notify(queue)
+			}()
+			r.execute(job)
+		}()
+	}
+	return nil
+}
+
+// execute runs a claimed job and records its result. Handlers run with the principal
+// that enqueued the job, so tenant-scoped repositories see its organization.
+func (r *Runner) execute(job domainJob.Job) {
+	ctx := r.interrupted
+	if job.TenantID != 0 {
+		principal := security.Principal{TenantID: job.TenantID}
+		if job.CreatedBy != nil {
+			principal.UserID = *job.CreatedBy
+		}
+		ctx = security.WithPrincipal(ctx, principal)
+	}
+	fields := []zap.Field{
+		zap.Int64("id", job.ID),
+		zap.String("type", job.Type),
+		zap.Int("attempt", job.Attempts),
+	}
+
+	started := r.now()
+	err := r.call(ctx, job)
+	result := domainJob.Result{At: r.now()}
+	fields = append(fields, zap.Duration("duration", result.At.Sub(started)))
+	switch {
+	case err == nil:
+		result.Status = domainJob.StatusSucceeded
+		r.Logger.Info("Job succeeded", fields...)
+	case r.interrupted.Err() != nil:
+		result.Status = domainJob.StatusPending
+		result.Error = "interrupted by shutdown"
+		result.RunAt = result.At
+		result.Released = true
+		r.Logger.Warn("Job interrupted", fields...)
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		result.Status = domainJob.StatusFailed
+		result.Error = err.Error()
+		r.Logger.Error("Job failed", append(fields, zap.Error(err))...)
+	default:
+		result.Status = domainJob.StatusPending
+		result.Error = err.Error()
+		result.RunAt = result.At.Add(r.backoff(job.Attempts))
+		r.Logger.Warn("Job failed, retrying", append(fields, zap.Error(err), zap.Time("runAt", result.RunAt))...)
+	}
+	if err := r.store.Complete(context.Background(), job.ID, job.Attempts, result); err != nil {
+		r.Logger.Error("Error recording job result", append(fields, zap.Error(err))...)
+	}
+}
+
+// call runs the handler of job, turning a panic into an error
+func (r *Runner) call(ctx context.Context, job domainJob.Job) (err error) {
+	r.mu.RLock()
+	registration, ok := r.handlers[job.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return Permanent(errors.New("no handler registered for " + job.Type))
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler panicked: %v", recovered)
+		}
+	}()
+	return registration.call(ctx, []byte(job.Payload))
+}
+
+// notify wakes the loop of queue, if it runs here, to claim jobs now
+func (r *Runner) notify(queue string) {
+	r.mu.RLock()
+	wake := r.wake[queue]
+	r.mu.RUnlock()
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// backoff returns the wait before the attempt following attempt
+func (r *Runner) backoff(attempt int) time.Duration {
+	delay := r.config.RetryBase
+	for i := 1; i < attempt && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if r.config.MaxBackoff > 0 && delay > r.config.MaxBackoff {
+		return r.config.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	psqlJob "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/job"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type reportArgs struct {
+	Name string `json:"name"`
+}
+
+var reportJob = Kind[reportArgs]{Name: "report", MaxAttempts: 3}
+
+func setupRunner(t *testing.T) (*Runner, *psqlJob.Repository, *time.Time) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&psqlJob.Job{}))
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+
+	repository := psqlJob.NewJobRepository(db, loggerInstance)
+	runner := NewRunner(repository, Config{
+		Interval:    time.Hour,
+		Queues:      map[string]int{domainJob.DefaultQueue: 2},
+		Lease:       time.Minute,
+		MaxAttempts: 5,
+		RetryBase:   10 * time.Second,
+		MaxBackoff:  time.Minute,
+	}, loggerInstance)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	runner.now = func() time.Time { return now }
+	return runner, repository, &now
+}
+
+// runDue runs the due jobs of the default queue and waits for them
+func runDue(t *testing.T, runner *Runner) {
+	t.Helper()
+	types := runner.queues()[domainJob.DefaultQueue]
+	require.NoError(t, runner.claim(context.Background(), domainJob.DefaultQueue, types, make(chan struct{}, 10)))
+	runner.running.Wait()
+}
+
+func TestRunner_RunsRegisteredHandler(t *testing.T) {
+	runner, repository, _ := setupRunner(t)
+	var got reportArgs
+	var principal security.Principal
+	Register(runner, reportJob, func(ctx context.Context, args reportArgs) error {
+		got = args
+		principal, _ = security.PrincipalFromContext(ctx)
+		return nil
+	})
+
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 7, TenantID: 3})
+	job, err := Enqueue(ctx, runner, reportJob, reportArgs{Name: "stock"})
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.DefaultQueue, job.Queue)
+	assert.Equal(t, 3, job.MaxAttempts)
+	// the SQLite test database has no tenant or audit callbacks
+	require.NoError(t, repository.DB.Model(&psqlJob.Job{}).Where("id = ?", job.ID).
+		UpdateColumns(map[string]any{"tenant_id": 3, "created_by": 7}).Error)
+
+	runDue(t, runner)
+
+	assert.Equal(t, reportArgs{Name: "stock"}, got)
+	assert.Equal(t, security.Principal{UserID: 7, TenantID: 3}, principal)
+	stored, err := repository.GetByID(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+}
+
+func TestRunner_RetriesWithBackoff(t *testing.T) {
+	runner, repository, now := setupRunner(t)
+	calls := 0
+	Register(runner, reportJob, func(context.Context, reportArgs) error {
+		calls++
+		return errors.New("database unavailable")
+	})
+	job, err := Enqueue(context.Background(), runner, reportJob, reportArgs{})
+	require.NoError(t, err)
+
+	runDue(t, runner)
+	stored, err := repository.GetByID(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusPending, stored.Status)
+	assert.Equal(t, "database unavailable", stored.LastError)
+	assert.True(t, stored.RunAt.Equal(now.Add(10*time.Second)))
+
+	runDue(t, runner)
+	assert.Equal(t, 1, calls, "the retry is not due yet")
+
+	*now = now.Add(10 * time.Second)
+	runDue(t, runner)
+	stored, err = repository.GetByID(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.RunAt.Equal(now.Add(20*time.Second)), "the back-off doubles")
+
+	*now = now.Add(time.Minute)
+	runDue(t, runner)
+	stored, err = repository.GetByID(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, domainJob.StatusFailed, stored.Status, "MaxAttempts of the kind is reached")
+}
+
+func TestRunner_FailsWithoutRetry(t *testing.T) {
+	runner, repository, now := setupRunner(t)
+	Register(runner, reportJob, func(_ context.Context, args reportArgs) error {
+		if args.Name == "panic" {
+			panic("boom")
+		}
+		return Permanent(errors.New("unknown report"))
+	})
+	permanent, err := Enqueue(context.Background(), runner, reportJob, reportArgs{Name: "unknown"})
+	require.NoError(t, err)
+	invalid, err := repository.Enqueue(context.Background(), &domainJob.Job{
+		Queue:       domainJob.DefaultQueue,
+		Type:        reportJob.Name,
+		Payload:     `["not", "an", "object"]`,
+		Status:      domainJob.StatusPending,
+		MaxAttempts: 3,
+		RunAt:       *now,
+	})
+	require.NoError(t, err)
+	panicking, err := Enqueue(context.Background(), runner, reportJob, reportArgs{Name: "panic"})
+	require.NoError(t, err)
+
+	runDue(t, runner)
+
+	for _, id := range []int64{permanent.ID, invalid.ID} {
+		stored, err := repository.GetByID(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, domainJob.StatusFailed, stored.Status, stored.LastError)
+		assert.Equal(t, 1, stored.Attempts)
+	}
+	stored, err := repository.GetByID(context.Background(), panicking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusPending, stored.Status, "a panic is retried like an error")
+	assert.Contains(t, stored.LastError, "boom")
+}
+
+func TestRunner_ShutdownDrainsRunningJobs(t *testing.T) {
+	runner, repository, _ := setupRunner(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	Register(runner, reportJob, func(context.Context, reportArgs) error {
+		close(started)
+		<-release
+		return nil
+	})
+	job, err := Enqueue(context.Background(), runner, reportJob, reportArgs{})
+	require.NoError(t, err)
+	runner.config.Interval = 10 * time.Millisecond
+	runner.Start(context.Background())
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- runner.Shutdown(context.Background()) }()
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown returned while a job was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-shutdown)
+
+	stored, err := repository.GetByID(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, stored.Status)
+}
+
+func TestRunner_ShutdownTimeoutReleasesJobs(t *testing.T) {
+	runner, repository, _ := setupRunner(t)
+	Register(runner, reportJob, func(ctx context.Context, _ reportArgs) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job, err := Enqueue(context.Background(), runner, reportJob, reportArgs{})
+	require.NoError(t, err)
+	require.NoError(t, runner.claim(context.Background(), domainJob.DefaultQueue, []string{reportJob.Name}, make(chan struct{}, 1)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, runner.Shutdown(ctx), context.DeadlineExceeded)
+
+	stored, err := repository.GetByID(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusPending, stored.Status)
+	assert.Equal(t, 0, stored.Attempts, "the interrupted run is not counted")
+}
+
+func TestRunner_ConcurrencyPerQueue(t *testing.T) {
+	runner, repository, _ := setupRunner(t)
+	release := make(chan struct{})
+	Register(runner, reportJob, func(context.Context, reportArgs) error {
+		<-release
+		return nil
+	})
+	for range 5 {
+		_, err := Enqueue(context.Background(), runner, reportJob, reportArgs{})
+		require.NoError(t, err)
+	}
+	slots := make(chan struct{}, 2)
+	require.NoError(t, runner.claim(context.Background(), domainJob.DefaultQueue, []string{reportJob.Name}, slots))
+	require.NoError(t, runner.claim(context.Background(), domainJob.DefaultQueue, []string{reportJob.Name}, slots))
+
+	running, err := repository.GetAll(context.Background(), domain.DataFilters{
+		Matches: map[string][]string{"status": {domainJob.StatusRunning}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), running.Total, "a full queue claims no more jobs")
+	close(release)
+	runner.running.Wait()
+}
+
+func TestParseQueues(t *testing.T) {
+	assert.Equal(t, map[string]int{"default": 4, "emails": 2},
+		ParseQueues(" default=4, emails = 2,broken,zero=0,=3,nan=x"))
+}
+
+func TestRunner_NilIsSafe(t *testing.T) {
+	var runner *Runner
+	runner.Start(context.Background())
+	assert.NoError(t, runner.Shutdown(context.Background()))
+}
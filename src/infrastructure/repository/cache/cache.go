@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/replica"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// Backend stores serialized values under string keys. A ttl that is not positive
+// keeps the value until it is deleted or evicted.
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Cache drivers accepted by CACHE_DRIVER
+const (
+	DriverNone   = ""
+	DriverMemory = "memory"
+	DriverRedis  = "redis"
+)
+
+// Config holds the cache settings
+type Config struct {
+	Driver        string
+	TTL           time.Duration
+	Size          int
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	RedisPoolSize int
+}
+
+// LoadConfig loads cache configuration from environment variables
+func LoadConfig() Config {
+	return Config{
+		Driver:        os.Getenv("CACHE_DRIVER"),
+		TTL:           time.Duration(config.EnvAsIntOrDefault("CACHE_TTL_SECONDS", 300)) * time.Second,
+		Size:          config.EnvAsIntOrDefault("CACHE_SIZE", 10000),
+		RedisAddr:     config.EnvOrDefault("REDIS_ADDR", "localhost:6379"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+		RedisDB:       config.EnvAsIntOrDefault("REDIS_DB", 0),
+		RedisPoolSize: config.EnvAsIntOrDefault("REDIS_POOL_SIZE", 10),
+	}
+}
+
+// NewBackend builds the backend selected by the configuration. It returns nil
+// when caching is disabled.
+func NewBackend(config Config) (Backend, error) {
+	switch config.Driver {
+	case DriverNone:
+		return nil, nil
+	case DriverMemory:
+		return NewLRU(config.Size), nil
+	case DriverRedis:
+		return NewRedis(config.RedisAddr, config.RedisPassword, config.RedisDB, config.RedisPoolSize), nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE_DRIVER %q: expected %s or %s", config.Driver, DriverMemory, DriverRedis)
+	}
+}
+
+// Metrics counts the lookups of a cached repository
+type Metrics struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+}
+
+// Stats is a snapshot of Metrics
+type Stats struct {
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	Errors   uint64  `json:"errors"`
+	HitRatio float64 `json:"hitRatio"`
+}
+
+// Stats returns the current counters
+func (m *Metrics) Stats() Stats {
+	stats := Stats{Hits: m.hits.Load(), Misses: m.misses.Load(), Errors: m.errors.Load()}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+// store holds what the cached repositories share: the backend, the key namespace
+// and the singleflight group that lets concurrent misses of a key load it once.
+type store struct {
+	backend Backend
+	ttl     time.Duration
+	prefix  string
+	group   singleflight.Group
+	metrics *Metrics
+	Logger  *logger.Logger
+}
+
+func (s *store) idKey(id int) string {
+	return fmt.Sprintf("%s:id:%d", s.prefix, id)
+}
+
+func (s *store) versionKey() string {
+	return s.prefix + ":version"
+}
+
+// searchKey namespaces a search by the tenant in ctx and the current version, so a
+// write makes every previous search unreachable instead of deleting them one by one.
+// The version also tells fetch that a write happened while it was loading.
+func (s *store) searchKey(ctx context.Context, property, searchText string) string {
+	tenantID, _ := security.TenantID(ctx)
+	return fmt.Sprintf("%s:search:%s:%d:%s:%s", s.prefix, s.version(ctx), tenantID, property, searchText)
+}
+
+// version returns the search version, starting a new one when none is stored
+func (s *store) version(ctx context.Context) string {
+	value, found, err := s.backend.Get(ctx, s.versionKey())
+	if err != nil {
+		s.fail("get", s.versionKey(), err)
+	}
+	if found {
+		return string(value)
+	}
+	return s.bump(ctx)
+}
+
+func (s *store) bump(ctx context.Context) string {
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := s.backend.Set(ctx, s.versionKey(), []byte(version), 0); err != nil {
+		s.fail("set", s.versionKey(), err)
+	}
+	return version
+}
+
+// invalidate drops the cached entity of every id and every cached search
+func (s *store) invalidate(ctx context.Context, ids ...int) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, s.idKey(id))
+	}
+	if len(keys) > 0 {
+		if err := s.backend.Delete(ctx, keys...); err != nil {
+			s.fail("delete", keys[0], err)
+		}
+	}
+	s.bump(ctx)
+}
+
+func (s *store) fail(operation, key string, err error) {
+	s.metrics.errors.Add(1)
+	s.Logger.Warn("Cache operation failed", zap.String("operation", operation), zap.String("key", key), zap.Error(err))
+}
+
+// fetch returns the value cached under key or loads it once for every concurrent
+// caller. Backend failures fall back to load, so the cache never fails a read.
+// flight separates callers that may see different results for the same key.
+//
+// load reads the primary, as a lagging replica could return data older than the last
+// invalidation. A value is only cached when no invalidation happened while it was
+// loading, since it may predate the write that caused it.
+func fetch[T any](ctx context.Context, s *store, key, flight string, load func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+	data, found, err := s.backend.Get(ctx, key)
+	if err != nil {
+		s.fail("get", key, err)
+	}
+	if found {
+		if err := json.Unmarshal(data, &value); err == nil {
+			s.metrics.hits.Add(1)
+			return value, nil
+		}
+	}
+	s.metrics.misses.Add(1)
+
+	shared, err, _ := s.group.Do(flight, func() (any, error) {
+		version := s.version(ctx)
+		loaded, err := load(replica.WithPrimary(ctx))
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(loaded)
+		if err != nil {
+			return nil, err
+		}
+		if s.version(ctx) != version {
+			return data, nil
+		}
+		if err := s.backend.Set(ctx, key, data, s.ttl); err != nil {
+			s.fail("set", key, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return value, err
+	}
+	// every caller decodes its own copy so none of them shares mutable state
+	err = json.Unmarshal(shared.([]byte), &value)
+	return value, err
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+	require.NoError(t, lru.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, lru.Set(ctx, "b", []byte("2"), 0))
+	_, found, _ := lru.Get(ctx, "a")
+	require.True(t, found)
+
+	require.NoError(t, lru.Set(ctx, "c", []byte("3"), 0))
+
+	_, found, _ = lru.Get(ctx, "b")
+	assert.False(t, found, "b was the least recently used key")
+	value, found, _ := lru.Get(ctx, "a")
+	assert.True(t, found)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func TestLRU_ExpiresAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lru := NewLRU(10)
+	lru.now = func() time.Time { return now }
+	require.NoError(t, lru.Set(ctx, "short", []byte("1"), time.Minute))
+	require.NoError(t, lru.Set(ctx, "forever", []byte("2"), 0))
+
+	now = now.Add(time.Minute)
+
+	_, found, _ := lru.Get(ctx, "short")
+	assert.False(t, found)
+	_, found, _ = lru.Get(ctx, "forever")
+	assert.True(t, found)
+	assert.Equal(t, 1, lru.Len())
+}
+
+func TestLRU_SetCopiesAndDelete(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(10)
+	value := []byte("original")
+	require.NoError(t, lru.Set(ctx, "key", value, 0))
+	value[0] = 'X'
+
+	stored, _, _ := lru.Get(ctx, "key")
+	assert.Equal(t, []byte("original"), stored)
+
+	require.NoError(t, lru.Delete(ctx, "key", "missing"))
+	_, found, _ := lru.Get(ctx, "key")
+	assert.False(t, found)
+}
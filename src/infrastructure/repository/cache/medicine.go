@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+)
+
+// MedicineRepository caches GetByID and SearchByProperty of the wrapped repository.
+// Create, Update, Delete, Restore, RenameLaboratory and LinkLaboratories drop the cached medicines and
+// every cached search; the remaining methods go straight to the wrapped repository.
+type MedicineRepository struct {
+	medicine.MedicineRepositoryInterface
+	store   *store
+	Metrics *Metrics
+}
+
+var _ medicine.MedicineRepositoryInterface = (*MedicineRepository)(nil)
+
+func NewMedicineRepository(inner medicine.MedicineRepositoryInterface, backend Backend, ttl time.Duration, loggerInstance *logger.Logger) *MedicineRepository {
+	metrics := &Metrics{}
+	return &MedicineRepository{
+		MedicineRepositoryInterface: inner,
+		store:                       &store{backend: backend, ttl: ttl, prefix: "medicine", metrics: metrics, Logger: loggerInstance},
+		Metrics:                     metrics,
+	}
+}
+
+func (r *MedicineRepository) GetByID(ctx context.Context, id int) (*domainMedicine.Medicine, error) {
+	tenantID, scoped := security.TenantID(ctx)
+	key := r.store.idKey(id)
+	cached, err := fetch(ctx, r.store, key, fmt.Sprintf("%s@%d", key, tenantID), func(ctx context.Context) (*domainMedicine.Medicine, error) {
+		return r.MedicineRepositoryInterface.GetByID(ctx, id)
+	})
+	if err == nil && scoped && cached.TenantID != tenantID {
+		// cached for another organization; the wrapped repository reports it as not found
+		return r.MedicineRepositoryInterface.GetByID(ctx, id)
+	}
+	return cached, err
+}
+
+func (r *MedicineRepository) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
+	key := r.store.searchKey(ctx, property, searchText)
+	return fetch(ctx, r.store, key, key, func(ctx context.Context) (*[]string, error) {
+		return r.MedicineRepositoryInterface.SearchByProperty(ctx, property, searchText)
+	})
+}
+
+func (r *MedicineRepository) Create(ctx context.Context, newMedicine *domainMedicine.Medicine) (*domainMedicine.Medicine, error) {
+	created, err := r.MedicineRepositoryInterface.Create(ctx, newMedicine)
+	if err == nil {
+		r.store.invalidate(ctx)
+	}
+	return created, err
+}
+
+func (r *MedicineRepository) Update(ctx context.Context, id int, medicineMap map[string]any) (*domainMedicine.Medicine, error) {
+	updated, err := r.MedicineRepositoryInterface.Update(ctx, id, medicineMap)
+	r.store.invalidate(ctx, id)
+	return updated, err
+}
+
+func (r *MedicineRepository) Delete(ctx context.Context, id int) error {
+	err := r.MedicineRepositoryInterface.Delete(ctx, id)
+	r.store.invalidate(ctx, id)
+	return err
+}
+
+func (r *MedicineRepository) Restore(ctx context.Context, id int) (*domainMedicine.Medicine, error) {
+	restored, err := r.MedicineRepositoryInterface.Restore(ctx, id)
+	r.store.invalidate(ctx, id)
+	return restored, err
+}
+
+func (r *MedicineRepository) RenameLaboratory(ctx context.Context, laboratoryID int, name string) (*domainLaboratory.Laboratory, *[]domainMedicine.Medicine, error) {
+	laboratory, renamed, err := r.MedicineRepositoryInterface.RenameLaboratory(ctx, laboratoryID, name)
+	r.store.invalidate(ctx, medicineIDs(renamed)...)
+	return laboratory, renamed, err
+}
+
+func (r *MedicineRepository) LinkLaboratories(ctx context.Context) (*[]domainMedicine.Medicine, error) {
+	linked, err := r.MedicineRepositoryInterface.LinkLaboratories(ctx)
+	r.store.invalidate(ctx, medicineIDs(linked)...)
+	return linked, err
+}
+
+func medicineIDs(medicines *[]domainMedicine.Medicine) []int {
+	if medicines == nil {
+		return nil
+	}
+	ids := make([]int, 0, len(*medicines))
+	for _, medicine := range *medicines {
+		ids = append(ids, medicine.ID)
+	}
+	return ids
+}
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	memoryMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/replica"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingMedicines counts the reads reaching the wrapped repository. With release
+// set, GetByID holds what it read until release is closed.
+type countingMedicines struct {
+	medicine.MedicineRepositoryInterface
+	gets         atomic.Int32
+	searches     atomic.Int32
+	primaryReads atomic.Int32
+	release      chan struct{}
+}
+
+func (c *countingMedicines) GetByID(ctx context.Context, id int) (*domainMedicine.Medicine, error) {
+	c.count(ctx, &c.gets)
+	found, err := c.MedicineRepositoryInterface.GetByID(ctx, id)
+	if c.release != nil {
+		<-c.release
+	}
+	return found, err
+}
+
+func (c *countingMedicines) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
+	c.count(ctx, &c.searches)
+	return c.MedicineRepositoryInterface.SearchByProperty(ctx, property, searchText)
+}
+
+func (c *countingMedicines) count(ctx context.Context, reads *atomic.Int32) {
+	reads.Add(1)
+	if replica.PrimaryRequested(ctx) {
+		c.primaryReads.Add(1)
+	}
+}
+
+// failingBackend fails every operation
+type failingBackend struct{}
+
+func (failingBackend) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("unavailable")
+}
+func (failingBackend) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("unavailable")
+}
+func (failingBackend) Delete(context.Context, ...string) error { return errors.New("unavailable") }
+
+func setupMedicines(t *testing.T, backend Backend) (*MedicineRepository, *countingMedicines) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	inner := &countingMedicines{MedicineRepositoryInterface: memoryMedicine.NewMedicineRepository(loggerInstance)}
+	return NewMedicineRepository(inner, backend, time.Minute, loggerInstance), inner
+}
+
+func tenantContext(tenantID int) context.Context {
+	return security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: tenantID})
+}
+
+func TestMedicineRepository_GetByIDReadsThrough(t *testing.T) {
+	repo, inner := setupMedicines(t, NewLRU(100))
+	ctx := tenantContext(1)
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+
+	for range 3 {
+		found, err := repo.GetByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Aspirin", found.Name)
+	}
+	assert.Equal(t, int32(1), inner.gets.Load())
+	assert.Equal(t, Stats{Hits: 2, Misses: 1, HitRatio: 2.0 / 3}, repo.Metrics.Stats())
+
+	_, err = repo.Update(ctx, created.ID, map[string]any{"name": "Aspirin Forte"})
+	require.NoError(t, err)
+	found, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Aspirin Forte", found.Name)
+	assert.Equal(t, int32(2), inner.gets.Load())
+
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	_, err = repo.GetByID(ctx, created.ID)
+	assert.Error(t, err)
+	_, err = repo.GetByID(ctx, created.ID)
+	assert.Error(t, err)
+	assert.Equal(t, int32(4), inner.gets.Load(), "missing medicines are not cached")
+}
+
+func TestMedicineRepository_GetByIDKeepsTenantsApart(t *testing.T) {
+	repo, _ := setupMedicines(t, NewLRU(100))
+	created, err := repo.Create(tenantContext(1), &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	_, err = repo.GetByID(tenantContext(1), created.ID)
+	require.NoError(t, err)
+
+	_, err = repo.GetByID(tenantContext(2), created.ID)
+	assert.Error(t, err)
+	_, err = repo.GetByID(context.Background(), created.ID)
+	assert.NoError(t, err, "contexts without a tenant are unscoped")
+}
+
+func TestMedicineRepository_SearchByPropertyInvalidatedByWrites(t *testing.T) {
+	repo, inner := setupMedicines(t, NewLRU(100))
+	ctx := tenantContext(1)
+	_, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+
+	for range 2 {
+		coincidences, err := repo.SearchByProperty(ctx, "name", "asp")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Aspirin"}, *coincidences)
+	}
+	assert.Equal(t, int32(1), inner.searches.Load())
+
+	_, err = repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspartame", EanCode: "7502"})
+	require.NoError(t, err)
+	coincidences, err := repo.SearchByProperty(ctx, "name", "asp")
+	require.NoError(t, err)
+	assert.Len(t, *coincidences, 2)
+	assert.Equal(t, int32(2), inner.searches.Load())
+
+	coincidences, err = repo.SearchByProperty(tenantContext(2), "name", "asp")
+	require.NoError(t, err)
+	assert.Empty(t, *coincidences)
+}
+
+func TestMedicineRepository_ConcurrentMissesLoadOnce(t *testing.T) {
+	repo, inner := setupMedicines(t, NewLRU(100))
+	ctx := tenantContext(1)
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	inner.release = make(chan struct{})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := repo.GetByID(ctx, created.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, created.ID, found.ID)
+		}()
+	}
+	require.Eventually(t, func() bool { return repo.Metrics.Stats().Misses == callers }, time.Second, time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), inner.gets.Load())
+}
+
+func TestMedicineRepository_LoadRacingAWriteIsNotCached(t *testing.T) {
+	repo, inner := setupMedicines(t, NewLRU(100))
+	ctx := tenantContext(1)
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	inner.release = make(chan struct{})
+
+	loaded := make(chan *domainMedicine.Medicine)
+	go func() {
+		found, err := repo.GetByID(ctx, created.ID)
+		assert.NoError(t, err)
+		loaded <- found
+	}()
+	require.Eventually(t, func() bool { return inner.gets.Load() == 1 }, time.Second, time.Millisecond)
+	_, err = repo.Update(ctx, created.ID, map[string]any{"name": "Aspirin Forte"})
+	require.NoError(t, err)
+	close(inner.release)
+	assert.Equal(t, "Aspirin", (<-loaded).Name, "the load read the medicine before the update")
+
+	found, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Aspirin Forte", found.Name, "the value loaded before the update was not cached")
+	assert.Equal(t, int32(2), inner.gets.Load())
+
+	_, err = repo.SearchByProperty(ctx, "name", "asp")
+	require.NoError(t, err)
+	assert.Equal(t, inner.gets.Load()+inner.searches.Load(), inner.primaryReads.Load(), "loads read the primary")
+}
+
+func TestMedicineRepository_BackendFailuresFallBack(t *testing.T) {
+	repo, inner := setupMedicines(t, failingBackend{})
+	ctx := tenantContext(1)
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+
+	found, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Aspirin", found.Name)
+	assert.Equal(t, int32(1), inner.gets.Load())
+	assert.NotZero(t, repo.Metrics.Stats().Errors)
+}
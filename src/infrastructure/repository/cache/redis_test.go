@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis answers the commands used by the Redis backend from a map
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]string
+	password string
+	commands []string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	server := &fakeRedis{values: map[string]string{}, ttls: map[string]string{}, password: password}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server, listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, args[0])
+		switch {
+		case args[0] == "AUTH":
+			authenticated = args[1] == f.password
+			if authenticated {
+				fmt.Fprint(conn, "+OK\r\n")
+			} else {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+			}
+		case !authenticated:
+			fmt.Fprint(conn, "-NOAUTH Authentication required\r\n")
+		case args[0] == "GET":
+			if value, found := f.values[args[1]]; found {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+			} else {
+				fmt.Fprint(conn, "$-1\r\n")
+			}
+		case args[0] == "SET":
+			f.values[args[1]] = args[2]
+			if len(args) == 5 {
+				f.ttls[args[1]] = args[4]
+			}
+			fmt.Fprint(conn, "+OK\r\n")
+		case args[0] == "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, found := f.values[key]; found {
+					delete(f.values, key)
+					deleted++
+				}
+			}
+			fmt.Fprintf(conn, ":%d\r\n", deleted)
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		f.mu.Unlock()
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:size])
+	}
+	return args, nil
+}
+
+func TestRedis_SetGetDelete(t *testing.T) {
+	server, addr := startFakeRedis(t, "secret")
+	redis := NewRedis(addr, "secret", 0, 2)
+	defer redis.Close()
+	ctx := context.Background()
+
+	_, found, err := redis.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, redis.Set(ctx, "key", []byte("value\r\nwith newline"), 1500*time.Millisecond))
+	value, found, err := redis.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("value\r\nwith newline"), value)
+
+	require.NoError(t, redis.Delete(ctx, "key"))
+	_, found, err = redis.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "1500", server.ttls["key"])
+	assert.Equal(t, 1, strings.Count(strings.Join(server.commands, " "), "AUTH"), "the connection is reused")
+}
+
+func TestRedis_Errors(t *testing.T) {
+	_, addr := startFakeRedis(t, "secret")
+	ctx := context.Background()
+
+	_, _, err := NewRedis(addr, "wrong", 0, 1).Get(ctx, "key")
+	assert.ErrorContains(t, err, "WRONGPASS")
+
+	_, _, err = NewRedis("127.0.0.1:1", "", 0, 1).Get(ctx, "key")
+	assert.Error(t, err)
+}
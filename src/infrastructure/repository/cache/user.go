@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+)
+
+// UserRepository caches GetByID and SearchByProperty of the wrapped repository.
+// GetByEmail is left uncached because login must see password changes at once.
+type UserRepository struct {
+	user.UserRepositoryInterface
+	store   *store
+	Metrics *Metrics
+}
+
+var _ user.UserRepositoryInterface = (*UserRepository)(nil)
+
+func NewUserRepository(inner user.UserRepositoryInterface, backend Backend, ttl time.Duration, loggerInstance *logger.Logger) *UserRepository {
+	metrics := &Metrics{}
+	return &UserRepository{
+		UserRepositoryInterface: inner,
+		store:                   &store{backend: backend, ttl: ttl, prefix: "user", metrics: metrics, Logger: loggerInstance},
+		Metrics:                 metrics,
+	}
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*domainUser.User, error) {
+	tenantID, scoped := security.TenantID(ctx)
+	key := r.store.idKey(id)
+	cached, err := fetch(ctx, r.store, key, fmt.Sprintf("%s@%d", key, tenantID), func(ctx context.Context) (*domainUser.User, error) {
+		return r.UserRepositoryInterface.GetByID(ctx, id)
+	})
+	if err == nil && scoped && cached.TenantID != tenantID {
+		return r.UserRepositoryInterface.GetByID(ctx, id)
+	}
+	return cached, err
+}
+
+func (r *UserRepository) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
+	key := r.store.searchKey(ctx, property, searchText)
+	return fetch(ctx, r.store, key, key, func(ctx context.Context) (*[]string, error) {
+		return r.UserRepositoryInterface.SearchByProperty(ctx, property, searchText)
+	})
+}
+
+func (r *UserRepository) Create(ctx context.Context, userDomain *domainUser.User) (*domainUser.User, error) {
+	created, err := r.UserRepositoryInterface.Create(ctx, userDomain)
+	if err == nil {
+		r.store.invalidate(ctx)
+	}
+	return created, err
+}
+
+func (r *UserRepository) Update(ctx context.Context, id int, userMap map[string]interface{}) (*domainUser.User, error) {
+	updated, err := r.UserRepositoryInterface.Update(ctx, id, userMap)
+	r.store.invalidate(ctx, id)
+	return updated, err
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	err := r.UserRepositoryInterface.Delete(ctx, id)
+	r.store.invalidate(ctx, id)
+	return err
+}
+
+func (r *UserRepository) Restore(ctx context.Context, id int) (*domainUser.User, error) {
+	restored, err := r.UserRepositoryInterface.Restore(ctx, id)
+	r.store.invalidate(ctx, id)
+	return restored, err
+}
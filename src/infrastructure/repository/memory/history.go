@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/history"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+)
+
+type historyRecord[T any] struct {
+	tenantID int
+	entry    domainHistory.Entry
+	snapshot T
+}
+
+// History keeps the change log of in-memory records. It is not safe for concurrent
+// use on its own; repositories guard it with the same lock as their records.
+type History[T any] struct {
+	lastID  int
+	records map[int][]historyRecord[T]
+}
+
+// Record stores the change between the before and after fields of an entity owned by
+// tenantID together with its resulting snapshot. A nil map stands for a record that does
+// not exist; updates without changes are skipped, as they are by the SQL repositories.
+func (h *History[T]) Record(ctx context.Context, tenantID, entityID int, action domainHistory.Action, before, after map[string]any, snapshot T) error {
+	changes, err := history.Diff(mapOrNil(before), mapOrNil(after))
+	if err != nil {
+		return err
+	}
+	if action == domainHistory.ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+	if h.records == nil {
+		h.records = make(map[int][]historyRecord[T])
+	}
+	h.lastID++
+	h.records[entityID] = append(h.records[entityID], historyRecord[T]{
+		tenantID: tenantID,
+		entry: domainHistory.Entry{
+			ID:        h.lastID,
+			EntityID:  entityID,
+			Action:    action,
+			Changes:   changes,
+			ActorID:   security.ActorID(ctx),
+			CreatedAt: time.Now(),
+		},
+		snapshot: snapshot,
+	})
+	return nil
+}
+
+// Entries returns every recorded change of an entity visible in ctx, oldest first
+func (h *History[T]) Entries(ctx context.Context, entityID int) []domainHistory.Entry {
+	entries := []domainHistory.Entry{}
+	for _, record := range h.records[entityID] {
+		if InTenant(ctx, record.tenantID) {
+			entries = append(entries, record.entry)
+		}
+	}
+	return entries
+}
+
+// AsOf returns the snapshot of an entity visible in ctx recorded last at or before at
+func (h *History[T]) AsOf(ctx context.Context, entityID int, at time.Time) (T, bool) {
+	records := h.records[entityID]
+	for i := len(records) - 1; i >= 0; i-- {
+		if InTenant(ctx, records[i].tenantID) && !records[i].entry.CreatedAt.After(at) {
+			return records[i].snapshot, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func mapOrNil(fields map[string]any) any {
+	if fields == nil {
+		return nil
+	}
+	return fields
+}
@@ -0,0 +1,229 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// Repository keeps the job queue in process memory. Data is lost when the process
+// exits, together with the jobs that had not run yet.
+type Repository struct {
+	Logger *logger.Logger
+	now    func() time.Time
+
+	mu     sync.RWMutex
+	lastID int64
+	jobs   map[int64]domainJob.Job
+}
+
+func NewJobRepository(loggerInstance *logger.Logger) *Repository {
+	return &Repository{
+		Logger: loggerInstance,
+		now:    time.Now,
+		jobs:   make(map[int64]domainJob.Job),
+	}
+}
+
+// GetAll lists jobs, newest first unless filters sort otherwise
+func (r *Repository) GetAll(ctx context.Context, filters domain.DataFilters) (*domainJob.SearchResultJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := make([]domainJob.Job, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		if memory.InTenant(ctx, job.TenantID) {
+			jobs = append(jobs, job)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b domainJob.Job) int { return int(b.ID - a.ID) })
+	page := memory.Paginate(jobs, filters, fields)
+	r.Logger.Info("Successfully listed jobs", zap.Int64("total", page.Total))
+	return &domainJob.SearchResultJob{
+		Data:       &page.Data,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+	}, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*domainJob.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.find(ctx, id)
+	if !ok {
+		r.Logger.Warn("Job not found", zap.Int64("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &job, nil
+}
+
+// Retry queues a failed or cancelled job to run now with all its attempts
+func (r *Repository) Retry(ctx context.Context, id int64) (*domainJob.Job, error) {
+	return r.transition(ctx, id, []string{domainJob.StatusFailed, domainJob.StatusCancelled}, func(job *domainJob.Job) {
+		job.Status = domainJob.StatusPending
+		job.Attempts = 0
+		job.RunAt = r.now()
+		job.LockedUntil = nil
+		job.FinishedAt = nil
+	})
+}
+
+// Cancel stops a pending or scheduled job from running
+func (r *Repository) Cancel(ctx context.Context, id int64) (*domainJob.Job, error) {
+	return r.transition(ctx, id, []string{domainJob.StatusPending}, func(job *domainJob.Job) {
+		now := r.now()
+		job.Status = domainJob.StatusCancelled
+		job.FinishedAt = &now
+	})
+}
+
+func (r *Repository) transition(ctx context.Context, id int64, from []string, change func(job *domainJob.Job)) (*domainJob.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.find(ctx, id)
+	if !ok {
+		r.Logger.Warn("Job not found", zap.Int64("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if !slices.Contains(from, job.Status) {
+		r.Logger.Warn("Job status cannot change", zap.Int64("id", id), zap.String("status", job.Status))
+		return nil, domainErrors.NewAppError(fmt.Errorf("job %d is %s", id, job.Status), domainErrors.ValidationError)
+	}
+	change(&job)
+	job.UpdatedAt = r.now()
+	job.UpdatedBy = security.ActorID(ctx)
+	r.jobs[id] = job
+	r.Logger.Info("Successfully changed job status", zap.Int64("id", id), zap.String("status", job.Status))
+	return &job, nil
+}
+
+// Enqueue stores a new pending job. Inside a request it belongs to the caller's organization.
+func (r *Repository) Enqueue(ctx context.Context, newJob *domainJob.Job) (*domainJob.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	job := *newJob
+	job.ID = r.lastID + 1
+	job.TenantID = memory.TenantOf(ctx, newJob.TenantID)
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	job.CreatedBy = security.ActorID(ctx)
+	r.lastID = job.ID
+	r.jobs[job.ID] = job
+	return &job, nil
+}
+
+// ClaimDue reserves up to limit due jobs of queue and types, as the SQL repository does
+func (r *Repository) ClaimDue(_ context.Context, queue string, types []string, now time.Time, lease time.Duration, limit int) ([]domainJob.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []domainJob.Job
+	for _, job := range r.jobs {
+		if job.Queue != queue || !slices.Contains(types, job.Type) {
+			continue
+		}
+		pending := job.Status == domainJob.StatusPending && !job.RunAt.After(now)
+		expired := job.Status == domainJob.StatusRunning && job.LockedUntil != nil && !job.LockedUntil.After(now)
+		if pending || expired {
+			due = append(due, job)
+		}
+	}
+	slices.SortFunc(due, func(a, b domainJob.Job) int {
+		if c := a.RunAt.Compare(b.RunAt); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	})
+
+	lockedUntil := now.Add(lease)
+	var claimed []domainJob.Job
+	for _, job := range due {
+		if len(claimed) == limit {
+			break
+		}
+		if job.Status == domainJob.StatusRunning && job.Attempts >= job.MaxAttempts {
+			job.Status = domainJob.StatusFailed
+			job.LastError = domainJob.LeaseExpired
+			job.LockedUntil = nil
+			job.FinishedAt = &now
+			r.jobs[job.ID] = job
+			continue
+		}
+		job.Status = domainJob.StatusRunning
+		job.Attempts++
+		job.LockedUntil = &lockedUntil
+		job.StartedAt = &now
+		job.UpdatedAt = now
+		r.jobs[job.ID] = job
+		claimed = append(claimed, job)
+	}
+	return claimed, nil
+}
+
+// Complete records the result of the given attempt of a claimed job, unless the job
+// is no longer running that attempt. As in the SQL repository, the attempt whose lease
+// expired still completes the job it failed.
+func (r *Repository) Complete(_ context.Context, id int64, attempt int, result domainJob.Result) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	expired := job.Status == domainJob.StatusFailed && job.LastError == domainJob.LeaseExpired
+	if !ok || (job.Status != domainJob.StatusRunning && !expired) || job.Attempts != attempt {
+		return nil
+	}
+	job.Status = result.Status
+	job.LockedUntil = nil
+	if result.Error != "" {
+		job.LastError = result.Error
+	}
+	if result.Status == domainJob.StatusPending {
+		job.RunAt = result.RunAt
+		if result.Released {
+			job.Attempts--
+		}
+	} else {
+		job.FinishedAt = &result.At
+	}
+	job.UpdatedAt = r.now()
+	r.jobs[id] = job
+	return nil
+}
+
+func (r *Repository) find(ctx context.Context, id int64) (domainJob.Job, bool) {
+	job, ok := r.jobs[id]
+	if !ok || !memory.InTenant(ctx, job.TenantID) {
+		return domainJob.Job{}, false
+	}
+	return job, true
+}
+
+func fields(job *domainJob.Job) map[string]any {
+	return map[string]any{
+		"id":         job.ID,
+		"queue":      job.Queue,
+		"type":       job.Type,
+		"status":     job.Status,
+		"attempts":   job.Attempts,
+		"runAt":      job.RunAt,
+		"startedAt":  job.StartedAt,
+		"finishedAt": job.FinishedAt,
+		"createdAt":  job.CreatedAt,
+	}
+}
@@ -0,0 +1,61 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_ClaimAndComplete(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	repository := NewJobRepository(loggerInstance)
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	enqueue := func(jobType string, runAt time.Time, maxAttempts int) *domainJob.Job {
+		job, err := repository.Enqueue(ctx, &domainJob.Job{
+			Queue: domainJob.DefaultQueue, Type: jobType, Status: domainJob.StatusPending, MaxAttempts: maxAttempts, RunAt: runAt,
+		})
+		require.NoError(t, err)
+		return job
+	}
+	first := enqueue("report", now, 2)
+	lastAttempt := enqueue("report", now, 1)
+	enqueue("report", now.Add(time.Hour), 1)
+	enqueue("import", now, 1)
+
+	claimed, err := repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, first.ID, claimed[0].ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+
+	// the runner died: the leases expire
+	claimed, err = repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, now.Add(time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, first.ID, claimed[0].ID)
+	expired, err := repository.GetByID(ctx, lastAttempt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusFailed, expired.Status)
+	require.NoError(t, repository.Complete(ctx, lastAttempt.ID, 1, domainJob.Result{Status: domainJob.StatusSucceeded, At: now}))
+	late, err := repository.GetByID(ctx, lastAttempt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, late.Status, "the expired last attempt still completes its job")
+
+	require.NoError(t, repository.Complete(ctx, first.ID, 1, domainJob.Result{Status: domainJob.StatusFailed, At: now}))
+	stale, err := repository.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusRunning, stale.Status, "the expired attempt cannot overwrite the new one")
+	require.NoError(t, repository.Complete(ctx, first.ID, 2, domainJob.Result{Status: domainJob.StatusSucceeded, At: now}))
+	done, err := repository.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, done.Status)
+	assert.Equal(t, 2, done.Attempts)
+	assert.Nil(t, done.LockedUntil)
+}
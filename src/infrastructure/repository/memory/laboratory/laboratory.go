@@ -0,0 +1,218 @@
+package laboratory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	memoryMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/medicine"
+	psqlLaboratory "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// Medicines are the medicines linked to laboratories, which the SQL repository
+// reaches through the medicines table
+type Medicines interface {
+	// LinkedTo returns how many live medicines are linked to the laboratory
+	LinkedTo(ctx context.Context, laboratoryID int) int
+	UnlinkLaboratory(ctx context.Context, laboratoryID int)
+	// UseLaboratories hands the medicines the laboratories to rename along with them
+	UseLaboratories(laboratories memoryMedicine.Laboratories)
+}
+
+// Repository keeps laboratories in process memory. Data is lost when the process exits.
+type Repository struct {
+	Logger    *logger.Logger
+	medicines Medicines
+
+	mu           sync.RWMutex
+	lastID       int
+	laboratories map[int]domainLaboratory.Laboratory
+}
+
+// NewLaboratoryRepository creates a repository guarding and unlinking medicines as the
+// SQL repository does; medicines may be nil when nothing links to laboratories
+func NewLaboratoryRepository(loggerInstance *logger.Logger, medicines Medicines) psqlLaboratory.LaboratoryRepositoryInterface {
+	repository := &Repository{
+		Logger:       loggerInstance,
+		medicines:    medicines,
+		laboratories: make(map[int]domainLaboratory.Laboratory),
+	}
+	if medicines != nil {
+		medicines.UseLaboratories(repository)
+	}
+	return repository
+}
+
+func (r *Repository) GetAll(ctx context.Context) (*[]domainLaboratory.Laboratory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	laboratories := r.list(ctx)
+	r.Logger.Info("Successfully retrieved all laboratories", zap.Int("count", len(laboratories)))
+	return &laboratories, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainLaboratory.Laboratory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	laboratory, ok := r.laboratories[id]
+	if !ok || !memory.InTenant(ctx, laboratory.TenantID) {
+		r.Logger.Warn("Laboratory not found", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &laboratory, nil
+}
+
+// GetByName returns the laboratory known by any spelling of name
+func (r *Repository) GetByName(ctx context.Context, name string) (*domainLaboratory.Laboratory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if laboratory := r.byKey(ctx, domainLaboratory.Key(name), 0); laboratory != nil {
+		return laboratory, nil
+	}
+	r.Logger.Warn("Laboratory not found", zap.String("name", name))
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (r *Repository) Create(ctx context.Context, newLaboratory *domainLaboratory.Laboratory) (*domainLaboratory.Laboratory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.byKey(ctx, domainLaboratory.Key(newLaboratory.Name), 0) != nil {
+		r.Logger.Error("Error creating laboratory", zap.String("name", newLaboratory.Name), zap.String("reason", "duplicated name"))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	now := time.Now()
+	laboratory := domainLaboratory.Laboratory{
+		ID:        r.lastID + 1,
+		TenantID:  memory.TenantOf(ctx, newLaboratory.TenantID),
+		Name:      newLaboratory.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+		CreatedBy: security.ActorID(ctx),
+		UpdatedBy: security.ActorID(ctx),
+	}
+	r.lastID = laboratory.ID
+	r.laboratories[laboratory.ID] = laboratory
+	r.Logger.Info("Successfully created laboratory", zap.String("name", laboratory.Name), zap.Int("id", laboratory.ID))
+	return &laboratory, nil
+}
+
+// Update renames a laboratory alone; the medicine repository renames it along with its
+// medicines through Rename
+func (r *Repository) Update(ctx context.Context, id int, laboratoryMap map[string]any) (*domainLaboratory.Laboratory, error) {
+	if name, ok := laboratoryMap["name"].(string); ok {
+		return r.Rename(ctx, id, name)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Rename gives a laboratory a new name unless another laboratory already goes by it
+func (r *Repository) Rename(ctx context.Context, id int, name string) (*domainLaboratory.Laboratory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	laboratory, ok := r.laboratories[id]
+	if !ok || !memory.InTenant(ctx, laboratory.TenantID) {
+		r.Logger.Warn("Laboratory not found for update", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if r.byKey(ctx, domainLaboratory.Key(name), id) != nil {
+		r.Logger.Error("Error updating laboratory", zap.Int("id", id), zap.String("reason", "duplicated name"))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	laboratory.Name = name
+	laboratory.UpdatedAt = time.Now()
+	if actorID := security.ActorID(ctx); actorID != nil {
+		laboratory.UpdatedBy = actorID
+	}
+	r.laboratories[id] = laboratory
+	r.Logger.Info("Successfully updated laboratory", zap.Int("id", id))
+	return &laboratory, nil
+}
+
+// Delete removes a laboratory no live medicine is linked to. Deleted medicines linked
+// to it are unlinked and keep its name.
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	laboratory, ok := r.laboratories[id]
+	if !ok || !memory.InTenant(ctx, laboratory.TenantID) {
+		r.Logger.Warn("Laboratory not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if r.medicines != nil {
+		if r.medicines.LinkedTo(ctx, id) > 0 {
+			r.Logger.Error("Error deleting laboratory", zap.Int("id", id), zap.String("reason", "laboratory has medicines"))
+			return domainErrors.NewAppError(errors.New("laboratory has medicines; link them to another laboratory first"), domainErrors.ValidationError)
+		}
+		r.medicines.UnlinkLaboratory(ctx, id)
+	}
+	delete(r.laboratories, id)
+	r.Logger.Info("Successfully deleted laboratory", zap.Int("id", id))
+	return nil
+}
+
+// SearchPaginated lists laboratories ordered by name unless filters sort otherwise
+func (r *Repository) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainLaboratory.SearchResultLaboratory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	page := memory.Paginate(r.list(ctx), filters, fields)
+	r.Logger.Info("Successfully searched laboratories", zap.Int64("total", page.Total), zap.Int("page", page.Page))
+	return &domainLaboratory.SearchResultLaboratory{
+		Data:       &page.Data,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+	}, nil
+}
+
+// list returns the laboratories visible in ctx ordered by name
+func (r *Repository) list(ctx context.Context) []domainLaboratory.Laboratory {
+	laboratories := []domainLaboratory.Laboratory{}
+	for _, laboratory := range r.laboratories {
+		if memory.InTenant(ctx, laboratory.TenantID) {
+			laboratories = append(laboratories, laboratory)
+		}
+	}
+	slices.SortFunc(laboratories, func(a, b domainLaboratory.Laboratory) int { return strings.Compare(a.Name, b.Name) })
+	return laboratories
+}
+
+// byKey returns the laboratory visible in ctx going by nameKey, other than the one
+// with exceptID
+func (r *Repository) byKey(ctx context.Context, nameKey string, exceptID int) *domainLaboratory.Laboratory {
+	for id, laboratory := range r.laboratories {
+		if id != exceptID && memory.InTenant(ctx, laboratory.TenantID) && domainLaboratory.Key(laboratory.Name) == nameKey {
+			return &laboratory
+		}
+	}
+	return nil
+}
+
+func fields(l *domainLaboratory.Laboratory) map[string]any {
+	return map[string]any{
+		"id":        l.ID,
+		"tenantId":  l.TenantID,
+		"name":      l.Name,
+		"createdAt": l.CreatedAt,
+		"updatedAt": l.UpdatedAt,
+		"createdBy": l.CreatedBy,
+		"updatedBy": l.UpdatedBy,
+	}
+}
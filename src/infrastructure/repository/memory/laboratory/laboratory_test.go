@@ -0,0 +1,58 @@
+package laboratory
+
+import (
+	"context"
+	"testing"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	memoryMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/medicine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestRepository_GuardsMedicines(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	medicines := memoryMedicine.NewMedicineRepository(loggerInstance)
+	repo := NewLaboratoryRepository(loggerInstance, medicines.(Medicines))
+	ctx := context.Background()
+
+	pfizer, err := repo.Create(ctx, &domainLaboratory.Laboratory{Name: "Pfizer"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &domainLaboratory.Laboratory{Name: "PFIZER Inc."})
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+	found, err := repo.GetByName(ctx, "pfizer inc")
+	require.NoError(t, err)
+	assert.Equal(t, pfizer.ID, found.ID)
+
+	medicine, err := medicines.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "04006381333931", LaboratoryID: &pfizer.ID, Laboratory: "Pfizer"})
+	require.NoError(t, err)
+	updated, renamed, err := medicines.RenameLaboratory(ctx, pfizer.ID, "Pfizer Pharma")
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Pharma", updated.Name)
+	require.Len(t, *renamed, 1)
+	assert.Equal(t, "Pfizer Pharma", (*renamed)[0].Laboratory)
+	found, err = repo.GetByID(ctx, pfizer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Pharma", found.Name, "the laboratory is renamed with its medicines")
+	_, err = repo.Create(ctx, &domainLaboratory.Laboratory{Name: "Roche"})
+	require.NoError(t, err)
+	_, _, err = medicines.RenameLaboratory(ctx, pfizer.ID, "ROCHE")
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+
+	assertErrorType(t, repo.Delete(ctx, pfizer.ID), domainErrors.ValidationError)
+	require.NoError(t, medicines.Delete(ctx, medicine.ID))
+	require.NoError(t, repo.Delete(ctx, pfizer.ID))
+	_, err = repo.GetByID(ctx, pfizer.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
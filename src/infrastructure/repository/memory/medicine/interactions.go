@@ -0,0 +1,113 @@
+package medicine
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// SaveInteractions adds interactions to the knowledge base, replacing the severity and
+// description of the pairs it already has, and returns how many were saved
+func (r *Repository) SaveInteractions(ctx context.Context, interactions []domainMedicine.Interaction) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, interaction := range interactions {
+		saved := interaction
+		saved.TenantID = memory.TenantOf(ctx, interaction.TenantID)
+		saved.UpdatedAt, saved.UpdatedBy = now, security.ActorID(ctx)
+		index := slices.IndexFunc(r.interactions, func(existing domainMedicine.Interaction) bool {
+			return existing.TenantID == saved.TenantID && samePair(&existing, &saved)
+		})
+		if index >= 0 {
+			saved.ID, saved.CreatedAt, saved.CreatedBy = r.interactions[index].ID, r.interactions[index].CreatedAt, r.interactions[index].CreatedBy
+			r.interactions[index] = saved
+			continue
+		}
+		r.lastInteraction++
+		saved.ID, saved.CreatedAt, saved.CreatedBy = r.lastInteraction, now, security.ActorID(ctx)
+		r.interactions = append(r.interactions, saved)
+	}
+	r.Logger.Info("Successfully saved medicine interactions", zap.Int("count", len(interactions)))
+	return len(interactions), nil
+}
+
+// SaveIngredientClasses adds ingredients to classes, skipping the memberships already
+// known, and returns how many were given
+func (r *Repository) SaveIngredientClasses(ctx context.Context, classes []domainMedicine.IngredientClass) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, class := range classes {
+		saved := class
+		saved.TenantID = memory.TenantOf(ctx, class.TenantID)
+		known := slices.ContainsFunc(r.classes, func(existing domainMedicine.IngredientClass) bool {
+			return existing.TenantID == saved.TenantID &&
+				domainMedicine.IngredientKey(existing.Class) == domainMedicine.IngredientKey(saved.Class) &&
+				domainMedicine.IngredientKey(existing.Ingredient) == domainMedicine.IngredientKey(saved.Ingredient)
+		})
+		if known {
+			continue
+		}
+		r.lastClass++
+		saved.ID, saved.CreatedAt, saved.CreatedBy = r.lastClass, time.Now(), security.ActorID(ctx)
+		r.classes = append(r.classes, saved)
+	}
+	r.Logger.Info("Successfully saved ingredient classes", zap.Int("count", len(classes)))
+	return len(classes), nil
+}
+
+// GetIngredientClasses returns the class memberships of the named ingredients
+func (r *Repository) GetIngredientClasses(ctx context.Context, ingredients []string) (*[]domainMedicine.IngredientClass, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := ingredientKeys(ingredients)
+	classes := []domainMedicine.IngredientClass{}
+	for _, class := range r.classes {
+		if memory.InTenant(ctx, class.TenantID) && slices.Contains(keys, domainMedicine.IngredientKey(class.Ingredient)) {
+			classes = append(classes, class)
+		}
+	}
+	return &classes, nil
+}
+
+// GetInteractions returns the interactions whose two subjects are both in subjects
+func (r *Repository) GetInteractions(ctx context.Context, subjects []domainMedicine.InteractionSubject) (*[]domainMedicine.Interaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	listed := func(subject domainMedicine.InteractionSubject) bool {
+		return slices.ContainsFunc(subjects, func(other domainMedicine.InteractionSubject) bool {
+			return other.Class == subject.Class && other.Key() == subject.Key()
+		})
+	}
+	interactions := []domainMedicine.Interaction{}
+	for _, interaction := range r.interactions {
+		if memory.InTenant(ctx, interaction.TenantID) && listed(interaction.First) && listed(interaction.Second) {
+			interactions = append(interactions, interaction)
+		}
+	}
+	return &interactions, nil
+}
+
+// samePair reports whether two interactions have the same subjects, mirroring the
+// unique index of the SQL schema
+func samePair(a, b *domainMedicine.Interaction) bool {
+	return a.First.Class == b.First.Class && a.First.Key() == b.First.Key() &&
+		a.Second.Class == b.Second.Class && a.Second.Key() == b.Second.Key()
+}
+
+func ingredientKeys(names []string) []string {
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = domainMedicine.IngredientKey(name)
+	}
+	return keys
+}
@@ -0,0 +1,753 @@
+package medicine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	psqlMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// updatableColumns are the columns Update may change, as selected by the SQL repository
+var updatableColumns = []string{"name", "description", "ean_code", "laboratory_id", "laboratory", "dosage_form", "route", "package_size", "price", "ingredients"}
+
+// StockTotals reports the units on hand of every medicine with stock visible in ctx
+type StockTotals interface {
+	Totals(ctx context.Context) map[int]int
+}
+
+// Laboratories renames the laboratories whose medicines RenameLaboratory renames
+type Laboratories interface {
+	Rename(ctx context.Context, id int, name string) (*domainLaboratory.Laboratory, error)
+}
+
+// Repository keeps medicines in process memory. Data is lost when the process exits.
+type Repository struct {
+	Logger       *logger.Logger
+	stock        StockTotals
+	laboratories Laboratories
+
+	mu               sync.RWMutex
+	lastID           int
+	medicines        map[int]domainMedicine.Medicine
+	history          memory.History[domainMedicine.Medicine]
+	lastIngredientID int
+	ingredients      map[ingredientKey]domainMedicine.Ingredient
+	lastEquivalence  int
+	equivalences     map[int]domainMedicine.Equivalence
+	lastInteraction  int
+	interactions     []domainMedicine.Interaction
+	lastClass        int
+	classes          []domainMedicine.IngredientClass
+}
+
+// ingredientKey identifies an active ingredient of an organization, mirroring the
+// unique index of the SQL schema
+type ingredientKey struct {
+	tenantID int
+	name     string
+}
+
+func NewMedicineRepository(loggerInstance *logger.Logger) psqlMedicine.MedicineRepositoryInterface {
+	return &Repository{
+		Logger:    loggerInstance,
+		medicines: make(map[int]domainMedicine.Medicine),
+	}
+}
+
+// NewMedicineRepositoryWithStock creates a repository whose listings report, filter
+// and sort by the stock quantities of stock
+func NewMedicineRepositoryWithStock(loggerInstance *logger.Logger, stock StockTotals) psqlMedicine.MedicineRepositoryInterface {
+	return &Repository{
+		Logger:    loggerInstance,
+		stock:     stock,
+		medicines: make(map[int]domainMedicine.Medicine),
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, newMedicine *domainMedicine.Medicine) (*domainMedicine.Medicine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	medicine := domainMedicine.Medicine{
+		ID:           r.lastID + 1,
+		TenantID:     memory.TenantOf(ctx, newMedicine.TenantID),
+		Name:         newMedicine.Name,
+		Description:  newMedicine.Description,
+		EanCode:      newMedicine.EanCode,
+		LaboratoryID: newMedicine.LaboratoryID,
+		Laboratory:   newMedicine.Laboratory,
+		DosageForm:   newMedicine.DosageForm,
+		Route:        newMedicine.Route,
+		PackageSize:  copyPackageSize(newMedicine.PackageSize),
+		Price:        price(newMedicine.Price),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		CreatedBy:    security.ActorID(ctx),
+		UpdatedBy:    security.ActorID(ctx),
+	}
+	if r.conflicts(&medicine) {
+		r.Logger.Error("Error creating medicine", zap.String("name", newMedicine.Name), zap.String("reason", "duplicated name or EAN code"))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	medicine.Ingredients = r.register(medicine.TenantID, newMedicine.Ingredients)
+	if err := r.history.Record(ctx, medicine.TenantID, medicine.ID, domainHistory.ActionCreate, nil, fields(&medicine), medicine); err != nil {
+		r.Logger.Error("Error creating medicine", zap.Error(err), zap.String("name", newMedicine.Name))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.lastID = medicine.ID
+	r.medicines[medicine.ID] = medicine
+	r.Logger.Info("Successfully created medicine", zap.String("name", newMedicine.Name), zap.Int("id", medicine.ID))
+	return &medicine, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainMedicine.Medicine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	medicine, ok := r.find(ctx, id)
+	if !ok || medicine.DeletedAt != nil {
+		r.Logger.Warn("Medicine not found", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully retrieved medicine by ID", zap.Int("id", id))
+	return &medicine, nil
+}
+
+// GetByEanCode returns the live medicine whose EAN code is any of eanCodes
+func (r *Repository) GetByEanCode(ctx context.Context, eanCodes []string) (*domainMedicine.Medicine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, medicine := range r.list(ctx, false) {
+		if slices.Contains(eanCodes, medicine.EanCode) {
+			r.Logger.Info("Successfully retrieved medicine by EAN code", zap.Int("id", medicine.ID))
+			return &medicine, nil
+		}
+	}
+	r.Logger.Warn("Medicine not found", zap.Strings("eanCodes", eanCodes))
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (r *Repository) Update(ctx context.Context, id int, medicineMap map[string]any) (*domainMedicine.Medicine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.find(ctx, id)
+	if !ok || before.DeletedAt != nil {
+		r.Logger.Error("Error updating medicine", zap.Int("id", id), zap.String("reason", "not found"))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	medicine := before
+	var ingredients []domainMedicine.Ingredient
+	replaceIngredients := false
+	for key, value := range medicineMap {
+		column := psqlMedicine.ColumnsMedicineMapping[key]
+		if column == "" {
+			column = key
+		}
+		if !slices.Contains(updatableColumns, column) {
+			continue
+		}
+		switch column {
+		case "laboratory_id":
+			medicine.LaboratoryID = laboratoryID(value)
+			continue
+		case "package_size":
+			size, _ := value.(*domainMedicine.PackageSize)
+			medicine.PackageSize = copyPackageSize(size)
+			continue
+		case "ingredients":
+			ingredients, _ = value.([]domainMedicine.Ingredient)
+			replaceIngredients = true
+			continue
+		case "price":
+			medicine.Price = price(value)
+			continue
+		}
+		text := ""
+		if value != nil {
+			text = fmt.Sprint(value)
+		}
+		switch column {
+		case "name":
+			medicine.Name = text
+		case "description":
+			medicine.Description = text
+		case "ean_code":
+			medicine.EanCode = text
+		case "laboratory":
+			medicine.Laboratory = text
+		case "dosage_form":
+			medicine.DosageForm = text
+		case "route":
+			medicine.Route = text
+		}
+	}
+	medicine.UpdatedAt = time.Now()
+	if actorID := security.ActorID(ctx); actorID != nil {
+		medicine.UpdatedBy = actorID
+	}
+	if r.conflicts(&medicine) {
+		r.Logger.Error("Error updating medicine", zap.Int("id", id), zap.String("reason", "duplicated name or EAN code"))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	if replaceIngredients {
+		medicine.Ingredients = r.register(medicine.TenantID, ingredients)
+	}
+	if err := r.history.Record(ctx, medicine.TenantID, id, domainHistory.ActionUpdate, fields(&before), fields(&medicine), medicine); err != nil {
+		r.Logger.Error("Error updating medicine", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.medicines[id] = medicine
+	r.Logger.Info("Successfully updated medicine", zap.Int("id", id))
+	return &medicine, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.find(ctx, id)
+	if !ok || before.DeletedAt != nil {
+		r.Logger.Warn("Medicine not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	medicine := before
+	deletedAt := time.Now()
+	medicine.DeletedAt = &deletedAt
+	if err := r.history.Record(ctx, medicine.TenantID, id, domainHistory.ActionDelete, fields(&before), fields(&medicine), medicine); err != nil {
+		r.Logger.Error("Error deleting medicine", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.medicines[id] = medicine
+	r.Logger.Info("Successfully deleted medicine", zap.Int("id", id))
+	return nil
+}
+
+// Restore clears the deletion mark of a soft-deleted medicine
+func (r *Repository) Restore(ctx context.Context, id int) (*domainMedicine.Medicine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.find(ctx, id)
+	if !ok || before.DeletedAt == nil {
+		r.Logger.Warn("Deleted medicine not found for restore", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	medicine := before
+	medicine.DeletedAt = nil
+	medicine.UpdatedAt = time.Now()
+	if actorID := security.ActorID(ctx); actorID != nil {
+		medicine.UpdatedBy = actorID
+	}
+	if r.conflicts(&medicine) {
+		r.Logger.Error("Error restoring medicine", zap.Int("id", id), zap.String("reason", "duplicated name or EAN code"))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	if err := r.history.Record(ctx, medicine.TenantID, id, domainHistory.ActionRestore, fields(&before), fields(&medicine), medicine); err != nil {
+		r.Logger.Error("Error restoring medicine", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.medicines[id] = medicine
+	r.Logger.Info("Successfully restored medicine", zap.Int("id", id))
+	return &medicine, nil
+}
+
+// Purge permanently removes medicines soft-deleted before olderThan
+func (r *Repository) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for id, medicine := range r.medicines {
+		if memory.InTenant(ctx, medicine.TenantID) && medicine.DeletedAt != nil && medicine.DeletedAt.Before(olderThan) {
+			delete(r.medicines, id)
+			count++
+		}
+	}
+	// The equivalences of purged medicines go with them, as their foreign keys cascade
+	for id, equivalence := range r.equivalences {
+		_, hasMedicine := r.medicines[equivalence.MedicineID]
+		_, hasSubstitute := r.medicines[equivalence.SubstituteID]
+		if !hasMedicine || !hasSubstitute {
+			delete(r.equivalences, id)
+		}
+	}
+	r.Logger.Info("Successfully purged deleted medicines", zap.Int64("count", count))
+	return count, nil
+}
+
+func (r *Repository) GetAll(ctx context.Context) (*[]domainMedicine.Medicine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	medicines := r.list(ctx, false)
+	r.Logger.Info("Successfully retrieved all medicines", zap.Int("count", len(medicines)))
+	return &medicines, nil
+}
+
+func (r *Repository) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := toSearchResult(memory.Paginate(r.withStock(ctx, r.list(ctx, false)), searchFilters(filters), searchFields))
+	r.Logger.Info("Successfully searched medicines",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page),
+		zap.Int("pageSize", result.PageSize))
+	return result, nil
+}
+
+// GetTrash lists soft-deleted medicines using the same filters as SearchPaginated
+func (r *Repository) GetTrash(ctx context.Context, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := toSearchResult(memory.Paginate(r.withStock(ctx, r.list(ctx, true)), searchFilters(filters), searchFields))
+	r.Logger.Info("Successfully listed deleted medicines",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+	return result, nil
+}
+
+func (r *Repository) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
+	if property == psqlMedicine.IngredientField {
+		return r.searchIngredients(ctx, searchText), nil
+	}
+	if psqlMedicine.ColumnsMedicineMapping[property] == "" {
+		r.Logger.Warn("Invalid property for search", zap.String("property", property))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	coincidences, _ := memory.SearchByProperty(r.list(ctx, false), property, searchText, fields)
+	r.Logger.Info("Successfully searched by property",
+		zap.String("property", property),
+		zap.Int("results", len(coincidences)))
+	return &coincidences, nil
+}
+
+// GetHistory returns every recorded change of a medicine, oldest first
+func (r *Repository) GetHistory(ctx context.Context, id int) (*[]domainHistory.Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := r.history.Entries(ctx, id)
+	if len(entries) == 0 {
+		r.Logger.Warn("Medicine history not found", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully retrieved medicine history", zap.Int("id", id), zap.Int("count", len(entries)))
+	return &entries, nil
+}
+
+// GetAsOf rebuilds a medicine as it was at the given time from its history
+func (r *Repository) GetAsOf(ctx context.Context, id int, at time.Time) (*domainMedicine.Medicine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	medicine, ok := r.history.AsOf(ctx, id, at)
+	if !ok {
+		r.Logger.Warn("Medicine not found at requested time", zap.Int("id", id), zap.Time("asOf", at))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully retrieved medicine as of time", zap.Int("id", id), zap.Time("asOf", at))
+	return &medicine, nil
+}
+
+// GetSubstituteCandidates returns, with their stock quantities, the live medicines
+// other than medicine with ids and those of its dosage form containing its first
+// active ingredient
+func (r *Repository) GetSubstituteCandidates(ctx context.Context, medicine *domainMedicine.Medicine, ids []int) (*[]domainMedicine.Medicine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	candidates := []domainMedicine.Medicine{}
+	for _, candidate := range r.list(ctx, false) {
+		if candidate.ID != medicine.ID && (slices.Contains(ids, candidate.ID) || sameFormAndFirstIngredient(medicine, &candidate)) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	candidates = r.withStock(ctx, candidates)
+	r.Logger.Info("Successfully retrieved substitute candidates", zap.Int("id", medicine.ID), zap.Int("count", len(candidates)))
+	return &candidates, nil
+}
+
+// GetEquivalences returns the equivalences of a medicine ordered by substitute
+func (r *Repository) GetEquivalences(ctx context.Context, medicineID int) (*[]domainMedicine.Equivalence, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	equivalences := []domainMedicine.Equivalence{}
+	for _, equivalence := range r.equivalences {
+		if equivalence.MedicineID == medicineID && memory.InTenant(ctx, equivalence.TenantID) {
+			equivalences = append(equivalences, equivalence)
+		}
+	}
+	slices.SortFunc(equivalences, func(a, b domainMedicine.Equivalence) int { return a.SubstituteID - b.SubstituteID })
+	return &equivalences, nil
+}
+
+// SaveEquivalence creates the equivalence of a medicine and a substitute or replaces
+// its kind and note
+func (r *Repository) SaveEquivalence(ctx context.Context, equivalence *domainMedicine.Equivalence) (*domainMedicine.Equivalence, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	saved := domainMedicine.Equivalence{
+		ID:           r.lastEquivalence + 1,
+		TenantID:     memory.TenantOf(ctx, equivalence.TenantID),
+		MedicineID:   equivalence.MedicineID,
+		SubstituteID: equivalence.SubstituteID,
+		CreatedAt:    now,
+		CreatedBy:    security.ActorID(ctx),
+	}
+	for _, existing := range r.equivalences {
+		if existing.TenantID == saved.TenantID && existing.MedicineID == saved.MedicineID && existing.SubstituteID == saved.SubstituteID {
+			saved = existing
+		}
+	}
+	if r.equivalences == nil {
+		r.equivalences = make(map[int]domainMedicine.Equivalence)
+	}
+	r.lastEquivalence = max(r.lastEquivalence, saved.ID)
+	saved.Kind, saved.Note = equivalence.Kind, equivalence.Note
+	saved.UpdatedAt, saved.UpdatedBy = now, security.ActorID(ctx)
+	r.equivalences[saved.ID] = saved
+	r.Logger.Info("Successfully saved medicine equivalence", zap.Int("id", saved.ID), zap.Int("medicineId", saved.MedicineID), zap.Int("substituteId", saved.SubstituteID))
+	return &saved, nil
+}
+
+// DeleteEquivalence removes an equivalence
+func (r *Repository) DeleteEquivalence(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	equivalence, ok := r.equivalences[id]
+	if !ok || !memory.InTenant(ctx, equivalence.TenantID) {
+		r.Logger.Warn("Medicine equivalence not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	delete(r.equivalences, id)
+	r.Logger.Info("Successfully deleted medicine equivalence", zap.Int("id", id))
+	return nil
+}
+
+// searchIngredients returns the names of the active ingredients visible in ctx
+// containing searchText
+func (r *Repository) searchIngredients(ctx context.Context, searchText string) *[]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	coincidences := []string{}
+	for key, ingredient := range r.ingredients {
+		if memory.InTenant(ctx, key.tenantID) && strings.Contains(key.name, strings.ToLower(searchText)) {
+			coincidences = append(coincidences, ingredient.Name)
+		}
+	}
+	slices.Sort(coincidences)
+	coincidences = coincidences[:min(len(coincidences), 20)]
+	r.Logger.Info("Successfully searched active ingredients", zap.Int("results", len(coincidences)))
+	return &coincidences
+}
+
+// LinkedTo returns how many live medicines visible in ctx are linked to the laboratory
+func (r *Repository) LinkedTo(ctx context.Context, laboratoryID int) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, medicine := range r.list(ctx, false) {
+		if medicine.LaboratoryID != nil && *medicine.LaboratoryID == laboratoryID {
+			count++
+		}
+	}
+	return count
+}
+
+// UseLaboratories sets the laboratories RenameLaboratory renames
+func (r *Repository) UseLaboratories(laboratories Laboratories) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.laboratories = laboratories
+}
+
+// RenameLaboratory renames a laboratory and gives the medicines linked to it its new
+// name. It returns the laboratory and the live medicines, whose change is recorded as
+// any other update. The laboratory is renamed before the medicines are locked, as the
+// laboratory repository locks them while holding its own lock.
+func (r *Repository) RenameLaboratory(ctx context.Context, laboratoryID int, name string) (*domainLaboratory.Laboratory, *[]domainMedicine.Medicine, error) {
+	r.mu.RLock()
+	laboratories := r.laboratories
+	r.mu.RUnlock()
+	if laboratories == nil {
+		r.Logger.Warn("Laboratory not found for renaming", zap.Int("laboratoryId", laboratoryID))
+		return nil, nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	laboratory, err := laboratories.Rename(ctx, laboratoryID, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	renamed := []domainMedicine.Medicine{}
+	for _, before := range r.list(ctx, false) {
+		if before.LaboratoryID == nil || *before.LaboratoryID != laboratoryID || before.Laboratory == name {
+			continue
+		}
+		medicine := before
+		medicine.Laboratory = name
+		medicine.UpdatedAt = time.Now()
+		if actorID := security.ActorID(ctx); actorID != nil {
+			medicine.UpdatedBy = actorID
+		}
+		if err := r.history.Record(ctx, medicine.TenantID, medicine.ID, domainHistory.ActionUpdate, fields(&before), fields(&medicine), medicine); err != nil {
+			r.Logger.Error("Error renaming the laboratory of medicines", zap.Error(err), zap.Int("laboratoryId", laboratoryID))
+			return nil, nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		r.medicines[medicine.ID] = medicine
+		renamed = append(renamed, medicine)
+	}
+	for _, medicine := range r.list(ctx, true) {
+		if medicine.LaboratoryID != nil && *medicine.LaboratoryID == laboratoryID {
+			medicine.Laboratory = name
+			r.medicines[medicine.ID] = medicine
+		}
+	}
+	r.Logger.Info("Successfully renamed the laboratory of medicines", zap.Int("laboratoryId", laboratoryID), zap.Int("count", len(renamed)))
+	return laboratory, &renamed, nil
+}
+
+// LinkLaboratories links nothing: medicines in memory never predate laboratories
+func (r *Repository) LinkLaboratories(ctx context.Context) (*[]domainMedicine.Medicine, error) {
+	return &[]domainMedicine.Medicine{}, nil
+}
+
+// UnlinkLaboratory clears the link of the medicines linked to the laboratory, which
+// keep its name
+func (r *Repository) UnlinkLaboratory(ctx context.Context, laboratoryID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, medicine := range r.medicines {
+		if medicine.LaboratoryID != nil && *medicine.LaboratoryID == laboratoryID && memory.InTenant(ctx, medicine.TenantID) {
+			medicine.LaboratoryID = nil
+			r.medicines[id] = medicine
+		}
+	}
+}
+
+// find returns the medicine with the given ID when it is visible in ctx
+func (r *Repository) find(ctx context.Context, id int) (domainMedicine.Medicine, bool) {
+	medicine, ok := r.medicines[id]
+	if !ok || !memory.InTenant(ctx, medicine.TenantID) {
+		return domainMedicine.Medicine{}, false
+	}
+	return medicine, true
+}
+
+// list returns the live or the soft-deleted medicines visible in ctx ordered by ID
+func (r *Repository) list(ctx context.Context, deleted bool) []domainMedicine.Medicine {
+	medicines := make([]domainMedicine.Medicine, 0, len(r.medicines))
+	for _, medicine := range r.medicines {
+		if (medicine.DeletedAt != nil) == deleted && memory.InTenant(ctx, medicine.TenantID) {
+			medicines = append(medicines, medicine)
+		}
+	}
+	slices.SortFunc(medicines, func(a, b domainMedicine.Medicine) int { return a.ID - b.ID })
+	return medicines
+}
+
+// withStock sets the stock quantity of medicines, as the SQL listings compute it
+func (r *Repository) withStock(ctx context.Context, medicines []domainMedicine.Medicine) []domainMedicine.Medicine {
+	var totals map[int]int
+	if r.stock != nil {
+		totals = r.stock.Totals(ctx)
+	}
+	for i := range medicines {
+		quantity := totals[medicines[i].ID]
+		medicines[i].StockQuantity = &quantity
+	}
+	return medicines
+}
+
+// conflicts reports whether another live medicine of the same tenant shares the name or
+// EAN code of medicine, mirroring the partial unique indexes of the SQL schema
+func (r *Repository) conflicts(medicine *domainMedicine.Medicine) bool {
+	for id, other := range r.medicines {
+		if id == medicine.ID || other.DeletedAt != nil || other.TenantID != medicine.TenantID {
+			continue
+		}
+		if other.Name == medicine.Name || other.EanCode == medicine.EanCode {
+			return true
+		}
+	}
+	return false
+}
+
+// register returns ingredients with the IDs and first registered names of the active
+// ingredients of the organization, registering the compounds it does not know yet
+func (r *Repository) register(tenantID int, ingredients []domainMedicine.Ingredient) []domainMedicine.Ingredient {
+	if r.ingredients == nil {
+		r.ingredients = make(map[ingredientKey]domainMedicine.Ingredient)
+	}
+	registered := make([]domainMedicine.Ingredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		key := ingredientKey{tenantID: tenantID, name: domainMedicine.IngredientKey(ingredient.Name)}
+		known, ok := r.ingredients[key]
+		if !ok {
+			r.lastIngredientID++
+			known = domainMedicine.Ingredient{ID: r.lastIngredientID, Name: ingredient.Name}
+			r.ingredients[key] = known
+		}
+		registered[i] = domainMedicine.Ingredient{ID: known.ID, Name: known.Name, Strength: ingredient.Strength, Unit: ingredient.Unit}
+	}
+	return registered
+}
+
+func fields(m *domainMedicine.Medicine) map[string]any {
+	return map[string]any{
+		"id":            m.ID,
+		"tenantId":      m.TenantID,
+		"name":          m.Name,
+		"description":   m.Description,
+		"eanCode":       m.EanCode,
+		"laboratoryId":  m.LaboratoryID,
+		"laboratory":    m.Laboratory,
+		"ingredients":   ingredientFields(m.Ingredients),
+		"dosageForm":    m.DosageForm,
+		"route":         m.Route,
+		"packageSize":   packageSizeFields(m.PackageSize),
+		"price":         m.Price,
+		"createdAt":     m.CreatedAt,
+		"updatedAt":     m.UpdatedAt,
+		"deletedAt":     m.DeletedAt,
+		"createdBy":     m.CreatedBy,
+		"updatedBy":     m.UpdatedBy,
+		"stockQuantity": m.StockQuantity,
+	}
+}
+
+// searchFields are the fields listings filter by: those of fields plus the keys of the
+// names of the active ingredients, which ingredient filters match as the SQL ones do
+func searchFields(m *domainMedicine.Medicine) map[string]any {
+	values := fields(m)
+	keys := make([]string, len(m.Ingredients))
+	for i, ingredient := range m.Ingredients {
+		keys[i] = domainMedicine.IngredientKey(ingredient.Name)
+	}
+	values[psqlMedicine.IngredientField] = keys
+	return values
+}
+
+// searchFilters returns filters with the ingredient names they match turned into keys
+func searchFilters(filters domain.DataFilters) domain.DataFilters {
+	names, ok := filters.Matches[psqlMedicine.IngredientField]
+	if !ok {
+		return filters
+	}
+	matches := make(map[string][]string, len(filters.Matches))
+	for field, values := range filters.Matches {
+		matches[field] = values
+	}
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = domainMedicine.IngredientKey(name)
+	}
+	matches[psqlMedicine.IngredientField] = keys
+	filters.Matches = matches
+	return filters
+}
+
+// ingredientFields and packageSizeFields serialize like the SQL history snapshots
+func ingredientFields(ingredients []domainMedicine.Ingredient) []map[string]any {
+	values := make([]map[string]any, len(ingredients))
+	for i, ingredient := range ingredients {
+		values[i] = map[string]any{"id": ingredient.ID, "name": ingredient.Name, "strength": ingredient.Strength, "unit": ingredient.Unit}
+	}
+	return values
+}
+
+func packageSizeFields(size *domainMedicine.PackageSize) map[string]any {
+	if size == nil {
+		return nil
+	}
+	return map[string]any{"quantity": size.Quantity, "unit": size.Unit}
+}
+
+func copyPackageSize(size *domainMedicine.PackageSize) *domainMedicine.PackageSize {
+	if size == nil {
+		return nil
+	}
+	copied := *size
+	return &copied
+}
+
+// sameFormAndFirstIngredient reports whether candidate is of the dosage form of
+// medicine and contains its first active ingredient, as the SQL candidates are found
+func sameFormAndFirstIngredient(medicine, candidate *domainMedicine.Medicine) bool {
+	if medicine.DosageForm == "" || len(medicine.Ingredients) == 0 || candidate.DosageForm != medicine.DosageForm {
+		return false
+	}
+	return slices.ContainsFunc(candidate.Ingredients, func(ingredient domainMedicine.Ingredient) bool {
+		return ingredient.ID == medicine.Ingredients[0].ID
+	})
+}
+
+// price reads the price of a medicine or an update, which is nil while unknown
+func price(value any) *float64 {
+	switch typed := value.(type) {
+	case float64:
+		return &typed
+	case *float64:
+		if typed == nil {
+			return nil
+		}
+		copied := *typed
+		return &copied
+	}
+	return nil
+}
+
+// laboratoryID reads the laboratory ID of an update, which is nil to unlink it
+func laboratoryID(value any) *int {
+	switch typed := value.(type) {
+	case int:
+		return &typed
+	case *int:
+		return typed
+	case float64:
+		id := int(typed)
+		return &id
+	}
+	return nil
+}
+
+func toSearchResult(page memory.Page[domainMedicine.Medicine]) *domainMedicine.SearchResultMedicine {
+	return &domainMedicine.SearchResultMedicine{
+		Data:       &page.Data,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+	}
+}
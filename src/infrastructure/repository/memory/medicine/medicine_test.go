@@ -0,0 +1,250 @@
+package medicine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	psqlMedicine "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRepository(t *testing.T) psqlMedicine.MedicineRepositoryInterface {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewMedicineRepository(loggerInstance)
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestRepository_CreateAndGet(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 4})
+
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.ID)
+	assert.Equal(t, 4, *created.CreatedBy)
+	assert.Equal(t, 4, *created.UpdatedBy)
+
+	found, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Aspirin", found.Name)
+
+	_, err = repo.GetByID(ctx, 99)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_GetByEanCode(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 4, TenantID: 1})
+
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "4006381333931"})
+	require.NoError(t, err)
+
+	found, err := repo.GetByEanCode(ctx, []string{"04006381333931", "4006381333931"})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, found.ID)
+
+	_, err = repo.GetByEanCode(security.WithPrincipal(context.Background(), security.Principal{UserID: 5, TenantID: 2}), []string{"4006381333931"})
+	assertErrorType(t, err, domainErrors.NotFound)
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	_, err = repo.GetByEanCode(ctx, []string{"4006381333931"})
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_CreateDuplicate(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+
+	_, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &domainMedicine.Medicine{Name: "Other", EanCode: "7501"})
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+}
+
+func TestRepository_Update(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+
+	updated, err := repo.Update(ctx, created.ID, map[string]any{"ean_code": "7509", "id": 42})
+	require.NoError(t, err)
+	assert.Equal(t, "7509", updated.EanCode)
+	assert.Equal(t, created.ID, updated.ID)
+
+	_, err = repo.Update(ctx, 99, map[string]any{"name": "X"})
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_DeleteRestoreAndPurge(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	_, err = repo.GetByID(ctx, created.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+	assertErrorType(t, repo.Delete(ctx, created.ID), domainErrors.NotFound)
+
+	trash, err := repo.GetTrash(ctx, domain.DataFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), trash.Total)
+
+	restored, err := repo.Restore(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	count, err := repo.Purge(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	_, err = repo.Restore(ctx, created.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_RestoreConflict(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	_, err = repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7502"})
+	require.NoError(t, err)
+
+	_, err = repo.Restore(ctx, created.ID)
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+}
+
+func TestRepository_SearchPaginated(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	for _, name := range []string{"Aspirin", "Aspirin Forte", "Ibuprofen"} {
+		_, err := repo.Create(ctx, &domainMedicine.Medicine{Name: name, EanCode: name, Laboratory: "Bayer"})
+		require.NoError(t, err)
+	}
+
+	result, err := repo.SearchPaginated(ctx, domain.DataFilters{
+		LikeFilters:   map[string][]string{"name": {"aspirin"}},
+		SortBy:        []string{"name"},
+		SortDirection: domain.SortDesc,
+	})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 2)
+	assert.Equal(t, "Aspirin Forte", (*result.Data)[0].Name)
+
+	coincidences, err := repo.SearchByProperty(ctx, "laboratory", "bay")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bayer"}, *coincidences)
+
+	_, err = repo.SearchByProperty(ctx, "unknown", "x")
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestRepository_Ingredients(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	augmentin, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Augmentin", EanCode: "7501", Ingredients: []domainMedicine.Ingredient{
+		{Name: "Amoxicillin", Strength: 875, Unit: "mg"},
+		{Name: "Clavulanic acid", Strength: 125, Unit: "mg"},
+	}})
+	require.NoError(t, err)
+	amoxil, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Amoxil", EanCode: "7502", Ingredients: []domainMedicine.Ingredient{
+		{Name: "AMOXICILLIN", Strength: 50, Unit: "mg/mL"},
+	}})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7503"})
+	require.NoError(t, err)
+	assert.Equal(t, augmentin.Ingredients[0].ID, amoxil.Ingredients[0].ID)
+	assert.Equal(t, "Amoxicillin", amoxil.Ingredients[0].Name, "the first spelling is kept")
+
+	result, err := repo.SearchPaginated(ctx, domain.DataFilters{Matches: map[string][]string{"ingredient": {"amoxicillin"}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	result, err = repo.SearchPaginated(ctx, domain.DataFilters{LikeFilters: map[string][]string{"ingredient": {"CLAVUL"}}})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 1)
+	assert.Equal(t, "Augmentin", (*result.Data)[0].Name)
+
+	coincidences, err := repo.SearchByProperty(ctx, "ingredient", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Amoxicillin", "Clavulanic acid"}, *coincidences)
+
+	updated, err := repo.Update(ctx, amoxil.ID, map[string]any{
+		"ingredients":  []domainMedicine.Ingredient{{Name: "Clavulanic Acid", Strength: 31.25, Unit: "mg/mL"}},
+		"package_size": &domainMedicine.PackageSize{Quantity: 100, Unit: "mL"},
+		"dosage_form":  "suspension",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, augmentin.Ingredients[1].ID, updated.Ingredients[0].ID)
+	assert.Equal(t, "suspension", updated.DosageForm)
+	entries, err := repo.GetHistory(ctx, amoxil.ID)
+	require.NoError(t, err)
+	require.Len(t, *entries, 2)
+	assert.Contains(t, (*entries)[1].Changes, "ingredients")
+	assert.Contains(t, (*entries)[1].Changes, "packageSize")
+}
+
+func TestRepository_HistoryAndAsOf(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	beforeUpdate := time.Now()
+	time.Sleep(time.Millisecond)
+	_, err = repo.Update(ctx, created.ID, map[string]any{"name": "Aspirin Forte"})
+	require.NoError(t, err)
+
+	entries, err := repo.GetHistory(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, *entries, 2)
+	assert.Equal(t, domainHistory.ActionUpdate, (*entries)[1].Action)
+	assert.Equal(t, "Aspirin Forte", (*entries)[1].Changes["name"].After)
+
+	asOf, err := repo.GetAsOf(ctx, created.ID, beforeUpdate)
+	require.NoError(t, err)
+	assert.Equal(t, "Aspirin", asOf.Name)
+
+	_, err = repo.GetHistory(ctx, 99)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_TenantIsolation(t *testing.T) {
+	repo := setupRepository(t)
+	firstTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+	secondTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 2, TenantID: 2})
+
+	created, err := repo.Create(firstTenant, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501", TenantID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.TenantID)
+	_, err = repo.Create(secondTenant, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+
+	_, err = repo.GetByID(secondTenant, created.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+	assertErrorType(t, repo.Delete(secondTenant, created.ID), domainErrors.NotFound)
+	_, err = repo.GetHistory(secondTenant, created.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+
+	result, err := repo.SearchPaginated(firstTenant, domain.DataFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, *all, 2)
+}
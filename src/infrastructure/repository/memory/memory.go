@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+)
+
+// FieldsFunc returns the fields of a stored record keyed by their API name
+type FieldsFunc[T any] func(item *T) map[string]any
+
+// Page is one page of the records matching a DataFilters query
+type Page[T any] struct {
+	Data       []T
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// Paginate applies the like, match, date range, sorting and paging rules of the SQL
+// repositories to items. Unknown field names are ignored, as unmapped columns are there.
+func Paginate[T any](items []T, filters domain.DataFilters, fields FieldsFunc[T]) Page[T] {
+	matched := []T{}
+	var matchedFields []map[string]any
+	for i := range items {
+		values := fields(&items[i])
+		if matchesFilters(values, filters) {
+			matched = append(matched, items[i])
+			matchedFields = append(matchedFields, values)
+		}
+	}
+
+	if len(filters.SortBy) > 0 && filters.SortDirection.IsValid() {
+		order := make([]int, len(matched))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortStableFunc(order, func(a, b int) int {
+			for _, field := range filters.SortBy {
+				left, ok := matchedFields[a][field]
+				if !ok {
+					continue
+				}
+				result := compareValues(left, matchedFields[b][field])
+				if filters.SortDirection == domain.SortDesc {
+					result = -result
+				}
+				if result != 0 {
+					return result
+				}
+			}
+			return 0
+		})
+		sorted := make([]T, len(matched))
+		for i, index := range order {
+			sorted[i] = matched[index]
+		}
+		matched = sorted
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	total := len(matched)
+	start := min((filters.Page-1)*filters.PageSize, total)
+	end := min(start+filters.PageSize, total)
+
+	return Page[T]{
+		Data:       matched[start:end],
+		Total:      int64(total),
+		Page:       filters.Page,
+		PageSize:   filters.PageSize,
+		TotalPages: (total + filters.PageSize - 1) / filters.PageSize,
+	}
+}
+
+// SearchByProperty returns up to 20 distinct values of property containing searchText,
+// ignoring case. ok is false when the records have no such property.
+func SearchByProperty[T any](items []T, property string, searchText string, fields FieldsFunc[T]) (coincidences []string, ok bool) {
+	coincidences = []string{}
+	for i := range items {
+		value, exists := fields(&items[i])[property]
+		if !exists {
+			return nil, false
+		}
+		for _, text := range valueTexts(value) {
+			if !containsFold(text, searchText) || slices.Contains(coincidences, text) {
+				continue
+			}
+			coincidences = append(coincidences, text)
+			if len(coincidences) == 20 {
+				return coincidences, true
+			}
+		}
+	}
+	return coincidences, true
+}
+
+func matchesFilters(values map[string]any, filters domain.DataFilters) bool {
+	for field, likeValues := range filters.LikeFilters {
+		value, exists := values[field]
+		if !exists {
+			continue
+		}
+		for _, likeValue := range likeValues {
+			if likeValue == "" {
+				continue
+			}
+			if !slices.ContainsFunc(valueTexts(value), func(text string) bool { return containsFold(text, likeValue) }) {
+				return false
+			}
+		}
+	}
+
+	for field, candidates := range filters.Matches {
+		value, exists := values[field]
+		if !exists || len(candidates) == 0 {
+			continue
+		}
+		if !matchesAny(value, candidates) {
+			return false
+		}
+	}
+
+	for _, dateFilter := range filters.DateRangeFilters {
+		value, exists := values[dateFilter.Field]
+		if !exists || (dateFilter.Start == nil && dateFilter.End == nil) {
+			continue
+		}
+		date, isSet := normalize(value).(time.Time)
+		if !isSet {
+			return false
+		}
+		if dateFilter.Start != nil && date.Before(*dateFilter.Start) {
+			return false
+		}
+		if dateFilter.End != nil && date.After(*dateFilter.End) {
+			return false
+		}
+	}
+
+	for _, numberFilter := range filters.NumberRangeFilters {
+		value, exists := values[numberFilter.Field]
+		if !exists || (numberFilter.Min == nil && numberFilter.Max == nil) {
+			continue
+		}
+		number, isSet := normalize(value).(int)
+		if !isSet {
+			return false
+		}
+		if numberFilter.Min != nil && number < *numberFilter.Min {
+			return false
+		}
+		if numberFilter.Max != nil && number > *numberFilter.Max {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(value any, candidates []string) bool {
+	value = normalize(value)
+	if value == nil {
+		return false
+	}
+	if list, isList := value.([]string); isList {
+		return slices.ContainsFunc(list, func(text string) bool { return slices.Contains(candidates, text) })
+	}
+	for _, candidate := range candidates {
+		switch typed := value.(type) {
+		case bool:
+			if parsed, err := strconv.ParseBool(candidate); err == nil && parsed == typed {
+				return true
+			}
+		case time.Time:
+			if parsed, err := time.Parse(time.RFC3339, candidate); err == nil && parsed.Equal(typed) {
+				return true
+			}
+		default:
+			if fmt.Sprint(typed) == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareValues orders two field values; missing values sort last, as NULLs do in PostgreSQL
+func compareValues(a, b any) int {
+	a, b = normalize(a), normalize(b)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	switch left := a.(type) {
+	case string:
+		return strings.Compare(left, b.(string))
+	case int:
+		return left - b.(int)
+	case int64:
+		return cmp.Compare(left, b.(int64))
+	case float64:
+		return cmp.Compare(left, b.(float64))
+	case bool:
+		right := b.(bool)
+		if left == right {
+			return 0
+		}
+		if right {
+			return -1
+		}
+		return 1
+	case time.Time:
+		return left.Compare(b.(time.Time))
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+// normalize dereferences nullable fields so nil pointers compare as missing values
+func normalize(value any) any {
+	switch typed := value.(type) {
+	case *int:
+		if typed == nil {
+			return nil
+		}
+		return *typed
+	case *float64:
+		if typed == nil {
+			return nil
+		}
+		return *typed
+	case *time.Time:
+		if typed == nil {
+			return nil
+		}
+		return *typed
+	}
+	return value
+}
+
+func valueText(value any) (string, bool) {
+	value = normalize(value)
+	if value == nil {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// valueTexts returns the texts of a field, which holds one value or, like the
+// ingredients of a medicine, a list of them
+func valueTexts(value any) []string {
+	if list, isList := value.([]string); isList {
+		return list
+	}
+	if text, isSet := valueText(value); isSet {
+		return []string{text}
+	}
+	return nil
+}
+
+func containsFold(text, substring string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(substring))
+}
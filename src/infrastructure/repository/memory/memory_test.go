@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID        int
+	Name      string
+	Active    bool
+	CreatedAt time.Time
+	OwnerID   *int
+}
+
+func itemFields(i *item) map[string]any {
+	return map[string]any{
+		"id":        i.ID,
+		"name":      i.Name,
+		"active":    i.Active,
+		"createdAt": i.CreatedAt,
+		"ownerId":   i.OwnerID,
+	}
+}
+
+func sampleItems() []item {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	owner := 7
+	return []item{
+		{ID: 1, Name: "Aspirin", Active: true, CreatedAt: base},
+		{ID: 2, Name: "aspirin forte", Active: false, CreatedAt: base.Add(24 * time.Hour), OwnerID: &owner},
+		{ID: 3, Name: "Ibuprofen", Active: true, CreatedAt: base.Add(48 * time.Hour)},
+	}
+}
+
+func TestPaginate_LikeFiltersIgnoreCase(t *testing.T) {
+	page := Paginate(sampleItems(), domain.DataFilters{
+		LikeFilters: map[string][]string{"name": {"ASPIRIN"}},
+	}, itemFields)
+
+	assert.Equal(t, int64(2), page.Total)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 10, page.PageSize)
+}
+
+func TestPaginate_Matches(t *testing.T) {
+	page := Paginate(sampleItems(), domain.DataFilters{
+		Matches: map[string][]string{"active": {"false"}, "ownerId": {"7"}},
+	}, itemFields)
+
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, 2, page.Data[0].ID)
+}
+
+func TestPaginate_DateRange(t *testing.T) {
+	start := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	page := Paginate(sampleItems(), domain.DataFilters{
+		DateRangeFilters: []domain.DateRangeFilter{{Field: "createdAt", Start: &start}},
+	}, itemFields)
+
+	assert.Equal(t, int64(2), page.Total)
+}
+
+func TestPaginate_NumberRange(t *testing.T) {
+	low, high := 2, 3
+	page := Paginate(sampleItems(), domain.DataFilters{
+		NumberRangeFilters: []domain.NumberRangeFilter{{Field: "id", Min: &low}, {Field: "ownerId", Max: &high}},
+	}, itemFields)
+	assert.Equal(t, int64(0), page.Total, "item 2 is owned by 7 and item 3 by nobody")
+
+	page = Paginate(sampleItems(), domain.DataFilters{
+		NumberRangeFilters: []domain.NumberRangeFilter{{Field: "id", Min: &low, Max: &high}},
+	}, itemFields)
+	assert.Equal(t, int64(2), page.Total)
+}
+
+func TestPaginate_SortAndPage(t *testing.T) {
+	page := Paginate(sampleItems(), domain.DataFilters{
+		SortBy:        []string{"createdAt"},
+		SortDirection: domain.SortDesc,
+		Page:          2,
+		PageSize:      2,
+	}, itemFields)
+
+	assert.Equal(t, int64(3), page.Total)
+	assert.Equal(t, 2, page.TotalPages)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, 1, page.Data[0].ID)
+}
+
+func TestPaginate_NullsSortLast(t *testing.T) {
+	page := Paginate(sampleItems(), domain.DataFilters{
+		SortBy:        []string{"ownerId"},
+		SortDirection: domain.SortAsc,
+	}, itemFields)
+
+	assert.Equal(t, 2, page.Data[0].ID)
+}
+
+func TestPaginate_SortsPricesAsNumbers(t *testing.T) {
+	prices := []float64{100, 9.5, 12}
+	page := Paginate(sampleItems(), domain.DataFilters{
+		SortBy:        []string{"price"},
+		SortDirection: domain.SortDesc,
+	}, func(i *item) map[string]any {
+		values := itemFields(i)
+		values["price"] = &prices[i.ID-1]
+		return values
+	})
+
+	assert.Equal(t, []int{1, 3, 2}, []int{page.Data[0].ID, page.Data[1].ID, page.Data[2].ID})
+}
+
+func TestPaginate_NoMatchesReturnsEmptyPage(t *testing.T) {
+	page := Paginate(sampleItems(), domain.DataFilters{
+		LikeFilters: map[string][]string{"name": {"paracetamol"}},
+	}, itemFields)
+
+	assert.NotNil(t, page.Data)
+	assert.Empty(t, page.Data)
+	assert.Equal(t, 0, page.TotalPages)
+}
+
+func TestSearchByProperty(t *testing.T) {
+	coincidences, ok := SearchByProperty(sampleItems(), "name", "aspi", itemFields)
+	require.True(t, ok)
+	assert.Equal(t, []string{"Aspirin", "aspirin forte"}, coincidences)
+
+	_, ok = SearchByProperty(sampleItems(), "unknown", "x", itemFields)
+	assert.False(t, ok)
+}
+
+func TestPaginate_ListFields(t *testing.T) {
+	tagged := func(i *item) map[string]any {
+		values := itemFields(i)
+		values["tags"] = map[int][]string{1: {"analgesic", "nsaid"}, 3: {"nsaid"}}[i.ID]
+		return values
+	}
+	page := Paginate(sampleItems(), domain.DataFilters{Matches: map[string][]string{"tags": {"nsaid"}}}, tagged)
+	assert.Equal(t, int64(2), page.Total)
+
+	page = Paginate(sampleItems(), domain.DataFilters{LikeFilters: map[string][]string{"tags": {"ALGES"}}}, tagged)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, 1, page.Data[0].ID)
+
+	coincidences, ok := SearchByProperty(sampleItems(), "tags", "a", tagged)
+	require.True(t, ok)
+	assert.Equal(t, []string{"analgesic", "nsaid"}, coincidences)
+}
+
+func TestHistory_RecordAndAsOf(t *testing.T) {
+	var h History[item]
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 2})
+	created := item{ID: 1, Name: "Aspirin"}
+	updated := item{ID: 1, Name: "Aspirin Forte"}
+
+	require.NoError(t, h.Record(ctx, 1, 1, domainHistory.ActionCreate, nil, itemFields(&created), created))
+	between := time.Now()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, h.Record(ctx, 1, 1, domainHistory.ActionUpdate, itemFields(&created), itemFields(&updated), updated))
+	require.NoError(t, h.Record(ctx, 1, 1, domainHistory.ActionUpdate, itemFields(&updated), itemFields(&updated), updated))
+
+	entries := h.Entries(ctx, 1)
+	require.Len(t, entries, 2, "updates without changes are not recorded")
+	assert.Equal(t, 2, *entries[0].ActorID)
+	assert.Equal(t, "Aspirin Forte", entries[1].Changes["name"].After)
+
+	snapshot, ok := h.AsOf(ctx, 1, between)
+	require.True(t, ok)
+	assert.Equal(t, "Aspirin", snapshot.Name)
+
+	_, ok = h.AsOf(ctx, 1, between.Add(-time.Hour))
+	assert.False(t, ok)
+	otherTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 3, TenantID: 2})
+	assert.Empty(t, h.Entries(otherTenant, 1))
+	_, ok = h.AsOf(otherTenant, 1, time.Now())
+	assert.False(t, ok)
+}
+
+func TestInTenant(t *testing.T) {
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 2})
+
+	assert.True(t, InTenant(ctx, 2))
+	assert.False(t, InTenant(ctx, 3))
+	assert.True(t, InTenant(context.Background(), 3), "contexts without a tenant see every record")
+	assert.Equal(t, 2, TenantOf(ctx, 5))
+	assert.Equal(t, 5, TenantOf(context.Background(), 5))
+}
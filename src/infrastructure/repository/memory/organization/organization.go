@@ -0,0 +1,85 @@
+package organization
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOrganization "github.com/gbrayhan/microservices-go/src/domain/organization"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	psqlOrganization "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/organization"
+	"go.uber.org/zap"
+)
+
+// DefaultID is the ID of the organization every repository starts with, which owns the seeded user
+const DefaultID = 1
+
+// Repository keeps organizations in process memory. Data is lost when the process exits.
+type Repository struct {
+	Logger *logger.Logger
+
+	mu            sync.RWMutex
+	lastID        int
+	organizations map[int]domainOrganization.Organization
+}
+
+func NewOrganizationRepository(loggerInstance *logger.Logger) psqlOrganization.OrganizationRepositoryInterface {
+	now := time.Now()
+	return &Repository{
+		Logger: loggerInstance,
+		lastID: DefaultID,
+		organizations: map[int]domainOrganization.Organization{
+			DefaultID: {ID: DefaultID, Name: psqlOrganization.DefaultName, CreatedAt: now, UpdatedAt: now},
+		},
+	}
+}
+
+func (r *Repository) Create(_ context.Context, newOrganization *domainOrganization.Organization) (*domainOrganization.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.organizations {
+		if other.Name == newOrganization.Name {
+			r.Logger.Error("Error creating organization", zap.String("name", newOrganization.Name), zap.String("reason", "duplicated name"))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+		}
+	}
+	now := time.Now()
+	organization := domainOrganization.Organization{
+		ID:        r.lastID + 1,
+		Name:      newOrganization.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.lastID = organization.ID
+	r.organizations[organization.ID] = organization
+	r.Logger.Info("Successfully created organization", zap.String("name", organization.Name), zap.Int("id", organization.ID))
+	return &organization, nil
+}
+
+func (r *Repository) GetByID(_ context.Context, id int) (*domainOrganization.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	organization, ok := r.organizations[id]
+	if !ok {
+		r.Logger.Warn("Organization not found", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully retrieved organization by ID", zap.Int("id", id))
+	return &organization, nil
+}
+
+func (r *Repository) Delete(_ context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.organizations[id]; !ok {
+		r.Logger.Warn("Organization not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	delete(r.organizations, id)
+	r.Logger.Info("Successfully deleted organization", zap.Int("id", id))
+	return nil
+}
@@ -0,0 +1,41 @@
+package organization
+
+import (
+	"context"
+	"testing"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOrganization "github.com/gbrayhan/microservices-go/src/domain/organization"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestRepository_CreateGetAndDelete(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	repo := NewOrganizationRepository(loggerInstance)
+	ctx := context.Background()
+
+	defaultOrganization, err := repo.GetByID(ctx, DefaultID)
+	require.NoError(t, err)
+	assert.Equal(t, "Default", defaultOrganization.Name)
+
+	created, err := repo.Create(ctx, &domainOrganization.Organization{Name: "Acme Pharma"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, created.ID)
+	_, err = repo.Create(ctx, &domainOrganization.Organization{Name: "Acme Pharma"})
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	_, err = repo.GetByID(ctx, created.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+	assertErrorType(t, repo.Delete(ctx, created.ID), domainErrors.NotFound)
+}
@@ -0,0 +1,259 @@
+package stock
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// GetThresholds returns the thresholds of a medicine ordered by location
+func (r *Repository) GetThresholds(ctx context.Context, medicineID int) (*[]domainStock.Threshold, error) {
+	return r.findThresholds(ctx, func(threshold *domainStock.Threshold) bool {
+		return threshold.MedicineID == medicineID
+	}), nil
+}
+
+// GetAllThresholds returns the thresholds of every medicine
+func (r *Repository) GetAllThresholds(ctx context.Context) (*[]domainStock.Threshold, error) {
+	return r.findThresholds(ctx, func(*domainStock.Threshold) bool { return true }), nil
+}
+
+func (r *Repository) findThresholds(ctx context.Context, match func(*domainStock.Threshold) bool) *[]domainStock.Threshold {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	thresholds := []domainStock.Threshold{}
+	for i := range r.thresholds {
+		if memory.InTenant(ctx, r.thresholds[i].TenantID) && match(&r.thresholds[i]) {
+			thresholds = append(thresholds, r.thresholds[i])
+		}
+	}
+	slices.SortFunc(thresholds, func(a, b domainStock.Threshold) int {
+		return cmp.Or(cmp.Compare(a.MedicineID, b.MedicineID), cmp.Compare(a.Location, b.Location))
+	})
+	return &thresholds
+}
+
+// GetThreshold returns the threshold of a medicine at a location
+func (r *Repository) GetThreshold(ctx context.Context, medicineID int, location string) (*domainStock.Threshold, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, threshold := range r.thresholds {
+		if threshold.MedicineID == medicineID && threshold.Location == location && memory.InTenant(ctx, threshold.TenantID) {
+			return &threshold, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// SaveThreshold creates the threshold of a medicine at a location or replaces its levels
+func (r *Repository) SaveThreshold(ctx context.Context, threshold *domainStock.Threshold) (*domainStock.Threshold, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	tenantID := memory.TenantOf(ctx, threshold.TenantID)
+	for i := range r.thresholds {
+		stored := &r.thresholds[i]
+		if stored.TenantID == tenantID && stored.MedicineID == threshold.MedicineID && stored.Location == threshold.Location {
+			stored.MinLevel = threshold.MinLevel
+			stored.ReorderPoint = threshold.ReorderPoint
+			stored.MaxLevel = threshold.MaxLevel
+			stored.UpdatedAt = now
+			stored.UpdatedBy = security.ActorID(ctx)
+			saved := *stored
+			return &saved, nil
+		}
+	}
+	r.lastThresholdID++
+	created := *threshold
+	created.ID = r.lastThresholdID
+	created.TenantID = tenantID
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	created.CreatedBy = security.ActorID(ctx)
+	created.UpdatedBy = security.ActorID(ctx)
+	r.thresholds = append(r.thresholds, created)
+
+	r.Logger.Info("Successfully saved stock threshold", zap.Int("id", created.ID), zap.Int("medicineId", created.MedicineID), zap.String("location", created.Location))
+	return &created, nil
+}
+
+// DeleteThreshold removes a threshold and returns it
+func (r *Repository) DeleteThreshold(ctx context.Context, id int) (*domainStock.Threshold, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, threshold := range r.thresholds {
+		if threshold.ID == id && memory.InTenant(ctx, threshold.TenantID) {
+			r.thresholds = slices.Delete(r.thresholds, i, i+1)
+			return &threshold, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// RaiseAlert opens alert, or updates the unresolved alert of its condition. It reports
+// whether the alert is new or its severity rose, which reopens an acknowledged one.
+func (r *Repository) RaiseAlert(ctx context.Context, alert *domainStock.Alert) (*domainStock.Alert, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	tenantID := memory.TenantOf(ctx, alert.TenantID)
+	if existing := r.unresolvedAlert(tenantID, alert.Key()); existing != nil {
+		raised := alert.Severity.Exceeds(existing.Severity)
+		if raised {
+			existing.Status = domainStock.AlertOpen
+			existing.AcknowledgedAt = nil
+			existing.AcknowledgedBy = nil
+		}
+		existing.Severity = alert.Severity
+		existing.Quantity = alert.Quantity
+		existing.Threshold = alert.Threshold
+		existing.ExpiresAt = alert.ExpiresAt
+		existing.Message = alert.Message
+		existing.UpdatedAt = now
+		updated := *existing
+		return &updated, raised, nil
+	}
+	r.lastAlertID++
+	created := *alert
+	created.ID = r.lastAlertID
+	created.TenantID = tenantID
+	created.Status = domainStock.AlertOpen
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	r.alerts = append(r.alerts, created)
+	return &created, true, nil
+}
+
+// ResolveAlert resolves the unresolved alert of the condition with key, if any
+func (r *Repository) ResolveAlert(ctx context.Context, key string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.alerts {
+		alert := &r.alerts[i]
+		if alert.Status != domainStock.AlertResolved && alert.Key() == key && memory.InTenant(ctx, alert.TenantID) {
+			alert.Status = domainStock.AlertResolved
+			alert.ResolvedAt = &at
+			alert.UpdatedAt = at
+			r.Logger.Info("Resolved stock alert", zap.String("key", key))
+		}
+	}
+	return nil
+}
+
+// GetUnresolvedAlerts returns the open and acknowledged alerts of alertType
+func (r *Repository) GetUnresolvedAlerts(ctx context.Context, alertType domainStock.AlertType) (*[]domainStock.Alert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alerts := []domainStock.Alert{}
+	for _, alert := range r.alerts {
+		if alert.Type == alertType && alert.Status != domainStock.AlertResolved && memory.InTenant(ctx, alert.TenantID) {
+			alerts = append(alerts, alert)
+		}
+	}
+	return &alerts, nil
+}
+
+func (r *Repository) GetAlertByID(ctx context.Context, id int) (*domainStock.Alert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alert := r.alert(ctx, id)
+	if alert == nil {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	found := *alert
+	return &found, nil
+}
+
+// GetAlerts lists alerts, newest first unless filters sort otherwise
+func (r *Repository) GetAlerts(ctx context.Context, filters domain.DataFilters) (*domainStock.SearchResultAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alerts := []domainStock.Alert{}
+	for i := len(r.alerts) - 1; i >= 0; i-- {
+		if memory.InTenant(ctx, r.alerts[i].TenantID) {
+			alerts = append(alerts, r.alerts[i])
+		}
+	}
+	page := memory.Paginate(alerts, filters, alertFields)
+	r.Logger.Info("Successfully listed stock alerts", zap.Int64("total", page.Total))
+	return &domainStock.SearchResultAlert{
+		Data:       &page.Data,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+	}, nil
+}
+
+// AcknowledgeAlert marks an open alert as seen by the user in ctx
+func (r *Repository) AcknowledgeAlert(ctx context.Context, id int, at time.Time) (*domainStock.Alert, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alert := r.alert(ctx, id)
+	if alert == nil {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if alert.Status != domainStock.AlertOpen {
+		return nil, domainErrors.NewAppError(fmt.Errorf("alert %d is %s, not %s", id, alert.Status, domainStock.AlertOpen), domainErrors.ValidationError)
+	}
+	alert.Status = domainStock.AlertAcknowledged
+	alert.AcknowledgedAt = &at
+	alert.AcknowledgedBy = security.ActorID(ctx)
+	alert.UpdatedAt = at
+	acknowledged := *alert
+	return &acknowledged, nil
+}
+
+// unresolvedAlert returns the stored unresolved alert of key; the caller holds the lock
+func (r *Repository) unresolvedAlert(tenantID int, key string) *domainStock.Alert {
+	for i := range r.alerts {
+		if r.alerts[i].TenantID == tenantID && r.alerts[i].Status != domainStock.AlertResolved && r.alerts[i].Key() == key {
+			return &r.alerts[i]
+		}
+	}
+	return nil
+}
+
+// alert returns the stored alert visible in ctx; the caller holds the lock
+func (r *Repository) alert(ctx context.Context, id int) *domainStock.Alert {
+	for i := range r.alerts {
+		if r.alerts[i].ID == id && memory.InTenant(ctx, r.alerts[i].TenantID) {
+			return &r.alerts[i]
+		}
+	}
+	return nil
+}
+
+func alertFields(alert *domainStock.Alert) map[string]any {
+	return map[string]any{
+		"id":             alert.ID,
+		"medicineId":     alert.MedicineID,
+		"location":       alert.Location,
+		"lotId":          alert.LotID,
+		"type":           string(alert.Type),
+		"severity":       string(alert.Severity),
+		"status":         string(alert.Status),
+		"createdAt":      alert.CreatedAt,
+		"updatedAt":      alert.UpdatedAt,
+		"acknowledgedBy": alert.AcknowledgedBy,
+	}
+}
@@ -0,0 +1,114 @@
+package stock
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// GetLocations returns every location ordered by code
+func (r *Repository) GetLocations(ctx context.Context) (*[]domainStock.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	locations := []domainStock.Location{}
+	for _, location := range r.locations {
+		if memory.InTenant(ctx, location.TenantID) {
+			locations = append(locations, location)
+		}
+	}
+	slices.SortFunc(locations, func(a, b domainStock.Location) int { return strings.Compare(a.Code, b.Code) })
+	r.Logger.Info("Successfully retrieved locations", zap.Int("count", len(locations)))
+	return &locations, nil
+}
+
+func (r *Repository) GetLocationByID(ctx context.Context, id int) (*domainStock.Location, error) {
+	return r.findLocation(ctx, func(location *domainStock.Location) bool { return location.ID == id })
+}
+
+func (r *Repository) GetLocationByCode(ctx context.Context, code string) (*domainStock.Location, error) {
+	return r.findLocation(ctx, func(location *domainStock.Location) bool { return location.Code == code })
+}
+
+func (r *Repository) findLocation(ctx context.Context, match func(location *domainStock.Location) bool) (*domainStock.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if location := r.location(ctx, match); location != nil {
+		found := *location
+		return &found, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// CreateLocation registers a location; codes are unique per organization
+func (r *Repository) CreateLocation(ctx context.Context, location *domainStock.Location) (*domainStock.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.location(ctx, func(existing *domainStock.Location) bool { return existing.Code == location.Code }) != nil {
+		r.Logger.Warn("Location already exists", zap.String("code", location.Code))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	now := time.Now()
+	r.lastLocationID++
+	created := *location
+	created.ID = r.lastLocationID
+	created.TenantID = memory.TenantOf(ctx, location.TenantID)
+	created.UserIDs = slices.Clone(location.UserIDs)
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	created.CreatedBy = security.ActorID(ctx)
+	created.UpdatedBy = security.ActorID(ctx)
+	r.locations = append(r.locations, created)
+
+	r.Logger.Info("Successfully created location", zap.Int("id", created.ID), zap.String("code", created.Code))
+	return &created, nil
+}
+
+// UpdateLocation changes the fields in locationMap, keyed by their JSON names
+func (r *Repository) UpdateLocation(ctx context.Context, id int, locationMap map[string]any) (*domainStock.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	location := r.location(ctx, func(location *domainStock.Location) bool { return location.ID == id })
+	if location == nil {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	for key, value := range locationMap {
+		switch key {
+		case "name":
+			location.Name, _ = value.(string)
+		case "type":
+			location.Type, _ = value.(domainStock.LocationType)
+		case "active":
+			location.Active, _ = value.(bool)
+		case "userIds":
+			ids, _ := value.([]int)
+			location.UserIDs = slices.Clone(ids)
+		}
+	}
+	location.UpdatedAt = time.Now()
+	location.UpdatedBy = security.ActorID(ctx)
+
+	r.Logger.Info("Successfully updated location", zap.Int("id", id))
+	updated := *location
+	return &updated, nil
+}
+
+// location returns the stored location visible in ctx matching match; the caller holds the lock
+func (r *Repository) location(ctx context.Context, match func(location *domainStock.Location) bool) *domainStock.Location {
+	for i := range r.locations {
+		if memory.InTenant(ctx, r.locations[i].TenantID) && match(&r.locations[i]) {
+			return &r.locations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// CreateLot records a received lot and its receipt into the stock of its location. A
+// lot number is received once, whatever the location.
+func (r *Repository) CreateLot(ctx context.Context, lot *domainStock.MedicineLot) (*domainStock.MedicineLot, *domainStock.Level, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := memory.TenantOf(ctx, lot.TenantID)
+	for _, existing := range r.lots {
+		if existing.TenantID == tenantID && existing.MedicineID == lot.MedicineID && existing.LotNumber == lot.LotNumber {
+			r.Logger.Warn("Lot already received", zap.Int("medicineId", lot.MedicineID), zap.String("lotNumber", lot.LotNumber))
+			return nil, nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+		}
+	}
+	now := time.Now()
+	r.lastLotID++
+	created := *lot
+	created.ID = r.lastLotID
+	created.TenantID = tenantID
+	created.ExpiresAt = domainStock.Day(lot.ExpiresAt)
+	created.QuarantinedAt = nil
+	created.QuarantineReason = ""
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	created.CreatedBy = security.ActorID(ctx)
+	created.UpdatedBy = security.ActorID(ctx)
+	level, err := r.record(ctx, domainStock.Movement{
+		TenantID:   tenantID,
+		MedicineID: lot.MedicineID,
+		Location:   created.Location,
+		LotID:      &created.ID,
+		Type:       domainStock.MovementReceipt,
+		Quantity:   lot.Quantity,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	r.lots = append(r.lots, created)
+
+	r.Logger.Info("Successfully created lot", zap.Int("id", created.ID), zap.Int("medicineId", created.MedicineID), zap.Int("quantity", created.Quantity))
+	return &created, &level, nil
+}
+
+// GetLotsByMedicine returns every lot of a medicine, first expiring first
+func (r *Repository) GetLotsByMedicine(ctx context.Context, medicineID int) (*[]domainStock.MedicineLot, error) {
+	lots := r.findLots(ctx, func(lot *domainStock.MedicineLot) bool { return lot.MedicineID == medicineID })
+	r.Logger.Info("Successfully retrieved lots", zap.Int("medicineId", medicineID), zap.Int("count", len(lots)))
+	return &lots, nil
+}
+
+// GetExpiringLots returns the lots with units left and not in quarantine that expire
+// on or before through, already expired ones included, first expiring first
+func (r *Repository) GetExpiringLots(ctx context.Context, through time.Time) (*[]domainStock.MedicineLot, error) {
+	lots := r.findLots(ctx, func(lot *domainStock.MedicineLot) bool {
+		return lot.Quantity > 0 && lot.QuarantinedAt == nil && !lot.ExpiresAt.After(through)
+	})
+	r.Logger.Info("Successfully retrieved expiring lots", zap.Time("through", through), zap.Int("count", len(lots)))
+	return &lots, nil
+}
+
+// GetLotByID returns one lot
+func (r *Repository) GetLotByID(ctx context.Context, lotID int) (*domainStock.MedicineLot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lot := r.lot(ctx, lotID)
+	if lot == nil {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	found := *lot
+	return &found, nil
+}
+
+// QuarantineLot keeps the units of a lot from being dispensed
+func (r *Repository) QuarantineLot(ctx context.Context, lotID int, reason string, at time.Time) (*domainStock.MedicineLot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lot := r.lot(ctx, lotID)
+	if lot == nil {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if lot.QuarantinedAt != nil {
+		return nil, domainErrors.NewAppError(fmt.Errorf("lot %s is already quarantined", lot.LotNumber), domainErrors.ValidationError)
+	}
+	lot.QuarantinedAt = &at
+	lot.QuarantineReason = reason
+	lot.UpdatedAt = time.Now()
+	lot.UpdatedBy = security.ActorID(ctx)
+
+	r.Logger.Info("Successfully quarantined lot", zap.Int("id", lotID))
+	quarantined := *lot
+	return &quarantined, nil
+}
+
+// Dispense removes quantity units of a medicine from its dispensable lots at a
+// location, first expired first out, and records a dispense movement per lot under
+// the repository lock
+func (r *Repository) Dispense(ctx context.Context, medicineID int, location string, quantity int, reason string, today time.Time) (*domainStock.Dispensation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lots []domainStock.MedicineLot
+	available := 0
+	for _, lot := range r.lots {
+		if lot.MedicineID == medicineID && lot.Location == location && memory.InTenant(ctx, lot.TenantID) {
+			lots = append(lots, lot)
+			if lot.Dispensable(today) {
+				available += lot.Quantity
+			}
+		}
+	}
+	allocations, ok := domainStock.AllocateFEFO(lots, quantity, today)
+	if !ok {
+		r.Logger.Warn("Insufficient stock in dispensable lots",
+			zap.Int("medicineId", medicineID),
+			zap.String("location", location),
+			zap.Int("quantity", quantity),
+			zap.Int("available", available))
+		return nil, domainErrors.NewAppError(
+			fmt.Errorf("insufficient stock: %d units in dispensable lots at %s", available, location), domainErrors.ValidationError)
+	}
+	movements := make([]domainStock.Movement, len(allocations))
+	for i, allocation := range allocations {
+		movements[i] = domainStock.Movement{
+			TenantID:   lots[0].TenantID,
+			MedicineID: medicineID,
+			Location:   location,
+			LotID:      &allocation.LotID,
+			Type:       domainStock.MovementDispense,
+			Quantity:   -allocation.Quantity,
+			Reason:     reason,
+		}
+	}
+	level, err := r.record(ctx, movements...)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, allocation := range allocations {
+		lot := r.lot(ctx, allocation.LotID)
+		lot.Quantity -= allocation.Quantity
+		lot.UpdatedAt = now
+		lot.UpdatedBy = security.ActorID(ctx)
+	}
+
+	r.Logger.Info("Successfully dispensed medicine",
+		zap.Int("medicineId", medicineID),
+		zap.Int("quantity", quantity),
+		zap.Int("lots", len(allocations)))
+	return &domainStock.Dispensation{
+		MedicineID:  medicineID,
+		Quantity:    quantity,
+		Allocations: allocations,
+		Level:       level,
+	}, nil
+}
+
+// lot returns the stored lot visible in ctx; the caller holds the lock
+func (r *Repository) lot(ctx context.Context, lotID int) *domainStock.MedicineLot {
+	for i := range r.lots {
+		if r.lots[i].ID == lotID && memory.InTenant(ctx, r.lots[i].TenantID) {
+			return &r.lots[i]
+		}
+	}
+	return nil
+}
+
+func (r *Repository) findLots(ctx context.Context, match func(lot *domainStock.MedicineLot) bool) []domainStock.MedicineLot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lots := []domainStock.MedicineLot{}
+	for i := range r.lots {
+		if memory.InTenant(ctx, r.lots[i].TenantID) && match(&r.lots[i]) {
+			lots = append(lots, r.lots[i])
+		}
+	}
+	slices.SortStableFunc(lots, func(a, b domainStock.MedicineLot) int { return a.ExpiresAt.Compare(b.ExpiresAt) })
+	return lots
+}
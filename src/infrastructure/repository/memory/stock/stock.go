@@ -0,0 +1,210 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	psqlStock "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+type levelKey struct {
+	tenantID   int
+	medicineID int
+	location   string
+}
+
+// Repository keeps stock levels in process memory. Data is lost when the process exits.
+type Repository struct {
+	Logger *logger.Logger
+
+	mu        sync.RWMutex
+	lastID    int
+	levels    map[levelKey]domainStock.Level
+	lastLotID int
+	lots      []domainStock.MedicineLot
+
+	lastMovementID int64
+	movements      []domainStock.Movement
+
+	lastLocationID int
+	locations      []domainStock.Location
+	lastTransferID int
+	transfers      []domainStock.Transfer
+
+	lastThresholdID int
+	thresholds      []domainStock.Threshold
+	lastAlertID     int
+	alerts          []domainStock.Alert
+}
+
+func NewStockRepository(loggerInstance *logger.Logger) *Repository {
+	return &Repository{
+		Logger: loggerInstance,
+		levels: make(map[levelKey]domainStock.Level),
+	}
+}
+
+var _ psqlStock.StockRepositoryInterface = (*Repository)(nil)
+
+// GetByMedicine returns the stock levels of a medicine ordered by location
+func (r *Repository) GetByMedicine(ctx context.Context, medicineID int) (*[]domainStock.Level, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	levels := []domainStock.Level{}
+	for _, level := range r.levels {
+		if level.MedicineID == medicineID && memory.InTenant(ctx, level.TenantID) {
+			levels = append(levels, level)
+		}
+	}
+	slices.SortFunc(levels, func(a, b domainStock.Level) int { return strings.Compare(a.Location, b.Location) })
+	r.Logger.Info("Successfully retrieved stock levels", zap.Int("medicineId", medicineID), zap.Int("count", len(levels)))
+	return &levels, nil
+}
+
+// Adjust records adjustment in the ledger under the repository lock, refusing to
+// take the stock below zero
+func (r *Repository) Adjust(ctx context.Context, adjustment domainStock.Adjustment) (*domainStock.Level, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	movementType := adjustment.Type
+	if movementType == "" {
+		movementType = domainStock.MovementAdjustment
+	}
+	level, err := r.record(ctx, domainStock.Movement{
+		TenantID:   adjustment.TenantID,
+		MedicineID: adjustment.MedicineID,
+		Location:   adjustment.Location,
+		Type:       movementType,
+		Quantity:   adjustment.Delta,
+		Reason:     adjustment.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// record appends movements of the stock of one medicine at one location to the
+// ledger and applies them to its level, which memory keeps current; the caller holds
+// the write lock
+func (r *Repository) record(ctx context.Context, movements ...domainStock.Movement) (domainStock.Level, error) {
+	first := movements[0]
+	key := levelKey{
+		tenantID:   memory.TenantOf(ctx, first.TenantID),
+		medicineID: first.MedicineID,
+		location:   first.Location,
+	}
+	delta := 0
+	for _, movement := range movements {
+		delta += movement.Quantity
+	}
+	now := time.Now()
+	level, exists := r.levels[key]
+	if level.Quantity+delta < 0 {
+		r.Logger.Warn("Insufficient stock for adjustment",
+			zap.Int("medicineId", key.medicineID),
+			zap.String("location", key.location),
+			zap.Int("delta", delta),
+			zap.Int("available", level.Quantity))
+		return level, domainErrors.NewAppError(
+			fmt.Errorf("insufficient stock: %d on hand at %s", level.Quantity, key.location), domainErrors.ValidationError)
+	}
+	if !exists {
+		r.lastID++
+		level = domainStock.Level{
+			ID:         r.lastID,
+			TenantID:   key.tenantID,
+			MedicineID: key.medicineID,
+			Location:   key.location,
+			CreatedAt:  now,
+			CreatedBy:  security.ActorID(ctx),
+		}
+	}
+	for _, movement := range movements {
+		r.lastMovementID++
+		movement.ID = r.lastMovementID
+		movement.TenantID = key.tenantID
+		movement.CreatedAt = now
+		movement.CreatedBy = security.ActorID(ctx)
+		r.movements = append(r.movements, movement)
+	}
+	level.Quantity += delta
+	level.UpdatedAt = now
+	level.UpdatedBy = security.ActorID(ctx)
+	r.levels[key] = level
+
+	r.Logger.Info("Successfully adjusted stock",
+		zap.Int("medicineId", key.medicineID),
+		zap.String("location", key.location),
+		zap.Int("delta", delta),
+		zap.Int("quantity", level.Quantity))
+	return level, nil
+}
+
+// GetMovements returns a page of the ledger of a medicine, latest first unless sorted
+func (r *Repository) GetMovements(ctx context.Context, medicineID int, filters domain.DataFilters) (*domainStock.SearchResultMovement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	movements := []domainStock.Movement{}
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		if r.movements[i].MedicineID == medicineID && memory.InTenant(ctx, r.movements[i].TenantID) {
+			movements = append(movements, r.movements[i])
+		}
+	}
+	page := memory.Paginate(movements, filters, movementFields)
+	r.Logger.Info("Successfully listed stock movements", zap.Int("medicineId", medicineID), zap.Int64("total", page.Total))
+	return &domainStock.SearchResultMovement{
+		Data:       &page.Data,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+	}, nil
+}
+
+// Snapshot has nothing to fold: memory keeps every level current
+func (r *Repository) Snapshot(context.Context) (int64, error) {
+	return 0, nil
+}
+
+// Totals returns the units on hand of every medicine with stock visible in ctx
+func (r *Repository) Totals(ctx context.Context) map[int]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	totals := make(map[int]int)
+	for _, level := range r.levels {
+		if memory.InTenant(ctx, level.TenantID) {
+			totals[level.MedicineID] += level.Quantity
+		}
+	}
+	return totals
+}
+
+func movementFields(movement *domainStock.Movement) map[string]any {
+	return map[string]any{
+		"id":         movement.ID,
+		"location":   movement.Location,
+		"lotId":      movement.LotID,
+		"transferId": movement.TransferID,
+		"type":       string(movement.Type),
+		"quantity":   movement.Quantity,
+		"reason":     movement.Reason,
+		"createdAt":  movement.CreatedAt,
+		"createdBy":  movement.CreatedBy,
+	}
+}
@@ -0,0 +1,255 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+func (r *Repository) CreateTransfer(ctx context.Context, transfer *domainStock.Transfer) (*domainStock.Transfer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.lastTransferID++
+	created := *transfer
+	created.ID = r.lastTransferID
+	created.TenantID = memory.TenantOf(ctx, transfer.TenantID)
+	created.Status = domainStock.TransferRequested
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	created.CreatedBy = security.ActorID(ctx)
+	created.UpdatedBy = security.ActorID(ctx)
+	r.transfers = append(r.transfers, created)
+
+	r.Logger.Info("Successfully created transfer", zap.Int("id", created.ID), zap.Int("medicineId", created.MedicineID))
+	return &created, nil
+}
+
+func (r *Repository) GetTransferByID(ctx context.Context, id int) (*domainStock.Transfer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transfer := r.transfer(ctx, id)
+	if transfer == nil {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	found := *transfer
+	return &found, nil
+}
+
+// GetTransfers lists transfers, newest first unless filters sort otherwise
+func (r *Repository) GetTransfers(ctx context.Context, filters domain.DataFilters) (*domainStock.SearchResultTransfer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transfers := []domainStock.Transfer{}
+	for i := len(r.transfers) - 1; i >= 0; i-- {
+		if memory.InTenant(ctx, r.transfers[i].TenantID) {
+			transfers = append(transfers, r.transfers[i])
+		}
+	}
+	page := memory.Paginate(transfers, filters, transferFields)
+	r.Logger.Info("Successfully listed transfers", zap.Int64("total", page.Total))
+	return &domainStock.SearchResultTransfer{
+		Data:       &page.Data,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+	}, nil
+}
+
+// ShipTransfer takes the units of a requested transfer from its source location, and
+// from its lot there when it has one, under the repository lock
+func (r *Repository) ShipTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, *domainStock.Level, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transfer, err := r.advanceable(ctx, id, domainStock.TransferShipped)
+	if err != nil {
+		return nil, nil, err
+	}
+	var lot *domainStock.MedicineLot
+	if transfer.LotID != nil {
+		lot = r.lot(ctx, *transfer.LotID)
+		if lot == nil || lot.Location != transfer.FromLocation || lot.Quantity < transfer.Quantity {
+			held := 0
+			if lot != nil && lot.Location == transfer.FromLocation {
+				held = lot.Quantity
+			}
+			return nil, nil, domainErrors.NewAppError(
+				fmt.Errorf("insufficient stock: lot holds %d units at %s", held, transfer.FromLocation), domainErrors.ValidationError)
+		}
+	}
+	level, err := r.record(ctx, transferMovement(transfer, transfer.FromLocation, -transfer.Quantity, transfer.LotID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if lot != nil {
+		lot.Quantity -= transfer.Quantity
+		lot.UpdatedAt = at
+		lot.UpdatedBy = security.ActorID(ctx)
+	}
+	shipped := r.advance(ctx, transfer, domainStock.TransferShipped, at)
+	return &shipped, &level, nil
+}
+
+// ReceiveTransfer adds the units of a shipped transfer to its destination location
+// under the repository lock. The units of a lot are added to the lot of the same
+// number there, which is created from the source lot when missing.
+func (r *Repository) ReceiveTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, *domainStock.Level, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transfer, err := r.advanceable(ctx, id, domainStock.TransferReceived)
+	if err != nil {
+		return nil, nil, err
+	}
+	var lotID *int
+	if transfer.LotID != nil {
+		destination := r.receiveLot(ctx, *transfer.LotID, transfer.ToLocation, transfer.Quantity, at)
+		lotID = &destination.ID
+	}
+	level, err := r.record(ctx, transferMovement(transfer, transfer.ToLocation, transfer.Quantity, lotID))
+	if err != nil {
+		return nil, nil, err
+	}
+	received := r.advance(ctx, transfer, domainStock.TransferReceived, at)
+	return &received, &level, nil
+}
+
+// CancelTransfer withdraws a requested transfer
+func (r *Repository) CancelTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transfer, err := r.advanceable(ctx, id, domainStock.TransferCancelled)
+	if err != nil {
+		return nil, err
+	}
+	cancelled := r.advance(ctx, transfer, domainStock.TransferCancelled, at)
+	return &cancelled, nil
+}
+
+// InTransit returns the units of a medicine in shipped transfers not received yet
+func (r *Repository) InTransit(ctx context.Context, medicineID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, transfer := range r.transfers {
+		if transfer.MedicineID == medicineID && transfer.Status == domainStock.TransferShipped && memory.InTenant(ctx, transfer.TenantID) {
+			total += transfer.Quantity
+		}
+	}
+	return total, nil
+}
+
+// advanceable returns the stored transfer when it can move to status; the caller
+// holds the write lock
+func (r *Repository) advanceable(ctx context.Context, id int, status domainStock.TransferStatus) (*domainStock.Transfer, error) {
+	transfer := r.transfer(ctx, id)
+	if transfer == nil {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if transfer.Status != status.Previous() {
+		r.Logger.Warn("Transfer cannot advance", zap.Int("id", id), zap.String("status", string(transfer.Status)))
+		return nil, domainErrors.NewAppError(
+			fmt.Errorf("transfer %d is %s, not %s", id, transfer.Status, status.Previous()), domainErrors.ValidationError)
+	}
+	return transfer, nil
+}
+
+// advance moves a stored transfer to status and returns a copy; the caller holds the write lock
+func (r *Repository) advance(ctx context.Context, transfer *domainStock.Transfer, status domainStock.TransferStatus, at time.Time) domainStock.Transfer {
+	transfer.Status = status
+	switch status {
+	case domainStock.TransferShipped:
+		transfer.ShippedAt = &at
+	case domainStock.TransferReceived:
+		transfer.ReceivedAt = &at
+	case domainStock.TransferCancelled:
+		transfer.CancelledAt = &at
+	}
+	transfer.UpdatedAt = at
+	transfer.UpdatedBy = security.ActorID(ctx)
+	r.Logger.Info("Successfully advanced transfer", zap.Int("id", transfer.ID), zap.String("status", string(status)))
+	return *transfer
+}
+
+// receiveLot adds quantity units to the lot at location with the number of the lot
+// sourceID, creating it from the source lot when the location holds none; the caller
+// holds the write lock
+func (r *Repository) receiveLot(ctx context.Context, sourceID int, location string, quantity int, at time.Time) *domainStock.MedicineLot {
+	source := *r.lot(ctx, sourceID)
+	for i := range r.lots {
+		lot := &r.lots[i]
+		if lot.TenantID == source.TenantID && lot.MedicineID == source.MedicineID && lot.LotNumber == source.LotNumber && lot.Location == location {
+			lot.Quantity += quantity
+			lot.UpdatedAt = at
+			lot.UpdatedBy = security.ActorID(ctx)
+			return lot
+		}
+	}
+	r.lastLotID++
+	created := source
+	created.ID = r.lastLotID
+	created.Location = location
+	created.Quantity = quantity
+	created.CreatedAt = at
+	created.UpdatedAt = at
+	created.CreatedBy = security.ActorID(ctx)
+	created.UpdatedBy = security.ActorID(ctx)
+	r.lots = append(r.lots, created)
+	return &r.lots[len(r.lots)-1]
+}
+
+// transfer returns the stored transfer visible in ctx; the caller holds the lock
+func (r *Repository) transfer(ctx context.Context, id int) *domainStock.Transfer {
+	for i := range r.transfers {
+		if r.transfers[i].ID == id && memory.InTenant(ctx, r.transfers[i].TenantID) {
+			return &r.transfers[i]
+		}
+	}
+	return nil
+}
+
+// transferMovement is the ledger entry of transfer moving delta units at location
+func transferMovement(transfer *domainStock.Transfer, location string, delta int, lotID *int) domainStock.Movement {
+	transferID := transfer.ID
+	return domainStock.Movement{
+		TenantID:   transfer.TenantID,
+		MedicineID: transfer.MedicineID,
+		Location:   location,
+		LotID:      lotID,
+		TransferID: &transferID,
+		Type:       domainStock.MovementTransfer,
+		Quantity:   delta,
+		Reason:     transfer.Reason,
+	}
+}
+
+func transferFields(transfer *domainStock.Transfer) map[string]any {
+	return map[string]any{
+		"id":           transfer.ID,
+		"medicineId":   transfer.MedicineID,
+		"lotId":        transfer.LotID,
+		"fromLocation": transfer.FromLocation,
+		"toLocation":   transfer.ToLocation,
+		"quantity":     transfer.Quantity,
+		"status":       string(transfer.Status),
+		"reason":       transfer.Reason,
+		"shippedAt":    transfer.ShippedAt,
+		"receivedAt":   transfer.ReceivedAt,
+		"createdAt":    transfer.CreatedAt,
+		"createdBy":    transfer.CreatedBy,
+	}
+}
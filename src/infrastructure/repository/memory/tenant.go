@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+)
+
+// InTenant reports whether a record owned by tenantID is visible in ctx. Contexts
+// without a tenant, such as background workers, see every record, as they do in SQL.
+func InTenant(ctx context.Context, tenantID int) bool {
+	current, ok := security.TenantID(ctx)
+	return !ok || current == tenantID
+}
+
+// TenantOf returns the tenant a new record created in ctx belongs to: the principal's
+// tenant when there is one, else fallback
+func TenantOf(ctx context.Context, fallback int) int {
+	if tenantID, ok := security.TenantID(ctx); ok {
+		return tenantID
+	}
+	return fallback
+}
@@ -0,0 +1,42 @@
+package user
+
+import (
+	"context"
+	"os"
+
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	psqlUser "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SeedInitialUser creates the START_USER_EMAIL account in the tenantID organization,
+// as the SQL databases do on start
+func SeedInitialUser(repo psqlUser.UserRepositoryInterface, tenantID int, loggerInstance *logger.Logger) error {
+	email := os.Getenv("START_USER_EMAIL")
+	pw := os.Getenv("START_USER_PW")
+	if email == "" || pw == "" {
+		loggerInstance.Info("Initial user seed skipped: START_USER_EMAIL or START_USER_PW not set")
+		return nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+	if err != nil {
+		loggerInstance.Error("Error hashing password for initial user", zap.Error(err))
+		return err
+	}
+
+	_, err = repo.Create(context.Background(), &domainUser.User{
+		TenantID:     tenantID,
+		Email:        email,
+		HashPassword: string(hashedPassword),
+	})
+	if err != nil {
+		loggerInstance.Error("Error creating initial user", zap.Error(err))
+		return err
+	}
+
+	loggerInstance.Info("Initial user created successfully", zap.String("email", email))
+	return nil
+}
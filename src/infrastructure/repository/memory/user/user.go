@@ -0,0 +1,386 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	psqlUser "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// updatableColumns are the columns Update may change, as selected by the SQL repository
+var updatableColumns = []string{"user_name", "email", "first_name", "last_name", "status"}
+
+// Repository keeps users in process memory. Data is lost when the process exits.
+type Repository struct {
+	Logger *logger.Logger
+
+	mu      sync.RWMutex
+	lastID  int
+	users   map[int]domainUser.User
+	history memory.History[domainUser.User]
+}
+
+func NewUserRepository(loggerInstance *logger.Logger) psqlUser.UserRepositoryInterface {
+	return &Repository{
+		Logger: loggerInstance,
+		users:  make(map[int]domainUser.User),
+	}
+}
+
+func (r *Repository) GetAll(ctx context.Context) (*[]domainUser.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := r.list(ctx, false)
+	r.Logger.Info("Successfully retrieved all users", zap.Int("count", len(users)))
+	return &users, nil
+}
+
+func (r *Repository) Create(ctx context.Context, userDomain *domainUser.User) (*domainUser.User, error) {
+	r.Logger.Info("Creating new user", zap.String("email", userDomain.Email))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	user := domainUser.User{
+		ID:           r.lastID + 1,
+		TenantID:     memory.TenantOf(ctx, userDomain.TenantID),
+		UserName:     userDomain.UserName,
+		Email:        userDomain.Email,
+		FirstName:    userDomain.FirstName,
+		LastName:     userDomain.LastName,
+		Status:       userDomain.Status,
+		HashPassword: userDomain.HashPassword,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		CreatedBy:    security.ActorID(ctx),
+		UpdatedBy:    security.ActorID(ctx),
+	}
+	if r.conflicts(&user) {
+		r.Logger.Error("Error creating user", zap.String("email", userDomain.Email), zap.String("reason", "duplicated user name or email"))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	if err := r.recordHistory(ctx, domainHistory.ActionCreate, nil, &user); err != nil {
+		r.Logger.Error("Error creating user", zap.Error(err), zap.String("email", userDomain.Email))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.lastID = user.ID
+	r.users[user.ID] = user
+	r.Logger.Info("Successfully created user", zap.String("email", userDomain.Email), zap.Int("id", user.ID))
+	return &user, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainUser.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.find(ctx, id)
+	if !ok || user.DeletedAt != nil {
+		r.Logger.Warn("User not found", zap.Int("id", id))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully retrieved user by ID", zap.Int("id", id))
+	return &user, nil
+}
+
+func (r *Repository) GetByEmail(ctx context.Context, email string) (*domainUser.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.list(ctx, false) {
+		if user.Email == email {
+			r.Logger.Info("Successfully retrieved user by email", zap.String("email", email))
+			return &user, nil
+		}
+	}
+	r.Logger.Warn("User not found", zap.String("email", email))
+	return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (r *Repository) Update(ctx context.Context, id int, userMap map[string]interface{}) (*domainUser.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.find(ctx, id)
+	if !ok || before.DeletedAt != nil {
+		r.Logger.Error("Error updating user", zap.Int("id", id), zap.String("reason", "not found"))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	user := before
+	for key, value := range userMap {
+		column := psqlUser.ColumnsUserMapping[key]
+		if column == "" {
+			column = key
+		}
+		if !slices.Contains(updatableColumns, column) {
+			continue
+		}
+		if column == "status" {
+			status, isBool := value.(bool)
+			if !isBool {
+				r.Logger.Error("Error updating user", zap.Int("id", id), zap.String("reason", "status must be a boolean"))
+				return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+			}
+			user.Status = status
+			continue
+		}
+		text := ""
+		if value != nil {
+			text = fmt.Sprint(value)
+		}
+		switch column {
+		case "user_name":
+			user.UserName = text
+		case "email":
+			user.Email = text
+		case "first_name":
+			user.FirstName = text
+		case "last_name":
+			user.LastName = text
+		}
+	}
+	user.UpdatedAt = time.Now()
+	if actorID := security.ActorID(ctx); actorID != nil {
+		user.UpdatedBy = actorID
+	}
+	if r.conflicts(&user) {
+		r.Logger.Error("Error updating user", zap.Int("id", id), zap.String("reason", "duplicated user name or email"))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	if err := r.recordHistory(ctx, domainHistory.ActionUpdate, &before, &user); err != nil {
+		r.Logger.Error("Error updating user", zap.Error(err), zap.Int("id", id))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.users[id] = user
+	r.Logger.Info("Successfully updated user", zap.Int("id", id))
+	return &user, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.find(ctx, id)
+	if !ok || before.DeletedAt != nil {
+		r.Logger.Warn("User not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	user := before
+	deletedAt := time.Now()
+	user.DeletedAt = &deletedAt
+	if err := r.recordHistory(ctx, domainHistory.ActionDelete, &before, &user); err != nil {
+		r.Logger.Error("Error deleting user", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.users[id] = user
+	r.Logger.Info("Successfully deleted user", zap.Int("id", id))
+	return nil
+}
+
+// Restore clears the deletion mark of a soft-deleted user
+func (r *Repository) Restore(ctx context.Context, id int) (*domainUser.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.find(ctx, id)
+	if !ok || before.DeletedAt == nil {
+		r.Logger.Warn("Deleted user not found for restore", zap.Int("id", id))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	user := before
+	user.DeletedAt = nil
+	user.UpdatedAt = time.Now()
+	if actorID := security.ActorID(ctx); actorID != nil {
+		user.UpdatedBy = actorID
+	}
+	if r.conflicts(&user) {
+		r.Logger.Error("Error restoring user", zap.Int("id", id), zap.String("reason", "duplicated user name or email"))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	if err := r.recordHistory(ctx, domainHistory.ActionRestore, &before, &user); err != nil {
+		r.Logger.Error("Error restoring user", zap.Error(err), zap.Int("id", id))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.users[id] = user
+	r.Logger.Info("Successfully restored user", zap.Int("id", id))
+	return &user, nil
+}
+
+// Purge permanently removes users soft-deleted before olderThan
+func (r *Repository) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for id, user := range r.users {
+		if memory.InTenant(ctx, user.TenantID) && user.DeletedAt != nil && user.DeletedAt.Before(olderThan) {
+			delete(r.users, id)
+			count++
+		}
+	}
+	r.Logger.Info("Successfully purged deleted users", zap.Int64("count", count))
+	return count, nil
+}
+
+func (r *Repository) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := toSearchResult(memory.Paginate(r.list(ctx, false), filters, fields))
+	r.Logger.Info("Successfully searched users",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page),
+		zap.Int("pageSize", result.PageSize))
+	return result, nil
+}
+
+// GetTrash lists soft-deleted users using the same filters as SearchPaginated
+func (r *Repository) GetTrash(ctx context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := toSearchResult(memory.Paginate(r.list(ctx, true), filters, fields))
+	r.Logger.Info("Successfully listed deleted users",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+	return result, nil
+}
+
+func (r *Repository) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
+	if psqlUser.ColumnsUserMapping[property] == "" {
+		r.Logger.Warn("Invalid property for search", zap.String("property", property))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	coincidences, _ := memory.SearchByProperty(r.list(ctx, false), property, searchText, fields)
+	r.Logger.Info("Successfully searched by property",
+		zap.String("property", property),
+		zap.Int("results", len(coincidences)))
+	return &coincidences, nil
+}
+
+// GetHistory returns every recorded change of a user, oldest first
+func (r *Repository) GetHistory(ctx context.Context, id int) (*[]domainHistory.Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := r.history.Entries(ctx, id)
+	if len(entries) == 0 {
+		r.Logger.Warn("User history not found", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully retrieved user history", zap.Int("id", id), zap.Int("count", len(entries)))
+	return &entries, nil
+}
+
+// GetAsOf rebuilds a user as it was at the given time from its history
+func (r *Repository) GetAsOf(ctx context.Context, id int, at time.Time) (*domainUser.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.history.AsOf(ctx, id, at)
+	if !ok {
+		r.Logger.Warn("User not found at requested time", zap.Int("id", id), zap.Time("asOf", at))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully retrieved user as of time", zap.Int("id", id), zap.Time("asOf", at))
+	return &user, nil
+}
+
+// recordHistory stores the change between before and after. As in the SQL repository,
+// the password hash is kept out of both the recorded changes and the snapshot.
+func (r *Repository) recordHistory(ctx context.Context, action domainHistory.Action, before, after *domainUser.User) error {
+	var beforeFields, afterFields map[string]any
+	if before != nil {
+		beforeFields = snapshotFields(before)
+	}
+	if after != nil {
+		afterFields = snapshotFields(after)
+	}
+	entityID, snapshot := after.ID, *after
+	snapshot.HashPassword = ""
+	return r.history.Record(ctx, snapshot.TenantID, entityID, action, beforeFields, afterFields, snapshot)
+}
+
+// find returns the user with the given ID when it is visible in ctx
+func (r *Repository) find(ctx context.Context, id int) (domainUser.User, bool) {
+	user, ok := r.users[id]
+	if !ok || !memory.InTenant(ctx, user.TenantID) {
+		return domainUser.User{}, false
+	}
+	return user, true
+}
+
+// list returns the live or the soft-deleted users visible in ctx ordered by ID
+func (r *Repository) list(ctx context.Context, deleted bool) []domainUser.User {
+	users := make([]domainUser.User, 0, len(r.users))
+	for _, user := range r.users {
+		if (user.DeletedAt != nil) == deleted && memory.InTenant(ctx, user.TenantID) {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b domainUser.User) int { return a.ID - b.ID })
+	return users
+}
+
+// conflicts reports whether another live user shares the user name or email of user,
+// mirroring the partial unique indexes of the SQL schema. Both stay unique across
+// organizations since users log in by email before their tenant is known.
+func (r *Repository) conflicts(user *domainUser.User) bool {
+	for id, other := range r.users {
+		if id == user.ID || other.DeletedAt != nil {
+			continue
+		}
+		if other.UserName == user.UserName || other.Email == user.Email {
+			return true
+		}
+	}
+	return false
+}
+
+func fields(u *domainUser.User) map[string]any {
+	values := snapshotFields(u)
+	values["hashPassword"] = u.HashPassword
+	return values
+}
+
+func snapshotFields(u *domainUser.User) map[string]any {
+	return map[string]any{
+		"id":        u.ID,
+		"tenantId":  u.TenantID,
+		"userName":  u.UserName,
+		"email":     u.Email,
+		"firstName": u.FirstName,
+		"lastName":  u.LastName,
+		"status":    u.Status,
+		"createdAt": u.CreatedAt,
+		"updatedAt": u.UpdatedAt,
+		"deletedAt": u.DeletedAt,
+		"createdBy": u.CreatedBy,
+		"updatedBy": u.UpdatedBy,
+	}
+}
+
+func toSearchResult(page memory.Page[domainUser.User]) *domainUser.SearchResultUser {
+	return &domainUser.SearchResultUser{
+		Data:       &page.Data,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+	}
+}
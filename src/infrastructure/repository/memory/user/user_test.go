@@ -0,0 +1,132 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	psqlUser "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return loggerInstance
+}
+
+func setupRepository(t *testing.T) psqlUser.UserRepositoryInterface {
+	return NewUserRepository(setupLogger(t))
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestRepository_CreateAndGetByEmail(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &domainUser.User{UserName: "jdoe", Email: "jdoe@example.com", HashPassword: "hash"})
+	require.NoError(t, err)
+
+	found, err := repo.GetByEmail(ctx, "jdoe@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, found.ID)
+	assert.Equal(t, "hash", found.HashPassword)
+
+	_, err = repo.Create(ctx, &domainUser.User{UserName: "other", Email: "jdoe@example.com"})
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	_, err = repo.GetByEmail(ctx, "jdoe@example.com")
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_Update(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &domainUser.User{UserName: "jdoe", Email: "jdoe@example.com"})
+	require.NoError(t, err)
+
+	updated, err := repo.Update(ctx, created.ID, map[string]interface{}{"firstName": "John", "status": true, "hash_password": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "John", updated.FirstName)
+	assert.True(t, updated.Status)
+	assert.Empty(t, updated.HashPassword)
+
+	_, err = repo.Update(ctx, created.ID, map[string]interface{}{"status": "yes"})
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestRepository_SearchPaginated(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	_, err := repo.Create(ctx, &domainUser.User{UserName: "jdoe", Email: "jdoe@example.com", Status: true})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &domainUser.User{UserName: "asmith", Email: "asmith@example.com"})
+	require.NoError(t, err)
+
+	result, err := repo.SearchPaginated(ctx, domain.DataFilters{Matches: map[string][]string{"status": {"true"}}})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 1)
+	assert.Equal(t, "jdoe", (*result.Data)[0].UserName)
+}
+
+func TestRepository_HistoryOmitsPasswordHash(t *testing.T) {
+	repo := setupRepository(t)
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &domainUser.User{Email: "jdoe@example.com", HashPassword: "hash"})
+	require.NoError(t, err)
+
+	entries, err := repo.GetHistory(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, *entries, 1)
+	assert.NotContains(t, (*entries)[0].Changes, "hashPassword")
+
+	asOf, err := repo.GetAsOf(ctx, created.ID, (*entries)[0].CreatedAt)
+	require.NoError(t, err)
+	assert.Empty(t, asOf.HashPassword)
+}
+
+func TestSeedInitialUser(t *testing.T) {
+	t.Setenv("START_USER_EMAIL", "admin@example.com")
+	t.Setenv("START_USER_PW", "secret")
+	repo := setupRepository(t)
+
+	require.NoError(t, SeedInitialUser(repo, 1, setupLogger(t)))
+
+	seeded, err := repo.GetByEmail(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	assert.NotEqual(t, "secret", seeded.HashPassword)
+	assert.Equal(t, 1, seeded.TenantID)
+}
+
+func TestRepository_TenantIsolation(t *testing.T) {
+	repo := setupRepository(t)
+	firstTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+	secondTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 2, TenantID: 2})
+
+	created, err := repo.Create(firstTenant, &domainUser.User{UserName: "jdoe", Email: "jdoe@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.TenantID)
+	_, err = repo.Create(secondTenant, &domainUser.User{UserName: "jdoe", Email: "jdoe@example.com"})
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+
+	_, err = repo.GetByID(secondTenant, created.ID)
+	assertErrorType(t, err, domainErrors.NotFound)
+	_, err = repo.GetByEmail(secondTenant, "jdoe@example.com")
+	assertErrorType(t, err, domainErrors.NotFound)
+
+	found, err := repo.GetByEmail(context.Background(), "jdoe@example.com")
+	require.NoError(t, err, "log in looks users up across organizations")
+	assert.Equal(t, created.ID, found.ID)
+}
@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory"
+	psqlWebhook "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+// Repository keeps webhooks in process memory. Data is lost when the process exits.
+// Events are not delivered without a database, so the delivery log is always empty.
+type Repository struct {
+	Logger *logger.Logger
+
+	mu       sync.RWMutex
+	lastID   int
+	webhooks map[int]domainWebhook.Webhook
+}
+
+func NewWebhookRepository(loggerInstance *logger.Logger) psqlWebhook.WebhookRepositoryInterface {
+	return &Repository{
+		Logger:   loggerInstance,
+		webhooks: make(map[int]domainWebhook.Webhook),
+	}
+}
+
+func (r *Repository) GetAll(ctx context.Context) (*[]domainWebhook.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhooks := make([]domainWebhook.Webhook, 0, len(r.webhooks))
+	for _, webhook := range r.webhooks {
+		if memory.InTenant(ctx, webhook.TenantID) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	slices.SortFunc(webhooks, func(a, b domainWebhook.Webhook) int { return a.ID - b.ID })
+	r.Logger.Info("Successfully retrieved all webhooks", zap.Int("count", len(webhooks)))
+	return &webhooks, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainWebhook.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhook, ok := r.find(ctx, id)
+	if !ok {
+		r.Logger.Warn("Webhook not found", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully retrieved webhook by ID", zap.Int("id", id))
+	return &webhook, nil
+}
+
+func (r *Repository) Create(ctx context.Context, newWebhook *domainWebhook.Webhook) (*domainWebhook.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	webhook := *newWebhook
+	webhook.ID = r.lastID + 1
+	webhook.TenantID = memory.TenantOf(ctx, newWebhook.TenantID)
+	webhook.EventTypes = slices.Clone(newWebhook.EventTypes)
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	webhook.CreatedBy = security.ActorID(ctx)
+	webhook.UpdatedBy = security.ActorID(ctx)
+	r.lastID = webhook.ID
+	r.webhooks[webhook.ID] = webhook
+	r.Logger.Info("Successfully created webhook", zap.String("url", webhook.URL), zap.Int("id", webhook.ID))
+	return &webhook, nil
+}
+
+func (r *Repository) Update(ctx context.Context, id int, webhookMap map[string]any) (*domainWebhook.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook, ok := r.find(ctx, id)
+	if !ok {
+		r.Logger.Warn("Webhook not found for update", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	for key, value := range webhookMap {
+		switch key {
+		case "url":
+			webhook.URL, _ = value.(string)
+		case "eventTypes":
+			eventTypes, _ := value.([]string)
+			webhook.EventTypes = slices.Clone(eventTypes)
+		case "secret":
+			webhook.Secret, _ = value.(string)
+		case "active":
+			webhook.Active, _ = value.(bool)
+			if webhook.Active {
+				webhook.ConsecutiveFailures = 0
+				webhook.DisabledAt = nil
+				webhook.DisabledReason = ""
+			}
+		}
+	}
+	webhook.UpdatedAt = time.Now()
+	webhook.UpdatedBy = security.ActorID(ctx)
+	r.webhooks[id] = webhook
+	r.Logger.Info("Successfully updated webhook", zap.Int("id", id))
+	return &webhook, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.find(ctx, id); !ok {
+		r.Logger.Warn("Webhook not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	delete(r.webhooks, id)
+	r.Logger.Info("Successfully deleted webhook", zap.Int("id", id))
+	return nil
+}
+
+func (r *Repository) GetDeliveries(ctx context.Context, webhookID int, filters domain.DataFilters) (*domainWebhook.SearchResultDelivery, error) {
+	if _, err := r.GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	return &domainWebhook.SearchResultDelivery{
+		Data:     &[]domainWebhook.Delivery{},
+		Page:     filters.Page,
+		PageSize: filters.PageSize,
+	}, nil
+}
+
+// find returns the webhook with the given ID when it is visible in ctx
+func (r *Repository) find(ctx context.Context, id int) (domainWebhook.Webhook, bool) {
+	webhook, ok := r.webhooks[id]
+	if !ok || !memory.InTenant(ctx, webhook.TenantID) {
+		return domainWebhook.Webhook{}, false
+	}
+	return webhook, true
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"slices"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"gorm.io/gorm"
+)
+
+const (
+	createdByField = "CreatedBy"
+	updatedByField = "UpdatedBy"
+)
+
+// RegisterCallbacks makes GORM fill the CreatedBy/UpdatedBy fields of any model that
+// declares them with the principal found in the statement context
+func RegisterCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("audit:created_by", setCreatedBy); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("audit:updated_by", setUpdatedBy)
+}
+
+func setCreatedBy(db *gorm.DB) {
+	setActor(db, createdByField, updatedByField)
+}
+
+func setUpdatedBy(db *gorm.DB) {
+	setActor(db, updatedByField)
+}
+
+func setActor(db *gorm.DB, fieldNames ...string) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	actorID := security.ActorID(db.Statement.Context)
+	if actorID == nil {
+		return
+	}
+	for _, name := range fieldNames {
+		field := db.Statement.Schema.LookUpField(name)
+		if field == nil {
+			continue
+		}
+		db.Statement.SetColumn(field.DBName, *actorID, true)
+		// Explicit Select clauses would otherwise drop the audit column from the statement
+		if len(db.Statement.Selects) > 0 && !slices.Contains(db.Statement.Selects, "*") {
+			db.Statement.Selects = append(db.Statement.Selects, field.DBName)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type auditedModel struct {
+	ID        int `gorm:"primaryKey"`
+	Name      string
+	CreatedBy *int
+	UpdatedBy *int
+}
+
+func (auditedModel) TableName() string {
+	return "audited"
+}
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, RegisterCallbacks(gormDB))
+	return gormDB, mock
+}
+
+func TestCreateSetsCreatedAndUpdatedBy(t *testing.T) {
+	db, mock := setupMockDB(t)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 9})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audited" ("name","created_by","updated_by") VALUES ($1,$2,$3) RETURNING "id"`)).
+		WithArgs("Aspirin", 9, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	model := auditedModel{Name: "Aspirin"}
+	require.NoError(t, db.WithContext(ctx).Create(&model).Error)
+	assert.Equal(t, 9, *model.CreatedBy)
+	assert.Equal(t, 9, *model.UpdatedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateSetsUpdatedBy(t *testing.T) {
+	db, mock := setupMockDB(t)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 4})
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "audited" SET "name"=$1,"updated_by"=$2 WHERE "id" = $3`)).
+		WithArgs("Ibuprofen", 4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := db.WithContext(ctx).Model(&auditedModel{ID: 1}).Select("name").Updates(map[string]any{"name": "Ibuprofen"}).Error
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateWithoutPrincipal(t *testing.T) {
+	db, mock := setupMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audited"`)).
+		WithArgs("Aspirin", nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	model := auditedModel{Name: "Aspirin"}
+	require.NoError(t, db.WithContext(context.Background()).Create(&model).Error)
+	assert.Nil(t, model.CreatedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
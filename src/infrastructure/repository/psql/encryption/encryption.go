@@ -0,0 +1,283 @@
+package encryption
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security/envelope"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	pluginName = "encryption"
+	// encryptedTag marks an encrypted string field and names its blind index column,
+	// as in `gorm:"encrypted:email_index"`
+	encryptedTag = "ENCRYPTED"
+	restoreKey   = "encryption:restore"
+)
+
+type plugin struct {
+	cipher *envelope.Cipher
+}
+
+// encryptedField is an encrypted field together with its blind index field
+type encryptedField struct {
+	value *schema.Field
+	index *schema.Field
+}
+
+// RegisterCallbacks makes GORM encrypt the fields tagged `encrypted:<index column>`
+// with cipher before they are written and decrypt them after they are read. The index
+// column, a nullable string, receives the blind index of the plaintext so exact
+// matches keep working through Match. Empty values are neither encrypted nor indexed.
+// A nil cipher leaves every value in plaintext.
+func RegisterCallbacks(db *gorm.DB, cipher *envelope.Cipher) error {
+	return db.Use(&plugin{cipher: cipher})
+}
+
+func (p *plugin) Name() string {
+	return pluginName
+}
+
+func (p *plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("encryption:encrypt", p.encrypt); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:create").Register("encryption:restore", restore); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("encryption:encrypt", p.encrypt); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("encryption:restore", restore); err != nil {
+		return err
+	}
+	return callbacks.Query().After("gorm:query").Register("encryption:decrypt", p.decrypt)
+}
+
+// CipherOf returns the cipher registered on db, or nil when encryption is disabled
+func CipherOf(db *gorm.DB) *envelope.Cipher {
+	if p, ok := db.Config.Plugins[pluginName].(*plugin); ok {
+		return p.cipher
+	}
+	return nil
+}
+
+// Encrypted reports whether column of model is stored encrypted on db
+func Encrypted(db *gorm.DB, model any, column string) bool {
+	_, found := indexColumn(db, model, column)
+	return found && CipherOf(db) != nil
+}
+
+// Match returns the condition selecting the rows of model whose column equals any
+// of values, comparing blind indexes when the column is encrypted
+func Match(db *gorm.DB, model any, column string, values []string) clause.Expression {
+	index, found := indexColumn(db, model, column)
+	cipher := CipherOf(db)
+	if !found || cipher == nil {
+		return clause.IN{Column: clause.Column{Name: column}, Values: toAny(values)}
+	}
+	var indexes []any
+	empty := false
+	for _, value := range values {
+		if value == "" {
+			empty = true
+			continue
+		}
+		indexes = append(indexes, cipher.BlindIndex(index, value))
+	}
+	condition := clause.Expression(clause.IN{Column: clause.Column{Name: index}, Values: indexes})
+	if empty {
+		condition = clause.Or(condition, clause.Eq{Column: clause.Column{Name: column}, Value: ""})
+	}
+	return condition
+}
+
+func (p *plugin) encrypt(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || p.cipher == nil {
+		return
+	}
+	fields := encryptedFields(db.Statement.Schema)
+	if len(fields) == 0 {
+		return
+	}
+	if values, ok := db.Statement.Dest.(map[string]any); ok {
+		p.encryptMap(db, fields, values)
+		return
+	}
+
+	var restores []func()
+	ctx := db.Statement.Context
+	err := eachRecord(db, func(record reflect.Value) error {
+		for _, field := range fields {
+			value, _ := field.value.ValueOf(ctx, record)
+			plaintext, ok := value.(string)
+			if !ok || envelope.IsEncrypted(plaintext) {
+				continue
+			}
+			sealed, index, err := p.seal(field, plaintext)
+			if err != nil {
+				return err
+			}
+			if err := field.value.Set(ctx, record, sealed); err != nil {
+				return err
+			}
+			if err := field.index.Set(ctx, record, index); err != nil {
+				return err
+			}
+			// the caller keeps working with the plaintext once the statement ran
+			restores = append(restores, func() { _ = field.value.Set(ctx, record, plaintext) })
+		}
+		return nil
+	})
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(restoreKey, restores)
+}
+
+// encryptMap encrypts the values of an update given as a column map
+func (p *plugin) encryptMap(db *gorm.DB, fields []encryptedField, values map[string]any) {
+	selects := db.Statement.Selects
+	restricted := len(selects) > 0 && !slices.Contains(selects, "*")
+	for _, field := range fields {
+		for _, key := range []string{field.value.DBName, field.value.Name} {
+			plaintext, ok := values[key].(string)
+			if !ok || envelope.IsEncrypted(plaintext) {
+				continue
+			}
+			sealed, index, err := p.seal(field, plaintext)
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			values[key] = sealed
+			values[field.index.DBName] = index
+			// explicit Select clauses would otherwise drop the index column from the statement
+			if restricted && (slices.Contains(selects, field.value.DBName) || slices.Contains(selects, field.value.Name)) {
+				db.Statement.Selects = append(db.Statement.Selects, field.index.DBName)
+			}
+		}
+	}
+}
+
+func (p *plugin) seal(field encryptedField, plaintext string) (string, *string, error) {
+	if plaintext == "" {
+		return plaintext, nil, nil
+	}
+	sealed, err := p.cipher.Encrypt(plaintext)
+	if err != nil {
+		return "", nil, err
+	}
+	index := p.cipher.BlindIndex(field.index.DBName, plaintext)
+	return sealed, &index, nil
+}
+
+func restore(db *gorm.DB) {
+	restores, ok := db.InstanceGet(restoreKey)
+	if !ok {
+		return
+	}
+	for _, restore := range restores.([]func()) {
+		restore()
+	}
+}
+
+func (p *plugin) decrypt(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	fields := encryptedFields(db.Statement.Schema)
+	if len(fields) == 0 {
+		return
+	}
+	ctx := db.Statement.Context
+	err := eachRecord(db, func(record reflect.Value) error {
+		for _, field := range fields {
+			value, _ := field.value.ValueOf(ctx, record)
+			sealed, ok := value.(string)
+			if !ok || !envelope.IsEncrypted(sealed) {
+				continue
+			}
+			plaintext, err := p.cipher.Decrypt(sealed)
+			if err != nil {
+				return fmt.Errorf("decrypting %s: %w", field.value.DBName, err)
+			}
+			if err := field.value.Set(ctx, record, plaintext); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	db.AddError(err)
+}
+
+// eachRecord calls fn with every model struct held by the statement destination
+func eachRecord(db *gorm.DB, fn func(record reflect.Value) error) error {
+	modelType := db.Statement.Schema.ModelType
+	destValue := reflect.ValueOf(db.Statement.Dest)
+	for destValue.Kind() == reflect.Ptr {
+		destValue = destValue.Elem()
+	}
+	switch destValue.Kind() {
+	case reflect.Struct:
+		if destValue.Type() != modelType {
+			return nil
+		}
+		if !destValue.CanAddr() {
+			return fmt.Errorf("encryption: %s must be passed by pointer", modelType.Name())
+		}
+		return fn(destValue)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < destValue.Len(); i++ {
+			record := reflect.Indirect(destValue.Index(i))
+			if record.Kind() != reflect.Struct || record.Type() != modelType {
+				return nil
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func encryptedFields(s *schema.Schema) []encryptedField {
+	var fields []encryptedField
+	for _, field := range s.Fields {
+		indexName, tagged := field.TagSettings[encryptedTag]
+		if !tagged {
+			continue
+		}
+		if index := s.LookUpField(indexName); index != nil {
+			fields = append(fields, encryptedField{value: field, index: index})
+		}
+	}
+	return fields
+}
+
+func indexColumn(db *gorm.DB, model any, column string) (string, bool) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", false
+	}
+	for _, field := range encryptedFields(stmt.Schema) {
+		if field.value.DBName == column {
+			return field.index.DBName, true
+		}
+	}
+	return "", false
+}
+
+func toAny(values []string) []any {
+	converted := make([]any, len(values))
+	for i, value := range values {
+		converted[i] = value
+	}
+	return converted
+}
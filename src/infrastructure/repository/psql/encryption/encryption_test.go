@@ -0,0 +1,148 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security/envelope"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type person struct {
+	ID         int    `gorm:"primaryKey"`
+	Email      string `gorm:"encrypted:email_index"`
+	EmailIndex *string
+	Nickname   string
+}
+
+func testKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(fill), 32)))
+}
+
+func newCipher(t *testing.T, masterKeys string) *envelope.Cipher {
+	t.Helper()
+	cipher, err := envelope.NewCipher(envelope.Config{MasterKeys: masterKeys, BlindIndexKey: testKey('i')})
+	require.NoError(t, err)
+	return cipher
+}
+
+func setupDB(t *testing.T, cipher *envelope.Cipher) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, RegisterCallbacks(db, cipher))
+	require.NoError(t, db.AutoMigrate(&person{}))
+	return db
+}
+
+func rawEmail(t *testing.T, db *gorm.DB, id int) (string, *string) {
+	t.Helper()
+	var row struct {
+		Email      string
+		EmailIndex *string
+	}
+	require.NoError(t, db.Table("people").Select("email", "email_index").Where("id = ?", id).Scan(&row).Error)
+	return row.Email, row.EmailIndex
+}
+
+func TestCreateAndQuery(t *testing.T) {
+	cipher := newCipher(t, "k1:"+testKey('a'))
+	db := setupDB(t, cipher)
+
+	created := &person{Email: "ana@example.com", Nickname: "ana"}
+	require.NoError(t, db.Create(created).Error)
+	assert.Equal(t, "ana@example.com", created.Email, "the caller keeps the plaintext")
+
+	stored, index := rawEmail(t, db, created.ID)
+	assert.True(t, envelope.IsEncrypted(stored))
+	require.NotNil(t, index)
+	assert.Equal(t, cipher.BlindIndex("email_index", "ana@example.com"), *index)
+
+	var found person
+	require.NoError(t, db.Where(Match(db, &person{}, "email", []string{"ana@example.com"})).First(&found).Error)
+	assert.Equal(t, "ana@example.com", found.Email)
+
+	var all []*person
+	require.NoError(t, db.Find(&all).Error)
+	require.Len(t, all, 1)
+	assert.Equal(t, "ana@example.com", all[0].Email)
+
+	assert.True(t, Encrypted(db, &person{}, "email"))
+	assert.False(t, Encrypted(db, &person{}, "nickname"))
+}
+
+func TestUpdateWithMapAndSelect(t *testing.T) {
+	cipher := newCipher(t, "k1:"+testKey('a'))
+	db := setupDB(t, cipher)
+	created := &person{Email: "ana@example.com"}
+	require.NoError(t, db.Create(created).Error)
+
+	err := db.Model(&person{ID: created.ID}).Select("email", "nickname").
+		Updates(map[string]any{"email": "bea@example.com", "nickname": "bea"}).Error
+	require.NoError(t, err)
+
+	stored, index := rawEmail(t, db, created.ID)
+	assert.True(t, envelope.IsEncrypted(stored))
+	assert.Equal(t, cipher.BlindIndex("email_index", "bea@example.com"), *index)
+
+	require.NoError(t, db.Model(&person{ID: created.ID}).Update("email", "").Error)
+	stored, index = rawEmail(t, db, created.ID)
+	assert.Empty(t, stored)
+	assert.Nil(t, index, "empty values are not indexed")
+	var found person
+	require.NoError(t, db.Where(Match(db, &person{}, "email", []string{""})).First(&found).Error)
+	assert.Equal(t, created.ID, found.ID)
+}
+
+func TestDisabled(t *testing.T) {
+	db := setupDB(t, nil)
+	created := &person{Email: "ana@example.com"}
+	require.NoError(t, db.Create(created).Error)
+
+	stored, index := rawEmail(t, db, created.ID)
+	assert.Equal(t, "ana@example.com", stored)
+	assert.Nil(t, index)
+	var found person
+	require.NoError(t, db.Where(Match(db, &person{}, "email", []string{"ana@example.com"})).First(&found).Error)
+	assert.Equal(t, created.ID, found.ID)
+	assert.False(t, Encrypted(db, &person{}, "email"))
+}
+
+func TestReencrypt(t *testing.T) {
+	oldCipher := newCipher(t, "k1:"+testKey('a'))
+	db := setupDB(t, oldCipher)
+	for _, email := range []string{"ana@example.com", "bea@example.com", ""} {
+		require.NoError(t, db.Create(&person{Email: email}).Error)
+	}
+	// a row written before encryption was enabled
+	require.NoError(t, db.Exec(`INSERT INTO people (email) VALUES ('legacy@example.com')`).Error)
+
+	rotated := setupDB(t, newCipher(t, "k1:"+testKey('a')+",k2:"+testKey('b')))
+	var rows []map[string]any
+	require.NoError(t, db.Table("people").Find(&rows).Error)
+	require.NoError(t, rotated.Table("people").Create(&rows).Error)
+
+	updated, err := Reencrypt(context.Background(), rotated, &person{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), updated)
+
+	for id := 1; id <= 4; id++ {
+		stored, _ := rawEmail(t, rotated, id)
+		assert.True(t, stored == "" || strings.HasPrefix(stored, "enc:v1:k2:"), stored)
+	}
+	var legacy person
+	require.NoError(t, rotated.Where(Match(rotated, &person{}, "email", []string{"legacy@example.com"})).First(&legacy).Error)
+	assert.Equal(t, 4, legacy.ID)
+
+	updated, err = Reencrypt(context.Background(), rotated, &person{}, 2)
+	require.NoError(t, err)
+	assert.Zero(t, updated, "rows already under the active key are left alone")
+}
@@ -0,0 +1,107 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DefaultBatchSize is the number of rows Reencrypt reads at a time when none is given
+const DefaultBatchSize = 500
+
+// Reencrypt walks every row of model, deleted ones included, in batches of batchSize
+// and brings its encrypted columns up to date: plaintext left from before encryption
+// was enabled is encrypted, values under a retired master key are rewrapped with the
+// active one, and blind indexes are recomputed. It returns the number of updated rows.
+func Reencrypt(ctx context.Context, db *gorm.DB, model any, batchSize int) (int64, error) {
+	cipher := CipherOf(db)
+	if cipher == nil {
+		return 0, fmt.Errorf("encryption: no master keys configured")
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return 0, err
+	}
+	fields := encryptedFields(stmt.Schema)
+	primaryKey := stmt.Schema.PrioritizedPrimaryField
+	if len(fields) == 0 || primaryKey == nil {
+		return 0, nil
+	}
+	columns := []string{primaryKey.DBName}
+	for _, field := range fields {
+		columns = append(columns, field.value.DBName, field.index.DBName)
+	}
+
+	// Rows are read and written as plain maps through the table name, so the callbacks
+	// neither decrypt nor re-encrypt them and no audit column is touched
+	var updated int64
+	var lastID any = 0
+	for {
+		var rows []map[string]any
+		err := db.WithContext(ctx).Table(stmt.Schema.Table).
+			Select(columns).
+			Where(primaryKey.DBName+" > ?", lastID).
+			Order(primaryKey.DBName).
+			Limit(batchSize).
+			Find(&rows).Error
+		if err != nil {
+			return updated, err
+		}
+		for _, row := range rows {
+			changes := make(map[string]any)
+			for _, field := range fields {
+				value := toString(row[field.value.DBName])
+				if value == "" {
+					continue
+				}
+				rotated, changed, err := cipher.Rotate(value)
+				if err != nil {
+					return updated, fmt.Errorf("row %v, column %s: %w", row[primaryKey.DBName], field.value.DBName, err)
+				}
+				plaintext, err := cipher.Decrypt(rotated)
+				if err != nil {
+					return updated, err
+				}
+				index := cipher.BlindIndex(field.index.DBName, plaintext)
+				if changed {
+					changes[field.value.DBName] = rotated
+				}
+				if toString(row[field.index.DBName]) != index {
+					changes[field.index.DBName] = index
+				}
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			err := db.WithContext(ctx).Table(stmt.Schema.Table).
+				Where(primaryKey.DBName+" = ?", row[primaryKey.DBName]).
+				UpdateColumns(changes).Error
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		if len(rows) < batchSize {
+			return updated, nil
+		}
+		lastID = rows[len(rows)-1][primaryKey.DBName]
+	}
+}
+
+func toString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case []byte:
+		return string(typed)
+	case *string:
+		if typed != nil {
+			return *typed
+		}
+	}
+	return ""
+}
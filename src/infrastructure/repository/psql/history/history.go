@@ -0,0 +1,161 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/google/uuid"
+)
+
+// Entry is the GORM model shared by every <entity>_history table.
+// Entities embed it in their own history type to get a dedicated table.
+type Entry struct {
+	ID        int       `gorm:"primaryKey"`
+	TenantID  int       `gorm:"index"`
+	EntityID  int       `gorm:"index"`
+	Action    string    `gorm:"size:20"`
+	Changes   string    `gorm:"type:jsonb"`
+	Snapshot  string    `gorm:"type:jsonb"`
+	ActorID   *int      `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime:milli;index"`
+}
+
+type fieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// ignoredFields are bookkeeping fields that change on every write and are not worth recording
+var ignoredFields = map[string]bool{
+	"updatedAt": true,
+	"updatedBy": true,
+}
+
+// Diff compares two JSON-serializable snapshots field by field. A nil snapshot
+// stands for a record that does not exist, so every field of the other one is reported.
+func Diff(before, after any) (map[string]domainHistory.FieldChange, error) {
+	beforeMap, err := toMap(before)
+	if err != nil {
+		return nil, err
+	}
+	afterMap, err := toMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]domainHistory.FieldChange)
+	for field, afterValue := range afterMap {
+		beforeValue := beforeMap[field]
+		if ignoredFields[field] || reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes[field] = domainHistory.FieldChange{Before: beforeValue, After: afterValue}
+	}
+	for field, beforeValue := range beforeMap {
+		if _, exists := afterMap[field]; exists || ignoredFields[field] {
+			continue
+		}
+		changes[field] = domainHistory.FieldChange{Before: beforeValue, After: nil}
+	}
+	return changes, nil
+}
+
+// NewEntry builds a history entry attributed to the principal found in ctx
+func NewEntry(ctx context.Context, entityID int, action domainHistory.Action, changes map[string]domainHistory.FieldChange, snapshot any) (*Entry, error) {
+	encodedChanges := make(map[string]fieldChange, len(changes))
+	for field, change := range changes {
+		encodedChanges[field] = fieldChange(change)
+	}
+	changesJSON, err := json.Marshal(encodedChanges)
+	if err != nil {
+		return nil, err
+	}
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{
+		EntityID: entityID,
+		Action:   string(action),
+		Changes:  string(changesJSON),
+		Snapshot: string(snapshotJSON),
+		ActorID:  security.ActorID(ctx),
+	}, nil
+}
+
+// ToDomain decodes the stored changes into a domain history entry
+func (e *Entry) ToDomain() (*domainHistory.Entry, error) {
+	var encodedChanges map[string]fieldChange
+	if e.Changes != "" {
+		if err := json.Unmarshal([]byte(e.Changes), &encodedChanges); err != nil {
+			return nil, err
+		}
+	}
+	changes := make(map[string]domainHistory.FieldChange, len(encodedChanges))
+	for field, change := range encodedChanges {
+		changes[field] = domainHistory.FieldChange(change)
+	}
+	return &domainHistory.Entry{
+		ID:        e.ID,
+		EntityID:  e.EntityID,
+		Action:    domainHistory.Action(e.Action),
+		Changes:   changes,
+		ActorID:   e.ActorID,
+		CreatedAt: e.CreatedAt,
+	}, nil
+}
+
+// ToEvent returns the event announcing the change recorded by a stored entry. Its data
+// carries the changes and the snapshot exactly as stored, so personal data encrypted in
+// the history stays encrypted in the event.
+func (e *Entry) ToEvent(aggregateType string) event.Event {
+	data, _ := json.Marshal(map[string]json.RawMessage{
+		"changes":  rawOrNull(e.Changes),
+		"snapshot": rawOrNull(e.Snapshot),
+	})
+	return event.Event{
+		ID:            uuid.NewString(),
+		Type:          event.TypeOf(aggregateType, domainHistory.Action(e.Action)),
+		AggregateType: aggregateType,
+		AggregateID:   e.EntityID,
+		TenantID:      e.TenantID,
+		ActorID:       e.ActorID,
+		OccurredAt:    e.CreatedAt,
+		Data:          data,
+	}
+}
+
+// DecodeSnapshot unmarshals the stored snapshot into target
+func (e *Entry) DecodeSnapshot(target any) error {
+	return json.Unmarshal([]byte(e.Snapshot), target)
+}
+
+func toMap(snapshot any) (map[string]any, error) {
+	result := map[string]any{}
+	if snapshot == nil {
+		return result, nil
+	}
+	if value := reflect.ValueOf(snapshot); value.Kind() == reflect.Ptr && value.IsNil() {
+		return result, nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func rawOrNull(value string) json.RawMessage {
+	if value == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(value)
+}
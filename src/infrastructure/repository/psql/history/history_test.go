@@ -0,0 +1,96 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshot struct {
+	Name      string `json:"name"`
+	Stock     int    `json:"stock"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+func TestDiff_Update(t *testing.T) {
+	changes, err := Diff(
+		&snapshot{Name: "Aspirin", Stock: 1, UpdatedAt: "yesterday"},
+		&snapshot{Name: "Aspirin Forte", Stock: 1, UpdatedAt: "today"},
+	)
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "Aspirin", changes["name"].Before)
+	assert.Equal(t, "Aspirin Forte", changes["name"].After)
+}
+
+func TestDiff_Create(t *testing.T) {
+	var before *snapshot
+	changes, err := Diff(before, &snapshot{Name: "Aspirin", Stock: 2})
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Nil(t, changes["name"].Before)
+	assert.Equal(t, float64(2), changes["stock"].After)
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	changes, err := Diff(&snapshot{Name: "Aspirin"}, &snapshot{Name: "Aspirin"})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestNewEntry_RoundTrip(t *testing.T) {
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 5})
+	changes := map[string]domainHistory.FieldChange{"name": {Before: "Old", After: "New"}}
+
+	entry, err := NewEntry(ctx, 10, domainHistory.ActionUpdate, changes, &snapshot{Name: "New"})
+	require.NoError(t, err)
+	assert.Equal(t, 10, entry.EntityID)
+	assert.Equal(t, "update", entry.Action)
+	assert.Equal(t, 5, *entry.ActorID)
+
+	domainEntry, err := entry.ToDomain()
+	require.NoError(t, err)
+	assert.Equal(t, domainHistory.ActionUpdate, domainEntry.Action)
+	assert.Equal(t, "New", domainEntry.Changes["name"].After)
+
+	var decoded snapshot
+	require.NoError(t, entry.DecodeSnapshot(&decoded))
+	assert.Equal(t, "New", decoded.Name)
+}
+
+func TestNewEntry_WithoutPrincipal(t *testing.T) {
+	entry, err := NewEntry(context.Background(), 1, domainHistory.ActionDelete, nil, &snapshot{})
+	require.NoError(t, err)
+	assert.Nil(t, entry.ActorID)
+	assert.Equal(t, "{}", entry.Changes)
+}
+
+func TestEntry_ToEvent(t *testing.T) {
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 5})
+	changes := map[string]domainHistory.FieldChange{"name": {Before: nil, After: "Aspirin"}}
+	entry, err := NewEntry(ctx, 10, domainHistory.ActionCreate, changes, &snapshot{Name: "Aspirin"})
+	require.NoError(t, err)
+	entry.TenantID = 3
+
+	first := entry.ToEvent(event.AggregateMedicine)
+	assert.Equal(t, event.MedicineCreated, first.Type)
+	assert.Equal(t, 10, first.AggregateID)
+	assert.Equal(t, 3, first.TenantID)
+	assert.Equal(t, 5, *first.ActorID)
+	assert.NotEmpty(t, first.ID)
+	assert.NotEqual(t, first.ID, entry.ToEvent(event.AggregateMedicine).ID)
+
+	var data struct {
+		Changes  map[string]domainHistory.FieldChange `json:"changes"`
+		Snapshot snapshot                             `json:"snapshot"`
+	}
+	require.NoError(t, json.Unmarshal(first.Data, &data))
+	assert.Equal(t, "Aspirin", data.Changes["name"].After)
+	assert.Equal(t, "Aspirin", data.Snapshot.Name)
+}
@@ -0,0 +1,230 @@
+// Package job stores the background job queue. The admin side lists, retries and
+// cancels jobs; the queue side is used by the runner in infrastructure/jobs.
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// JobRepositoryInterface defines the interface for job repository operations
+type JobRepositoryInterface interface {
+	GetAll(ctx context.Context, filters domain.DataFilters) (*domainJob.SearchResultJob, error)
+	GetByID(ctx context.Context, id int64) (*domainJob.Job, error)
+	Retry(ctx context.Context, id int64) (*domainJob.Job, error)
+	Cancel(ctx context.Context, id int64) (*domainJob.Job, error)
+}
+
+// Job is a queued job. Jobs enqueued during a request belong to its organization;
+// those enqueued by background tasks have no tenant.
+type Job struct {
+	ID          int64     `gorm:"primaryKey"`
+	TenantID    int       `gorm:"index"`
+	Queue       string    `gorm:"size:100;index:idx_jobs_due,priority:1"`
+	Type        string    `gorm:"size:100;index"`
+	Payload     string    `gorm:"type:jsonb"`
+	Status      string    `gorm:"size:20;index:idx_jobs_due,priority:2"`
+	Attempts    int       `gorm:"not null;default:0"`
+	MaxAttempts int       `gorm:"not null"`
+	RunAt       time.Time `gorm:"index:idx_jobs_due,priority:3"`
+	LockedUntil *time.Time
+	LastError   string
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy   *int
+	UpdatedBy   *int
+}
+
+func (*Job) TableName() string {
+	return "jobs"
+}
+
+// ColumnsJobMapping maps the filterable job fields to their columns
+var ColumnsJobMapping = map[string]string{
+	"id":         "id",
+	"queue":      "queue",
+	"type":       "type",
+	"status":     "status",
+	"attempts":   "attempts",
+	"runAt":      "run_at",
+	"startedAt":  "started_at",
+	"finishedAt": "finished_at",
+	"createdAt":  "created_at",
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+	now    func() time.Time
+}
+
+func NewJobRepository(db *gorm.DB, loggerInstance *logger.Logger) *Repository {
+	return &Repository{DB: db, Logger: loggerInstance, now: time.Now}
+}
+
+// GetAll lists jobs, newest first unless filters sort otherwise
+func (r *Repository) GetAll(ctx context.Context, filters domain.DataFilters) (*domainJob.SearchResultJob, error) {
+	query := r.DB.WithContext(ctx).Model(&Job{})
+
+	for field, values := range filters.Matches {
+		if column := ColumnsJobMapping[field]; column != "" && len(values) > 0 {
+			query = query.Where(column+" IN ?", values)
+		}
+	}
+	for _, dateFilter := range filters.DateRangeFilters {
+		column := ColumnsJobMapping[dateFilter.Field]
+		if column == "" {
+			continue
+		}
+		if dateFilter.Start != nil {
+			query = query.Where(column+" >= ?", dateFilter.Start)
+		}
+		if dateFilter.End != nil {
+			query = query.Where(column+" <= ?", dateFilter.End)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Logger.Error("Error counting jobs", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	sorted := false
+	if filters.SortDirection.IsValid() {
+		for _, sortField := range filters.SortBy {
+			if column := ColumnsJobMapping[sortField]; column != "" {
+				query = query.Order(column + " " + string(filters.SortDirection))
+				sorted = true
+			}
+		}
+	}
+	if !sorted {
+		query = query.Order("id DESC")
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	var jobs []Job
+	if err := query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize).Find(&jobs).Error; err != nil {
+		r.Logger.Error("Error listing jobs", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	data := make([]domainJob.Job, len(jobs))
+	for i := range jobs {
+		data[i] = *jobs[i].toDomainMapper()
+	}
+	r.Logger.Info("Successfully listed jobs", zap.Int64("total", total))
+	return &domainJob.SearchResultJob{
+		Data:       &data,
+		Total:      total,
+		Page:       filters.Page,
+		PageSize:   filters.PageSize,
+		TotalPages: int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize)),
+	}, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*domainJob.Job, error) {
+	var job Job
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Job not found", zap.Int64("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting job by ID", zap.Error(err), zap.Int64("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return job.toDomainMapper(), nil
+}
+
+// Retry queues a failed or cancelled job to run now with all its attempts
+func (r *Repository) Retry(ctx context.Context, id int64) (*domainJob.Job, error) {
+	return r.transition(ctx, id, []string{domainJob.StatusFailed, domainJob.StatusCancelled}, map[string]any{
+		"status":       domainJob.StatusPending,
+		"attempts":     0,
+		"run_at":       r.now(),
+		"locked_until": nil,
+		"finished_at":  nil,
+	})
+}
+
+// Cancel stops a pending or scheduled job from running. A running job cannot be
+// cancelled: its handler is already at work.
+func (r *Repository) Cancel(ctx context.Context, id int64) (*domainJob.Job, error) {
+	return r.transition(ctx, id, []string{domainJob.StatusPending}, map[string]any{
+		"status":      domainJob.StatusCancelled,
+		"finished_at": r.now(),
+	})
+}
+
+// transition applies updates to the job when its status is one of from, in a single
+// statement so that it cannot race with the runner
+func (r *Repository) transition(ctx context.Context, id int64, from []string, updates map[string]any) (*domainJob.Job, error) {
+	result := r.DB.WithContext(ctx).Model(&Job{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		r.Logger.Error("Error changing job status", zap.Error(result.Error), zap.Int64("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	job, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		r.Logger.Warn("Job status cannot change", zap.Int64("id", id), zap.String("status", job.Status))
+		return nil, domainErrors.NewAppError(fmt.Errorf("job %d is %s", id, job.Status), domainErrors.ValidationError)
+	}
+	r.Logger.Info("Successfully changed job status", zap.Int64("id", id), zap.String("status", job.Status))
+	return job, nil
+}
+
+func fromDomainMapper(j *domainJob.Job) *Job {
+	return &Job{
+		ID:          j.ID,
+		TenantID:    j.TenantID,
+		Queue:       j.Queue,
+		Type:        j.Type,
+		Payload:     j.Payload,
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+	}
+}
+
+func (j *Job) toDomainMapper() *domainJob.Job {
+	return &domainJob.Job{
+		ID:          j.ID,
+		TenantID:    j.TenantID,
+		Queue:       j.Queue,
+		Type:        j.Type,
+		Payload:     j.Payload,
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		LockedUntil: j.LockedUntil,
+		LastError:   j.LastError,
+		StartedAt:   j.StartedAt,
+		FinishedAt:  j.FinishedAt,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+		CreatedBy:   j.CreatedBy,
+		UpdatedBy:   j.UpdatedBy,
+	}
+}
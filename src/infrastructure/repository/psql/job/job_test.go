@@ -0,0 +1,250 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var start = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func setupRepository(t *testing.T) *Repository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&Job{}))
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	repository := NewJobRepository(db, loggerInstance)
+	repository.now = func() time.Time { return start }
+	return repository
+}
+
+func assertAppError(t *testing.T, err error, errorType domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, errorType, appErr.Type)
+}
+
+func enqueue(t *testing.T, repository *Repository, jobType string, runAt time.Time, maxAttempts int) *domainJob.Job {
+	t.Helper()
+	job, err := repository.Enqueue(context.Background(), &domainJob.Job{
+		Queue:       domainJob.DefaultQueue,
+		Type:        jobType,
+		Payload:     `{"id":1}`,
+		Status:      domainJob.StatusPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+	})
+	require.NoError(t, err)
+	return job
+}
+
+func TestRepository_ClaimDue(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+	first := enqueue(t, repository, "report", start.Add(-time.Minute), 3)
+	second := enqueue(t, repository, "report", start, 3)
+	enqueue(t, repository, "report", start.Add(time.Hour), 3)
+	enqueue(t, repository, "import", start, 3)
+
+	claimed, err := repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, start, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, first.ID, claimed[0].ID)
+	assert.Equal(t, second.ID, claimed[1].ID)
+	assert.Equal(t, domainJob.StatusRunning, claimed[0].Status)
+	assert.Equal(t, 1, claimed[0].Attempts)
+
+	stored, err := repository.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusRunning, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	require.NotNil(t, stored.LockedUntil)
+	assert.True(t, stored.LockedUntil.Equal(start.Add(time.Minute)))
+
+	claimed, err = repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, start, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "leased jobs are not claimed twice")
+}
+
+func TestRepository_ClaimDueExpiredLease(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+	retried := enqueue(t, repository, "report", start, 2)
+	lastAttempt := enqueue(t, repository, "report", start, 1)
+	_, err := repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, start, time.Minute, 10)
+	require.NoError(t, err)
+
+	claimed, err := repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, start.Add(time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, retried.ID, claimed[0].ID)
+	assert.Equal(t, 2, claimed[0].Attempts)
+
+	failed, err := repository.GetByID(ctx, lastAttempt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusFailed, failed.Status)
+	assert.NotEmpty(t, failed.LastError)
+	assert.NotNil(t, failed.FinishedAt)
+}
+
+func TestRepository_Complete(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+	job := enqueue(t, repository, "report", start, 3)
+	_, err := repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, start, time.Minute, 1)
+	require.NoError(t, err)
+
+	next := start.Add(time.Minute)
+	require.NoError(t, repository.Complete(ctx, job.ID, 1, domainJob.Result{
+		Status: domainJob.StatusPending, At: start, Error: "timeout", RunAt: next,
+	}))
+	stored, err := repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusPending, stored.Status)
+	assert.Equal(t, "timeout", stored.LastError)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.True(t, stored.RunAt.Equal(next))
+	assert.Nil(t, stored.LockedUntil)
+
+	_, err = repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, next, time.Minute, 1)
+	require.NoError(t, err)
+	require.NoError(t, repository.Complete(ctx, job.ID, 2, domainJob.Result{
+		Status: domainJob.StatusPending, At: next, Error: "interrupted", RunAt: next, Released: true,
+	}))
+	stored, err = repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Attempts, "a released run is not an attempt")
+
+	_, err = repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, next, time.Minute, 1)
+	require.NoError(t, err)
+	require.NoError(t, repository.Complete(ctx, job.ID, 2, domainJob.Result{Status: domainJob.StatusSucceeded, At: next}))
+	stored, err = repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, "interrupted", stored.LastError)
+	require.NotNil(t, stored.FinishedAt)
+
+	require.NoError(t, repository.Complete(ctx, job.ID, 2, domainJob.Result{Status: domainJob.StatusFailed, At: next}))
+	stored, err = repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, stored.Status, "only running jobs are completed")
+}
+
+func TestRepository_CompleteStaleAttempt(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+	job := enqueue(t, repository, "report", start, 3)
+	_, err := repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, start, time.Minute, 1)
+	require.NoError(t, err)
+	// the first runner outlives its lease and another one claims the job again
+	expired := start.Add(time.Minute)
+	claimed, err := repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, expired, time.Minute, 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	require.NoError(t, repository.Complete(ctx, job.ID, 1, domainJob.Result{
+		Status: domainJob.StatusPending, At: expired, Error: "timeout", RunAt: expired.Add(time.Hour),
+	}))
+	stored, err := repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusRunning, stored.Status, "the stale failure does not reschedule the running job")
+	assert.Empty(t, stored.LastError)
+
+	require.NoError(t, repository.Complete(ctx, job.ID, claimed[0].Attempts, domainJob.Result{Status: domainJob.StatusSucceeded, At: expired}))
+	require.NoError(t, repository.Complete(ctx, job.ID, 1, domainJob.Result{Status: domainJob.StatusFailed, At: expired}))
+	stored, err = repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, stored.Status, "the stale result does not overwrite the newer attempt")
+}
+
+func TestRepository_CompleteAfterLeaseExpired(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+	job := enqueue(t, repository, "report", start, 1)
+	_, err := repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, start, time.Minute, 1)
+	require.NoError(t, err)
+	// the handler outlives the lease of its last attempt, which fails the job
+	expired := start.Add(time.Minute)
+	_, err = repository.ClaimDue(ctx, domainJob.DefaultQueue, []string{"report"}, expired, time.Minute, 1)
+	require.NoError(t, err)
+	stored, err := repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, domainJob.StatusFailed, stored.Status)
+	assert.Equal(t, domainJob.LeaseExpired, stored.LastError)
+
+	done := expired.Add(time.Second)
+	require.NoError(t, repository.Complete(ctx, job.ID, 1, domainJob.Result{Status: domainJob.StatusSucceeded, At: done}))
+	stored, err = repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, stored.Status, "the late result of the attempt replaces the expiry")
+	assert.True(t, stored.FinishedAt.Equal(done))
+
+	require.NoError(t, repository.Complete(ctx, job.ID, 1, domainJob.Result{Status: domainJob.StatusFailed, At: done, Error: "again"}))
+	stored, err = repository.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusSucceeded, stored.Status, "other finished jobs are left alone")
+}
+
+func TestRepository_RetryAndCancel(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+	scheduled := enqueue(t, repository, "report", start.Add(time.Hour), 3)
+
+	_, err := repository.Retry(ctx, scheduled.ID)
+	assertAppError(t, err, domainErrors.ValidationError)
+
+	cancelled, err := repository.Cancel(ctx, scheduled.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusCancelled, cancelled.Status)
+	_, err = repository.Cancel(ctx, scheduled.ID)
+	assertAppError(t, err, domainErrors.ValidationError)
+
+	retried, err := repository.Retry(ctx, scheduled.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainJob.StatusPending, retried.Status)
+	assert.Equal(t, 0, retried.Attempts)
+	assert.True(t, retried.RunAt.Equal(start))
+	assert.Nil(t, retried.FinishedAt)
+
+	_, err = repository.Retry(ctx, 999)
+	assertAppError(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_GetAll(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+	for range 3 {
+		enqueue(t, repository, "report", start, 3)
+	}
+	imported := enqueue(t, repository, "import", start, 3)
+	_, err := repository.Cancel(ctx, imported.ID)
+	require.NoError(t, err)
+
+	result, err := repository.GetAll(ctx, domain.DataFilters{Page: 1, PageSize: 2, Matches: map[string][]string{"type": {"report"}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Equal(t, 2, result.TotalPages)
+	require.Len(t, *result.Data, 2)
+	assert.Equal(t, int64(3), (*result.Data)[0].ID, "newest first")
+
+	result, err = repository.GetAll(ctx, domain.DataFilters{Matches: map[string][]string{"status": {domainJob.StatusCancelled}}})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 1)
+	assert.Equal(t, imported.ID, (*result.Data)[0].ID)
+}
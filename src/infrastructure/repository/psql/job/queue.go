@@ -0,0 +1,107 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Enqueue stores a new pending job. Inside a request it belongs to the caller's organization.
+func (r *Repository) Enqueue(ctx context.Context, newJob *domainJob.Job) (*domainJob.Job, error) {
+	job := fromDomainMapper(newJob)
+	if err := r.DB.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job.toDomainMapper(), nil
+}
+
+// ClaimDue reserves up to limit jobs of queue and types that are due at now and marks
+// them running until now+lease, counting an attempt. A running job whose lease expired,
+// because its runner died or is slow, is claimed again, or failed with
+// domainJob.LeaseExpired when that was its last attempt.
+// On Postgres concurrent runners skip the rows another one is claiming.
+func (r *Repository) ClaimDue(ctx context.Context, queue string, types []string, now time.Time, lease time.Duration, limit int) ([]domainJob.Job, error) {
+	var claimed []domainJob.Job
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("queue = ? AND type IN ?", queue, types).
+			Where(tx.Where("status = ? AND run_at <= ?", domainJob.StatusPending, now).
+				Or("status = ? AND locked_until <= ?", domainJob.StatusRunning, now)).
+			Order("run_at, id").
+			Limit(limit)
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		var jobs []Job
+		if err := query.Find(&jobs).Error; err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		lockedUntil := now.Add(lease)
+		var claimedIDs, expiredIDs []int64
+		for _, job := range jobs {
+			if job.Status == domainJob.StatusRunning && job.Attempts >= job.MaxAttempts {
+				expiredIDs = append(expiredIDs, job.ID)
+				continue
+			}
+			job.Status = domainJob.StatusRunning
+			job.Attempts++
+			job.LockedUntil = &lockedUntil
+			job.StartedAt = &now
+			claimedIDs = append(claimedIDs, job.ID)
+			claimed = append(claimed, *job.toDomainMapper())
+		}
+		if len(expiredIDs) > 0 {
+			if err := tx.Model(&Job{}).Where("id IN ?", expiredIDs).UpdateColumns(map[string]any{
+				"status":       domainJob.StatusFailed,
+				"last_error":   domainJob.LeaseExpired,
+				"locked_until": nil,
+				"finished_at":  now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if len(claimedIDs) > 0 {
+			return tx.Model(&Job{}).Where("id IN ?", claimedIDs).UpdateColumns(map[string]any{
+				"status":       domainJob.StatusRunning,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"locked_until": lockedUntil,
+				"started_at":   now,
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// Complete records the result of the given attempt of a claimed job. It is ignored
+// once the job is no longer running that attempt: its lease expired and another
+// runner claimed it again, or the job was finished meanwhile. A job failed because that
+// attempt outlived its lease still takes the result, which the attempt got in the end.
+func (r *Repository) Complete(ctx context.Context, id int64, attempt int, result domainJob.Result) error {
+	updates := map[string]any{
+		"status":       result.Status,
+		"locked_until": nil,
+	}
+	if result.Error != "" {
+		updates["last_error"] = result.Error
+	}
+	if result.Status == domainJob.StatusPending {
+		updates["run_at"] = result.RunAt
+		if result.Released {
+			updates["attempts"] = gorm.Expr("attempts - 1")
+		}
+	} else {
+		updates["finished_at"] = result.At
+	}
+	return r.DB.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND attempts = ?", id, attempt).
+		Where(r.DB.Where("status = ?", domainJob.StatusRunning).
+			Or("status = ? AND last_error = ?", domainJob.StatusFailed, domainJob.LeaseExpired)).
+		UpdateColumns(updates).Error
+}
@@ -0,0 +1,121 @@
+package laboratory
+
+import (
+	"errors"
+	"hash/fnv"
+	"slices"
+
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	"gorm.io/gorm"
+)
+
+// spelling is one laboratory name written in the medicines of an organization
+type spelling struct {
+	TenantID   int
+	Laboratory string
+	Count      int
+}
+
+// groupKey identifies a laboratory within an organization
+type groupKey struct {
+	tenantID int
+	nameKey  string
+}
+
+// laboratoryGroup gathers the spellings of one laboratory within an organization
+type laboratoryGroup struct {
+	groupKey
+	spellings map[string]int
+}
+
+// deduplicateLockKey is the PostgreSQL advisory lock key serializing Deduplicate
+// across instances starting together
+var deduplicateLockKey = func() int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("laboratory:deduplicate"))
+	return int64(hash.Sum64())
+}()
+
+// Link names the laboratory the medicines of an organization that only have one of
+// Names as their laboratory belong to
+type Link struct {
+	TenantID   int
+	Names      []string
+	Laboratory *domainLaboratory.Laboratory
+}
+
+// Deduplicate creates, within the transaction tx, a laboratory per organization for
+// every set of laboratory names of unlinked medicines sharing a key, named after the
+// preferred spelling, and returns the links the medicine repository is to make. It
+// returns none once every medicine is linked.
+// On PostgreSQL it holds an advisory lock until tx ends, so an instance starting
+// alongside another waits for it and then finds nothing left to link.
+func Deduplicate(tx *gorm.DB) ([]Link, error) {
+	if tx.Dialector.Name() == "postgres" {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", deduplicateLockKey).Error; err != nil {
+			return nil, err
+		}
+	}
+	var spellings []spelling
+	err := tx.Table(medicinesTable).
+		Select("tenant_id, laboratory, COUNT(*) AS count").
+		Where("laboratory_id IS NULL AND laboratory <> ''").
+		Group("tenant_id, laboratory").
+		Scan(&spellings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*laboratoryGroup
+	byKey := make(map[groupKey]*laboratoryGroup)
+	for _, found := range spellings {
+		nameKey := domainLaboratory.Key(found.Laboratory)
+		if nameKey == "" {
+			continue
+		}
+		key := groupKey{tenantID: found.TenantID, nameKey: nameKey}
+		group := byKey[key]
+		if group == nil {
+			group = &laboratoryGroup{groupKey: key, spellings: map[string]int{}}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.spellings[found.Laboratory] += found.Count
+	}
+
+	links := make([]Link, 0, len(groups))
+	for _, group := range groups {
+		laboratory, err := ensureLaboratory(tx, group)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(group.spellings))
+		for name := range group.spellings {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		links = append(links, Link{TenantID: group.tenantID, Names: names, Laboratory: laboratory.toDomainMapper()})
+	}
+	return links, nil
+}
+
+// ensureLaboratory returns the laboratory of group, creating it when missing
+func ensureLaboratory(tx *gorm.DB, group *laboratoryGroup) (*Laboratory, error) {
+	var laboratory Laboratory
+	err := tx.Where("tenant_id = ? AND name_key = ?", group.tenantID, group.nameKey).First(&laboratory).Error
+	if err == nil {
+		return &laboratory, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	laboratory = Laboratory{
+		TenantID: group.tenantID,
+		Name:     domainLaboratory.CleanName(domainLaboratory.PreferredName(group.spellings)),
+		NameKey:  group.nameKey,
+	}
+	if err := tx.Create(&laboratory).Error; err != nil {
+		return nil, err
+	}
+	return &laboratory, nil
+}
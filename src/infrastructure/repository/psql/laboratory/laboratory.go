@@ -0,0 +1,325 @@
+package laboratory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// LaboratoryRepositoryInterface defines the interface for laboratory repository operations
+type LaboratoryRepositoryInterface interface {
+	GetAll(ctx context.Context) (*[]domainLaboratory.Laboratory, error)
+	GetByID(ctx context.Context, id int) (*domainLaboratory.Laboratory, error)
+	GetByName(ctx context.Context, name string) (*domainLaboratory.Laboratory, error)
+	Create(ctx context.Context, laboratory *domainLaboratory.Laboratory) (*domainLaboratory.Laboratory, error)
+	Update(ctx context.Context, id int, laboratoryMap map[string]any) (*domainLaboratory.Laboratory, error)
+	Delete(ctx context.Context, id int) error
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainLaboratory.SearchResultLaboratory, error)
+}
+
+// Laboratory names are unique per organization by their key, see domainLaboratory.Key
+type Laboratory struct {
+	ID        int       `gorm:"primaryKey"`
+	TenantID  int       `gorm:"uniqueIndex:idx_laboratories_tenant_name_key,priority:1"`
+	Name      string    `gorm:"size:255"`
+	NameKey   string    `gorm:"uniqueIndex:idx_laboratories_tenant_name_key,priority:2;size:255"`
+	CreatedAt time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy *int      `gorm:"index"`
+	UpdatedBy *int      `gorm:"index"`
+}
+
+func (*Laboratory) TableName() string {
+	return "laboratories"
+}
+
+// medicinesTable holds the medicines linked to laboratories by laboratory_id, which
+// Delete checks and unlinks
+const medicinesTable = "medicines"
+
+var ColumnsLaboratoryMapping = map[string]string{
+	"id":        "id",
+	"tenantId":  "tenant_id",
+	"name":      "name",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	"createdBy": "created_by",
+	"updatedBy": "updated_by",
+}
+
+// errLaboratoryInUse aborts the deletion of a laboratory live medicines are linked to
+var errLaboratoryInUse = errors.New("laboratory has medicines; link them to another laboratory first")
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewLaboratoryRepository(db *gorm.DB, loggerInstance *logger.Logger) LaboratoryRepositoryInterface {
+	return &Repository{DB: db, Logger: loggerInstance}
+}
+
+func (r *Repository) GetAll(ctx context.Context) (*[]domainLaboratory.Laboratory, error) {
+	var laboratories []Laboratory
+	if err := r.DB.WithContext(ctx).Order("name").Find(&laboratories).Error; err != nil {
+		r.Logger.Error("Error getting all laboratories", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved all laboratories", zap.Int("count", len(laboratories)))
+	return arrayToDomainMapper(&laboratories), nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainLaboratory.Laboratory, error) {
+	return r.get(ctx, "id = ?", id)
+}
+
+// GetByName returns the laboratory known by any spelling of name
+func (r *Repository) GetByName(ctx context.Context, name string) (*domainLaboratory.Laboratory, error) {
+	return r.get(ctx, "name_key = ?", domainLaboratory.Key(name))
+}
+
+func (r *Repository) get(ctx context.Context, query string, value any) (*domainLaboratory.Laboratory, error) {
+	var laboratory Laboratory
+	if err := r.DB.WithContext(ctx).Where(query, value).First(&laboratory).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Logger.Warn("Laboratory not found", zap.Any("key", value))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting laboratory", zap.Error(err), zap.Any("key", value))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return laboratory.toDomainMapper(), nil
+}
+
+func (r *Repository) Create(ctx context.Context, newLaboratory *domainLaboratory.Laboratory) (*domainLaboratory.Laboratory, error) {
+	laboratory := &Laboratory{
+		TenantID: newLaboratory.TenantID,
+		Name:     newLaboratory.Name,
+		NameKey:  domainLaboratory.Key(newLaboratory.Name),
+	}
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkNameKey(tx, 0, laboratory.NameKey); err != nil {
+			return err
+		}
+		return tx.Create(laboratory).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error creating laboratory", zap.Error(err), zap.String("name", newLaboratory.Name))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully created laboratory", zap.String("name", laboratory.Name), zap.Int("id", laboratory.ID))
+	return laboratory.toDomainMapper(), nil
+}
+
+// Update renames a laboratory alone. Renaming it along with its medicines, so their
+// cache, history and events follow, is up to the medicine repository, see Rename.
+func (r *Repository) Update(ctx context.Context, id int, laboratoryMap map[string]any) (*domainLaboratory.Laboratory, error) {
+	var laboratory *domainLaboratory.Laboratory
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		name, ok := laboratoryMap["name"].(string)
+		if ok {
+			var err error
+			laboratory, err = Rename(tx, id, name)
+			return err
+		}
+		var found Laboratory
+		if err := tx.Where("id = ?", id).First(&found).Error; err != nil {
+			return err
+		}
+		laboratory = found.toDomainMapper()
+		return nil
+	})
+	if err != nil {
+		r.Logger.Error("Error updating laboratory", zap.Error(err), zap.Int("id", id))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully updated laboratory", zap.Int("id", id))
+	return laboratory, nil
+}
+
+// Rename gives the laboratory with the given ID a new name within the transaction tx,
+// unless another laboratory already goes by it. The medicine repository calls it to
+// rename a laboratory and its medicines at once.
+func Rename(tx *gorm.DB, id int, name string) (*domainLaboratory.Laboratory, error) {
+	var laboratory Laboratory
+	if err := tx.Where("id = ?", id).First(&laboratory).Error; err != nil {
+		return nil, err
+	}
+	nameKey := domainLaboratory.Key(name)
+	if err := checkNameKey(tx, id, nameKey); err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&laboratory).Updates(map[string]any{"name": name, "name_key": nameKey}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("id = ?", id).First(&laboratory).Error; err != nil {
+		return nil, err
+	}
+	return laboratory.toDomainMapper(), nil
+}
+
+// Delete removes a laboratory no live medicine is linked to. Deleted medicines linked
+// to it are unlinked and keep its name.
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var laboratory Laboratory
+		if err := tx.Where("id = ?", id).First(&laboratory).Error; err != nil {
+			return err
+		}
+		var linked int64
+		if err := tx.Table(medicinesTable).Where("laboratory_id = ? AND deleted_at IS NULL", id).Count(&linked).Error; err != nil {
+			return err
+		}
+		if linked > 0 {
+			return domainErrors.NewAppError(errLaboratoryInUse, domainErrors.ValidationError)
+		}
+		if err := tx.Table(medicinesTable).Where("laboratory_id = ?", id).UpdateColumn("laboratory_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&laboratory).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error deleting laboratory", zap.Error(err), zap.Int("id", id))
+		return writeError(err)
+	}
+	r.Logger.Info("Successfully deleted laboratory", zap.Int("id", id))
+	return nil
+}
+
+// SearchPaginated lists laboratories ordered by name unless filters sort otherwise
+func (r *Repository) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainLaboratory.SearchResultLaboratory, error) {
+	query := r.DB.WithContext(ctx).Model(&Laboratory{})
+
+	for field, values := range filters.LikeFilters {
+		column := ColumnsLaboratoryMapping[field]
+		if column == "" {
+			continue
+		}
+		for _, value := range values {
+			if value != "" {
+				query = query.Where(column+" "+likeOperator(query)+" ?", "%"+value+"%")
+			}
+		}
+	}
+	for field, values := range filters.Matches {
+		if column := ColumnsLaboratoryMapping[field]; column != "" && len(values) > 0 {
+			query = query.Where(column+" IN ?", values)
+		}
+	}
+	for _, dateFilter := range filters.DateRangeFilters {
+		column := ColumnsLaboratoryMapping[dateFilter.Field]
+		if column == "" {
+			continue
+		}
+		if dateFilter.Start != nil {
+			query = query.Where(column+" >= ?", dateFilter.Start)
+		}
+		if dateFilter.End != nil {
+			query = query.Where(column+" <= ?", dateFilter.End)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Logger.Error("Error counting laboratories", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	sorted := false
+	if filters.SortDirection.IsValid() {
+		for _, sortField := range filters.SortBy {
+			if column := ColumnsLaboratoryMapping[sortField]; column != "" {
+				query = query.Order(column + " " + string(filters.SortDirection))
+				sorted = true
+			}
+		}
+	}
+	if !sorted {
+		query = query.Order("name")
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	var laboratories []Laboratory
+	if err := query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize).Find(&laboratories).Error; err != nil {
+		r.Logger.Error("Error searching laboratories", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	r.Logger.Info("Successfully searched laboratories", zap.Int64("total", total), zap.Int("page", filters.Page))
+	return &domainLaboratory.SearchResultLaboratory{
+		Data:       arrayToDomainMapper(&laboratories),
+		Total:      total,
+		Page:       filters.Page,
+		PageSize:   filters.PageSize,
+		TotalPages: int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize)),
+	}, nil
+}
+
+// checkNameKey fails when a laboratory other than the one with the given ID already
+// goes by nameKey
+func checkNameKey(tx *gorm.DB, id int, nameKey string) error {
+	var count int64
+	if err := tx.Model(&Laboratory{}).Where("name_key = ? AND id <> ?", nameKey, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	return nil
+}
+
+// writeError translates a failed write into the matching application error
+func writeError(err error) error {
+	var appErr *domainErrors.AppError
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+}
+
+// likeOperator returns the case-insensitive LIKE operator of the database behind db.
+// SQLite has no ILIKE, but its LIKE already ignores case for ASCII text.
+func likeOperator(db *gorm.DB) string {
+	if db.Dialector.Name() == "sqlite" {
+		return "LIKE"
+	}
+	return "ILIKE"
+}
+
+// Mappers
+func (l *Laboratory) toDomainMapper() *domainLaboratory.Laboratory {
+	return &domainLaboratory.Laboratory{
+		ID:        l.ID,
+		TenantID:  l.TenantID,
+		Name:      l.Name,
+		CreatedAt: l.CreatedAt,
+		UpdatedAt: l.UpdatedAt,
+		CreatedBy: l.CreatedBy,
+		UpdatedBy: l.UpdatedBy,
+	}
+}
+
+func arrayToDomainMapper(laboratories *[]Laboratory) *[]domainLaboratory.Laboratory {
+	result := make([]domainLaboratory.Laboratory, len(*laboratories))
+	for i := range *laboratories {
+		result[i] = *(*laboratories)[i].toDomainMapper()
+	}
+	return &result
+}
@@ -0,0 +1,122 @@
+package laboratory
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupRepository(t *testing.T) (LaboratoryRepositoryInterface, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	require.NoError(t, err)
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewLaboratoryRepository(gormDB, loggerInstance), mock
+}
+
+func TestTableName(t *testing.T) {
+	assert.Equal(t, "laboratories", (&Laboratory{}).TableName())
+}
+
+func TestRepository_GetByName(t *testing.T) {
+	repo, mock := setupRepository(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "laboratories" WHERE name_key = $1`)).
+		WithArgs("pfizer", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "name_key"}).AddRow(4, "Pfizer", "pfizer"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "laboratories" WHERE name_key = $1`)).
+		WithArgs("roche", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	found, err := repo.GetByName(context.Background(), "PFIZER Inc.")
+	require.NoError(t, err)
+	assert.Equal(t, 4, found.ID)
+	assert.Equal(t, "Pfizer", found.Name)
+
+	_, err = repo.GetByName(context.Background(), "Roche")
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create(t *testing.T) {
+	repo, mock := setupRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "laboratories" WHERE name_key = $1 AND id <> $2`)).
+		WithArgs("bayer", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "laboratories"`)).
+		WithArgs(0, "Bayer AG", "bayer", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "laboratories" WHERE name_key = $1 AND id <> $2`)).
+		WithArgs("bayer", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	created, err := repo.Create(context.Background(), &domainLaboratory.Laboratory{Name: "Bayer AG"})
+	require.NoError(t, err)
+	assert.Equal(t, 7, created.ID)
+
+	_, err = repo.Create(context.Background(), &domainLaboratory.Laboratory{Name: "BAYER"})
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ResourceAlreadyExists, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete_InUse(t *testing.T) {
+	repo, mock := setupRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "laboratories" WHERE id = $1`)).
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "Pfizer"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "medicines" WHERE laboratory_id = $1 AND deleted_at IS NULL`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectRollback()
+
+	err := repo.Delete(context.Background(), 4)
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeduplicate_HoldsAdvisoryLock(t *testing.T) {
+	repo, mock := setupRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WithArgs(deduplicateLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT tenant_id, laboratory, COUNT(*) AS count FROM "medicines"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "laboratory", "count"}))
+	mock.ExpectCommit()
+
+	var links []Link
+	err := repo.(*Repository).DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		links, err = Deduplicate(tx)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Empty(t, links)
+	assert.NoError(t, mock.ExpectationsWereMet(), "the lock is taken before the medicines are read")
+}
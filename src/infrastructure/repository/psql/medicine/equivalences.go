@@ -0,0 +1,138 @@
+package medicine
+
+import (
+	"context"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Equivalence marks a medicine as a substitute of another, or excludes it; a medicine
+// has one equivalence per substitute
+type Equivalence struct {
+	ID           int       `gorm:"primaryKey"`
+	TenantID     int       `gorm:"uniqueIndex:idx_medicine_equivalences_pair,priority:1"`
+	MedicineID   int       `gorm:"uniqueIndex:idx_medicine_equivalences_pair,priority:2"`
+	SubstituteID int       `gorm:"uniqueIndex:idx_medicine_equivalences_pair,priority:3;index;check:chk_medicine_equivalences_distinct,medicine_id <> substitute_id"`
+	Kind         string    `gorm:"size:16"`
+	Note         string    `gorm:"size:255"`
+	CreatedAt    time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy    *int      `gorm:"index"`
+	UpdatedBy    *int      `gorm:"index"`
+	// Medicine and Substitute only declare the foreign keys and are never loaded
+	Medicine   *Medicine `gorm:"foreignKey:MedicineID;constraint:OnDelete:CASCADE"`
+	Substitute *Medicine `gorm:"foreignKey:SubstituteID;constraint:OnDelete:CASCADE"`
+}
+
+func (*Equivalence) TableName() string {
+	return "medicine_equivalences"
+}
+
+// GetSubstituteCandidates returns, with their stock quantities, the live medicines
+// other than medicine with ids and those of its dosage form containing its first
+// active ingredient, which holds every product equivalent to it
+func (r *Repository) GetSubstituteCandidates(ctx context.Context, medicine *domainMedicine.Medicine, ids []int) (*[]domainMedicine.Medicine, error) {
+	candidates := []Medicine{}
+	db := r.readDB(ctx)
+	query := db.Model(&Medicine{}).Where("medicines.id <> ?", medicine.ID)
+	switch {
+	case medicine.DosageForm != "" && len(medicine.Ingredients) > 0:
+		sameForm := db.Where("dosage_form = ? AND medicines.id IN (SELECT medicine_id FROM medicine_ingredients WHERE ingredient_id = ?)",
+			medicine.DosageForm, medicine.Ingredients[0].ID)
+		if len(ids) > 0 {
+			sameForm = sameForm.Or("medicines.id IN ?", ids)
+		}
+		query = query.Where(sameForm)
+	case len(ids) > 0:
+		query = query.Where("medicines.id IN ?", ids)
+	default:
+		return arrayToDomainMapper(&candidates), nil
+	}
+	err := query.Select("medicines.*", stockQuantityColumn+" AS stock_quantity").Order("medicines.id").Find(&candidates).Error
+	if err == nil {
+		err = loadPageIngredients(db, candidates)
+	}
+	if err != nil {
+		r.Logger.Error("Error getting substitute candidates", zap.Error(err), zap.Int("id", medicine.ID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved substitute candidates", zap.Int("id", medicine.ID), zap.Int("count", len(candidates)))
+	return arrayToDomainMapper(&candidates), nil
+}
+
+// GetEquivalences returns the equivalences of a medicine ordered by substitute
+func (r *Repository) GetEquivalences(ctx context.Context, medicineID int) (*[]domainMedicine.Equivalence, error) {
+	var equivalences []Equivalence
+	if err := r.DB.WithContext(ctx).Where("medicine_id = ?", medicineID).Order("substitute_id").Find(&equivalences).Error; err != nil {
+		r.Logger.Error("Error getting medicine equivalences", zap.Error(err), zap.Int("medicineId", medicineID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainMedicine.Equivalence, len(equivalences))
+	for i := range equivalences {
+		result[i] = *equivalences[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+// SaveEquivalence creates the equivalence of a medicine and a substitute or replaces
+// its kind and note
+func (r *Repository) SaveEquivalence(ctx context.Context, equivalence *domainMedicine.Equivalence) (*domainMedicine.Equivalence, error) {
+	row := &Equivalence{
+		TenantID:     equivalence.TenantID,
+		MedicineID:   equivalence.MedicineID,
+		SubstituteID: equivalence.SubstituteID,
+		Kind:         string(equivalence.Kind),
+		Note:         equivalence.Note,
+	}
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "medicine_id"}, {Name: "substitute_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"kind", "note", "updated_at", "updated_by"}),
+		}).Create(row).Error; err != nil {
+			return err
+		}
+		return tx.Where("medicine_id = ? AND substitute_id = ?", equivalence.MedicineID, equivalence.SubstituteID).First(row).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error saving medicine equivalence", zap.Error(err), zap.Int("medicineId", equivalence.MedicineID))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully saved medicine equivalence", zap.Int("id", row.ID), zap.Int("medicineId", row.MedicineID), zap.Int("substituteId", row.SubstituteID))
+	return row.toDomainMapper(), nil
+}
+
+// DeleteEquivalence removes an equivalence, so its substitute is ranked by its
+// composition again
+func (r *Repository) DeleteEquivalence(ctx context.Context, id int) error {
+	result := r.DB.WithContext(ctx).Delete(&Equivalence{}, id)
+	if result.Error != nil {
+		r.Logger.Error("Error deleting medicine equivalence", zap.Error(result.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if result.RowsAffected == 0 {
+		r.Logger.Warn("Medicine equivalence not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted medicine equivalence", zap.Int("id", id))
+	return nil
+}
+
+func (e *Equivalence) toDomainMapper() *domainMedicine.Equivalence {
+	return &domainMedicine.Equivalence{
+		ID:           e.ID,
+		TenantID:     e.TenantID,
+		MedicineID:   e.MedicineID,
+		SubstituteID: e.SubstituteID,
+		Kind:         domainMedicine.EquivalenceKind(e.Kind),
+		Note:         e.Note,
+		CreatedAt:    e.CreatedAt,
+		UpdatedAt:    e.UpdatedAt,
+		CreatedBy:    e.CreatedBy,
+		UpdatedBy:    e.UpdatedBy,
+	}
+}
@@ -0,0 +1,199 @@
+package medicine
+
+import (
+	"context"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/history"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/outbox"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MedicineHistory stores every recorded change of a medicine
+type MedicineHistory struct {
+	history.Entry
+}
+
+func (*MedicineHistory) TableName() string {
+	return "medicine_history"
+}
+
+// ingredientSnapshot is the serialized state of an active ingredient of a medicine
+type ingredientSnapshot struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Strength float64 `json:"strength"`
+	Unit     string  `json:"unit"`
+}
+
+// medicineSnapshot is the serialized state of a medicine kept in its history
+type medicineSnapshot struct {
+	ID           int    `json:"id"`
+	TenantID     int    `json:"tenantId"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	EanCode      string `json:"eanCode"`
+	LaboratoryID *int   `json:"laboratoryId"`
+	Laboratory   string `json:"laboratory"`
+	// Ingredients is never nil, so snapshots taken with and without ingredients loaded
+	// compare equal when the medicine has none
+	Ingredients []ingredientSnapshot        `json:"ingredients"`
+	DosageForm  string                      `json:"dosageForm"`
+	Route       string                      `json:"route"`
+	PackageSize *domainMedicine.PackageSize `json:"packageSize"`
+	Price       *float64                    `json:"price"`
+	CreatedAt   time.Time                   `json:"createdAt"`
+	UpdatedAt   time.Time                   `json:"updatedAt"`
+	DeletedAt   *time.Time                  `json:"deletedAt"`
+	CreatedBy   *int                        `json:"createdBy"`
+	UpdatedBy   *int                        `json:"updatedBy"`
+}
+
+func (m *Medicine) toSnapshot() *medicineSnapshot {
+	return &medicineSnapshot{
+		ID:           m.ID,
+		TenantID:     m.TenantID,
+		Name:         m.Name,
+		Description:  m.Description,
+		EanCode:      m.EANCode,
+		LaboratoryID: m.LaboratoryID,
+		Laboratory:   m.Laboratory,
+		Ingredients:  ingredientSnapshots(m.domainIngredients()),
+		DosageForm:   m.DosageForm,
+		Route:        m.Route,
+		PackageSize:  m.packageSize(),
+		Price:        m.Price,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+		DeletedAt:    deletedAtToDomain(m.DeletedAt),
+		CreatedBy:    m.CreatedBy,
+		UpdatedBy:    m.UpdatedBy,
+	}
+}
+
+func (s *medicineSnapshot) toDomainMapper() *domainMedicine.Medicine {
+	return &domainMedicine.Medicine{
+		ID:           s.ID,
+		TenantID:     s.TenantID,
+		Name:         s.Name,
+		Description:  s.Description,
+		EanCode:      s.EanCode,
+		LaboratoryID: s.LaboratoryID,
+		Laboratory:   s.Laboratory,
+		Ingredients:  s.domainIngredients(),
+		DosageForm:   s.DosageForm,
+		Route:        s.Route,
+		PackageSize:  s.PackageSize,
+		Price:        s.Price,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+		DeletedAt:    s.DeletedAt,
+		CreatedBy:    s.CreatedBy,
+		UpdatedBy:    s.UpdatedBy,
+	}
+}
+
+func ingredientSnapshots(ingredients []domainMedicine.Ingredient) []ingredientSnapshot {
+	snapshots := make([]ingredientSnapshot, len(ingredients))
+	for i, ingredient := range ingredients {
+		snapshots[i] = ingredientSnapshot(ingredient)
+	}
+	return snapshots
+}
+
+func (s *medicineSnapshot) domainIngredients() []domainMedicine.Ingredient {
+	ingredients := make([]domainMedicine.Ingredient, len(s.Ingredients))
+	for i, ingredient := range s.Ingredients {
+		ingredients[i] = domainMedicine.Ingredient(ingredient)
+	}
+	return ingredients
+}
+
+// recordHistory stores the change between before and after, together with the event
+// announcing it; updates without changes are skipped
+func recordHistory(ctx context.Context, tx *gorm.DB, action domainHistory.Action, before, after *Medicine) error {
+	var beforeSnapshot, afterSnapshot *medicineSnapshot
+	entityID := 0
+	if before != nil {
+		beforeSnapshot = before.toSnapshot()
+		entityID = before.ID
+	}
+	if after != nil {
+		afterSnapshot = after.toSnapshot()
+		entityID = after.ID
+	}
+	changes, err := history.Diff(beforeSnapshot, afterSnapshot)
+	if err != nil {
+		return err
+	}
+	if action == domainHistory.ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+	snapshot := afterSnapshot
+	if snapshot == nil {
+		snapshot = beforeSnapshot
+	}
+	entry, err := history.NewEntry(ctx, entityID, action, changes, snapshot)
+	if err != nil {
+		return err
+	}
+	entry.TenantID = snapshot.TenantID
+	row := &MedicineHistory{Entry: *entry}
+	if err := tx.Create(row).Error; err != nil {
+		return err
+	}
+	return outbox.Enqueue(tx, row.ToEvent(event.AggregateMedicine))
+}
+
+// GetHistory returns every recorded change of a medicine, oldest first
+func (r *Repository) GetHistory(ctx context.Context, id int) (*[]domainHistory.Entry, error) {
+	var rows []MedicineHistory
+	if err := r.DB.WithContext(ctx).Where("entity_id = ?", id).Order("created_at, id").Find(&rows).Error; err != nil {
+		r.Logger.Error("Error getting medicine history", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if len(rows) == 0 {
+		r.Logger.Warn("Medicine history not found", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	entries := make([]domainHistory.Entry, len(rows))
+	for i, row := range rows {
+		entry, err := row.ToDomain()
+		if err != nil {
+			r.Logger.Error("Error decoding medicine history", zap.Error(err), zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		entries[i] = *entry
+	}
+	r.Logger.Info("Successfully retrieved medicine history", zap.Int("id", id), zap.Int("count", len(entries)))
+	return &entries, nil
+}
+
+// GetAsOf rebuilds a medicine as it was at the given time from its history
+func (r *Repository) GetAsOf(ctx context.Context, id int, at time.Time) (*domainMedicine.Medicine, error) {
+	var row MedicineHistory
+	err := r.DB.WithContext(ctx).
+		Where("entity_id = ? AND created_at <= ?", id, at).
+		Order("created_at DESC, id DESC").
+		First(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Medicine not found at requested time", zap.Int("id", id), zap.Time("asOf", at))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting medicine as of time", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	var snapshot medicineSnapshot
+	if err := row.DecodeSnapshot(&snapshot); err != nil {
+		r.Logger.Error("Error decoding medicine snapshot", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved medicine as of time", zap.Int("id", id), zap.Time("asOf", at))
+	return snapshot.toDomainMapper(), nil
+}
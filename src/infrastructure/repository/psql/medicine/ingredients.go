@@ -0,0 +1,155 @@
+package medicine
+
+import (
+	"errors"
+	"time"
+
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IngredientField is the field listings filter medicines by the names of their active
+// ingredients with, as in ingredient_like=ibupro or ingredient_match=Ibuprofen
+const IngredientField = "ingredient"
+
+// ActiveIngredient is a compound medicines contain. Its names are unique per
+// organization by domainMedicine.IngredientKey; the first spelling used is kept.
+type ActiveIngredient struct {
+	ID        int       `gorm:"primaryKey"`
+	TenantID  int       `gorm:"uniqueIndex:idx_active_ingredients_tenant_name_key,priority:1"`
+	Name      string    `gorm:"size:255"`
+	NameKey   string    `gorm:"uniqueIndex:idx_active_ingredients_tenant_name_key,priority:2;size:255"`
+	CreatedAt time.Time `gorm:"autoCreateTime:milli"`
+}
+
+func (*ActiveIngredient) TableName() string {
+	return "active_ingredients"
+}
+
+// MedicineIngredient links a medicine to one of its active ingredients with its
+// strength; Position keeps the order the ingredients were listed in
+type MedicineIngredient struct {
+	MedicineID   int `gorm:"primaryKey;autoIncrement:false"`
+	IngredientID int `gorm:"primaryKey;autoIncrement:false;index"`
+	Position     int
+	Strength     float64
+	Unit         string `gorm:"size:16"`
+	// Name is only read, from the active ingredient
+	Name string `gorm:"->;-:migration"`
+	// Medicine and Ingredient only declare the foreign keys and are never loaded
+	Medicine   *Medicine         `gorm:"foreignKey:MedicineID;constraint:OnDelete:CASCADE"`
+	Ingredient *ActiveIngredient `gorm:"foreignKey:IngredientID;constraint:OnDelete:RESTRICT"`
+}
+
+func (*MedicineIngredient) TableName() string {
+	return "medicine_ingredients"
+}
+
+// ingredientMedicines selects the IDs of the medicines containing an active ingredient
+// meeting the condition appended to it
+const ingredientMedicines = "medicines.id IN (SELECT medicine_ingredients.medicine_id FROM medicine_ingredients" +
+	" JOIN active_ingredients ON active_ingredients.id = medicine_ingredients.ingredient_id WHERE "
+
+// loadIngredients sets the ingredients of medicines, in the order they were listed
+func loadIngredients(db *gorm.DB, medicines ...*Medicine) error {
+	if len(medicines) == 0 {
+		return nil
+	}
+	byID := make(map[int]*Medicine, len(medicines))
+	ids := make([]int, len(medicines))
+	for i, medicine := range medicines {
+		medicine.Ingredients = []MedicineIngredient{}
+		byID[medicine.ID] = medicine
+		ids[i] = medicine.ID
+	}
+	var rows []MedicineIngredient
+	err := db.Model(&MedicineIngredient{}).
+		Select("medicine_ingredients.*, active_ingredients.name AS name").
+		Joins("JOIN active_ingredients ON active_ingredients.id = medicine_ingredients.ingredient_id").
+		Where("medicine_ingredients.medicine_id IN ?", ids).
+		Order("medicine_ingredients.medicine_id, medicine_ingredients.position").
+		Find(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if medicine := byID[row.MedicineID]; medicine != nil {
+			medicine.Ingredients = append(medicine.Ingredients, row)
+		}
+	}
+	return nil
+}
+
+// loadPageIngredients sets the ingredients of every medicine in medicines
+func loadPageIngredients(db *gorm.DB, medicines []Medicine) error {
+	pointers := make([]*Medicine, len(medicines))
+	for i := range medicines {
+		pointers[i] = &medicines[i]
+	}
+	return loadIngredients(db, pointers...)
+}
+
+// replaceIngredients makes ingredients the active ingredients of medicine, registering
+// the compounds its organization does not know yet
+func replaceIngredients(tx *gorm.DB, medicine *Medicine, ingredients []domainMedicine.Ingredient) error {
+	if err := tx.Where("medicine_id = ?", medicine.ID).Delete(&MedicineIngredient{}).Error; err != nil {
+		return err
+	}
+	medicine.Ingredients = make([]MedicineIngredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		active, err := ensureIngredient(tx, medicine.TenantID, ingredient.Name)
+		if err != nil {
+			return err
+		}
+		medicine.Ingredients[i] = MedicineIngredient{
+			MedicineID:   medicine.ID,
+			IngredientID: active.ID,
+			Position:     i,
+			Strength:     ingredient.Strength,
+			Unit:         ingredient.Unit,
+		}
+		if err := tx.Omit(clause.Associations).Create(&medicine.Ingredients[i]).Error; err != nil {
+			return err
+		}
+		medicine.Ingredients[i].Name = active.Name
+	}
+	return nil
+}
+
+// ensureIngredient returns the active ingredient of the organization known by name,
+// creating it when missing
+func ensureIngredient(tx *gorm.DB, tenantID int, name string) (*ActiveIngredient, error) {
+	var active ActiveIngredient
+	nameKey := domainMedicine.IngredientKey(name)
+	err := tx.Where("tenant_id = ? AND name_key = ?", tenantID, nameKey).First(&active).Error
+	if err == nil {
+		return &active, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	active = ActiveIngredient{TenantID: tenantID, Name: name, NameKey: nameKey}
+	if err := tx.Create(&active).Error; err != nil {
+		return nil, err
+	}
+	return &active, nil
+}
+
+// ingredientKeys returns the keys of the ingredient names matched by a listing
+func ingredientKeys(names []string) []string {
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = domainMedicine.IngredientKey(name)
+	}
+	return keys
+}
+
+func (i *MedicineIngredient) toDomainMapper() domainMedicine.Ingredient {
+	return domainMedicine.Ingredient{
+		ID:       i.IngredientID,
+		Name:     i.Name,
+		Strength: i.Strength,
+		Unit:     i.Unit,
+	}
+}
@@ -0,0 +1,183 @@
+package medicine
+
+import (
+	"context"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// Interaction is an entry of the interaction knowledge base. Its subjects are keyed by
+// domainMedicine.IngredientKey rather than linked to active ingredients, so it can
+// name compounds no medicine of the organization contains yet.
+type Interaction struct {
+	ID          int       `gorm:"primaryKey"`
+	TenantID    int       `gorm:"uniqueIndex:idx_medicine_interactions_pair,priority:1"`
+	FirstClass  bool      `gorm:"uniqueIndex:idx_medicine_interactions_pair,priority:2"`
+	FirstKey    string    `gorm:"uniqueIndex:idx_medicine_interactions_pair,priority:3;size:255"`
+	SecondClass bool      `gorm:"uniqueIndex:idx_medicine_interactions_pair,priority:4"`
+	SecondKey   string    `gorm:"uniqueIndex:idx_medicine_interactions_pair,priority:5;index;size:255"`
+	FirstName   string    `gorm:"size:255"`
+	SecondName  string    `gorm:"size:255"`
+	Severity    string    `gorm:"size:16"`
+	Description string    `gorm:"size:1000"`
+	CreatedAt   time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy   *int      `gorm:"index"`
+	UpdatedBy   *int      `gorm:"index"`
+}
+
+func (*Interaction) TableName() string {
+	return "medicine_interactions"
+}
+
+// IngredientClass makes an active ingredient, by its key, a member of a class
+type IngredientClass struct {
+	ID            int       `gorm:"primaryKey"`
+	TenantID      int       `gorm:"uniqueIndex:idx_ingredient_classes_member,priority:1"`
+	ClassKey      string    `gorm:"uniqueIndex:idx_ingredient_classes_member,priority:2;size:255"`
+	IngredientKey string    `gorm:"uniqueIndex:idx_ingredient_classes_member,priority:3;index;size:255"`
+	Class         string    `gorm:"size:255"`
+	Ingredient    string    `gorm:"size:255"`
+	CreatedAt     time.Time `gorm:"autoCreateTime:milli"`
+	CreatedBy     *int      `gorm:"index"`
+}
+
+func (*IngredientClass) TableName() string {
+	return "ingredient_classes"
+}
+
+// importBatchSize is how many knowledge base rows are written per statement
+const importBatchSize = 500
+
+// SaveInteractions adds interactions to the knowledge base, replacing the severity and
+// description of the pairs it already has, and returns how many were saved. A pair
+// must not be repeated in interactions.
+func (r *Repository) SaveInteractions(ctx context.Context, interactions []domainMedicine.Interaction) (int, error) {
+	if len(interactions) == 0 {
+		return 0, nil
+	}
+	rows := make([]Interaction, len(interactions))
+	for i := range interactions {
+		rows[i] = *interactionFromDomainMapper(&interactions[i])
+	}
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "tenant_id"}, {Name: "first_class"}, {Name: "first_key"}, {Name: "second_class"}, {Name: "second_key"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"first_name", "second_name", "severity", "description", "updated_at", "updated_by"}),
+	}).CreateInBatches(&rows, importBatchSize).Error
+	if err != nil {
+		r.Logger.Error("Error saving medicine interactions", zap.Error(err), zap.Int("count", len(interactions)))
+		return 0, writeError(err)
+	}
+	r.Logger.Info("Successfully saved medicine interactions", zap.Int("count", len(interactions)))
+	return len(interactions), nil
+}
+
+// SaveIngredientClasses adds ingredients to classes, skipping the memberships already
+// known, and returns how many were given
+func (r *Repository) SaveIngredientClasses(ctx context.Context, classes []domainMedicine.IngredientClass) (int, error) {
+	if len(classes) == 0 {
+		return 0, nil
+	}
+	rows := make([]IngredientClass, len(classes))
+	for i, class := range classes {
+		rows[i] = IngredientClass{
+			ClassKey:      domainMedicine.IngredientKey(class.Class),
+			IngredientKey: domainMedicine.IngredientKey(class.Ingredient),
+			Class:         class.Class,
+			Ingredient:    class.Ingredient,
+		}
+	}
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, importBatchSize).Error
+	if err != nil {
+		r.Logger.Error("Error saving ingredient classes", zap.Error(err), zap.Int("count", len(classes)))
+		return 0, writeError(err)
+	}
+	r.Logger.Info("Successfully saved ingredient classes", zap.Int("count", len(classes)))
+	return len(classes), nil
+}
+
+// GetIngredientClasses returns the class memberships of the named ingredients
+func (r *Repository) GetIngredientClasses(ctx context.Context, ingredients []string) (*[]domainMedicine.IngredientClass, error) {
+	var rows []IngredientClass
+	if len(ingredients) > 0 {
+		err := r.readDB(ctx).Where("ingredient_key IN ?", ingredientKeys(ingredients)).Order("id").Find(&rows).Error
+		if err != nil {
+			r.Logger.Error("Error getting ingredient classes", zap.Error(err))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+	}
+	classes := make([]domainMedicine.IngredientClass, len(rows))
+	for i := range rows {
+		classes[i] = domainMedicine.IngredientClass{
+			ID:         rows[i].ID,
+			TenantID:   rows[i].TenantID,
+			Class:      rows[i].Class,
+			Ingredient: rows[i].Ingredient,
+			CreatedAt:  rows[i].CreatedAt,
+			CreatedBy:  rows[i].CreatedBy,
+		}
+	}
+	return &classes, nil
+}
+
+// GetInteractions returns the interactions whose two subjects are both in subjects
+func (r *Repository) GetInteractions(ctx context.Context, subjects []domainMedicine.InteractionSubject) (*[]domainMedicine.Interaction, error) {
+	interactions := []domainMedicine.Interaction{}
+	if len(subjects) == 0 {
+		return &interactions, nil
+	}
+	keys := make([]string, len(subjects))
+	listed := make(map[domainMedicine.InteractionSubject]bool, len(subjects))
+	for i, subject := range subjects {
+		keys[i] = subject.Key()
+		listed[domainMedicine.InteractionSubject{Name: keys[i], Class: subject.Class}] = true
+	}
+	var rows []Interaction
+	err := r.readDB(ctx).Where("first_key IN ? AND second_key IN ?", keys, keys).Order("id").Find(&rows).Error
+	if err != nil {
+		r.Logger.Error("Error getting medicine interactions", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	for i := range rows {
+		// A class may be named like one of the ingredients
+		if listed[domainMedicine.InteractionSubject{Name: rows[i].FirstKey, Class: rows[i].FirstClass}] &&
+			listed[domainMedicine.InteractionSubject{Name: rows[i].SecondKey, Class: rows[i].SecondClass}] {
+			interactions = append(interactions, *rows[i].toDomainMapper())
+		}
+	}
+	return &interactions, nil
+}
+
+func interactionFromDomainMapper(interaction *domainMedicine.Interaction) *Interaction {
+	return &Interaction{
+		FirstClass:  interaction.First.Class,
+		FirstKey:    interaction.First.Key(),
+		FirstName:   interaction.First.Name,
+		SecondClass: interaction.Second.Class,
+		SecondKey:   interaction.Second.Key(),
+		SecondName:  interaction.Second.Name,
+		Severity:    string(interaction.Severity),
+		Description: interaction.Description,
+	}
+}
+
+func (i *Interaction) toDomainMapper() *domainMedicine.Interaction {
+	return &domainMedicine.Interaction{
+		ID:          i.ID,
+		TenantID:    i.TenantID,
+		First:       domainMedicine.InteractionSubject{Name: i.FirstName, Class: i.FirstClass},
+		Second:      domainMedicine.InteractionSubject{Name: i.SecondName, Class: i.SecondClass},
+		Severity:    domainMedicine.Severity(i.Severity),
+		Description: i.Description,
+		CreatedAt:   i.CreatedAt,
+		UpdatedAt:   i.UpdatedAt,
+		CreatedBy:   i.CreatedBy,
+		UpdatedBy:   i.UpdatedBy,
+	}
+}
@@ -1,37 +1,82 @@
 package medicine
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
 	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/replica"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // MedicineRepositoryInterface defines the interface for medicine repository operations
 type MedicineRepositoryInterface interface {
-	GetAll() (*[]domainMedicine.Medicine, error)
-	GetByID(id int) (*domainMedicine.Medicine, error)
-	Create(medicine *domainMedicine.Medicine) (*domainMedicine.Medicine, error)
-	Delete(id int) error
-	Update(id int, medicineMap map[string]any) (*domainMedicine.Medicine, error)
-	SearchPaginated(filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error)
-	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetAll(ctx context.Context) (*[]domainMedicine.Medicine, error)
+	GetByID(ctx context.Context, id int) (*domainMedicine.Medicine, error)
+	GetByEanCode(ctx context.Context, eanCodes []string) (*domainMedicine.Medicine, error)
+	Create(ctx context.Context, medicine *domainMedicine.Medicine) (*domainMedicine.Medicine, error)
+	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, medicineMap map[string]any) (*domainMedicine.Medicine, error)
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error)
+	SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error)
+	GetTrash(ctx context.Context, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error)
+	Restore(ctx context.Context, id int) (*domainMedicine.Medicine, error)
+	Purge(ctx context.Context, olderThan time.Time) (int64, error)
+	GetHistory(ctx context.Context, id int) (*[]domainHistory.Entry, error)
+	GetAsOf(ctx context.Context, id int, at time.Time) (*domainMedicine.Medicine, error)
+	RenameLaboratory(ctx context.Context, laboratoryID int, name string) (*domainLaboratory.Laboratory, *[]domainMedicine.Medicine, error)
+	LinkLaboratories(ctx context.Context) (*[]domainMedicine.Medicine, error)
+	GetSubstituteCandidates(ctx context.Context, medicine *domainMedicine.Medicine, ids []int) (*[]domainMedicine.Medicine, error)
+	GetEquivalences(ctx context.Context, medicineID int) (*[]domainMedicine.Equivalence, error)
+	SaveEquivalence(ctx context.Context, equivalence *domainMedicine.Equivalence) (*domainMedicine.Equivalence, error)
+	DeleteEquivalence(ctx context.Context, id int) error
+	SaveInteractions(ctx context.Context, interactions []domainMedicine.Interaction) (int, error)
+	SaveIngredientClasses(ctx context.Context, classes []domainMedicine.IngredientClass) (int, error)
+	GetIngredientClasses(ctx context.Context, ingredients []string) (*[]domainMedicine.IngredientClass, error)
+	GetInteractions(ctx context.Context, subjects []domainMedicine.InteractionSubject) (*[]domainMedicine.Interaction, error)
 }
 
 // Structures
+
+// Medicine names and EAN codes are unique per organization, so both indexes lead with the tenant
 type Medicine struct {
 	ID          int    `gorm:"primaryKey"`
-	Name        string `gorm:"unique"`
+	TenantID    int    `gorm:"uniqueIndex:idx_medicines_tenant_name,priority:1,where:deleted_at IS NULL;uniqueIndex:idx_medicines_tenant_ean_code,priority:1,where:deleted_at IS NULL"`
+	Name        string `gorm:"uniqueIndex:idx_medicines_tenant_name,priority:2,where:deleted_at IS NULL"`
 	Description string
-	EANCode     string `gorm:"unique"`
-	Laboratory  string
-	CreatedAt   time.Time `gorm:"autoCreateTime:milli"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime:milli"`
+	EANCode     string `gorm:"uniqueIndex:idx_medicines_tenant_ean_code,priority:2,where:deleted_at IS NULL"`
+	// Laboratory repeats the name of the laboratory LaboratoryID links to, so listings
+	// filter by it without a join; renaming the laboratory renames it too
+	LaboratoryID *int `gorm:"index"`
+	Laboratory   string
+	// LaboratoryRecord only declares the foreign key of LaboratoryID and is never loaded
+	LaboratoryRecord *laboratory.Laboratory `gorm:"foreignKey:LaboratoryID;constraint:OnDelete:RESTRICT"`
+	DosageForm       string                 `gorm:"size:32;index"`
+	Route            string                 `gorm:"size:32"`
+	// PackageQuantity and PackageUnit are both empty while the package size is unknown
+	PackageQuantity *float64
+	PackageUnit     string `gorm:"size:8"`
+	// Price is the price of one package, NULL while unknown
+	Price *float64
+	// Ingredients are stored in medicine_ingredients and loaded by loadIngredients
+	Ingredients []MedicineIngredient `gorm:"-"`
+	CreatedAt   time.Time            `gorm:"autoCreateTime:milli"`
+	UpdatedAt   time.Time            `gorm:"autoUpdateTime:milli"`
+	DeletedAt   gorm.DeletedAt       `gorm:"index"`
+	CreatedBy   *int                 `gorm:"index"`
+	UpdatedBy   *int                 `gorm:"index"`
+	// StockQuantity is only read by listings, computed from the stock levels
+	StockQuantity *int `gorm:"->;-:migration"`
 }
 
 type PaginationResultMedicine struct {
@@ -49,18 +94,49 @@ func (*Medicine) TableName() string {
 }
 
 var ColumnsMedicineMapping = map[string]string{
-	"id":          "id",
-	"name":        "name",
-	"description": "description",
-	"eanCode":     "ean_code",
-	"laboratory":  "laboratory",
-	"createdAt":   "created_at",
-	"updatedAt":   "updated_at",
+	"id":           "id",
+	"tenantId":     "tenant_id",
+	"name":         "name",
+	"description":  "description",
+	"eanCode":      "ean_code",
+	"laboratory":   "laboratory",
+	"laboratoryId": "laboratory_id",
+	"dosageForm":   "dosage_form",
+	"route":        "route",
+	"price":        "price",
+	"createdAt":    "created_at",
+	"updatedAt":    "updated_at",
+	"deletedAt":    "deleted_at",
+	"createdBy":    "created_by",
+	"updatedBy":    "updated_by",
+}
+
+// ComputedMedicineColumns are the numeric values derived from other tables that
+// listings can match, filter by range and sort by
+var ComputedMedicineColumns = map[string]string{
+	"stockQuantity": stockQuantityColumn,
+}
+
+// stockQuantityColumn is the number of units of the medicine on hand over all locations:
+// the stock level snapshots plus the ledger movements recorded since
+const stockQuantityColumn = "(COALESCE((SELECT SUM(stock_levels.quantity) FROM stock_levels WHERE stock_levels.medicine_id = medicines.id), 0)" +
+	" + COALESCE((SELECT SUM(stock_movements.quantity) FROM stock_movements JOIN stock_levels" +
+	" ON stock_levels.tenant_id = stock_movements.tenant_id AND stock_levels.medicine_id = stock_movements.medicine_id" +
+	" AND stock_levels.location = stock_movements.location" +
+	" WHERE stock_movements.medicine_id = medicines.id AND stock_movements.id > stock_levels.movement_id), 0))"
+
+// sortableColumn returns the column or expression of a field listings match and sort by
+func sortableColumn(field string) string {
+	if column := ColumnsMedicineMapping[field]; column != "" {
+		return column
+	}
+	return ComputedMedicineColumns[field]
 }
 
 type Repository struct {
-	DB     *gorm.DB
-	Logger *logger.Logger
+	DB       *gorm.DB
+	Replicas *replica.Set
+	Logger   *logger.Logger
 }
 
 func NewMedicineRepository(DB *gorm.DB, loggerInstance *logger.Logger) MedicineRepositoryInterface {
@@ -70,37 +146,64 @@ func NewMedicineRepository(DB *gorm.DB, loggerInstance *logger.Logger) MedicineR
 	}
 }
 
-func (r *Repository) Create(newMedicine *domainMedicine.Medicine) (*domainMedicine.Medicine, error) {
+// NewMedicineRepositoryWithReplicas creates a repository whose listing queries are served by replicas
+func NewMedicineRepositoryWithReplicas(DB *gorm.DB, replicas *replica.Set, loggerInstance *logger.Logger) MedicineRepositoryInterface {
+	return &Repository{
+		DB:       DB,
+		Replicas: replicas,
+		Logger:   loggerInstance,
+	}
+}
+
+// readDB returns the connection for listing queries: a healthy replica when configured
+// and ctx does not ask for the primary, else the primary
+func (r *Repository) readDB(ctx context.Context) *gorm.DB {
+	if r.Replicas == nil || replica.PrimaryRequested(ctx) {
+		return r.DB.WithContext(ctx)
+	}
+	return r.Replicas.Reader().WithContext(ctx)
+}
+
+func (r *Repository) Create(ctx context.Context, newMedicine *domainMedicine.Medicine) (*domainMedicine.Medicine, error) {
 	medicine := &Medicine{
-		Name:        newMedicine.Name,
-		Description: newMedicine.Description,
-		EANCode:     newMedicine.EanCode,
-		Laboratory:  newMedicine.Laboratory,
+		TenantID:     newMedicine.TenantID,
+		Name:         newMedicine.Name,
+		Description:  newMedicine.Description,
+		EANCode:      newMedicine.EanCode,
+		LaboratoryID: newMedicine.LaboratoryID,
+		Laboratory:   newMedicine.Laboratory,
+		DosageForm:   newMedicine.DosageForm,
+		Route:        newMedicine.Route,
+		Price:        newMedicine.Price,
 	}
+	medicine.setPackageSize(newMedicine.PackageSize)
 
-	tx := r.DB.Create(medicine)
-	if tx.Error != nil {
-		r.Logger.Error("Error creating medicine", zap.Error(tx.Error), zap.String("name", newMedicine.Name))
-		byteErr, _ := json.Marshal(tx.Error)
-		var newError domainErrors.GormErr
-		err := json.Unmarshal(byteErr, &newError)
-		if err != nil {
-			return nil, err
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(medicine).Error; err != nil {
+			return err
 		}
-		switch newError.Number {
-		case 1062:
-			return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
-		default:
-			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		if len(newMedicine.Ingredients) > 0 {
+			if err := replaceIngredients(tx, medicine, newMedicine.Ingredients); err != nil {
+				return err
+			}
 		}
+		return recordHistory(ctx, tx, domainHistory.ActionCreate, nil, medicine)
+	})
+	if err != nil {
+		r.Logger.Error("Error creating medicine", zap.Error(err), zap.String("name", newMedicine.Name))
+		return nil, writeError(err)
 	}
 	r.Logger.Info("Successfully created medicine", zap.String("name", newMedicine.Name), zap.Int("id", medicine.ID))
 	return medicine.toDomainMapper(), nil
 }
 
-func (r *Repository) GetByID(id int) (*domainMedicine.Medicine, error) {
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainMedicine.Medicine, error) {
 	var medicine Medicine
-	err := r.DB.Where("id = ?", id).First(&medicine).Error
+	db := r.DB.WithContext(ctx)
+	err := db.Where("id = ?", id).First(&medicine).Error
+	if err == nil {
+		err = loadIngredients(db, &medicine)
+	}
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.Logger.Warn("Medicine not found", zap.Int("id", id))
@@ -113,53 +216,244 @@ func (r *Repository) GetByID(id int) (*domainMedicine.Medicine, error) {
 	return medicine.toDomainMapper(), nil
 }
 
-func (r *Repository) Update(id int, medicineMap map[string]any) (*domainMedicine.Medicine, error) {
-	var med Medicine
-	med.ID = id
-	err := r.DB.Model(&med).
-		Select("name", "description", "ean_code", "laboratory").
-		Updates(medicineMap).Error
+// GetByEanCode returns the live medicine whose EAN code is any of eanCodes
+func (r *Repository) GetByEanCode(ctx context.Context, eanCodes []string) (*domainMedicine.Medicine, error) {
+	var medicine Medicine
+	db := r.DB.WithContext(ctx)
+	err := db.Where("ean_code IN ?", eanCodes).First(&medicine).Error
+	if err == nil {
+		err = loadIngredients(db, &medicine)
+	}
 	if err != nil {
-		r.Logger.Error("Error updating medicine", zap.Error(err), zap.Int("id", id))
-		byteErr, _ := json.Marshal(err)
-		var newError domainErrors.GormErr
-		errUnmarshal := json.Unmarshal(byteErr, &newError)
-		if errUnmarshal != nil {
-			return nil, errUnmarshal
-		}
-		switch newError.Number {
-		case 1062:
-			return nil, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
-		default:
-			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Medicine not found", zap.Strings("eanCodes", eanCodes))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
 		}
+		r.Logger.Error("Error getting medicine by EAN code", zap.Error(err), zap.Strings("eanCodes", eanCodes))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
-	err = r.DB.Where("id = ?", id).First(&med).Error
+	r.Logger.Info("Successfully retrieved medicine by EAN code", zap.Int("id", medicine.ID))
+	return medicine.toDomainMapper(), nil
+}
+
+func (r *Repository) Update(ctx context.Context, id int, medicineMap map[string]any) (*domainMedicine.Medicine, error) {
+	var before, med Medicine
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&before).Error; err != nil {
+			return err
+		}
+		if err := loadIngredients(tx, &before); err != nil {
+			return err
+		}
+		columns, ingredients, replace := updateColumns(medicineMap)
+		med.ID = id
+		if err := tx.Model(&med).
+			Select("name", "description", "ean_code", "laboratory_id", "laboratory", "dosage_form", "route", "package_quantity", "package_unit", "price").
+			Updates(columns).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", id).First(&med).Error; err != nil {
+			return err
+		}
+		if replace {
+			if err := replaceIngredients(tx, &med, ingredients); err != nil {
+				return err
+			}
+		} else {
+			med.Ingredients = before.Ingredients
+		}
+		return recordHistory(ctx, tx, domainHistory.ActionUpdate, &before, &med)
+	})
 	if err != nil {
-		r.Logger.Error("Error retrieving updated medicine", zap.Error(err), zap.Int("id", id))
-		return nil, err
+		r.Logger.Error("Error updating medicine", zap.Error(err), zap.Int("id", id))
+		return nil, writeError(err)
 	}
 	r.Logger.Info("Successfully updated medicine", zap.Int("id", id))
 	return med.toDomainMapper(), nil
 }
 
-func (r *Repository) Delete(id int) error {
-	tx := r.DB.Delete(&Medicine{}, id)
-	if tx.Error != nil {
-		r.Logger.Error("Error deleting medicine", zap.Error(tx.Error), zap.Int("id", id))
-		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+// RenameLaboratory renames a laboratory and gives the medicines linked to it its new
+// name in one transaction. It returns the laboratory and the live medicines, whose
+// change is recorded as any other update. Deleted medicines take the name silently, as
+// they are in the trash.
+func (r *Repository) RenameLaboratory(ctx context.Context, laboratoryID int, name string) (*domainLaboratory.Laboratory, *[]domainMedicine.Medicine, error) {
+	var renamedLaboratory *domainLaboratory.Laboratory
+	var renamed []Medicine
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if renamedLaboratory, err = laboratory.Rename(tx, laboratoryID, name); err != nil {
+			return err
+		}
+		var linked []Medicine
+		if err := tx.Where("laboratory_id = ? AND laboratory <> ?", laboratoryID, name).Order("id").Find(&linked).Error; err != nil {
+			return err
+		}
+		for i := range linked {
+			before := linked[i]
+			if err := loadIngredients(tx, &before); err != nil {
+				return err
+			}
+			med := Medicine{ID: before.ID}
+			if err := tx.Model(&med).Updates(map[string]any{"laboratory": name}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id = ?", before.ID).First(&med).Error; err != nil {
+				return err
+			}
+			med.Ingredients = before.Ingredients
+			if err := recordHistory(ctx, tx, domainHistory.ActionUpdate, &before, &med); err != nil {
+				return err
+			}
+			renamed = append(renamed, med)
+		}
+		return tx.Unscoped().Model(&Medicine{}).
+			Where("laboratory_id = ? AND deleted_at IS NOT NULL", laboratoryID).
+			UpdateColumn("laboratory", name).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error renaming a laboratory and its medicines", zap.Error(err), zap.Int("laboratoryId", laboratoryID))
+		return nil, nil, writeError(err)
 	}
-	if tx.RowsAffected == 0 {
-		r.Logger.Warn("Medicine not found for deletion", zap.Int("id", id))
-		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	r.Logger.Info("Successfully renamed a laboratory and its medicines", zap.Int("laboratoryId", laboratoryID), zap.Int("count", len(renamed)))
+	return renamedLaboratory, arrayToDomainMapper(&renamed), nil
+}
+
+// LinkLaboratories links the medicines created with only a laboratory name, before
+// laboratories existed, to the laboratories laboratory.Deduplicate makes of their
+// spellings. Linked medicines take the name of their laboratory. It returns the live
+// ones, whose change is recorded as any other update; deleted medicines are linked
+// silently. It is a no-op once every medicine is linked.
+func (r *Repository) LinkLaboratories(ctx context.Context) (*[]domainMedicine.Medicine, error) {
+	var linked []Medicine
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		links, err := laboratory.Deduplicate(tx)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			var unlinked []Medicine
+			if err := tx.Where("tenant_id = ? AND laboratory_id IS NULL AND laboratory IN ?", link.TenantID, link.Names).Order("id").Find(&unlinked).Error; err != nil {
+				return err
+			}
+			for i := range unlinked {
+				before := unlinked[i]
+				if err := loadIngredients(tx, &before); err != nil {
+					return err
+				}
+				med := Medicine{ID: before.ID}
+				if err := tx.Model(&med).Updates(map[string]any{"laboratory_id": link.Laboratory.ID, "laboratory": link.Laboratory.Name}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("id = ?", before.ID).First(&med).Error; err != nil {
+					return err
+				}
+				med.Ingredients = before.Ingredients
+				if err := recordHistory(ctx, tx, domainHistory.ActionUpdate, &before, &med); err != nil {
+					return err
+				}
+				linked = append(linked, med)
+			}
+			err := tx.Unscoped().Model(&Medicine{}).
+				Where("tenant_id = ? AND laboratory_id IS NULL AND laboratory IN ? AND deleted_at IS NOT NULL", link.TenantID, link.Names).
+				UpdateColumns(map[string]any{"laboratory_id": link.Laboratory.ID, "laboratory": link.Laboratory.Name}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.Logger.Error("Error linking medicines to their laboratories", zap.Error(err))
+		return nil, writeError(err)
+	}
+	if len(linked) > 0 {
+		r.Logger.Info("Linked medicines to their laboratories", zap.Int("count", len(linked)))
+	}
+	return arrayToDomainMapper(&linked), nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before, after Medicine
+		if err := tx.Where("id = ?", id).First(&before).Error; err != nil {
+			return err
+		}
+		if err := loadIngredients(tx, &before); err != nil {
+			return err
+		}
+		if err := tx.Delete(&Medicine{}, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id = ?", id).First(&after).Error; err != nil {
+			return err
+		}
+		after.Ingredients = before.Ingredients
+		return recordHistory(ctx, tx, domainHistory.ActionDelete, &before, &after)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Medicine not found for deletion", zap.Int("id", id))
+			return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error deleting medicine", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
 	r.Logger.Info("Successfully deleted medicine", zap.Int("id", id))
 	return nil
 }
 
-func (r *Repository) GetAll() (*[]domainMedicine.Medicine, error) {
+// Restore clears the deletion mark of a soft-deleted medicine
+func (r *Repository) Restore(ctx context.Context, id int) (*domainMedicine.Medicine, error) {
+	var before, after Medicine
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&before).Error; err != nil {
+			return err
+		}
+		if err := loadIngredients(tx, &before); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&Medicine{}).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", id).First(&after).Error; err != nil {
+			return err
+		}
+		after.Ingredients = before.Ingredients
+		return recordHistory(ctx, tx, domainHistory.ActionRestore, &before, &after)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Deleted medicine not found for restore", zap.Int("id", id))
+		} else {
+			r.Logger.Error("Error restoring medicine", zap.Error(err), zap.Int("id", id))
+		}
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully restored medicine", zap.Int("id", id))
+	return after.toDomainMapper(), nil
+}
+
+// Purge permanently removes medicines soft-deleted before olderThan
+func (r *Repository) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	tx := r.DB.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", olderThan).
+		Delete(&Medicine{})
+	if tx.Error != nil {
+		r.Logger.Error("Error purging deleted medicines", zap.Error(tx.Error), zap.Time("olderThan", olderThan))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully purged deleted medicines", zap.Int64("count", tx.RowsAffected))
+	return tx.RowsAffected, nil
+}
+
+func (r *Repository) GetAll(ctx context.Context) (*[]domainMedicine.Medicine, error) {
 	var medicines []Medicine
-	if err := r.DB.Find(&medicines).Error; err != nil {
+	db := r.readDB(ctx)
+	if err := db.Find(&medicines).Error; err != nil {
+		r.Logger.Error("Error getting all medicines", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if err := loadPageIngredients(db, medicines); err != nil {
 		r.Logger.Error("Error getting all medicines", zap.Error(err))
 		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
@@ -170,14 +464,124 @@ func (r *Repository) GetAll() (*[]domainMedicine.Medicine, error) {
 // Mappers
 func (m *Medicine) toDomainMapper() *domainMedicine.Medicine {
 	return &domainMedicine.Medicine{
-		ID:          m.ID,
-		Name:        m.Name,
-		Description: m.Description,
-		EanCode:     m.EANCode,
-		Laboratory:  m.Laboratory,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
+		ID:            m.ID,
+		TenantID:      m.TenantID,
+		Name:          m.Name,
+		Description:   m.Description,
+		EanCode:       m.EANCode,
+		LaboratoryID:  m.LaboratoryID,
+		Laboratory:    m.Laboratory,
+		Ingredients:   m.domainIngredients(),
+		DosageForm:    m.DosageForm,
+		Route:         m.Route,
+		PackageSize:   m.packageSize(),
+		Price:         m.Price,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+		DeletedAt:     deletedAtToDomain(m.DeletedAt),
+		CreatedBy:     m.CreatedBy,
+		UpdatedBy:     m.UpdatedBy,
+		StockQuantity: m.StockQuantity,
+	}
+}
+
+func (m *Medicine) domainIngredients() []domainMedicine.Ingredient {
+	ingredients := make([]domainMedicine.Ingredient, len(m.Ingredients))
+	for i := range m.Ingredients {
+		ingredients[i] = m.Ingredients[i].toDomainMapper()
+	}
+	return ingredients
+}
+
+func (m *Medicine) packageSize() *domainMedicine.PackageSize {
+	if m.PackageQuantity == nil {
+		return nil
+	}
+	return &domainMedicine.PackageSize{Quantity: *m.PackageQuantity, Unit: m.PackageUnit}
+}
+
+func (m *Medicine) setPackageSize(size *domainMedicine.PackageSize) {
+	m.PackageQuantity, m.PackageUnit = packageColumns(size)
+}
+
+func packageColumns(size *domainMedicine.PackageSize) (*float64, string) {
+	if size == nil {
+		return nil, ""
+	}
+	quantity := size.Quantity
+	return &quantity, size.Unit
+}
+
+// updateColumns splits an update into the medicine columns it sets and, when it has
+// the ingredients key, the ingredients replacing the current ones. The package_size
+// key, a *domainMedicine.PackageSize or nil, sets both package columns.
+func updateColumns(medicineMap map[string]any) (map[string]any, []domainMedicine.Ingredient, bool) {
+	columns := make(map[string]any, len(medicineMap))
+	for column, value := range medicineMap {
+		columns[column] = value
+	}
+	if size, ok := columns["package_size"]; ok {
+		delete(columns, "package_size")
+		packageSize, _ := size.(*domainMedicine.PackageSize)
+		columns["package_quantity"], columns["package_unit"] = packageColumns(packageSize)
 	}
+	value, replace := columns["ingredients"]
+	delete(columns, "ingredients")
+	ingredients, _ := value.([]domainMedicine.Ingredient)
+	return columns, ingredients, replace
+}
+
+// matchValues converts the values matched against a computed column to numbers,
+// dropping those that are not
+func matchValues(field string, values []string) any {
+	if _, computed := ComputedMedicineColumns[field]; !computed {
+		return values
+	}
+	numbers := []int{}
+	for _, value := range values {
+		if number, err := strconv.Atoi(value); err == nil {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// writeError translates a failed write into the matching application error
+func writeError(err error) error {
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if err == gorm.ErrRecordNotFound {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	byteErr, _ := json.Marshal(err)
+	var newError domainErrors.GormErr
+	if errUnmarshal := json.Unmarshal(byteErr, &newError); errUnmarshal != nil {
+		return errUnmarshal
+	}
+	switch newError.Number {
+	case 1062:
+		return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	default:
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+}
+
+// likeOperator returns the case-insensitive LIKE operator of the database behind db.
+// SQLite has no ILIKE, but its LIKE already ignores case for ASCII text.
+func likeOperator(db *gorm.DB) string {
+	if db.Dialector.Name() == "sqlite" {
+		return "LIKE"
+	}
+	return "ILIKE"
+}
+
+func deletedAtToDomain(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	return &deletedAt.Time
 }
 
 func arrayToDomainMapper(medicines *[]Medicine) *[]domainMedicine.Medicine {
@@ -190,17 +594,50 @@ func arrayToDomainMapper(medicines *[]Medicine) *[]domainMedicine.Medicine {
 
 // IsZeroValue checks if a value is the zero value of its type
 
-func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
-	query := r.DB.Model(&Medicine{})
+func (r *Repository) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
+	result, err := r.paginate(r.readDB(ctx).Model(&Medicine{}), filters)
+	if err != nil {
+		r.Logger.Error("Error searching medicines", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	r.Logger.Info("Successfully searched medicines",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page),
+		zap.Int("pageSize", result.PageSize))
+
+	return result, nil
+}
+
+// GetTrash lists soft-deleted medicines using the same filters as SearchPaginated
+func (r *Repository) GetTrash(ctx context.Context, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
+	query := r.DB.WithContext(ctx).Unscoped().Model(&Medicine{}).Where("deleted_at IS NOT NULL")
+	result, err := r.paginate(query, filters)
+	if err != nil {
+		r.Logger.Error("Error listing deleted medicines", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
 
+	r.Logger.Info("Successfully listed deleted medicines",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+
+	return result, nil
+}
+
+func (r *Repository) paginate(query *gorm.DB, filters domain.DataFilters) (*domainMedicine.SearchResultMedicine, error) {
 	// Apply like filters
 	for field, values := range filters.LikeFilters {
 		if len(values) > 0 {
 			for _, value := range values {
 				if value != "" {
+					if field == IngredientField {
+						query = query.Where(ingredientMedicines+"active_ingredients.name "+likeOperator(query)+" ?)", "%"+value+"%")
+						continue
+					}
 					column := ColumnsMedicineMapping[field]
 					if column != "" {
-						query = query.Where(column+" ILIKE ?", "%"+value+"%")
+						query = query.Where(column+" "+likeOperator(query)+" ?", "%"+value+"%")
 					}
 				}
 			}
@@ -210,9 +647,13 @@ func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainMedicin
 	// Apply exact matches
 	for field, values := range filters.Matches {
 		if len(values) > 0 {
-			column := ColumnsMedicineMapping[field]
+			if field == IngredientField {
+				query = query.Where(ingredientMedicines+"active_ingredients.name_key IN ?)", ingredientKeys(values))
+				continue
+			}
+			column := sortableColumn(field)
 			if column != "" {
-				query = query.Where(column+" IN ?", values)
+				query = query.Where(column+" IN ?", matchValues(field, values))
 			}
 		}
 	}
@@ -230,10 +671,23 @@ func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainMedicin
 		}
 	}
 
+	// Apply number range filters
+	for _, numberFilter := range filters.NumberRangeFilters {
+		column := sortableColumn(numberFilter.Field)
+		if column != "" {
+			if numberFilter.Min != nil {
+				query = query.Where(column+" >= ?", *numberFilter.Min)
+			}
+			if numberFilter.Max != nil {
+				query = query.Where(column+" <= ?", *numberFilter.Max)
+			}
+		}
+	}
+
 	// Apply sorting
 	if len(filters.SortBy) > 0 && filters.SortDirection.IsValid() {
 		for _, sortField := range filters.SortBy {
-			column := ColumnsMedicineMapping[sortField]
+			column := sortableColumn(sortField)
 			if column != "" {
 				query = query.Order(column + " " + string(filters.SortDirection))
 			}
@@ -255,30 +709,29 @@ func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainMedicin
 	offset := (filters.Page - 1) * filters.PageSize
 
 	var medicines []Medicine
-	if err := query.Offset(offset).Limit(filters.PageSize).Find(&medicines).Error; err != nil {
-		r.Logger.Error("Error searching medicines", zap.Error(err))
-		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	if err := query.Select("medicines.*", stockQuantityColumn+" AS stock_quantity").
+		Offset(offset).Limit(filters.PageSize).Find(&medicines).Error; err != nil {
+		return nil, err
+	}
+	if err := loadPageIngredients(query.Session(&gorm.Session{NewDB: true}), medicines); err != nil {
+		return nil, err
 	}
 
 	totalPages := int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize))
 
-	result := &domainMedicine.SearchResultMedicine{
+	return &domainMedicine.SearchResultMedicine{
 		Data:       arrayToDomainMapper(&medicines),
 		Total:      total,
 		Page:       filters.Page,
 		PageSize:   filters.PageSize,
 		TotalPages: totalPages,
-	}
-
-	r.Logger.Info("Successfully searched medicines",
-		zap.Int64("total", total),
-		zap.Int("page", filters.Page),
-		zap.Int("pageSize", filters.PageSize))
-
-	return result, nil
+	}, nil
 }
 
-func (r *Repository) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (r *Repository) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
+	if property == IngredientField {
+		return r.searchIngredients(ctx, searchText)
+	}
 	column := ColumnsMedicineMapping[property]
 	if column == "" {
 		r.Logger.Warn("Invalid property for search", zap.String("property", property))
@@ -286,9 +739,10 @@ func (r *Repository) SearchByProperty(property string, searchText string) (*[]st
 	}
 
 	var coincidences []string
-	if err := r.DB.Model(&Medicine{}).
+	query := r.readDB(ctx)
+	if err := query.Model(&Medicine{}).
 		Distinct(column).
-		Where(column+" ILIKE ?", "%"+searchText+"%").
+		Where(column+" "+likeOperator(query)+" ?", "%"+searchText+"%").
 		Limit(20).
 		Pluck(column, &coincidences).Error; err != nil {
 		r.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
@@ -301,3 +755,19 @@ func (r *Repository) SearchByProperty(property string, searchText string) (*[]st
 
 	return &coincidences, nil
 }
+
+// searchIngredients returns the names of the active ingredients containing searchText
+func (r *Repository) searchIngredients(ctx context.Context, searchText string) (*[]string, error) {
+	var coincidences []string
+	query := r.readDB(ctx)
+	if err := query.Model(&ActiveIngredient{}).
+		Where("name "+likeOperator(query)+" ?", "%"+searchText+"%").
+		Order("name").
+		Limit(20).
+		Pluck("name", &coincidences).Error; err != nil {
+		r.Logger.Error("Error searching active ingredients", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully searched active ingredients", zap.Int("results", len(coincidences)))
+	return &coincidences, nil
+}
@@ -1,13 +1,18 @@
 package medicine
 
 import (
+	"context"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/replica"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
@@ -31,6 +36,12 @@ func setupLogger(t *testing.T) *logger.Logger {
 	return loggerInstance
 }
 
+// expectNoIngredients expects the query loading the ingredients of the medicines read
+func expectNoIngredients(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT medicine_ingredients.*, active_ingredients.name AS name FROM "medicine_ingredients"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"medicine_id", "ingredient_id"}))
+}
+
 func TestTableName(t *testing.T) {
 	medicine := &Medicine{}
 	assert.Equal(t, "medicines", medicine.TableName())
@@ -107,12 +118,38 @@ func TestRepository_GetAll(t *testing.T) {
 		AddRow(1, "Medicine 1", "Description 1", "1234567890123", "Lab 1").
 		AddRow(2, "Medicine 2", "Description 2", "1234567890124", "Lab 2")
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines"`)).WillReturnRows(rows)
-	medicines, err := repo.GetAll()
+	expectNoIngredients(mock)
+	medicines, err := repo.GetAll(context.Background())
 	assert.NoError(t, err)
 	assert.NotNil(t, medicines)
 	assert.Len(t, *medicines, 2)
 }
 
+func TestRepository_ReadsRoutedToReplica(t *testing.T) {
+	primary, primaryMock, cleanupPrimary := setupMockDB(t)
+	defer cleanupPrimary()
+	replicaDB, replicaMock, cleanupReplica := setupMockDB(t)
+	defer cleanupReplica()
+	logger := setupLogger(t)
+	replicas := replica.NewSet(primary, []*replica.Replica{{Name: "replica", DB: replicaDB}}, 0, logger)
+	repo := NewMedicineRepositoryWithReplicas(primary, replicas, logger)
+
+	replicaMock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Medicine 1"))
+	expectNoIngredients(replicaMock)
+	primaryMock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1`)).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Medicine 1"))
+	expectNoIngredients(primaryMock)
+
+	_, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	_, err = repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
 func TestRepository_GetByID(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -120,14 +157,37 @@ func TestRepository_GetByID(t *testing.T) {
 	repo := NewMedicineRepository(db, logger)
 	rows := sqlmock.NewRows([]string{"id", "name", "description", "ean_code", "laboratory"}).
 		AddRow(1, "Medicine 1", "Description 1", "1234567890123", "Lab 1")
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 ORDER BY "medicines"."id" LIMIT $2`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 AND "medicines"."deleted_at" IS NULL ORDER BY "medicines"."id" LIMIT $2`)).
 		WithArgs(1, 1).WillReturnRows(rows)
-	medicine, err := repo.GetByID(1)
+	expectNoIngredients(mock)
+	medicine, err := repo.GetByID(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, medicine)
 	assert.Equal(t, "Medicine 1", medicine.Name)
 }
 
+func TestRepository_GetByEanCode(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	query := regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE ean_code IN ($1,$2) AND "medicines"."deleted_at" IS NULL ORDER BY "medicines"."id" LIMIT $3`)
+	rows := sqlmock.NewRows([]string{"id", "name", "ean_code"}).AddRow(1, "Medicine 1", "4006381333931")
+	mock.ExpectQuery(query).WithArgs("04006381333931", "4006381333931", 1).WillReturnRows(rows)
+	expectNoIngredients(mock)
+	mock.ExpectQuery(query).WithArgs("09506000134352", "9506000134352", 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	medicine, err := repo.GetByEanCode(context.Background(), []string{"04006381333931", "4006381333931"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Medicine 1", medicine.Name)
+
+	_, err = repo.GetByEanCode(context.Background(), []string{"09506000134352", "9506000134352"})
+	appErr, ok := err.(*domainErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestRepository_Create(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -142,8 +202,15 @@ func TestRepository_Create(t *testing.T) {
 	mock.ExpectBegin()
 	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "medicines"`)).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "medicine_history"`)).
+		WithArgs(0, 1, "create", sqlmock.AnyArg(), sqlmock.AnyArg(), 7, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+		WithArgs(sqlmock.AnyArg(), 0, "medicine", 1, "medicine.created", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
-	medicine, err := repo.Create(domainM)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 7})
+	medicine, err := repo.Create(ctx, domainM)
 	assert.NoError(t, err)
 	assert.NotNil(t, medicine)
 	assert.Equal(t, "New Medicine", medicine.Name)
@@ -155,17 +222,64 @@ func TestRepository_Update(t *testing.T) {
 	logger := setupLogger(t)
 	repo := NewMedicineRepository(db, logger)
 	mock.ExpectBegin()
+	before := sqlmock.NewRows([]string{"id", "name", "description", "ean_code", "laboratory"}).
+		AddRow(1, "Old Medicine", "Updated Description", "1234567890123", "Updated Lab")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 AND "medicines"."deleted_at" IS NULL ORDER BY "medicines"."id" LIMIT $2`)).
+		WithArgs(1, 1).WillReturnRows(before)
+	expectNoIngredients(mock)
 	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "medicines" SET`)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectCommit()
 	rows := sqlmock.NewRows([]string{"id", "name", "description", "ean_code", "laboratory"}).
 		AddRow(1, "Updated Medicine", "Updated Description", "1234567890123", "Updated Lab")
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 AND "medicines"."id" = $2 ORDER BY "medicines"."id" LIMIT $3`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 AND "medicines"."deleted_at" IS NULL AND "medicines"."id" = $2 ORDER BY "medicines"."id" LIMIT $3`)).
 		WithArgs(1, 1, 1).WillReturnRows(rows)
-	medicine, err := repo.Update(1, map[string]any{"name": "Updated Medicine"})
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "medicine_history"`)).
+		WithArgs(0, 1, "update", `{"name":{"before":"Old Medicine","after":"Updated Medicine"}}`, sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+		WithArgs(sqlmock.AnyArg(), 0, "medicine", 1, "medicine.updated", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectCommit()
+	medicine, err := repo.Update(context.Background(), 1, map[string]any{"name": "Updated Medicine"})
 	assert.NoError(t, err)
 	assert.NotNil(t, medicine)
 	assert.Equal(t, "Updated Medicine", medicine.Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Update_NoChanges(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	columns := []string{"id", "name", "description", "ean_code", "laboratory"}
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Same", "Desc", "1234567890123", "Lab"))
+	expectNoIngredients(mock)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "medicines" SET`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Same", "Desc", "1234567890123", "Lab"))
+	mock.ExpectCommit()
+	_, err := repo.Update(context.Background(), 1, map[string]any{"name": "Same"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Update_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1`)).
+		WillReturnError(gorm.ErrRecordNotFound)
+	mock.ExpectRollback()
+	_, err := repo.Update(context.Background(), 1, map[string]any{"name": "Updated"})
+	var appErr *domainErrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
 }
 
 func TestRepository_Delete(t *testing.T) {
@@ -174,11 +288,38 @@ func TestRepository_Delete(t *testing.T) {
 	logger := setupLogger(t)
 	repo := NewMedicineRepository(db, logger)
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "medicines" WHERE "medicines"."id" = $1`)).
-		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 AND "medicines"."deleted_at" IS NULL`)).
+		WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Medicine"))
+	expectNoIngredients(mock)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "medicines" SET "deleted_at"=$1 WHERE "medicines"."id" = $2 AND "medicines"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 ORDER BY`)).
+		WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(1, "Medicine", time.Now()))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "medicine_history"`)).
+		WithArgs(0, 1, "delete", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+		WithArgs(sqlmock.AnyArg(), 0, "medicine", 1, "medicine.deleted", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
 	mock.ExpectCommit()
-	err := repo.Delete(1)
+	err := repo.Delete(context.Background(), 1)
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	err := repo.Delete(context.Background(), 1)
+	var appErr *domainErrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
 }
 
 func TestColumnsMedicineMapping(t *testing.T) {
@@ -190,3 +331,165 @@ func TestColumnsMedicineMapping(t *testing.T) {
 	assert.Equal(t, "created_at", ColumnsMedicineMapping["createdAt"])
 	assert.Equal(t, "updated_at", ColumnsMedicineMapping["updatedAt"])
 }
+
+func TestToDomainMapper_DeletedAt(t *testing.T) {
+	now := time.Now()
+	medicine := &Medicine{ID: 1, DeletedAt: gorm.DeletedAt{Time: now, Valid: true}}
+	assert.Equal(t, &now, medicine.toDomainMapper().DeletedAt)
+
+	medicine.DeletedAt = gorm.DeletedAt{}
+	assert.Nil(t, medicine.toDomainMapper().DeletedAt)
+}
+
+func TestToDomainMapper_AuditColumns(t *testing.T) {
+	createdBy, updatedBy := 1, 2
+	medicine := &Medicine{ID: 1, CreatedBy: &createdBy, UpdatedBy: &updatedBy}
+	domainMedicine := medicine.toDomainMapper()
+	assert.Equal(t, 1, *domainMedicine.CreatedBy)
+	assert.Equal(t, 2, *domainMedicine.UpdatedBy)
+	assert.Equal(t, "created_by", ColumnsMedicineMapping["createdBy"])
+	assert.Equal(t, "updated_by", ColumnsMedicineMapping["updatedBy"])
+}
+
+func TestRepository_GetTrash(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "medicines" WHERE deleted_at IS NOT NULL`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"id", "name", "deleted_at"}).
+		AddRow(1, "Deleted Medicine", time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT medicines.*,` + stockQuantityColumn + ` AS stock_quantity FROM "medicines" WHERE deleted_at IS NOT NULL`)).
+		WillReturnRows(rows)
+	expectNoIngredients(mock)
+	result, err := repo.GetTrash(context.Background(), domain.DataFilters{Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	assert.Len(t, *result.Data, 1)
+	assert.NotNil(t, (*result.Data)[0].DeletedAt)
+}
+
+func TestRepository_Restore(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 AND deleted_at IS NOT NULL`)).
+		WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(1, "Restored Medicine", time.Now()))
+	expectNoIngredients(mock)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "medicines" SET "deleted_at"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Restored Medicine")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 AND "medicines"."deleted_at" IS NULL`)).
+		WithArgs(1, 1).WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "medicine_history"`)).
+		WithArgs(0, 1, "restore", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+		WithArgs(sqlmock.AnyArg(), 0, "medicine", 1, "medicine.restored", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectCommit()
+	medicine, err := repo.Restore(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Restored Medicine", medicine.Name)
+	assert.Nil(t, medicine.DeletedAt)
+}
+
+func TestRepository_Restore_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicines" WHERE id = $1 AND deleted_at IS NOT NULL`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	_, err := repo.Restore(context.Background(), 1)
+	var appErr *domainErrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestRepository_Purge(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "medicines" WHERE deleted_at IS NOT NULL AND deleted_at < $1`)).
+		WithArgs(cutoff).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	purged, err := repo.Purge(context.Background(), cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func TestMedicineHistoryTableName(t *testing.T) {
+	assert.Equal(t, "medicine_history", (&MedicineHistory{}).TableName())
+}
+
+func TestRepository_GetHistory(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "entity_id", "action", "changes", "snapshot", "actor_id", "created_at"}).
+		AddRow(1, 1, "create", `{"name":{"before":null,"after":"Aspirin"}}`, `{"id":1,"name":"Aspirin"}`, 7, now).
+		AddRow(2, 1, "update", `{"name":{"before":"Aspirin","after":"Aspirin Forte"}}`, `{"id":1,"name":"Aspirin Forte"}`, nil, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicine_history" WHERE entity_id = $1 ORDER BY created_at, id`)).
+		WithArgs(1).WillReturnRows(rows)
+	entries, err := repo.GetHistory(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, *entries, 2)
+	assert.Equal(t, "create", string((*entries)[0].Action))
+	assert.Equal(t, 7, *(*entries)[0].ActorID)
+	assert.Equal(t, "Aspirin Forte", (*entries)[1].Changes["name"].After)
+	assert.Nil(t, (*entries)[1].ActorID)
+}
+
+func TestRepository_GetHistory_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicine_history"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err := repo.GetHistory(context.Background(), 1)
+	var appErr *domainErrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestRepository_GetAsOf(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "entity_id", "action", "changes", "snapshot", "created_at"}).
+		AddRow(1, 1, "update", `{}`, `{"id":1,"name":"Aspirin","eanCode":"1234567890123","deletedAt":null}`, at)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicine_history" WHERE entity_id = $1 AND created_at <= $2 ORDER BY created_at DESC, id DESC`)).
+		WithArgs(1, at, 1).WillReturnRows(rows)
+	medicine, err := repo.GetAsOf(context.Background(), 1, at)
+	require.NoError(t, err)
+	assert.Equal(t, "Aspirin", medicine.Name)
+	assert.Equal(t, "1234567890123", medicine.EanCode)
+	assert.Nil(t, medicine.DeletedAt)
+}
+
+func TestRepository_GetAsOf_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewMedicineRepository(db, logger)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "medicine_history"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err := repo.GetAsOf(context.Background(), 1, time.Now())
+	var appErr *domainErrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
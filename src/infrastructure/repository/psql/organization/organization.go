@@ -0,0 +1,114 @@
+package organization
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOrganization "github.com/gbrayhan/microservices-go/src/domain/organization"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DefaultName is the organization owning the data that existed before multi-tenancy
+const DefaultName = "Default"
+
+// OrganizationRepositoryInterface defines the interface for organization repository operations
+type OrganizationRepositoryInterface interface {
+	Create(ctx context.Context, organization *domainOrganization.Organization) (*domainOrganization.Organization, error)
+	GetByID(ctx context.Context, id int) (*domainOrganization.Organization, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// Organization is a tenant; it is not itself tenant-scoped
+type Organization struct {
+	ID        int       `gorm:"primaryKey"`
+	Name      string    `gorm:"uniqueIndex:idx_organizations_name"`
+	CreatedAt time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:milli"`
+}
+
+func (*Organization) TableName() string {
+	return "organizations"
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewOrganizationRepository(db *gorm.DB, loggerInstance *logger.Logger) OrganizationRepositoryInterface {
+	return &Repository{DB: db, Logger: loggerInstance}
+}
+
+func (r *Repository) Create(ctx context.Context, newOrganization *domainOrganization.Organization) (*domainOrganization.Organization, error) {
+	organization := &Organization{Name: newOrganization.Name}
+	if err := r.DB.WithContext(ctx).Create(organization).Error; err != nil {
+		r.Logger.Error("Error creating organization", zap.Error(err), zap.String("name", newOrganization.Name))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully created organization", zap.String("name", organization.Name), zap.Int("id", organization.ID))
+	return organization.toDomainMapper(), nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainOrganization.Organization, error) {
+	var organization Organization
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&organization).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Organization not found", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting organization by ID", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved organization by ID", zap.Int("id", id))
+	return organization.toDomainMapper(), nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	tx := r.DB.WithContext(ctx).Delete(&Organization{}, id)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting organization", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("Organization not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted organization", zap.Int("id", id))
+	return nil
+}
+
+// EnsureDefault returns the default organization, creating it on first use
+func EnsureDefault(db *gorm.DB) (*Organization, error) {
+	organization := &Organization{}
+	err := db.Where(Organization{Name: DefaultName}).FirstOrCreate(organization).Error
+	return organization, err
+}
+
+func (o *Organization) toDomainMapper() *domainOrganization.Organization {
+	return &domainOrganization.Organization{
+		ID:        o.ID,
+		Name:      o.Name,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+	}
+}
+
+// writeError translates a failed write into the matching application error
+func writeError(err error) error {
+	byteErr, _ := json.Marshal(err)
+	var newError domainErrors.GormErr
+	if errUnmarshal := json.Unmarshal(byteErr, &newError); errUnmarshal != nil {
+		return errUnmarshal
+	}
+	switch newError.Number {
+	case 1062:
+		return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	default:
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+}
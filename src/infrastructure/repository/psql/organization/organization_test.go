@@ -0,0 +1,90 @@
+package organization
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOrganization "github.com/gbrayhan/microservices-go/src/domain/organization"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupRepository(t *testing.T) (OrganizationRepositoryInterface, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	require.NoError(t, err)
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewOrganizationRepository(gormDB, loggerInstance), mock
+}
+
+func TestTableName(t *testing.T) {
+	assert.Equal(t, "organizations", (&Organization{}).TableName())
+}
+
+func TestRepository_Create(t *testing.T) {
+	repo, mock := setupRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "organizations"`)).
+		WithArgs("Acme Pharma", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectCommit()
+
+	created, err := repo.Create(context.Background(), &domainOrganization.Organization{Name: "Acme Pharma"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, created.ID)
+	assert.Equal(t, "Acme Pharma", created.Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetByID(t *testing.T) {
+	repo, mock := setupRepository(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "organizations" WHERE id = $1`)).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Acme Pharma"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "organizations" WHERE id = $1`)).
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	found, err := repo.GetByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Acme Pharma", found.Name)
+
+	_, err = repo.GetByID(context.Background(), 9)
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	repo, mock := setupRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "organizations" WHERE "organizations"."id" = $1`)).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "organizations"`)).
+		WithArgs(9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Delete(context.Background(), 2))
+	err := repo.Delete(context.Background(), 9)
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// notifyChannel is the Postgres channel the relay announces published messages on
+const notifyChannel = "outbox_published"
+
+// listenRetryDelay is the wait before a Listener reconnects after losing its connection
+const listenRetryDelay = 5 * time.Second
+
+// Notifier announces each published event on Postgres by its ID. As a publisher of the
+// relay it lets the Listeners of every instance learn about the changes committed
+// through any of them, although only one instance relays each message.
+type Notifier struct {
+	DB *gorm.DB
+}
+
+func NewNotifier(db *gorm.DB) *Notifier {
+	return &Notifier{DB: db}
+}
+
+func (n *Notifier) Publish(ctx context.Context, e event.Event) error {
+	return n.DB.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", notifyChannel, e.ID).Error
+}
+
+// Listener passes the events announced by the Notifiers of all instances to a
+// publisher of this instance. Events announced while its connection is down are not
+// passed on.
+type Listener struct {
+	DB        *gorm.DB
+	publisher event.Publisher
+	Logger    *logger.Logger
+}
+
+func NewListener(db *gorm.DB, publisher event.Publisher, loggerInstance *logger.Logger) *Listener {
+	return &Listener{
+		DB:        db,
+		publisher: publisher,
+		Logger:    loggerInstance,
+	}
+}
+
+// Start listens in the background until ctx is cancelled, reconnecting when the
+// connection is lost. A nil listener does nothing.
+func (l *Listener) Start(ctx context.Context) {
+	if l == nil {
+		return
+	}
+	l.Logger.Info("Listening for published outbox messages", zap.String("channel", notifyChannel))
+
+	go func() {
+		for {
+			err := l.listen(ctx)
+			if ctx.Err() != nil {
+				l.Logger.Info("Outbox listener stopped")
+				return
+			}
+			l.Logger.Error("Error listening for published outbox messages", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				l.Logger.Info("Outbox listener stopped")
+				return
+			case <-time.After(listenRetryDelay):
+			}
+		}
+	}()
+}
+
+// listen holds a connection of the pool for LISTEN until it fails or ctx is cancelled.
+// The connection is then discarded rather than returned to the pool still listening.
+func (l *Listener) listen(ctx context.Context) error {
+	sqlDB, err := l.DB.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("listening for outbox messages needs the pgx driver")
+		}
+		pgConn := stdlibConn.Conn()
+		if _, err := pgConn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+			return errors.Join(err, driver.ErrBadConn)
+		}
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return errors.Join(err, driver.ErrBadConn)
+			}
+			if err := l.deliver(ctx, notification.Payload); err != nil {
+				l.Logger.Error("Error passing on a published outbox message", zap.Error(err), zap.String("eventId", notification.Payload))
+			}
+		}
+	})
+}
+
+// deliver passes the stored event eventID on to the publisher
+func (l *Listener) deliver(ctx context.Context, eventID string) error {
+	var message Message
+	if err := l.DB.WithContext(ctx).Where("event_id = ?", eventID).Take(&message).Error; err != nil {
+		return err
+	}
+	return l.publisher.Publish(ctx, message.toEvent())
+}
@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/changefeed"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/events"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFeed(t *testing.T) (*changefeed.Feed, *changefeed.Subscription) {
+	t.Helper()
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	feed := changefeed.New(changefeed.Config{LogSize: 10, ClientBuffer: 10}, loggerInstance)
+	_, subscription, _ := feed.Subscribe(changefeed.Filter{TenantID: 1, Resources: changefeed.Resources}, "")
+	t.Cleanup(func() { feed.Unsubscribe(subscription) })
+	return feed, subscription
+}
+
+func enqueueUpdate(t *testing.T, relay *Relay, id string, aggregateID int, at time.Time) {
+	t.Helper()
+	e := event.Event{ID: id, Type: event.MedicineUpdated, AggregateType: event.AggregateMedicine, AggregateID: aggregateID, TenantID: 1, OccurredAt: at,
+		Data: []byte(`{"changes":{"name":{"before":"Aspirin","after":"Aspirin Forte"}},"snapshot":{}}`)}
+	require.NoError(t, Enqueue(relay.DB, e))
+}
+
+func TestRelay_FeedsChangeFeed(t *testing.T) {
+	broker := &recordingPublisher{failing: map[string]bool{"a1": true}}
+	feed, subscription := setupFeed(t)
+	relay, now := setupRelay(t, events.Fanout{broker, feed})
+	enqueueUpdate(t, relay, "a1", 12, *now)
+
+	_, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Len(t, subscription.C, 1, "the feed got the event although the broker failed")
+	notification := <-subscription.C
+	assert.Equal(t, event.MedicineUpdated, notification.Type)
+	assert.Equal(t, 12, notification.ResourceID)
+	assert.Equal(t, []string{"name"}, notification.Fields)
+
+	delete(broker.failing, "a1")
+	*now = now.Add(time.Minute)
+	published, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Empty(t, subscription.C, "the retry is not streamed again")
+}
+
+func TestListener_DeliversAnnouncedEvents(t *testing.T) {
+	relay, now := setupRelay(t, &recordingPublisher{})
+	feed, subscription := setupFeed(t)
+	listener := NewListener(relay.DB, feed, relay.Logger)
+	enqueueUpdate(t, relay, "a1", 12, *now)
+
+	require.NoError(t, listener.deliver(context.Background(), "a1"))
+	require.Len(t, subscription.C, 1)
+	assert.Equal(t, 12, (<-subscription.C).ResourceID)
+
+	assert.Error(t, listener.deliver(context.Background(), "unknown"))
+}
+
+func TestListener_StartNil(t *testing.T) {
+	var listener *Listener
+	listener.Start(context.Background())
+}
@@ -0,0 +1,69 @@
+// Package outbox stores the events raised by a write in the same transaction as the
+// write itself, so an event is published if and only if its change was committed.
+// The Relay then delivers the stored events to a publisher.
+package outbox
+
+import (
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"gorm.io/gorm"
+)
+
+// Message is an event waiting in the outbox table to be delivered
+type Message struct {
+	ID            int64  `gorm:"primaryKey"`
+	EventID       string `gorm:"size:36;uniqueIndex"`
+	TenantID      int    `gorm:"index"`
+	AggregateType string `gorm:"size:50;index:idx_outbox_aggregate,priority:1"`
+	AggregateID   int    `gorm:"index:idx_outbox_aggregate,priority:2"`
+	EventType     string `gorm:"size:100"`
+	Payload       string `gorm:"type:jsonb"`
+	ActorID       *int
+	OccurredAt    time.Time
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time  `gorm:"index"`
+	PublishedAt   *time.Time `gorm:"index"`
+}
+
+func (*Message) TableName() string {
+	return "outbox"
+}
+
+// Enqueue stores e in the outbox through tx. Call it inside the transaction of the
+// write raising the event.
+func Enqueue(tx *gorm.DB, e event.Event) error {
+	return tx.Create(fromEvent(e)).Error
+}
+
+func fromEvent(e event.Event) *Message {
+	payload := string(e.Data)
+	if payload == "" {
+		payload = "null"
+	}
+	return &Message{
+		EventID:       e.ID,
+		TenantID:      e.TenantID,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		EventType:     e.Type,
+		Payload:       payload,
+		ActorID:       e.ActorID,
+		OccurredAt:    e.OccurredAt,
+		NextAttemptAt: e.OccurredAt,
+	}
+}
+
+func (m *Message) toEvent() event.Event {
+	return event.Event{
+		ID:            m.EventID,
+		Type:          m.EventType,
+		AggregateType: m.AggregateType,
+		AggregateID:   m.AggregateID,
+		TenantID:      m.TenantID,
+		ActorID:       m.ActorID,
+		OccurredAt:    m.OccurredAt,
+		Data:          []byte(m.Payload),
+	}
+}
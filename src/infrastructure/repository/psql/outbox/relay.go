@@ -0,0 +1,184 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// retryBaseDelay is the wait before the first retry of a failed message, doubled on every further failure
+const retryBaseDelay = time.Second
+
+// RelayConfig holds the delivery settings of the outbox relay
+type RelayConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	MaxBackoff time.Duration
+	Retention  time.Duration
+}
+
+// LoadRelayConfig loads relay configuration from environment variables
+func LoadRelayConfig() RelayConfig {
+	return RelayConfig{
+		Interval:   time.Duration(config.EnvAsIntOrDefault("OUTBOX_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+		BatchSize:  config.EnvAsIntOrDefault("OUTBOX_BATCH_SIZE", 100),
+		MaxBackoff: time.Duration(config.EnvAsIntOrDefault("OUTBOX_MAX_BACKOFF_SECONDS", 300)) * time.Second,
+		Retention:  time.Duration(config.EnvAsIntOrDefault("OUTBOX_RETENTION_HOURS", 168)) * time.Hour,
+	}
+}
+
+// Relay delivers the messages of the outbox to a publisher, oldest first. Messages of
+// one aggregate are published in the order they were stored: while a message waits
+// for a retry the later messages of its aggregate wait too. Delivery is at least once,
+// consumers discard duplicates by event ID.
+type Relay struct {
+	DB        *gorm.DB
+	publisher event.Publisher
+	config    RelayConfig
+	Logger    *logger.Logger
+	now       func() time.Time
+}
+
+func NewRelay(db *gorm.DB, publisher event.Publisher, config RelayConfig, loggerInstance *logger.Logger) *Relay {
+	return &Relay{
+		DB:        db,
+		publisher: publisher,
+		config:    config,
+		Logger:    loggerInstance,
+		now:       time.Now,
+	}
+}
+
+// Start runs the relay loop in the background until ctx is cancelled. A nil relay does nothing.
+func (r *Relay) Start(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	if r.config.Interval <= 0 {
+		r.Logger.Info("Outbox relay disabled: interval is not positive")
+		return
+	}
+	r.Logger.Info("Starting outbox relay",
+		zap.Duration("interval", r.config.Interval),
+		zap.Int("batchSize", r.config.BatchSize))
+
+	go func() {
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				r.Logger.Info("Outbox relay stopped")
+				return
+			case <-ticker.C:
+				// drain a backlog without waiting a tick per batch: relay again while
+				// every message of the batch published. A failed one leaves the batch
+				// short, so a broker outage waits for the next tick instead of spinning.
+				for {
+					published, err := r.RunOnce(ctx)
+					if err != nil {
+						r.Logger.Error("Error relaying outbox messages", zap.Error(err))
+					}
+					if err != nil || published < r.config.BatchSize || ctx.Err() != nil {
+						break
+					}
+				}
+				if _, err := r.Cleanup(ctx); err != nil {
+					r.Logger.Error("Error cleaning up the outbox", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// RunOnce delivers one batch of due messages and returns the number of published ones.
+// On Postgres the batch stays locked until it is marked, so relays running in several
+// instances never deliver the same message concurrently. SQLite runs a single instance
+// on a single connection, which publishers writing to the database need as well, so
+// there the batch is not held in a transaction.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	now := r.now()
+	published := 0
+	relay := func(tx *gorm.DB) error {
+		// a message is held back while an earlier one of its aggregate waits for a retry
+		query := tx.Where("published_at IS NULL AND next_attempt_at <= ?", now).
+			Where(`NOT EXISTS (SELECT 1 FROM outbox earlier
+				WHERE earlier.aggregate_type = outbox.aggregate_type
+				AND earlier.aggregate_id = outbox.aggregate_id
+				AND earlier.id < outbox.id
+				AND earlier.published_at IS NULL
+				AND earlier.next_attempt_at > ?)`, now).
+			Order("id").
+			Limit(r.config.BatchSize)
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var messages []Message
+		if err := query.Find(&messages).Error; err != nil {
+			return err
+		}
+
+		blocked := make(map[aggregateKey]bool)
+		for i := range messages {
+			message := &messages[i]
+			key := aggregateKey{message.AggregateType, message.AggregateID}
+			if blocked[key] {
+				continue
+			}
+			updates := map[string]any{"attempts": message.Attempts + 1}
+			if err := r.publisher.Publish(ctx, message.toEvent()); err != nil {
+				blocked[key] = true
+				updates["last_error"] = err.Error()
+				updates["next_attempt_at"] = now.Add(r.backoff(message.Attempts + 1))
+				r.Logger.Warn("Error publishing outbox message",
+					zap.Error(err),
+					zap.String("eventId", message.EventID),
+					zap.String("type", message.EventType),
+					zap.Int("attempts", message.Attempts+1))
+			} else {
+				updates["last_error"] = ""
+				updates["published_at"] = now
+				published++
+			}
+			if err := tx.Model(&Message{}).Where("id = ?", message.ID).UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	db := r.DB.WithContext(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return published, relay(db)
+	}
+	return published, db.Transaction(relay)
+}
+
+// Cleanup removes the messages published before the retention period and returns their number
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	cutoff := r.now().Add(-r.config.Retention)
+	result := r.DB.WithContext(ctx).Where("published_at < ?", cutoff).Delete(&Message{})
+	return result.RowsAffected, result.Error
+}
+
+// backoff returns the wait before the next attempt of a message that failed attempts times
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if r.config.MaxBackoff > 0 && delay > r.config.MaxBackoff {
+		return r.config.MaxBackoff
+	}
+	return delay
+}
+
+type aggregateKey struct {
+	aggregateType string
+	aggregateID   int
+}
@@ -0,0 +1,196 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/events"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// recordingPublisher keeps the published events and fails those listed in failing
+type recordingPublisher struct {
+	published []event.Event
+	failing   map[string]bool
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, e event.Event) error {
+	if p.failing[e.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, e)
+	return nil
+}
+
+func setupRelay(t *testing.T, publisher event.Publisher) (*Relay, *time.Time) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&Message{}))
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	relay := NewRelay(db, publisher, RelayConfig{BatchSize: 10, MaxBackoff: time.Minute, Retention: time.Hour}, loggerInstance)
+	relay.now = func() time.Time { return now }
+	return relay, &now
+}
+
+func enqueue(t *testing.T, relay *Relay, id string, aggregateID int, at time.Time) {
+	t.Helper()
+	e := event.Event{ID: id, Type: event.MedicineUpdated, AggregateType: event.AggregateMedicine, AggregateID: aggregateID, OccurredAt: at, Data: []byte(`{"changes":{}}`)}
+	require.NoError(t, Enqueue(relay.DB, e))
+}
+
+func publishedIDs(publisher *recordingPublisher) []string {
+	ids := make([]string, 0, len(publisher.published))
+	for _, e := range publisher.published {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestRelay_PublishesInOrder(t *testing.T) {
+	publisher := &recordingPublisher{}
+	relay, now := setupRelay(t, publisher)
+	enqueue(t, relay, "a1", 1, *now)
+	enqueue(t, relay, "b1", 2, *now)
+	enqueue(t, relay, "a2", 1, *now)
+
+	published, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, published)
+	assert.Equal(t, []string{"a1", "b1", "a2"}, publishedIDs(publisher))
+	assert.JSONEq(t, `{"changes":{}}`, string(publisher.published[0].Data))
+
+	published, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published, "published messages are not delivered twice")
+}
+
+func TestRelay_RetryHoldsBackAggregate(t *testing.T) {
+	publisher := &recordingPublisher{failing: map[string]bool{"a1": true}}
+	relay, now := setupRelay(t, publisher)
+	enqueue(t, relay, "a1", 1, *now)
+	enqueue(t, relay, "a2", 1, *now)
+	enqueue(t, relay, "b1", 2, *now)
+
+	published, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"b1"}, publishedIDs(publisher))
+
+	var failed Message
+	require.NoError(t, relay.DB.Where("event_id = ?", "a1").First(&failed).Error)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "broker unavailable", failed.LastError)
+	assert.True(t, failed.NextAttemptAt.Equal(now.Add(time.Second)))
+
+	*now = now.Add(500 * time.Millisecond)
+	published, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published, "a2 waits for a1 to be retried")
+
+	delete(publisher.failing, "a1")
+	*now = now.Add(time.Second)
+	published, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"b1", "a1", "a2"}, publishedIDs(publisher))
+}
+
+// flakySender times out while the broker is down
+type flakySender struct {
+	down bool
+	sent []string
+}
+
+func (s *flakySender) Send(_ context.Context, message events.Message) error {
+	if s.down {
+		return context.DeadlineExceeded
+	}
+	s.sent = append(s.sent, message.ID)
+	return nil
+}
+
+func (s *flakySender) Close() error { return nil }
+
+func TestRelay_BrokerOutageHoldsBackAggregate(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	sender := &flakySender{down: true}
+	publisher := events.NewBrokerPublisher(sender, events.Config{
+		Topics:          events.Topics{Template: "{type}"},
+		DeadLetterTopic: "events.dead-letter",
+		Attempts:        2,
+		RetryBackoff:    time.Millisecond,
+	}, loggerInstance)
+	relay, now := setupRelay(t, publisher)
+	enqueue(t, relay, "a1", 1, *now)
+	enqueue(t, relay, "a2", 1, *now)
+
+	published, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published)
+	assert.Empty(t, sender.sent, "a1 is neither dead-lettered nor overtaken by a2")
+
+	sender.down = false
+	*now = now.Add(time.Minute)
+	published, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"a1", "a2"}, sender.sent)
+}
+
+func TestRelay_DelayedMessagesWait(t *testing.T) {
+	publisher := &recordingPublisher{}
+	relay, now := setupRelay(t, publisher)
+	enqueue(t, relay, "later", 1, now.Add(time.Minute))
+
+	published, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published)
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay := &Relay{config: RelayConfig{MaxBackoff: 10 * time.Second}}
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 2*time.Second, relay.backoff(2))
+	assert.Equal(t, 8*time.Second, relay.backoff(4))
+	assert.Equal(t, 10*time.Second, relay.backoff(5))
+	assert.Equal(t, 10*time.Second, relay.backoff(50))
+}
+
+func TestRelay_Cleanup(t *testing.T) {
+	publisher := &recordingPublisher{}
+	relay, now := setupRelay(t, publisher)
+	enqueue(t, relay, "old", 1, *now)
+	enqueue(t, relay, "pending", 2, now.Add(time.Hour))
+	_, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	*now = now.Add(2 * time.Hour)
+	removed, err := relay.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	var remaining []Message
+	require.NoError(t, relay.DB.Find(&remaining).Error)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "pending", remaining[0].EventID)
+}
+
+func TestRelay_StartNil(t *testing.T) {
+	var relay *Relay
+	relay.Start(context.Background())
+}
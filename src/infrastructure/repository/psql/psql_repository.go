@@ -1,13 +1,27 @@
 package psql
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/audit"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/encryption"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/job"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/organization"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/outbox"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/replica"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/tenant"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security/envelope"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
@@ -23,8 +37,24 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	TimeZone string
+	// ReplicaHosts lists read replicas as host or host:port; they share the primary's credentials
+	ReplicaHosts []string
+	// ReplicaHealthInterval is how often replicas are pinged to eject or reinstate them
+	ReplicaHealthInterval time.Duration
+	Pool                  PoolConfig
 }
 
+// PoolConfig holds the connection pool limits applied to the primary and every replica
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+const defaultTimeZone = "America/Mexico_City"
+
 // loadDatabaseConfig loads database configuration from environment variables
 // Returns error if any required environment variable is missing
 func loadDatabaseConfig() (DatabaseConfig, error) {
@@ -61,19 +91,50 @@ func loadDatabaseConfig() (DatabaseConfig, error) {
 	}
 
 	return DatabaseConfig{
-		Host:     host,
-		Port:     port,
-		User:     user,
-		Password: password,
-		DBName:   dbName,
-		SSLMode:  sslMode,
+		Host:                  host,
+		Port:                  port,
+		User:                  user,
+		Password:              password,
+		DBName:                dbName,
+		SSLMode:               sslMode,
+		TimeZone:              config.EnvOrDefault("DB_TIMEZONE", defaultTimeZone),
+		ReplicaHosts:          splitList(os.Getenv("DB_REPLICA_HOSTS")),
+		ReplicaHealthInterval: time.Duration(config.EnvAsIntOrDefault("DB_REPLICA_HEALTH_INTERVAL", 10)) * time.Second,
+		Pool:                  loadPoolConfig(),
 	}, nil
 }
 
+// loadPoolConfig loads connection pool limits from environment variables.
+// Lifetimes are in seconds; zero leaves connections open indefinitely.
+func loadPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    config.EnvAsIntOrDefault("DB_MAX_OPEN_CONNS", 50),
+		MaxIdleConns:    config.EnvAsIntOrDefault("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: time.Duration(config.EnvAsIntOrDefault("DB_CONN_MAX_LIFETIME", 300)) * time.Second,
+		ConnMaxIdleTime: time.Duration(config.EnvAsIntOrDefault("DB_CONN_MAX_IDLE_TIME", 0)) * time.Second,
+	}
+}
+
+// Apply sets the pool limits on the connection pool behind db
+func (c PoolConfig) Apply(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	return nil
+}
+
 type PSQLRepository struct {
-	DB     *gorm.DB
-	Logger *logger.Logger
-	Auth   AuthService
+	DB       *gorm.DB
+	Replicas *replica.Set
+	Logger   *logger.Logger
+	Auth     AuthService
+	// cipher encrypts user PII on the primary and decrypts it on every replica; nil disables it
+	cipher *envelope.Cipher
 }
 
 type AuthService interface {
@@ -100,13 +161,30 @@ func (r *PSQLRepository) LoadDBConfig() (DatabaseConfig, error) {
 }
 
 func (c DatabaseConfig) GetDSN() string {
-	return "host=" + c.Host +
-		" port=" + c.Port +
+	return c.dsn(c.Host, c.Port)
+}
+
+// GetReplicaDSN returns the DSN of a replica given as host or host:port
+func (c DatabaseConfig) GetReplicaDSN(replicaHost string) string {
+	host, port, found := strings.Cut(replicaHost, ":")
+	if !found {
+		port = c.Port
+	}
+	return c.dsn(host, port)
+}
+
+func (c DatabaseConfig) dsn(host, port string) string {
+	timeZone := c.TimeZone
+	if timeZone == "" {
+		timeZone = defaultTimeZone
+	}
+	return "host=" + host +
+		" port=" + port +
 		" user=" + c.User +
 		" password=" + c.Password +
 		" dbname=" + c.DBName +
 		" sslmode=" + c.SSLMode +
-		" TimeZone=America/Mexico_City"
+		" TimeZone=" + timeZone
 }
 
 func (r *PSQLRepository) InitDatabase() error {
@@ -128,6 +206,102 @@ func (r *PSQLRepository) InitDatabase() error {
 		return err
 	}
 
+	err = cfg.Pool.Apply(r.DB)
+	if err != nil {
+		r.Logger.Error("Error configuring the connection pool", zap.Error(err))
+		return err
+	}
+
+	err = r.prepareDatabase()
+	if err != nil {
+		return err
+	}
+
+	return r.openReplicas(cfg, gormZap)
+}
+
+// openReplicas connects to the configured read replicas. Replicas are not pinged on
+// open so an unreachable one does not block start-up; the health checks eject it instead.
+func (r *PSQLRepository) openReplicas(cfg DatabaseConfig, gormLogger gormlogger.Interface) error {
+	replicas := make([]*replica.Replica, 0, len(cfg.ReplicaHosts))
+	for _, host := range cfg.ReplicaHosts {
+		db, err := gorm.Open(postgres.Open(cfg.GetReplicaDSN(host)), &gorm.Config{
+			Logger:               gormLogger,
+			DisableAutomaticPing: true,
+		})
+		if err != nil {
+			r.Logger.Error("Error connecting to read replica", zap.Error(err), zap.String("replica", host))
+			return err
+		}
+		err = cfg.Pool.Apply(db)
+		if err != nil {
+			r.Logger.Error("Error configuring the read replica connection pool", zap.Error(err), zap.String("replica", host))
+			return err
+		}
+		replicas = append(replicas, &replica.Replica{Name: host, DB: db})
+	}
+	err := r.setReplicas(replicas, cfg.ReplicaHealthInterval)
+	if err != nil {
+		return err
+	}
+	r.Replicas.CheckHealth(context.Background())
+	r.Logger.Info("Read replicas configured", zap.Int("configured", len(replicas)), zap.Strings("healthy", r.Replicas.Healthy()))
+	return nil
+}
+
+// setReplicas registers the same GORM callbacks as the primary on every replica, so
+// reads they serve are scoped to the tenant and decrypted, and builds the replica set
+func (r *PSQLRepository) setReplicas(replicas []*replica.Replica, healthInterval time.Duration) error {
+	for _, readReplica := range replicas {
+		err := r.registerPlugins(readReplica.DB)
+		if err != nil {
+			return err
+		}
+	}
+	r.Replicas = replica.NewSet(r.DB, replicas, healthInterval, r.Logger)
+	return nil
+}
+
+// registerPlugins registers the audit, tenant and PII encryption callbacks on db
+func (r *PSQLRepository) registerPlugins(db *gorm.DB) error {
+	err := audit.RegisterCallbacks(db)
+	if err != nil {
+		r.Logger.Error("Error registering audit callbacks", zap.Error(err))
+		return err
+	}
+
+	err = tenant.RegisterCallbacks(db)
+	if err != nil {
+		r.Logger.Error("Error registering tenant callbacks", zap.Error(err))
+		return err
+	}
+
+	err = encryption.RegisterCallbacks(db, r.cipher)
+	if err != nil {
+		r.Logger.Error("Error registering encryption callbacks", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// prepareDatabase registers the GORM callbacks, migrates the schema and seeds the
+// initial user on the freshly opened r.DB
+func (r *PSQLRepository) prepareDatabase() error {
+	cipher, err := envelope.NewCipher(envelope.LoadConfig())
+	if err != nil {
+		r.Logger.Error("Error loading PII encryption keys", zap.Error(err))
+		return err
+	}
+	r.cipher = cipher
+
+	err = r.registerPlugins(r.DB)
+	if err != nil {
+		return err
+	}
+	if cipher != nil {
+		r.Logger.Info("Encrypting user personal data", zap.String("activeKeyId", cipher.ActiveKeyID()))
+	}
+
 	err = r.MigrateEntitiesGORM()
 	if err != nil {
 		r.Logger.Error("Error migrating the database", zap.Error(err))
@@ -146,20 +320,124 @@ func (r *PSQLRepository) InitDatabase() error {
 
 func (r *PSQLRepository) MigrateEntitiesGORM() error {
 	// Import the models to register them with GORM
+	organizationModel := &organization.Organization{}
 	userModel := &user.User{}
+	laboratoryModel := &laboratory.Laboratory{}
 	medicineModel := &medicine.Medicine{}
+	activeIngredientModel := &medicine.ActiveIngredient{}
+	medicineIngredientModel := &medicine.MedicineIngredient{}
+	medicineEquivalenceModel := &medicine.Equivalence{}
+	medicineInteractionModel := &medicine.Interaction{}
+	ingredientClassModel := &medicine.IngredientClass{}
+	userHistoryModel := &user.UserHistory{}
+	medicineHistoryModel := &medicine.MedicineHistory{}
+	outboxModel := &outbox.Message{}
+	webhookModel := &webhook.Webhook{}
+	webhookDeliveryModel := &webhook.Delivery{}
+	jobModel := &job.Job{}
+	stockLevelModel := &stock.StockLevel{}
+	medicineLotModel := &stock.MedicineLot{}
+	stockMovementModel := &stock.StockMovement{}
+	locationModel := &stock.Location{}
+	stockTransferModel := &stock.Transfer{}
+	stockThresholdModel := &stock.Threshold{}
+	stockAlertModel := &stock.Alert{}
 
 	// Auto migrate the models to create/update tables
-	err := r.DB.AutoMigrate(userModel, medicineModel)
+	err := r.DB.AutoMigrate(organizationModel, userModel, laboratoryModel, medicineModel, activeIngredientModel, medicineIngredientModel, medicineEquivalenceModel, medicineInteractionModel, ingredientClassModel, userHistoryModel, medicineHistoryModel, outboxModel, webhookModel, webhookDeliveryModel, jobModel, stockLevelModel, medicineLotModel, stockMovementModel, locationModel, stockTransferModel, stockThresholdModel, stockAlertModel)
 	if err != nil {
 		r.Logger.Error("Error migrating database entities", zap.Error(err))
 		return err
 	}
 
+	err = r.dropLegacyUniqueConstraints()
+	if err != nil {
+		r.Logger.Error("Error dropping legacy unique constraints", zap.Error(err))
+		return err
+	}
+
+	err = r.assignDefaultTenant()
+	if err != nil {
+		r.Logger.Error("Error assigning rows to the default organization", zap.Error(err))
+		return err
+	}
+
 	r.Logger.Info("Database entities migration completed successfully")
 	return nil
 }
 
+// dropLegacyUniqueConstraints removes the table-wide unique constraints created before
+// soft deletion, which are superseded by partial unique indexes ignoring deleted rows
+func (r *PSQLRepository) dropLegacyUniqueConstraints() error {
+	legacyConstraints := []struct {
+		model any
+		name  string
+	}{
+		{&user.User{}, "uni_users_user_name"},
+		{&user.User{}, "uni_users_email"},
+		{&medicine.Medicine{}, "uni_medicines_name"},
+		{&medicine.Medicine{}, "uni_medicines_ean_code"},
+	}
+
+	migrator := r.DB.Migrator()
+	for _, legacy := range legacyConstraints {
+		if !migrator.HasConstraint(legacy.model, legacy.name) {
+			continue
+		}
+		if err := migrator.DropConstraint(legacy.model, legacy.name); err != nil {
+			return err
+		}
+		r.Logger.Info("Dropped legacy unique constraint", zap.String("constraint", legacy.name))
+	}
+
+	// Medicine names and EAN codes used to be unique across the whole table; they are
+	// now unique per organization. Lot numbers used to be unique per medicine; they are
+	// now unique per medicine and location.
+	legacyIndexes := []struct {
+		model any
+		name  string
+	}{
+		{&medicine.Medicine{}, "idx_medicines_name"},
+		{&medicine.Medicine{}, "idx_medicines_ean_code"},
+		{&stock.MedicineLot{}, "idx_medicine_lots_number"},
+	}
+	for _, legacy := range legacyIndexes {
+		if !migrator.HasIndex(legacy.model, legacy.name) {
+			continue
+		}
+		if err := migrator.DropIndex(legacy.model, legacy.name); err != nil {
+			return err
+		}
+		r.Logger.Info("Dropped legacy unique index", zap.String("index", legacy.name))
+	}
+	return nil
+}
+
+// assignDefaultTenant moves rows created before multi-tenancy, which have no
+// organization, to the default organization
+func (r *PSQLRepository) assignDefaultTenant() error {
+	defaultOrganization, err := organization.EnsureDefault(r.DB)
+	if err != nil {
+		return err
+	}
+
+	tenantModels := []any{&user.User{}, &medicine.Medicine{}, &user.UserHistory{}, &medicine.MedicineHistory{}}
+	for _, model := range tenantModels {
+		tx := r.DB.Unscoped().Model(model).
+			Where("tenant_id IS NULL OR tenant_id = 0").
+			UpdateColumn("tenant_id", defaultOrganization.ID)
+		if tx.Error != nil {
+			return tx.Error
+		}
+		if tx.RowsAffected > 0 {
+			r.Logger.Info("Assigned rows to the default organization",
+				zap.String("table", tx.Statement.Table),
+				zap.Int64("rows", tx.RowsAffected))
+		}
+	}
+	return nil
+}
+
 func (r *PSQLRepository) SeedInitialUser() error {
 	email := os.Getenv("START_USER_EMAIL")
 	pw := os.Getenv("START_USER_PW")
@@ -170,7 +448,7 @@ func (r *PSQLRepository) SeedInitialUser() error {
 
 	// Check if user already exists
 	var existingUser user.User
-	err := r.DB.Where("email = ?", email).First(&existingUser).Error
+	err := r.DB.Where(encryption.Match(r.DB, &user.User{}, "email", []string{email})).First(&existingUser).Error
 	if err == nil {
 		r.Logger.Info("Initial user already exists, skipping seed", zap.String("email", email))
 		return nil
@@ -183,7 +461,14 @@ func (r *PSQLRepository) SeedInitialUser() error {
 		return err
 	}
 
+	defaultOrganization, err := organization.EnsureDefault(r.DB)
+	if err != nil {
+		r.Logger.Error("Error getting default organization for initial user", zap.Error(err))
+		return err
+	}
+
 	newUser := user.User{
+		TenantID:     defaultOrganization.ID,
 		Email:        email,
 		HashPassword: string(hashedPassword),
 	}
@@ -200,14 +485,30 @@ func (r *PSQLRepository) SeedInitialUser() error {
 
 // InitPSQLDB initializes the database connection with logger
 func InitPSQLDB(loggerInstance *logger.Logger) (*gorm.DB, error) {
+	db, _, err := InitPSQLDBWithReplicas(loggerInstance)
+	return db, err
+}
+
+// InitPSQLDBWithReplicas initializes the primary connection together with the read replica set
+func InitPSQLDBWithReplicas(loggerInstance *logger.Logger) (*gorm.DB, *replica.Set, error) {
 	repo := &PSQLRepository{
 		Logger: loggerInstance,
 	}
 
 	err := repo.InitDatabase()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return repo.DB, nil
+	return repo.DB, repo.Replicas, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,61 @@
+package psql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setDatabaseEnv(t *testing.T) {
+	t.Setenv("DB_HOST", "primary")
+	t.Setenv("DB_PORT", "5432")
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("DB_NAME", "app")
+	t.Setenv("DB_SSLMODE", "disable")
+}
+
+func TestLoadDatabaseConfig_Defaults(t *testing.T) {
+	setDatabaseEnv(t)
+
+	cfg, err := loadDatabaseConfig()
+
+	require.NoError(t, err)
+	assert.Equal(t, "America/Mexico_City", cfg.TimeZone)
+	assert.Empty(t, cfg.ReplicaHosts)
+	assert.Equal(t, 10*time.Second, cfg.ReplicaHealthInterval)
+	assert.Equal(t, PoolConfig{MaxOpenConns: 50, MaxIdleConns: 10, ConnMaxLifetime: 300 * time.Second}, cfg.Pool)
+}
+
+func TestLoadDatabaseConfig_ReplicasAndPool(t *testing.T) {
+	setDatabaseEnv(t)
+	t.Setenv("DB_TIMEZONE", "UTC")
+	t.Setenv("DB_REPLICA_HOSTS", "replica-1, replica-2:5433,")
+	t.Setenv("DB_REPLICA_HEALTH_INTERVAL", "30")
+	t.Setenv("DB_MAX_OPEN_CONNS", "20")
+	t.Setenv("DB_MAX_IDLE_CONNS", "5")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "60")
+	t.Setenv("DB_CONN_MAX_IDLE_TIME", "15")
+
+	cfg, err := loadDatabaseConfig()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"replica-1", "replica-2:5433"}, cfg.ReplicaHosts)
+	assert.Equal(t, 30*time.Second, cfg.ReplicaHealthInterval)
+	assert.Equal(t, PoolConfig{MaxOpenConns: 20, MaxIdleConns: 5, ConnMaxLifetime: time.Minute, ConnMaxIdleTime: 15 * time.Second}, cfg.Pool)
+	assert.Contains(t, cfg.GetDSN(), "host=primary port=5432")
+	assert.Contains(t, cfg.GetDSN(), "TimeZone=UTC")
+	assert.Contains(t, cfg.GetReplicaDSN("replica-1"), "host=replica-1 port=5432")
+	assert.Contains(t, cfg.GetReplicaDSN("replica-2:5433"), "host=replica-2 port=5433")
+}
+
+func TestLoadDatabaseConfig_MissingVariables(t *testing.T) {
+	setDatabaseEnv(t)
+	t.Setenv("DB_HOST", "")
+
+	_, err := loadDatabaseConfig()
+
+	assert.ErrorContains(t, err, "DB_HOST")
+}
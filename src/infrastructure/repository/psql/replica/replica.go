@@ -0,0 +1,139 @@
+package replica
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const pingTimeout = 2 * time.Second
+
+// Replica is a read-only copy of the primary database
+type Replica struct {
+	Name    string
+	DB      *gorm.DB
+	healthy atomic.Bool
+}
+
+// Set routes read-only queries to its healthy replicas in turn and falls back to
+// the primary when none is available. Replicas failing a health check are ejected
+// until they answer again.
+type Set struct {
+	primary  *gorm.DB
+	replicas []*Replica
+	next     atomic.Uint64
+	interval time.Duration
+	Logger   *logger.Logger
+}
+
+// NewSet builds a replica set; every replica starts healthy until its first check
+func NewSet(primary *gorm.DB, replicas []*Replica, interval time.Duration, loggerInstance *logger.Logger) *Set {
+	for _, replica := range replicas {
+		replica.healthy.Store(true)
+	}
+	return &Set{
+		primary:  primary,
+		replicas: replicas,
+		interval: interval,
+		Logger:   loggerInstance,
+	}
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a copy of ctx whose reads go to the primary, for reads that must
+// see the writes committed just before them
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryRequested reports whether the reads of ctx must go to the primary
+func PrimaryRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(primaryKey{}).(bool)
+	return requested
+}
+
+// Primary returns the connection used for writes
+func (s *Set) Primary() *gorm.DB {
+	return s.primary
+}
+
+// Reader returns the connection for the next read-only query
+func (s *Set) Reader() *gorm.DB {
+	count := uint64(len(s.replicas))
+	for range count {
+		replica := s.replicas[s.next.Add(1)%count]
+		if replica.healthy.Load() {
+			return replica.DB
+		}
+	}
+	return s.primary
+}
+
+// Healthy returns the names of the replicas currently receiving reads
+func (s *Set) Healthy() []string {
+	names := []string{}
+	for _, replica := range s.replicas {
+		if replica.healthy.Load() {
+			names = append(names, replica.Name)
+		}
+	}
+	return names
+}
+
+// CheckHealth pings every replica, ejecting those that fail and reinstating those that recovered
+func (s *Set) CheckHealth(ctx context.Context) {
+	for _, replica := range s.replicas {
+		err := ping(ctx, replica.DB)
+		wasHealthy := replica.healthy.Swap(err == nil)
+		switch {
+		case err != nil && wasHealthy:
+			s.Logger.Warn("Ejecting unhealthy read replica", zap.String("replica", replica.Name), zap.Error(err))
+		case err == nil && !wasHealthy:
+			s.Logger.Info("Read replica recovered", zap.String("replica", replica.Name))
+		}
+	}
+}
+
+// Start runs the health checks in the background until ctx is cancelled
+func (s *Set) Start(ctx context.Context) {
+	if s == nil || len(s.replicas) == 0 {
+		return
+	}
+	if s.interval <= 0 {
+		s.Logger.Info("Read replica health checks disabled: interval is not positive")
+		return
+	}
+	s.Logger.Info("Starting read replica health checks",
+		zap.Int("replicas", len(s.replicas)),
+		zap.Duration("interval", s.interval))
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		s.CheckHealth(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				s.Logger.Info("Read replica health checks stopped")
+				return
+			case <-ticker.C:
+				s.CheckHealth(ctx)
+			}
+		}
+	}()
+}
+
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,90 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	return gormDB, mock
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return loggerInstance
+}
+
+func TestReader_RoundRobin(t *testing.T) {
+	primary, _ := setupMockDB(t)
+	first, _ := setupMockDB(t)
+	second, _ := setupMockDB(t)
+	set := NewSet(primary, []*Replica{{Name: "r1", DB: first}, {Name: "r2", DB: second}}, 0, setupLogger(t))
+
+	readers := []*gorm.DB{set.Reader(), set.Reader(), set.Reader()}
+
+	assert.Same(t, second, readers[0])
+	assert.Same(t, first, readers[1])
+	assert.Same(t, second, readers[2])
+	assert.Same(t, primary, set.Primary())
+}
+
+func TestReader_WithoutReplicasUsesPrimary(t *testing.T) {
+	primary, _ := setupMockDB(t)
+	set := NewSet(primary, nil, 0, setupLogger(t))
+
+	assert.Same(t, primary, set.Reader())
+}
+
+func TestWithPrimary(t *testing.T) {
+	assert.False(t, PrimaryRequested(context.Background()))
+	assert.True(t, PrimaryRequested(WithPrimary(context.Background())))
+}
+
+func TestCheckHealth_EjectsAndReinstates(t *testing.T) {
+	primary, _ := setupMockDB(t)
+	healthyDB, healthyMock := setupMockDB(t)
+	failingDB, failingMock := setupMockDB(t)
+	set := NewSet(primary, []*Replica{{Name: "healthy", DB: healthyDB}, {Name: "failing", DB: failingDB}}, 0, setupLogger(t))
+
+	healthyMock.ExpectPing()
+	failingMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	set.CheckHealth(context.Background())
+
+	assert.Equal(t, []string{"healthy"}, set.Healthy())
+	for range 3 {
+		assert.Same(t, healthyDB, set.Reader())
+	}
+
+	healthyMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	failingMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	set.CheckHealth(context.Background())
+	assert.Empty(t, set.Healthy())
+	assert.Same(t, primary, set.Reader())
+
+	healthyMock.ExpectPing()
+	failingMock.ExpectPing()
+	set.CheckHealth(context.Background())
+	assert.Equal(t, []string{"healthy", "failing"}, set.Healthy())
+
+	assert.NoError(t, healthyMock.ExpectationsWereMet())
+	assert.NoError(t, failingMock.ExpectationsWereMet())
+}
+
+func TestStart_NilSet(t *testing.T) {
+	var set *Set
+	assert.NotPanics(t, func() { set.Start(context.Background()) })
+}
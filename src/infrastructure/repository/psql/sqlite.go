@@ -0,0 +1,63 @@
+package psql
+
+import (
+	"os"
+	"time"
+
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const defaultSQLitePath = "microservices.db"
+
+// InitSQLiteDB opens the SQLite database at SQLITE_PATH (":memory:" keeps it in memory)
+// and prepares it the same way InitPSQLDB prepares PostgreSQL
+func InitSQLiteDB(loggerInstance *logger.Logger) (*gorm.DB, error) {
+	repo, err := openSQLite(loggerInstance)
+	if err != nil {
+		return nil, err
+	}
+	return repo.DB, nil
+}
+
+// openSQLite opens and prepares the SQLite database, returning the repository holding it
+func openSQLite(loggerInstance *logger.Logger) (*PSQLRepository, error) {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = defaultSQLitePath
+	}
+
+	gormZap := logger.NewGormLogger(loggerInstance.Log).
+		LogMode(gormlogger.Warn)
+
+	db, err := gorm.Open(sqlite.Open(path), sqliteConfig(gormZap))
+	if err != nil {
+		loggerInstance.Error("Error opening the SQLite database", zap.Error(err), zap.String("path", path))
+		return nil, err
+	}
+
+	// SQLite allows a single writer, and every connection to ":memory:" opens a new database
+	sqlDB, err := db.DB()
+	if err != nil {
+		loggerInstance.Error("Error getting the SQLite connection pool", zap.Error(err))
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	repo := NewRepository(db, loggerInstance)
+	if err := repo.prepareDatabase(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func sqliteConfig(gormLogger gormlogger.Interface) *gorm.Config {
+	return &gorm.Config{
+		Logger: gormLogger,
+		// SQLite compares timestamps as text, so every stored time must share one offset
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	}
+}
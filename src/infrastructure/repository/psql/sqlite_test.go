@@ -0,0 +1,605 @@
+package psql
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	domainLaboratory "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	domainMedicine "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/outbox"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/replica"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupSQLite(t *testing.T) *logger.Logger {
+	t.Setenv("SQLITE_PATH", ":memory:")
+	t.Setenv("START_USER_EMAIL", "admin@example.com")
+	t.Setenv("START_USER_PW", "secret")
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return loggerInstance
+}
+
+func TestInitSQLiteDB_SeedsInitialUser(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+
+	seeded, err := user.NewUserRepository(db, loggerInstance).GetByEmail(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	assert.NotEmpty(t, seeded.HashPassword)
+}
+
+func TestInitSQLiteDB_MedicineRepository(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	repo := medicine.NewMedicineRepository(db, loggerInstance)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 3})
+
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501", Laboratory: "Bayer"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, *created.CreatedBy)
+	_, err = repo.Create(ctx, &domainMedicine.Medicine{Name: "Ibuprofen", EanCode: "7502", Laboratory: "Pfizer"})
+	require.NoError(t, err)
+
+	result, err := repo.SearchPaginated(ctx, domain.DataFilters{
+		LikeFilters: map[string][]string{"name": {"ASPI"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 1)
+	assert.Equal(t, "Aspirin", (*result.Data)[0].Name)
+
+	coincidences, err := repo.SearchByProperty(ctx, "laboratory", "fiz")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pfizer"}, *coincidences)
+
+	require.NoError(t, repo.Delete(ctx, created.ID))
+	_, err = repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err, "partial unique indexes must ignore deleted rows")
+
+	entries, err := repo.GetHistory(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, *entries, 2)
+	assert.Equal(t, domainHistory.ActionDelete, (*entries)[1].Action)
+
+	asOf, err := repo.GetAsOf(ctx, created.ID, time.Now())
+	require.NoError(t, err)
+	assert.NotNil(t, asOf.DeletedAt)
+}
+
+func TestInitSQLiteDB_MedicineStockQuantity(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	repo := medicine.NewMedicineRepository(db, loggerInstance)
+	stockRepo := stock.NewStockRepository(db, loggerInstance)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+
+	quantities := map[string][]int{"Aspirin": {4, 8}, "Ibuprofen": {2}, "Paracetamol": nil}
+	for name, deltas := range quantities {
+		created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: name, EanCode: name})
+		require.NoError(t, err)
+		for i, delta := range deltas {
+			_, err := stockRepo.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: created.ID, Location: strconv.Itoa(i), Delta: delta})
+			require.NoError(t, err)
+		}
+	}
+
+	high := 5
+	result, err := repo.SearchPaginated(ctx, domain.DataFilters{
+		NumberRangeFilters: []domain.NumberRangeFilter{{Field: "stockQuantity", Max: &high}},
+		SortBy:             []string{"stockQuantity"},
+		SortDirection:      domain.SortDesc,
+	})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 2)
+	assert.Equal(t, "Ibuprofen", (*result.Data)[0].Name)
+	assert.Equal(t, 2, *(*result.Data)[0].StockQuantity)
+	assert.Equal(t, 0, *(*result.Data)[1].StockQuantity, "medicines without stock have none on hand")
+
+	result, err = repo.SearchPaginated(ctx, domain.DataFilters{Matches: map[string][]string{"stockQuantity": {"12"}}})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 1)
+	assert.Equal(t, "Aspirin", (*result.Data)[0].Name)
+}
+
+func TestInitSQLiteDB_TenantIsolation(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	repo := medicine.NewMedicineRepository(db, loggerInstance)
+	firstTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+	secondTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 2, TenantID: 2})
+
+	created, err := repo.Create(firstTenant, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501", Laboratory: "Bayer"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.TenantID)
+	_, err = repo.Create(secondTenant, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501", Laboratory: "Bayer"})
+	require.NoError(t, err, "names and EAN codes are unique per organization only")
+	_, err = repo.Create(firstTenant, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7599"})
+	assert.Error(t, err)
+
+	_, err = repo.GetByID(secondTenant, created.ID)
+	assert.Error(t, err)
+	assert.Error(t, repo.Delete(secondTenant, created.ID))
+
+	result, err := repo.SearchPaginated(secondTenant, domain.DataFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	assert.Equal(t, 2, (*result.Data)[0].TenantID)
+
+	coincidences, err := repo.SearchByProperty(firstTenant, "name", "asp")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Aspirin"}, *coincidences)
+
+	seeded, err := user.NewUserRepository(db, loggerInstance).GetByEmail(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, seeded.TenantID, "the initial user belongs to the default organization")
+}
+
+func TestInitSQLiteDB_DeduplicatesLaboratories(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasConstraint(&medicine.Medicine{}, "LaboratoryRecord"))
+	repo := medicine.NewMedicineRepository(db, loggerInstance)
+	laboratories := laboratory.NewLaboratoryRepository(db, loggerInstance)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+
+	for i, name := range []string{"Pfizer", "pfizer inc", "PFIZER", "Bayer AG"} {
+		_, err := repo.Create(ctx, &domainMedicine.Medicine{Name: name + " medicine", EanCode: strconv.Itoa(7501 + i), Laboratory: name})
+		require.NoError(t, err)
+	}
+
+	linked, err := repo.LinkLaboratories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, *linked, 4)
+	assert.Equal(t, "pfizer inc medicine", (*linked)[2].Name)
+	linkEntries, err := repo.GetHistory(ctx, (*linked)[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer", (*linkEntries)[len(*linkEntries)-1].Changes["laboratory"].After, "links are recorded as updates")
+	var published int64
+	require.NoError(t, db.Model(&outbox.Message{}).Count(&published).Error)
+	assert.Equal(t, int64(8), published, "the creations and the links are published")
+	linked, err = repo.LinkLaboratories(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, *linked, "linked medicines are left alone")
+
+	all, err := laboratories.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, *all, 2)
+	assert.Equal(t, "Bayer AG", (*all)[0].Name)
+	pfizer := (*all)[1]
+	assert.Equal(t, "Pfizer", pfizer.Name)
+
+	result, err := repo.SearchPaginated(ctx, domain.DataFilters{Matches: map[string][]string{"laboratory": {"Pfizer"}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Equal(t, pfizer.ID, *(*result.Data)[0].LaboratoryID)
+
+	require.NoError(t, repo.Delete(ctx, (*result.Data)[2].ID))
+	renamedLaboratory, renamed, err := repo.RenameLaboratory(ctx, pfizer.ID, "Pfizer Inc.")
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Inc.", renamedLaboratory.Name)
+	require.Len(t, *renamed, 2, "deleted medicines are renamed silently")
+	assert.Equal(t, 1, *(*renamed)[0].UpdatedBy)
+	entries, err := repo.GetHistory(ctx, (*renamed)[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Inc.", (*entries)[len(*entries)-1].Changes["laboratory"].After)
+	result, err = repo.SearchPaginated(ctx, domain.DataFilters{LikeFilters: map[string][]string{"laboratory": {"inc."}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	trash, err := repo.GetTrash(ctx, domain.DataFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Inc.", (*trash.Data)[0].Laboratory)
+
+	_, _, err = repo.RenameLaboratory(ctx, pfizer.ID, "BAYER")
+	assert.Error(t, err, "renaming after another laboratory fails")
+	found, err := laboratories.GetByID(ctx, pfizer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Inc.", found.Name)
+	kept, err := repo.GetByID(ctx, (*renamed)[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Pfizer Inc.", kept.Laboratory, "medicines are renamed with their laboratory or not at all")
+
+	_, err = laboratories.Create(ctx, &domainLaboratory.Laboratory{Name: "BAYER"})
+	assert.Error(t, err, "spellings of an existing laboratory are rejected")
+	assert.Error(t, laboratories.Delete(ctx, pfizer.ID), "laboratories with medicines cannot be deleted")
+}
+
+func TestInitSQLiteDB_MedicineIngredients(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasConstraint(&medicine.MedicineIngredient{}, "Medicine"))
+	assert.True(t, db.Migrator().HasConstraint(&medicine.MedicineIngredient{}, "Ingredient"))
+	repo := medicine.NewMedicineRepository(db, loggerInstance)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+
+	augmentin, err := repo.Create(ctx, &domainMedicine.Medicine{
+		Name: "Augmentin", EanCode: "7501", DosageForm: "tablet", Route: "oral",
+		PackageSize: &domainMedicine.PackageSize{Quantity: 14, Unit: "unit"},
+		Ingredients: []domainMedicine.Ingredient{
+			{Name: "Amoxicillin", Strength: 875, Unit: "mg"},
+			{Name: "Clavulanic acid", Strength: 125, Unit: "mg"},
+		},
+	})
+	require.NoError(t, err)
+	amoxil, err := repo.Create(ctx, &domainMedicine.Medicine{
+		Name: "Amoxil", EanCode: "7502", DosageForm: "suspension",
+		Ingredients: []domainMedicine.Ingredient{{Name: "AMOXICILLIN", Strength: 50, Unit: "mg/mL"}},
+	})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7503"})
+	require.NoError(t, err)
+	assert.Equal(t, augmentin.Ingredients[0].ID, amoxil.Ingredients[0].ID, "spellings of a compound share the ingredient")
+	assert.Equal(t, "Amoxicillin", amoxil.Ingredients[0].Name)
+
+	found, err := repo.GetByID(ctx, augmentin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Amoxicillin", "Clavulanic acid"}, []string{found.Ingredients[0].Name, found.Ingredients[1].Name})
+	assert.Equal(t, &domainMedicine.PackageSize{Quantity: 14, Unit: "unit"}, found.PackageSize)
+	assert.Equal(t, "tablet", found.DosageForm)
+
+	result, err := repo.SearchPaginated(ctx, domain.DataFilters{Matches: map[string][]string{"ingredient": {"amoxicillin"}}, SortBy: []string{"name"}, SortDirection: domain.SortAsc})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 2)
+	assert.Equal(t, "Amoxil", (*result.Data)[0].Name)
+	assert.Len(t, (*result.Data)[1].Ingredients, 2)
+
+	result, err = repo.SearchPaginated(ctx, domain.DataFilters{LikeFilters: map[string][]string{"ingredient": {"clavul"}}})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 1)
+	assert.Equal(t, "Augmentin", (*result.Data)[0].Name)
+
+	coincidences, err := repo.SearchByProperty(ctx, "ingredient", "ICILL")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Amoxicillin"}, *coincidences)
+
+	updated, err := repo.Update(ctx, amoxil.ID, map[string]any{
+		"ingredients":  []domainMedicine.Ingredient{{Name: "Amoxicillin", Strength: 100, Unit: "mg/mL"}},
+		"package_size": &domainMedicine.PackageSize{Quantity: 60, Unit: "mL"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, updated.Ingredients[0].Strength)
+	assert.Equal(t, "mL", updated.PackageSize.Unit)
+	entries, err := repo.GetHistory(ctx, amoxil.ID)
+	require.NoError(t, err)
+	require.Len(t, *entries, 2)
+	assert.Contains(t, (*entries)[1].Changes, "ingredients")
+	assert.Contains(t, (*entries)[1].Changes, "packageSize")
+
+	updated, err = repo.Update(ctx, amoxil.ID, map[string]any{"package_size": nil})
+	require.NoError(t, err)
+	assert.Nil(t, updated.PackageSize)
+	assert.Len(t, updated.Ingredients, 1, "updates without ingredients keep them")
+}
+
+func TestInitSQLiteDB_MedicineSubstitutes(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasConstraint(&medicine.Equivalence{}, "Substitute"))
+	repo := medicine.NewMedicineRepository(db, loggerInstance)
+	stockRepo := stock.NewStockRepository(db, loggerInstance)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+
+	price := 12.5
+	create := func(name, form string, strength float64) *domainMedicine.Medicine {
+		created, err := repo.Create(ctx, &domainMedicine.Medicine{
+			Name: name, EanCode: name, DosageForm: form, Price: &price,
+			Ingredients: []domainMedicine.Ingredient{{Name: "Ibuprofen", Strength: strength, Unit: "mg"}},
+		})
+		require.NoError(t, err)
+		return created
+	}
+	advil := create("Advil", "tablet", 400)
+	motrin := create("Motrin", "tablet", 400)
+	create("Ibuprofen gel", "gel", 400)
+	strong := create("Ibuprofen 600", "tablet", 600)
+	aspirin, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "Aspirin"})
+	require.NoError(t, err)
+	_, err = stockRepo.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: motrin.ID, Delta: 7})
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, *advil.Price)
+
+	candidates, err := repo.GetSubstituteCandidates(ctx, advil, []int{aspirin.ID})
+	require.NoError(t, err)
+	names := []string{}
+	for _, candidate := range *candidates {
+		names = append(names, candidate.Name)
+	}
+	assert.Equal(t, []string{"Motrin", "Ibuprofen 600", "Aspirin"}, names, "the same form with the first ingredient, or listed")
+	assert.Equal(t, 7, *(*candidates)[0].StockQuantity)
+	assert.Len(t, (*candidates)[0].Ingredients, 1)
+	candidates, err = repo.GetSubstituteCandidates(ctx, aspirin, nil)
+	require.NoError(t, err)
+	assert.Empty(t, *candidates, "medicines of unknown composition have no candidates")
+
+	saved, err := repo.SaveEquivalence(ctx, &domainMedicine.Equivalence{MedicineID: advil.ID, SubstituteID: strong.ID, Kind: domainMedicine.EquivalenceExcluded})
+	require.NoError(t, err)
+	assert.Equal(t, 1, saved.TenantID)
+	replaced, err := repo.SaveEquivalence(ctx, &domainMedicine.Equivalence{MedicineID: advil.ID, SubstituteID: strong.ID, Kind: domainMedicine.EquivalenceMarked, Note: "halve"})
+	require.NoError(t, err)
+	assert.Equal(t, saved.ID, replaced.ID, "a medicine has one equivalence per substitute")
+	assert.Equal(t, domainMedicine.EquivalenceMarked, replaced.Kind)
+	_, err = repo.SaveEquivalence(ctx, &domainMedicine.Equivalence{MedicineID: advil.ID, SubstituteID: advil.ID, Kind: domainMedicine.EquivalenceMarked})
+	require.Error(t, err, "a medicine is no substitute of itself")
+
+	equivalences, err := repo.GetEquivalences(ctx, advil.ID)
+	require.NoError(t, err)
+	require.Len(t, *equivalences, 1)
+	assert.Equal(t, "halve", (*equivalences)[0].Note)
+	other, err := repo.GetEquivalences(security.WithPrincipal(context.Background(), security.Principal{UserID: 2, TenantID: 2}), advil.ID)
+	require.NoError(t, err)
+	assert.Empty(t, *other)
+
+	require.NoError(t, repo.Delete(ctx, strong.ID))
+	candidates, err = repo.GetSubstituteCandidates(ctx, advil, []int{strong.ID})
+	require.NoError(t, err)
+	assert.Len(t, *candidates, 1, "deleted medicines are no substitutes")
+	require.NoError(t, repo.DeleteEquivalence(ctx, saved.ID))
+	err = repo.DeleteEquivalence(ctx, saved.ID)
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+
+	updated, err := repo.Update(ctx, advil.ID, map[string]any{"price": nil})
+	require.NoError(t, err)
+	assert.Nil(t, updated.Price)
+	entries, err := repo.GetHistory(ctx, advil.ID)
+	require.NoError(t, err)
+	assert.Contains(t, (*entries)[len(*entries)-1].Changes, "price")
+}
+
+func TestInitSQLiteDB_OutboxFollowsWrites(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	repo := medicine.NewMedicineRepository(db, loggerInstance)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 3, TenantID: 1})
+
+	created, err := repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7599"})
+	require.Error(t, err)
+	_, err = repo.Update(ctx, created.ID, map[string]any{"laboratory": "Bayer"})
+	require.NoError(t, err)
+
+	var messages []outbox.Message
+	require.NoError(t, db.Where("aggregate_type = ?", event.AggregateMedicine).Order("id").Find(&messages).Error)
+	require.Len(t, messages, 2, "a rolled back write raises no event")
+	assert.Equal(t, event.MedicineCreated, messages[0].EventType)
+	assert.Equal(t, event.MedicineUpdated, messages[1].EventType)
+	assert.Equal(t, created.ID, messages[1].AggregateID)
+	assert.Equal(t, 1, messages[1].TenantID)
+	assert.Equal(t, 3, *messages[1].ActorID)
+	assert.Contains(t, messages[1].Payload, "Bayer")
+	assert.Nil(t, messages[1].PublishedAt)
+}
+
+func TestInitSQLiteDB_EncryptsUserPII(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "pii.db"))
+	key := func(fill string) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(fill, 32))) }
+	t.Setenv("PII_MASTER_KEYS", "k1:"+key("a"))
+	t.Setenv("PII_BLIND_INDEX_KEY", key("i"))
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	repo := user.NewUserRepository(db, loggerInstance)
+	ctx := context.Background()
+
+	seeded, err := repo.GetByEmail(ctx, "admin@example.com")
+	require.NoError(t, err, "the initial user is found through its blind index")
+	created, err := repo.Create(ctx, &domainUser.User{UserName: "jane", Email: "jane@example.com", FirstName: "Jane", LastName: "Doe"})
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", created.Email)
+	_, err = repo.Update(ctx, created.ID, map[string]any{"firstName": "Janet"})
+	require.NoError(t, err)
+
+	var stored map[string]any
+	require.NoError(t, db.Table("users").Where("id = ?", created.ID).Take(&stored).Error)
+	assert.True(t, strings.HasPrefix(stored["email"].(string), "enc:v1:k1:"))
+	assert.True(t, strings.HasPrefix(stored["first_name"].(string), "enc:v1:k1:"))
+	var history []map[string]any
+	require.NoError(t, db.Table("user_history").Where("entity_id = ?", created.ID).Find(&history).Error)
+	for _, entry := range history {
+		assert.NotContains(t, entry["changes"], "Jan")
+		assert.NotContains(t, entry["snapshot"], "jane@example.com")
+	}
+
+	found, err := repo.GetByEmail(ctx, "jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Janet", found.FirstName)
+	result, err := repo.SearchPaginated(ctx, domain.DataFilters{Matches: map[string][]string{"email": {"jane@example.com", seeded.Email}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	_, err = repo.SearchPaginated(ctx, domain.DataFilters{LikeFilters: map[string][]string{"email": {"jane"}}})
+	assert.Error(t, err)
+	_, err = repo.SearchByProperty(ctx, "email", "jane")
+	assert.Error(t, err)
+	entries, err := repo.GetHistory(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Janet", (*entries)[1].Changes["firstName"].After)
+	asOf, err := repo.GetAsOf(ctx, created.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", asOf.Email)
+
+	// a restart with a new active master key followed by the re-encryption command
+	t.Setenv("PII_MASTER_KEYS", "k1:"+key("a")+",k2:"+key("b"))
+	rotatedDB, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	updated, err := user.Reencrypt(ctx, rotatedDB, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2+len(history)), updated)
+
+	require.NoError(t, rotatedDB.Table("users").Where("id = ?", created.ID).Take(&stored).Error)
+	assert.True(t, strings.HasPrefix(stored["email"].(string), "enc:v1:k2:"))
+	var rotatedHistory map[string]any
+	require.NoError(t, rotatedDB.Table("user_history").Where("entity_id = ?", created.ID).Order("id DESC").Take(&rotatedHistory).Error)
+	assert.Contains(t, rotatedHistory["snapshot"], "enc:v1:k2:")
+	assert.NotContains(t, rotatedHistory["snapshot"], "enc:v1:k1:")
+
+	t.Setenv("PII_MASTER_KEYS", "k2:"+key("b"))
+	retiredDB, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	retiredRepo := user.NewUserRepository(retiredDB, loggerInstance)
+	found, err = retiredRepo.GetByEmail(ctx, "jane@example.com")
+	require.NoError(t, err, "the retired key is no longer needed")
+	assert.Equal(t, "Janet", found.FirstName)
+	asOf, err = retiredRepo.GetAsOf(ctx, created.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", asOf.Email)
+}
+
+func TestInitSQLiteDB_MedicineInteractions(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	db, err := InitSQLiteDB(loggerInstance)
+	require.NoError(t, err)
+	repo := medicine.NewMedicineRepository(db, loggerInstance)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+	otherTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 2, TenantID: 2})
+	interaction := func(first, second, severity, description string) domainMedicine.Interaction {
+		interaction, err := domainMedicine.NewInteraction(first, second, severity, description)
+		require.NoError(t, err)
+		return *interaction
+	}
+
+	saved, err := repo.SaveInteractions(ctx, []domainMedicine.Interaction{
+		interaction("Warfarin", "class:NSAIDs", "moderate", "first version"),
+		interaction("Warfarin", "NSAIDs", "minor", "an ingredient named like the class"),
+		interaction("Warfarin", "Amiodarone", "contraindicated", ""),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, saved)
+	_, err = repo.SaveInteractions(ctx, []domainMedicine.Interaction{interaction("class:nsaids", "WARFARIN", "major", "Increases the risk of bleeding")})
+	require.NoError(t, err)
+	_, err = repo.SaveInteractions(otherTenant, []domainMedicine.Interaction{interaction("Warfarin", "class:NSAIDs", "minor", "")})
+	require.NoError(t, err)
+	_, err = repo.SaveIngredientClasses(ctx, []domainMedicine.IngredientClass{{Class: "NSAIDs", Ingredient: "Ibuprofen"}, {Class: "NSAIDs", Ingredient: "Naproxen"}})
+	require.NoError(t, err)
+	_, err = repo.SaveIngredientClasses(ctx, []domainMedicine.IngredientClass{{Class: "nsaids", Ingredient: "ibuprofen"}})
+	require.NoError(t, err, "known memberships are skipped")
+
+	classes, err := repo.GetIngredientClasses(ctx, []string{"IBUPROFEN", "Warfarin"})
+	require.NoError(t, err)
+	require.Len(t, *classes, 1)
+	assert.Equal(t, "Ibuprofen", (*classes)[0].Ingredient)
+	assert.Equal(t, 1, (*classes)[0].TenantID)
+
+	interactions, err := repo.GetInteractions(ctx, []domainMedicine.InteractionSubject{{Name: "warfarin"}, {Name: "NSAIDs", Class: true}})
+	require.NoError(t, err)
+	require.Len(t, *interactions, 1, "the ingredient named like the class and other tenants are left out")
+	found := (*interactions)[0]
+	assert.Equal(t, domainMedicine.SeverityMajor, found.Severity)
+	assert.Equal(t, "Increases the risk of bleeding", found.Description)
+	assert.Equal(t, domainMedicine.InteractionSubject{Name: "WARFARIN"}, found.First, "the last spelling is kept")
+	assert.Equal(t, 1, *found.CreatedBy)
+
+	interactions, err = repo.GetInteractions(otherTenant, []domainMedicine.InteractionSubject{{Name: "warfarin"}, {Name: "NSAIDs", Class: true}})
+	require.NoError(t, err)
+	require.Len(t, *interactions, 1)
+	assert.Equal(t, domainMedicine.SeverityMinor, (*interactions)[0].Severity)
+}
+
+// openSQLiteWithReplica prepares a file-backed database and serves reads from a second
+// connection to the same file registered as a read replica
+func openSQLiteWithReplica(t *testing.T, loggerInstance *logger.Logger) *PSQLRepository {
+	path := filepath.Join(t.TempDir(), "replicated.db")
+	t.Setenv("SQLITE_PATH", path)
+	repo, err := openSQLite(loggerInstance)
+	require.NoError(t, err)
+	replicaDB, err := gorm.Open(sqlite.Open(path), sqliteConfig(repo.DB.Logger))
+	require.NoError(t, err)
+	require.NoError(t, repo.setReplicas([]*replica.Replica{{Name: "replica", DB: replicaDB}}, 0))
+	require.Equal(t, replicaDB, repo.Replicas.Reader())
+	return repo
+}
+
+func TestInitSQLiteDB_ReplicaReadsAreTenantScoped(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	repo := openSQLiteWithReplica(t, loggerInstance)
+	medicines := medicine.NewMedicineRepositoryWithReplicas(repo.DB, repo.Replicas, loggerInstance)
+	users := user.NewUserRepositoryWithReplicas(repo.DB, repo.Replicas, loggerInstance)
+	firstTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: 1})
+	secondTenant := security.WithPrincipal(context.Background(), security.Principal{UserID: 2, TenantID: 2})
+
+	_, err := medicines.Create(firstTenant, &domainMedicine.Medicine{Name: "Aspirin", EanCode: "7501", Laboratory: "Bayer"})
+	require.NoError(t, err)
+	_, err = medicines.Create(secondTenant, &domainMedicine.Medicine{Name: "Advil", EanCode: "7502", Laboratory: "Pfizer"})
+	require.NoError(t, err)
+	_, err = users.Create(secondTenant, &domainUser.User{UserName: "jane", Email: "jane@example.com"})
+	require.NoError(t, err)
+
+	result, err := medicines.SearchPaginated(secondTenant, domain.DataFilters{})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Total)
+	assert.Equal(t, "Advil", (*result.Data)[0].Name)
+	all, err := medicines.GetAll(firstTenant)
+	require.NoError(t, err)
+	require.Len(t, *all, 1)
+	assert.Equal(t, "Aspirin", (*all)[0].Name)
+	coincidences, err := medicines.SearchByProperty(firstTenant, "laboratory", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bayer"}, *coincidences)
+
+	firstUsers, err := users.SearchPaginated(firstTenant, domain.DataFilters{})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), firstUsers.Total, "only the initial user belongs to the default organization")
+	assert.Equal(t, "admin@example.com", (*firstUsers.Data)[0].Email)
+	secondUsers, err := users.GetAll(secondTenant)
+	require.NoError(t, err)
+	require.Len(t, *secondUsers, 1)
+	assert.Equal(t, "jane", (*secondUsers)[0].UserName)
+}
+
+func TestInitSQLiteDB_ReplicaReadsDecryptUserPII(t *testing.T) {
+	loggerInstance := setupSQLite(t)
+	key := func(fill string) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(fill, 32))) }
+	t.Setenv("PII_MASTER_KEYS", "k1:"+key("a"))
+	t.Setenv("PII_BLIND_INDEX_KEY", key("i"))
+	repo := openSQLiteWithReplica(t, loggerInstance)
+	users := user.NewUserRepositoryWithReplicas(repo.DB, repo.Replicas, loggerInstance)
+	ctx := context.Background()
+
+	_, err := users.Create(ctx, &domainUser.User{UserName: "jane", Email: "jane@example.com", FirstName: "Jane"})
+	require.NoError(t, err)
+
+	all, err := users.GetAll(ctx)
+	require.NoError(t, err)
+	emails := []string{}
+	for _, listed := range *all {
+		emails = append(emails, listed.Email)
+	}
+	assert.ElementsMatch(t, []string{"admin@example.com", "jane@example.com"}, emails)
+
+	result, err := users.SearchPaginated(ctx, domain.DataFilters{Matches: map[string][]string{"email": {"jane@example.com"}}})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Total, "matches go through the blind index on the replica")
+	assert.Equal(t, "Jane", (*result.Data)[0].FirstName)
+	_, err = users.SearchPaginated(ctx, domain.DataFilters{LikeFilters: map[string][]string{"email": {"jane"}}})
+	assert.Error(t, err)
+	_, err = users.SearchByProperty(ctx, "email", "jane")
+	assert.Error(t, err)
+}
@@ -0,0 +1,418 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Threshold holds the stock levels of a medicine at a location that raise alerts
+type Threshold struct {
+	ID           int       `gorm:"primaryKey"`
+	TenantID     int       `gorm:"uniqueIndex:idx_stock_thresholds_medicine_location,priority:1"`
+	MedicineID   int       `gorm:"uniqueIndex:idx_stock_thresholds_medicine_location,priority:2;index"`
+	Location     string    `gorm:"uniqueIndex:idx_stock_thresholds_medicine_location,priority:3;size:100"`
+	MinLevel     int       `gorm:"not null;default:0;check:chk_stock_thresholds_levels,min_level >= 0 AND min_level <= reorder_point AND reorder_point < max_level"`
+	ReorderPoint int       `gorm:"not null;default:0"`
+	MaxLevel     int       `gorm:"not null;default:0"`
+	CreatedAt    time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy    *int      `gorm:"index"`
+	UpdatedBy    *int      `gorm:"index"`
+}
+
+func (*Threshold) TableName() string {
+	return "stock_thresholds"
+}
+
+// Alert is a raised stock alert. OpenKey holds the key of its condition until it is
+// resolved, so the unique index keeps a single unresolved alert per condition while
+// resolved ones, whose key is NULL, pile up.
+type Alert struct {
+	ID             int        `gorm:"primaryKey"`
+	TenantID       int        `gorm:"uniqueIndex:idx_stock_alerts_open_key,priority:1"`
+	OpenKey        *string    `gorm:"uniqueIndex:idx_stock_alerts_open_key,priority:2;size:150"`
+	MedicineID     int        `gorm:"index"`
+	Location       string     `gorm:"size:100"`
+	LotID          *int       `gorm:"index"`
+	Type           string     `gorm:"size:20;index"`
+	Severity       string     `gorm:"size:20"`
+	Status         string     `gorm:"size:20;index"`
+	Quantity       int        `gorm:"not null;default:0"`
+	Threshold      int        `gorm:"not null;default:0"`
+	ExpiresAt      *time.Time `gorm:"type:date"`
+	Message        string     `gorm:"type:text"`
+	AcknowledgedAt *time.Time
+	AcknowledgedBy *int `gorm:"index"`
+	ResolvedAt     *time.Time
+	CreatedAt      time.Time `gorm:"autoCreateTime:milli;index"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime:milli"`
+}
+
+func (*Alert) TableName() string {
+	return "stock_alerts"
+}
+
+// ColumnsAlertMapping maps the filterable alert fields to their columns
+var ColumnsAlertMapping = map[string]string{
+	"id":             "id",
+	"medicineId":     "medicine_id",
+	"location":       "location",
+	"lotId":          "lot_id",
+	"type":           "type",
+	"severity":       "severity",
+	"status":         "status",
+	"createdAt":      "created_at",
+	"updatedAt":      "updated_at",
+	"acknowledgedBy": "acknowledged_by",
+}
+
+// GetThresholds returns the thresholds of a medicine ordered by location
+func (r *Repository) GetThresholds(ctx context.Context, medicineID int) (*[]domainStock.Threshold, error) {
+	return r.findThresholds(r.DB.WithContext(ctx).Where("medicine_id = ?", medicineID))
+}
+
+// GetAllThresholds returns the thresholds of every medicine
+func (r *Repository) GetAllThresholds(ctx context.Context) (*[]domainStock.Threshold, error) {
+	return r.findThresholds(r.DB.WithContext(ctx))
+}
+
+func (r *Repository) findThresholds(query *gorm.DB) (*[]domainStock.Threshold, error) {
+	var thresholds []Threshold
+	if err := query.Order("medicine_id, location").Find(&thresholds).Error; err != nil {
+		r.Logger.Error("Error getting stock thresholds", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainStock.Threshold, len(thresholds))
+	for i := range thresholds {
+		result[i] = *thresholds[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+// GetThreshold returns the threshold of a medicine at a location
+func (r *Repository) GetThreshold(ctx context.Context, medicineID int, location string) (*domainStock.Threshold, error) {
+	var threshold Threshold
+	if err := r.DB.WithContext(ctx).Where("medicine_id = ? AND location = ?", medicineID, location).First(&threshold).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting stock threshold", zap.Error(err), zap.Int("medicineId", medicineID), zap.String("location", location))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return threshold.toDomainMapper(), nil
+}
+
+// SaveThreshold creates the threshold of a medicine at a location or replaces its levels
+func (r *Repository) SaveThreshold(ctx context.Context, threshold *domainStock.Threshold) (*domainStock.Threshold, error) {
+	row := thresholdFromDomainMapper(threshold)
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "medicine_id"}, {Name: "location"}},
+			DoUpdates: clause.AssignmentColumns([]string{"min_level", "reorder_point", "max_level", "updated_at", "updated_by"}),
+		}).Create(row).Error; err != nil {
+			return err
+		}
+		return tx.Where("medicine_id = ? AND location = ?", threshold.MedicineID, threshold.Location).First(row).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error saving stock threshold", zap.Error(err), zap.Int("medicineId", threshold.MedicineID))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully saved stock threshold", zap.Int("id", row.ID), zap.Int("medicineId", row.MedicineID), zap.String("location", row.Location))
+	return row.toDomainMapper(), nil
+}
+
+// DeleteThreshold removes a threshold and returns it
+func (r *Repository) DeleteThreshold(ctx context.Context, id int) (*domainStock.Threshold, error) {
+	var threshold Threshold
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&threshold, id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&threshold).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Logger.Warn("Stock threshold not found for deletion", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error deleting stock threshold", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully deleted stock threshold", zap.Int("id", id))
+	return threshold.toDomainMapper(), nil
+}
+
+// RaiseAlert opens alert, or updates the unresolved alert of its condition. It reports
+// whether the alert is new or its severity rose, which reopens an acknowledged one.
+func (r *Repository) RaiseAlert(ctx context.Context, alert *domainStock.Alert) (*domainStock.Alert, bool, error) {
+	key := alert.Key()
+	row := alertFromDomainMapper(alert)
+	row.OpenKey = &key
+	row.Status = string(domainStock.AlertOpen)
+	raised := false
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "open_key"}},
+			DoNothing: true,
+		}).Create(row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			raised = true
+			return nil
+		}
+		var existing Alert
+		if err := tx.Where("tenant_id = ? AND open_key = ?", row.TenantID, key).First(&existing).Error; err != nil {
+			return err
+		}
+		updates := map[string]any{
+			"severity":   row.Severity,
+			"quantity":   row.Quantity,
+			"threshold":  row.Threshold,
+			"expires_at": row.ExpiresAt,
+			"message":    row.Message,
+		}
+		if alert.Severity.Exceeds(domainStock.AlertSeverity(existing.Severity)) {
+			raised = true
+			updates["status"] = string(domainStock.AlertOpen)
+			updates["acknowledged_at"] = nil
+			updates["acknowledged_by"] = nil
+		}
+		if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(row, existing.ID).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error raising stock alert", zap.Error(err), zap.String("key", key))
+		return nil, false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return row.toDomainMapper(), raised, nil
+}
+
+// ResolveAlert resolves the unresolved alert of the condition with key, if any
+func (r *Repository) ResolveAlert(ctx context.Context, key string, at time.Time) error {
+	result := r.DB.WithContext(ctx).Model(&Alert{}).
+		Where("open_key = ?", key).
+		Updates(map[string]any{"status": string(domainStock.AlertResolved), "resolved_at": at, "open_key": nil})
+	if result.Error != nil {
+		r.Logger.Error("Error resolving stock alert", zap.Error(result.Error), zap.String("key", key))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if result.RowsAffected > 0 {
+		r.Logger.Info("Resolved stock alert", zap.String("key", key))
+	}
+	return nil
+}
+
+// GetUnresolvedAlerts returns the open and acknowledged alerts of alertType
+func (r *Repository) GetUnresolvedAlerts(ctx context.Context, alertType domainStock.AlertType) (*[]domainStock.Alert, error) {
+	var alerts []Alert
+	if err := r.DB.WithContext(ctx).
+		Where("type = ? AND open_key IS NOT NULL", string(alertType)).
+		Order("id").Find(&alerts).Error; err != nil {
+		r.Logger.Error("Error getting unresolved stock alerts", zap.Error(err), zap.String("type", string(alertType)))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainStock.Alert, len(alerts))
+	for i := range alerts {
+		result[i] = *alerts[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *Repository) GetAlertByID(ctx context.Context, id int) (*domainStock.Alert, error) {
+	var alert Alert
+	if err := r.DB.WithContext(ctx).First(&alert, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Logger.Warn("Stock alert not found", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting stock alert", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return alert.toDomainMapper(), nil
+}
+
+// GetAlerts lists alerts, newest first unless filters sort otherwise
+func (r *Repository) GetAlerts(ctx context.Context, filters domain.DataFilters) (*domainStock.SearchResultAlert, error) {
+	query := r.DB.WithContext(ctx).Model(&Alert{})
+
+	for field, values := range filters.Matches {
+		if column := ColumnsAlertMapping[field]; column != "" && len(values) > 0 {
+			query = query.Where(column+" IN ?", values)
+		}
+	}
+	for _, dateFilter := range filters.DateRangeFilters {
+		column := ColumnsAlertMapping[dateFilter.Field]
+		if column == "" {
+			continue
+		}
+		if dateFilter.Start != nil {
+			query = query.Where(column+" >= ?", dateFilter.Start)
+		}
+		if dateFilter.End != nil {
+			query = query.Where(column+" <= ?", dateFilter.End)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Logger.Error("Error counting stock alerts", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	sorted := false
+	if filters.SortDirection.IsValid() {
+		for _, sortField := range filters.SortBy {
+			if column := ColumnsAlertMapping[sortField]; column != "" {
+				query = query.Order(column + " " + string(filters.SortDirection))
+				sorted = true
+			}
+		}
+	}
+	if !sorted {
+		query = query.Order("id DESC")
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	var alerts []Alert
+	if err := query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize).Find(&alerts).Error; err != nil {
+		r.Logger.Error("Error listing stock alerts", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	data := make([]domainStock.Alert, len(alerts))
+	for i := range alerts {
+		data[i] = *alerts[i].toDomainMapper()
+	}
+	r.Logger.Info("Successfully listed stock alerts", zap.Int64("total", total))
+	return &domainStock.SearchResultAlert{
+		Data:       &data,
+		Total:      total,
+		Page:       filters.Page,
+		PageSize:   filters.PageSize,
+		TotalPages: int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize)),
+	}, nil
+}
+
+// AcknowledgeAlert marks an open alert as seen by the user in ctx
+func (r *Repository) AcknowledgeAlert(ctx context.Context, id int, at time.Time) (*domainStock.Alert, error) {
+	var alert Alert
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Alert{}).
+			Where("id = ? AND status = ?", id, string(domainStock.AlertOpen)).
+			Updates(map[string]any{
+				"status":          string(domainStock.AlertAcknowledged),
+				"acknowledged_at": at,
+				"acknowledged_by": security.ActorID(ctx),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.First(&alert, id).Error; err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return domainErrors.NewAppError(fmt.Errorf("alert %d is %s, not %s", id, alert.Status, domainStock.AlertOpen), domainErrors.ValidationError)
+		}
+		return nil
+	})
+	if err != nil {
+		var appErr *domainErrors.AppError
+		switch {
+		case errors.As(err, &appErr):
+			r.Logger.Warn("Stock alert cannot be acknowledged", zap.Int("id", id), zap.String("status", alert.Status))
+			return nil, appErr
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			r.Logger.Warn("Stock alert not found for acknowledgement", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error acknowledging stock alert", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully acknowledged stock alert", zap.Int("id", id))
+	return alert.toDomainMapper(), nil
+}
+
+// Mappers
+func (t *Threshold) toDomainMapper() *domainStock.Threshold {
+	return &domainStock.Threshold{
+		ID:           t.ID,
+		TenantID:     t.TenantID,
+		MedicineID:   t.MedicineID,
+		Location:     t.Location,
+		MinLevel:     t.MinLevel,
+		ReorderPoint: t.ReorderPoint,
+		MaxLevel:     t.MaxLevel,
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+		CreatedBy:    t.CreatedBy,
+		UpdatedBy:    t.UpdatedBy,
+	}
+}
+
+func thresholdFromDomainMapper(threshold *domainStock.Threshold) *Threshold {
+	return &Threshold{
+		TenantID:     threshold.TenantID,
+		MedicineID:   threshold.MedicineID,
+		Location:     threshold.Location,
+		MinLevel:     threshold.MinLevel,
+		ReorderPoint: threshold.ReorderPoint,
+		MaxLevel:     threshold.MaxLevel,
+	}
+}
+
+func (a *Alert) toDomainMapper() *domainStock.Alert {
+	return &domainStock.Alert{
+		ID:             a.ID,
+		TenantID:       a.TenantID,
+		MedicineID:     a.MedicineID,
+		Location:       a.Location,
+		LotID:          a.LotID,
+		Type:           domainStock.AlertType(a.Type),
+		Severity:       domainStock.AlertSeverity(a.Severity),
+		Status:         domainStock.AlertStatus(a.Status),
+		Quantity:       a.Quantity,
+		Threshold:      a.Threshold,
+		ExpiresAt:      dayPointer(a.ExpiresAt),
+		Message:        a.Message,
+		AcknowledgedAt: a.AcknowledgedAt,
+		AcknowledgedBy: a.AcknowledgedBy,
+		ResolvedAt:     a.ResolvedAt,
+		CreatedAt:      a.CreatedAt,
+		UpdatedAt:      a.UpdatedAt,
+	}
+}
+
+func alertFromDomainMapper(alert *domainStock.Alert) *Alert {
+	return &Alert{
+		TenantID:   alert.TenantID,
+		MedicineID: alert.MedicineID,
+		Location:   alert.Location,
+		LotID:      alert.LotID,
+		Type:       string(alert.Type),
+		Severity:   string(alert.Severity),
+		Quantity:   alert.Quantity,
+		Threshold:  alert.Threshold,
+		ExpiresAt:  alert.ExpiresAt,
+		Message:    alert.Message,
+	}
+}
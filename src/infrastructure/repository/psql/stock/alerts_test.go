@@ -0,0 +1,130 @@
+package stock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Thresholds(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+
+	saved, err := repository.SaveThreshold(ctx, &domainStock.Threshold{TenantID: 1, MedicineID: 5, Location: "main", MinLevel: 2, ReorderPoint: 10, MaxLevel: 40})
+	require.NoError(t, err)
+	assert.Equal(t, 3, *saved.CreatedBy)
+	updated, err := repository.SaveThreshold(ctx, &domainStock.Threshold{TenantID: 1, MedicineID: 5, Location: "main", MinLevel: 5, ReorderPoint: 20, MaxLevel: 60})
+	require.NoError(t, err)
+	assert.Equal(t, saved.ID, updated.ID, "a medicine has one threshold per location")
+	assert.Equal(t, 20, updated.ReorderPoint)
+	_, err = repository.SaveThreshold(ctx, &domainStock.Threshold{TenantID: 1, MedicineID: 5, Location: "front", ReorderPoint: 3, MaxLevel: 10})
+	require.NoError(t, err)
+
+	thresholds, err := repository.GetThresholds(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, *thresholds, 2)
+	all, err := repository.GetAllThresholds(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, *all, 2)
+	other, err := repository.GetThresholds(tenantContext(2, 4), 5)
+	require.NoError(t, err)
+	assert.Empty(t, *other)
+
+	found, err := repository.GetThreshold(ctx, 5, "main")
+	require.NoError(t, err)
+	assert.Equal(t, 5, found.MinLevel)
+	_, err = repository.GetThreshold(ctx, 5, "back")
+	assertAppError(t, err, domainErrors.NotFound)
+
+	deleted, err := repository.DeleteThreshold(ctx, saved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "main", deleted.Location)
+	_, err = repository.DeleteThreshold(ctx, saved.ID)
+	assertAppError(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_RaiseAlert(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+	lowStock := func(severity domainStock.AlertSeverity, quantity int) *domainStock.Alert {
+		return &domainStock.Alert{TenantID: 1, MedicineID: 5, Location: "main", Type: domainStock.AlertLowStock, Severity: severity, Quantity: quantity, Threshold: 10}
+	}
+
+	alert, raised, err := repository.RaiseAlert(context.Background(), lowStock(domainStock.SeverityWarning, 8))
+	require.NoError(t, err)
+	assert.True(t, raised)
+	assert.Equal(t, domainStock.AlertOpen, alert.Status)
+
+	acknowledged, err := repository.AcknowledgeAlert(ctx, alert.ID, today)
+	require.NoError(t, err)
+	assert.Equal(t, domainStock.AlertAcknowledged, acknowledged.Status)
+	assert.Equal(t, 3, *acknowledged.AcknowledgedBy)
+	_, err = repository.AcknowledgeAlert(ctx, alert.ID, today)
+	assertAppError(t, err, domainErrors.ValidationError)
+
+	again, raised, err := repository.RaiseAlert(context.Background(), lowStock(domainStock.SeverityWarning, 6))
+	require.NoError(t, err)
+	assert.False(t, raised, "the same condition is not raised twice")
+	assert.Equal(t, alert.ID, again.ID)
+	assert.Equal(t, 6, again.Quantity)
+	assert.Equal(t, domainStock.AlertAcknowledged, again.Status)
+
+	worse, raised, err := repository.RaiseAlert(context.Background(), lowStock(domainStock.SeverityCritical, 1))
+	require.NoError(t, err)
+	assert.True(t, raised)
+	assert.Equal(t, alert.ID, worse.ID)
+	assert.Equal(t, domainStock.AlertOpen, worse.Status, "a more severe alert is reopened")
+	assert.Nil(t, worse.AcknowledgedBy)
+
+	require.NoError(t, repository.ResolveAlert(context.Background(), worse.Key(), today))
+	resolved, err := repository.GetAlertByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainStock.AlertResolved, resolved.Status)
+	assert.NotNil(t, resolved.ResolvedAt)
+	_, err = repository.AcknowledgeAlert(ctx, alert.ID, today)
+	assertAppError(t, err, domainErrors.ValidationError)
+
+	reopened, raised, err := repository.RaiseAlert(context.Background(), lowStock(domainStock.SeverityWarning, 9))
+	require.NoError(t, err)
+	assert.True(t, raised)
+	assert.NotEqual(t, alert.ID, reopened.ID, "a condition that returns opens a new alert")
+}
+
+func TestRepository_GetAlerts(t *testing.T) {
+	repository := setupRepository(t)
+	lotID := 4
+	expiresAt := today.AddDate(0, 0, 10)
+	for _, alert := range []*domainStock.Alert{
+		{TenantID: 1, MedicineID: 5, Location: "main", Type: domainStock.AlertLowStock, Severity: domainStock.SeverityWarning},
+		{TenantID: 1, MedicineID: 5, Location: "main", LotID: &lotID, Type: domainStock.AlertExpiry, Severity: domainStock.SeverityWarning, ExpiresAt: &expiresAt},
+		{TenantID: 2, MedicineID: 6, Location: "main", Type: domainStock.AlertLowStock, Severity: domainStock.SeverityCritical},
+	} {
+		_, _, err := repository.RaiseAlert(context.Background(), alert)
+		require.NoError(t, err)
+	}
+
+	result, err := repository.GetAlerts(tenantContext(1, 3), domain.DataFilters{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	assert.Equal(t, domainStock.AlertExpiry, (*result.Data)[0].Type, "newest first")
+	assert.Equal(t, expiresAt, *(*result.Data)[0].ExpiresAt)
+
+	result, err = repository.GetAlerts(tenantContext(1, 3), domain.DataFilters{
+		Page: 1, PageSize: 10, Matches: map[string][]string{"type": {"low-stock"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 1)
+	assert.Equal(t, domainStock.AlertLowStock, (*result.Data)[0].Type)
+
+	unresolved, err := repository.GetUnresolvedAlerts(context.Background(), domainStock.AlertExpiry)
+	require.NoError(t, err)
+	require.Len(t, *unresolved, 1)
+	assert.Equal(t, "expiry/4", (*unresolved)[0].Key())
+	_, err = repository.GetAlertByID(tenantContext(2, 4), (*unresolved)[0].ID)
+	assertAppError(t, err, domainErrors.NotFound)
+}
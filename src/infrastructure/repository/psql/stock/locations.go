@@ -0,0 +1,198 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Location is a place stock is kept at. UserIDs holds the IDs of the users allowed to
+// change its stock separated by commas, empty when every user is.
+type Location struct {
+	ID        int    `gorm:"primaryKey"`
+	TenantID  int    `gorm:"uniqueIndex:idx_locations_code,priority:1"`
+	Code      string `gorm:"uniqueIndex:idx_locations_code,priority:2;size:100"`
+	Name      string `gorm:"size:255"`
+	Type      string `gorm:"size:20"`
+	Active    bool
+	UserIDs   string    `gorm:"size:1024"`
+	CreatedAt time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy *int      `gorm:"index"`
+	UpdatedBy *int      `gorm:"index"`
+}
+
+func (*Location) TableName() string {
+	return "locations"
+}
+
+// ColumnsLocationMapping maps the JSON keys accepted by UpdateLocation to their columns
+var ColumnsLocationMapping = map[string]string{
+	"name":    "name",
+	"type":    "type",
+	"active":  "active",
+	"userIds": "user_ids",
+}
+
+// GetLocations returns every location ordered by code
+func (r *Repository) GetLocations(ctx context.Context) (*[]domainStock.Location, error) {
+	var locations []Location
+	if err := r.DB.WithContext(ctx).Order("code").Find(&locations).Error; err != nil {
+		r.Logger.Error("Error getting locations", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved locations", zap.Int("count", len(locations)))
+	return locationArrayToDomainMapper(&locations), nil
+}
+
+func (r *Repository) GetLocationByID(ctx context.Context, id int) (*domainStock.Location, error) {
+	return r.getLocation(ctx, "id = ?", id)
+}
+
+func (r *Repository) GetLocationByCode(ctx context.Context, code string) (*domainStock.Location, error) {
+	return r.getLocation(ctx, "code = ?", code)
+}
+
+func (r *Repository) getLocation(ctx context.Context, query string, value any) (*domainStock.Location, error) {
+	var location Location
+	if err := r.DB.WithContext(ctx).Where(query, value).First(&location).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Logger.Warn("Location not found", zap.Any("key", value))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting location", zap.Error(err), zap.Any("key", value))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return location.toDomainMapper(), nil
+}
+
+// CreateLocation registers a location; codes are unique per organization
+func (r *Repository) CreateLocation(ctx context.Context, location *domainStock.Location) (*domainStock.Location, error) {
+	row := locationFromDomainMapper(location)
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Location{}).Where("code = ?", location.Code).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+		}
+		return tx.Create(row).Error
+	})
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) {
+			r.Logger.Warn("Location already exists", zap.String("code", location.Code))
+			return nil, appErr
+		}
+		r.Logger.Error("Error creating location", zap.Error(err), zap.String("code", location.Code))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully created location", zap.Int("id", row.ID), zap.String("code", row.Code))
+	return row.toDomainMapper(), nil
+}
+
+// UpdateLocation changes the fields in locationMap, keyed by their JSON names
+func (r *Repository) UpdateLocation(ctx context.Context, id int, locationMap map[string]any) (*domainStock.Location, error) {
+	updates := make(map[string]any, len(locationMap))
+	for key, value := range locationMap {
+		column := ColumnsLocationMapping[key]
+		if column == "" {
+			continue
+		}
+		switch typed := value.(type) {
+		case []int:
+			value = joinIDs(typed)
+		case domainStock.LocationType:
+			value = string(typed)
+		}
+		updates[column] = value
+	}
+
+	var location Location
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&location).Error; err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&location).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", id).First(&location).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Logger.Warn("Location not found for update", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error updating location", zap.Error(err), zap.Int("id", id))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully updated location", zap.Int("id", id))
+	return location.toDomainMapper(), nil
+}
+
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitIDs(value string) []int {
+	if value == "" {
+		return nil
+	}
+	var ids []int
+	for _, part := range strings.Split(value, ",") {
+		if id, err := strconv.Atoi(part); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Mappers
+func (l *Location) toDomainMapper() *domainStock.Location {
+	return &domainStock.Location{
+		ID:        l.ID,
+		TenantID:  l.TenantID,
+		Code:      l.Code,
+		Name:      l.Name,
+		Type:      domainStock.LocationType(l.Type),
+		Active:    l.Active,
+		UserIDs:   splitIDs(l.UserIDs),
+		CreatedAt: l.CreatedAt,
+		UpdatedAt: l.UpdatedAt,
+		CreatedBy: l.CreatedBy,
+		UpdatedBy: l.UpdatedBy,
+	}
+}
+
+func locationFromDomainMapper(location *domainStock.Location) *Location {
+	return &Location{
+		TenantID: location.TenantID,
+		Code:     location.Code,
+		Name:     location.Name,
+		Type:     string(location.Type),
+		Active:   location.Active,
+		UserIDs:  joinIDs(location.UserIDs),
+	}
+}
+
+func locationArrayToDomainMapper(locations *[]Location) *[]domainStock.Location {
+	result := make([]domainStock.Location, len(*locations))
+	for i := range *locations {
+		result[i] = *(*locations)[i].toDomainMapper()
+	}
+	return &result
+}
@@ -0,0 +1,49 @@
+package stock
+
+import (
+	"testing"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Locations(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+
+	front, err := repository.CreateLocation(ctx, &domainStock.Location{Code: "front", Name: "Front desk", Type: domainStock.LocationStore, Active: true, UserIDs: []int{2, 4}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, front.TenantID)
+	assert.Equal(t, []int{2, 4}, front.UserIDs)
+	assert.Equal(t, 3, *front.CreatedBy)
+	_, err = repository.CreateLocation(ctx, &domainStock.Location{Code: "back", Name: "Back room", Type: domainStock.LocationWarehouse, Active: true})
+	require.NoError(t, err)
+
+	_, err = repository.CreateLocation(ctx, &domainStock.Location{Code: "front", Name: "Again", Type: domainStock.LocationCabinet})
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ResourceAlreadyExists, appErr.Type)
+	_, err = repository.CreateLocation(tenantContext(2, 5), &domainStock.Location{Code: "front", Name: "Front", Type: domainStock.LocationStore})
+	require.NoError(t, err, "codes are unique per organization")
+
+	locations, err := repository.GetLocations(ctx)
+	require.NoError(t, err)
+	require.Len(t, *locations, 2)
+	assert.Equal(t, "back", (*locations)[0].Code)
+
+	updated, err := repository.UpdateLocation(ctx, front.ID, map[string]any{"active": false, "type": domainStock.LocationCabinet, "userIds": []int{}})
+	require.NoError(t, err)
+	assert.False(t, updated.Active)
+	assert.Equal(t, domainStock.LocationCabinet, updated.Type)
+	assert.Empty(t, updated.UserIDs)
+
+	found, err := repository.GetLocationByCode(ctx, "front")
+	require.NoError(t, err)
+	assert.Equal(t, front.ID, found.ID)
+	_, err = repository.GetLocationByID(tenantContext(2, 5), front.ID)
+	appErr, ok = err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
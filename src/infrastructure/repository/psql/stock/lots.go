@@ -0,0 +1,290 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MedicineLot holds the units left of a lot at a location; a lot number has one row
+// per location holding units of it
+type MedicineLot struct {
+	ID               int        `gorm:"primaryKey"`
+	TenantID         int        `gorm:"uniqueIndex:idx_medicine_lots_location_number,priority:1"`
+	MedicineID       int        `gorm:"uniqueIndex:idx_medicine_lots_location_number,priority:2;index"`
+	LotNumber        string     `gorm:"uniqueIndex:idx_medicine_lots_location_number,priority:3;size:100"`
+	Location         string     `gorm:"uniqueIndex:idx_medicine_lots_location_number,priority:4;size:100;not null;default:'main'"`
+	ManufacturedAt   *time.Time `gorm:"type:date"`
+	ExpiresAt        time.Time  `gorm:"type:date;index"`
+	Quantity         int        `gorm:"not null;default:0;check:chk_medicine_lots_quantity,quantity >= 0"`
+	Supplier         string     `gorm:"size:255"`
+	QuarantinedAt    *time.Time
+	QuarantineReason string    `gorm:"size:255"`
+	CreatedAt        time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy        *int      `gorm:"index"`
+	UpdatedBy        *int      `gorm:"index"`
+}
+
+func (*MedicineLot) TableName() string {
+	return "medicine_lots"
+}
+
+// errInsufficientLots aborts a dispensation the dispensable lots cannot cover
+var errInsufficientLots = errors.New("insufficient stock in dispensable lots")
+
+// CreateLot records a received lot and its receipt into the stock of its location in
+// one transaction. A lot number is received once, whatever the location.
+func (r *Repository) CreateLot(ctx context.Context, lot *domainStock.MedicineLot) (*domainStock.MedicineLot, *domainStock.Level, error) {
+	row := lotFromDomainMapper(lot)
+	var level StockLevel
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&MedicineLot{}).
+			Where("medicine_id = ? AND lot_number = ?", lot.MedicineID, lot.LotNumber).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+		}
+		if err := tx.Create(row).Error; err != nil {
+			return err
+		}
+		var err error
+		level, err = record(tx, StockMovement{
+			TenantID:   row.TenantID,
+			MedicineID: row.MedicineID,
+			Location:   row.Location,
+			LotID:      &row.ID,
+			Type:       string(domainStock.MovementReceipt),
+			Quantity:   row.Quantity,
+		})
+		return err
+	})
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) {
+			r.Logger.Warn("Lot already received", zap.Int("medicineId", lot.MedicineID), zap.String("lotNumber", lot.LotNumber))
+			return nil, nil, appErr
+		}
+		r.Logger.Error("Error creating lot", zap.Error(err), zap.Int("medicineId", lot.MedicineID))
+		return nil, nil, writeError(err)
+	}
+	r.Logger.Info("Successfully created lot", zap.Int("id", row.ID), zap.Int("medicineId", row.MedicineID), zap.Int("quantity", row.Quantity))
+	return row.toDomainMapper(), level.toDomainMapper(), nil
+}
+
+// GetLotsByMedicine returns every lot of a medicine, first expiring first
+func (r *Repository) GetLotsByMedicine(ctx context.Context, medicineID int) (*[]domainStock.MedicineLot, error) {
+	var lots []MedicineLot
+	if err := r.DB.WithContext(ctx).Where("medicine_id = ?", medicineID).Order("expires_at, id").Find(&lots).Error; err != nil {
+		r.Logger.Error("Error getting lots", zap.Error(err), zap.Int("medicineId", medicineID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved lots", zap.Int("medicineId", medicineID), zap.Int("count", len(lots)))
+	return lotArrayToDomainMapper(&lots), nil
+}
+
+// GetExpiringLots returns the lots with units left and not in quarantine that expire
+// on or before through, already expired ones included, first expiring first
+func (r *Repository) GetExpiringLots(ctx context.Context, through time.Time) (*[]domainStock.MedicineLot, error) {
+	var lots []MedicineLot
+	if err := r.DB.WithContext(ctx).
+		Where("quantity > 0 AND quarantined_at IS NULL AND expires_at <= ?", through).
+		Order("expires_at, id").
+		Find(&lots).Error; err != nil {
+		r.Logger.Error("Error getting expiring lots", zap.Error(err), zap.Time("through", through))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved expiring lots", zap.Time("through", through), zap.Int("count", len(lots)))
+	return lotArrayToDomainMapper(&lots), nil
+}
+
+// GetLotByID returns one lot
+func (r *Repository) GetLotByID(ctx context.Context, lotID int) (*domainStock.MedicineLot, error) {
+	var lot MedicineLot
+	if err := r.DB.WithContext(ctx).First(&lot, lotID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Logger.Warn("Lot not found", zap.Int("id", lotID))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting lot", zap.Error(err), zap.Int("id", lotID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return lot.toDomainMapper(), nil
+}
+
+// QuarantineLot keeps the units of a lot from being dispensed
+func (r *Repository) QuarantineLot(ctx context.Context, lotID int, reason string, at time.Time) (*domainStock.MedicineLot, error) {
+	var lot MedicineLot
+	if err := r.DB.WithContext(ctx).First(&lot, lotID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting lot", zap.Error(err), zap.Int("id", lotID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := r.DB.WithContext(ctx).Model(&lot).
+		Where("quarantined_at IS NULL").
+		Updates(map[string]any{"quarantined_at": at, "quarantine_reason": reason})
+	if result.Error != nil {
+		r.Logger.Error("Error quarantining lot", zap.Error(result.Error), zap.Int("id", lotID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if result.RowsAffected == 0 {
+		return nil, domainErrors.NewAppError(fmt.Errorf("lot %s is already quarantined", lot.LotNumber), domainErrors.ValidationError)
+	}
+	if err := r.DB.WithContext(ctx).First(&lot, lotID).Error; err != nil {
+		r.Logger.Error("Error getting lot", zap.Error(err), zap.Int("id", lotID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully quarantined lot", zap.Int("id", lotID))
+	return lot.toDomainMapper(), nil
+}
+
+// Dispense removes quantity units of a medicine from its dispensable lots at a
+// location, first expired first out, and records a dispense movement per lot, all in
+// one transaction. On PostgreSQL the lots are locked while allocated; every update is
+// also conditional, so a lot never goes below zero.
+func (r *Repository) Dispense(ctx context.Context, medicineID int, location string, quantity int, reason string, today time.Time) (*domainStock.Dispensation, error) {
+	var allocations []domainStock.Allocation
+	var level StockLevel
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("medicine_id = ? AND location = ? AND quantity > 0 AND quarantined_at IS NULL AND expires_at >= ?", medicineID, location, today)
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var lots []MedicineLot
+		if err := query.Find(&lots).Error; err != nil {
+			return err
+		}
+		var ok bool
+		allocations, ok = domainStock.AllocateFEFO(*lotArrayToDomainMapper(&lots), quantity, today)
+		if !ok {
+			return errInsufficientLots
+		}
+		movements := make([]StockMovement, len(allocations))
+		for i, allocation := range allocations {
+			movements[i] = StockMovement{
+				TenantID:   lots[0].TenantID,
+				MedicineID: medicineID,
+				Location:   location,
+				LotID:      &allocation.LotID,
+				Type:       string(domainStock.MovementDispense),
+				Quantity:   -allocation.Quantity,
+				Reason:     reason,
+			}
+			result := tx.Model(&MedicineLot{}).
+				Where("id = ? AND quantity >= ?", allocation.LotID, allocation.Quantity).
+				Updates(map[string]any{"quantity": gorm.Expr("quantity - ?", allocation.Quantity)})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errInsufficientLots
+			}
+		}
+		var err error
+		level, err = record(tx, movements...)
+		return err
+	})
+	if errors.Is(err, errInsufficientLots) {
+		available := r.dispensable(ctx, medicineID, location, today)
+		r.Logger.Warn("Insufficient stock in dispensable lots",
+			zap.Int("medicineId", medicineID),
+			zap.String("location", location),
+			zap.Int("quantity", quantity),
+			zap.Int("available", available))
+		return nil, domainErrors.NewAppError(
+			fmt.Errorf("insufficient stock: %d units in dispensable lots at %s", available, location), domainErrors.ValidationError)
+	}
+	if err != nil {
+		return nil, r.recordError(ctx, err, medicineID, location, -quantity)
+	}
+	r.Logger.Info("Successfully dispensed medicine",
+		zap.Int("medicineId", medicineID),
+		zap.Int("quantity", quantity),
+		zap.Int("lots", len(allocations)))
+	return &domainStock.Dispensation{
+		MedicineID:  medicineID,
+		Quantity:    quantity,
+		Allocations: allocations,
+		Level:       *level.toDomainMapper(),
+	}, nil
+}
+
+// dispensable returns the units of a medicine in the lots at a location dispensable today
+func (r *Repository) dispensable(ctx context.Context, medicineID int, location string, today time.Time) int {
+	var total int
+	r.DB.WithContext(ctx).Model(&MedicineLot{}).
+		Where("medicine_id = ? AND location = ? AND quantity > 0 AND quarantined_at IS NULL AND expires_at >= ?", medicineID, location, today).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total)
+	return total
+}
+
+// writeError translates a failed write into the matching application error
+func writeError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+}
+
+// Mappers
+func (l *MedicineLot) toDomainMapper() *domainStock.MedicineLot {
+	return &domainStock.MedicineLot{
+		ID:               l.ID,
+		TenantID:         l.TenantID,
+		MedicineID:       l.MedicineID,
+		LotNumber:        l.LotNumber,
+		Location:         l.Location,
+		ManufacturedAt:   dayPointer(l.ManufacturedAt),
+		ExpiresAt:        domainStock.Day(l.ExpiresAt),
+		Quantity:         l.Quantity,
+		Supplier:         l.Supplier,
+		QuarantinedAt:    l.QuarantinedAt,
+		QuarantineReason: l.QuarantineReason,
+		CreatedAt:        l.CreatedAt,
+		UpdatedAt:        l.UpdatedAt,
+		CreatedBy:        l.CreatedBy,
+		UpdatedBy:        l.UpdatedBy,
+	}
+}
+
+func lotFromDomainMapper(lot *domainStock.MedicineLot) *MedicineLot {
+	return &MedicineLot{
+		TenantID:       lot.TenantID,
+		MedicineID:     lot.MedicineID,
+		LotNumber:      lot.LotNumber,
+		Location:       lot.Location,
+		ManufacturedAt: dayPointer(lot.ManufacturedAt),
+		ExpiresAt:      domainStock.Day(lot.ExpiresAt),
+		Quantity:       lot.Quantity,
+		Supplier:       lot.Supplier,
+	}
+}
+
+func lotArrayToDomainMapper(lots *[]MedicineLot) *[]domainStock.MedicineLot {
+	lotsDomain := make([]domainStock.MedicineLot, len(*lots))
+	for i, lot := range *lots {
+		lotsDomain[i] = *lot.toDomainMapper()
+	}
+	return &lotsDomain
+}
+
+func dayPointer(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	day := domainStock.Day(*t)
+	return &day
+}
@@ -0,0 +1,131 @@
+package stock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var today = time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+
+func receiveLots(t *testing.T, repository StockRepositoryInterface, lots ...domainStock.MedicineLot) []domainStock.MedicineLot {
+	t.Helper()
+	created := make([]domainStock.MedicineLot, len(lots))
+	for i := range lots {
+		lot, _, err := repository.CreateLot(tenantContext(lots[i].TenantID, 1), &lots[i])
+		require.NoError(t, err)
+		created[i] = *lot
+	}
+	return created
+}
+
+func TestRepository_CreateLot(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+	manufacturedAt := today.AddDate(-1, 0, 0)
+
+	lot, level, err := repository.CreateLot(ctx, &domainStock.MedicineLot{
+		TenantID: 1, MedicineID: 5, LotNumber: "L-1", ManufacturedAt: &manufacturedAt,
+		ExpiresAt: today.AddDate(1, 0, 0), Quantity: 20, Supplier: "Acme",
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, lot.ID)
+	assert.Equal(t, today.AddDate(1, 0, 0), lot.ExpiresAt)
+	assert.Equal(t, manufacturedAt, *lot.ManufacturedAt)
+	assert.Equal(t, 3, *lot.CreatedBy)
+	assert.Equal(t, domainStock.DefaultLocation, level.Location)
+	assert.Equal(t, 20, level.Quantity, "received units are added to the stock")
+
+	_, _, err = repository.CreateLot(ctx, &domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-1", ExpiresAt: today, Quantity: 1})
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ResourceAlreadyExists, appErr.Type)
+
+	levels, err := repository.GetByMedicine(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 20, (*levels)[0].Quantity, "a refused lot leaves the stock unchanged")
+}
+
+func TestRepository_DispenseFEFO(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 1)
+	lots := receiveLots(t, repository,
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-LATE", ExpiresAt: today.AddDate(1, 0, 0), Quantity: 10},
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-EXPIRED", ExpiresAt: today.AddDate(0, 0, -1), Quantity: 10},
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-SOON", ExpiresAt: today, Quantity: 3},
+	)
+
+	dispensation, err := repository.Dispense(ctx, 5, "main", 5, "prescription", today)
+	require.NoError(t, err)
+	require.Len(t, dispensation.Allocations, 2)
+	assert.Equal(t, "L-SOON", dispensation.Allocations[0].LotNumber)
+	assert.Equal(t, 3, dispensation.Allocations[0].Quantity)
+	assert.Equal(t, "L-LATE", dispensation.Allocations[1].LotNumber)
+	assert.Equal(t, 2, dispensation.Allocations[1].Quantity)
+	assert.Equal(t, 18, dispensation.Level.Quantity)
+
+	movements, err := repository.GetMovements(ctx, 5, domain.DataFilters{Matches: map[string][]string{"type": {"dispense"}}})
+	require.NoError(t, err)
+	require.Len(t, *movements.Data, 2, "one movement per lot")
+	assert.Equal(t, lots[0].ID, *(*movements.Data)[0].LotID)
+	assert.Equal(t, -2, (*movements.Data)[0].Quantity)
+	assert.Equal(t, "prescription", (*movements.Data)[0].Reason)
+
+	_, err = repository.QuarantineLot(ctx, lots[0].ID, "recall", today)
+	require.NoError(t, err)
+	_, err = repository.Dispense(ctx, 5, "main", 1, "", today)
+	require.Error(t, err)
+	assert.Equal(t, "insufficient stock: 0 units in dispensable lots at main", err.Error())
+
+	stored, err := repository.GetLotsByMedicine(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, *stored, 3)
+	assert.Equal(t, []string{"L-EXPIRED", "L-SOON", "L-LATE"},
+		[]string{(*stored)[0].LotNumber, (*stored)[1].LotNumber, (*stored)[2].LotNumber})
+	assert.Equal(t, 0, (*stored)[1].Quantity)
+	assert.Equal(t, 8, (*stored)[2].Quantity, "a refused dispensation leaves the lots unchanged")
+}
+
+func TestRepository_QuarantineLot(t *testing.T) {
+	repository := setupRepository(t)
+	lots := receiveLots(t, repository,
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-1", ExpiresAt: today, Quantity: 1})
+
+	lot, err := repository.QuarantineLot(tenantContext(1, 2), lots[0].ID, "temperature excursion", today)
+	require.NoError(t, err)
+	require.NotNil(t, lot.QuarantinedAt)
+	assert.Equal(t, "temperature excursion", lot.QuarantineReason)
+	assert.Equal(t, 2, *lot.UpdatedBy)
+
+	_, err = repository.QuarantineLot(tenantContext(1, 2), lots[0].ID, "again", today)
+	assert.EqualError(t, err, "lot L-1 is already quarantined")
+
+	_, err = repository.QuarantineLot(tenantContext(2, 2), lots[0].ID, "other tenant", today)
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestRepository_GetExpiringLots(t *testing.T) {
+	repository := setupRepository(t)
+	lots := receiveLots(t, repository,
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-EXPIRED", ExpiresAt: today.AddDate(0, 0, -3), Quantity: 1},
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 6, LotNumber: "L-WEEK", ExpiresAt: today.AddDate(0, 0, 7), Quantity: 1},
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-MONTH", ExpiresAt: today.AddDate(0, 0, 8), Quantity: 1},
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-QUARANTINED", ExpiresAt: today, Quantity: 1},
+		domainStock.MedicineLot{TenantID: 2, MedicineID: 9, LotNumber: "L-OTHER", ExpiresAt: today, Quantity: 1},
+	)
+	_, err := repository.QuarantineLot(tenantContext(1, 1), lots[3].ID, "recall", today)
+	require.NoError(t, err)
+
+	expiring, err := repository.GetExpiringLots(tenantContext(1, 1), today.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, *expiring, 2)
+	assert.Equal(t, "L-EXPIRED", (*expiring)[0].LotNumber)
+	assert.Equal(t, "L-WEEK", (*expiring)[1].LotNumber)
+}
@@ -0,0 +1,428 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockRepositoryInterface defines the interface for stock repository operations
+type StockRepositoryInterface interface {
+	GetByMedicine(ctx context.Context, medicineID int) (*[]domainStock.Level, error)
+	Adjust(ctx context.Context, adjustment domainStock.Adjustment) (*domainStock.Level, error)
+	CreateLot(ctx context.Context, lot *domainStock.MedicineLot) (*domainStock.MedicineLot, *domainStock.Level, error)
+	GetLotsByMedicine(ctx context.Context, medicineID int) (*[]domainStock.MedicineLot, error)
+	GetExpiringLots(ctx context.Context, through time.Time) (*[]domainStock.MedicineLot, error)
+	QuarantineLot(ctx context.Context, lotID int, reason string, at time.Time) (*domainStock.MedicineLot, error)
+	GetLotByID(ctx context.Context, lotID int) (*domainStock.MedicineLot, error)
+	Dispense(ctx context.Context, medicineID int, location string, quantity int, reason string, today time.Time) (*domainStock.Dispensation, error)
+	GetMovements(ctx context.Context, medicineID int, filters domain.DataFilters) (*domainStock.SearchResultMovement, error)
+	Snapshot(ctx context.Context) (int64, error)
+	GetLocations(ctx context.Context) (*[]domainStock.Location, error)
+	GetLocationByID(ctx context.Context, id int) (*domainStock.Location, error)
+	GetLocationByCode(ctx context.Context, code string) (*domainStock.Location, error)
+	CreateLocation(ctx context.Context, location *domainStock.Location) (*domainStock.Location, error)
+	UpdateLocation(ctx context.Context, id int, locationMap map[string]any) (*domainStock.Location, error)
+	CreateTransfer(ctx context.Context, transfer *domainStock.Transfer) (*domainStock.Transfer, error)
+	GetTransferByID(ctx context.Context, id int) (*domainStock.Transfer, error)
+	GetTransfers(ctx context.Context, filters domain.DataFilters) (*domainStock.SearchResultTransfer, error)
+	ShipTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, *domainStock.Level, error)
+	ReceiveTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, *domainStock.Level, error)
+	CancelTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, error)
+	InTransit(ctx context.Context, medicineID int) (int, error)
+	GetThresholds(ctx context.Context, medicineID int) (*[]domainStock.Threshold, error)
+	GetAllThresholds(ctx context.Context) (*[]domainStock.Threshold, error)
+	GetThreshold(ctx context.Context, medicineID int, location string) (*domainStock.Threshold, error)
+	SaveThreshold(ctx context.Context, threshold *domainStock.Threshold) (*domainStock.Threshold, error)
+	DeleteThreshold(ctx context.Context, id int) (*domainStock.Threshold, error)
+	RaiseAlert(ctx context.Context, alert *domainStock.Alert) (*domainStock.Alert, bool, error)
+	ResolveAlert(ctx context.Context, key string, at time.Time) error
+	GetUnresolvedAlerts(ctx context.Context, alertType domainStock.AlertType) (*[]domainStock.Alert, error)
+	GetAlertByID(ctx context.Context, id int) (*domainStock.Alert, error)
+	GetAlerts(ctx context.Context, filters domain.DataFilters) (*domainStock.SearchResultAlert, error)
+	AcknowledgeAlert(ctx context.Context, id int, at time.Time) (*domainStock.Alert, error)
+}
+
+// Structures
+
+// StockLevel is the snapshot of the stock of a medicine at a location: Quantity is the
+// sum of its ledger up to MovementID, and UpdatedAt/UpdatedBy come from that movement.
+// The current stock adds the movements recorded since; Snapshot folds them in.
+type StockLevel struct {
+	ID         int       `gorm:"primaryKey"`
+	TenantID   int       `gorm:"uniqueIndex:idx_stock_levels_medicine_location,priority:1"`
+	MedicineID int       `gorm:"uniqueIndex:idx_stock_levels_medicine_location,priority:2;index"`
+	Location   string    `gorm:"uniqueIndex:idx_stock_levels_medicine_location,priority:3;size:100"`
+	Quantity   int       `gorm:"not null;default:0;check:chk_stock_levels_quantity,quantity >= 0"`
+	MovementID int64     `gorm:"not null;default:0"`
+	CreatedAt  time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy  *int      `gorm:"index"`
+	UpdatedBy  *int      `gorm:"index"`
+}
+
+func (*StockLevel) TableName() string {
+	return "stock_levels"
+}
+
+// StockMovement is an entry of the stock ledger. The repository only ever inserts them.
+type StockMovement struct {
+	ID         int64     `gorm:"primaryKey"`
+	TenantID   int       `gorm:"index:idx_stock_movements_level,priority:1"`
+	MedicineID int       `gorm:"index:idx_stock_movements_level,priority:2"`
+	Location   string    `gorm:"index:idx_stock_movements_level,priority:3;size:100"`
+	LotID      *int      `gorm:"index"`
+	TransferID *int      `gorm:"index"`
+	Type       string    `gorm:"size:20;index"`
+	Quantity   int       `gorm:"not null"`
+	Reason     string    `gorm:"size:50"`
+	CreatedAt  time.Time `gorm:"autoCreateTime:milli;index"`
+	CreatedBy  *int      `gorm:"index"`
+}
+
+func (*StockMovement) TableName() string {
+	return "stock_movements"
+}
+
+// ColumnsMovementMapping maps the filterable movement fields to their columns
+var ColumnsMovementMapping = map[string]string{
+	"id":         "id",
+	"location":   "location",
+	"lotId":      "lot_id",
+	"transferId": "transfer_id",
+	"type":       "type",
+	"quantity":   "quantity",
+	"reason":     "reason",
+	"createdAt":  "created_at",
+	"createdBy":  "created_by",
+}
+
+// errInsufficient aborts a change that would take the stock below zero
+var errInsufficient = errors.New("insufficient stock")
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewStockRepository(DB *gorm.DB, loggerInstance *logger.Logger) StockRepositoryInterface {
+	return &Repository{
+		DB:     DB,
+		Logger: loggerInstance,
+	}
+}
+
+// GetByMedicine returns the current stock levels of a medicine ordered by location
+func (r *Repository) GetByMedicine(ctx context.Context, medicineID int) (*[]domainStock.Level, error) {
+	var levels []StockLevel
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("medicine_id = ?", medicineID).Order("location").Find(&levels).Error; err != nil {
+			return err
+		}
+		for i := range levels {
+			if err := current(tx, &levels[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.Logger.Error("Error getting stock levels", zap.Error(err), zap.Int("medicineId", medicineID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved stock levels", zap.Int("medicineId", medicineID), zap.Int("count", len(levels)))
+	return arrayToDomainMapper(&levels), nil
+}
+
+// Adjust records adjustment in the ledger
+func (r *Repository) Adjust(ctx context.Context, adjustment domainStock.Adjustment) (*domainStock.Level, error) {
+	movementType := adjustment.Type
+	if movementType == "" {
+		movementType = domainStock.MovementAdjustment
+	}
+	var level StockLevel
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		level, err = record(tx, StockMovement{
+			TenantID:   adjustment.TenantID,
+			MedicineID: adjustment.MedicineID,
+			Location:   adjustment.Location,
+			Type:       string(movementType),
+			Quantity:   adjustment.Delta,
+			Reason:     adjustment.Reason,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, r.recordError(ctx, err, adjustment.MedicineID, adjustment.Location, adjustment.Delta)
+	}
+	r.Logger.Info("Successfully adjusted stock",
+		zap.Int("medicineId", adjustment.MedicineID),
+		zap.String("location", adjustment.Location),
+		zap.Int("delta", adjustment.Delta),
+		zap.Int("quantity", level.Quantity))
+	return level.toDomainMapper(), nil
+}
+
+// record appends movements of the stock of one medicine at one location to the ledger
+// within tx and returns the resulting level. The level is locked first, so writers of
+// the same stock are serialized and removals are checked against its current quantity.
+func record(tx *gorm.DB, movements ...StockMovement) (StockLevel, error) {
+	first := movements[0]
+	seed := StockLevel{TenantID: first.TenantID, MedicineID: first.MedicineID, Location: first.Location}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "medicine_id"}, {Name: "location"}},
+		DoNothing: true,
+	}).Create(&seed).Error; err != nil {
+		return StockLevel{}, err
+	}
+	level, err := lockLevel(tx, first.TenantID, first.MedicineID, first.Location)
+	if err != nil {
+		return level, err
+	}
+	if err := current(tx, &level); err != nil {
+		return level, err
+	}
+	delta := 0
+	for _, movement := range movements {
+		delta += movement.Quantity
+	}
+	if level.Quantity+delta < 0 {
+		return level, errInsufficient
+	}
+	if err := tx.Create(&movements).Error; err != nil {
+		return level, err
+	}
+	last := movements[len(movements)-1]
+	level.Quantity += delta
+	level.UpdatedAt = last.CreatedAt
+	level.UpdatedBy = last.CreatedBy
+	return level, nil
+}
+
+// lockLevel reads the snapshot of a stock level within tx. On PostgreSQL the row stays
+// locked until tx ends; SQLite serializes write transactions anyway.
+func lockLevel(tx *gorm.DB, tenantID, medicineID int, location string) (StockLevel, error) {
+	var level StockLevel
+	query := tx.Where("tenant_id = ? AND medicine_id = ? AND location = ?", tenantID, medicineID, location)
+	if tx.Dialector.Name() == "postgres" {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	err := query.First(&level).Error
+	return level, err
+}
+
+// current brings a level snapshot up to date with the movements recorded since
+func current(tx *gorm.DB, level *StockLevel) error {
+	_, err := fold(tx, level)
+	return err
+}
+
+// fold adds the movements recorded after the snapshot of level to it and returns the
+// ID of the last one, zero when there are none
+func fold(tx *gorm.DB, level *StockLevel) (int64, error) {
+	var pending struct {
+		Quantity int
+		LastID   int64
+	}
+	if err := tx.Model(&StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0) AS quantity, COALESCE(MAX(id), 0) AS last_id").
+		Where("tenant_id = ? AND medicine_id = ? AND location = ? AND id > ?", level.TenantID, level.MedicineID, level.Location, level.MovementID).
+		Scan(&pending).Error; err != nil || pending.LastID == 0 {
+		return 0, err
+	}
+	var last StockMovement
+	if err := tx.First(&last, pending.LastID).Error; err != nil {
+		return 0, err
+	}
+	level.Quantity += pending.Quantity
+	level.UpdatedAt = last.CreatedAt
+	level.UpdatedBy = last.CreatedBy
+	return pending.LastID, nil
+}
+
+// recordError translates the failure of a stock change into the matching application error
+func (r *Repository) recordError(ctx context.Context, err error, medicineID int, location string, delta int) error {
+	if errors.Is(err, errInsufficient) {
+		available := r.quantity(ctx, medicineID, location)
+		r.Logger.Warn("Insufficient stock for adjustment",
+			zap.Int("medicineId", medicineID),
+			zap.String("location", location),
+			zap.Int("delta", delta),
+			zap.Int("available", available))
+		return domainErrors.NewAppError(
+			fmt.Errorf("insufficient stock: %d on hand at %s", available, location), domainErrors.ValidationError)
+	}
+	r.Logger.Error("Error adjusting stock", zap.Error(err), zap.Int("medicineId", medicineID))
+	return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+}
+
+// quantity returns the units on hand at a location, zero when it has no stock
+func (r *Repository) quantity(ctx context.Context, medicineID int, location string) int {
+	var level StockLevel
+	db := r.DB.WithContext(ctx)
+	if err := db.Where("medicine_id = ? AND location = ?", medicineID, location).First(&level).Error; err != nil {
+		return 0
+	}
+	if err := current(db, &level); err != nil {
+		return 0
+	}
+	return level.Quantity
+}
+
+// GetMovements returns a page of the ledger of a medicine, latest first unless sorted
+func (r *Repository) GetMovements(ctx context.Context, medicineID int, filters domain.DataFilters) (*domainStock.SearchResultMovement, error) {
+	query := r.DB.WithContext(ctx).Model(&StockMovement{}).Where("medicine_id = ?", medicineID)
+
+	for field, values := range filters.Matches {
+		if column := ColumnsMovementMapping[field]; column != "" && len(values) > 0 {
+			query = query.Where(column+" IN ?", values)
+		}
+	}
+	for _, dateFilter := range filters.DateRangeFilters {
+		column := ColumnsMovementMapping[dateFilter.Field]
+		if column == "" {
+			continue
+		}
+		if dateFilter.Start != nil {
+			query = query.Where(column+" >= ?", dateFilter.Start)
+		}
+		if dateFilter.End != nil {
+			query = query.Where(column+" <= ?", dateFilter.End)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Logger.Error("Error counting stock movements", zap.Error(err), zap.Int("medicineId", medicineID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	sorted := false
+	if filters.SortDirection.IsValid() {
+		for _, sortField := range filters.SortBy {
+			if column := ColumnsMovementMapping[sortField]; column != "" {
+				query = query.Order(column + " " + string(filters.SortDirection))
+				sorted = true
+			}
+		}
+	}
+	if !sorted {
+		query = query.Order("id DESC")
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	var movements []StockMovement
+	if err := query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize).Find(&movements).Error; err != nil {
+		r.Logger.Error("Error listing stock movements", zap.Error(err), zap.Int("medicineId", medicineID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	data := make([]domainStock.Movement, len(movements))
+	for i := range movements {
+		data[i] = *movements[i].toDomainMapper()
+	}
+	r.Logger.Info("Successfully listed stock movements", zap.Int("medicineId", medicineID), zap.Int64("total", total))
+	return &domainStock.SearchResultMovement{
+		Data:       &data,
+		Total:      total,
+		Page:       filters.Page,
+		PageSize:   filters.PageSize,
+		TotalPages: int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize)),
+	}, nil
+}
+
+// Snapshot folds the movements recorded since the last snapshot into the stock levels
+// and returns the number of levels brought up to date. Each level is locked while it
+// is folded, so no movement of it can be in flight.
+func (r *Repository) Snapshot(ctx context.Context) (int64, error) {
+	var levels []StockLevel
+	if err := r.DB.WithContext(ctx).
+		Where(`EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.tenant_id = stock_levels.tenant_id
+			AND stock_movements.medicine_id = stock_levels.medicine_id
+			AND stock_movements.location = stock_levels.location
+			AND stock_movements.id > stock_levels.movement_id)`).
+		Find(&levels).Error; err != nil {
+		r.Logger.Error("Error finding stock levels to snapshot", zap.Error(err))
+		return 0, err
+	}
+	var updated int64
+	for _, candidate := range levels {
+		err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			level, err := lockLevel(tx, candidate.TenantID, candidate.MedicineID, candidate.Location)
+			if err != nil {
+				return err
+			}
+			lastID, err := fold(tx, &level)
+			if err != nil || lastID == 0 {
+				return err
+			}
+			return tx.Model(&StockLevel{ID: level.ID}).UpdateColumns(map[string]any{
+				"quantity":    level.Quantity,
+				"movement_id": lastID,
+				"updated_at":  level.UpdatedAt,
+				"updated_by":  level.UpdatedBy,
+			}).Error
+		})
+		if err != nil {
+			r.Logger.Error("Error taking stock snapshot", zap.Error(err), zap.Int("levelId", candidate.ID))
+			return updated, err
+		}
+		updated++
+	}
+	r.Logger.Info("Successfully took stock snapshots", zap.Int64("levels", updated))
+	return updated, nil
+}
+
+// Mappers
+func (l *StockLevel) toDomainMapper() *domainStock.Level {
+	return &domainStock.Level{
+		ID:         l.ID,
+		TenantID:   l.TenantID,
+		MedicineID: l.MedicineID,
+		Location:   l.Location,
+		Quantity:   l.Quantity,
+		CreatedAt:  l.CreatedAt,
+		UpdatedAt:  l.UpdatedAt,
+		CreatedBy:  l.CreatedBy,
+		UpdatedBy:  l.UpdatedBy,
+	}
+}
+
+func arrayToDomainMapper(levels *[]StockLevel) *[]domainStock.Level {
+	levelsDomain := make([]domainStock.Level, len(*levels))
+	for i, level := range *levels {
+		levelsDomain[i] = *level.toDomainMapper()
+	}
+	return &levelsDomain
+}
+
+func (m *StockMovement) toDomainMapper() *domainStock.Movement {
+	return &domainStock.Movement{
+		ID:         m.ID,
+		TenantID:   m.TenantID,
+		MedicineID: m.MedicineID,
+		Location:   m.Location,
+		LotID:      m.LotID,
+		TransferID: m.TransferID,
+		Type:       domainStock.MovementType(m.Type),
+		Quantity:   m.Quantity,
+		Reason:     m.Reason,
+		CreatedAt:  m.CreatedAt,
+		CreatedBy:  m.CreatedBy,
+	}
+}
@@ -0,0 +1,151 @@
+package stock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/audit"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/tenant"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupRepository(t *testing.T) StockRepositoryInterface {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, tenant.RegisterCallbacks(db))
+	require.NoError(t, audit.RegisterCallbacks(db))
+	require.NoError(t, db.AutoMigrate(&StockLevel{}, &StockMovement{}, &MedicineLot{}, &Location{}, &Transfer{}, &Threshold{}, &Alert{}))
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewStockRepository(db, loggerInstance)
+}
+
+func tenantContext(tenantID, userID int) context.Context {
+	return security.WithPrincipal(context.Background(), security.Principal{UserID: userID, TenantID: tenantID})
+}
+
+func TestRepository_Adjust(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+
+	level, err := repository.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "main", Delta: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 10, level.Quantity)
+	assert.Equal(t, 3, *level.CreatedBy)
+
+	level, err = repository.Adjust(tenantContext(1, 4), domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "main", Delta: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 12, level.Quantity, "additions increment the existing level")
+	assert.Equal(t, 4, *level.UpdatedBy)
+
+	level, err = repository.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "main", Delta: -12})
+	require.NoError(t, err)
+	assert.Equal(t, 0, level.Quantity)
+
+	_, err = repository.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "main", Delta: -1})
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.Equal(t, "insufficient stock: 0 on hand at main", appErr.Error())
+
+	_, err = repository.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "front", Delta: -1})
+	assert.Error(t, err, "a location without stock has nothing to remove")
+}
+
+func TestRepository_GetByMedicineIsScoped(t *testing.T) {
+	repository := setupRepository(t)
+	for _, adjustment := range []domainStock.Adjustment{
+		{TenantID: 1, MedicineID: 5, Location: "main", Delta: 3},
+		{TenantID: 1, MedicineID: 5, Location: "front", Delta: 1},
+		{TenantID: 1, MedicineID: 6, Location: "main", Delta: 9},
+		{TenantID: 2, MedicineID: 5, Location: "main", Delta: 7},
+	} {
+		_, err := repository.Adjust(tenantContext(adjustment.TenantID, 1), adjustment)
+		require.NoError(t, err)
+	}
+
+	levels, err := repository.GetByMedicine(tenantContext(1, 1), 5)
+	require.NoError(t, err)
+	require.Len(t, *levels, 2)
+	assert.Equal(t, "front", (*levels)[0].Location)
+	assert.Equal(t, 3, (*levels)[1].Quantity)
+}
+
+func TestRepository_GetMovements(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+	for _, adjustment := range []domainStock.Adjustment{
+		{TenantID: 1, MedicineID: 5, Location: "main", Delta: 10},
+		{TenantID: 1, MedicineID: 5, Location: "main", Delta: -2, Type: domainStock.MovementWriteOff, Reason: "damaged"},
+		{TenantID: 1, MedicineID: 5, Location: "front", Delta: 1, Type: domainStock.MovementReturn},
+		{TenantID: 1, MedicineID: 6, Location: "main", Delta: 4},
+	} {
+		_, err := repository.Adjust(ctx, adjustment)
+		require.NoError(t, err)
+	}
+
+	result, err := repository.GetMovements(ctx, 5, domain.DataFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	require.Len(t, *result.Data, 3)
+	latest := (*result.Data)[0]
+	assert.Equal(t, domainStock.MovementReturn, latest.Type, "latest first")
+	assert.Equal(t, 3, *latest.CreatedBy)
+
+	result, err = repository.GetMovements(ctx, 5, domain.DataFilters{Matches: map[string][]string{"type": {"write-off"}}})
+	require.NoError(t, err)
+	require.Len(t, *result.Data, 1)
+	assert.Equal(t, -2, (*result.Data)[0].Quantity)
+	assert.Equal(t, "damaged", (*result.Data)[0].Reason)
+
+	result, err = repository.GetMovements(tenantContext(2, 1), 5, domain.DataFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.Total, "movements are scoped to the tenant")
+}
+
+func TestRepository_Snapshot(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+	for _, delta := range []int{10, -4, 2} {
+		_, err := repository.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "main", Delta: delta})
+		require.NoError(t, err)
+	}
+	_, err := repository.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "front", Delta: 1})
+	require.NoError(t, err)
+
+	updated, err := repository.Snapshot(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+
+	db := repository.(*Repository).DB
+	var level StockLevel
+	require.NoError(t, db.Where("location = ?", "main").First(&level).Error)
+	assert.Equal(t, 8, level.Quantity, "the snapshot holds the sum of the ledger")
+	assert.NotZero(t, level.MovementID)
+
+	updated, err = repository.Snapshot(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), updated, "nothing left to fold")
+
+	_, err = repository.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "main", Delta: -8})
+	require.NoError(t, err)
+	levels, err := repository.GetByMedicine(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, (*levels)[0].Quantity)
+	assert.Equal(t, 0, (*levels)[1].Quantity, "reads add the movements after the snapshot")
+
+	_, err = repository.Adjust(ctx, domainStock.Adjustment{TenantID: 1, MedicineID: 5, Location: "main", Delta: -1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,373 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Transfer moves units of a medicine between two locations. Its status only ever
+// advances through conditional updates, so each step happens once.
+type Transfer struct {
+	ID           int    `gorm:"primaryKey"`
+	TenantID     int    `gorm:"index"`
+	MedicineID   int    `gorm:"index"`
+	LotID        *int   `gorm:"index"`
+	FromLocation string `gorm:"size:100"`
+	ToLocation   string `gorm:"size:100"`
+	Quantity     int    `gorm:"not null;check:chk_stock_transfers_quantity,quantity > 0"`
+	Status       string `gorm:"size:20;index"`
+	Reason       string `gorm:"size:50"`
+	ShippedAt    *time.Time
+	ReceivedAt   *time.Time
+	CancelledAt  *time.Time
+	CreatedAt    time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy    *int      `gorm:"index"`
+	UpdatedBy    *int      `gorm:"index"`
+}
+
+func (*Transfer) TableName() string {
+	return "stock_transfers"
+}
+
+// ColumnsTransferMapping maps the filterable transfer fields to their columns
+var ColumnsTransferMapping = map[string]string{
+	"id":           "id",
+	"medicineId":   "medicine_id",
+	"lotId":        "lot_id",
+	"fromLocation": "from_location",
+	"toLocation":   "to_location",
+	"quantity":     "quantity",
+	"status":       "status",
+	"reason":       "reason",
+	"shippedAt":    "shipped_at",
+	"receivedAt":   "received_at",
+	"createdAt":    "created_at",
+	"createdBy":    "created_by",
+}
+
+// transferTimestamps maps each status a transfer advances to onto the column recording when
+var transferTimestamps = map[domainStock.TransferStatus]string{
+	domainStock.TransferShipped:   "shipped_at",
+	domainStock.TransferReceived:  "received_at",
+	domainStock.TransferCancelled: "cancelled_at",
+}
+
+// errInsufficientLot aborts a shipment the lot being transferred cannot cover
+var errInsufficientLot = errors.New("insufficient stock in lot")
+
+func (r *Repository) CreateTransfer(ctx context.Context, transfer *domainStock.Transfer) (*domainStock.Transfer, error) {
+	row := transferFromDomainMapper(transfer)
+	row.Status = string(domainStock.TransferRequested)
+	if err := r.DB.WithContext(ctx).Create(row).Error; err != nil {
+		r.Logger.Error("Error creating transfer", zap.Error(err), zap.Int("medicineId", transfer.MedicineID))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully created transfer", zap.Int("id", row.ID), zap.Int("medicineId", row.MedicineID))
+	return row.toDomainMapper(), nil
+}
+
+func (r *Repository) GetTransferByID(ctx context.Context, id int) (*domainStock.Transfer, error) {
+	var transfer Transfer
+	if err := r.DB.WithContext(ctx).First(&transfer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Logger.Warn("Transfer not found", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting transfer", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return transfer.toDomainMapper(), nil
+}
+
+// GetTransfers lists transfers, newest first unless filters sort otherwise
+func (r *Repository) GetTransfers(ctx context.Context, filters domain.DataFilters) (*domainStock.SearchResultTransfer, error) {
+	query := r.DB.WithContext(ctx).Model(&Transfer{})
+
+	for field, values := range filters.Matches {
+		if column := ColumnsTransferMapping[field]; column != "" && len(values) > 0 {
+			query = query.Where(column+" IN ?", values)
+		}
+	}
+	for _, dateFilter := range filters.DateRangeFilters {
+		column := ColumnsTransferMapping[dateFilter.Field]
+		if column == "" {
+			continue
+		}
+		if dateFilter.Start != nil {
+			query = query.Where(column+" >= ?", dateFilter.Start)
+		}
+		if dateFilter.End != nil {
+			query = query.Where(column+" <= ?", dateFilter.End)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Logger.Error("Error counting transfers", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	sorted := false
+	if filters.SortDirection.IsValid() {
+		for _, sortField := range filters.SortBy {
+			if column := ColumnsTransferMapping[sortField]; column != "" {
+				query = query.Order(column + " " + string(filters.SortDirection))
+				sorted = true
+			}
+		}
+	}
+	if !sorted {
+		query = query.Order("id DESC")
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	var transfers []Transfer
+	if err := query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize).Find(&transfers).Error; err != nil {
+		r.Logger.Error("Error listing transfers", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	data := make([]domainStock.Transfer, len(transfers))
+	for i := range transfers {
+		data[i] = *transfers[i].toDomainMapper()
+	}
+	r.Logger.Info("Successfully listed transfers", zap.Int64("total", total))
+	return &domainStock.SearchResultTransfer{
+		Data:       &data,
+		Total:      total,
+		Page:       filters.Page,
+		PageSize:   filters.PageSize,
+		TotalPages: int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize)),
+	}, nil
+}
+
+// ShipTransfer takes the units of a requested transfer from its source location, and
+// from its lot there when it has one, in one transaction
+func (r *Repository) ShipTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, *domainStock.Level, error) {
+	var transfer Transfer
+	var lot MedicineLot
+	var level StockLevel
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if transfer, err = advance(tx, id, domainStock.TransferShipped, at); err != nil {
+			return err
+		}
+		if transfer.LotID != nil {
+			if err := tx.First(&lot, *transfer.LotID).Error; err != nil {
+				return err
+			}
+			result := tx.Model(&MedicineLot{}).
+				Where("id = ? AND location = ? AND quantity >= ?", lot.ID, transfer.FromLocation, transfer.Quantity).
+				Updates(map[string]any{"quantity": gorm.Expr("quantity - ?", transfer.Quantity)})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errInsufficientLot
+			}
+		}
+		level, err = record(tx, transfer.movement(transfer.FromLocation, -transfer.Quantity, transfer.LotID))
+		return err
+	})
+	if errors.Is(err, errInsufficientLot) {
+		r.Logger.Warn("Insufficient stock in transferred lot", zap.Int("id", id), zap.Int("lotId", lot.ID))
+		return nil, nil, domainErrors.NewAppError(
+			fmt.Errorf("insufficient stock: lot %s holds %d units at %s", lot.LotNumber, lot.Quantity, transfer.FromLocation), domainErrors.ValidationError)
+	}
+	if err != nil {
+		return nil, nil, r.transferError(ctx, err, id, transfer, -transfer.Quantity)
+	}
+	r.Logger.Info("Successfully shipped transfer", zap.Int("id", id), zap.Int("quantity", transfer.Quantity))
+	return transfer.toDomainMapper(), level.toDomainMapper(), nil
+}
+
+// ReceiveTransfer adds the units of a shipped transfer to its destination location in
+// one transaction. The units of a lot are added to the lot of the same number there,
+// which is created with the dates, supplier and quarantine of the source lot.
+func (r *Repository) ReceiveTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, *domainStock.Level, error) {
+	var transfer Transfer
+	var level StockLevel
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if transfer, err = advance(tx, id, domainStock.TransferReceived, at); err != nil {
+			return err
+		}
+		var lotID *int
+		if transfer.LotID != nil {
+			destination, err := receiveLot(tx, *transfer.LotID, transfer.ToLocation, transfer.Quantity)
+			if err != nil {
+				return err
+			}
+			lotID = &destination.ID
+		}
+		level, err = record(tx, transfer.movement(transfer.ToLocation, transfer.Quantity, lotID))
+		return err
+	})
+	if err != nil {
+		return nil, nil, r.transferError(ctx, err, id, transfer, transfer.Quantity)
+	}
+	r.Logger.Info("Successfully received transfer", zap.Int("id", id), zap.Int("quantity", transfer.Quantity))
+	return transfer.toDomainMapper(), level.toDomainMapper(), nil
+}
+
+// CancelTransfer withdraws a requested transfer
+func (r *Repository) CancelTransfer(ctx context.Context, id int, at time.Time) (*domainStock.Transfer, error) {
+	var transfer Transfer
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = advance(tx, id, domainStock.TransferCancelled, at)
+		return err
+	})
+	if err != nil {
+		return nil, r.transferError(ctx, err, id, transfer, 0)
+	}
+	r.Logger.Info("Successfully cancelled transfer", zap.Int("id", id))
+	return transfer.toDomainMapper(), nil
+}
+
+// InTransit returns the units of a medicine in shipped transfers not received yet
+func (r *Repository) InTransit(ctx context.Context, medicineID int) (int, error) {
+	var total int
+	if err := r.DB.WithContext(ctx).Model(&Transfer{}).
+		Where("medicine_id = ? AND status = ?", medicineID, string(domainStock.TransferShipped)).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total).Error; err != nil {
+		r.Logger.Error("Error getting stock in transit", zap.Error(err), zap.Int("medicineId", medicineID))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return total, nil
+}
+
+// advance moves a transfer to status within tx and returns it. The update only
+// applies to a transfer in the previous status, so of two concurrent requests for the
+// same step one fails.
+func advance(tx *gorm.DB, id int, status domainStock.TransferStatus, at time.Time) (Transfer, error) {
+	var transfer Transfer
+	result := tx.Model(&Transfer{}).
+		Where("id = ? AND status = ?", id, string(status.Previous())).
+		Updates(map[string]any{"status": string(status), transferTimestamps[status]: at})
+	if result.Error != nil {
+		return transfer, result.Error
+	}
+	if err := tx.First(&transfer, id).Error; err != nil {
+		return transfer, err
+	}
+	if result.RowsAffected == 0 {
+		return transfer, domainErrors.NewAppError(
+			fmt.Errorf("transfer %d is %s, not %s", id, transfer.Status, status.Previous()), domainErrors.ValidationError)
+	}
+	return transfer, nil
+}
+
+// receiveLot adds quantity units to the lot at location with the number of the lot
+// sourceID, creating it from the source lot when the location holds none
+func receiveLot(tx *gorm.DB, sourceID int, location string, quantity int) (MedicineLot, error) {
+	var source MedicineLot
+	if err := tx.First(&source, sourceID).Error; err != nil {
+		return source, err
+	}
+	destination := MedicineLot{
+		TenantID:         source.TenantID,
+		MedicineID:       source.MedicineID,
+		LotNumber:        source.LotNumber,
+		Location:         location,
+		ManufacturedAt:   source.ManufacturedAt,
+		ExpiresAt:        source.ExpiresAt,
+		Supplier:         source.Supplier,
+		QuarantinedAt:    source.QuarantinedAt,
+		QuarantineReason: source.QuarantineReason,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "medicine_id"}, {Name: "lot_number"}, {Name: "location"}},
+		DoNothing: true,
+	}).Create(&destination).Error; err != nil {
+		return destination, err
+	}
+	if err := tx.Where("medicine_id = ? AND lot_number = ? AND location = ?", source.MedicineID, source.LotNumber, location).
+		First(&destination).Error; err != nil {
+		return destination, err
+	}
+	err := tx.Model(&MedicineLot{}).Where("id = ?", destination.ID).
+		Updates(map[string]any{"quantity": gorm.Expr("quantity + ?", quantity)}).Error
+	return destination, err
+}
+
+// transferError translates the failure of a transfer step into the matching application error
+func (r *Repository) transferError(ctx context.Context, err error, id int, transfer Transfer, delta int) error {
+	var appErr *domainErrors.AppError
+	switch {
+	case errors.As(err, &appErr):
+		r.Logger.Warn("Transfer cannot advance", zap.Int("id", id), zap.String("status", transfer.Status))
+		return appErr
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		r.Logger.Warn("Transfer not found", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	case errors.Is(err, errInsufficient):
+		return r.recordError(ctx, err, transfer.MedicineID, transfer.FromLocation, delta)
+	}
+	r.Logger.Error("Error advancing transfer", zap.Error(err), zap.Int("id", id))
+	return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+}
+
+// movement is the ledger entry of the transfer moving delta units at location
+func (t *Transfer) movement(location string, delta int, lotID *int) StockMovement {
+	return StockMovement{
+		TenantID:   t.TenantID,
+		MedicineID: t.MedicineID,
+		Location:   location,
+		LotID:      lotID,
+		TransferID: &t.ID,
+		Type:       string(domainStock.MovementTransfer),
+		Quantity:   delta,
+		Reason:     t.Reason,
+	}
+}
+
+// Mappers
+func (t *Transfer) toDomainMapper() *domainStock.Transfer {
+	return &domainStock.Transfer{
+		ID:           t.ID,
+		TenantID:     t.TenantID,
+		MedicineID:   t.MedicineID,
+		LotID:        t.LotID,
+		FromLocation: t.FromLocation,
+		ToLocation:   t.ToLocation,
+		Quantity:     t.Quantity,
+		Status:       domainStock.TransferStatus(t.Status),
+		Reason:       t.Reason,
+		ShippedAt:    t.ShippedAt,
+		ReceivedAt:   t.ReceivedAt,
+		CancelledAt:  t.CancelledAt,
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+		CreatedBy:    t.CreatedBy,
+		UpdatedBy:    t.UpdatedBy,
+	}
+}
+
+func transferFromDomainMapper(transfer *domainStock.Transfer) *Transfer {
+	return &Transfer{
+		TenantID:     transfer.TenantID,
+		MedicineID:   transfer.MedicineID,
+		LotID:        transfer.LotID,
+		FromLocation: transfer.FromLocation,
+		ToLocation:   transfer.ToLocation,
+		Quantity:     transfer.Quantity,
+		Reason:       transfer.Reason,
+	}
+}
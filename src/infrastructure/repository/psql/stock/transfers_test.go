@@ -0,0 +1,115 @@
+package stock
+
+import (
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainStock "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertAppError(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestRepository_TransferLot(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+	lots := receiveLots(t, repository,
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-1", ExpiresAt: today.AddDate(1, 0, 0), Quantity: 10, Supplier: "Acme"})
+
+	transfer, err := repository.CreateTransfer(ctx, &domainStock.Transfer{TenantID: 1, MedicineID: 5, LotID: &lots[0].ID, FromLocation: "main", ToLocation: "front", Quantity: 4, Reason: "restock"})
+	require.NoError(t, err)
+	assert.Equal(t, domainStock.TransferRequested, transfer.Status)
+
+	shipped, level, err := repository.ShipTransfer(ctx, transfer.ID, today)
+	require.NoError(t, err)
+	assert.Equal(t, domainStock.TransferShipped, shipped.Status)
+	assert.Equal(t, today, *shipped.ShippedAt)
+	assert.Equal(t, 6, level.Quantity)
+	inTransit, err := repository.InTransit(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 4, inTransit)
+
+	_, _, err = repository.ShipTransfer(ctx, transfer.ID, today)
+	assertAppError(t, err, domainErrors.ValidationError)
+	assert.Equal(t, "transfer 1 is shipped, not requested", err.Error())
+
+	received, level, err := repository.ReceiveTransfer(ctx, transfer.ID, today)
+	require.NoError(t, err)
+	assert.Equal(t, domainStock.TransferReceived, received.Status)
+	assert.Equal(t, "front", level.Location)
+	assert.Equal(t, 4, level.Quantity)
+	inTransit, err = repository.InTransit(ctx, 5)
+	require.NoError(t, err)
+	assert.Zero(t, inTransit)
+
+	stored, err := repository.GetLotsByMedicine(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, *stored, 2, "the destination holds its own row of the lot")
+	for _, lot := range *stored {
+		assert.Equal(t, "L-1", lot.LotNumber)
+		assert.Equal(t, "Acme", lot.Supplier)
+		if lot.Location == "front" {
+			assert.Equal(t, 4, lot.Quantity)
+		} else {
+			assert.Equal(t, 6, lot.Quantity)
+		}
+	}
+
+	movements, err := repository.GetMovements(ctx, 5, domain.DataFilters{Matches: map[string][]string{"transferId": {"1"}}})
+	require.NoError(t, err)
+	require.Len(t, *movements.Data, 2)
+	assert.Equal(t, 4, (*movements.Data)[0].Quantity)
+	assert.Equal(t, -4, (*movements.Data)[1].Quantity)
+
+	dispensation, err := repository.Dispense(ctx, 5, "front", 4, "", today)
+	require.NoError(t, err)
+	assert.Equal(t, 0, dispensation.Level.Quantity)
+}
+
+func TestRepository_TransferRefusals(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := tenantContext(1, 3)
+	lots := receiveLots(t, repository,
+		domainStock.MedicineLot{TenantID: 1, MedicineID: 5, LotNumber: "L-1", ExpiresAt: today.AddDate(1, 0, 0), Quantity: 2})
+
+	short, err := repository.CreateTransfer(ctx, &domainStock.Transfer{TenantID: 1, MedicineID: 5, LotID: &lots[0].ID, FromLocation: "main", ToLocation: "front", Quantity: 3})
+	require.NoError(t, err)
+	_, _, err = repository.ShipTransfer(ctx, short.ID, today)
+	assertAppError(t, err, domainErrors.ValidationError)
+	assert.Equal(t, "insufficient stock: lot L-1 holds 2 units at main", err.Error())
+	stored, err := repository.GetTransferByID(ctx, short.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domainStock.TransferRequested, stored.Status, "a refused shipment leaves the transfer requested")
+
+	unbacked, err := repository.CreateTransfer(ctx, &domainStock.Transfer{TenantID: 1, MedicineID: 5, FromLocation: "front", ToLocation: "main", Quantity: 1})
+	require.NoError(t, err)
+	_, _, err = repository.ShipTransfer(ctx, unbacked.ID, today)
+	assertAppError(t, err, domainErrors.ValidationError)
+
+	cancelled, err := repository.CancelTransfer(ctx, short.ID, today)
+	require.NoError(t, err)
+	assert.Equal(t, domainStock.TransferCancelled, cancelled.Status)
+	_, _, err = repository.ReceiveTransfer(ctx, short.ID, today)
+	assertAppError(t, err, domainErrors.ValidationError)
+	_, err = repository.CancelTransfer(ctx, 99, today)
+	assertAppError(t, err, domainErrors.NotFound)
+	_, err = repository.GetTransferByID(tenantContext(2, 5), short.ID)
+	assertAppError(t, err, domainErrors.NotFound)
+
+	result, err := repository.GetTransfers(ctx, domain.DataFilters{Matches: map[string][]string{"status": {"requested"}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	assert.Equal(t, unbacked.ID, (*result.Data)[0].ID)
+
+	levels, err := repository.GetByMedicine(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, *levels, 1)
+	assert.Equal(t, 2, (*levels)[0].Quantity)
+}
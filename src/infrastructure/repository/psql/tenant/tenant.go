@@ -0,0 +1,78 @@
+package tenant
+
+import (
+	"slices"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	tenantField = "TenantID"
+	scopedKey   = "tenant:scoped"
+)
+
+// RegisterCallbacks makes GORM isolate every model declaring a TenantID field by the
+// tenant of the principal found in the statement context: inserts are stamped with it
+// and every other statement is filtered by it. Statements without a tenant in their
+// context, such as migrations and background workers, are left untouched.
+func RegisterCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:assign", assignTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	return callbacks.Row().Before("gorm:row").Register("tenant:scope", scopeTenant)
+}
+
+func assignTenant(db *gorm.DB) {
+	field, tenantID, ok := lookUp(db)
+	if !ok {
+		return
+	}
+	db.Statement.SetColumn(field.DBName, tenantID, true)
+	// Explicit Select clauses would otherwise drop the tenant column from the statement
+	if len(db.Statement.Selects) > 0 && !slices.Contains(db.Statement.Selects, "*") {
+		db.Statement.Selects = append(db.Statement.Selects, field.DBName)
+	}
+}
+
+func scopeTenant(db *gorm.DB) {
+	field, tenantID, ok := lookUp(db)
+	if !ok {
+		return
+	}
+	// Count and Find may run on the same statement; the condition must only be added once
+	if _, scoped := db.InstanceGet(scopedKey); scoped {
+		return
+	}
+	db.InstanceSet(scopedKey, true)
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+func lookUp(db *gorm.DB) (*schema.Field, int, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, 0, false
+	}
+	tenantID, ok := security.TenantID(db.Statement.Context)
+	if !ok {
+		return nil, 0, false
+	}
+	field := db.Statement.Schema.LookUpField(tenantField)
+	if field == nil {
+		return nil, 0, false
+	}
+	return field, tenantID, true
+}
@@ -0,0 +1,120 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type scopedModel struct {
+	ID       int `gorm:"primaryKey"`
+	TenantID int
+	Name     string
+}
+
+func (scopedModel) TableName() string {
+	return "scoped"
+}
+
+type globalModel struct {
+	ID   int `gorm:"primaryKey"`
+	Name string
+}
+
+func (globalModel) TableName() string {
+	return "global"
+}
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, RegisterCallbacks(gormDB))
+	return gormDB, mock
+}
+
+func tenantContext(tenantID int) context.Context {
+	return security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: tenantID})
+}
+
+func TestCreateAssignsTenant(t *testing.T) {
+	db, mock := setupMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "scoped" ("tenant_id","name") VALUES ($1,$2) RETURNING "id"`)).
+		WithArgs(3, "Aspirin").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// A tenant supplied by the caller cannot escape the principal's organization
+	model := scopedModel{TenantID: 8, Name: "Aspirin"}
+	require.NoError(t, db.WithContext(tenantContext(3)).Create(&model).Error)
+	assert.Equal(t, 3, model.TenantID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryIsScoped(t *testing.T) {
+	db, mock := setupMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "scoped" WHERE name LIKE $1 AND "scoped"."tenant_id" = $2`)).
+		WithArgs("%a%", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "scoped" WHERE name LIKE $1 AND "scoped"."tenant_id" = $2 LIMIT $3`)).
+		WithArgs("%a%", 3, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name"}).AddRow(1, 3, "Aspirin"))
+
+	// Count and Find share the statement, as in the repositories' pagination
+	query := db.WithContext(tenantContext(3)).Model(&scopedModel{}).Where("name LIKE ?", "%a%")
+	var total int64
+	require.NoError(t, query.Count(&total).Error)
+	var models []scopedModel
+	require.NoError(t, query.Limit(10).Find(&models).Error)
+	assert.Len(t, models, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateAndDeleteAreScoped(t *testing.T) {
+	db, mock := setupMockDB(t)
+	ctx := tenantContext(3)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "scoped" SET "name"=$1 WHERE "scoped"."tenant_id" = $2 AND "id" = $3`)).
+		WithArgs("Ibuprofen", 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "scoped" WHERE "scoped"."id" = $1 AND "scoped"."tenant_id" = $2`)).
+		WithArgs(1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, db.WithContext(ctx).Model(&scopedModel{ID: 1}).Update("name", "Ibuprofen").Error)
+	require.NoError(t, db.WithContext(ctx).Delete(&scopedModel{}, 1).Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnscopedStatements(t *testing.T) {
+	db, mock := setupMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "scoped"`)).
+		WithArgs().
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "global"`)).
+		WithArgs().
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	var scoped []scopedModel
+	require.NoError(t, db.WithContext(context.Background()).Find(&scoped).Error)
+	var global []globalModel
+	require.NoError(t, db.WithContext(tenantContext(3)).Find(&global).Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,223 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/encryption"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/history"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/outbox"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// UserHistory stores every recorded change of a user
+type UserHistory struct {
+	history.Entry
+}
+
+func (*UserHistory) TableName() string {
+	return "user_history"
+}
+
+// userSnapshot is the serialized state of a user kept in its history.
+// The password hash is deliberately left out.
+type userSnapshot struct {
+	ID        int        `json:"id"`
+	TenantID  int        `json:"tenantId"`
+	UserName  string     `json:"userName"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Status    bool       `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt"`
+	CreatedBy *int       `json:"createdBy"`
+	UpdatedBy *int       `json:"updatedBy"`
+}
+
+func (u *User) toSnapshot() *userSnapshot {
+	return &userSnapshot{
+		ID:        u.ID,
+		TenantID:  u.TenantID,
+		UserName:  u.UserName,
+		Email:     u.Email,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Status:    u.Status,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+		DeletedAt: deletedAtToDomain(u.DeletedAt),
+		CreatedBy: u.CreatedBy,
+		UpdatedBy: u.UpdatedBy,
+	}
+}
+
+func (s *userSnapshot) toDomainMapper() *domainUser.User {
+	return &domainUser.User{
+		ID:        s.ID,
+		TenantID:  s.TenantID,
+		UserName:  s.UserName,
+		Email:     s.Email,
+		FirstName: s.FirstName,
+		LastName:  s.LastName,
+		Status:    s.Status,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+		DeletedAt: s.DeletedAt,
+		CreatedBy: s.CreatedBy,
+		UpdatedBy: s.UpdatedBy,
+	}
+}
+
+// piiFields are the snapshot fields holding the encrypted user columns
+var piiFields = []string{"email", "firstName", "lastName"}
+
+// transform returns a copy of the snapshot with fn applied to its personal data
+func (s *userSnapshot) transform(fn func(string) (string, error)) (*userSnapshot, error) {
+	transformed := *s
+	for _, value := range []*string{&transformed.Email, &transformed.FirstName, &transformed.LastName} {
+		if *value == "" {
+			continue
+		}
+		result, err := fn(*value)
+		if err != nil {
+			return nil, err
+		}
+		*value = result
+	}
+	return &transformed, nil
+}
+
+// transformChanges applies fn to the recorded values of the personal data fields
+func transformChanges(changes map[string]domainHistory.FieldChange, fn func(string) (string, error)) error {
+	for _, field := range piiFields {
+		change, found := changes[field]
+		if !found {
+			continue
+		}
+		var err error
+		if change.Before, err = transformValue(change.Before, fn); err != nil {
+			return err
+		}
+		if change.After, err = transformValue(change.After, fn); err != nil {
+			return err
+		}
+		changes[field] = change
+	}
+	return nil
+}
+
+func transformValue(value any, fn func(string) (string, error)) (any, error) {
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return value, nil
+	}
+	return fn(text)
+}
+
+// recordHistory stores the change between before and after, together with the event
+// announcing it; updates without changes are skipped
+func recordHistory(ctx context.Context, tx *gorm.DB, action domainHistory.Action, before, after *User) error {
+	var beforeSnapshot, afterSnapshot *userSnapshot
+	entityID := 0
+	if before != nil {
+		beforeSnapshot = before.toSnapshot()
+		entityID = before.ID
+	}
+	if after != nil {
+		afterSnapshot = after.toSnapshot()
+		entityID = after.ID
+	}
+	changes, err := history.Diff(beforeSnapshot, afterSnapshot)
+	if err != nil {
+		return err
+	}
+	if action == domainHistory.ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+	snapshot := afterSnapshot
+	if snapshot == nil {
+		snapshot = beforeSnapshot
+	}
+	// The history must not leak the personal data encrypted in the users table
+	cipher := encryption.CipherOf(tx)
+	if err := transformChanges(changes, cipher.Encrypt); err != nil {
+		return err
+	}
+	if snapshot, err = snapshot.transform(cipher.Encrypt); err != nil {
+		return err
+	}
+	entry, err := history.NewEntry(ctx, entityID, action, changes, snapshot)
+	if err != nil {
+		return err
+	}
+	entry.TenantID = snapshot.TenantID
+	row := &UserHistory{Entry: *entry}
+	if err := tx.Create(row).Error; err != nil {
+		return err
+	}
+	return outbox.Enqueue(tx, row.ToEvent(event.AggregateUser))
+}
+
+// GetHistory returns every recorded change of a user, oldest first
+func (r *Repository) GetHistory(ctx context.Context, id int) (*[]domainHistory.Entry, error) {
+	var rows []UserHistory
+	if err := r.DB.WithContext(ctx).Where("entity_id = ?", id).Order("created_at, id").Find(&rows).Error; err != nil {
+		r.Logger.Error("Error getting user history", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if len(rows) == 0 {
+		r.Logger.Warn("User history not found", zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	cipher := encryption.CipherOf(r.DB)
+	entries := make([]domainHistory.Entry, len(rows))
+	for i, row := range rows {
+		entry, err := row.ToDomain()
+		if err == nil {
+			err = transformChanges(entry.Changes, cipher.Decrypt)
+		}
+		if err != nil {
+			r.Logger.Error("Error decoding user history", zap.Error(err), zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		entries[i] = *entry
+	}
+	r.Logger.Info("Successfully retrieved user history", zap.Int("id", id), zap.Int("count", len(entries)))
+	return &entries, nil
+}
+
+// GetAsOf rebuilds a user as it was at the given time from its history
+func (r *Repository) GetAsOf(ctx context.Context, id int, at time.Time) (*domainUser.User, error) {
+	var row UserHistory
+	err := r.DB.WithContext(ctx).
+		Where("entity_id = ? AND created_at <= ?", id, at).
+		Order("created_at DESC, id DESC").
+		First(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("User not found at requested time", zap.Int("id", id), zap.Time("asOf", at))
+			return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting user as of time", zap.Error(err), zap.Int("id", id))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	var encoded userSnapshot
+	err = row.DecodeSnapshot(&encoded)
+	var snapshot *userSnapshot
+	if err == nil {
+		snapshot, err = encoded.transform(encryption.CipherOf(r.DB).Decrypt)
+	}
+	if err != nil {
+		r.Logger.Error("Error decoding user snapshot", zap.Error(err), zap.Int("id", id))
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved user as of time", zap.Int("id", id), zap.Time("asOf", at))
+	return snapshot.toDomainMapper(), nil
+}
@@ -0,0 +1,102 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/encryption"
+	"gorm.io/gorm"
+)
+
+// Reencrypt brings the personal data of every user and of their history up to date
+// with the configured keys, as encryption.Reencrypt does for a single table. It returns
+// the number of updated user and history rows.
+func Reencrypt(ctx context.Context, db *gorm.DB, batchSize int) (int64, error) {
+	updated, err := encryption.Reencrypt(ctx, db, &User{}, batchSize)
+	if err != nil {
+		return updated, err
+	}
+	if batchSize <= 0 {
+		batchSize = encryption.DefaultBatchSize
+	}
+	rotate := func(value string) (string, error) {
+		rotated, _, err := encryption.CipherOf(db).Rotate(value)
+		return rotated, err
+	}
+
+	lastID := 0
+	for {
+		var rows []UserHistory
+		err := db.WithContext(ctx).Where("id > ?", lastID).Order("id").Limit(batchSize).Find(&rows).Error
+		if err != nil {
+			return updated, err
+		}
+		for _, row := range rows {
+			changes, snapshot, err := rotateHistory(&row, rotate)
+			if err != nil {
+				return updated, err
+			}
+			if changes == row.Changes && snapshot == row.Snapshot {
+				continue
+			}
+			err = db.WithContext(ctx).Model(&UserHistory{}).Where("id = ?", row.ID).
+				UpdateColumns(map[string]any{"changes": changes, "snapshot": snapshot}).Error
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		if len(rows) < batchSize {
+			return updated, nil
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// rotateHistory returns the stored changes and snapshot of a history row with their
+// personal data rotated, re-encoding them only when a value changed
+func rotateHistory(row *UserHistory, rotate func(string) (string, error)) (string, string, error) {
+	changes, snapshot := row.Changes, row.Snapshot
+
+	var encodedChanges map[string]map[string]any
+	if err := json.Unmarshal([]byte(row.Changes), &encodedChanges); err != nil {
+		return "", "", err
+	}
+	changed := false
+	for _, field := range piiFields {
+		for side, value := range encodedChanges[field] {
+			rotated, err := transformValue(value, rotate)
+			if err != nil {
+				return "", "", err
+			}
+			if rotated != value {
+				encodedChanges[field][side] = rotated
+				changed = true
+			}
+		}
+	}
+	if changed {
+		data, err := json.Marshal(encodedChanges)
+		if err != nil {
+			return "", "", err
+		}
+		changes = string(data)
+	}
+
+	var encodedSnapshot userSnapshot
+	if err := row.DecodeSnapshot(&encodedSnapshot); err != nil {
+		return "", "", err
+	}
+	rotatedSnapshot, err := encodedSnapshot.transform(rotate)
+	if err != nil {
+		return "", "", err
+	}
+	if *rotatedSnapshot != encodedSnapshot {
+		data, err := json.Marshal(rotatedSnapshot)
+		if err != nil {
+			return "", "", err
+		}
+		snapshot = string(data)
+	}
+	return changes, snapshot, nil
+}
@@ -1,27 +1,41 @@
 package user
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/encryption"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/replica"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// Email, first and last names are encrypted when PII master keys are configured; their
+// blind index columns hold keyed hashes that keep exact-match lookups working
 type User struct {
-	ID           int       `gorm:"primaryKey"`
-	UserName     string    `gorm:"column:user_name;unique"`
-	Email        string    `gorm:"unique"`
-	FirstName    string    `gorm:"column:first_name"`
-	LastName     string    `gorm:"column:last_name"`
-	Status       bool      `gorm:"column:status"`
-	HashPassword string    `gorm:"column:hash_password"`
-	CreatedAt    time.Time `gorm:"autoCreateTime:mili"`
-	UpdatedAt    time.Time `gorm:"autoUpdateTime:mili"`
+	ID             int            `gorm:"primaryKey"`
+	TenantID       int            `gorm:"column:tenant_id;index"`
+	UserName       string         `gorm:"column:user_name;uniqueIndex:idx_users_user_name,where:deleted_at IS NULL"`
+	Email          string         `gorm:"uniqueIndex:idx_users_email,where:deleted_at IS NULL;encrypted:email_index"`
+	EmailIndex     *string        `gorm:"column:email_index;uniqueIndex:idx_users_email_index,where:deleted_at IS NULL"`
+	FirstName      string         `gorm:"column:first_name;encrypted:first_name_index"`
+	FirstNameIndex *string        `gorm:"column:first_name_index;index"`
+	LastName       string         `gorm:"column:last_name;encrypted:last_name_index"`
+	LastNameIndex  *string        `gorm:"column:last_name_index;index"`
+	Status         bool           `gorm:"column:status"`
+	HashPassword   string         `gorm:"column:hash_password"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime:mili"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime:mili"`
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	CreatedBy      *int           `gorm:"column:created_by;index"`
+	UpdatedBy      *int           `gorm:"column:updated_by;index"`
 }
 
 func (User) TableName() string {
@@ -30,6 +44,7 @@ func (User) TableName() string {
 
 var ColumnsUserMapping = map[string]string{
 	"id":           "id",
+	"tenantId":     "tenant_id",
 	"userName":     "user_name",
 	"email":        "email",
 	"firstName":    "first_name",
@@ -38,32 +53,55 @@ var ColumnsUserMapping = map[string]string{
 	"hashPassword": "hash_password",
 	"createdAt":    "created_at",
 	"updatedAt":    "updated_at",
+	"deletedAt":    "deleted_at",
+	"createdBy":    "created_by",
+	"updatedBy":    "updated_by",
 }
 
 // UserRepositoryInterface defines the interface for user repository operations
 type UserRepositoryInterface interface {
-	GetAll() (*[]domainUser.User, error)
-	Create(userDomain *domainUser.User) (*domainUser.User, error)
-	GetByID(id int) (*domainUser.User, error)
-	GetByEmail(email string) (*domainUser.User, error)
-	Update(id int, userMap map[string]interface{}) (*domainUser.User, error)
-	Delete(id int) error
-	SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error)
-	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetAll(ctx context.Context) (*[]domainUser.User, error)
+	Create(ctx context.Context, userDomain *domainUser.User) (*domainUser.User, error)
+	GetByID(ctx context.Context, id int) (*domainUser.User, error)
+	GetByEmail(ctx context.Context, email string) (*domainUser.User, error)
+	Update(ctx context.Context, id int, userMap map[string]interface{}) (*domainUser.User, error)
+	Delete(ctx context.Context, id int) error
+	SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error)
+	SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error)
+	GetTrash(ctx context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error)
+	Restore(ctx context.Context, id int) (*domainUser.User, error)
+	Purge(ctx context.Context, olderThan time.Time) (int64, error)
+	GetHistory(ctx context.Context, id int) (*[]domainHistory.Entry, error)
+	GetAsOf(ctx context.Context, id int, at time.Time) (*domainUser.User, error)
 }
 
 type Repository struct {
-	DB     *gorm.DB
-	Logger *logger.Logger
+	DB       *gorm.DB
+	Replicas *replica.Set
+	Logger   *logger.Logger
 }
 
 func NewUserRepository(db *gorm.DB, loggerInstance *logger.Logger) UserRepositoryInterface {
 	return &Repository{DB: db, Logger: loggerInstance}
 }
 
-func (r *Repository) GetAll() (*[]domainUser.User, error) {
+// NewUserRepositoryWithReplicas creates a repository whose listing queries are served by replicas
+func NewUserRepositoryWithReplicas(db *gorm.DB, replicas *replica.Set, loggerInstance *logger.Logger) UserRepositoryInterface {
+	return &Repository{DB: db, Replicas: replicas, Logger: loggerInstance}
+}
+
+// readDB returns the connection for listing queries: a healthy replica when configured
+// and ctx does not ask for the primary, else the primary
+func (r *Repository) readDB(ctx context.Context) *gorm.DB {
+	if r.Replicas == nil || replica.PrimaryRequested(ctx) {
+		return r.DB.WithContext(ctx)
+	}
+	return r.Replicas.Reader().WithContext(ctx)
+}
+
+func (r *Repository) GetAll(ctx context.Context) (*[]domainUser.User, error) {
 	var users []User
-	if err := r.DB.Find(&users).Error; err != nil {
+	if err := r.readDB(ctx).Find(&users).Error; err != nil {
 		r.Logger.Error("Error getting all users", zap.Error(err))
 		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
@@ -71,34 +109,26 @@ func (r *Repository) GetAll() (*[]domainUser.User, error) {
 	return arrayToDomainMapper(&users), nil
 }
 
-func (r *Repository) Create(userDomain *domainUser.User) (*domainUser.User, error) {
+func (r *Repository) Create(ctx context.Context, userDomain *domainUser.User) (*domainUser.User, error) {
 	r.Logger.Info("Creating new user", zap.String("email", userDomain.Email))
 	userRepository := fromDomainMapper(userDomain)
-	txDb := r.DB.Create(userRepository)
-	err := txDb.Error
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(userRepository).Error; err != nil {
+			return err
+		}
+		return recordHistory(ctx, tx, domainHistory.ActionCreate, nil, userRepository)
+	})
 	if err != nil {
 		r.Logger.Error("Error creating user", zap.Error(err), zap.String("email", userDomain.Email))
-		byteErr, _ := json.Marshal(err)
-		var newError domainErrors.GormErr
-		errUnmarshal := json.Unmarshal(byteErr, &newError)
-		if errUnmarshal != nil {
-			return &domainUser.User{}, errUnmarshal
-		}
-		switch newError.Number {
-		case 1062:
-			err = domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
-			return &domainUser.User{}, err
-		default:
-			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
-		}
+		return &domainUser.User{}, writeError(err)
 	}
 	r.Logger.Info("Successfully created user", zap.String("email", userDomain.Email), zap.Int("id", userRepository.ID))
-	return userRepository.toDomainMapper(), err
+	return userRepository.toDomainMapper(), nil
 }
 
-func (r *Repository) GetByID(id int) (*domainUser.User, error) {
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainUser.User, error) {
 	var user User
-	err := r.DB.Where("id = ?", id).First(&user).Error
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&user).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.Logger.Warn("User not found", zap.Int("id", id))
@@ -113,9 +143,9 @@ func (r *Repository) GetByID(id int) (*domainUser.User, error) {
 	return user.toDomainMapper(), nil
 }
 
-func (r *Repository) GetByEmail(email string) (*domainUser.User, error) {
+func (r *Repository) GetByEmail(ctx context.Context, email string) (*domainUser.User, error) {
 	var user User
-	err := r.DB.Where("email = ?", email).First(&user).Error
+	err := r.DB.WithContext(ctx).Where(encryption.Match(r.DB, &User{}, "email", []string{email})).First(&user).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.Logger.Warn("User not found", zap.String("email", email))
@@ -130,9 +160,8 @@ func (r *Repository) GetByEmail(email string) (*domainUser.User, error) {
 	return user.toDomainMapper(), nil
 }
 
-func (r *Repository) Update(id int, userMap map[string]interface{}) (*domainUser.User, error) {
-	var userObj User
-	userObj.ID = id
+func (r *Repository) Update(ctx context.Context, id int, userMap map[string]interface{}) (*domainUser.User, error) {
+	var before, userObj User
 
 	// Map JSON field names to DB column names
 	updateData := make(map[string]interface{})
@@ -144,49 +173,127 @@ func (r *Repository) Update(id int, userMap map[string]interface{}) (*domainUser
 		}
 	}
 
-	err := r.DB.Model(&userObj).
-		Select("user_name", "email", "first_name", "last_name", "status", "role").
-		Updates(updateData).Error
-	if err != nil {
-		r.Logger.Error("Error updating user", zap.Error(err), zap.Int("id", id))
-		byteErr, _ := json.Marshal(err)
-		var newError domainErrors.GormErr
-		errUnmarshal := json.Unmarshal(byteErr, &newError)
-		if errUnmarshal != nil {
-			return &domainUser.User{}, errUnmarshal
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&before).Error; err != nil {
+			return err
 		}
-		switch newError.Number {
-		case 1062:
-			return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
-		default:
-			return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		userObj.ID = id
+		if err := tx.Model(&userObj).
+			Select("user_name", "email", "first_name", "last_name", "status", "role").
+			Updates(updateData).Error; err != nil {
+			return err
 		}
-	}
-	if err := r.DB.Where("id = ?", id).First(&userObj).Error; err != nil {
-		r.Logger.Error("Error retrieving updated user", zap.Error(err), zap.Int("id", id))
-		return &domainUser.User{}, err
+		if err := tx.Where("id = ?", id).First(&userObj).Error; err != nil {
+			return err
+		}
+		return recordHistory(ctx, tx, domainHistory.ActionUpdate, &before, &userObj)
+	})
+	if err != nil {
+		r.Logger.Error("Error updating user", zap.Error(err), zap.Int("id", id))
+		return &domainUser.User{}, writeError(err)
 	}
 	r.Logger.Info("Successfully updated user", zap.Int("id", id))
 	return userObj.toDomainMapper(), nil
 }
 
-func (r *Repository) Delete(id int) error {
-	tx := r.DB.Delete(&User{}, id)
-	if tx.Error != nil {
-		r.Logger.Error("Error deleting user", zap.Error(tx.Error), zap.Int("id", id))
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before, after User
+		if err := tx.Where("id = ?", id).First(&before).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&User{}, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id = ?", id).First(&after).Error; err != nil {
+			return err
+		}
+		return recordHistory(ctx, tx, domainHistory.ActionDelete, &before, &after)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("User not found for deletion", zap.Int("id", id))
+			return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error deleting user", zap.Error(err), zap.Int("id", id))
 		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
-	if tx.RowsAffected == 0 {
-		r.Logger.Warn("User not found for deletion", zap.Int("id", id))
-		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
-	}
 	r.Logger.Info("Successfully deleted user", zap.Int("id", id))
 	return nil
 }
 
-func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
-	query := r.DB.Model(&User{})
+// Restore clears the deletion mark of a soft-deleted user
+func (r *Repository) Restore(ctx context.Context, id int) (*domainUser.User, error) {
+	var before, after User
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&before).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&User{}).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", id).First(&after).Error; err != nil {
+			return err
+		}
+		return recordHistory(ctx, tx, domainHistory.ActionRestore, &before, &after)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Deleted user not found for restore", zap.Int("id", id))
+		} else {
+			r.Logger.Error("Error restoring user", zap.Error(err), zap.Int("id", id))
+		}
+		return &domainUser.User{}, writeError(err)
+	}
+	r.Logger.Info("Successfully restored user", zap.Int("id", id))
+	return after.toDomainMapper(), nil
+}
+
+// Purge permanently removes users soft-deleted before olderThan
+func (r *Repository) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	tx := r.DB.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", olderThan).
+		Delete(&User{})
+	if tx.Error != nil {
+		r.Logger.Error("Error purging deleted users", zap.Error(tx.Error), zap.Time("olderThan", olderThan))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully purged deleted users", zap.Int64("count", tx.RowsAffected))
+	return tx.RowsAffected, nil
+}
 
+func (r *Repository) SearchPaginated(ctx context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	result, err := r.paginate(r.readDB(ctx).Model(&User{}), filters)
+	if err != nil {
+		r.Logger.Error("Error searching users", zap.Error(err))
+		return nil, searchError(err)
+	}
+
+	r.Logger.Info("Successfully searched users",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page),
+		zap.Int("pageSize", result.PageSize))
+
+	return result, nil
+}
+
+// GetTrash lists soft-deleted users using the same filters as SearchPaginated
+func (r *Repository) GetTrash(ctx context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	query := r.DB.WithContext(ctx).Unscoped().Model(&User{}).Where("deleted_at IS NOT NULL")
+	result, err := r.paginate(query, filters)
+	if err != nil {
+		r.Logger.Error("Error listing deleted users", zap.Error(err))
+		return nil, searchError(err)
+	}
+
+	r.Logger.Info("Successfully listed deleted users",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+
+	return result, nil
+}
+
+func (r *Repository) paginate(query *gorm.DB, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
 	// Apply like filters
 	for field, values := range filters.LikeFilters {
 		if len(values) > 0 {
@@ -194,7 +301,10 @@ func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainUser.Se
 				if value != "" {
 					column := ColumnsUserMapping[field]
 					if column != "" {
-						query = query.Where(column+" ILIKE ?", "%"+value+"%")
+						if encryption.Encrypted(query, &User{}, column) {
+							return nil, errEncryptedColumn
+						}
+						query = query.Where(column+" "+likeOperator(query)+" ?", "%"+value+"%")
 					}
 				}
 			}
@@ -206,7 +316,7 @@ func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainUser.Se
 		if len(values) > 0 {
 			column := ColumnsUserMapping[field]
 			if column != "" {
-				query = query.Where(column+" IN ?", values)
+				query = query.Where(encryption.Match(query, &User{}, column, values))
 			}
 		}
 	}
@@ -229,6 +339,9 @@ func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainUser.Se
 		for _, sortField := range filters.SortBy {
 			column := ColumnsUserMapping[sortField]
 			if column != "" {
+				if encryption.Encrypted(query, &User{}, column) {
+					return nil, errEncryptedColumn
+				}
 				query = query.Order(column + " " + string(filters.SortDirection))
 			}
 		}
@@ -250,39 +363,32 @@ func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainUser.Se
 
 	var users []User
 	if err := query.Offset(offset).Limit(filters.PageSize).Find(&users).Error; err != nil {
-		r.Logger.Error("Error searching users", zap.Error(err))
-		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		return nil, err
 	}
 
 	totalPages := int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize))
 
-	result := &domainUser.SearchResultUser{
+	return &domainUser.SearchResultUser{
 		Data:       arrayToDomainMapper(&users),
 		Total:      total,
 		Page:       filters.Page,
 		PageSize:   filters.PageSize,
 		TotalPages: totalPages,
-	}
-
-	r.Logger.Info("Successfully searched users",
-		zap.Int64("total", total),
-		zap.Int("page", filters.Page),
-		zap.Int("pageSize", filters.PageSize))
-
-	return result, nil
+	}, nil
 }
 
-func (r *Repository) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (r *Repository) SearchByProperty(ctx context.Context, property string, searchText string) (*[]string, error) {
 	column := ColumnsUserMapping[property]
-	if column == "" {
+	if column == "" || encryption.Encrypted(r.DB, &User{}, column) {
 		r.Logger.Warn("Invalid property for search", zap.String("property", property))
 		return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
 	}
 
 	var coincidences []string
-	if err := r.DB.Model(&User{}).
+	query := r.readDB(ctx)
+	if err := query.Model(&User{}).
 		Distinct(column).
-		Where(column+" ILIKE ?", "%"+searchText+"%").
+		Where(column+" "+likeOperator(query)+" ?", "%"+searchText+"%").
 		Limit(20).
 		Pluck(column, &coincidences).Error; err != nil {
 		r.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
@@ -300,6 +406,7 @@ func (r *Repository) SearchByProperty(property string, searchText string) (*[]st
 func (u *User) toDomainMapper() *domainUser.User {
 	return &domainUser.User{
 		ID:           u.ID,
+		TenantID:     u.TenantID,
 		UserName:     u.UserName,
 		Email:        u.Email,
 		FirstName:    u.FirstName,
@@ -308,12 +415,62 @@ func (u *User) toDomainMapper() *domainUser.User {
 		HashPassword: u.HashPassword,
 		CreatedAt:    u.CreatedAt,
 		UpdatedAt:    u.UpdatedAt,
+		DeletedAt:    deletedAtToDomain(u.DeletedAt),
+		CreatedBy:    u.CreatedBy,
+		UpdatedBy:    u.UpdatedBy,
+	}
+}
+
+// writeError translates a failed write into the matching application error
+func writeError(err error) error {
+	if err == gorm.ErrRecordNotFound {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	byteErr, _ := json.Marshal(err)
+	var newError domainErrors.GormErr
+	if errUnmarshal := json.Unmarshal(byteErr, &newError); errUnmarshal != nil {
+		return errUnmarshal
+	}
+	switch newError.Number {
+	case 1062:
+		return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	default:
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+}
+
+// errEncryptedColumn rejects partial matches and sorting on encrypted columns, whose
+// stored values are ciphertext; only exact matches are possible through blind indexes
+var errEncryptedColumn = errors.New("encrypted fields only support exact matches")
+
+// searchError reports searches on encrypted columns as validation errors and hides the rest
+func searchError(err error) error {
+	if errors.Is(err, errEncryptedColumn) {
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+}
+
+// likeOperator returns the case-insensitive LIKE operator of the database behind db.
+// SQLite has no ILIKE, but its LIKE already ignores case for ASCII text.
+func likeOperator(db *gorm.DB) string {
+	if db.Dialector.Name() == "sqlite" {
+		return "LIKE"
+	}
+	return "ILIKE"
+}
+
+func deletedAtToDomain(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
 	}
+	return &deletedAt.Time
 }
 
 func fromDomainMapper(u *domainUser.User) *User {
 	return &User{
 		ID:           u.ID,
+		TenantID:     u.TenantID,
 		UserName:     u.UserName,
 		Email:        u.Email,
 		FirstName:    u.FirstName,
@@ -1,13 +1,19 @@
 package user
 
 import (
+	"context"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/history"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
@@ -92,7 +98,7 @@ func TestRepository_GetAll(t *testing.T) {
 		AddRow(1, "user1", "a@a.com", "A", "B", true, "hash1").
 		AddRow(2, "user2", "b@b.com", "C", "D", false, "hash2")
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).WillReturnRows(rows)
-	users, err := repo.GetAll()
+	users, err := repo.GetAll(context.Background())
 	assert.NoError(t, err)
 	assert.NotNil(t, users)
 	assert.Len(t, *users, 2)
@@ -105,16 +111,16 @@ func TestRepository_GetByID(t *testing.T) {
 	repo := NewUserRepository(db, logger)
 	rows := sqlmock.NewRows([]string{"id", "user_name", "email", "first_name", "last_name", "status", "hash_password"}).
 		AddRow(1, "user1", "a@a.com", "A", "B", true, "hash1")
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 ORDER BY "users"."id" LIMIT $2`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
 		WithArgs(1, 1).WillReturnRows(rows)
-	user, err := repo.GetByID(1)
+	user, err := repo.GetByID(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, "user1", user.UserName)
 	// Not found
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 ORDER BY "users"."id" LIMIT $2`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
 		WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "user_name", "email", "first_name", "last_name", "status", "hash_password"}))
-	user, err = repo.GetByID(2)
+	user, err = repo.GetByID(context.Background(), 2)
 	assert.Error(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, 0, user.ID) // Should be zero value
@@ -136,8 +142,15 @@ func TestRepository_Create(t *testing.T) {
 	mock.ExpectBegin()
 	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user_history"`)).
+		WithArgs(0, 1, "create", sqlmock.AnyArg(), sqlmock.AnyArg(), 3, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+		WithArgs(sqlmock.AnyArg(), 0, "user", 1, "user.created", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
-	user, err := repo.Create(domainU)
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: 3})
+	user, err := repo.Create(ctx, domainU)
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, "user1", user.UserName)
@@ -149,17 +162,56 @@ func TestRepository_Delete(t *testing.T) {
 	logger := setupLogger(t)
 	repo := NewUserRepository(db, logger)
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE "users"."id" = $1`)).
-		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL`)).
+		WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "user_name"}).AddRow(1, "user1"))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1 WHERE "users"."id" = $2 AND "users"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 ORDER BY`)).
+		WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "user_name", "deleted_at"}).AddRow(1, "user1", time.Now()))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user_history"`)).
+		WithArgs(0, 1, "delete", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+		WithArgs(sqlmock.AnyArg(), 0, "user", 1, "user.deleted", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
 	mock.ExpectCommit()
-	err := repo.Delete(1)
+	err := repo.Delete(context.Background(), 1)
 	assert.NoError(t, err)
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE "users"."id" = $1`)).
-		WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL`)).
+		WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	err = repo.Delete(context.Background(), 2)
+	var appErr *domainErrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Update(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewUserRepository(db, logger)
+	columns := []string{"id", "user_name", "email", "first_name", "last_name", "status", "hash_password"}
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL`)).
+		WithArgs(1, 1).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "user1", "a@a.com", "A", "B", true, "hash1"))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL`)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "user1", "a@a.com", "Alice", "B", true, "hash2"))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user_history"`)).
+		WithArgs(0, 1, "update", `{"firstName":{"before":"A","after":"Alice"}}`, sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+		WithArgs(sqlmock.AnyArg(), 0, "user", 1, "user.updated", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
 	mock.ExpectCommit()
-	err = repo.Delete(2)
-	assert.Error(t, err)
+	user, err := repo.Update(context.Background(), 1, map[string]interface{}{"firstName": "Alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", user.FirstName)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestRepository_GetByEmail(t *testing.T) {
@@ -171,26 +223,153 @@ func TestRepository_GetByEmail(t *testing.T) {
 	email := "test@example.com"
 	rows := sqlmock.NewRows([]string{"id", "user_name", "email", "first_name", "last_name", "status", "hash_password"}).
 		AddRow(1, "user1", email, "A", "B", true, "hash1")
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE email = $1 ORDER BY "users"."id" LIMIT $2`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "email" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
 		WithArgs(email, 1).WillReturnRows(rows)
-	user, err := repo.GetByEmail(email)
+	user, err := repo.GetByEmail(context.Background(), email)
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, email, user.Email)
 
 	// Not found
 	emailNotFound := "notfound@example.com"
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE email = $1 ORDER BY "users"."id" LIMIT $2`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "email" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
 		WithArgs(emailNotFound, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "user_name", "email", "first_name", "last_name", "status", "hash_password"}))
-	user, err = repo.GetByEmail(emailNotFound)
+	user, err = repo.GetByEmail(context.Background(), emailNotFound)
 	assert.Error(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, 0, user.ID) // Should be zero value
 }
 
+func TestRepository_GetTrash(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewUserRepository(db, logger)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE deleted_at IS NOT NULL`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"id", "user_name", "email", "deleted_at"}).
+		AddRow(1, "user1", "user1@example.com", time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE deleted_at IS NOT NULL`)).
+		WillReturnRows(rows)
+	result, err := repo.GetTrash(context.Background(), domain.DataFilters{Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	assert.Len(t, *result.Data, 1)
+	assert.NotNil(t, (*result.Data)[0].DeletedAt)
+}
+
+func TestRepository_Restore(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewUserRepository(db, logger)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND deleted_at IS NOT NULL`)).
+		WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "user_name", "deleted_at"}).AddRow(1, "user1", time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rows := sqlmock.NewRows([]string{"id", "user_name", "email"}).AddRow(1, "user1", "user1@example.com")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL`)).
+		WithArgs(1, 1).WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user_history"`)).
+		WithArgs(0, 1, "restore", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+		WithArgs(sqlmock.AnyArg(), 0, "user", 1, "user.restored", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectCommit()
+	user, err := repo.Restore(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", user.UserName)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND deleted_at IS NOT NULL`)).
+		WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	_, err = repo.Restore(context.Background(), 2)
+	var appErr *domainErrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestRepository_Purge(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewUserRepository(db, logger)
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE deleted_at IS NOT NULL AND deleted_at < $1`)).
+		WithArgs(cutoff).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	purged, err := repo.Purge(context.Background(), cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestToDomainMapper_AuditColumns(t *testing.T) {
+	createdBy := 5
+	user := &User{ID: 1, CreatedBy: &createdBy}
+	domainU := user.toDomainMapper()
+	assert.Equal(t, 5, *domainU.CreatedBy)
+	assert.Nil(t, domainU.UpdatedBy)
+	assert.Equal(t, "created_by", ColumnsUserMapping["createdBy"])
+	assert.Equal(t, "updated_by", ColumnsUserMapping["updatedBy"])
+}
+
+func TestUserHistoryTableName(t *testing.T) {
+	assert.Equal(t, "user_history", (&UserHistory{}).TableName())
+}
+
+func TestUserSnapshot_ExcludesPassword(t *testing.T) {
+	user := &User{ID: 1, UserName: "user1", HashPassword: "secret-hash"}
+	entry, err := history.NewEntry(context.Background(), user.ID, domainHistory.ActionCreate, nil, user.toSnapshot())
+	require.NoError(t, err)
+	assert.NotContains(t, entry.Snapshot, "secret-hash")
+	assert.NotContains(t, entry.Snapshot, "hashPassword")
+}
+
+func TestRepository_GetHistory(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewUserRepository(db, logger)
+	rows := sqlmock.NewRows([]string{"id", "entity_id", "action", "changes", "snapshot", "actor_id", "created_at"}).
+		AddRow(1, 1, "create", `{"email":{"before":null,"after":"a@a.com"}}`, `{"id":1,"email":"a@a.com"}`, 3, time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_history" WHERE entity_id = $1 ORDER BY created_at, id`)).
+		WithArgs(1).WillReturnRows(rows)
+	entries, err := repo.GetHistory(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, *entries, 1)
+	assert.Equal(t, "a@a.com", (*entries)[0].Changes["email"].After)
+	assert.Equal(t, 3, *(*entries)[0].ActorID)
+}
+
+func TestRepository_GetAsOf(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewUserRepository(db, logger)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "entity_id", "action", "changes", "snapshot", "created_at"}).
+		AddRow(2, 1, "update", `{}`, `{"id":1,"userName":"user1","email":"old@example.com","status":true}`, at)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_history" WHERE entity_id = $1 AND created_at <= $2 ORDER BY created_at DESC, id DESC`)).
+		WithArgs(1, at, 1).WillReturnRows(rows)
+	user, err := repo.GetAsOf(context.Background(), 1, at)
+	require.NoError(t, err)
+	assert.Equal(t, "old@example.com", user.Email)
+	assert.Empty(t, user.HashPassword)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_history"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = repo.GetAsOf(context.Background(), 1, at)
+	var appErr *domainErrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
 // The following tests need refactoring to use sqlmock or should be moved to integration:
 // TestRepository_GetOneByMap
-// TestRepository_Update
 // TestRepository_Create_DuplicateEmail
 // TestRepository_ErrorCases
 // TestRepository_GetOneByMap_WithFilters
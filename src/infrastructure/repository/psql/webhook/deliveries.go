@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Claim is a due delivery reserved for one dispatcher, with the webhook it targets
+type Claim struct {
+	Delivery Delivery
+	Webhook  Webhook
+}
+
+// Result is the outcome of a delivery attempt. NextAttemptAt schedules another
+// attempt of a failed delivery; it is ignored when the attempt succeeded.
+type Result struct {
+	Succeeded      bool
+	AttemptedAt    time.Time
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	Duration       time.Duration
+	NextAttemptAt  *time.Time
+}
+
+// Enqueue schedules the first delivery of e to every active webhook of its
+// organization subscribed to its type and returns their number. Enqueueing the
+// same event again adds nothing.
+func (r *Repository) Enqueue(ctx context.Context, e event.Event, payload []byte, at time.Time) (int, error) {
+	// events are relayed without a tenant in ctx, so the organization is filtered explicitly
+	var webhooks []Webhook
+	if err := r.DB.WithContext(ctx).Where("tenant_id = ? AND active = ?", e.TenantID, true).Find(&webhooks).Error; err != nil {
+		return 0, err
+	}
+	var deliveries []Delivery
+	for i := range webhooks {
+		if !webhooks[i].toDomainMapper().Subscribes(e.Type) {
+			continue
+		}
+		deliveries = append(deliveries, Delivery{
+			TenantID:    webhooks[i].TenantID,
+			WebhookID:   webhooks[i].ID,
+			EventID:     e.ID,
+			Attempt:     1,
+			EventType:   e.Type,
+			Payload:     string(payload),
+			Status:      domainWebhook.StatusPending,
+			ScheduledAt: at,
+		})
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+	result := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries)
+	return int(result.RowsAffected), result.Error
+}
+
+// ClaimDue reserves up to limit pending deliveries due at now by moving them lease
+// into the future, so a dispatcher that dies mid-delivery only delays them. On
+// Postgres concurrent dispatchers skip the rows another one is claiming. Deliveries
+// of webhooks disabled meanwhile are cancelled instead of claimed.
+func (r *Repository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Claim, error) {
+	var claims []Claim
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("status = ? AND scheduled_at <= ?", domainWebhook.StatusPending, now).
+			Order("scheduled_at, id").
+			Limit(limit)
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		var deliveries []Delivery
+		if err := query.Find(&deliveries).Error; err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		webhookIDs := make([]int, 0, len(deliveries))
+		for i := range deliveries {
+			webhookIDs = append(webhookIDs, deliveries[i].WebhookID)
+		}
+		var webhooks []Webhook
+		if err := tx.Where("id IN ?", webhookIDs).Find(&webhooks).Error; err != nil {
+			return err
+		}
+		byID := make(map[int]Webhook, len(webhooks))
+		for _, webhook := range webhooks {
+			byID[webhook.ID] = webhook
+		}
+
+		var claimed, cancelled []int64
+		for _, delivery := range deliveries {
+			webhook, ok := byID[delivery.WebhookID]
+			if !ok || !webhook.Active {
+				cancelled = append(cancelled, delivery.ID)
+				continue
+			}
+			delivery.ScheduledAt = now.Add(lease)
+			claimed = append(claimed, delivery.ID)
+			claims = append(claims, Claim{Delivery: delivery, Webhook: webhook})
+		}
+		if len(cancelled) > 0 {
+			if err := tx.Model(&Delivery{}).Where("id IN ?", cancelled).
+				UpdateColumn("status", domainWebhook.StatusCancelled).Error; err != nil {
+				return err
+			}
+		}
+		if len(claimed) > 0 {
+			return tx.Model(&Delivery{}).Where("id IN ?", claimed).
+				UpdateColumn("scheduled_at", now.Add(lease)).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Complete records the result of a claimed delivery and tracks the consecutive
+// failures of its webhook. A failure schedules result.NextAttemptAt as the next
+// attempt, unless the webhook reaches disableAfter failures: then it is disabled,
+// its pending deliveries are cancelled and Complete reports true.
+func (r *Repository) Complete(ctx context.Context, claim Claim, result Result, disableAfter int) (bool, error) {
+	status := domainWebhook.StatusFailed
+	if result.Succeeded {
+		status = domainWebhook.StatusSucceeded
+	}
+	disabled := false
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Delivery{}).Where("id = ?", claim.Delivery.ID).UpdateColumns(map[string]any{
+			"status":          status,
+			"attempted_at":    result.AttemptedAt,
+			"response_status": result.ResponseStatus,
+			"response_body":   result.ResponseBody,
+			"error":           result.Error,
+			"duration_ms":     result.Duration.Milliseconds(),
+		}).Error; err != nil {
+			return err
+		}
+
+		if result.Succeeded {
+			return tx.Model(&Webhook{}).Where("id = ? AND consecutive_failures <> 0", claim.Webhook.ID).
+				UpdateColumn("consecutive_failures", 0).Error
+		}
+
+		if err := tx.Model(&Webhook{}).Where("id = ?", claim.Webhook.ID).
+			UpdateColumn("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+			return err
+		}
+		var webhook Webhook
+		if err := tx.Where("id = ?", claim.Webhook.ID).First(&webhook).Error; err != nil {
+			return err
+		}
+
+		if disableAfter > 0 && webhook.ConsecutiveFailures >= disableAfter {
+			disabled = true
+			if err := tx.Model(&Webhook{}).Where("id = ?", webhook.ID).UpdateColumns(map[string]any{
+				"active":          false,
+				"disabled_at":     result.AttemptedAt,
+				"disabled_reason": "too many consecutive failed deliveries",
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&Delivery{}).
+				Where("webhook_id = ? AND status = ?", webhook.ID, domainWebhook.StatusPending).
+				UpdateColumn("status", domainWebhook.StatusCancelled).Error
+		}
+
+		if result.NextAttemptAt == nil {
+			return nil
+		}
+		next := Delivery{
+			TenantID:    claim.Delivery.TenantID,
+			WebhookID:   claim.Delivery.WebhookID,
+			EventID:     claim.Delivery.EventID,
+			Attempt:     claim.Delivery.Attempt + 1,
+			EventType:   claim.Delivery.EventType,
+			Payload:     claim.Delivery.Payload,
+			Status:      domainWebhook.StatusPending,
+			ScheduledAt: *result.NextAttemptAt,
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&next).Error
+	})
+	return disabled, err
+}
@@ -0,0 +1,354 @@
+// Package webhook stores the webhooks of each organization and the log of their
+// deliveries. The delivery side is used by the dispatcher in infrastructure/webhooks.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// WebhookRepositoryInterface defines the interface for webhook repository operations
+type WebhookRepositoryInterface interface {
+	GetAll(ctx context.Context) (*[]domainWebhook.Webhook, error)
+	GetByID(ctx context.Context, id int) (*domainWebhook.Webhook, error)
+	Create(ctx context.Context, webhook *domainWebhook.Webhook) (*domainWebhook.Webhook, error)
+	Update(ctx context.Context, id int, webhookMap map[string]any) (*domainWebhook.Webhook, error)
+	Delete(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, webhookID int, filters domain.DataFilters) (*domainWebhook.SearchResultDelivery, error)
+}
+
+// Webhook is an endpoint registered by an organization. EventTypes holds the
+// subscribed event types separated by commas.
+type Webhook struct {
+	ID                  int    `gorm:"primaryKey"`
+	TenantID            int    `gorm:"index"`
+	URL                 string `gorm:"size:2048"`
+	EventTypes          string `gorm:"size:1024"`
+	Secret              string `gorm:"size:128"`
+	Active              bool
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+	DisabledReason      string    `gorm:"size:255"`
+	CreatedAt           time.Time `gorm:"autoCreateTime:milli"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime:milli"`
+	CreatedBy           *int
+	UpdatedBy           *int
+}
+
+func (*Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Delivery is one attempt to deliver an event to a webhook. Payload is the request
+// body, kept so every attempt of an event sends the same bytes.
+type Delivery struct {
+	ID             int64     `gorm:"primaryKey"`
+	TenantID       int       `gorm:"index"`
+	WebhookID      int       `gorm:"uniqueIndex:idx_webhook_deliveries_attempt,priority:1"`
+	EventID        string    `gorm:"size:36;uniqueIndex:idx_webhook_deliveries_attempt,priority:2"`
+	Attempt        int       `gorm:"uniqueIndex:idx_webhook_deliveries_attempt,priority:3"`
+	EventType      string    `gorm:"size:100"`
+	Payload        string    `gorm:"type:jsonb"`
+	Status         string    `gorm:"size:20;index:idx_webhook_deliveries_due,priority:1"`
+	ScheduledAt    time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	AttemptedAt    *time.Time
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	DurationMs     int64
+	CreatedAt      time.Time `gorm:"autoCreateTime:milli"`
+}
+
+func (*Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// ColumnsWebhookMapping maps the JSON keys accepted by Update to their columns
+var ColumnsWebhookMapping = map[string]string{
+	"url":        "url",
+	"eventTypes": "event_types",
+	"secret":     "secret",
+	"active":     "active",
+}
+
+// ColumnsDeliveryMapping maps the filterable delivery fields to their columns
+var ColumnsDeliveryMapping = map[string]string{
+	"id":             "id",
+	"eventId":        "event_id",
+	"eventType":      "event_type",
+	"attempt":        "attempt",
+	"status":         "status",
+	"responseStatus": "response_status",
+	"scheduledAt":    "scheduled_at",
+	"attemptedAt":    "attempted_at",
+	"createdAt":      "created_at",
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+	now    func() time.Time
+}
+
+func NewWebhookRepository(db *gorm.DB, loggerInstance *logger.Logger) *Repository {
+	return &Repository{DB: db, Logger: loggerInstance, now: time.Now}
+}
+
+func (r *Repository) GetAll(ctx context.Context) (*[]domainWebhook.Webhook, error) {
+	var webhooks []Webhook
+	if err := r.DB.WithContext(ctx).Order("id").Find(&webhooks).Error; err != nil {
+		r.Logger.Error("Error getting all webhooks", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved all webhooks", zap.Int("count", len(webhooks)))
+	return arrayToDomainMapper(&webhooks), nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*domainWebhook.Webhook, error) {
+	var webhook Webhook
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Webhook not found", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting webhook by ID", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved webhook by ID", zap.Int("id", id))
+	return webhook.toDomainMapper(), nil
+}
+
+func (r *Repository) Create(ctx context.Context, newWebhook *domainWebhook.Webhook) (*domainWebhook.Webhook, error) {
+	webhook := fromDomainMapper(newWebhook)
+	if err := r.DB.WithContext(ctx).Create(webhook).Error; err != nil {
+		r.Logger.Error("Error creating webhook", zap.Error(err), zap.String("url", newWebhook.URL))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully created webhook", zap.String("url", webhook.URL), zap.Int("id", webhook.ID))
+	return webhook.toDomainMapper(), nil
+}
+
+// Update changes the fields in webhookMap, keyed by their JSON names. Enabling a
+// webhook again clears its failure count and the reason it was disabled.
+func (r *Repository) Update(ctx context.Context, id int, webhookMap map[string]any) (*domainWebhook.Webhook, error) {
+	updates := make(map[string]any, len(webhookMap)+3)
+	for key, value := range webhookMap {
+		column := ColumnsWebhookMapping[key]
+		if column == "" {
+			continue
+		}
+		if eventTypes, ok := value.([]string); ok {
+			value = strings.Join(eventTypes, ",")
+		}
+		updates[column] = value
+	}
+	if active, ok := updates["active"].(bool); ok && active {
+		updates["consecutive_failures"] = 0
+		updates["disabled_at"] = nil
+		updates["disabled_reason"] = ""
+	}
+
+	var webhook Webhook
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&webhook).Error; err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&webhook).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", id).First(&webhook).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Webhook not found for update", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error updating webhook", zap.Error(err), zap.Int("id", id))
+		return nil, writeError(err)
+	}
+	r.Logger.Info("Successfully updated webhook", zap.Int("id", id))
+	return webhook.toDomainMapper(), nil
+}
+
+// Delete removes the webhook together with its delivery log
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&Webhook{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&Delivery{}).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Webhook not found for deletion", zap.Int("id", id))
+			return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error deleting webhook", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully deleted webhook", zap.Int("id", id))
+	return nil
+}
+
+// GetDeliveries lists the delivery attempts of a webhook, newest first unless filters sort otherwise
+func (r *Repository) GetDeliveries(ctx context.Context, webhookID int, filters domain.DataFilters) (*domainWebhook.SearchResultDelivery, error) {
+	if _, err := r.GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	query := r.DB.WithContext(ctx).Model(&Delivery{}).Where("webhook_id = ?", webhookID)
+
+	for field, values := range filters.Matches {
+		if column := ColumnsDeliveryMapping[field]; column != "" && len(values) > 0 {
+			query = query.Where(column+" IN ?", values)
+		}
+	}
+	for _, dateFilter := range filters.DateRangeFilters {
+		column := ColumnsDeliveryMapping[dateFilter.Field]
+		if column == "" {
+			continue
+		}
+		if dateFilter.Start != nil {
+			query = query.Where(column+" >= ?", dateFilter.Start)
+		}
+		if dateFilter.End != nil {
+			query = query.Where(column+" <= ?", dateFilter.End)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.Logger.Error("Error counting webhook deliveries", zap.Error(err), zap.Int("webhookId", webhookID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	sorted := false
+	if filters.SortDirection.IsValid() {
+		for _, sortField := range filters.SortBy {
+			if column := ColumnsDeliveryMapping[sortField]; column != "" {
+				query = query.Order(column + " " + string(filters.SortDirection))
+				sorted = true
+			}
+		}
+	}
+	if !sorted {
+		query = query.Order("id DESC")
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	var deliveries []Delivery
+	if err := query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize).Find(&deliveries).Error; err != nil {
+		r.Logger.Error("Error listing webhook deliveries", zap.Error(err), zap.Int("webhookId", webhookID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	data := make([]domainWebhook.Delivery, len(deliveries))
+	for i := range deliveries {
+		data[i] = *deliveries[i].toDomainMapper()
+	}
+	r.Logger.Info("Successfully listed webhook deliveries", zap.Int("webhookId", webhookID), zap.Int64("total", total))
+	return &domainWebhook.SearchResultDelivery{
+		Data:       &data,
+		Total:      total,
+		Page:       filters.Page,
+		PageSize:   filters.PageSize,
+		TotalPages: int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize)),
+	}, nil
+}
+
+func fromDomainMapper(w *domainWebhook.Webhook) *Webhook {
+	return &Webhook{
+		ID:                  w.ID,
+		TenantID:            w.TenantID,
+		URL:                 w.URL,
+		EventTypes:          strings.Join(w.EventTypes, ","),
+		Secret:              w.Secret,
+		Active:              w.Active,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		DisabledAt:          w.DisabledAt,
+		DisabledReason:      w.DisabledReason,
+	}
+}
+
+func (w *Webhook) toDomainMapper() *domainWebhook.Webhook {
+	var eventTypes []string
+	if w.EventTypes != "" {
+		eventTypes = strings.Split(w.EventTypes, ",")
+	}
+	return &domainWebhook.Webhook{
+		ID:                  w.ID,
+		TenantID:            w.TenantID,
+		URL:                 w.URL,
+		EventTypes:          eventTypes,
+		Secret:              w.Secret,
+		Active:              w.Active,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		DisabledAt:          w.DisabledAt,
+		DisabledReason:      w.DisabledReason,
+		CreatedAt:           w.CreatedAt,
+		UpdatedAt:           w.UpdatedAt,
+		CreatedBy:           w.CreatedBy,
+		UpdatedBy:           w.UpdatedBy,
+	}
+}
+
+func arrayToDomainMapper(webhooks *[]Webhook) *[]domainWebhook.Webhook {
+	result := make([]domainWebhook.Webhook, len(*webhooks))
+	for i := range *webhooks {
+		result[i] = *(*webhooks)[i].toDomainMapper()
+	}
+	return &result
+}
+
+func (d *Delivery) toDomainMapper() *domainWebhook.Delivery {
+	return &domainWebhook.Delivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Attempt:        d.Attempt,
+		Status:         d.Status,
+		ScheduledAt:    d.ScheduledAt,
+		AttemptedAt:    d.AttemptedAt,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		Error:          d.Error,
+		DurationMs:     d.DurationMs,
+		CreatedAt:      d.CreatedAt,
+	}
+}
+
+// writeError translates a failed write into the matching application error
+func writeError(err error) error {
+	byteErr, _ := json.Marshal(err)
+	var newError domainErrors.GormErr
+	if errUnmarshal := json.Unmarshal(byteErr, &newError); errUnmarshal != nil {
+		return errUnmarshal
+	}
+	switch newError.Number {
+	case 1062:
+		return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	default:
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+}
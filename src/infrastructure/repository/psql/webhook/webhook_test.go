@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupRepository(t *testing.T) *Repository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&Webhook{}, &Delivery{}))
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewWebhookRepository(db, loggerInstance)
+}
+
+func assertAppError(t *testing.T, err error, errorType domainErrors.ErrorType) {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok, "expected an AppError, got %v", err)
+	assert.Equal(t, errorType, appErr.Type)
+}
+
+func TestRepository_CreateAndUpdate(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+
+	created, err := repository.Create(ctx, &domainWebhook.Webhook{
+		TenantID:   1,
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{event.MedicineCreated, event.MedicineDeleted},
+		Secret:     "whsec_one",
+		Active:     true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{event.MedicineCreated, event.MedicineDeleted}, created.EventTypes)
+
+	updated, err := repository.Update(ctx, created.ID, map[string]any{
+		"url":        "https://partner.example.com/v2/hooks",
+		"eventTypes": []string{event.UserCreated},
+		"active":     false,
+		"tenantId":   99,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://partner.example.com/v2/hooks", updated.URL)
+	assert.Equal(t, []string{event.UserCreated}, updated.EventTypes)
+	assert.False(t, updated.Active)
+	assert.Equal(t, 1, updated.TenantID, "unknown keys are ignored")
+
+	_, err = repository.Update(ctx, 404, map[string]any{"active": true})
+	assertAppError(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_DeliveriesAndDelete(t *testing.T) {
+	repository := setupRepository(t)
+	ctx := context.Background()
+	webhook, err := repository.Create(ctx, &domainWebhook.Webhook{TenantID: 1, URL: "https://example.com", EventTypes: []string{event.MedicineCreated}, Active: true})
+	require.NoError(t, err)
+
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, id := range []string{"evt-1", "evt-2", "evt-3"} {
+		queued, err := repository.Enqueue(ctx, event.Event{ID: id, Type: event.MedicineCreated, TenantID: 1}, []byte(`{}`), at)
+		require.NoError(t, err)
+		assert.Equal(t, 1, queued)
+	}
+	queued, err := repository.Enqueue(ctx, event.Event{ID: "evt-4", Type: event.MedicineCreated, TenantID: 2}, []byte(`{}`), at)
+	require.NoError(t, err)
+	assert.Zero(t, queued, "webhooks only receive the events of their organization")
+
+	page, err := repository.GetDeliveries(ctx, webhook.ID, domain.DataFilters{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Equal(t, 2, page.TotalPages)
+	require.Len(t, *page.Data, 2)
+	assert.Equal(t, "evt-3", (*page.Data)[0].EventID, "newest first")
+
+	filtered, err := repository.GetDeliveries(ctx, webhook.ID, domain.DataFilters{Matches: map[string][]string{"eventId": {"evt-2"}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), filtered.Total)
+
+	_, err = repository.GetDeliveries(ctx, 404, domain.DataFilters{})
+	assertAppError(t, err, domainErrors.NotFound)
+
+	require.NoError(t, repository.Delete(ctx, webhook.ID))
+	var remaining int64
+	require.NoError(t, repository.DB.Model(&Delivery{}).Count(&remaining).Error)
+	assert.Zero(t, remaining)
+	assertAppError(t, repository.Delete(ctx, webhook.ID), domainErrors.NotFound)
+}
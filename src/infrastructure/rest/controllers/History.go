@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"time"
+
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
+)
+
+type FieldChangeResponse struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type HistoryEntryResponse struct {
+	ID        int                            `json:"id"`
+	EntityID  int                            `json:"entityId"`
+	Action    string                         `json:"action"`
+	Changes   map[string]FieldChangeResponse `json:"changes"`
+	ActorID   *int                           `json:"actorId"`
+	CreatedAt time.Time                      `json:"createdAt"`
+}
+
+func HistoryToResponseMapper(entries *[]domainHistory.Entry) *[]HistoryEntryResponse {
+	res := make([]HistoryEntryResponse, len(*entries))
+	for i, entry := range *entries {
+		changes := make(map[string]FieldChangeResponse, len(entry.Changes))
+		for field, change := range entry.Changes {
+			changes[field] = FieldChangeResponse(change)
+		}
+		res[i] = HistoryEntryResponse{
+			ID:        entry.ID,
+			EntityID:  entry.EntityID,
+			Action:    string(entry.Action),
+			Changes:   changes,
+			ActorID:   entry.ActorID,
+			CreatedAt: entry.CreatedAt,
+		}
+	}
+	return &res
+}
+
+// ParseAsOf reads the optional asOf query value; a nil time means the current state was requested
+func ParseAsOf(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &asOf, nil
+}
@@ -38,7 +38,7 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	domainUser, authTokens, err := c.authUseCase.Login(request.Email, request.Password)
+	domainUser, authTokens, err := c.authUseCase.Login(ctx.Request.Context(), request.Email, request.Password)
 	if err != nil {
 		c.Logger.Error("Login failed", zap.Error(err), zap.String("email", request.Email))
 		_ = ctx.Error(err)
@@ -76,7 +76,7 @@ func (c *AuthController) GetAccessTokenByRefreshToken(ctx *gin.Context) {
 		return
 	}
 
-	domainUser, authTokens, err := c.authUseCase.AccessTokenByRefreshToken(request.RefreshToken)
+	domainUser, authTokens, err := c.authUseCase.AccessTokenByRefreshToken(ctx.Request.Context(), request.RefreshToken)
 	if err != nil {
 		c.Logger.Error("Token refresh failed", zap.Error(err))
 		_ = ctx.Error(err)
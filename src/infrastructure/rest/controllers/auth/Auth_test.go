@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -20,14 +21,14 @@ type MockAuthUseCase struct {
 	accessTokenByRefreshFunc func(string) (*userDomain.User, *useCaseAuth.AuthTokens, error)
 }
 
-func (m *MockAuthUseCase) Login(email, password string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
+func (m *MockAuthUseCase) Login(_ context.Context, email, password string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
 	if m.loginFunc != nil {
 		return m.loginFunc(email, password)
 	}
 	return nil, nil, nil
 }
 
-func (m *MockAuthUseCase) AccessTokenByRefreshToken(refreshToken string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
+func (m *MockAuthUseCase) AccessTokenByRefreshToken(_ context.Context, refreshToken string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
 	if m.accessTokenByRefreshFunc != nil {
 		return m.accessTokenByRefreshFunc(refreshToken)
 	}
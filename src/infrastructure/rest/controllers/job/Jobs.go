@@ -0,0 +1,170 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	jobDomain "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+type ResponseJob struct {
+	ID          int64           `json:"id"`
+	TenantID    int             `json:"tenantId,omitempty"`
+	Queue       string          `json:"queue"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	RunAt       time.Time       `json:"runAt"`
+	LockedUntil *time.Time      `json:"lockedUntil,omitempty"`
+	LastError   string          `json:"lastError,omitempty"`
+	StartedAt   *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time      `json:"finishedAt,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+	CreatedBy   *int            `json:"createdBy"`
+	UpdatedBy   *int            `json:"updatedBy"`
+}
+
+type IJobController interface {
+	GetAllJobs(ctx *gin.Context)
+	GetJobByID(ctx *gin.Context)
+	RetryJob(ctx *gin.Context)
+	CancelJob(ctx *gin.Context)
+}
+
+type Controller struct {
+	jobService jobDomain.IJobService
+	Logger     *logger.Logger
+}
+
+func NewJobController(jobService jobDomain.IJobService, loggerInstance *logger.Logger) IJobController {
+	return &Controller{jobService: jobService, Logger: loggerInstance}
+}
+
+// GetAllJobs lists jobs, newest first. The status, queue and type query parameters
+// narrow the list.
+func (c *Controller) GetAllJobs(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	filters := domain.DataFilters{Page: page, PageSize: pageSize, Matches: map[string][]string{}}
+	for _, field := range []string{"status", "queue", "type"} {
+		if values := ctx.QueryArray(field); len(values) > 0 {
+			filters.Matches[field] = values
+		}
+	}
+
+	c.Logger.Info("Getting jobs")
+	result, err := c.jobService.GetAll(ctx.Request.Context(), filters)
+	if err != nil {
+		c.Logger.Error("Error getting jobs", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	data := make([]ResponseJob, len(*result.Data))
+	for i := range *result.Data {
+		data[i] = *domainToResponseMapper(&(*result.Data)[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+	})
+}
+
+func (c *Controller) GetJobByID(ctx *gin.Context) {
+	jobID, ok := c.jobID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Getting job by ID", zap.Int64("id", jobID))
+	job, err := c.jobService.GetByID(ctx.Request.Context(), jobID)
+	if err != nil {
+		c.Logger.Error("Error getting job by ID", zap.Error(err), zap.Int64("id", jobID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponseMapper(job))
+}
+
+// RetryJob runs a failed or cancelled job again
+func (c *Controller) RetryJob(ctx *gin.Context) {
+	jobID, ok := c.jobID(ctx)
+	if !ok {
+		return
+	}
+	job, err := c.jobService.Retry(ctx.Request.Context(), jobID)
+	if err != nil {
+		c.Logger.Error("Error retrying job", zap.Error(err), zap.Int64("id", jobID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Job queued again", zap.Int64("id", jobID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(job))
+}
+
+// CancelJob keeps a pending or scheduled job from running
+func (c *Controller) CancelJob(ctx *gin.Context) {
+	jobID, ok := c.jobID(ctx)
+	if !ok {
+		return
+	}
+	job, err := c.jobService.Cancel(ctx.Request.Context(), jobID)
+	if err != nil {
+		c.Logger.Error("Error cancelling job", zap.Error(err), zap.Int64("id", jobID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Job cancelled", zap.Int64("id", jobID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(job))
+}
+
+func (c *Controller) jobID(ctx *gin.Context) (int64, bool) {
+	jobID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Logger.Error("Invalid job ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("job id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return jobID, true
+}
+
+// Mappers
+func domainToResponseMapper(job *jobDomain.Job) *ResponseJob {
+	payload := json.RawMessage(job.Payload)
+	if !json.Valid(payload) {
+		payload = json.RawMessage("null")
+	}
+	return &ResponseJob{
+		ID:          job.ID,
+		TenantID:    job.TenantID,
+		Queue:       job.Queue,
+		Type:        job.Type,
+		Payload:     payload,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		LockedUntil: job.LockedUntil,
+		LastError:   job.LastError,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+		CreatedBy:   job.CreatedBy,
+		UpdatedBy:   job.UpdatedBy,
+	}
+}
@@ -0,0 +1,142 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	jobDomain "github.com/gbrayhan/microservices-go/src/domain/job"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockJobService is a mock implementation of IJobService
+type MockJobService struct {
+	mock.Mock
+}
+
+func (m *MockJobService) GetAll(_ context.Context, filters domain.DataFilters) (*jobDomain.SearchResultJob, error) {
+	args := m.Called(filters)
+	result, _ := args.Get(0).(*jobDomain.SearchResultJob)
+	return result, args.Error(1)
+}
+
+func (m *MockJobService) GetByID(_ context.Context, id int64) (*jobDomain.Job, error) {
+	args := m.Called(id)
+	job, _ := args.Get(0).(*jobDomain.Job)
+	return job, args.Error(1)
+}
+
+func (m *MockJobService) Retry(_ context.Context, id int64) (*jobDomain.Job, error) {
+	args := m.Called(id)
+	job, _ := args.Get(0).(*jobDomain.Job)
+	return job, args.Error(1)
+}
+
+func (m *MockJobService) Cancel(_ context.Context, id int64) (*jobDomain.Job, error) {
+	args := m.Called(id)
+	job, _ := args.Get(0).(*jobDomain.Job)
+	return job, args.Error(1)
+}
+
+func setupController(t *testing.T) (*MockJobService, IJobController) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	mockService := &MockJobService{}
+	return mockService, NewJobController(mockService, loggerInstance)
+}
+
+func setupGinContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	return c, w
+}
+
+func TestJobController_GetAllJobs(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/jobs/?status=failed&queue=maintenance&page=2&pageSize=5", nil)
+	filters := domain.DataFilters{Page: 2, PageSize: 5, Matches: map[string][]string{
+		"status": {"failed"},
+		"queue":  {"maintenance"},
+	}}
+	mockService.On("GetAll", filters).Return(&jobDomain.SearchResultJob{
+		Data:       &[]jobDomain.Job{{ID: 9, Type: "records.purge", Payload: `{"days":30}`, Status: "failed", LastError: "timeout"}},
+		Total:      6,
+		Page:       2,
+		PageSize:   5,
+		TotalPages: 2,
+	}, nil)
+
+	controller.GetAllJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data  []ResponseJob `json:"data"`
+		Total int64         `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(6), response.Total)
+	require.Len(t, response.Data, 1)
+	assert.JSONEq(t, `{"days":30}`, string(response.Data[0].Payload))
+	assert.Equal(t, "timeout", response.Data[0].LastError)
+	mockService.AssertExpectations(t)
+}
+
+func TestJobController_RetryJob(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/jobs/9/retry", nil)
+	c.Params = gin.Params{{Key: "id", Value: "9"}}
+	mockService.On("Retry", int64(9)).Return(&jobDomain.Job{ID: 9, Status: jobDomain.StatusPending}, nil)
+
+	controller.RetryJob(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestJobController_CancelJobError(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, _ := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/jobs/9/cancel", nil)
+	c.Params = gin.Params{{Key: "id", Value: "9"}}
+	mockService.On("Cancel", int64(9)).Return(nil, domainErrors.NewAppError(errors.New("job 9 is running"), domainErrors.ValidationError))
+
+	controller.CancelJob(c)
+
+	require.Len(t, c.Errors, 1)
+	assert.EqualError(t, c.Errors[0].Err, "job 9 is running")
+}
+
+func TestJobController_InvalidID(t *testing.T) {
+	_, controller := setupController(t)
+	for name, handler := range map[string]gin.HandlerFunc{
+		"get":    controller.GetJobByID,
+		"retry":  controller.RetryJob,
+		"cancel": controller.CancelJob,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := setupGinContext()
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/jobs/abc", nil)
+			c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+			handler(c)
+
+			require.Len(t, c.Errors, 1)
+			appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+		})
+	}
+}
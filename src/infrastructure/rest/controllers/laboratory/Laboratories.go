@@ -0,0 +1,222 @@
+package laboratory
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainError "github.com/gbrayhan/microservices-go/src/domain/errors"
+	laboratoryDomain "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/laboratory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+type NewLaboratoryRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type ResponseLaboratory struct {
+	ID        int       `json:"id"`
+	TenantID  int       `json:"tenantId"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	CreatedBy *int      `json:"createdBy"`
+	UpdatedBy *int      `json:"updatedBy"`
+}
+
+type ILaboratoryController interface {
+	NewLaboratory(ctx *gin.Context)
+	GetAllLaboratories(ctx *gin.Context)
+	GetLaboratoryByID(ctx *gin.Context)
+	UpdateLaboratory(ctx *gin.Context)
+	DeleteLaboratory(ctx *gin.Context)
+	SearchPaginated(ctx *gin.Context)
+}
+
+type Controller struct {
+	laboratoryService laboratoryDomain.ILaboratoryService
+	Logger            *logger.Logger
+}
+
+func NewLaboratoryController(laboratoryService laboratoryDomain.ILaboratoryService, loggerInstance *logger.Logger) ILaboratoryController {
+	return &Controller{laboratoryService: laboratoryService, Logger: loggerInstance}
+}
+
+// NewLaboratory registers a laboratory; other spellings of an existing name are rejected
+func (c *Controller) NewLaboratory(ctx *gin.Context) {
+	c.Logger.Info("Creating new laboratory")
+	var request NewLaboratoryRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new laboratory", zap.Error(err))
+		appError := domainError.NewAppError(err, domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	created, err := c.laboratoryService.Create(ctx.Request.Context(), &laboratoryDomain.Laboratory{Name: request.Name})
+	if err != nil {
+		c.Logger.Error("Error creating laboratory", zap.Error(err), zap.String("name", request.Name))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Laboratory created successfully", zap.String("name", created.Name), zap.Int("id", created.ID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(created))
+}
+
+func (c *Controller) GetAllLaboratories(ctx *gin.Context) {
+	c.Logger.Info("Getting all laboratories")
+	laboratories, err := c.laboratoryService.GetAll(ctx.Request.Context())
+	if err != nil {
+		c.Logger.Error("Error getting all laboratories", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully retrieved all laboratories", zap.Int("count", len(*laboratories)))
+	ctx.JSON(http.StatusOK, arrayDomainToResponseMapper(laboratories))
+}
+
+func (c *Controller) GetLaboratoryByID(ctx *gin.Context) {
+	laboratoryID, ok := c.laboratoryID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Getting laboratory by ID", zap.Int("id", laboratoryID))
+	found, err := c.laboratoryService.GetByID(ctx.Request.Context(), laboratoryID)
+	if err != nil {
+		c.Logger.Error("Error getting laboratory by ID", zap.Error(err), zap.Int("id", laboratoryID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponseMapper(found))
+}
+
+// UpdateLaboratory renames a laboratory together with its medicines
+func (c *Controller) UpdateLaboratory(ctx *gin.Context) {
+	laboratoryID, ok := c.laboratoryID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Updating laboratory", zap.Int("id", laboratoryID))
+	var requestMap map[string]any
+	if err := controllers.BindJSONMap(ctx, &requestMap); err != nil {
+		c.Logger.Error("Error binding JSON for laboratory update", zap.Error(err), zap.Int("id", laboratoryID))
+		appError := domainError.NewAppError(err, domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	updated, err := c.laboratoryService.Update(ctx.Request.Context(), laboratoryID, requestMap)
+	if err != nil {
+		c.Logger.Error("Error updating laboratory", zap.Error(err), zap.Int("id", laboratoryID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Laboratory updated successfully", zap.Int("id", laboratoryID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(updated))
+}
+
+// DeleteLaboratory removes a laboratory no medicine is linked to
+func (c *Controller) DeleteLaboratory(ctx *gin.Context) {
+	laboratoryID, ok := c.laboratoryID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Deleting laboratory", zap.Int("id", laboratoryID))
+	if err := c.laboratoryService.Delete(ctx.Request.Context(), laboratoryID); err != nil {
+		c.Logger.Error("Error deleting laboratory", zap.Error(err), zap.Int("id", laboratoryID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Laboratory deleted successfully", zap.Int("id", laboratoryID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+func (c *Controller) SearchPaginated(ctx *gin.Context) {
+	c.Logger.Info("Searching laboratories with pagination")
+	filters := buildDataFilters(ctx)
+	result, err := c.laboratoryService.SearchPaginated(ctx.Request.Context(), filters)
+	if err != nil {
+		c.Logger.Error("Error searching laboratories", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully searched laboratories", zap.Int64("total", result.Total), zap.Int("page", result.Page))
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       arrayDomainToResponseMapper(result.Data),
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+		"filters":    filters,
+	})
+}
+
+// buildDataFilters reads pagination, filters and sorting from the query string
+func buildDataFilters(ctx *gin.Context) domain.DataFilters {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	filters := domain.DataFilters{
+		Page:          page,
+		PageSize:      pageSize,
+		LikeFilters:   map[string][]string{},
+		Matches:       map[string][]string{},
+		SortBy:        ctx.QueryArray("sortBy"),
+		SortDirection: domain.SortDirection(ctx.DefaultQuery("sortDirection", "asc")),
+	}
+	for field := range laboratory.ColumnsLaboratoryMapping {
+		if values := ctx.QueryArray(field + "_like"); len(values) > 0 {
+			filters.LikeFilters[field] = values
+		}
+		if values := ctx.QueryArray(field + "_match"); len(values) > 0 {
+			filters.Matches[field] = values
+		}
+		dateRange := domain.DateRangeFilter{Field: field}
+		if start, err := time.Parse(time.RFC3339, ctx.Query(field+"_start")); err == nil {
+			dateRange.Start = &start
+		}
+		if end, err := time.Parse(time.RFC3339, ctx.Query(field+"_end")); err == nil {
+			dateRange.End = &end
+		}
+		if dateRange.Start != nil || dateRange.End != nil {
+			filters.DateRangeFilters = append(filters.DateRangeFilters, dateRange)
+		}
+	}
+	return filters
+}
+
+func (c *Controller) laboratoryID(ctx *gin.Context) (int, bool) {
+	laboratoryID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid laboratory ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainError.NewAppError(errors.New("laboratory id is invalid"), domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return laboratoryID, true
+}
+
+// Mappers
+func domainToResponseMapper(l *laboratoryDomain.Laboratory) *ResponseLaboratory {
+	return &ResponseLaboratory{
+		ID:        l.ID,
+		TenantID:  l.TenantID,
+		Name:      l.Name,
+		CreatedAt: l.CreatedAt,
+		UpdatedAt: l.UpdatedAt,
+		CreatedBy: l.CreatedBy,
+		UpdatedBy: l.UpdatedBy,
+	}
+}
+
+func arrayDomainToResponseMapper(laboratories *[]laboratoryDomain.Laboratory) *[]ResponseLaboratory {
+	res := make([]ResponseLaboratory, len(*laboratories))
+	for i := range *laboratories {
+		res[i] = *domainToResponseMapper(&(*laboratories)[i])
+	}
+	return &res
+}
@@ -0,0 +1,168 @@
+package laboratory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	laboratoryDomain "github.com/gbrayhan/microservices-go/src/domain/laboratory"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockLaboratoryService is a mock implementation of ILaboratoryService
+type MockLaboratoryService struct {
+	mock.Mock
+}
+
+func (m *MockLaboratoryService) GetAll(_ context.Context) (*[]laboratoryDomain.Laboratory, error) {
+	args := m.Called()
+	laboratories, _ := args.Get(0).(*[]laboratoryDomain.Laboratory)
+	return laboratories, args.Error(1)
+}
+
+func (m *MockLaboratoryService) GetByID(_ context.Context, id int) (*laboratoryDomain.Laboratory, error) {
+	args := m.Called(id)
+	laboratory, _ := args.Get(0).(*laboratoryDomain.Laboratory)
+	return laboratory, args.Error(1)
+}
+
+func (m *MockLaboratoryService) Create(_ context.Context, laboratory *laboratoryDomain.Laboratory) (*laboratoryDomain.Laboratory, error) {
+	args := m.Called(laboratory)
+	created, _ := args.Get(0).(*laboratoryDomain.Laboratory)
+	return created, args.Error(1)
+}
+
+func (m *MockLaboratoryService) Update(_ context.Context, id int, laboratoryMap map[string]any) (*laboratoryDomain.Laboratory, error) {
+	args := m.Called(id, laboratoryMap)
+	updated, _ := args.Get(0).(*laboratoryDomain.Laboratory)
+	return updated, args.Error(1)
+}
+
+func (m *MockLaboratoryService) Delete(_ context.Context, id int) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockLaboratoryService) SearchPaginated(_ context.Context, filters domain.DataFilters) (*laboratoryDomain.SearchResultLaboratory, error) {
+	args := m.Called(filters)
+	result, _ := args.Get(0).(*laboratoryDomain.SearchResultLaboratory)
+	return result, args.Error(1)
+}
+
+func setupController(t *testing.T) (*MockLaboratoryService, ILaboratoryController) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	mockService := &MockLaboratoryService{}
+	return mockService, NewLaboratoryController(mockService, loggerInstance)
+}
+
+func setupGinContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	return c, w
+}
+
+func TestLaboratoryController_NewLaboratory(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService, controller := setupController(t)
+		c, w := setupGinContext()
+		body, _ := json.Marshal(NewLaboratoryRequest{Name: "Pfizer"})
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/laboratory/", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		mockService.On("Create", &laboratoryDomain.Laboratory{Name: "Pfizer"}).Return(&laboratoryDomain.Laboratory{ID: 3, TenantID: 1, Name: "Pfizer"}, nil)
+
+		controller.NewLaboratory(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response ResponseLaboratory
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.ID)
+		assert.Equal(t, "Pfizer", response.Name)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+		_, controller := setupController(t)
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/laboratory/", bytes.NewBufferString(`{}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		controller.NewLaboratory(c)
+
+		require.Len(t, c.Errors, 1)
+		appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	})
+}
+
+func TestLaboratoryController_UpdateLaboratory(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	c.Request = httptest.NewRequest(http.MethodPut, "/v1/laboratory/3", bytes.NewBufferString(`{"name":"Pfizer Inc."}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	mockService.On("Update", 3, map[string]any{"name": "Pfizer Inc."}).Return(&laboratoryDomain.Laboratory{ID: 3, Name: "Pfizer Inc."}, nil)
+
+	controller.UpdateLaboratory(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Pfizer Inc."`)
+	mockService.AssertExpectations(t)
+}
+
+func TestLaboratoryController_DeleteLaboratory(t *testing.T) {
+	t.Run("In use", func(t *testing.T) {
+		mockService, controller := setupController(t)
+		c, _ := setupGinContext()
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+		c.Request = httptest.NewRequest(http.MethodDelete, "/v1/laboratory/3", nil)
+		mockService.On("Delete", 3).Return(domainErrors.NewAppErrorWithType(domainErrors.ValidationError))
+
+		controller.DeleteLaboratory(c)
+
+		require.Len(t, c.Errors, 1)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		_, controller := setupController(t)
+		c, _ := setupGinContext()
+		c.Params = gin.Params{{Key: "id", Value: "abc"}}
+		c.Request = httptest.NewRequest(http.MethodDelete, "/v1/laboratory/abc", nil)
+
+		controller.DeleteLaboratory(c)
+
+		require.Len(t, c.Errors, 1)
+		appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	})
+}
+
+func TestLaboratoryController_SearchPaginated(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/laboratory/search?name_like=pfi&page=2&pageSize=5", nil)
+	mockService.On("SearchPaginated", mock.MatchedBy(func(filters domain.DataFilters) bool {
+		return filters.Page == 2 && filters.PageSize == 5 && assert.ObjectsAreEqual([]string{"pfi"}, filters.LikeFilters["name"])
+	})).Return(&laboratoryDomain.SearchResultLaboratory{
+		Data:  &[]laboratoryDomain.Laboratory{{ID: 3, Name: "Pfizer"}},
+		Total: 6, Page: 2, PageSize: 5, TotalPages: 2,
+	}, nil)
+
+	controller.SearchPaginated(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Pfizer"`)
+	mockService.AssertExpectations(t)
+}
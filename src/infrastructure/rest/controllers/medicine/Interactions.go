@@ -0,0 +1,218 @@
+package medicine
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	domainError "github.com/gbrayhan/microservices-go/src/domain/errors"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxImportSize is the size of the largest CSV file the knowledge base imports take
+const maxImportSize = 10 << 20
+
+// Structures
+
+// CheckInteractionsRequest lists the medicines dispensed together
+type CheckInteractionsRequest struct {
+	MedicineIDs []int `json:"medicineIds" binding:"required,min=1,max=100,dive,gt=0"`
+}
+
+type ResponseImport struct {
+	Imported int `json:"imported"`
+}
+
+// ResponseInteractionMedicine is a medicine taking part in an interaction, with its
+// ingredients matching the subject of the interaction
+type ResponseInteractionMedicine struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Ingredients []string `json:"ingredients"`
+}
+
+// ResponseInteraction is an interaction found between two medicines; the first medicine
+// matches First and the second one Second
+type ResponseInteraction struct {
+	InteractionID int                            `json:"interactionId"`
+	First         string                         `json:"first"`
+	Second        string                         `json:"second"`
+	Severity      string                         `json:"severity"`
+	Description   string                         `json:"description"`
+	Medicines     [2]ResponseInteractionMedicine `json:"medicines"`
+}
+
+// ImportInteractions adds the interactions of a CSV file with the columns first,
+// second, severity and, optionally, description to the knowledge base
+func (c *Controller) ImportInteractions(ctx *gin.Context) {
+	var interactions []medicineDomain.Interaction
+	err := readCSV(ctx, []string{"first", "second", "severity"}, []string{"description"}, func(fields []string) error {
+		interaction, err := medicineDomain.NewInteraction(fields[0], fields[1], fields[2], fields[3])
+		if err == nil {
+			interactions = append(interactions, *interaction)
+		}
+		return err
+	})
+	if err != nil {
+		c.Logger.Error("Error reading medicine interactions", zap.Error(err))
+		_ = ctx.Error(domainError.NewAppError(err, domainError.ValidationError))
+		return
+	}
+	imported, err := c.medicineService.ImportInteractions(ctx.Request.Context(), interactions)
+	if err != nil {
+		c.Logger.Error("Error importing medicine interactions", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Medicine interactions imported", zap.Int("count", imported))
+	ctx.JSON(http.StatusOK, ResponseImport{Imported: imported})
+}
+
+// ImportIngredientClasses adds the memberships of a CSV file with the columns class and
+// ingredient to the classes interactions may name
+func (c *Controller) ImportIngredientClasses(ctx *gin.Context) {
+	var classes []medicineDomain.IngredientClass
+	err := readCSV(ctx, []string{"class", "ingredient"}, nil, func(fields []string) error {
+		class, err := medicineDomain.NewIngredientClass(fields[0], fields[1])
+		if err == nil {
+			classes = append(classes, *class)
+		}
+		return err
+	})
+	if err != nil {
+		c.Logger.Error("Error reading ingredient classes", zap.Error(err))
+		_ = ctx.Error(domainError.NewAppError(err, domainError.ValidationError))
+		return
+	}
+	imported, err := c.medicineService.ImportIngredientClasses(ctx.Request.Context(), classes)
+	if err != nil {
+		c.Logger.Error("Error importing ingredient classes", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Ingredient classes imported", zap.Int("count", imported))
+	ctx.JSON(http.StatusOK, ResponseImport{Imported: imported})
+}
+
+// CheckInteractions lists the interactions between the medicines of a request, the
+// most serious first
+func (c *Controller) CheckInteractions(ctx *gin.Context) {
+	var request CheckInteractionsRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for interaction check", zap.Error(err))
+		appError := domainError.NewAppError(err, domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	warnings, err := c.medicineService.CheckInteractions(ctx.Request.Context(), request.MedicineIDs)
+	if err != nil {
+		c.Logger.Error("Error checking medicine interactions", zap.Error(err), zap.Ints("ids", request.MedicineIDs))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]ResponseInteraction, len(*warnings))
+	for i := range *warnings {
+		response[i] = *interactionToResponseMapper(&(*warnings)[i])
+	}
+	c.Logger.Info("Successfully checked medicine interactions", zap.Ints("ids", request.MedicineIDs), zap.Int("count", len(response)))
+	ctx.JSON(http.StatusOK, response)
+}
+
+// readCSV reads the CSV file of a request, sent as the body or as the file field of a
+// form, and calls row with the fields of every record: those of the required columns,
+// then those of the optional ones, empty when the header lacks them. Columns are found
+// by their names in the header, in any order and letter case.
+func readCSV(ctx *gin.Context, required, optional []string, row func(fields []string) error) error {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+	var body io.Reader = ctx.Request.Body
+	if strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return errors.New("the form has no file")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		body = file
+	}
+
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return errors.New("the file is empty")
+	}
+	if err != nil {
+		return err
+	}
+	// Spreadsheets may start the file with a byte order mark
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+	positions := make([]int, 0, len(required)+len(optional))
+	for _, column := range append(slices.Clone(required), optional...) {
+		position := slices.Index(header, column)
+		if position < 0 && slices.Contains(required, column) {
+			return fmt.Errorf("the file has no %s column", column)
+		}
+		positions = append(positions, position)
+	}
+
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fields := make([]string, len(positions))
+		for i, position := range positions {
+			if position >= 0 {
+				fields[i] = record[position]
+			}
+		}
+		if err := row(fields); err != nil {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		rows++
+	}
+	if rows == 0 {
+		return errors.New("the file has no rows")
+	}
+	return nil
+}
+
+// Mappers
+func interactionToResponseMapper(warning *medicineDomain.InteractionWarning) *ResponseInteraction {
+	return &ResponseInteraction{
+		InteractionID: warning.Interaction.ID,
+		First:         warning.Interaction.First.String(),
+		Second:        warning.Interaction.Second.String(),
+		Severity:      string(warning.Interaction.Severity),
+		Description:   warning.Interaction.Description,
+		Medicines: [2]ResponseInteractionMedicine{
+			partyToResponseMapper(&warning.First),
+			partyToResponseMapper(&warning.Second),
+		},
+	}
+}
+
+func partyToResponseMapper(party *medicineDomain.InteractionParty) ResponseInteractionMedicine {
+	return ResponseInteractionMedicine{
+		ID:          party.MedicineID,
+		Name:        party.Medicine,
+		Ingredients: party.Ingredients,
+	}
+}
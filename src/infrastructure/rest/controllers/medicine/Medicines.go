@@ -20,8 +20,32 @@ import (
 type NewMedicineRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description" binding:"required"`
-	Laboratory  string `json:"laboratory" binding:"required"`
 	EanCode     string `json:"eanCode" binding:"required"`
+	// LaboratoryID links the medicine to a laboratory; without it, Laboratory names
+	// one, which is registered when no spelling of it is known yet
+	LaboratoryID *int   `json:"laboratoryId" binding:"omitempty,gt=0"`
+	Laboratory   string `json:"laboratory" binding:"required_without=LaboratoryID"`
+	// Ingredients, DosageForm, Route, PackageSize and Price, the price of one package,
+	// may be left out while unknown
+	Ingredients []IngredientRequest `json:"ingredients" binding:"omitempty,dive"`
+	DosageForm  string              `json:"dosageForm"`
+	Route       string              `json:"route"`
+	PackageSize *PackageSizeRequest `json:"packageSize"`
+	Price       *float64            `json:"price" binding:"omitempty,gte=0"`
+}
+
+// IngredientRequest is an active ingredient with its strength, such as 500 mg or
+// 50 mg/mL
+type IngredientRequest struct {
+	Name     string  `json:"name" binding:"required"`
+	Strength float64 `json:"strength" binding:"required,gt=0"`
+	Unit     string  `json:"unit" binding:"required"`
+}
+
+// PackageSizeRequest is the quantity in one package, in units such as tablets or in mL
+type PackageSizeRequest struct {
+	Quantity float64 `json:"quantity" binding:"required,gt=0"`
+	Unit     string  `json:"unit" binding:"required"`
 }
 
 type DataMedicineRequest struct {
@@ -34,13 +58,49 @@ type DataMedicineRequest struct {
 }
 
 type ResponseMedicine struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	EanCode     string    `json:"eanCode"`
-	Laboratory  string    `json:"laboratory"`
-	CreatedAt   time.Time `json:"createdAt,omitempty"`
-	UpdatedAt   time.Time `json:"updatedAt,omitempty"`
+	ID           int    `json:"id"`
+	TenantID     int    `json:"tenantId"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	EanCode      string `json:"eanCode"`
+	LaboratoryID *int   `json:"laboratoryId"`
+	Laboratory   string `json:"laboratory"`
+	// Ingredients is always a list; DosageForm and Route are empty, and PackageSize and
+	// Price null, while unknown
+	Ingredients []ResponseIngredient `json:"ingredients"`
+	DosageForm  string               `json:"dosageForm"`
+	Route       string               `json:"route"`
+	PackageSize *ResponsePackageSize `json:"packageSize"`
+	Price       *float64             `json:"price"`
+	CreatedAt   time.Time            `json:"createdAt,omitempty"`
+	UpdatedAt   time.Time            `json:"updatedAt,omitempty"`
+	DeletedAt   *time.Time           `json:"deletedAt,omitempty"`
+	CreatedBy   *int                 `json:"createdBy"`
+	UpdatedBy   *int                 `json:"updatedBy"`
+	// StockQuantity is only listed by the search, the trash and the substitutes
+	StockQuantity *int `json:"stockQuantity,omitempty"`
+}
+
+type ResponseIngredient struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Strength float64 `json:"strength"`
+	Unit     string  `json:"unit"`
+}
+
+type ResponsePackageSize struct {
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+// ResponseBarcode is a scanned code with the medicine it identifies. The lot, serial
+// number and expiry are only read from GS1 element strings.
+type ResponseBarcode struct {
+	GTIN      string            `json:"gtin"`
+	Lot       string            `json:"lot,omitempty"`
+	Serial    string            `json:"serial,omitempty"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	Medicine  *ResponseMedicine `json:"medicine"`
 }
 
 type PaginationResultMedicine struct {
@@ -61,6 +121,17 @@ type IMedicineController interface {
 	DeleteMedicine(ctx *gin.Context)
 	SearchPaginated(ctx *gin.Context)
 	SearchByProperty(ctx *gin.Context)
+	GetTrash(ctx *gin.Context)
+	RestoreMedicine(ctx *gin.Context)
+	GetMedicineHistory(ctx *gin.Context)
+	GetMedicineByBarcode(ctx *gin.Context)
+	GetMedicineSubstitutes(ctx *gin.Context)
+	GetMedicineEquivalences(ctx *gin.Context)
+	SetMedicineEquivalence(ctx *gin.Context)
+	DeleteEquivalence(ctx *gin.Context)
+	ImportInteractions(ctx *gin.Context)
+	ImportIngredientClasses(ctx *gin.Context)
+	CheckInteractions(ctx *gin.Context)
 }
 
 type Controller struct {
@@ -82,12 +153,20 @@ func (c *Controller) NewMedicine(ctx *gin.Context) {
 		return
 	}
 	newMed := medicineDomain.Medicine{
-		Name:        request.Name,
-		Description: request.Description,
-		Laboratory:  request.Laboratory,
-		EanCode:     request.EanCode,
+		Name:         request.Name,
+		Description:  request.Description,
+		LaboratoryID: request.LaboratoryID,
+		Laboratory:   request.Laboratory,
+		EanCode:      request.EanCode,
+		Ingredients:  ingredientsRequestMapper(request.Ingredients),
+		DosageForm:   request.DosageForm,
+		Route:        request.Route,
+		Price:        request.Price,
 	}
-	dMed, err := c.medicineService.Create(&newMed)
+	if request.PackageSize != nil {
+		newMed.PackageSize = &medicineDomain.PackageSize{Quantity: request.PackageSize.Quantity, Unit: request.PackageSize.Unit}
+	}
+	dMed, err := c.medicineService.Create(ctx.Request.Context(), &newMed)
 	if err != nil {
 		c.Logger.Error("Error creating medicine", zap.Error(err), zap.String("name", request.Name))
 		_ = ctx.Error(err)
@@ -100,7 +179,7 @@ func (c *Controller) NewMedicine(ctx *gin.Context) {
 
 func (c *Controller) GetAllMedicines(ctx *gin.Context) {
 	c.Logger.Info("Getting all medicines")
-	medicines, err := c.medicineService.GetAll()
+	medicines, err := c.medicineService.GetAll(ctx.Request.Context())
 	if err != nil {
 		c.Logger.Error("Error getting all medicines", zap.Error(err))
 		appError := domainError.NewAppErrorWithType(domainError.UnknownError)
@@ -119,8 +198,20 @@ func (c *Controller) GetMedicinesByID(ctx *gin.Context) {
 		_ = ctx.Error(appError)
 		return
 	}
+	asOf, err := controllers.ParseAsOf(ctx.Query("asOf"))
+	if err != nil {
+		c.Logger.Error("Invalid asOf parameter", zap.Error(err), zap.String("asOf", ctx.Query("asOf")))
+		appError := domainError.NewAppError(errors.New("asOf must be an RFC3339 timestamp"), domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
 	c.Logger.Info("Getting medicine by ID", zap.Int("id", medicineID))
-	dMed, err := c.medicineService.GetByID(medicineID)
+	var dMed *medicineDomain.Medicine
+	if asOf != nil {
+		dMed, err = c.medicineService.GetAsOf(ctx.Request.Context(), medicineID, *asOf)
+	} else {
+		dMed, err = c.medicineService.GetByID(ctx.Request.Context(), medicineID)
+	}
 	if err != nil {
 		c.Logger.Error("Error getting medicine by ID", zap.Error(err), zap.Int("id", medicineID))
 		_ = ctx.Error(err)
@@ -153,7 +244,7 @@ func (c *Controller) UpdateMedicine(ctx *gin.Context) {
 		_ = ctx.Error(err)
 		return
 	}
-	updated, err := c.medicineService.Update(medicineID, requestMap)
+	updated, err := c.medicineService.Update(ctx.Request.Context(), medicineID, requestMap)
 	if err != nil {
 		c.Logger.Error("Error updating medicine", zap.Error(err), zap.Int("id", medicineID))
 		_ = ctx.Error(err)
@@ -173,7 +264,7 @@ func (c *Controller) DeleteMedicine(ctx *gin.Context) {
 		return
 	}
 	c.Logger.Info("Deleting medicine", zap.Int("id", medicineID))
-	if err = c.medicineService.Delete(medicineID); err != nil {
+	if err = c.medicineService.Delete(ctx.Request.Context(), medicineID); err != nil {
 		c.Logger.Error("Error deleting medicine", zap.Error(err), zap.Int("id", medicineID))
 		_ = ctx.Error(err)
 		return
@@ -185,6 +276,97 @@ func (c *Controller) DeleteMedicine(ctx *gin.Context) {
 func (c *Controller) SearchPaginated(ctx *gin.Context) {
 	c.Logger.Info("Searching medicines with pagination")
 
+	filters := buildDataFilters(ctx)
+
+	result, err := c.medicineService.SearchPaginated(ctx.Request.Context(), filters)
+	if err != nil {
+		c.Logger.Error("Error searching medicines", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := gin.H{
+		"data":       arrayDomainToResponseMapper(result.Data),
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+		"filters":    filters,
+	}
+
+	c.Logger.Info("Successfully searched medicines",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) GetTrash(ctx *gin.Context) {
+	c.Logger.Info("Listing deleted medicines")
+
+	filters := buildDataFilters(ctx)
+
+	result, err := c.medicineService.GetTrash(ctx.Request.Context(), filters)
+	if err != nil {
+		c.Logger.Error("Error listing deleted medicines", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := gin.H{
+		"data":       arrayDomainToResponseMapper(result.Data),
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+		"filters":    filters,
+	}
+
+	c.Logger.Info("Successfully listed deleted medicines",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) RestoreMedicine(ctx *gin.Context) {
+	medicineID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid medicine ID parameter for restore", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainError.NewAppError(errors.New("param id is necessary"), domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Restoring medicine", zap.Int("id", medicineID))
+	restored, err := c.medicineService.Restore(ctx.Request.Context(), medicineID)
+	if err != nil {
+		c.Logger.Error("Error restoring medicine", zap.Error(err), zap.Int("id", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Medicine restored successfully", zap.Int("id", medicineID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(restored))
+}
+
+func (c *Controller) GetMedicineHistory(ctx *gin.Context) {
+	medicineID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid medicine ID parameter for history", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainError.NewAppError(errors.New("medicine id is invalid"), domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Getting medicine history", zap.Int("id", medicineID))
+	entries, err := c.medicineService.GetHistory(ctx.Request.Context(), medicineID)
+	if err != nil {
+		c.Logger.Error("Error getting medicine history", zap.Error(err), zap.Int("id", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully retrieved medicine history", zap.Int("id", medicineID), zap.Int("count", len(*entries)))
+	ctx.JSON(http.StatusOK, controllers.HistoryToResponseMapper(entries))
+}
+
+// buildDataFilters reads pagination, filters and sorting from the query string
+func buildDataFilters(ctx *gin.Context) domain.DataFilters {
 	// Parse query parameters
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	if page < 1 {
@@ -208,6 +390,9 @@ func (c *Controller) SearchPaginated(ctx *gin.Context) {
 			likeFilters[field] = values
 		}
 	}
+	if values := ctx.QueryArray(medicine.IngredientField + "_like"); len(values) > 0 {
+		likeFilters[medicine.IngredientField] = values
+	}
 	filters.LikeFilters = likeFilters
 
 	// Parse exact matches
@@ -217,6 +402,14 @@ func (c *Controller) SearchPaginated(ctx *gin.Context) {
 			matches[field] = values
 		}
 	}
+	if values := ctx.QueryArray(medicine.IngredientField + "_match"); len(values) > 0 {
+		matches[medicine.IngredientField] = values
+	}
+	for field := range medicine.ComputedMedicineColumns {
+		if values := ctx.QueryArray(field + "_match"); len(values) > 0 {
+			matches[field] = values
+		}
+	}
 	filters.Matches = matches
 
 	// Parse date range filters
@@ -245,6 +438,22 @@ func (c *Controller) SearchPaginated(ctx *gin.Context) {
 	}
 	filters.DateRangeFilters = dateRanges
 
+	// Parse number range filters
+	var numberRanges []domain.NumberRangeFilter
+	for field := range medicine.ComputedMedicineColumns {
+		numberRange := domain.NumberRangeFilter{Field: field}
+		if minValue, err := strconv.Atoi(ctx.Query(field + "_min")); err == nil {
+			numberRange.Min = &minValue
+		}
+		if maxValue, err := strconv.Atoi(ctx.Query(field + "_max")); err == nil {
+			numberRange.Max = &maxValue
+		}
+		if numberRange.Min != nil || numberRange.Max != nil {
+			numberRanges = append(numberRanges, numberRange)
+		}
+	}
+	filters.NumberRangeFilters = numberRanges
+
 	// Parse sorting
 	sortBy := ctx.QueryArray("sortBy")
 	if len(sortBy) > 0 {
@@ -256,26 +465,7 @@ func (c *Controller) SearchPaginated(ctx *gin.Context) {
 		filters.SortDirection = sortDirection
 	}
 
-	result, err := c.medicineService.SearchPaginated(filters)
-	if err != nil {
-		c.Logger.Error("Error searching medicines", zap.Error(err))
-		_ = ctx.Error(err)
-		return
-	}
-
-	response := gin.H{
-		"data":       arrayDomainToResponseMapper(result.Data),
-		"total":      result.Total,
-		"page":       result.Page,
-		"pageSize":   result.PageSize,
-		"totalPages": result.TotalPages,
-		"filters":    filters,
-	}
-
-	c.Logger.Info("Successfully searched medicines",
-		zap.Int64("total", result.Total),
-		zap.Int("page", result.Page))
-	ctx.JSON(http.StatusOK, response)
+	return filters
 }
 
 func (c *Controller) SearchByProperty(ctx *gin.Context) {
@@ -295,6 +485,7 @@ func (c *Controller) SearchByProperty(ctx *gin.Context) {
 		"description": true,
 		"eanCode":     true,
 		"laboratory":  true,
+		"ingredient":  true,
 	}
 	if !allowed[property] {
 		c.Logger.Error("Invalid property for search", zap.String("property", property))
@@ -303,7 +494,7 @@ func (c *Controller) SearchByProperty(ctx *gin.Context) {
 		return
 	}
 
-	coincidences, err := c.medicineService.SearchByProperty(property, searchText)
+	coincidences, err := c.medicineService.SearchByProperty(ctx.Request.Context(), property, searchText)
 	if err != nil {
 		c.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
 		_ = ctx.Error(err)
@@ -316,16 +507,48 @@ func (c *Controller) SearchByProperty(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, coincidences)
 }
 
+// GetMedicineByBarcode returns the medicine a scanner read, from an EAN-8, UPC-A,
+// EAN-13 or GTIN-14, or from the GS1 element string of a DataMatrix
+func (c *Controller) GetMedicineByBarcode(ctx *gin.Context) {
+	code := ctx.Param("code")
+	c.Logger.Info("Getting medicine by barcode", zap.String("code", code))
+	dMed, barcode, err := c.medicineService.GetByBarcode(ctx.Request.Context(), code)
+	if err != nil {
+		c.Logger.Error("Error getting medicine by barcode", zap.Error(err), zap.String("code", code))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully retrieved medicine by barcode", zap.Int("id", dMed.ID), zap.String("gtin", barcode.GTIN))
+	ctx.JSON(http.StatusOK, ResponseBarcode{
+		GTIN:      barcode.GTIN,
+		Lot:       barcode.Lot,
+		Serial:    barcode.Serial,
+		ExpiresAt: barcode.ExpiresAt,
+		Medicine:  domainToResponseMapper(dMed),
+	})
+}
+
 // Mappers
 func domainToResponseMapper(m *medicineDomain.Medicine) *ResponseMedicine {
 	return &ResponseMedicine{
-		ID:          m.ID,
-		Name:        m.Name,
-		Description: m.Description,
-		EanCode:     m.EanCode,
-		Laboratory:  m.Laboratory,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
+		ID:            m.ID,
+		TenantID:      m.TenantID,
+		Name:          m.Name,
+		Description:   m.Description,
+		EanCode:       m.EanCode,
+		LaboratoryID:  m.LaboratoryID,
+		Laboratory:    m.Laboratory,
+		Ingredients:   ingredientsToResponseMapper(m.Ingredients),
+		DosageForm:    m.DosageForm,
+		Route:         m.Route,
+		PackageSize:   packageSizeToResponseMapper(m.PackageSize),
+		Price:         m.Price,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+		DeletedAt:     m.DeletedAt,
+		CreatedBy:     m.CreatedBy,
+		UpdatedBy:     m.UpdatedBy,
+		StockQuantity: m.StockQuantity,
 	}
 }
 
@@ -336,3 +559,26 @@ func arrayDomainToResponseMapper(m *[]medicineDomain.Medicine) *[]ResponseMedici
 	}
 	return &res
 }
+
+func ingredientsRequestMapper(requests []IngredientRequest) []medicineDomain.Ingredient {
+	ingredients := make([]medicineDomain.Ingredient, len(requests))
+	for i, request := range requests {
+		ingredients[i] = medicineDomain.Ingredient{Name: request.Name, Strength: request.Strength, Unit: request.Unit}
+	}
+	return ingredients
+}
+
+func ingredientsToResponseMapper(ingredients []medicineDomain.Ingredient) []ResponseIngredient {
+	res := make([]ResponseIngredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		res[i] = ResponseIngredient(ingredient)
+	}
+	return res
+}
+
+func packageSizeToResponseMapper(size *medicineDomain.PackageSize) *ResponsePackageSize {
+	if size == nil {
+		return nil
+	}
+	return &ResponsePackageSize{Quantity: size.Quantity, Unit: size.Unit}
+}
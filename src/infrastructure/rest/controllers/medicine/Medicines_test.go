@@ -2,12 +2,19 @@ package medicine
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
+	domainError "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
 	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gin-gonic/gin"
@@ -19,18 +26,30 @@ type MockMedicineService struct {
 	getAllFunc  func() ([]*medicineDomain.Medicine, error)
 	getDataFunc func(int64, int64, string, string, map[string][]string, string, []domain.DateRangeFilter) (*medicineDomain.DataMedicine, error)
 	getByIDFunc func(int) (*medicineDomain.Medicine, error)
+	barcodeFunc func(string) (*medicineDomain.Medicine, *medicineDomain.Barcode, error)
 	updateFunc  func(int, map[string]any) (*medicineDomain.Medicine, error)
 	deleteFunc  func(int) error
+	trashFunc   func(domain.DataFilters) (*medicineDomain.SearchResultMedicine, error)
+	restoreFunc func(int) (*medicineDomain.Medicine, error)
+
+	getHistoryFunc func(int) (*[]domainHistory.Entry, error)
+	getAsOfFunc    func(int, time.Time) (*medicineDomain.Medicine, error)
+
+	substitutesFunc    func(int) (*[]medicineDomain.Substitute, error)
+	setEquivalenceFunc func(*medicineDomain.Equivalence) (*medicineDomain.Equivalence, error)
+
+	importInteractionsFunc func([]medicineDomain.Interaction) (int, error)
+	checkInteractionsFunc  func([]int) (*[]medicineDomain.InteractionWarning, error)
 }
 
-func (m *MockMedicineService) Create(medicine *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+func (m *MockMedicineService) Create(_ context.Context, medicine *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
 	if m.createFunc != nil {
 		return m.createFunc(medicine)
 	}
 	return nil, nil
 }
 
-func (m *MockMedicineService) GetAll() (*[]medicineDomain.Medicine, error) {
+func (m *MockMedicineService) GetAll(_ context.Context) (*[]medicineDomain.Medicine, error) {
 	if m.getAllFunc != nil {
 		result, err := m.getAllFunc()
 		if result == nil {
@@ -53,21 +72,28 @@ func (m *MockMedicineService) GetData(page, limit int64, sortBy, sortDirection s
 	return nil, nil
 }
 
-func (m *MockMedicineService) GetByID(id int) (*medicineDomain.Medicine, error) {
+func (m *MockMedicineService) GetByID(_ context.Context, id int) (*medicineDomain.Medicine, error) {
 	if m.getByIDFunc != nil {
 		return m.getByIDFunc(id)
 	}
 	return nil, nil
 }
 
-func (m *MockMedicineService) Update(id int, updates map[string]any) (*medicineDomain.Medicine, error) {
+func (m *MockMedicineService) GetByBarcode(_ context.Context, code string) (*medicineDomain.Medicine, *medicineDomain.Barcode, error) {
+	if m.barcodeFunc != nil {
+		return m.barcodeFunc(code)
+	}
+	return nil, nil, nil
+}
+
+func (m *MockMedicineService) Update(_ context.Context, id int, updates map[string]any) (*medicineDomain.Medicine, error) {
 	if m.updateFunc != nil {
 		return m.updateFunc(id, updates)
 	}
 	return nil, nil
 }
 
-func (m *MockMedicineService) Delete(id int) error {
+func (m *MockMedicineService) Delete(_ context.Context, id int) error {
 	if m.deleteFunc != nil {
 		return m.deleteFunc(id)
 	}
@@ -78,14 +104,86 @@ func (m *MockMedicineService) GetByMap(medicineMap map[string]any) (*medicineDom
 	return nil, nil
 }
 
-func (m *MockMedicineService) SearchPaginated(filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+func (m *MockMedicineService) SearchPaginated(_ context.Context, filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+	return nil, nil
+}
+
+func (m *MockMedicineService) SearchByProperty(_ context.Context, property string, searchText string) (*[]string, error) {
+	return nil, nil
+}
+
+func (m *MockMedicineService) GetTrash(_ context.Context, filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+	if m.trashFunc != nil {
+		return m.trashFunc(filters)
+	}
 	return nil, nil
 }
 
-func (m *MockMedicineService) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (m *MockMedicineService) Restore(_ context.Context, id int) (*medicineDomain.Medicine, error) {
+	if m.restoreFunc != nil {
+		return m.restoreFunc(id)
+	}
 	return nil, nil
 }
 
+func (m *MockMedicineService) Purge(_ context.Context, olderThan time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockMedicineService) GetHistory(_ context.Context, id int) (*[]domainHistory.Entry, error) {
+	if m.getHistoryFunc != nil {
+		return m.getHistoryFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *MockMedicineService) GetAsOf(_ context.Context, id int, at time.Time) (*medicineDomain.Medicine, error) {
+	if m.getAsOfFunc != nil {
+		return m.getAsOfFunc(id, at)
+	}
+	return nil, nil
+}
+
+func (m *MockMedicineService) GetSubstitutes(_ context.Context, id int) (*[]medicineDomain.Substitute, error) {
+	if m.substitutesFunc != nil {
+		return m.substitutesFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *MockMedicineService) GetEquivalences(_ context.Context, id int) (*[]medicineDomain.Equivalence, error) {
+	return &[]medicineDomain.Equivalence{}, nil
+}
+
+func (m *MockMedicineService) SetEquivalence(_ context.Context, equivalence *medicineDomain.Equivalence) (*medicineDomain.Equivalence, error) {
+	if m.setEquivalenceFunc != nil {
+		return m.setEquivalenceFunc(equivalence)
+	}
+	return nil, nil
+}
+
+func (m *MockMedicineService) DeleteEquivalence(_ context.Context, id int) error {
+	return nil
+}
+
+func (m *MockMedicineService) ImportInteractions(_ context.Context, interactions []medicineDomain.Interaction) (int, error) {
+	if m.importInteractionsFunc != nil {
+		return m.importInteractionsFunc(interactions)
+	}
+	return len(interactions), nil
+}
+
+func (m *MockMedicineService) ImportIngredientClasses(_ context.Context, classes []medicineDomain.IngredientClass) (int, error) {
+	return len(classes), nil
+}
+
+func (m *MockMedicineService) CheckInteractions(_ context.Context, ids []int) (*[]medicineDomain.InteractionWarning, error) {
+	if m.checkInteractionsFunc != nil {
+		return m.checkInteractionsFunc(ids)
+	}
+	return &[]medicineDomain.InteractionWarning{}, nil
+}
+
 func setupLogger(t *testing.T) *logger.Logger {
 	loggerInstance, err := logger.NewLogger()
 	if err != nil {
@@ -180,6 +278,103 @@ func TestController_NewMedicine_InvalidRequest(t *testing.T) {
 	}
 }
 
+func TestController_NewMedicine_LaboratoryID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received *medicineDomain.Medicine
+	mockService := &MockMedicineService{
+		createFunc: func(medicine *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+			received = medicine
+			medicine.ID = 1
+			medicine.Laboratory = "Pfizer"
+			return medicine, nil
+		},
+	}
+	controller := NewMedicineController(mockService, setupLogger(t))
+
+	// A laboratory ID stands in for the laboratory name
+	requestBody := []byte(`{"name": "Test Medicine", "description": "Test Description", "eanCode": "4006381333931", "laboratoryId": 3}`)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/medicines", bytes.NewBuffer(requestBody))
+	c.Request.Header.Set("Content-Type", "application/json")
+	controller.NewMedicine(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if received.LaboratoryID == nil || *received.LaboratoryID != 3 {
+		t.Errorf("Expected laboratory 3 to be passed on, got %v", received.LaboratoryID)
+	}
+	var response ResponseMedicine
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.LaboratoryID == nil || *response.LaboratoryID != 3 || response.Laboratory != "Pfizer" {
+		t.Errorf("Expected laboratory 3 named Pfizer, got %v %s", response.LaboratoryID, response.Laboratory)
+	}
+
+	// Without either the request is rejected
+	requestBody = []byte(`{"name": "Test Medicine", "description": "Test Description", "eanCode": "4006381333931"}`)
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/medicines", bytes.NewBuffer(requestBody))
+	c.Request.Header.Set("Content-Type", "application/json")
+	controller.NewMedicine(c)
+
+	if len(c.Errors) == 0 {
+		t.Error("Expected error to be added to context")
+	}
+}
+
+func TestController_NewMedicine_Composition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received *medicineDomain.Medicine
+	mockService := &MockMedicineService{
+		createFunc: func(medicine *medicineDomain.Medicine) (*medicineDomain.Medicine, error) {
+			received = medicine
+			medicine.ID = 1
+			medicine.Ingredients[0].ID = 5
+			return medicine, nil
+		},
+	}
+	controller := NewMedicineController(mockService, setupLogger(t))
+
+	requestBody := []byte(`{"name": "Amoxil", "description": "Antibiotic", "eanCode": "4006381333931", "laboratory": "GSK",
+		"ingredients": [{"name": "Amoxicillin", "strength": 50, "unit": "mg/mL"}],
+		"dosageForm": "suspension", "route": "oral", "packageSize": {"quantity": 100, "unit": "mL"}}`)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/medicines", bytes.NewBuffer(requestBody))
+	c.Request.Header.Set("Content-Type", "application/json")
+	controller.NewMedicine(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if received.DosageForm != "suspension" || received.Route != "oral" || *received.PackageSize != (medicineDomain.PackageSize{Quantity: 100, Unit: "mL"}) {
+		t.Errorf("Expected the composition to be passed on, got %+v", received)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"ingredients":[{"id":5,"name":"Amoxicillin","strength":50,"unit":"mg/mL"}]`)) ||
+		!bytes.Contains(w.Body.Bytes(), []byte(`"packageSize":{"quantity":100,"unit":"mL"}`)) {
+		t.Errorf("Expected the composition in the response, got %s", w.Body.String())
+	}
+
+	// Ingredients need a positive strength
+	requestBody = []byte(`{"name": "Amoxil", "description": "Antibiotic", "eanCode": "4006381333931", "laboratory": "GSK",
+		"ingredients": [{"name": "Amoxicillin", "strength": 0, "unit": "mg"}]}`)
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/medicines", bytes.NewBuffer(requestBody))
+	c.Request.Header.Set("Content-Type", "application/json")
+	controller.NewMedicine(c)
+
+	if len(c.Errors) == 0 {
+		t.Error("Expected error to be added to context")
+	}
+}
+
 func TestController_GetAllMedicines_Success(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
@@ -288,6 +483,262 @@ func TestController_GetMedicinesByID_InvalidID(t *testing.T) {
 	}
 }
 
+func TestController_GetMedicineByBarcode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	expiresAt := time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC)
+	mockService := &MockMedicineService{
+		barcodeFunc: func(code string) (*medicineDomain.Medicine, *medicineDomain.Barcode, error) {
+			if code != "(01)09506000134352(17)271231(10)ABC123" {
+				return nil, nil, domainError.NewAppError(errors.New("barcode is invalid"), domainError.ValidationError)
+			}
+			return &medicineDomain.Medicine{ID: 3, Name: "Aspirin", EanCode: "09506000134352"},
+				&medicineDomain.Barcode{GTIN: "09506000134352", Lot: "ABC123", ExpiresAt: &expiresAt}, nil
+		},
+	}
+	controller := NewMedicineController(mockService, setupLogger(t))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/medicine/barcode/x", nil)
+	c.Params = gin.Params{{Key: "code", Value: "(01)09506000134352(17)271231(10)ABC123"}}
+	controller.GetMedicineByBarcode(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response ResponseBarcode
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.GTIN != "09506000134352" || response.Lot != "ABC123" || response.Medicine.ID != 3 {
+		t.Errorf("Unexpected response %+v", response)
+	}
+	if response.ExpiresAt == nil || !response.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected expiry %v, got %v", expiresAt, response.ExpiresAt)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/medicine/barcode/123", nil)
+	c.Params = gin.Params{{Key: "code", Value: "123"}}
+	controller.GetMedicineByBarcode(c)
+	if len(c.Errors) == 0 {
+		t.Error("Expected error to be added to context")
+	}
+}
+
+func TestController_GetMedicineSubstitutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	price, stock := 12.0, 4
+	mockService := &MockMedicineService{
+		substitutesFunc: func(id int) (*[]medicineDomain.Substitute, error) {
+			if id != 3 {
+				return nil, domainError.NewAppErrorWithType(domainError.NotFound)
+			}
+			return &[]medicineDomain.Substitute{{
+				Medicine: medicineDomain.Medicine{
+					ID: 8, Name: "Motrin", Price: &price, StockQuantity: &stock,
+					PackageSize: &medicineDomain.PackageSize{Quantity: 24, Unit: "unit"},
+				},
+				Match: medicineDomain.SubstituteManual,
+				Note:  "same class",
+			}}, nil
+		},
+	}
+	controller := NewMedicineController(mockService, setupLogger(t))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/medicine/3/substitutes", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	controller.GetMedicineSubstitutes(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response) != 1 || response[0]["id"] != float64(8) || response[0]["match"] != "manual" || response[0]["note"] != "same class" {
+		t.Errorf("Unexpected response %v", response)
+	}
+	if response[0]["unitPrice"] != 0.5 || response[0]["price"] != 12.0 || response[0]["stockQuantity"] != float64(4) {
+		t.Errorf("Expected the prices and stock of the substitute, got %v", response[0])
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/medicine/x/substitutes", nil)
+	c.Params = gin.Params{{Key: "id", Value: "x"}}
+	controller.GetMedicineSubstitutes(c)
+	if len(c.Errors) == 0 {
+		t.Error("Expected error to be added to context")
+	}
+}
+
+func TestController_SetMedicineEquivalence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var received *medicineDomain.Equivalence
+	mockService := &MockMedicineService{
+		setEquivalenceFunc: func(equivalence *medicineDomain.Equivalence) (*medicineDomain.Equivalence, error) {
+			received = equivalence
+			saved := *equivalence
+			saved.ID = 11
+			return &saved, nil
+		},
+	}
+	controller := NewMedicineController(mockService, setupLogger(t))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/v1/medicine/3/equivalences",
+		bytes.NewBufferString(`{"substituteId": 8, "kind": "excluded", "note": "different excipients"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	controller.SetMedicineEquivalence(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	expected := medicineDomain.Equivalence{MedicineID: 3, SubstituteID: 8, Kind: medicineDomain.EquivalenceExcluded, Note: "different excipients"}
+	if received == nil || *received != expected {
+		t.Errorf("Expected %+v, got %+v", expected, received)
+	}
+	var response ResponseEquivalence
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.ID != 11 || response.Kind != "excluded" {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	received = nil
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/v1/medicine/3/equivalences", bytes.NewBufferString(`{"substituteId": 8, "kind": "similar"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	controller.SetMedicineEquivalence(c)
+	if len(c.Errors) == 0 || received != nil {
+		t.Error("Expected an unknown kind to be rejected")
+	}
+}
+
+func TestController_ImportInteractions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var received []medicineDomain.Interaction
+	mockService := &MockMedicineService{
+		importInteractionsFunc: func(interactions []medicineDomain.Interaction) (int, error) {
+			received = interactions
+			return len(interactions), nil
+		},
+	}
+	controller := NewMedicineController(mockService, setupLogger(t))
+	importCSV := func(request *http.Request) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = request
+		controller.ImportInteractions(c)
+		return w, c
+	}
+
+	// Spreadsheets may add a byte order mark and order the columns their own way
+	body := "\ufeffSeverity,First,Second,Description\n" +
+		"major,Warfarin,class:NSAIDs,\"Increases the risk of bleeding, mostly gastrointestinal\"\n" +
+		"minor, Paracetamol ,Warfarin,\n"
+	request := httptest.NewRequest(http.MethodPost, "/v1/medicine/interactions/import", bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "text/csv")
+	w, _ := importCSV(request)
+	if w.Code != http.StatusOK || w.Body.String() != `{"imported":2}` {
+		t.Fatalf("Expected two interactions imported, got %d %s", w.Code, w.Body.String())
+	}
+	if len(received) != 2 || received[0].Second != (medicineDomain.InteractionSubject{Name: "NSAIDs", Class: true}) ||
+		received[0].Description != "Increases the risk of bleeding, mostly gastrointestinal" || received[1].First.Name != "Paracetamol" {
+		t.Errorf("Unexpected interactions %+v", received)
+	}
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	file, _ := writer.CreateFormFile("file", "interactions.csv")
+	_, _ = file.Write([]byte("first,second,severity\nWarfarin,Amiodarone,contraindicated\n"))
+	_ = writer.Close()
+	request = httptest.NewRequest(http.MethodPost, "/v1/medicine/interactions/import", &form)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	if w, _ := importCSV(request); w.Code != http.StatusOK || received[0].Severity != medicineDomain.SeverityContraindicated {
+		t.Errorf("Expected the file of the form to be imported, got %d %+v", w.Code, received)
+	}
+
+	invalid := map[string]string{
+		"no severity column":  "first,second\nWarfarin,Aspirin\n",
+		"no rows":             "first,second,severity\n",
+		"an empty file":       "",
+		"an unknown severity": "first,second,severity\nWarfarin,Aspirin,major\nWarfarin,Ibuprofen,severe\n",
+	}
+	for name, body := range invalid {
+		received = nil
+		_, c := importCSV(httptest.NewRequest(http.MethodPost, "/v1/medicine/interactions/import", bytes.NewBufferString(body)))
+		if len(c.Errors) == 0 || received != nil {
+			t.Errorf("Expected a file with %s to be rejected", name)
+		}
+	}
+	_, c := importCSV(httptest.NewRequest(http.MethodPost, "/v1/medicine/interactions/import",
+		bytes.NewBufferString("first,second,severity\nWarfarin,Aspirin,major\nWarfarin,Ibuprofen,severe\n")))
+	if len(c.Errors) == 0 || !strings.HasPrefix(c.Errors[0].Error(), "line 3:") {
+		t.Errorf("Expected the error to name the line, got %v", c.Errors)
+	}
+}
+
+func TestController_CheckInteractions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var received []int
+	mockService := &MockMedicineService{
+		checkInteractionsFunc: func(ids []int) (*[]medicineDomain.InteractionWarning, error) {
+			received = ids
+			return &[]medicineDomain.InteractionWarning{{
+				Interaction: medicineDomain.Interaction{
+					ID:          4,
+					First:       medicineDomain.InteractionSubject{Name: "Warfarin"},
+					Second:      medicineDomain.InteractionSubject{Name: "NSAIDs", Class: true},
+					Severity:    medicineDomain.SeverityMajor,
+					Description: "Increases the risk of bleeding",
+				},
+				First:  medicineDomain.InteractionParty{MedicineID: 2, Medicine: "Coumadin", Ingredients: []string{"Warfarin"}},
+				Second: medicineDomain.InteractionParty{MedicineID: 1, Medicine: "Advil", Ingredients: []string{"Ibuprofen"}},
+			}}, nil
+		},
+	}
+	controller := NewMedicineController(mockService, setupLogger(t))
+	check := func(body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/medicine/interactions/check", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		controller.CheckInteractions(c)
+		return w, c
+	}
+
+	w, _ := check(`{"medicineIds": [1, 2]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response []ResponseInteraction
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(received) != 2 || len(response) != 1 || response[0].Second != "class:NSAIDs" || response[0].Severity != "major" ||
+		response[0].Medicines[0].Name != "Coumadin" || response[0].Medicines[1].Ingredients[0] != "Ibuprofen" {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	for _, body := range []string{`{"medicineIds": []}`, `{"medicineIds": [1, 0]}`, `{}`} {
+		received = nil
+		if _, c := check(body); len(c.Errors) == 0 || received != nil {
+			t.Errorf("Expected %s to be rejected", body)
+		}
+	}
+}
+
 func TestController_UpdateMedicine_Success(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
@@ -397,3 +848,237 @@ func TestController_DeleteMedicine_InvalidID(t *testing.T) {
 		t.Error("Expected error to be added to context")
 	}
 }
+
+func TestController_GetTrash_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deletedAt := time.Now()
+	var receivedFilters domain.DataFilters
+	mockService := &MockMedicineService{
+		trashFunc: func(filters domain.DataFilters) (*medicineDomain.SearchResultMedicine, error) {
+			receivedFilters = filters
+			return &medicineDomain.SearchResultMedicine{
+				Data:       &[]medicineDomain.Medicine{{ID: 1, Name: "Deleted", DeletedAt: &deletedAt}},
+				Total:      1,
+				Page:       1,
+				PageSize:   10,
+				TotalPages: 1,
+			}, nil
+		},
+	}
+
+	logger := setupLogger(t)
+	controller := NewMedicineController(mockService, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/medicine/trash?name_like=Del&sortBy=deletedAt&sortDirection=desc", nil)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.GetTrash(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if receivedFilters.LikeFilters["name"][0] != "Del" {
+		t.Errorf("Expected name like filter to be passed, got %v", receivedFilters.LikeFilters)
+	}
+	if receivedFilters.SortDirection != domain.SortDesc || receivedFilters.SortBy[0] != "deletedAt" {
+		t.Errorf("Expected sort by deletedAt desc, got %v %v", receivedFilters.SortBy, receivedFilters.SortDirection)
+	}
+	var response map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	data := response["data"].([]any)
+	if _, ok := data[0].(map[string]any)["deletedAt"]; !ok {
+		t.Error("Expected deletedAt in trash response")
+	}
+}
+
+func TestDomainToResponseMapper_AuditColumns(t *testing.T) {
+	createdBy, updatedBy := 3, 4
+	response := domainToResponseMapper(&medicineDomain.Medicine{ID: 1, CreatedBy: &createdBy, UpdatedBy: &updatedBy})
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	if !bytes.Contains(body, []byte(`"createdBy":3`)) || !bytes.Contains(body, []byte(`"updatedBy":4`)) {
+		t.Errorf("Expected audit columns in response, got %s", body)
+	}
+}
+
+func TestBuildDataFilters_Ingredient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/medicine/search?ingredient_match=Ibuprofen&ingredient_like=amoxi&dosageForm_match=tablet", nil)
+
+	filters := buildDataFilters(c)
+	if filters.Matches["ingredient"][0] != "Ibuprofen" || filters.LikeFilters["ingredient"][0] != "amoxi" {
+		t.Errorf("Expected the ingredient filters to be read, got %v %v", filters.Matches, filters.LikeFilters)
+	}
+	if filters.Matches["dosageForm"][0] != "tablet" {
+		t.Errorf("Expected the dosage form match to be read, got %v", filters.Matches)
+	}
+}
+
+func TestController_RestoreMedicine_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &MockMedicineService{
+		restoreFunc: func(id int) (*medicineDomain.Medicine, error) {
+			return &medicineDomain.Medicine{ID: id, Name: "Restored"}, nil
+		},
+	}
+
+	logger := setupLogger(t)
+	controller := NewMedicineController(mockService, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/medicine/1/restore", nil)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	controller.RestoreMedicine(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestController_RestoreMedicine_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &MockMedicineService{}
+	logger := setupLogger(t)
+	controller := NewMedicineController(mockService, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/medicine/invalid/restore", nil)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+	controller.RestoreMedicine(c)
+
+	if len(c.Errors) == 0 {
+		t.Error("Expected error to be added to context")
+	}
+}
+
+func TestController_GetMedicineHistory_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &MockMedicineService{
+		getHistoryFunc: func(id int) (*[]domainHistory.Entry, error) {
+			return &[]domainHistory.Entry{
+				{ID: 1, EntityID: id, Action: domainHistory.ActionCreate},
+				{ID: 2, EntityID: id, Action: domainHistory.ActionUpdate, Changes: map[string]domainHistory.FieldChange{
+					"name": {Before: "Aspirin", After: "Aspirin Forte"},
+				}},
+			}, nil
+		},
+	}
+
+	logger := setupLogger(t)
+	controller := NewMedicineController(mockService, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/medicine/1/history", nil)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	controller.GetMedicineHistory(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	var response []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(response))
+	}
+	change := response[1]["changes"].(map[string]any)["name"].(map[string]any)
+	if change["before"] != "Aspirin" || change["after"] != "Aspirin Forte" {
+		t.Errorf("Unexpected change: %v", change)
+	}
+}
+
+func TestController_GetMedicineHistory_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &MockMedicineService{}
+	logger := setupLogger(t)
+	controller := NewMedicineController(mockService, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/medicine/invalid/history", nil)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+	controller.GetMedicineHistory(c)
+
+	if len(c.Errors) == 0 {
+		t.Error("Expected error to be added to context")
+	}
+}
+
+func TestController_GetMedicinesByID_AsOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockService := &MockMedicineService{
+		getAsOfFunc: func(id int, asOf time.Time) (*medicineDomain.Medicine, error) {
+			if !asOf.Equal(at) {
+				t.Errorf("Expected asOf %v, got %v", at, asOf)
+			}
+			return &medicineDomain.Medicine{ID: id, Name: "Old Name"}, nil
+		},
+	}
+
+	logger := setupLogger(t)
+	controller := NewMedicineController(mockService, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/medicine/1?asOf=2024-01-01T00:00:00Z", nil)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	controller.GetMedicinesByID(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	var response ResponseMedicine
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Name != "Old Name" {
+		t.Errorf("Expected Old Name, got %s", response.Name)
+	}
+}
+
+func TestController_GetMedicinesByID_InvalidAsOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &MockMedicineService{}
+	logger := setupLogger(t)
+	controller := NewMedicineController(mockService, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/medicine/1?asOf=yesterday", nil)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	controller.GetMedicinesByID(c)
+
+	if len(c.Errors) == 0 {
+		t.Error("Expected error to be added to context")
+	}
+}
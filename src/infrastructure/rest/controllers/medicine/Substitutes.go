@@ -0,0 +1,167 @@
+package medicine
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	domainError "github.com/gbrayhan/microservices-go/src/domain/errors"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+
+// SetEquivalenceRequest marks a medicine as a substitute, with kind equivalent, or
+// excludes it from the substitutes, with kind excluded
+type SetEquivalenceRequest struct {
+	SubstituteID int    `json:"substituteId" binding:"required,gt=0"`
+	Kind         string `json:"kind" binding:"required,oneof=equivalent excluded"`
+	Note         string `json:"note" binding:"max=255"`
+}
+
+// ResponseSubstitute is a medicine that can be dispensed instead of another. Match is
+// generic for products of the same composition and manual for those marked as
+// equivalent; UnitPrice is the price of one unit of the package, null while unknown.
+type ResponseSubstitute struct {
+	ResponseMedicine
+	Match     string   `json:"match"`
+	Note      string   `json:"note,omitempty"`
+	UnitPrice *float64 `json:"unitPrice"`
+}
+
+type ResponseEquivalence struct {
+	ID           int       `json:"id"`
+	MedicineID   int       `json:"medicineId"`
+	SubstituteID int       `json:"substituteId"`
+	Kind         string    `json:"kind"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	CreatedBy    *int      `json:"createdBy"`
+	UpdatedBy    *int      `json:"updatedBy"`
+}
+
+// GetMedicineSubstitutes lists the medicines that can be dispensed instead of one, in
+// stock and cheapest first
+func (c *Controller) GetMedicineSubstitutes(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Getting medicine substitutes", zap.Int("id", medicineID))
+	substitutes, err := c.medicineService.GetSubstitutes(ctx.Request.Context(), medicineID)
+	if err != nil {
+		c.Logger.Error("Error getting medicine substitutes", zap.Error(err), zap.Int("id", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]ResponseSubstitute, len(*substitutes))
+	for i := range *substitutes {
+		response[i] = *substituteToResponseMapper(&(*substitutes)[i])
+	}
+	c.Logger.Info("Successfully retrieved medicine substitutes", zap.Int("id", medicineID), zap.Int("count", len(response)))
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetMedicineEquivalences lists the manual equivalences of a medicine
+func (c *Controller) GetMedicineEquivalences(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	equivalences, err := c.medicineService.GetEquivalences(ctx.Request.Context(), medicineID)
+	if err != nil {
+		c.Logger.Error("Error getting medicine equivalences", zap.Error(err), zap.Int("id", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]ResponseEquivalence, len(*equivalences))
+	for i := range *equivalences {
+		response[i] = *equivalenceToResponseMapper(&(*equivalences)[i])
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SetMedicineEquivalence creates or replaces the equivalence of a medicine with a substitute
+func (c *Controller) SetMedicineEquivalence(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	var request SetEquivalenceRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for medicine equivalence", zap.Error(err))
+		appError := domainError.NewAppError(err, domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	equivalence, err := c.medicineService.SetEquivalence(ctx.Request.Context(), &medicineDomain.Equivalence{
+		MedicineID:   medicineID,
+		SubstituteID: request.SubstituteID,
+		Kind:         medicineDomain.EquivalenceKind(request.Kind),
+		Note:         request.Note,
+	})
+	if err != nil {
+		c.Logger.Error("Error setting medicine equivalence", zap.Error(err), zap.Int("id", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Medicine equivalence set", zap.Int("id", equivalence.ID), zap.Int("medicineId", medicineID))
+	ctx.JSON(http.StatusOK, equivalenceToResponseMapper(equivalence))
+}
+
+func (c *Controller) DeleteEquivalence(ctx *gin.Context) {
+	equivalenceID, err := strconv.Atoi(ctx.Param("equivalenceId"))
+	if err != nil {
+		c.Logger.Error("Invalid equivalence ID parameter", zap.Error(err), zap.String("id", ctx.Param("equivalenceId")))
+		appError := domainError.NewAppError(errors.New("equivalence id is invalid"), domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	if err := c.medicineService.DeleteEquivalence(ctx.Request.Context(), equivalenceID); err != nil {
+		c.Logger.Error("Error deleting medicine equivalence", zap.Error(err), zap.Int("id", equivalenceID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Medicine equivalence deleted", zap.Int("id", equivalenceID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+func (c *Controller) medicineID(ctx *gin.Context) (int, bool) {
+	medicineID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid medicine ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainError.NewAppError(errors.New("medicine id is invalid"), domainError.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return medicineID, true
+}
+
+// Mappers
+func substituteToResponseMapper(substitute *medicineDomain.Substitute) *ResponseSubstitute {
+	return &ResponseSubstitute{
+		ResponseMedicine: *domainToResponseMapper(&substitute.Medicine),
+		Match:            string(substitute.Match),
+		Note:             substitute.Note,
+		UnitPrice:        substitute.Medicine.UnitPrice(),
+	}
+}
+
+func equivalenceToResponseMapper(equivalence *medicineDomain.Equivalence) *ResponseEquivalence {
+	return &ResponseEquivalence{
+		ID:           equivalence.ID,
+		MedicineID:   equivalence.MedicineID,
+		SubstituteID: equivalence.SubstituteID,
+		Kind:         string(equivalence.Kind),
+		Note:         equivalence.Note,
+		CreatedAt:    equivalence.CreatedAt,
+		UpdatedAt:    equivalence.UpdatedAt,
+		CreatedBy:    equivalence.CreatedBy,
+		UpdatedBy:    equivalence.UpdatedBy,
+	}
+}
@@ -0,0 +1,131 @@
+package organization
+
+import (
+	"net/http"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOrganization "github.com/gbrayhan/microservices-go/src/domain/organization"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+type NewOwnerRequest struct {
+	UserName  string `json:"user" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	FirstName string `json:"firstName" binding:"required"`
+	LastName  string `json:"lastName" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+}
+
+type NewOrganizationRequest struct {
+	Name  string          `json:"name" binding:"required"`
+	Owner NewOwnerRequest `json:"owner" binding:"required"`
+}
+
+type ResponseOrganization struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+type ResponseOwner struct {
+	ID        int    `json:"id"`
+	TenantID  int    `json:"tenantId"`
+	UserName  string `json:"user"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Status    bool   `json:"status"`
+}
+
+type ResponseNewOrganization struct {
+	Organization ResponseOrganization `json:"organization"`
+	Owner        ResponseOwner        `json:"owner"`
+}
+
+type IOrganizationController interface {
+	NewOrganization(ctx *gin.Context)
+	GetCurrentOrganization(ctx *gin.Context)
+}
+
+type OrganizationController struct {
+	organizationService domainOrganization.IOrganizationService
+	Logger              *logger.Logger
+}
+
+func NewOrganizationController(organizationService domainOrganization.IOrganizationService, loggerInstance *logger.Logger) IOrganizationController {
+	return &OrganizationController{organizationService: organizationService, Logger: loggerInstance}
+}
+
+func (c *OrganizationController) NewOrganization(ctx *gin.Context) {
+	c.Logger.Info("Creating new organization")
+	var request NewOrganizationRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new organization", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	organization, owner, err := c.organizationService.Create(ctx.Request.Context(),
+		&domainOrganization.Organization{Name: request.Name},
+		toOwnerMapper(&request.Owner))
+	if err != nil {
+		c.Logger.Error("Error creating organization", zap.Error(err), zap.String("name", request.Name))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Organization created successfully", zap.String("name", request.Name), zap.Int("id", organization.ID))
+	ctx.JSON(http.StatusOK, ResponseNewOrganization{
+		Organization: *domainToResponseMapper(organization),
+		Owner:        *ownerToResponseMapper(owner),
+	})
+}
+
+func (c *OrganizationController) GetCurrentOrganization(ctx *gin.Context) {
+	c.Logger.Info("Getting current organization")
+	organization, err := c.organizationService.GetCurrent(ctx.Request.Context())
+	if err != nil {
+		c.Logger.Error("Error getting current organization", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponseMapper(organization))
+}
+
+// Mappers
+func domainToResponseMapper(organization *domainOrganization.Organization) *ResponseOrganization {
+	return &ResponseOrganization{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		CreatedAt: organization.CreatedAt,
+		UpdatedAt: organization.UpdatedAt,
+	}
+}
+
+func ownerToResponseMapper(owner *domainUser.User) *ResponseOwner {
+	return &ResponseOwner{
+		ID:        owner.ID,
+		TenantID:  owner.TenantID,
+		UserName:  owner.UserName,
+		Email:     owner.Email,
+		FirstName: owner.FirstName,
+		LastName:  owner.LastName,
+		Status:    owner.Status,
+	}
+}
+
+func toOwnerMapper(req *NewOwnerRequest) *domainUser.User {
+	return &domainUser.User{
+		UserName:  req.UserName,
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Password:  req.Password,
+	}
+}
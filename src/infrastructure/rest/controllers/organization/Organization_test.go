@@ -0,0 +1,111 @@
+package organization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOrganization "github.com/gbrayhan/microservices-go/src/domain/organization"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOrganizationService is a mock implementation of IOrganizationService
+type MockOrganizationService struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationService) Create(_ context.Context, organization *domainOrganization.Organization, owner *domainUser.User) (*domainOrganization.Organization, *domainUser.User, error) {
+	args := m.Called(organization, owner)
+	created, _ := args.Get(0).(*domainOrganization.Organization)
+	createdOwner, _ := args.Get(1).(*domainUser.User)
+	return created, createdOwner, args.Error(2)
+}
+
+func (m *MockOrganizationService) GetByID(_ context.Context, id int) (*domainOrganization.Organization, error) {
+	args := m.Called(id)
+	organization, _ := args.Get(0).(*domainOrganization.Organization)
+	return organization, args.Error(1)
+}
+
+func (m *MockOrganizationService) GetCurrent(_ context.Context) (*domainOrganization.Organization, error) {
+	args := m.Called()
+	organization, _ := args.Get(0).(*domainOrganization.Organization)
+	return organization, args.Error(1)
+}
+
+func setupController(t *testing.T) (*MockOrganizationService, IOrganizationController) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	mockService := &MockOrganizationService{}
+	return mockService, NewOrganizationController(mockService, loggerInstance)
+}
+
+func setupGinContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	return c, w
+}
+
+func TestOrganizationController_NewOrganization(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService, controller := setupController(t)
+		c, w := setupGinContext()
+		body, _ := json.Marshal(NewOrganizationRequest{
+			Name:  "Acme Pharma",
+			Owner: NewOwnerRequest{UserName: "owner", Email: "owner@acme.com", FirstName: "Ada", LastName: "Lee", Password: "secret"},
+		})
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/organization/", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Create", &domainOrganization.Organization{Name: "Acme Pharma"}, mock.MatchedBy(func(owner *domainUser.User) bool {
+			return owner.Email == "owner@acme.com" && owner.Password == "secret"
+		})).Return(&domainOrganization.Organization{ID: 2, Name: "Acme Pharma"},
+			&domainUser.User{ID: 5, TenantID: 2, UserName: "owner", Email: "owner@acme.com", Status: true}, nil)
+
+		controller.NewOrganization(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response ResponseNewOrganization
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Organization.ID)
+		assert.Equal(t, 2, response.Owner.TenantID)
+		assert.NotContains(t, w.Body.String(), "secret")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing owner", func(t *testing.T) {
+		_, controller := setupController(t)
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/organization/", bytes.NewBufferString(`{"name":"Acme Pharma"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		controller.NewOrganization(c)
+
+		require.Len(t, c.Errors, 1)
+		appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	})
+}
+
+func TestOrganizationController_GetCurrentOrganization(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/organization/current", nil)
+	mockService.On("GetCurrent").Return(&domainOrganization.Organization{ID: 1, Name: "Default"}, nil)
+
+	controller.GetCurrentOrganization(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Default"`)
+}
@@ -0,0 +1,248 @@
+package stock
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+type SetThresholdRequest struct {
+	Location     string `json:"location"`
+	MinLevel     int    `json:"minLevel"`
+	ReorderPoint *int   `json:"reorderPoint" binding:"required"`
+	MaxLevel     int    `json:"maxLevel" binding:"required"`
+}
+
+type ResponseThreshold struct {
+	ID           int       `json:"id"`
+	MedicineID   int       `json:"medicineId"`
+	Location     string    `json:"location"`
+	MinLevel     int       `json:"minLevel"`
+	ReorderPoint int       `json:"reorderPoint"`
+	MaxLevel     int       `json:"maxLevel"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	CreatedBy    *int      `json:"createdBy"`
+	UpdatedBy    *int      `json:"updatedBy"`
+}
+
+type ResponseAlert struct {
+	ID             int        `json:"id"`
+	MedicineID     int        `json:"medicineId"`
+	Location       string     `json:"location"`
+	LotID          *int       `json:"lotId"`
+	Type           string     `json:"type"`
+	Severity       string     `json:"severity"`
+	Status         string     `json:"status"`
+	Quantity       int        `json:"quantity"`
+	Threshold      int        `json:"threshold,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	Message        string     `json:"message"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt"`
+	AcknowledgedBy *int       `json:"acknowledgedBy"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// GetMedicineThresholds returns the alert thresholds of a medicine at each location
+func (c *Controller) GetMedicineThresholds(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	thresholds, err := c.stockService.GetThresholds(ctx.Request.Context(), medicineID)
+	if err != nil {
+		c.Logger.Error("Error getting stock thresholds", zap.Error(err), zap.Int("medicineId", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]ResponseThreshold, len(*thresholds))
+	for i := range *thresholds {
+		response[i] = *thresholdToResponseMapper(&(*thresholds)[i])
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SetMedicineThreshold creates or replaces the alert threshold of a medicine at a location
+func (c *Controller) SetMedicineThreshold(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	var request SetThresholdRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for stock threshold", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	threshold, err := c.stockService.SetThreshold(ctx.Request.Context(), &stockDomain.Threshold{
+		MedicineID:   medicineID,
+		Location:     request.Location,
+		MinLevel:     request.MinLevel,
+		ReorderPoint: *request.ReorderPoint,
+		MaxLevel:     request.MaxLevel,
+	})
+	if err != nil {
+		c.Logger.Error("Error setting stock threshold", zap.Error(err), zap.Int("medicineId", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Stock threshold set", zap.Int("id", threshold.ID), zap.Int("medicineId", medicineID))
+	ctx.JSON(http.StatusOK, thresholdToResponseMapper(threshold))
+}
+
+func (c *Controller) DeleteThreshold(ctx *gin.Context) {
+	thresholdID, err := strconv.Atoi(ctx.Param("thresholdId"))
+	if err != nil {
+		c.Logger.Error("Invalid threshold ID parameter", zap.Error(err), zap.String("id", ctx.Param("thresholdId")))
+		appError := domainErrors.NewAppError(errors.New("threshold id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	if err := c.stockService.DeleteThreshold(ctx.Request.Context(), thresholdID); err != nil {
+		c.Logger.Error("Error deleting stock threshold", zap.Error(err), zap.Int("id", thresholdID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Stock threshold deleted", zap.Int("id", thresholdID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+// GetAlerts lists stock alerts, newest first. The <field>_match, createdAt_start and
+// createdAt_end query parameters narrow the list.
+func (c *Controller) GetAlerts(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	filters := domain.DataFilters{
+		Page:          page,
+		PageSize:      pageSize,
+		Matches:       map[string][]string{},
+		SortBy:        ctx.QueryArray("sortBy"),
+		SortDirection: domain.SortDirection(ctx.Query("sortDirection")),
+	}
+	for field := range stock.ColumnsAlertMapping {
+		if values := ctx.QueryArray(field + "_match"); len(values) > 0 {
+			filters.Matches[field] = values
+		}
+	}
+	dateRange := domain.DateRangeFilter{Field: "createdAt"}
+	if start, err := time.Parse(time.RFC3339, ctx.Query("createdAt_start")); err == nil {
+		dateRange.Start = &start
+	}
+	if end, err := time.Parse(time.RFC3339, ctx.Query("createdAt_end")); err == nil {
+		dateRange.End = &end
+	}
+	if dateRange.Start != nil || dateRange.End != nil {
+		filters.DateRangeFilters = []domain.DateRangeFilter{dateRange}
+	}
+
+	c.Logger.Info("Getting stock alerts")
+	result, err := c.stockService.GetAlerts(ctx.Request.Context(), filters)
+	if err != nil {
+		c.Logger.Error("Error getting stock alerts", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	data := make([]ResponseAlert, len(*result.Data))
+	for i := range *result.Data {
+		data[i] = *alertToResponseMapper(&(*result.Data)[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+	})
+}
+
+func (c *Controller) GetAlertByID(ctx *gin.Context) {
+	alertID, ok := c.alertID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Getting stock alert by ID", zap.Int("id", alertID))
+	alert, err := c.stockService.GetAlert(ctx.Request.Context(), alertID)
+	if err != nil {
+		c.Logger.Error("Error getting stock alert by ID", zap.Error(err), zap.Int("id", alertID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, alertToResponseMapper(alert))
+}
+
+// AcknowledgeAlert records that the user saw an open alert
+func (c *Controller) AcknowledgeAlert(ctx *gin.Context) {
+	alertID, ok := c.alertID(ctx)
+	if !ok {
+		return
+	}
+	alert, err := c.stockService.AcknowledgeAlert(ctx.Request.Context(), alertID)
+	if err != nil {
+		c.Logger.Error("Error acknowledging stock alert", zap.Error(err), zap.Int("id", alertID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Stock alert acknowledged", zap.Int("id", alertID))
+	ctx.JSON(http.StatusOK, alertToResponseMapper(alert))
+}
+
+func (c *Controller) alertID(ctx *gin.Context) (int, bool) {
+	alertID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid alert ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("alert id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return alertID, true
+}
+
+// Mappers
+func thresholdToResponseMapper(threshold *stockDomain.Threshold) *ResponseThreshold {
+	return &ResponseThreshold{
+		ID:           threshold.ID,
+		MedicineID:   threshold.MedicineID,
+		Location:     threshold.Location,
+		MinLevel:     threshold.MinLevel,
+		ReorderPoint: threshold.ReorderPoint,
+		MaxLevel:     threshold.MaxLevel,
+		CreatedAt:    threshold.CreatedAt,
+		UpdatedAt:    threshold.UpdatedAt,
+		CreatedBy:    threshold.CreatedBy,
+		UpdatedBy:    threshold.UpdatedBy,
+	}
+}
+
+func alertToResponseMapper(alert *stockDomain.Alert) *ResponseAlert {
+	return &ResponseAlert{
+		ID:             alert.ID,
+		MedicineID:     alert.MedicineID,
+		Location:       alert.Location,
+		LotID:          alert.LotID,
+		Type:           string(alert.Type),
+		Severity:       string(alert.Severity),
+		Status:         string(alert.Status),
+		Quantity:       alert.Quantity,
+		Threshold:      alert.Threshold,
+		ExpiresAt:      alert.ExpiresAt,
+		Message:        alert.Message,
+		AcknowledgedAt: alert.AcknowledgedAt,
+		AcknowledgedBy: alert.AcknowledgedBy,
+		ResolvedAt:     alert.ResolvedAt,
+		CreatedAt:      alert.CreatedAt,
+		UpdatedAt:      alert.UpdatedAt,
+	}
+}
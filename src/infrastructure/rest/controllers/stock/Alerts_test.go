@@ -0,0 +1,143 @@
+package stock
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStockController_SetMedicineThreshold(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPut, "/v1/medicine/5/thresholds",
+		strings.NewReader(`{"location":"front","minLevel":5,"reorderPoint":10,"maxLevel":50}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	mockService.On("SetThreshold", mock.MatchedBy(func(threshold *stockDomain.Threshold) bool {
+		return threshold.MedicineID == 5 && threshold.Location == "front" && threshold.MinLevel == 5 &&
+			threshold.ReorderPoint == 10 && threshold.MaxLevel == 50
+	})).Return(&stockDomain.Threshold{ID: 2, MedicineID: 5, Location: "front", MinLevel: 5, ReorderPoint: 10, MaxLevel: 50}, nil)
+
+	controller.SetMedicineThreshold(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ResponseThreshold
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.ID)
+	assert.Equal(t, 10, response.ReorderPoint)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_SetMedicineThresholdMissingReorderPoint(t *testing.T) {
+	_, controller := setupController(t)
+	c, _ := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPut, "/v1/medicine/5/thresholds", strings.NewReader(`{"maxLevel":50}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+	controller.SetMedicineThreshold(c)
+
+	require.Len(t, c.Errors, 1)
+	appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
+
+func TestStockController_GetMedicineThresholds(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/medicine/5/thresholds", nil)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	mockService.On("GetThresholds", 5).Return(&[]stockDomain.Threshold{{ID: 2, MedicineID: 5, Location: "main", ReorderPoint: 10, MaxLevel: 50}}, nil)
+
+	controller.GetMedicineThresholds(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []ResponseThreshold
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response, 1)
+	assert.Equal(t, "main", response[0].Location)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_DeleteThreshold(t *testing.T) {
+	mockService, controller := setupController(t)
+	mockService.On("DeleteThreshold", 2).Return(nil)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodDelete, "/v1/medicine/thresholds/2", nil)
+	c.Params = gin.Params{{Key: "thresholdId", Value: "2"}}
+
+	controller.DeleteThreshold(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_GetAlerts(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/alerts/?status_match=open&type_match=expiry", nil)
+	mockService.On("GetAlerts", mock.MatchedBy(func(filters domain.DataFilters) bool {
+		return filters.Page == 1 && filters.Matches["status"][0] == "open" && filters.Matches["type"][0] == "expiry"
+	})).Return(&stockDomain.SearchResultAlert{
+		Data:       &[]stockDomain.Alert{{ID: 3, Type: stockDomain.AlertExpiry, Severity: stockDomain.SeverityWarning, Status: stockDomain.AlertOpen}},
+		Total:      1,
+		Page:       1,
+		PageSize:   10,
+		TotalPages: 1,
+	}, nil)
+
+	controller.GetAlerts(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data  []ResponseAlert `json:"data"`
+		Total int64           `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.Total)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "expiry", response.Data[0].Type)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_AcknowledgeAlert(t *testing.T) {
+	mockService, controller := setupController(t)
+	userID := 9
+	mockService.On("AcknowledgeAlert", 3).Return(&stockDomain.Alert{ID: 3, Status: stockDomain.AlertAcknowledged, AcknowledgedBy: &userID}, nil)
+	mockService.On("AcknowledgeAlert", 4).Return(nil, domainErrors.NewAppError(errors.New("alert 4 is resolved, not open"), domainErrors.ValidationError))
+
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/alerts/3/acknowledge", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	controller.AcknowledgeAlert(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"acknowledged"`)
+	assert.Contains(t, w.Body.String(), `"acknowledgedBy":9`)
+
+	c, _ = setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/alerts/4/acknowledge", nil)
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+	controller.AcknowledgeAlert(c)
+	require.Len(t, c.Errors, 1)
+	appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+
+	c, _ = setupGinContext()
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+	controller.GetAlertByID(c)
+	require.Len(t, c.Errors, 1)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,146 @@
+package stock
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+type NewLocationRequest struct {
+	Code    string `json:"code" binding:"required"`
+	Name    string `json:"name" binding:"required"`
+	Type    string `json:"type" binding:"required"`
+	UserIDs []int  `json:"userIds"`
+}
+
+type ResponseLocation struct {
+	ID        int       `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Active    bool      `json:"active"`
+	UserIDs   []int     `json:"userIds"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	CreatedBy *int      `json:"createdBy"`
+	UpdatedBy *int      `json:"updatedBy"`
+}
+
+func (c *Controller) GetLocations(ctx *gin.Context) {
+	c.Logger.Info("Getting all locations")
+	locations, err := c.stockService.GetLocations(ctx.Request.Context())
+	if err != nil {
+		c.Logger.Error("Error getting locations", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]ResponseLocation, len(*locations))
+	for i := range *locations {
+		response[i] = *locationToResponseMapper(&(*locations)[i])
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) GetLocationByID(ctx *gin.Context) {
+	locationID, ok := c.locationID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Getting location by ID", zap.Int("id", locationID))
+	location, err := c.stockService.GetLocation(ctx.Request.Context(), locationID)
+	if err != nil {
+		c.Logger.Error("Error getting location by ID", zap.Error(err), zap.Int("id", locationID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, locationToResponseMapper(location))
+}
+
+// NewLocation registers a location. Only the users in userIds may change its stock,
+// every user when it is empty.
+func (c *Controller) NewLocation(ctx *gin.Context) {
+	c.Logger.Info("Creating new location")
+	var request NewLocationRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new location", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	created, err := c.stockService.CreateLocation(ctx.Request.Context(), &stockDomain.Location{
+		Code:    request.Code,
+		Name:    request.Name,
+		Type:    stockDomain.LocationType(request.Type),
+		UserIDs: request.UserIDs,
+	})
+	if err != nil {
+		c.Logger.Error("Error creating location", zap.Error(err), zap.String("code", request.Code))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Location created successfully", zap.Int("id", created.ID), zap.String("code", created.Code))
+	ctx.JSON(http.StatusOK, locationToResponseMapper(created))
+}
+
+// UpdateLocation changes the name, type, active flag or allowed users of a location
+func (c *Controller) UpdateLocation(ctx *gin.Context) {
+	locationID, ok := c.locationID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Updating location", zap.Int("id", locationID))
+	var requestMap map[string]any
+	if err := controllers.BindJSONMap(ctx, &requestMap); err != nil {
+		c.Logger.Error("Error binding JSON for location update", zap.Error(err), zap.Int("id", locationID))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	updated, err := c.stockService.UpdateLocation(ctx.Request.Context(), locationID, requestMap)
+	if err != nil {
+		c.Logger.Error("Error updating location", zap.Error(err), zap.Int("id", locationID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Location updated successfully", zap.Int("id", locationID))
+	ctx.JSON(http.StatusOK, locationToResponseMapper(updated))
+}
+
+func (c *Controller) locationID(ctx *gin.Context) (int, bool) {
+	locationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid location ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("location id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return locationID, true
+}
+
+// Mappers
+func locationToResponseMapper(location *stockDomain.Location) *ResponseLocation {
+	userIDs := location.UserIDs
+	if userIDs == nil {
+		userIDs = []int{}
+	}
+	return &ResponseLocation{
+		ID:        location.ID,
+		Code:      location.Code,
+		Name:      location.Name,
+		Type:      string(location.Type),
+		Active:    location.Active,
+		UserIDs:   userIDs,
+		CreatedAt: location.CreatedAt,
+		UpdatedAt: location.UpdatedAt,
+		CreatedBy: location.CreatedBy,
+		UpdatedBy: location.UpdatedBy,
+	}
+}
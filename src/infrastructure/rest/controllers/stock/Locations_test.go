@@ -0,0 +1,92 @@
+package stock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStockController_NewLocation(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/locations/",
+		strings.NewReader(`{"code":"front","name":"Front desk","type":"store","userIds":[2]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	mockService.On("CreateLocation", mock.MatchedBy(func(location *stockDomain.Location) bool {
+		return location.Code == "front" && location.Type == stockDomain.LocationStore && len(location.UserIDs) == 1
+	})).Return(&stockDomain.Location{ID: 1, Code: "front", Name: "Front desk", Type: stockDomain.LocationStore, Active: true, UserIDs: []int{2}}, nil)
+
+	controller.NewLocation(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ResponseLocation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "front", response.Code)
+	assert.Equal(t, []int{2}, response.UserIDs)
+	assert.True(t, response.Active)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_UpdateLocation(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPut, "/v1/locations/1", strings.NewReader(`{"active":false}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	mockService.On("UpdateLocation", 1, map[string]any{"active": false}).
+		Return(&stockDomain.Location{ID: 1, Code: "front", Type: stockDomain.LocationStore}, nil)
+
+	controller.UpdateLocation(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"active":false`)
+	assert.Contains(t, w.Body.String(), `"userIds":[]`)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_GetLocations(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/locations/", nil)
+	mockService.On("GetLocations").Return(&[]stockDomain.Location{{ID: 1, Code: "back"}, {ID: 2, Code: "front"}}, nil)
+
+	controller.GetLocations(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []ResponseLocation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response, 2)
+	assert.Equal(t, "front", response[1].Code)
+}
+
+func TestStockController_GetLocationByID(t *testing.T) {
+	mockService, controller := setupController(t)
+	mockService.On("GetLocation", 9).Return(nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound))
+
+	for id, expected := range map[string]domainErrors.ErrorType{
+		"abc": domainErrors.ValidationError,
+		"9":   domainErrors.NotFound,
+	} {
+		t.Run(id, func(t *testing.T) {
+			c, _ := setupGinContext()
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/locations/"+id, nil)
+			c.Params = gin.Params{{Key: "id", Value: id}}
+
+			controller.GetLocationByID(c)
+
+			require.Len(t, c.Errors, 1)
+			appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, expected, appErr.Type)
+		})
+	}
+}
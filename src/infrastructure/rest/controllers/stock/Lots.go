@@ -0,0 +1,256 @@
+package stock
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultExpiringDays is the look-ahead of GetExpiringLots without a days parameter
+const defaultExpiringDays = 30
+
+// Structures
+type ReceiveLotRequest struct {
+	LotNumber      string `json:"lotNumber" binding:"required"`
+	ManufacturedAt string `json:"manufacturedAt"`
+	ExpiresAt      string `json:"expiresAt" binding:"required"`
+	Quantity       int    `json:"quantity" binding:"required"`
+	Supplier       string `json:"supplier"`
+	Location       string `json:"location"`
+}
+
+type QuarantineLotRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type DispenseRequest struct {
+	Location string `json:"location"`
+	Quantity int    `json:"quantity" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+type ResponseLot struct {
+	ID               int        `json:"id"`
+	MedicineID       int        `json:"medicineId"`
+	LotNumber        string     `json:"lotNumber"`
+	Location         string     `json:"location"`
+	ManufacturedAt   *string    `json:"manufacturedAt"`
+	ExpiresAt        string     `json:"expiresAt"`
+	Quantity         int        `json:"quantity"`
+	Supplier         string     `json:"supplier"`
+	QuarantinedAt    *time.Time `json:"quarantinedAt"`
+	QuarantineReason string     `json:"quarantineReason,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	CreatedBy        *int       `json:"createdBy"`
+	UpdatedBy        *int       `json:"updatedBy"`
+}
+
+type ResponseAllocation struct {
+	LotID     int    `json:"lotId"`
+	LotNumber string `json:"lotNumber"`
+	ExpiresAt string `json:"expiresAt"`
+	Quantity  int    `json:"quantity"`
+}
+
+type ResponseDispensation struct {
+	MedicineID  int                  `json:"medicineId"`
+	Quantity    int                  `json:"quantity"`
+	Allocations []ResponseAllocation `json:"allocations"`
+	Level       ResponseLevel        `json:"level"`
+}
+
+// GetMedicineLots returns the lots of a medicine, first expiring first
+func (c *Controller) GetMedicineLots(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	lots, err := c.stockService.GetLots(ctx.Request.Context(), medicineID)
+	if err != nil {
+		c.Logger.Error("Error getting medicine lots", zap.Error(err), zap.Int("medicineId", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, lotArrayToResponseMapper(lots))
+}
+
+// ReceiveMedicineLot records a received lot and adds its units to stock
+func (c *Controller) ReceiveMedicineLot(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	var request ReceiveLotRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new lot", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	lot := &stockDomain.MedicineLot{
+		MedicineID: medicineID,
+		LotNumber:  request.LotNumber,
+		Quantity:   request.Quantity,
+		Supplier:   request.Supplier,
+		Location:   request.Location,
+	}
+	var err error
+	if lot.ExpiresAt, err = parseDate("expiresAt", request.ExpiresAt); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if request.ManufacturedAt != "" {
+		manufacturedAt, err := parseDate("manufacturedAt", request.ManufacturedAt)
+		if err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+		lot.ManufacturedAt = &manufacturedAt
+	}
+	created, err := c.stockService.ReceiveLot(ctx.Request.Context(), lot)
+	if err != nil {
+		c.Logger.Error("Error receiving medicine lot", zap.Error(err), zap.Int("medicineId", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Medicine lot received", zap.Int("id", created.ID), zap.Int("medicineId", medicineID))
+	ctx.JSON(http.StatusOK, lotToResponseMapper(created))
+}
+
+// GetExpiringLots returns the lots that expire within the days query parameter,
+// 30 by default
+func (c *Controller) GetExpiringLots(ctx *gin.Context) {
+	days := defaultExpiringDays
+	if value := ctx.Query("days"); value != "" {
+		var err error
+		if days, err = strconv.Atoi(value); err != nil {
+			appError := domainErrors.NewAppError(errors.New("days must be a number"), domainErrors.ValidationError)
+			_ = ctx.Error(appError)
+			return
+		}
+	}
+	lots, err := c.stockService.GetExpiringLots(ctx.Request.Context(), days)
+	if err != nil {
+		c.Logger.Error("Error getting expiring lots", zap.Error(err), zap.Int("days", days))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, lotArrayToResponseMapper(lots))
+}
+
+// QuarantineLot keeps the units of a lot from being dispensed
+func (c *Controller) QuarantineLot(ctx *gin.Context) {
+	lotID, err := strconv.Atoi(ctx.Param("lotId"))
+	if err != nil {
+		c.Logger.Error("Invalid lot ID parameter for quarantine", zap.Error(err), zap.String("lotId", ctx.Param("lotId")))
+		appError := domainErrors.NewAppError(errors.New("lot id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	var request QuarantineLotRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for lot quarantine", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	lot, err := c.stockService.QuarantineLot(ctx.Request.Context(), lotID, request.Reason)
+	if err != nil {
+		c.Logger.Error("Error quarantining lot", zap.Error(err), zap.Int("id", lotID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Lot quarantined", zap.Int("id", lotID))
+	ctx.JSON(http.StatusOK, lotToResponseMapper(lot))
+}
+
+// DispenseMedicine takes units of a medicine from its lots, first expired first out
+func (c *Controller) DispenseMedicine(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	var request DispenseRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for dispensation", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	dispensation, err := c.stockService.Dispense(ctx.Request.Context(), medicineID, request.Location, request.Quantity, request.Reason)
+	if err != nil {
+		c.Logger.Error("Error dispensing medicine", zap.Error(err), zap.Int("medicineId", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Medicine dispensed", zap.Int("medicineId", medicineID), zap.Int("quantity", request.Quantity))
+	ctx.JSON(http.StatusOK, dispensationToResponseMapper(dispensation))
+}
+
+// parseDate reads a calendar date in YYYY-MM-DD form
+func parseDate(field, value string) (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, domainErrors.NewAppError(fmt.Errorf("%s must be a date in YYYY-MM-DD format", field), domainErrors.ValidationError)
+	}
+	return date, nil
+}
+
+// Mappers
+func lotToResponseMapper(lot *stockDomain.MedicineLot) *ResponseLot {
+	response := &ResponseLot{
+		ID:               lot.ID,
+		MedicineID:       lot.MedicineID,
+		LotNumber:        lot.LotNumber,
+		Location:         lot.Location,
+		ExpiresAt:        lot.ExpiresAt.Format(time.DateOnly),
+		Quantity:         lot.Quantity,
+		Supplier:         lot.Supplier,
+		QuarantinedAt:    lot.QuarantinedAt,
+		QuarantineReason: lot.QuarantineReason,
+		CreatedAt:        lot.CreatedAt,
+		UpdatedAt:        lot.UpdatedAt,
+		CreatedBy:        lot.CreatedBy,
+		UpdatedBy:        lot.UpdatedBy,
+	}
+	if lot.ManufacturedAt != nil {
+		manufacturedAt := lot.ManufacturedAt.Format(time.DateOnly)
+		response.ManufacturedAt = &manufacturedAt
+	}
+	return response
+}
+
+func lotArrayToResponseMapper(lots *[]stockDomain.MedicineLot) []ResponseLot {
+	response := make([]ResponseLot, len(*lots))
+	for i := range *lots {
+		response[i] = *lotToResponseMapper(&(*lots)[i])
+	}
+	return response
+}
+
+func dispensationToResponseMapper(dispensation *stockDomain.Dispensation) *ResponseDispensation {
+	allocations := make([]ResponseAllocation, len(dispensation.Allocations))
+	for i, allocation := range dispensation.Allocations {
+		allocations[i] = ResponseAllocation{
+			LotID:     allocation.LotID,
+			LotNumber: allocation.LotNumber,
+			ExpiresAt: allocation.ExpiresAt.Format(time.DateOnly),
+			Quantity:  allocation.Quantity,
+		}
+	}
+	return &ResponseDispensation{
+		MedicineID:  dispensation.MedicineID,
+		Quantity:    dispensation.Quantity,
+		Allocations: allocations,
+		Level:       *levelToResponseMapper(&dispensation.Level),
+	}
+}
@@ -0,0 +1,145 @@
+package stock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func jsonRequest(c *gin.Context, target, body string) {
+	c.Request = httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+}
+
+func TestStockController_ReceiveMedicineLot(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	jsonRequest(c, "/v1/medicine/5/lots", `{"lotNumber":"L-1","manufacturedAt":"2026-01-15","expiresAt":"2027-01-31","quantity":40,"supplier":"Acme"}`)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	manufacturedAt := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+	mockService.On("ReceiveLot", &stockDomain.MedicineLot{
+		MedicineID:     5,
+		LotNumber:      "L-1",
+		ManufacturedAt: &manufacturedAt,
+		ExpiresAt:      expiresAt,
+		Quantity:       40,
+		Supplier:       "Acme",
+	}).Return(&stockDomain.MedicineLot{ID: 1, MedicineID: 5, LotNumber: "L-1", ManufacturedAt: &manufacturedAt, ExpiresAt: expiresAt, Quantity: 40}, nil)
+
+	controller.ReceiveMedicineLot(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ResponseLot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "2027-01-31", response.ExpiresAt)
+	require.NotNil(t, response.ManufacturedAt)
+	assert.Equal(t, "2026-01-15", *response.ManufacturedAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_ReceiveMedicineLotInvalidDate(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, _ := setupGinContext()
+	jsonRequest(c, "/v1/medicine/5/lots", `{"lotNumber":"L-1","expiresAt":"31/01/2027","quantity":40}`)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+	controller.ReceiveMedicineLot(c)
+
+	require.Len(t, c.Errors, 1)
+	assert.EqualError(t, c.Errors[0].Err, "expiresAt must be a date in YYYY-MM-DD format")
+	mockService.AssertNotCalled(t, "ReceiveLot", mock.Anything)
+}
+
+func TestStockController_GetExpiringLots(t *testing.T) {
+	mockService, controller := setupController(t)
+	mockService.On("GetExpiringLots", 30).Return(&[]stockDomain.MedicineLot{{ID: 1, LotNumber: "L-1"}}, nil)
+	mockService.On("GetExpiringLots", 7).Return(&[]stockDomain.MedicineLot{}, nil)
+
+	for target, expected := range map[string]int{
+		"/v1/medicine/lots/expiring":        1,
+		"/v1/medicine/lots/expiring?days=7": 0,
+	} {
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+
+		controller.GetExpiringLots(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []ResponseLot
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response, expected, target)
+	}
+	mockService.AssertExpectations(t)
+
+	c, _ := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/medicine/lots/expiring?days=soon", nil)
+	controller.GetExpiringLots(c)
+	require.Len(t, c.Errors, 1)
+}
+
+func TestStockController_QuarantineLot(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	jsonRequest(c, "/v1/medicine/lots/3/quarantine", `{"reason":"damaged packaging"}`)
+	c.Params = gin.Params{{Key: "lotId", Value: "3"}}
+	quarantinedAt := time.Now()
+	mockService.On("QuarantineLot", 3, "damaged packaging").
+		Return(&stockDomain.MedicineLot{ID: 3, QuarantinedAt: &quarantinedAt, QuarantineReason: "damaged packaging"}, nil)
+
+	controller.QuarantineLot(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"quarantineReason":"damaged packaging"`)
+	mockService.AssertExpectations(t)
+
+	c, _ = setupGinContext()
+	jsonRequest(c, "/v1/medicine/lots/abc/quarantine", `{"reason":"damaged packaging"}`)
+	c.Params = gin.Params{{Key: "lotId", Value: "abc"}}
+	controller.QuarantineLot(c)
+	require.Len(t, c.Errors, 1)
+	assert.EqualError(t, c.Errors[0].Err, "lot id is invalid")
+}
+
+func TestStockController_DispenseMedicine(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	jsonRequest(c, "/v1/medicine/5/dispense", `{"quantity":4,"reason":"prescription"}`)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	expiresAt := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	mockService.On("Dispense", 5, "", 4, "prescription").Return(&stockDomain.Dispensation{
+		MedicineID:  5,
+		Quantity:    4,
+		Allocations: []stockDomain.Allocation{{LotID: 1, LotNumber: "L-1", ExpiresAt: expiresAt, Quantity: 4}},
+		Level:       stockDomain.Level{Location: stockDomain.DefaultLocation, Quantity: 6},
+	}, nil)
+
+	controller.DispenseMedicine(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ResponseDispensation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Allocations, 1)
+	assert.Equal(t, "2026-12-31", response.Allocations[0].ExpiresAt)
+	assert.Equal(t, 6, response.Level.Quantity)
+	mockService.AssertExpectations(t)
+
+	c, _ = setupGinContext()
+	jsonRequest(c, "/v1/medicine/5/dispense", `{}`)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	controller.DispenseMedicine(c)
+	require.Len(t, c.Errors, 1)
+	appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
@@ -0,0 +1,233 @@
+package stock
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+type AdjustStockRequest struct {
+	Location string `json:"location"`
+	Delta    int    `json:"delta" binding:"required"`
+	Type     string `json:"type"`
+	Reason   string `json:"reason"`
+}
+
+type ResponseLevel struct {
+	Location  string    `json:"location"`
+	Quantity  int       `json:"quantity"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	UpdatedBy *int      `json:"updatedBy"`
+}
+
+type ResponseStock struct {
+	MedicineID int             `json:"medicineId"`
+	Total      int             `json:"total"`
+	InTransit  int             `json:"inTransit"`
+	Levels     []ResponseLevel `json:"levels"`
+}
+
+type ResponseMovement struct {
+	ID         int64     `json:"id"`
+	Location   string    `json:"location"`
+	LotID      *int      `json:"lotId"`
+	TransferID *int      `json:"transferId"`
+	Type       string    `json:"type"`
+	Quantity   int       `json:"quantity"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	CreatedBy  *int      `json:"createdBy"`
+}
+
+type IStockController interface {
+	GetMedicineStock(ctx *gin.Context)
+	AdjustMedicineStock(ctx *gin.Context)
+	GetMedicineMovements(ctx *gin.Context)
+	GetMedicineLots(ctx *gin.Context)
+	ReceiveMedicineLot(ctx *gin.Context)
+	GetExpiringLots(ctx *gin.Context)
+	QuarantineLot(ctx *gin.Context)
+	DispenseMedicine(ctx *gin.Context)
+	GetLocations(ctx *gin.Context)
+	GetLocationByID(ctx *gin.Context)
+	NewLocation(ctx *gin.Context)
+	UpdateLocation(ctx *gin.Context)
+	GetTransfers(ctx *gin.Context)
+	GetTransferByID(ctx *gin.Context)
+	RequestTransfer(ctx *gin.Context)
+	ShipTransfer(ctx *gin.Context)
+	ReceiveTransfer(ctx *gin.Context)
+	CancelTransfer(ctx *gin.Context)
+	GetMedicineThresholds(ctx *gin.Context)
+	SetMedicineThreshold(ctx *gin.Context)
+	DeleteThreshold(ctx *gin.Context)
+	GetAlerts(ctx *gin.Context)
+	GetAlertByID(ctx *gin.Context)
+	AcknowledgeAlert(ctx *gin.Context)
+}
+
+type Controller struct {
+	stockService stockDomain.IStockService
+	Logger       *logger.Logger
+}
+
+func NewStockController(stockService stockDomain.IStockService, loggerInstance *logger.Logger) IStockController {
+	return &Controller{stockService: stockService, Logger: loggerInstance}
+}
+
+// GetMedicineStock returns the units of a medicine on hand at each location
+func (c *Controller) GetMedicineStock(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Getting medicine stock", zap.Int("medicineId", medicineID))
+	stock, err := c.stockService.GetByMedicine(ctx.Request.Context(), medicineID)
+	if err != nil {
+		c.Logger.Error("Error getting medicine stock", zap.Error(err), zap.Int("medicineId", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponseMapper(stock))
+}
+
+// AdjustMedicineStock adds units to the stock of a medicine at a location, or removes
+// them with a negative delta, as an adjustment, a return or a write-off
+func (c *Controller) AdjustMedicineStock(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	var request AdjustStockRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for stock adjustment", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	level, err := c.stockService.Adjust(ctx.Request.Context(), stockDomain.Adjustment{
+		MedicineID: medicineID,
+		Location:   request.Location,
+		Delta:      request.Delta,
+		Type:       stockDomain.MovementType(request.Type),
+		Reason:     request.Reason,
+	})
+	if err != nil {
+		c.Logger.Error("Error adjusting medicine stock", zap.Error(err), zap.Int("medicineId", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Medicine stock adjusted", zap.Int("medicineId", medicineID), zap.String("location", level.Location))
+	ctx.JSON(http.StatusOK, levelToResponseMapper(level))
+}
+
+// GetMedicineMovements lists the stock ledger of a medicine, newest first. The
+// <field>_match, createdAt_start and createdAt_end query parameters narrow the list.
+func (c *Controller) GetMedicineMovements(ctx *gin.Context) {
+	medicineID, ok := c.medicineID(ctx)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	filters := domain.DataFilters{
+		Page:          page,
+		PageSize:      pageSize,
+		Matches:       map[string][]string{},
+		SortBy:        ctx.QueryArray("sortBy"),
+		SortDirection: domain.SortDirection(ctx.Query("sortDirection")),
+	}
+	for field := range stock.ColumnsMovementMapping {
+		if values := ctx.QueryArray(field + "_match"); len(values) > 0 {
+			filters.Matches[field] = values
+		}
+	}
+	dateRange := domain.DateRangeFilter{Field: "createdAt"}
+	if start, err := time.Parse(time.RFC3339, ctx.Query("createdAt_start")); err == nil {
+		dateRange.Start = &start
+	}
+	if end, err := time.Parse(time.RFC3339, ctx.Query("createdAt_end")); err == nil {
+		dateRange.End = &end
+	}
+	if dateRange.Start != nil || dateRange.End != nil {
+		filters.DateRangeFilters = []domain.DateRangeFilter{dateRange}
+	}
+
+	c.Logger.Info("Getting medicine stock movements", zap.Int("medicineId", medicineID))
+	result, err := c.stockService.GetMovements(ctx.Request.Context(), medicineID, filters)
+	if err != nil {
+		c.Logger.Error("Error getting medicine stock movements", zap.Error(err), zap.Int("medicineId", medicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	data := make([]ResponseMovement, len(*result.Data))
+	for i := range *result.Data {
+		data[i] = *movementToResponseMapper(&(*result.Data)[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+	})
+}
+
+func (c *Controller) medicineID(ctx *gin.Context) (int, bool) {
+	medicineID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid medicine ID parameter for stock", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("medicine id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return medicineID, true
+}
+
+// Mappers
+func levelToResponseMapper(level *stockDomain.Level) *ResponseLevel {
+	return &ResponseLevel{
+		Location:  level.Location,
+		Quantity:  level.Quantity,
+		UpdatedAt: level.UpdatedAt,
+		UpdatedBy: level.UpdatedBy,
+	}
+}
+
+func movementToResponseMapper(movement *stockDomain.Movement) *ResponseMovement {
+	return &ResponseMovement{
+		ID:         movement.ID,
+		Location:   movement.Location,
+		LotID:      movement.LotID,
+		TransferID: movement.TransferID,
+		Type:       string(movement.Type),
+		Quantity:   movement.Quantity,
+		Reason:     movement.Reason,
+		CreatedAt:  movement.CreatedAt,
+		CreatedBy:  movement.CreatedBy,
+	}
+}
+
+func domainToResponseMapper(stock *stockDomain.Stock) *ResponseStock {
+	levels := make([]ResponseLevel, len(stock.Levels))
+	for i := range stock.Levels {
+		levels[i] = *levelToResponseMapper(&stock.Levels[i])
+	}
+	return &ResponseStock{
+		MedicineID: stock.MedicineID,
+		Total:      stock.Total,
+		InTransit:  stock.InTransit,
+		Levels:     levels,
+	}
+}
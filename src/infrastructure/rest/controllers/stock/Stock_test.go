@@ -0,0 +1,322 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStockService is a mock implementation of IStockService
+type MockStockService struct {
+	mock.Mock
+}
+
+func (m *MockStockService) GetByMedicine(_ context.Context, medicineID int) (*stockDomain.Stock, error) {
+	args := m.Called(medicineID)
+	stock, _ := args.Get(0).(*stockDomain.Stock)
+	return stock, args.Error(1)
+}
+
+func (m *MockStockService) Adjust(_ context.Context, adjustment stockDomain.Adjustment) (*stockDomain.Level, error) {
+	args := m.Called(adjustment)
+	level, _ := args.Get(0).(*stockDomain.Level)
+	return level, args.Error(1)
+}
+
+func (m *MockStockService) ReceiveLot(_ context.Context, lot *stockDomain.MedicineLot) (*stockDomain.MedicineLot, error) {
+	args := m.Called(lot)
+	created, _ := args.Get(0).(*stockDomain.MedicineLot)
+	return created, args.Error(1)
+}
+
+func (m *MockStockService) GetLots(_ context.Context, medicineID int) (*[]stockDomain.MedicineLot, error) {
+	args := m.Called(medicineID)
+	lots, _ := args.Get(0).(*[]stockDomain.MedicineLot)
+	return lots, args.Error(1)
+}
+
+func (m *MockStockService) GetExpiringLots(_ context.Context, days int) (*[]stockDomain.MedicineLot, error) {
+	args := m.Called(days)
+	lots, _ := args.Get(0).(*[]stockDomain.MedicineLot)
+	return lots, args.Error(1)
+}
+
+func (m *MockStockService) QuarantineLot(_ context.Context, lotID int, reason string) (*stockDomain.MedicineLot, error) {
+	args := m.Called(lotID, reason)
+	lot, _ := args.Get(0).(*stockDomain.MedicineLot)
+	return lot, args.Error(1)
+}
+
+func (m *MockStockService) Dispense(_ context.Context, medicineID int, location string, quantity int, reason string) (*stockDomain.Dispensation, error) {
+	args := m.Called(medicineID, location, quantity, reason)
+	dispensation, _ := args.Get(0).(*stockDomain.Dispensation)
+	return dispensation, args.Error(1)
+}
+
+func (m *MockStockService) GetMovements(_ context.Context, medicineID int, filters domain.DataFilters) (*stockDomain.SearchResultMovement, error) {
+	args := m.Called(medicineID, filters)
+	result, _ := args.Get(0).(*stockDomain.SearchResultMovement)
+	return result, args.Error(1)
+}
+
+func (m *MockStockService) GetLocations(_ context.Context) (*[]stockDomain.Location, error) {
+	args := m.Called()
+	locations, _ := args.Get(0).(*[]stockDomain.Location)
+	return locations, args.Error(1)
+}
+
+func (m *MockStockService) GetLocation(_ context.Context, id int) (*stockDomain.Location, error) {
+	args := m.Called(id)
+	location, _ := args.Get(0).(*stockDomain.Location)
+	return location, args.Error(1)
+}
+
+func (m *MockStockService) CreateLocation(_ context.Context, location *stockDomain.Location) (*stockDomain.Location, error) {
+	args := m.Called(location)
+	created, _ := args.Get(0).(*stockDomain.Location)
+	return created, args.Error(1)
+}
+
+func (m *MockStockService) UpdateLocation(_ context.Context, id int, locationMap map[string]any) (*stockDomain.Location, error) {
+	args := m.Called(id, locationMap)
+	location, _ := args.Get(0).(*stockDomain.Location)
+	return location, args.Error(1)
+}
+
+func (m *MockStockService) RequestTransfer(_ context.Context, transfer *stockDomain.Transfer) (*stockDomain.Transfer, error) {
+	args := m.Called(transfer)
+	created, _ := args.Get(0).(*stockDomain.Transfer)
+	return created, args.Error(1)
+}
+
+func (m *MockStockService) GetTransfer(_ context.Context, id int) (*stockDomain.Transfer, error) {
+	return m.transfer(m.Called(id))
+}
+
+func (m *MockStockService) GetTransfers(_ context.Context, filters domain.DataFilters) (*stockDomain.SearchResultTransfer, error) {
+	args := m.Called(filters)
+	result, _ := args.Get(0).(*stockDomain.SearchResultTransfer)
+	return result, args.Error(1)
+}
+
+func (m *MockStockService) ShipTransfer(_ context.Context, id int) (*stockDomain.Transfer, error) {
+	return m.transfer(m.Called(id))
+}
+
+func (m *MockStockService) ReceiveTransfer(_ context.Context, id int) (*stockDomain.Transfer, error) {
+	return m.transfer(m.Called(id))
+}
+
+func (m *MockStockService) CancelTransfer(_ context.Context, id int) (*stockDomain.Transfer, error) {
+	return m.transfer(m.Called(id))
+}
+
+func (m *MockStockService) GetThresholds(_ context.Context, medicineID int) (*[]stockDomain.Threshold, error) {
+	args := m.Called(medicineID)
+	thresholds, _ := args.Get(0).(*[]stockDomain.Threshold)
+	return thresholds, args.Error(1)
+}
+
+func (m *MockStockService) SetThreshold(_ context.Context, threshold *stockDomain.Threshold) (*stockDomain.Threshold, error) {
+	args := m.Called(threshold)
+	saved, _ := args.Get(0).(*stockDomain.Threshold)
+	return saved, args.Error(1)
+}
+
+func (m *MockStockService) DeleteThreshold(_ context.Context, id int) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockStockService) GetAlerts(_ context.Context, filters domain.DataFilters) (*stockDomain.SearchResultAlert, error) {
+	args := m.Called(filters)
+	result, _ := args.Get(0).(*stockDomain.SearchResultAlert)
+	return result, args.Error(1)
+}
+
+func (m *MockStockService) GetAlert(_ context.Context, id int) (*stockDomain.Alert, error) {
+	return m.alert(m.Called(id))
+}
+
+func (m *MockStockService) AcknowledgeAlert(_ context.Context, id int) (*stockDomain.Alert, error) {
+	return m.alert(m.Called(id))
+}
+
+func (m *MockStockService) alert(args mock.Arguments) (*stockDomain.Alert, error) {
+	alert, _ := args.Get(0).(*stockDomain.Alert)
+	return alert, args.Error(1)
+}
+
+func (m *MockStockService) transfer(args mock.Arguments) (*stockDomain.Transfer, error) {
+	transfer, _ := args.Get(0).(*stockDomain.Transfer)
+	return transfer, args.Error(1)
+}
+
+func setupController(t *testing.T) (*MockStockService, IStockController) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	mockService := &MockStockService{}
+	return mockService, NewStockController(mockService, loggerInstance)
+}
+
+func setupGinContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	return c, w
+}
+
+func TestStockController_GetMedicineStock(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/medicine/5/stock", nil)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	mockService.On("GetByMedicine", 5).Return(&stockDomain.Stock{
+		MedicineID: 5,
+		Total:      7,
+		Levels: []stockDomain.Level{
+			{MedicineID: 5, Location: "front", Quantity: 2},
+			{MedicineID: 5, Location: "main", Quantity: 5},
+		},
+	}, nil)
+
+	controller.GetMedicineStock(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ResponseStock
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 7, response.Total)
+	require.Len(t, response.Levels, 2)
+	assert.Equal(t, "main", response.Levels[1].Location)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_AdjustMedicineStock(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/medicine/5/stock/adjustments", strings.NewReader(`{"location":"front","delta":-2}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	mockService.On("Adjust", stockDomain.Adjustment{MedicineID: 5, Location: "front", Delta: -2}).
+		Return(&stockDomain.Level{MedicineID: 5, Location: "front", Quantity: 3}, nil)
+
+	controller.AdjustMedicineStock(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"quantity":3`)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_AdjustMedicineStockWithType(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/medicine/5/stock/adjustments",
+		strings.NewReader(`{"delta":-1,"type":"write-off","reason":"damaged"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	mockService.On("Adjust", stockDomain.Adjustment{MedicineID: 5, Delta: -1, Type: stockDomain.MovementWriteOff, Reason: "damaged"}).
+		Return(&stockDomain.Level{MedicineID: 5, Location: stockDomain.DefaultLocation, Quantity: 4}, nil)
+
+	controller.AdjustMedicineStock(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_AdjustMedicineStockErrors(t *testing.T) {
+	mockService, controller := setupController(t)
+	mockService.On("Adjust", stockDomain.Adjustment{MedicineID: 5, Delta: -9}).
+		Return(nil, domainErrors.NewAppError(errors.New("insufficient stock: 3 on hand at main"), domainErrors.ValidationError))
+
+	for name, body := range map[string]string{
+		"missing delta": `{"location":"front"}`,
+		"insufficient":  `{"delta":-9}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := setupGinContext()
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/medicine/5/stock/adjustments", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+			controller.AdjustMedicineStock(c)
+
+			require.Len(t, c.Errors, 1)
+			appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+		})
+	}
+}
+
+func TestStockController_InvalidID(t *testing.T) {
+	_, controller := setupController(t)
+	for name, handler := range map[string]gin.HandlerFunc{
+		"get":       controller.GetMedicineStock,
+		"adjust":    controller.AdjustMedicineStock,
+		"movements": controller.GetMedicineMovements,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := setupGinContext()
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/medicine/abc/stock", nil)
+			c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+			handler(c)
+
+			require.Len(t, c.Errors, 1)
+			appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+		})
+	}
+}
+
+func TestStockController_GetMedicineMovements(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet,
+		"/v1/medicine/5/stock/movements?type_match=dispense&createdAt_start=2026-01-01T00:00:00Z&page=2&pageSize=1", nil)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	lotID := 3
+	mockService.On("GetMovements", 5, mock.MatchedBy(func(filters domain.DataFilters) bool {
+		return filters.Page == 2 && filters.PageSize == 1 &&
+			assert.ObjectsAreEqual([]string{"dispense"}, filters.Matches["type"]) &&
+			len(filters.DateRangeFilters) == 1 && filters.DateRangeFilters[0].Start.Equal(start)
+	})).Return(&stockDomain.SearchResultMovement{
+		Data: &[]stockDomain.Movement{
+			{ID: 9, MedicineID: 5, Location: stockDomain.DefaultLocation, LotID: &lotID, Type: stockDomain.MovementDispense, Quantity: -2, Reason: "prescription"},
+		},
+		Total:      2,
+		Page:       2,
+		PageSize:   1,
+		TotalPages: 2,
+	}, nil)
+
+	controller.GetMedicineMovements(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data  []ResponseMovement `json:"data"`
+		Total int64              `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.Total)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "dispense", response.Data[0].Type)
+	assert.Equal(t, -2, response.Data[0].Quantity)
+	assert.Equal(t, &lotID, response.Data[0].LotID)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,196 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+type RequestTransferRequest struct {
+	MedicineID   int    `json:"medicineId" binding:"required"`
+	LotID        *int   `json:"lotId"`
+	FromLocation string `json:"fromLocation" binding:"required"`
+	ToLocation   string `json:"toLocation" binding:"required"`
+	Quantity     int    `json:"quantity" binding:"required"`
+	Reason       string `json:"reason"`
+}
+
+type ResponseTransfer struct {
+	ID           int        `json:"id"`
+	MedicineID   int        `json:"medicineId"`
+	LotID        *int       `json:"lotId"`
+	FromLocation string     `json:"fromLocation"`
+	ToLocation   string     `json:"toLocation"`
+	Quantity     int        `json:"quantity"`
+	Status       string     `json:"status"`
+	Reason       string     `json:"reason,omitempty"`
+	ShippedAt    *time.Time `json:"shippedAt"`
+	ReceivedAt   *time.Time `json:"receivedAt"`
+	CancelledAt  *time.Time `json:"cancelledAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	CreatedBy    *int       `json:"createdBy"`
+	UpdatedBy    *int       `json:"updatedBy"`
+}
+
+// RequestTransfer asks for stock to be moved between two locations
+func (c *Controller) RequestTransfer(ctx *gin.Context) {
+	var request RequestTransferRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for transfer request", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	transfer, err := c.stockService.RequestTransfer(ctx.Request.Context(), &stockDomain.Transfer{
+		MedicineID:   request.MedicineID,
+		LotID:        request.LotID,
+		FromLocation: request.FromLocation,
+		ToLocation:   request.ToLocation,
+		Quantity:     request.Quantity,
+		Reason:       request.Reason,
+	})
+	if err != nil {
+		c.Logger.Error("Error requesting transfer", zap.Error(err), zap.Int("medicineId", request.MedicineID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Transfer requested", zap.Int("id", transfer.ID))
+	ctx.JSON(http.StatusOK, transferToResponseMapper(transfer))
+}
+
+// GetTransfers lists transfers, newest first. The <field>_match, createdAt_start and
+// createdAt_end query parameters narrow the list.
+func (c *Controller) GetTransfers(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	filters := domain.DataFilters{
+		Page:          page,
+		PageSize:      pageSize,
+		Matches:       map[string][]string{},
+		SortBy:        ctx.QueryArray("sortBy"),
+		SortDirection: domain.SortDirection(ctx.Query("sortDirection")),
+	}
+	for field := range stock.ColumnsTransferMapping {
+		if values := ctx.QueryArray(field + "_match"); len(values) > 0 {
+			filters.Matches[field] = values
+		}
+	}
+	dateRange := domain.DateRangeFilter{Field: "createdAt"}
+	if start, err := time.Parse(time.RFC3339, ctx.Query("createdAt_start")); err == nil {
+		dateRange.Start = &start
+	}
+	if end, err := time.Parse(time.RFC3339, ctx.Query("createdAt_end")); err == nil {
+		dateRange.End = &end
+	}
+	if dateRange.Start != nil || dateRange.End != nil {
+		filters.DateRangeFilters = []domain.DateRangeFilter{dateRange}
+	}
+
+	c.Logger.Info("Getting transfers")
+	result, err := c.stockService.GetTransfers(ctx.Request.Context(), filters)
+	if err != nil {
+		c.Logger.Error("Error getting transfers", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	data := make([]ResponseTransfer, len(*result.Data))
+	for i := range *result.Data {
+		data[i] = *transferToResponseMapper(&(*result.Data)[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+	})
+}
+
+func (c *Controller) GetTransferByID(ctx *gin.Context) {
+	transferID, ok := c.transferID(ctx)
+	if !ok {
+		return
+	}
+	c.Logger.Info("Getting transfer by ID", zap.Int("id", transferID))
+	transfer, err := c.stockService.GetTransfer(ctx.Request.Context(), transferID)
+	if err != nil {
+		c.Logger.Error("Error getting transfer by ID", zap.Error(err), zap.Int("id", transferID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, transferToResponseMapper(transfer))
+}
+
+// ShipTransfer takes the units of a requested transfer from its source location
+func (c *Controller) ShipTransfer(ctx *gin.Context) {
+	c.advanceTransfer(ctx, "shipping", c.stockService.ShipTransfer)
+}
+
+// ReceiveTransfer adds the units of a shipped transfer to its destination location
+func (c *Controller) ReceiveTransfer(ctx *gin.Context) {
+	c.advanceTransfer(ctx, "receiving", c.stockService.ReceiveTransfer)
+}
+
+// CancelTransfer withdraws a requested transfer
+func (c *Controller) CancelTransfer(ctx *gin.Context) {
+	c.advanceTransfer(ctx, "cancelling", c.stockService.CancelTransfer)
+}
+
+func (c *Controller) advanceTransfer(ctx *gin.Context, step string, advance func(ctx context.Context, id int) (*stockDomain.Transfer, error)) {
+	transferID, ok := c.transferID(ctx)
+	if !ok {
+		return
+	}
+	transfer, err := advance(ctx.Request.Context(), transferID)
+	if err != nil {
+		c.Logger.Error("Error "+step+" transfer", zap.Error(err), zap.Int("id", transferID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Transfer "+string(transfer.Status), zap.Int("id", transferID))
+	ctx.JSON(http.StatusOK, transferToResponseMapper(transfer))
+}
+
+func (c *Controller) transferID(ctx *gin.Context) (int, bool) {
+	transferID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid transfer ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("transfer id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return transferID, true
+}
+
+// Mappers
+func transferToResponseMapper(transfer *stockDomain.Transfer) *ResponseTransfer {
+	return &ResponseTransfer{
+		ID:           transfer.ID,
+		MedicineID:   transfer.MedicineID,
+		LotID:        transfer.LotID,
+		FromLocation: transfer.FromLocation,
+		ToLocation:   transfer.ToLocation,
+		Quantity:     transfer.Quantity,
+		Status:       string(transfer.Status),
+		Reason:       transfer.Reason,
+		ShippedAt:    transfer.ShippedAt,
+		ReceivedAt:   transfer.ReceivedAt,
+		CancelledAt:  transfer.CancelledAt,
+		CreatedAt:    transfer.CreatedAt,
+		UpdatedAt:    transfer.UpdatedAt,
+		CreatedBy:    transfer.CreatedBy,
+		UpdatedBy:    transfer.UpdatedBy,
+	}
+}
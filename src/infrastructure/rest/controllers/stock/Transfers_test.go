@@ -0,0 +1,117 @@
+package stock
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStockController_RequestTransfer(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/transfers/",
+		strings.NewReader(`{"medicineId":5,"lotId":3,"fromLocation":"main","toLocation":"front","quantity":4}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	lotID := 3
+	mockService.On("RequestTransfer", mock.MatchedBy(func(transfer *stockDomain.Transfer) bool {
+		return transfer.MedicineID == 5 && *transfer.LotID == 3 && transfer.ToLocation == "front" && transfer.Quantity == 4
+	})).Return(&stockDomain.Transfer{ID: 1, MedicineID: 5, LotID: &lotID, FromLocation: "main", ToLocation: "front", Quantity: 4, Status: stockDomain.TransferRequested}, nil)
+
+	controller.RequestTransfer(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ResponseTransfer
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "requested", response.Status)
+	assert.Equal(t, 3, *response.LotID)
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_RequestTransferMissingLocation(t *testing.T) {
+	_, controller := setupController(t)
+	c, _ := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/transfers/", strings.NewReader(`{"medicineId":5,"fromLocation":"main","quantity":4}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.RequestTransfer(c)
+
+	require.Len(t, c.Errors, 1)
+	appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
+
+func TestStockController_AdvanceTransfer(t *testing.T) {
+	mockService, controller := setupController(t)
+	mockService.On("ShipTransfer", 1).Return(&stockDomain.Transfer{ID: 1, Status: stockDomain.TransferShipped}, nil)
+	mockService.On("ReceiveTransfer", 1).Return(nil, domainErrors.NewAppError(errors.New("not allowed to change the stock at location front"), domainErrors.NotAuthorized))
+	mockService.On("CancelTransfer", 1).Return(nil, domainErrors.NewAppError(errors.New("transfer 1 is shipped, not requested"), domainErrors.ValidationError))
+
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/transfers/1/ship", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	controller.ShipTransfer(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"shipped"`)
+
+	for name, step := range map[string]struct {
+		handler  gin.HandlerFunc
+		expected domainErrors.ErrorType
+	}{
+		"receive": {controller.ReceiveTransfer, domainErrors.NotAuthorized},
+		"cancel":  {controller.CancelTransfer, domainErrors.ValidationError},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := setupGinContext()
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/transfers/1/"+name, nil)
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+			step.handler(c)
+
+			require.Len(t, c.Errors, 1)
+			appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, step.expected, appErr.Type)
+		})
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestStockController_GetTransfers(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/transfers/?status_match=shipped&toLocation_match=front", nil)
+	mockService.On("GetTransfers", mock.MatchedBy(func(filters domain.DataFilters) bool {
+		return filters.Page == 1 && filters.Matches["status"][0] == "shipped" && filters.Matches["toLocation"][0] == "front"
+	})).Return(&stockDomain.SearchResultTransfer{
+		Data:       &[]stockDomain.Transfer{{ID: 2, Status: stockDomain.TransferShipped, ToLocation: "front"}},
+		Total:      1,
+		Page:       1,
+		PageSize:   10,
+		TotalPages: 1,
+	}, nil)
+
+	controller.GetTransfers(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data  []ResponseTransfer `json:"data"`
+		Total int64              `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.Total)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, 2, response.Data[0].ID)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,234 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/changefeed"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// writeWait bounds the time a WebSocket client may take to accept a message
+const writeWait = 10 * time.Second
+
+// retryMillis is how long an EventSource waits before reconnecting
+const retryMillis = 3000
+
+// defaultHeartbeat keeps idle connections open through proxies when none is configured
+const defaultHeartbeat = 15 * time.Second
+
+// Structures
+type ResponseNotification struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Resource   string    `json:"resource"`
+	ResourceID int       `json:"resourceId"`
+	Fields     []string  `json:"fields,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// ResponseReset tells a WebSocket client that it missed notifications and must reload
+type ResponseReset struct {
+	Type string `json:"type"`
+}
+
+type IStreamController interface {
+	Stream(ctx *gin.Context)
+	WebSocket(ctx *gin.Context)
+}
+
+type Controller struct {
+	feed      *changefeed.Feed
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+	Logger    *logger.Logger
+}
+
+func NewStreamController(feed *changefeed.Feed, heartbeat time.Duration, loggerInstance *logger.Logger) IStreamController {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	return &Controller{
+		feed:      feed,
+		heartbeat: heartbeat,
+		upgrader: websocket.Upgrader{
+			// clients authenticate with a bearer token, not cookies, so any origin may connect
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		Logger: loggerInstance,
+	}
+}
+
+// Stream sends the changes of the caller's organization as Server-Sent Events. The
+// types query parameter lists the resources to receive, all by default. A client
+// reconnecting with Last-Event-ID first gets the changes it missed, or a "reset"
+// event when they are no longer known.
+func (c *Controller) Stream(ctx *gin.Context) {
+	filter, lastEventID, err := c.subscription(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	backlog, subscription, complete := c.feed.Subscribe(filter, lastEventID)
+	defer c.feed.Unsubscribe(subscription)
+	c.Logger.Info("Change stream opened", zap.Int("tenantId", filter.TenantID), zap.Strings("types", filter.Resources))
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	fmt.Fprintf(ctx.Writer, "retry: %d\n\n", retryMillis)
+	if !complete {
+		fmt.Fprint(ctx.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for i := range backlog {
+		writeEvent(ctx, &backlog[i])
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(c.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case notification, ok := <-subscription.C:
+			if !ok {
+				// dropped as too slow or by a shutdown: the client reconnects and resumes from the log
+				return
+			}
+			writeEvent(ctx, &notification)
+			ctx.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(ctx.Writer, ": ping\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// WebSocket sends the same changes as Stream as JSON text messages. The last seen
+// notification is passed in the lastEventId query parameter; a {"type":"reset"}
+// message means changes were missed.
+func (c *Controller) WebSocket(ctx *gin.Context) {
+	filter, lastEventID, err := c.subscription(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	conn, err := c.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// the upgrader already answered the client
+		c.Logger.Warn("Error upgrading change stream to WebSocket", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+	backlog, subscription, complete := c.feed.Subscribe(filter, lastEventID)
+	defer c.feed.Unsubscribe(subscription)
+	c.Logger.Info("Change WebSocket opened", zap.Int("tenantId", filter.TenantID), zap.Strings("types", filter.Resources))
+
+	// reading handles pings and close frames and notices when the client is gone
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(message any) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+		return conn.WriteJSON(message) == nil
+	}
+	if !complete && !send(ResponseReset{Type: "reset"}) {
+		return
+	}
+	for i := range backlog {
+		if !send(toResponse(&backlog[i])) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(c.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case notification, ok := <-subscription.C:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect"),
+					time.Now().Add(writeWait))
+				return
+			}
+			if !send(toResponse(&notification)) {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscription reads the filter and the resume point of a stream request
+func (c *Controller) subscription(ctx *gin.Context) (changefeed.Filter, string, error) {
+	tenantID, ok := security.TenantID(ctx.Request.Context())
+	if !ok {
+		return changefeed.Filter{}, "", domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated)
+	}
+	resources := changefeed.Resources
+	if raw := ctx.Query("types"); raw != "" {
+		resources = nil
+		for _, resource := range strings.Split(raw, ",") {
+			resource = strings.TrimSpace(resource)
+			if !slices.Contains(changefeed.Resources, resource) {
+				c.Logger.Warn("Unknown change stream type", zap.String("type", resource))
+				return changefeed.Filter{}, "", domainErrors.NewAppError(
+					fmt.Errorf("unknown type %q: expected %s", resource, strings.Join(changefeed.Resources, ", ")),
+					domainErrors.ValidationError)
+			}
+			resources = append(resources, resource)
+		}
+	}
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("lastEventId")
+	}
+	if len(lastEventID) > 64 {
+		return changefeed.Filter{}, "", domainErrors.NewAppError(errors.New("lastEventId is invalid"), domainErrors.ValidationError)
+	}
+	return changefeed.Filter{TenantID: tenantID, Resources: resources}, lastEventID, nil
+}
+
+func writeEvent(ctx *gin.Context, notification *changefeed.Notification) {
+	data, _ := json.Marshal(toResponse(notification))
+	fmt.Fprintf(ctx.Writer, "id: %s\ndata: %s\n\n", notification.ID, data)
+}
+
+// Mappers
+func toResponse(notification *changefeed.Notification) *ResponseNotification {
+	return &ResponseNotification{
+		ID:         notification.ID,
+		Type:       notification.Type,
+		Resource:   notification.Resource,
+		ResourceID: notification.ResourceID,
+		Fields:     notification.Fields,
+		OccurredAt: notification.OccurredAt,
+	}
+}
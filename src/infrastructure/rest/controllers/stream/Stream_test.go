@@ -0,0 +1,174 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/application/changefeed"
+	"github.com/gbrayhan/microservices-go/src/application/eventbus"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	medicineDomain "github.com/gbrayhan/microservices-go/src/domain/medicine"
+	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupServer serves the stream endpoints to tenant 1, as the JWT middleware would
+func setupServer(t *testing.T) (*httptest.Server, *eventbus.Bus, *changefeed.Feed) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	bus := eventbus.New(loggerInstance)
+	feed := changefeed.New(changefeed.Config{LogSize: 10, ClientBuffer: 10}, loggerInstance)
+	feed.Listen(bus)
+	controller := NewStreamController(feed, time.Hour, loggerInstance)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		principal := security.Principal{UserID: 1, TenantID: 1}
+		c.Request = c.Request.WithContext(security.WithPrincipal(c.Request.Context(), principal))
+	})
+	router.GET("/v1/events/stream", controller.Stream)
+	router.GET("/v1/events/ws", controller.WebSocket)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, bus, feed
+}
+
+func tenantContext(tenantID int) context.Context {
+	return security.WithPrincipal(context.Background(), security.Principal{UserID: 1, TenantID: tenantID})
+}
+
+// readEvent returns the fields of the next Server-Sent Event that is not a comment
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
+
+func openStream(t *testing.T, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	request.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	require.NoError(t, err)
+	t.Cleanup(func() { response.Body.Close() })
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	reader := bufio.NewReader(response.Body)
+	assert.Equal(t, "3000", readEvent(t, reader)["retry"])
+	return reader
+}
+
+func TestController_StreamSendsTenantChanges(t *testing.T) {
+	server, bus, _ := setupServer(t)
+	// the stream subscribes before it writes its first event
+	reader := openStream(t, server.URL+"/v1/events/stream?types=medicine", "")
+
+	eventbus.Publish(tenantContext(2), bus, medicineDomain.Created{Medicine: medicineDomain.Medicine{ID: 5, TenantID: 2}})
+	eventbus.Publish(tenantContext(1), bus, userDomain.Created{User: userDomain.User{ID: 6, TenantID: 1}})
+	eventbus.Publish(tenantContext(1), bus, medicineDomain.Updated{Medicine: medicineDomain.Medicine{ID: 7, TenantID: 1}, Fields: []string{"name"}})
+
+	fields := readEvent(t, reader)
+	var notification ResponseNotification
+	require.NoError(t, json.Unmarshal([]byte(fields["data"]), &notification))
+	assert.Equal(t, fields["id"], notification.ID)
+	assert.Equal(t, "medicine.updated", notification.Type)
+	assert.Equal(t, 7, notification.ResourceID)
+	assert.Equal(t, []string{"name"}, notification.Fields)
+}
+
+func TestController_StreamResumes(t *testing.T) {
+	server, bus, feed := setupServer(t)
+	_, subscription, _ := feed.Subscribe(changefeed.Filter{TenantID: 1, Resources: changefeed.Resources}, "")
+	for id := 1; id <= 3; id++ {
+		eventbus.Publish(tenantContext(1), bus, medicineDomain.Created{Medicine: medicineDomain.Medicine{ID: id, TenantID: 1}})
+	}
+	first := <-subscription.C
+
+	reader := openStream(t, server.URL+"/v1/events/stream", first.ID)
+	assert.Contains(t, readEvent(t, reader)["data"], `"resourceId":2`)
+	assert.Contains(t, readEvent(t, reader)["data"], `"resourceId":3`)
+
+	reader = openStream(t, server.URL+"/v1/events/stream", "unknown-1")
+	assert.Equal(t, "reset", readEvent(t, reader)["event"])
+}
+
+func TestController_StreamRejectsUnknownTypes(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	controller := NewStreamController(changefeed.New(changefeed.Config{}, loggerInstance), 0, loggerInstance)
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/events/stream?types=medicine,orders", nil).
+		WithContext(tenantContext(1))
+	controller.Stream(c)
+	require.Len(t, c.Errors, 1)
+	appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/events/stream", nil)
+	controller.Stream(c)
+	require.Len(t, c.Errors, 1)
+	appErr, ok = c.Errors[0].Err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.NotAuthenticated, appErr.Type)
+}
+
+func TestController_WebSocket(t *testing.T) {
+	server, bus, _ := setupServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/events/ws?types=user&lastEventId=unknown-1"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	var reset ResponseReset
+	require.NoError(t, conn.ReadJSON(&reset))
+	assert.Equal(t, "reset", reset.Type)
+
+	eventbus.Publish(tenantContext(1), bus, medicineDomain.Deleted{ID: 3})
+	eventbus.Publish(tenantContext(1), bus, userDomain.Deleted{ID: 4})
+
+	var notification ResponseNotification
+	require.NoError(t, conn.ReadJSON(&notification))
+	assert.Equal(t, "user.deleted", notification.Type)
+	assert.Equal(t, 4, notification.ResourceID)
+}
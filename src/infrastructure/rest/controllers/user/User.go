@@ -27,14 +27,18 @@ type NewUserRequest struct {
 }
 
 type ResponseUser struct {
-	ID        int       `json:"id"`
-	UserName  string    `json:"user"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
-	Status    bool      `json:"status"`
-	CreatedAt time.Time `json:"createdAt,omitempty"`
-	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+	ID        int        `json:"id"`
+	TenantID  int        `json:"tenantId"`
+	UserName  string     `json:"user"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Status    bool       `json:"status"`
+	CreatedAt time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt,omitempty"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	CreatedBy *int       `json:"createdBy"`
+	UpdatedBy *int       `json:"updatedBy"`
 }
 
 type IUserController interface {
@@ -45,6 +49,9 @@ type IUserController interface {
 	DeleteUser(ctx *gin.Context)
 	SearchPaginated(ctx *gin.Context)
 	SearchByProperty(ctx *gin.Context)
+	GetTrash(ctx *gin.Context)
+	RestoreUser(ctx *gin.Context)
+	GetUserHistory(ctx *gin.Context)
 }
 
 type UserController struct {
@@ -65,7 +72,7 @@ func (c *UserController) NewUser(ctx *gin.Context) {
 		_ = ctx.Error(appError)
 		return
 	}
-	userModel, err := c.userService.Create(toUsecaseMapper(&request))
+	userModel, err := c.userService.Create(ctx.Request.Context(), toUsecaseMapper(&request))
 	if err != nil {
 		c.Logger.Error("Error creating user", zap.Error(err), zap.String("email", request.Email))
 		_ = ctx.Error(err)
@@ -78,7 +85,7 @@ func (c *UserController) NewUser(ctx *gin.Context) {
 
 func (c *UserController) GetAllUsers(ctx *gin.Context) {
 	c.Logger.Info("Getting all users")
-	users, err := c.userService.GetAll()
+	users, err := c.userService.GetAll(ctx.Request.Context())
 	if err != nil {
 		c.Logger.Error("Error getting all users", zap.Error(err))
 		appError := domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
@@ -97,8 +104,20 @@ func (c *UserController) GetUsersByID(ctx *gin.Context) {
 		_ = ctx.Error(appError)
 		return
 	}
+	asOf, err := controllers.ParseAsOf(ctx.Query("asOf"))
+	if err != nil {
+		c.Logger.Error("Invalid asOf parameter", zap.Error(err), zap.String("asOf", ctx.Query("asOf")))
+		appError := domainErrors.NewAppError(errors.New("asOf must be an RFC3339 timestamp"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
 	c.Logger.Info("Getting user by ID", zap.Int("id", userID))
-	user, err := c.userService.GetByID(userID)
+	var user *domainUser.User
+	if asOf != nil {
+		user, err = c.userService.GetAsOf(ctx.Request.Context(), userID, *asOf)
+	} else {
+		user, err = c.userService.GetByID(ctx.Request.Context(), userID)
+	}
 	if err != nil {
 		c.Logger.Error("Error getting user by ID", zap.Error(err), zap.Int("id", userID))
 		_ = ctx.Error(err)
@@ -131,7 +150,7 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 		_ = ctx.Error(err)
 		return
 	}
-	userUpdated, err := c.userService.Update(userID, requestMap)
+	userUpdated, err := c.userService.Update(ctx.Request.Context(), userID, requestMap)
 	if err != nil {
 		c.Logger.Error("Error updating user", zap.Error(err), zap.Int("id", userID))
 		_ = ctx.Error(err)
@@ -150,7 +169,7 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 		return
 	}
 	c.Logger.Info("Deleting user", zap.Int("id", userID))
-	err = c.userService.Delete(userID)
+	err = c.userService.Delete(ctx.Request.Context(), userID)
 	if err != nil {
 		c.Logger.Error("Error deleting user", zap.Error(err), zap.Int("id", userID))
 		_ = ctx.Error(err)
@@ -163,6 +182,97 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 func (c *UserController) SearchPaginated(ctx *gin.Context) {
 	c.Logger.Info("Searching users with pagination")
 
+	filters := buildDataFilters(ctx)
+
+	result, err := c.userService.SearchPaginated(ctx.Request.Context(), filters)
+	if err != nil {
+		c.Logger.Error("Error searching users", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := gin.H{
+		"data":       arrayDomainToResponseMapper(result.Data),
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+		"filters":    filters,
+	}
+
+	c.Logger.Info("Successfully searched users",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *UserController) GetTrash(ctx *gin.Context) {
+	c.Logger.Info("Listing deleted users")
+
+	filters := buildDataFilters(ctx)
+
+	result, err := c.userService.GetTrash(ctx.Request.Context(), filters)
+	if err != nil {
+		c.Logger.Error("Error listing deleted users", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := gin.H{
+		"data":       arrayDomainToResponseMapper(result.Data),
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+		"filters":    filters,
+	}
+
+	c.Logger.Info("Successfully listed deleted users",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *UserController) RestoreUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid user ID parameter for restore", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Restoring user", zap.Int("id", userID))
+	restored, err := c.userService.Restore(ctx.Request.Context(), userID)
+	if err != nil {
+		c.Logger.Error("Error restoring user", zap.Error(err), zap.Int("id", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("User restored successfully", zap.Int("id", userID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(restored))
+}
+
+func (c *UserController) GetUserHistory(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid user ID parameter for history", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("user id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Getting user history", zap.Int("id", userID))
+	entries, err := c.userService.GetHistory(ctx.Request.Context(), userID)
+	if err != nil {
+		c.Logger.Error("Error getting user history", zap.Error(err), zap.Int("id", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully retrieved user history", zap.Int("id", userID), zap.Int("count", len(*entries)))
+	ctx.JSON(http.StatusOK, controllers.HistoryToResponseMapper(entries))
+}
+
+// buildDataFilters reads pagination, filters and sorting from the query string
+func buildDataFilters(ctx *gin.Context) domain.DataFilters {
 	// Parse query parameters
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	if page < 1 {
@@ -234,26 +344,7 @@ func (c *UserController) SearchPaginated(ctx *gin.Context) {
 		filters.SortDirection = sortDirection
 	}
 
-	result, err := c.userService.SearchPaginated(filters)
-	if err != nil {
-		c.Logger.Error("Error searching users", zap.Error(err))
-		_ = ctx.Error(err)
-		return
-	}
-
-	response := gin.H{
-		"data":       arrayDomainToResponseMapper(result.Data),
-		"total":      result.Total,
-		"page":       result.Page,
-		"pageSize":   result.PageSize,
-		"totalPages": result.TotalPages,
-		"filters":    filters,
-	}
-
-	c.Logger.Info("Successfully searched users",
-		zap.Int64("total", result.Total),
-		zap.Int("page", result.Page))
-	ctx.JSON(http.StatusOK, response)
+	return filters
 }
 
 func (c *UserController) SearchByProperty(ctx *gin.Context) {
@@ -282,7 +373,7 @@ func (c *UserController) SearchByProperty(ctx *gin.Context) {
 		return
 	}
 
-	coincidences, err := c.userService.SearchByProperty(property, searchText)
+	coincidences, err := c.userService.SearchByProperty(ctx.Request.Context(), property, searchText)
 	if err != nil {
 		c.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
 		_ = ctx.Error(err)
@@ -299,6 +390,7 @@ func (c *UserController) SearchByProperty(ctx *gin.Context) {
 func domainToResponseMapper(domainUser *domainUser.User) *ResponseUser {
 	return &ResponseUser{
 		ID:        domainUser.ID,
+		TenantID:  domainUser.TenantID,
 		UserName:  domainUser.UserName,
 		Email:     domainUser.Email,
 		FirstName: domainUser.FirstName,
@@ -306,6 +398,9 @@ func domainToResponseMapper(domainUser *domainUser.User) *ResponseUser {
 		Status:    domainUser.Status,
 		CreatedAt: domainUser.CreatedAt,
 		UpdatedAt: domainUser.UpdatedAt,
+		DeletedAt: domainUser.DeletedAt,
+		CreatedBy: domainUser.CreatedBy,
+		UpdatedBy: domainUser.UpdatedBy,
 	}
 }
 
@@ -2,6 +2,7 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
+	domainHistory "github.com/gbrayhan/microservices-go/src/domain/history"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gin-gonic/gin"
@@ -22,17 +24,17 @@ type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) GetAll() (*[]domainUser.User, error) {
+func (m *MockUserService) GetAll(_ context.Context) (*[]domainUser.User, error) {
 	args := m.Called()
 	return args.Get(0).(*[]domainUser.User), args.Error(1)
 }
 
-func (m *MockUserService) GetByID(id int) (*domainUser.User, error) {
+func (m *MockUserService) GetByID(_ context.Context, id int) (*domainUser.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserService) Create(user *domainUser.User) (*domainUser.User, error) {
+func (m *MockUserService) Create(_ context.Context, user *domainUser.User) (*domainUser.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
@@ -42,26 +44,51 @@ func (m *MockUserService) GetOneByMap(userMap map[string]interface{}) (*domainUs
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserService) Update(id int, userMap map[string]interface{}) (*domainUser.User, error) {
+func (m *MockUserService) Update(_ context.Context, id int, userMap map[string]interface{}) (*domainUser.User, error) {
 	args := m.Called(id, userMap)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserService) Delete(id int) error {
+func (m *MockUserService) Delete(_ context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockUserService) SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+func (m *MockUserService) SearchPaginated(_ context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
 	args := m.Called(filters)
 	return args.Get(0).(*domainUser.SearchResultUser), args.Error(1)
 }
 
-func (m *MockUserService) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (m *MockUserService) SearchByProperty(_ context.Context, property string, searchText string) (*[]string, error) {
 	args := m.Called(property, searchText)
 	return args.Get(0).(*[]string), args.Error(1)
 }
 
+func (m *MockUserService) GetTrash(_ context.Context, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	args := m.Called(filters)
+	return args.Get(0).(*domainUser.SearchResultUser), args.Error(1)
+}
+
+func (m *MockUserService) Restore(_ context.Context, id int) (*domainUser.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domainUser.User), args.Error(1)
+}
+
+func (m *MockUserService) Purge(_ context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) GetHistory(_ context.Context, id int) (*[]domainHistory.Entry, error) {
+	args := m.Called(id)
+	return args.Get(0).(*[]domainHistory.Entry), args.Error(1)
+}
+
+func (m *MockUserService) GetAsOf(_ context.Context, id int, at time.Time) (*domainUser.User, error) {
+	args := m.Called(id, at)
+	return args.Get(0).(*domainUser.User), args.Error(1)
+}
+
 func setupLogger(t *testing.T) *logger.Logger {
 	loggerInstance, err := logger.NewLogger()
 	if err != nil {
@@ -82,6 +109,7 @@ func TestNewUserController(t *testing.T) {
 
 func TestDomainToResponseMapper(t *testing.T) {
 	now := time.Now()
+	createdBy, updatedBy := 1, 2
 	domainUser := &domainUser.User{
 		ID:           1,
 		UserName:     "testuser",
@@ -92,6 +120,8 @@ func TestDomainToResponseMapper(t *testing.T) {
 		HashPassword: "hashedpassword",
 		CreatedAt:    now,
 		UpdatedAt:    now,
+		CreatedBy:    &createdBy,
+		UpdatedBy:    &updatedBy,
 	}
 
 	response := domainToResponseMapper(domainUser)
@@ -104,6 +134,8 @@ func TestDomainToResponseMapper(t *testing.T) {
 	assert.Equal(t, domainUser.Status, response.Status)
 	assert.Equal(t, domainUser.CreatedAt, response.CreatedAt)
 	assert.Equal(t, domainUser.UpdatedAt, response.UpdatedAt)
+	assert.Equal(t, 1, *response.CreatedBy)
+	assert.Equal(t, 2, *response.UpdatedBy)
 }
 
 func TestArrayDomainToResponseMapper(t *testing.T) {
@@ -490,3 +522,178 @@ func TestUserController_DeleteUser(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 }
+
+func TestUserController_GetTrash(t *testing.T) {
+	mockService := &MockUserService{}
+	loggerInstance := setupLogger(t)
+	controller := NewUserController(mockService, loggerInstance)
+
+	t.Run("Success", func(t *testing.T) {
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/users/trash?page=2&pageSize=5", nil)
+
+		deletedAt := time.Now()
+		expected := &domainUser.SearchResultUser{
+			Data:       &[]domainUser.User{{ID: 1, UserName: "user1", DeletedAt: &deletedAt}},
+			Total:      6,
+			Page:       2,
+			PageSize:   5,
+			TotalPages: 2,
+		}
+		mockService.On("GetTrash", mock.MatchedBy(func(filters domain.DataFilters) bool {
+			return filters.Page == 2 && filters.PageSize == 5
+		})).Return(expected, nil).Once()
+
+		controller.GetTrash(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(6), response["total"])
+		data := response["data"].([]any)
+		assert.Contains(t, data[0].(map[string]any), "deletedAt")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/users/trash", nil)
+
+		mockService.On("GetTrash", mock.Anything).Return((*domainUser.SearchResultUser)(nil), errors.New("service error")).Once()
+
+		controller.GetTrash(c)
+
+		assert.Len(t, c.Errors, 1)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUserController_RestoreUser(t *testing.T) {
+	mockService := &MockUserService{}
+	loggerInstance := setupLogger(t)
+	controller := NewUserController(mockService, loggerInstance)
+
+	t.Run("Success", func(t *testing.T) {
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest("POST", "/users/1/restore", nil)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		mockService.On("Restore", 1).Return(&domainUser.User{ID: 1, UserName: "user1"}, nil).Once()
+
+		controller.RestoreUser(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest("POST", "/users/invalid/restore", nil)
+		c.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+		controller.RestoreUser(c)
+
+		assert.Len(t, c.Errors, 1)
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest("POST", "/users/2/restore", nil)
+		c.Params = gin.Params{{Key: "id", Value: "2"}}
+
+		mockService.On("Restore", 2).Return((*domainUser.User)(nil), errors.New("service error")).Once()
+
+		controller.RestoreUser(c)
+
+		assert.Len(t, c.Errors, 1)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUserController_GetUserHistory(t *testing.T) {
+	mockService := &MockUserService{}
+	loggerInstance := setupLogger(t)
+	controller := NewUserController(mockService, loggerInstance)
+
+	t.Run("Success", func(t *testing.T) {
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/users/1/history", nil)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		actorID := 3
+		entries := &[]domainHistory.Entry{{
+			ID:       1,
+			EntityID: 1,
+			Action:   domainHistory.ActionUpdate,
+			Changes:  map[string]domainHistory.FieldChange{"email": {Before: "old@example.com", After: "new@example.com"}},
+			ActorID:  &actorID,
+		}}
+		mockService.On("GetHistory", 1).Return(entries, nil).Once()
+
+		controller.GetUserHistory(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response, 1)
+		assert.Equal(t, "update", response[0]["action"])
+		assert.Equal(t, float64(3), response[0]["actorId"])
+		changes := response[0]["changes"].(map[string]any)
+		assert.Equal(t, "new@example.com", changes["email"].(map[string]any)["after"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/users/invalid/history", nil)
+		c.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+		controller.GetUserHistory(c)
+
+		assert.Len(t, c.Errors, 1)
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/users/2/history", nil)
+		c.Params = gin.Params{{Key: "id", Value: "2"}}
+
+		mockService.On("GetHistory", 2).Return((*[]domainHistory.Entry)(nil), errors.New("service error")).Once()
+
+		controller.GetUserHistory(c)
+
+		assert.Len(t, c.Errors, 1)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUserController_GetUsersByID_AsOf(t *testing.T) {
+	mockService := &MockUserService{}
+	loggerInstance := setupLogger(t)
+	controller := NewUserController(mockService, loggerInstance)
+
+	t.Run("Success", func(t *testing.T) {
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/users/1?asOf=2024-01-01T00:00:00Z", nil)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockService.On("GetAsOf", 1, at).Return(&domainUser.User{ID: 1, Email: "old@example.com"}, nil).Once()
+
+		controller.GetUsersByID(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "old@example.com")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid asOf", func(t *testing.T) {
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/users/1?asOf=yesterday", nil)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		controller.GetUsersByID(c)
+
+		assert.Len(t, c.Errors, 1)
+	})
+}
@@ -0,0 +1,259 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	webhookDomain "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Structures
+type NewWebhookRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1"`
+	Secret     string   `json:"secret"`
+}
+
+type ResponseWebhook struct {
+	ID                  int        `json:"id"`
+	TenantID            int        `json:"tenantId"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"eventTypes"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
+	DisabledReason      string     `json:"disabledReason,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt           time.Time  `json:"updatedAt,omitempty"`
+	CreatedBy           *int       `json:"createdBy"`
+	UpdatedBy           *int       `json:"updatedBy"`
+}
+
+// ResponseNewWebhook is only returned on creation, the one time the secret is shown
+type ResponseNewWebhook struct {
+	ResponseWebhook
+	Secret string `json:"secret"`
+}
+
+type ResponseDelivery struct {
+	ID             int64      `json:"id"`
+	EventID        string     `json:"eventId"`
+	EventType      string     `json:"eventType"`
+	Attempt        int        `json:"attempt"`
+	Status         string     `json:"status"`
+	ScheduledAt    time.Time  `json:"scheduledAt"`
+	AttemptedAt    *time.Time `json:"attemptedAt,omitempty"`
+	ResponseStatus int        `json:"responseStatus,omitempty"`
+	ResponseBody   string     `json:"responseBody,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	DurationMs     int64      `json:"durationMs"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type IWebhookController interface {
+	NewWebhook(ctx *gin.Context)
+	GetAllWebhooks(ctx *gin.Context)
+	GetWebhookByID(ctx *gin.Context)
+	UpdateWebhook(ctx *gin.Context)
+	DeleteWebhook(ctx *gin.Context)
+	GetWebhookDeliveries(ctx *gin.Context)
+}
+
+type Controller struct {
+	webhookService webhookDomain.IWebhookService
+	Logger         *logger.Logger
+}
+
+func NewWebhookController(webhookService webhookDomain.IWebhookService, loggerInstance *logger.Logger) IWebhookController {
+	return &Controller{webhookService: webhookService, Logger: loggerInstance}
+}
+
+func (c *Controller) NewWebhook(ctx *gin.Context) {
+	c.Logger.Info("Creating new webhook")
+	var request NewWebhookRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new webhook", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	created, err := c.webhookService.Create(ctx.Request.Context(), &webhookDomain.Webhook{
+		URL:        request.URL,
+		EventTypes: request.EventTypes,
+		Secret:     request.Secret,
+	})
+	if err != nil {
+		c.Logger.Error("Error creating webhook", zap.Error(err), zap.String("url", request.URL))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Webhook created successfully", zap.String("url", created.URL), zap.Int("id", created.ID))
+	ctx.JSON(http.StatusOK, ResponseNewWebhook{
+		ResponseWebhook: *domainToResponseMapper(created),
+		Secret:          created.Secret,
+	})
+}
+
+func (c *Controller) GetAllWebhooks(ctx *gin.Context) {
+	c.Logger.Info("Getting all webhooks")
+	webhooks, err := c.webhookService.GetAll(ctx.Request.Context())
+	if err != nil {
+		c.Logger.Error("Error getting all webhooks", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]ResponseWebhook, len(*webhooks))
+	for i := range *webhooks {
+		response[i] = *domainToResponseMapper(&(*webhooks)[i])
+	}
+	c.Logger.Info("Successfully retrieved all webhooks", zap.Int("count", len(response)))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) GetWebhookByID(ctx *gin.Context) {
+	webhookID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid webhook ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("webhook id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Getting webhook by ID", zap.Int("id", webhookID))
+	webhook, err := c.webhookService.GetByID(ctx.Request.Context(), webhookID)
+	if err != nil {
+		c.Logger.Error("Error getting webhook by ID", zap.Error(err), zap.Int("id", webhookID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponseMapper(webhook))
+}
+
+func (c *Controller) UpdateWebhook(ctx *gin.Context) {
+	webhookID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid webhook ID parameter for update", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Updating webhook", zap.Int("id", webhookID))
+	var requestMap map[string]any
+	if err := controllers.BindJSONMap(ctx, &requestMap); err != nil {
+		c.Logger.Error("Error binding JSON for webhook update", zap.Error(err), zap.Int("id", webhookID))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	updated, err := c.webhookService.Update(ctx.Request.Context(), webhookID, requestMap)
+	if err != nil {
+		c.Logger.Error("Error updating webhook", zap.Error(err), zap.Int("id", webhookID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Webhook updated successfully", zap.Int("id", webhookID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(updated))
+}
+
+func (c *Controller) DeleteWebhook(ctx *gin.Context) {
+	webhookID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid webhook ID parameter for deletion", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Deleting webhook", zap.Int("id", webhookID))
+	if err := c.webhookService.Delete(ctx.Request.Context(), webhookID); err != nil {
+		c.Logger.Error("Error deleting webhook", zap.Error(err), zap.Int("id", webhookID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Webhook deleted successfully", zap.Int("id", webhookID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+// GetWebhookDeliveries lists the delivery attempts of a webhook, newest first. The
+// status and eventType query parameters narrow the list.
+func (c *Controller) GetWebhookDeliveries(ctx *gin.Context) {
+	webhookID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid webhook ID parameter for deliveries", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("webhook id is invalid"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	filters := domain.DataFilters{Page: page, PageSize: pageSize, Matches: map[string][]string{}}
+	for _, field := range []string{"status", "eventType", "eventId"} {
+		if values := ctx.QueryArray(field); len(values) > 0 {
+			filters.Matches[field] = values
+		}
+	}
+
+	c.Logger.Info("Getting webhook deliveries", zap.Int("id", webhookID))
+	result, err := c.webhookService.GetDeliveries(ctx.Request.Context(), webhookID, filters)
+	if err != nil {
+		c.Logger.Error("Error getting webhook deliveries", zap.Error(err), zap.Int("id", webhookID))
+		_ = ctx.Error(err)
+		return
+	}
+	data := make([]ResponseDelivery, len(*result.Data))
+	for i := range *result.Data {
+		data[i] = *deliveryToResponseMapper(&(*result.Data)[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"total":      result.Total,
+		"page":       result.Page,
+		"pageSize":   result.PageSize,
+		"totalPages": result.TotalPages,
+	})
+}
+
+// Mappers
+func domainToResponseMapper(webhook *webhookDomain.Webhook) *ResponseWebhook {
+	eventTypes := webhook.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return &ResponseWebhook{
+		ID:                  webhook.ID,
+		TenantID:            webhook.TenantID,
+		URL:                 webhook.URL,
+		EventTypes:          eventTypes,
+		Active:              webhook.Active,
+		ConsecutiveFailures: webhook.ConsecutiveFailures,
+		DisabledAt:          webhook.DisabledAt,
+		DisabledReason:      webhook.DisabledReason,
+		CreatedAt:           webhook.CreatedAt,
+		UpdatedAt:           webhook.UpdatedAt,
+		CreatedBy:           webhook.CreatedBy,
+		UpdatedBy:           webhook.UpdatedBy,
+	}
+}
+
+func deliveryToResponseMapper(delivery *webhookDomain.Delivery) *ResponseDelivery {
+	return &ResponseDelivery{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Attempt:        delivery.Attempt,
+		Status:         delivery.Status,
+		ScheduledAt:    delivery.ScheduledAt,
+		AttemptedAt:    delivery.AttemptedAt,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		Error:          delivery.Error,
+		DurationMs:     delivery.DurationMs,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
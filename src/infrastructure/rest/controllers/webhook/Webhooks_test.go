@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	webhookDomain "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWebhookService is a mock implementation of IWebhookService
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) GetAll(_ context.Context) (*[]webhookDomain.Webhook, error) {
+	args := m.Called()
+	webhooks, _ := args.Get(0).(*[]webhookDomain.Webhook)
+	return webhooks, args.Error(1)
+}
+
+func (m *MockWebhookService) GetByID(_ context.Context, id int) (*webhookDomain.Webhook, error) {
+	args := m.Called(id)
+	webhook, _ := args.Get(0).(*webhookDomain.Webhook)
+	return webhook, args.Error(1)
+}
+
+func (m *MockWebhookService) Create(_ context.Context, webhook *webhookDomain.Webhook) (*webhookDomain.Webhook, error) {
+	args := m.Called(webhook)
+	created, _ := args.Get(0).(*webhookDomain.Webhook)
+	return created, args.Error(1)
+}
+
+func (m *MockWebhookService) Update(_ context.Context, id int, webhookMap map[string]any) (*webhookDomain.Webhook, error) {
+	args := m.Called(id, webhookMap)
+	updated, _ := args.Get(0).(*webhookDomain.Webhook)
+	return updated, args.Error(1)
+}
+
+func (m *MockWebhookService) Delete(_ context.Context, id int) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockWebhookService) GetDeliveries(_ context.Context, id int, filters domain.DataFilters) (*webhookDomain.SearchResultDelivery, error) {
+	args := m.Called(id, filters)
+	result, _ := args.Get(0).(*webhookDomain.SearchResultDelivery)
+	return result, args.Error(1)
+}
+
+func setupController(t *testing.T) (*MockWebhookService, IWebhookController) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	mockService := &MockWebhookService{}
+	return mockService, NewWebhookController(mockService, loggerInstance)
+}
+
+func setupGinContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	return c, w
+}
+
+func TestWebhookController_NewWebhook(t *testing.T) {
+	t.Run("Success shows the secret", func(t *testing.T) {
+		mockService, controller := setupController(t)
+		c, w := setupGinContext()
+		body, _ := json.Marshal(NewWebhookRequest{URL: "https://example.com/hooks", EventTypes: []string{"medicine.created"}})
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/webhooks/", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		mockService.On("Create", &webhookDomain.Webhook{URL: "https://example.com/hooks", EventTypes: []string{"medicine.created"}}).
+			Return(&webhookDomain.Webhook{ID: 4, URL: "https://example.com/hooks", EventTypes: []string{"medicine.created"}, Secret: "whsec_abc", Active: true}, nil)
+
+		controller.NewWebhook(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response ResponseNewWebhook
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 4, response.ID)
+		assert.Equal(t, "whsec_abc", response.Secret)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing event types", func(t *testing.T) {
+		_, controller := setupController(t)
+		c, _ := setupGinContext()
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/webhooks/", bytes.NewBufferString(`{"url":"https://example.com"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		controller.NewWebhook(c)
+
+		require.Len(t, c.Errors, 1)
+		appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	})
+}
+
+func TestWebhookController_GetWebhookByIDHidesSecret(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/webhooks/4", nil)
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+	mockService.On("GetByID", 4).Return(&webhookDomain.Webhook{ID: 4, URL: "https://example.com", Secret: "whsec_abc"}, nil)
+
+	controller.GetWebhookByID(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "whsec_abc")
+	assert.Contains(t, w.Body.String(), `"eventTypes":[]`)
+}
+
+func TestWebhookController_UpdateWebhook(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodPut, "/v1/webhooks/4", bytes.NewBufferString(`{"active":true}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+	mockService.On("Update", 4, map[string]any{"active": true}).Return(&webhookDomain.Webhook{ID: 4, Active: true}, nil)
+
+	controller.UpdateWebhook(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"active":true`)
+	mockService.AssertExpectations(t)
+}
+
+func TestWebhookController_InvalidID(t *testing.T) {
+	_, controller := setupController(t)
+	for name, handler := range map[string]gin.HandlerFunc{
+		"get":        controller.GetWebhookByID,
+		"update":     controller.UpdateWebhook,
+		"delete":     controller.DeleteWebhook,
+		"deliveries": controller.GetWebhookDeliveries,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := setupGinContext()
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/webhooks/abc", nil)
+			c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+			handler(c)
+
+			require.Len(t, c.Errors, 1)
+			appErr, ok := c.Errors[0].Err.(*domainErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+		})
+	}
+}
+
+func TestWebhookController_GetWebhookDeliveries(t *testing.T) {
+	mockService, controller := setupController(t)
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/webhooks/4/deliveries?status=failed&page=2&pageSize=5", nil)
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+	filters := domain.DataFilters{Page: 2, PageSize: 5, Matches: map[string][]string{"status": {"failed"}}}
+	mockService.On("GetDeliveries", 4, filters).Return(&webhookDomain.SearchResultDelivery{
+		Data:       &[]webhookDomain.Delivery{{ID: 9, EventID: "evt-1", Attempt: 2, Status: "failed", ResponseStatus: 500}},
+		Total:      6,
+		Page:       2,
+		PageSize:   5,
+		TotalPages: 2,
+	}, nil)
+
+	controller.GetWebhookDeliveries(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data  []ResponseDelivery `json:"data"`
+		Total int64              `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(6), response.Total)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, 500, response.Data[0].ResponseStatus)
+	mockService.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +21,11 @@ func (w bodyLogWriter) Write(b []byte) (int, error) {
 }
 
 func GinBodyLogMiddleware(c *gin.Context) {
+	// streams never end on their own, their body would be buffered forever
+	if isStream(c) {
+		c.Next()
+		return
+	}
 	blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
 	c.Writer = blw
 
@@ -45,3 +51,13 @@ func GinBodyLogMiddleware(c *gin.Context) {
 	}
 	_ = fmt.Sprintf("%v", allDataIO)
 }
+
+// streamRoutes is the group routes.StreamRoutes registers the Server-Sent Events and
+// WebSocket endpoints in
+const streamRoutes = "/v1/events/"
+
+// isStream reports whether c was routed to a streaming endpoint. The route decides, not
+// the headers: a client may request a stream without saying it accepts one.
+func isStream(c *gin.Context) bool {
+	return strings.HasPrefix(c.FullPath(), streamRoutes)
+}
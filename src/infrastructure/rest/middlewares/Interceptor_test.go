@@ -190,3 +190,32 @@ func TestGinBodyLogMiddleware_LargeBody(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
 }
+
+func TestGinBodyLogMiddleware_SkipsStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinBodyLogMiddleware)
+	buffered := map[string]bool{}
+	handler := func(c *gin.Context) {
+		_, buffered[c.Request.URL.Path] = c.Writer.(*bodyLogWriter)
+		c.Status(http.StatusOK)
+	}
+	router.GET("/v1/events/stream", handler)
+	router.GET("/v1/events/ws", handler)
+	router.GET("/v1/medicines", handler)
+
+	// no Accept or Upgrade header, as curl sends
+	for _, path := range []string{"/v1/events/stream", "/v1/events/ws", "/v1/medicines"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	}
+	if buffered["/v1/events/stream"] || buffered["/v1/events/ws"] {
+		t.Error("a stream response is buffered")
+	}
+	if !buffered["/v1/medicines"] {
+		t.Error("expected other responses to be buffered")
+	}
+}
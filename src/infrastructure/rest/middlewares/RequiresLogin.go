@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -60,6 +61,33 @@ func AuthJWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Every query is scoped to the caller's organization, so tokens without one are refused
+		tenantID, ok := claims["tenantId"].(float64)
+		if !ok || tenantID <= 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token organization"})
+			c.Abort()
+			return
+		}
+
+		if id, ok := claims["id"].(float64); ok {
+			principal := security.Principal{UserID: int(id), TenantID: int(tenantID)}
+			c.Set("userID", principal.UserID)
+			c.Set("tenantID", principal.TenantID)
+			c.Request = c.Request.WithContext(security.WithPrincipal(c.Request.Context(), principal))
+		}
+
+		c.Next()
+	}
+}
+
+// QueryTokenMiddleware lets clients unable to set headers, such as the browser
+// EventSource and WebSocket APIs, pass the access token in the param query parameter.
+// It must run before AuthJWTMiddleware; a token in the Authorization header wins.
+func QueryTokenMiddleware(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query(param); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
 		c.Next()
 	}
 }
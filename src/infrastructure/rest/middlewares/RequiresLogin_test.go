@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
@@ -199,6 +200,37 @@ func TestAuthJWTMiddleware_ValidToken(t *testing.T) {
 	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
 
 	// Create valid token
+	claims := jwt.MapClaims{
+		"exp":      time.Now().Add(1 * time.Hour).Unix(),
+		"type":     "access",
+		"id":       123,
+		"tenantId": 5,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, _ := token.SignedString([]byte("test-secret"))
+
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+
+	middleware := AuthJWTMiddleware()
+	middleware(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	principal, ok := security.PrincipalFromContext(c.Request.Context())
+	assert.True(t, ok)
+	assert.Equal(t, 123, principal.UserID)
+	assert.Equal(t, 5, principal.TenantID)
+	assert.Equal(t, 123, c.GetInt("userID"))
+	assert.Equal(t, 5, c.GetInt("tenantID"))
+}
+
+func TestAuthJWTMiddleware_MissingTenant(t *testing.T) {
+	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
+	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
+	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
+
 	claims := jwt.MapClaims{
 		"exp":  time.Now().Add(1 * time.Hour).Unix(),
 		"type": "access",
@@ -214,7 +246,9 @@ func TestAuthJWTMiddleware_ValidToken(t *testing.T) {
 	middleware := AuthJWTMiddleware()
 	middleware(c)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	_, ok := security.PrincipalFromContext(c.Request.Context())
+	assert.False(t, ok)
 }
 
 func TestAuthJWTMiddleware_TokenWithoutBearer(t *testing.T) {
@@ -225,9 +259,10 @@ func TestAuthJWTMiddleware_TokenWithoutBearer(t *testing.T) {
 
 	// Create valid token
 	claims := jwt.MapClaims{
-		"exp":  time.Now().Add(1 * time.Hour).Unix(),
-		"type": "access",
-		"id":   123,
+		"exp":      time.Now().Add(1 * time.Hour).Unix(),
+		"type":     "access",
+		"id":       123,
+		"tenantId": 5,
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, _ := token.SignedString([]byte("test-secret"))
@@ -243,3 +278,16 @@ func TestAuthJWTMiddleware_TokenWithoutBearer(t *testing.T) {
 	// because strings.TrimPrefix handles this case
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestQueryTokenMiddleware(t *testing.T) {
+	c, _ := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/v1/events/stream?access_token=abc", nil)
+	QueryTokenMiddleware("access_token")(c)
+	assert.Equal(t, "Bearer abc", c.Request.Header.Get("Authorization"))
+
+	c, _ = setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/v1/events/stream?access_token=abc", nil)
+	c.Request.Header.Set("Authorization", "Bearer header-token")
+	QueryTokenMiddleware("access_token")(c)
+	assert.Equal(t, "Bearer header-token", c.Request.Header.Get("Authorization"))
+}
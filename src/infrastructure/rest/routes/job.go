@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/job"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func JobRoutes(router *gin.RouterGroup, controller job.IJobController) {
+	jobs := router.Group("/jobs")
+	jobs.Use(middlewares.AuthJWTMiddleware())
+	{
+		jobs.GET("/", controller.GetAllJobs)
+		jobs.GET("/:id", controller.GetJobByID)
+		jobs.POST("/:id/retry", controller.RetryJob)
+		jobs.POST("/:id/cancel", controller.CancelJob)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/laboratory"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func LaboratoryRoutes(router *gin.RouterGroup, controller laboratory.ILaboratoryController) {
+	lab := router.Group("/laboratory")
+	lab.Use(middlewares.AuthJWTMiddleware())
+	{
+		lab.GET("/", controller.GetAllLaboratories)
+		lab.POST("/", controller.NewLaboratory)
+		lab.GET("/search", controller.SearchPaginated)
+		lab.GET("/:id", controller.GetLaboratoryByID)
+		lab.PUT("/:id", controller.UpdateLaboratory)
+		lab.DELETE("/:id", controller.DeleteLaboratory)
+	}
+}
@@ -17,5 +17,16 @@ func MedicineRoutes(router *gin.RouterGroup, controller medicine.IMedicineContro
 		med.DELETE("/:id", controller.DeleteMedicine)
 		med.GET("/search", controller.SearchPaginated)
 		med.GET("/search-property", controller.SearchByProperty)
+		med.GET("/trash", controller.GetTrash)
+		med.GET("/barcode/:code", controller.GetMedicineByBarcode)
+		med.POST("/:id/restore", controller.RestoreMedicine)
+		med.GET("/:id/history", controller.GetMedicineHistory)
+		med.GET("/:id/substitutes", controller.GetMedicineSubstitutes)
+		med.GET("/:id/equivalences", controller.GetMedicineEquivalences)
+		med.PUT("/:id/equivalences", controller.SetMedicineEquivalence)
+		med.DELETE("/equivalences/:equivalenceId", controller.DeleteEquivalence)
+		med.POST("/interactions/import", controller.ImportInteractions)
+		med.POST("/interactions/classes/import", controller.ImportIngredientClasses)
+		med.POST("/interactions/check", controller.CheckInteractions)
 	}
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/organization"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func OrganizationRoutes(router *gin.RouterGroup, controller organization.IOrganizationController) {
+	org := router.Group("/organization")
+	org.Use(middlewares.AuthJWTMiddleware())
+	{
+		org.POST("/", controller.NewOrganization)
+		org.GET("/current", controller.GetCurrentOrganization)
+	}
+}
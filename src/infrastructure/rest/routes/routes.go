@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gbrayhan/microservices-go/src/infrastructure/di"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/cache"
 	"github.com/gin-gonic/gin"
 )
 
@@ -11,13 +12,27 @@ func ApplicationRouter(router *gin.Engine, appContext *di.ApplicationContext) {
 	v1 := router.Group("/v1")
 
 	v1.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
+		response := gin.H{
 			"status":  "ok",
 			"message": "Service is running",
-		})
+		}
+		if len(appContext.CacheMetrics) > 0 {
+			stats := make(map[string]cache.Stats, len(appContext.CacheMetrics))
+			for name, metrics := range appContext.CacheMetrics {
+				stats[name] = metrics.Stats()
+			}
+			response["cache"] = stats
+		}
+		c.JSON(http.StatusOK, response)
 	})
 
 	AuthRoutes(v1, appContext.AuthController)
 	UserRoutes(v1, appContext.UserController)
 	MedicineRoutes(v1, appContext.MedicineController)
+	LaboratoryRoutes(v1, appContext.LaboratoryController)
+	OrganizationRoutes(v1, appContext.OrganizationController)
+	WebhookRoutes(v1, appContext.WebhookController)
+	StreamRoutes(v1, appContext.StreamController)
+	JobRoutes(v1, appContext.JobController)
+	StockRoutes(v1, appContext.StockController)
 }
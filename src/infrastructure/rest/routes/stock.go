@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func StockRoutes(router *gin.RouterGroup, controller stock.IStockController) {
+	medicines := router.Group("/medicine")
+	medicines.Use(middlewares.AuthJWTMiddleware())
+	{
+		medicines.GET("/:id/stock", controller.GetMedicineStock)
+		medicines.POST("/:id/stock/adjustments", controller.AdjustMedicineStock)
+		medicines.GET("/:id/stock/movements", controller.GetMedicineMovements)
+		medicines.GET("/:id/lots", controller.GetMedicineLots)
+		medicines.POST("/:id/lots", controller.ReceiveMedicineLot)
+		medicines.POST("/:id/dispense", controller.DispenseMedicine)
+		medicines.GET("/lots/expiring", controller.GetExpiringLots)
+		medicines.POST("/lots/:lotId/quarantine", controller.QuarantineLot)
+		medicines.GET("/:id/thresholds", controller.GetMedicineThresholds)
+		medicines.PUT("/:id/thresholds", controller.SetMedicineThreshold)
+		medicines.DELETE("/thresholds/:thresholdId", controller.DeleteThreshold)
+	}
+
+	locations := router.Group("/locations")
+	locations.Use(middlewares.AuthJWTMiddleware())
+	{
+		locations.POST("/", controller.NewLocation)
+		locations.GET("/", controller.GetLocations)
+		locations.GET("/:id", controller.GetLocationByID)
+		locations.PUT("/:id", controller.UpdateLocation)
+	}
+	transfers := router.Group("/transfers")
+	transfers.Use(middlewares.AuthJWTMiddleware())
+	{
+		transfers.POST("/", controller.RequestTransfer)
+		transfers.GET("/", controller.GetTransfers)
+		transfers.GET("/:id", controller.GetTransferByID)
+		transfers.POST("/:id/ship", controller.ShipTransfer)
+		transfers.POST("/:id/receive", controller.ReceiveTransfer)
+		transfers.POST("/:id/cancel", controller.CancelTransfer)
+	}
+	alerts := router.Group("/alerts")
+	alerts.Use(middlewares.AuthJWTMiddleware())
+	{
+		alerts.GET("/", controller.GetAlerts)
+		alerts.GET("/:id", controller.GetAlertByID)
+		alerts.POST("/:id/acknowledge", controller.AcknowledgeAlert)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/stream"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// StreamRoutes registers the streaming endpoints, whose responses never end. The body
+// log middleware recognizes them by their /v1/events/ path and leaves them unbuffered.
+func StreamRoutes(router *gin.RouterGroup, controller stream.IStreamController) {
+	events := router.Group("/events")
+	// browsers cannot set headers on EventSource and WebSocket connections
+	events.Use(middlewares.QueryTokenMiddleware("access_token"), middlewares.AuthJWTMiddleware())
+	{
+		events.GET("/stream", controller.Stream)
+		events.GET("/ws", controller.WebSocket)
+	}
+}
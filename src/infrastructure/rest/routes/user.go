@@ -17,5 +17,8 @@ func UserRoutes(router *gin.RouterGroup, controller user.IUserController) {
 		u.DELETE("/:id", controller.DeleteUser)
 		u.GET("/search", controller.SearchPaginated)
 		u.GET("/search-property", controller.SearchByProperty)
+		u.GET("/trash", controller.GetTrash)
+		u.POST("/:id/restore", controller.RestoreUser)
+		u.GET("/:id/history", controller.GetUserHistory)
 	}
 }
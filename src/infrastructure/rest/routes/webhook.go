@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/webhook"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func WebhookRoutes(router *gin.RouterGroup, controller webhook.IWebhookController) {
+	hooks := router.Group("/webhooks")
+	hooks.Use(middlewares.AuthJWTMiddleware())
+	{
+		hooks.POST("/", controller.NewWebhook)
+		hooks.GET("/", controller.GetAllWebhooks)
+		hooks.GET("/:id", controller.GetWebhookByID)
+		hooks.PUT("/:id", controller.UpdateWebhook)
+		hooks.DELETE("/:id", controller.DeleteWebhook)
+		hooks.GET("/:id/deliveries", controller.GetWebhookDeliveries)
+	}
+}
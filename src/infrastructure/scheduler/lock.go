@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"sync"
+)
+
+// AdvisoryLock elects the leader of each schedule with a PostgreSQL session advisory
+// lock. The locks are held on one dedicated connection: when the replica dies or
+// loses the connection, the server releases them and another replica takes the lead.
+type AdvisoryLock struct {
+	db *sql.DB
+
+	mu   sync.Mutex
+	conn *sql.Conn
+	held map[string]bool
+}
+
+func NewAdvisoryLock(db *sql.DB) *AdvisoryLock {
+	return &AdvisoryLock{db: db, held: make(map[string]bool)}
+}
+
+// LockKey returns the advisory lock key of schedule name
+func LockKey(name string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("scheduler:" + name))
+	return int64(hash.Sum64())
+}
+
+func (l *AdvisoryLock) Lead(ctx context.Context, name string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		conn, err := l.db.Conn(ctx)
+		if err != nil {
+			return false, err
+		}
+		l.conn = conn
+	}
+	if l.held[name] {
+		// the lock lasts as long as the session: check that it is still open
+		if err := l.conn.PingContext(ctx); err != nil {
+			l.reset()
+			return false, err
+		}
+		return true, nil
+	}
+	var locked bool
+	if err := l.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", LockKey(name)).Scan(&locked); err != nil {
+		l.reset()
+		return false, err
+	}
+	l.held[name] = locked
+	return locked, nil
+}
+
+// Close ends the session, which releases every lock it holds
+func (l *AdvisoryLock) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		l.reset()
+	}
+	return nil
+}
+
+// reset ends the session rather than returning it to the pool, where it would keep
+// its locks
+func (l *AdvisoryLock) reset() {
+	_ = l.conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = l.conn.Close()
+	l.conn = nil
+	clear(l.held)
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockKey(t *testing.T) {
+	assert.Equal(t, LockKey("records.purge"), LockKey("records.purge"))
+	assert.NotEqual(t, LockKey("records.purge"), LockKey("stock.alerts"))
+}
+
+func TestAdvisoryLock_Lead(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	lock := NewAdvisoryLock(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(LockKey("purge")).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(LockKey("reports")).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	leading, err := lock.Lead(ctx, "purge")
+	require.NoError(t, err)
+	assert.True(t, leading)
+	leading, err = lock.Lead(ctx, "reports")
+	require.NoError(t, err)
+	assert.False(t, leading, "another replica holds the lock")
+
+	// a held lock is not taken again, the session is only checked
+	mock.ExpectPing()
+	leading, err = lock.Lead(ctx, "purge")
+	require.NoError(t, err)
+	assert.True(t, leading)
+
+	// losing the session loses the lead
+	mock.ExpectPing().WillReturnError(errors.New("connection reset"))
+	leading, err = lock.Lead(ctx, "purge")
+	assert.Error(t, err)
+	assert.False(t, leading)
+	assert.Empty(t, lock.held)
+
+	assert.NoError(t, lock.Close())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,231 @@
+// Package scheduler runs recurring maintenance tasks on cron schedules. A task only
+// enqueues a background job, so the work itself is retried and observed like any
+// other job. Every replica runs a scheduler, but each schedule fires on the single
+// replica that leads it.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/jobs"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// Disabled is the schedule of a task that never runs
+const Disabled = "off"
+
+// envPrefix starts the variables overriding the schedule of a task
+const envPrefix = "SCHEDULE_"
+
+// Config holds the scheduler settings
+type Config struct {
+	Enabled bool
+	// Tick is the longest wait between two checks of leadership
+	Tick time.Duration
+	// Specs override the default schedules, by environment variable name
+	Specs map[string]string
+}
+
+// LoadConfig loads scheduler configuration from environment variables. The schedule
+// of a task named "records.purge" is read from SCHEDULE_RECORDS_PURGE.
+func LoadConfig() Config {
+	specs := make(map[string]string)
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		if strings.HasPrefix(name, envPrefix) && value != "" {
+			specs[name] = value
+		}
+	}
+	return Config{
+		Enabled: os.Getenv("SCHEDULER_ENABLED") != "false",
+		Tick:    time.Duration(config.EnvAsIntOrDefault("SCHEDULER_TICK_SECONDS", 15)) * time.Second,
+		Specs:   specs,
+	}
+}
+
+// EnvName returns the environment variable overriding the schedule of task name
+func EnvName(name string) string {
+	return envPrefix + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Task does the work of a schedule for the fire time at
+type Task func(ctx context.Context, at time.Time) error
+
+// EnqueueTask returns a Task that enqueues a job of kind with args
+func EnqueueTask[A any](runner *jobs.Runner, kind jobs.Kind[A], args A) Task {
+	return func(ctx context.Context, _ time.Time) error {
+		_, err := jobs.Enqueue(ctx, runner, kind, args)
+		return err
+	}
+}
+
+// Elector decides which replica runs a schedule
+type Elector interface {
+	// Lead reports whether this replica leads schedule name, taking the lead when
+	// no other replica has it
+	Lead(ctx context.Context, name string) (bool, error)
+	// Close gives up every schedule led by this replica
+	Close() error
+}
+
+// Local leads every schedule: it suits deployments of a single replica
+type Local struct{}
+
+func (Local) Lead(context.Context, string) (bool, error) { return true, nil }
+
+func (Local) Close() error { return nil }
+
+type entry struct {
+	name     string
+	spec     string
+	schedule cron.Schedule
+	task     Task
+	// next is the fire time, zero while another replica leads the schedule
+	next time.Time
+}
+
+// Scheduler fires the tasks added to it on their schedule
+type Scheduler struct {
+	config  Config
+	elector Elector
+	Logger  *logger.Logger
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries []*entry
+}
+
+func New(config Config, elector Elector, loggerInstance *logger.Logger) *Scheduler {
+	return &Scheduler{
+		config:  config,
+		elector: elector,
+		Logger:  loggerInstance,
+		now:     time.Now,
+	}
+}
+
+// Add schedules task under name with spec, a standard five-field cron expression or
+// a descriptor such as "@daily", unless the configuration overrides it. A schedule
+// of "off" or "" disables the task.
+func (s *Scheduler) Add(name, spec string, task Task) error {
+	if override, ok := s.config.Specs[EnvName(name)]; ok {
+		spec = override
+	}
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == Disabled {
+		s.Logger.Info("Schedule disabled", zap.String("schedule", name))
+		return nil
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for %s: %w", spec, name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.name == name {
+			return fmt.Errorf("schedule %s is already added", name)
+		}
+	}
+	s.entries = append(s.entries, &entry{name: name, spec: spec, schedule: schedule, task: task})
+	return nil
+}
+
+// Start fires the schedules in the background until ctx is cancelled, then gives up
+// their lead
+func (s *Scheduler) Start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	if !s.config.Enabled || len(s.entries) == 0 {
+		s.Logger.Info("Scheduler disabled")
+		return
+	}
+	tick := s.config.Tick
+	if tick <= 0 {
+		tick = 15 * time.Second
+	}
+	s.Logger.Info("Starting scheduler", zap.Int("schedules", len(s.entries)), zap.Duration("tick", tick))
+
+	go func() {
+		defer func() {
+			if err := s.elector.Close(); err != nil {
+				s.Logger.Warn("Error giving up schedules", zap.Error(err))
+			}
+			s.Logger.Info("Scheduler stopped")
+		}()
+		for {
+			wait := tick
+			if next := s.RunDue(ctx); !next.IsZero() {
+				wait = min(wait, max(next.Sub(s.now()), 0))
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// RunDue fires the schedules led by this replica whose time has come and returns the
+// earliest next fire time, zero when it leads none. A schedule whose lead was just
+// taken fires from now on: the previous leader ran the earlier times.
+func (s *Scheduler) RunDue(ctx context.Context) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var earliest time.Time
+	for _, e := range s.entries {
+		leading, err := s.elector.Lead(ctx, e.name)
+		if err != nil {
+			s.Logger.Warn("Error electing schedule leader", zap.String("schedule", e.name), zap.Error(err))
+		}
+		if !leading {
+			if !e.next.IsZero() {
+				s.Logger.Info("Lost lead of schedule", zap.String("schedule", e.name))
+			}
+			e.next = time.Time{}
+			continue
+		}
+		now := s.now()
+		if e.next.IsZero() {
+			e.next = e.schedule.Next(now)
+			s.Logger.Info("Leading schedule",
+				zap.String("schedule", e.name), zap.String("spec", e.spec), zap.Time("next", e.next))
+		}
+		if !now.Before(e.next) {
+			s.fire(ctx, e)
+			e.next = e.schedule.Next(now)
+		}
+		if earliest.IsZero() || e.next.Before(earliest) {
+			earliest = e.next
+		}
+	}
+	return earliest
+}
+
+func (s *Scheduler) fire(ctx context.Context, e *entry) {
+	if err := e.task(ctx, e.next); err != nil {
+		s.Logger.Error("Error running schedule", zap.String("schedule", e.name), zap.Error(err))
+		return
+	}
+	s.Logger.Info("Ran schedule", zap.String("schedule", e.name), zap.Time("at", e.next))
+}
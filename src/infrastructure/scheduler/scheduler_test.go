@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	domainJob "github.com/gbrayhan/microservices-go/src/domain/job"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/jobs"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	memoryJob "github.com/gbrayhan/microservices-go/src/infrastructure/repository/memory/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeElector leads the schedules listed in leading
+type fakeElector struct {
+	leading map[string]bool
+	err     error
+	closed  atomic.Bool
+}
+
+func (f *fakeElector) Lead(_ context.Context, name string) (bool, error) {
+	return f.leading[name], f.err
+}
+
+func (f *fakeElector) Close() error {
+	f.closed.Store(true)
+	return nil
+}
+
+func setupScheduler(t *testing.T, config Config, elector Elector) (*Scheduler, *time.Time) {
+	t.Helper()
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	scheduler := New(config, elector, loggerInstance)
+	now := time.Date(2026, 1, 1, 2, 59, 0, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+	return scheduler, &now
+}
+
+func TestLoadConfig(t *testing.T) {
+	os.Setenv("SCHEDULER_TICK_SECONDS", "5")
+	os.Setenv("SCHEDULE_RECORDS_PURGE", "@hourly")
+	defer os.Unsetenv("SCHEDULER_TICK_SECONDS")
+	defer os.Unsetenv("SCHEDULE_RECORDS_PURGE")
+
+	config := LoadConfig()
+
+	assert.True(t, config.Enabled)
+	assert.Equal(t, 5*time.Second, config.Tick)
+	assert.Equal(t, "@hourly", config.Specs[EnvName("records.purge")])
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "SCHEDULE_RECORDS_PURGE", EnvName("records.purge"))
+	assert.Equal(t, "SCHEDULE_STOCK_ALERTS_2", EnvName("stock-alerts.2"))
+}
+
+func TestScheduler_Add(t *testing.T) {
+	scheduler, _ := setupScheduler(t, Config{Specs: map[string]string{
+		"SCHEDULE_REPORTS": Disabled,
+		"SCHEDULE_PURGE":   "*/5 * * * *",
+	}}, Local{})
+	noop := func(context.Context, time.Time) error { return nil }
+
+	assert.Error(t, scheduler.Add("broken", "61 * * * *", noop))
+	assert.NoError(t, scheduler.Add("reports", "@daily", noop))
+	assert.NoError(t, scheduler.Add("purge", "0 3 * * *", noop))
+	assert.Error(t, scheduler.Add("purge", "@daily", noop))
+
+	require.Len(t, scheduler.entries, 1, "reports is disabled")
+	assert.Equal(t, "*/5 * * * *", scheduler.entries[0].spec)
+}
+
+func TestScheduler_RunDue(t *testing.T) {
+	elector := &fakeElector{leading: map[string]bool{"purge": true}}
+	scheduler, now := setupScheduler(t, Config{}, elector)
+	var fired []time.Time
+	require.NoError(t, scheduler.Add("purge", "0 3 * * *", func(_ context.Context, at time.Time) error {
+		fired = append(fired, at)
+		return nil
+	}))
+	require.NoError(t, scheduler.Add("reports", "@hourly", func(context.Context, time.Time) error {
+		t.Fatal("reports is led by another replica")
+		return nil
+	}))
+
+	threeAM := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	assert.Equal(t, threeAM, scheduler.RunDue(context.Background()))
+	assert.Empty(t, fired)
+
+	*now = threeAM.Add(10 * time.Second)
+	assert.Equal(t, threeAM.Add(24*time.Hour), scheduler.RunDue(context.Background()))
+	assert.Equal(t, []time.Time{threeAM}, fired)
+
+	scheduler.RunDue(context.Background())
+	assert.Len(t, fired, 1, "each fire time runs once")
+}
+
+func TestScheduler_TakingTheLeadDoesNotCatchUp(t *testing.T) {
+	elector := &fakeElector{leading: map[string]bool{}}
+	scheduler, now := setupScheduler(t, Config{}, elector)
+	fired := 0
+	require.NoError(t, scheduler.Add("purge", "0 3 * * *", func(context.Context, time.Time) error {
+		fired++
+		return nil
+	}))
+
+	assert.True(t, scheduler.RunDue(context.Background()).IsZero())
+
+	// the leader ran 03:00 and then stopped
+	*now = time.Date(2026, 1, 1, 3, 0, 30, 0, time.UTC)
+	elector.leading["purge"] = true
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC), scheduler.RunDue(context.Background()))
+	assert.Zero(t, fired)
+}
+
+func TestScheduler_ElectionError(t *testing.T) {
+	elector := &fakeElector{leading: map[string]bool{"purge": true}}
+	scheduler, now := setupScheduler(t, Config{}, elector)
+	fired := 0
+	require.NoError(t, scheduler.Add("purge", "0 3 * * *", func(context.Context, time.Time) error {
+		fired++
+		return nil
+	}))
+	scheduler.RunDue(context.Background())
+
+	*now = time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	elector.leading["purge"], elector.err = false, errors.New("connection reset")
+	scheduler.RunDue(context.Background())
+	assert.Zero(t, fired)
+	assert.True(t, scheduler.entries[0].next.IsZero())
+}
+
+func TestScheduler_StartClosesElector(t *testing.T) {
+	elector := &fakeElector{leading: map[string]bool{}}
+	scheduler, _ := setupScheduler(t, Config{Enabled: true, Tick: time.Hour}, elector)
+	require.NoError(t, scheduler.Add("purge", "@daily", func(context.Context, time.Time) error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler.Start(ctx)
+	cancel()
+
+	assert.Eventually(t, elector.closed.Load, time.Second, 10*time.Millisecond)
+
+	var nilScheduler *Scheduler
+	assert.NotPanics(t, func() { nilScheduler.Start(context.Background()) })
+}
+
+func TestEnqueueTask(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	repository := memoryJob.NewJobRepository(loggerInstance)
+	runner := jobs.NewRunner(repository, jobs.Config{}, loggerInstance)
+	kind := jobs.Kind[struct{}]{Name: "records.purge", Queue: "maintenance"}
+
+	task := EnqueueTask(runner, kind, struct{}{})
+	require.NoError(t, task(context.Background(), time.Now()))
+
+	job, err := repository.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "records.purge", job.Type)
+	assert.Equal(t, "maintenance", job.Queue)
+	assert.Equal(t, domainJob.StatusPending, job.Status)
+}
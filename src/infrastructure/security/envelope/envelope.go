@@ -0,0 +1,261 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	prefix  = "enc:v1:"
+	keySize = 32
+)
+
+var (
+	// ErrNoKeys is returned when an encrypted value is read without any configured key
+	ErrNoKeys = errors.New("envelope: value is encrypted but no master keys are configured")
+	// ErrUnknownKey is returned when a value was encrypted with a master key that is not configured
+	ErrUnknownKey = errors.New("envelope: value is encrypted with an unknown master key")
+	// ErrMalformed is returned for values carrying the envelope prefix that cannot be parsed
+	ErrMalformed = errors.New("envelope: malformed encrypted value")
+)
+
+// Config holds the encryption settings as read from the environment
+type Config struct {
+	// MasterKeys lists "id:base64key" pairs separated by commas
+	MasterKeys string
+	// ActiveKeyID names the master key used for new values; defaults to the last listed
+	ActiveKeyID string
+	// BlindIndexKey is the base64 key of the blind indexes
+	BlindIndexKey string
+}
+
+// LoadConfig loads encryption configuration from environment variables
+func LoadConfig() Config {
+	return Config{
+		MasterKeys:    os.Getenv("PII_MASTER_KEYS"),
+		ActiveKeyID:   os.Getenv("PII_ACTIVE_KEY_ID"),
+		BlindIndexKey: os.Getenv("PII_BLIND_INDEX_KEY"),
+	}
+}
+
+// Cipher encrypts values with envelope encryption: every value gets its own random
+// data key, which is wrapped by a master key and stored next to the ciphertext together
+// with the master key id. Rotating a master key therefore only rewraps data keys.
+//
+// Encrypted values look like "enc:v1:<key id>:<wrapped data key>:<ciphertext>". Values
+// without that prefix are treated as plaintext written before encryption was enabled.
+//
+// A nil Cipher means encryption is disabled: values are stored and returned unchanged.
+type Cipher struct {
+	masterKeys  map[string]cipher.AEAD
+	activeKeyID string
+	indexKey    []byte
+}
+
+// NewCipher builds a Cipher from the configuration. It returns nil when no master
+// keys are configured.
+func NewCipher(config Config) (*Cipher, error) {
+	if strings.TrimSpace(config.MasterKeys) == "" {
+		return nil, nil
+	}
+	c := &Cipher{masterKeys: make(map[string]cipher.AEAD)}
+	for _, pair := range strings.Split(config.MasterKeys, ",") {
+		id, encodedKey, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || id == "" {
+			return nil, fmt.Errorf("envelope: master key %q must be written as id:base64key", pair)
+		}
+		key, err := decodeKey(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("envelope: master key %q: %w", id, err)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		c.masterKeys[id] = aead
+		c.activeKeyID = id
+	}
+	if config.ActiveKeyID != "" {
+		if _, found := c.masterKeys[config.ActiveKeyID]; !found {
+			return nil, fmt.Errorf("envelope: active key %q is not among the master keys", config.ActiveKeyID)
+		}
+		c.activeKeyID = config.ActiveKeyID
+	}
+	indexKey, err := decodeKey(config.BlindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: blind index key: %w", err)
+	}
+	c.indexKey = indexKey
+	return c, nil
+}
+
+// ActiveKeyID returns the id of the master key wrapping new data keys
+func (c *Cipher) ActiveKeyID() string {
+	if c == nil {
+		return ""
+	}
+	return c.activeKeyID
+}
+
+// IsEncrypted reports whether value is an encrypted envelope
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt seals plaintext under a fresh data key wrapped by the active master key
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if c == nil {
+		return plaintext, nil
+	}
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	body, err := seal(dataAEAD, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return c.wrap(dataKey, body)
+}
+
+// Decrypt opens an encrypted value; plaintext values are returned unchanged
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrNoKeys
+	}
+	_, dataKey, body, err := c.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, body, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rotate returns value protected by the active master key and whether it changed.
+// Plaintext values are encrypted; values under another master key have their data
+// key rewrapped, leaving the ciphertext itself untouched.
+func (c *Cipher) Rotate(value string) (string, bool, error) {
+	if c == nil {
+		return value, false, nil
+	}
+	if !IsEncrypted(value) {
+		encrypted, err := c.Encrypt(value)
+		return encrypted, err == nil, err
+	}
+	keyID, dataKey, body, err := c.unwrap(value)
+	if err != nil {
+		return "", false, err
+	}
+	if keyID == c.activeKeyID {
+		return value, false, nil
+	}
+	rotated, err := c.wrap(dataKey, body)
+	return rotated, err == nil, err
+}
+
+// BlindIndex returns a keyed hash of value that allows exact-match lookups without
+// decrypting. The domain, usually the index column, keeps equal values stored in
+// different columns from sharing an index.
+func (c *Cipher) BlindIndex(domain, value string) string {
+	if c == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(domain))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Cipher) wrap(dataKey, body []byte) (string, error) {
+	wrappedKey, err := seal(c.masterKeys[c.activeKeyID], dataKey, []byte(c.activeKeyID))
+	if err != nil {
+		return "", err
+	}
+	return prefix + c.activeKeyID + ":" +
+		base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(body), nil
+}
+
+func (c *Cipher) unwrap(value string) (keyID string, dataKey, body []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+	keyID = parts[0]
+	masterAEAD, found := c.masterKeys[keyID]
+	if !found {
+		return "", nil, nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	body, err = base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	dataKey, err = open(masterAEAD, wrappedKey, []byte(keyID))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return keyID, dataKey, body, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext and prepends the random nonce
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,124 @@
+package envelope
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(fill), keySize)))
+}
+
+func newTestCipher(t *testing.T, masterKeys, activeKeyID string) *Cipher {
+	t.Helper()
+	c, err := NewCipher(Config{MasterKeys: masterKeys, ActiveKeyID: activeKeyID, BlindIndexKey: testKey('i')})
+	require.NoError(t, err)
+	return c
+}
+
+func TestCipher_EncryptDecrypt(t *testing.T) {
+	c := newTestCipher(t, "k1:"+testKey('a'), "")
+
+	first, err := c.Encrypt("john@example.com")
+	require.NoError(t, err)
+	second, err := c.Encrypt("john@example.com")
+	require.NoError(t, err)
+
+	assert.True(t, IsEncrypted(first))
+	assert.True(t, strings.HasPrefix(first, "enc:v1:k1:"))
+	assert.NotContains(t, first, "john")
+	assert.NotEqual(t, first, second, "every value gets its own data key and nonce")
+
+	plaintext, err := c.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", plaintext)
+
+	legacy, err := c.Decrypt("plain@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "plain@example.com", legacy)
+}
+
+func TestCipher_DecryptFailures(t *testing.T) {
+	c := newTestCipher(t, "k1:"+testKey('a'), "")
+	other := newTestCipher(t, "k2:"+testKey('b'), "")
+	encrypted, err := other.Encrypt("secret")
+	require.NoError(t, err)
+
+	_, err = c.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = c.Decrypt("enc:v1:k1:garbage")
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	tampered := encrypted[:len(encrypted)-2] + "AA"
+	_, err = other.Decrypt(tampered)
+	assert.Error(t, err)
+
+	var disabled *Cipher
+	_, err = disabled.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrNoKeys)
+}
+
+func TestCipher_Rotate(t *testing.T) {
+	old := newTestCipher(t, "k1:"+testKey('a'), "")
+	rotating := newTestCipher(t, "k1:"+testKey('a')+",k2:"+testKey('b'), "")
+	assert.Equal(t, "k2", rotating.ActiveKeyID(), "the last listed key is active by default")
+
+	encrypted, err := old.Encrypt("Jane")
+	require.NoError(t, err)
+
+	rotated, changed, err := rotating.Rotate(encrypted)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, strings.HasPrefix(rotated, "enc:v1:k2:"))
+	assert.Equal(t, strings.SplitN(encrypted, ":", 5)[4], strings.SplitN(rotated, ":", 5)[4], "only the data key is rewrapped")
+	plaintext, err := rotating.Decrypt(rotated)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", plaintext)
+
+	_, changed, err = rotating.Rotate(rotated)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	fromPlaintext, changed, err := rotating.Rotate("Jane")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, IsEncrypted(fromPlaintext))
+}
+
+func TestCipher_BlindIndex(t *testing.T) {
+	c := newTestCipher(t, "k1:"+testKey('a'), "")
+	rotated := newTestCipher(t, "k2:"+testKey('b'), "")
+
+	assert.Equal(t, c.BlindIndex("email_index", "a@b.com"), c.BlindIndex("email_index", "a@b.com"))
+	assert.Equal(t, c.BlindIndex("email_index", "a@b.com"), rotated.BlindIndex("email_index", "a@b.com"),
+		"blind indexes do not depend on the master keys")
+	assert.NotEqual(t, c.BlindIndex("email_index", "a@b.com"), c.BlindIndex("first_name_index", "a@b.com"))
+	assert.NotEqual(t, c.BlindIndex("email_index", "a@b.com"), c.BlindIndex("email_index", "A@b.com"))
+}
+
+func TestNewCipher(t *testing.T) {
+	disabled, err := NewCipher(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, disabled)
+	value, err := disabled.Encrypt("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", value)
+
+	active := newTestCipher(t, "k1:"+testKey('a')+",k2:"+testKey('b'), "k1")
+	assert.Equal(t, "k1", active.ActiveKeyID())
+
+	invalid := []Config{
+		{MasterKeys: "k1", BlindIndexKey: testKey('i')},
+		{MasterKeys: "k1:short", BlindIndexKey: testKey('i')},
+		{MasterKeys: "k1:" + testKey('a'), ActiveKeyID: "k9", BlindIndexKey: testKey('i')},
+		{MasterKeys: "k1:" + testKey('a')},
+	}
+	for _, config := range invalid {
+		_, err := NewCipher(config)
+		assert.Error(t, err, "%+v", config)
+	}
+}
@@ -3,11 +3,10 @@ package security
 import (
 	"errors"
 	"fmt"
-	"os"
-	"strconv"
 	"time"
 
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -23,8 +22,9 @@ type AppToken struct {
 }
 
 type Claims struct {
-	ID   int    `json:"id"`
-	Type string `json:"type"`
+	ID       int    `json:"id"`
+	TenantID int    `json:"tenantId"`
+	Type     string `json:"type"`
 	jwt.RegisteredClaims
 }
 
@@ -38,7 +38,7 @@ type JWTConfig struct {
 
 // IJWTService defines the interface for JWT operations
 type IJWTService interface {
-	GenerateJWTToken(userID int, tokenType string) (*AppToken, error)
+	GenerateJWTToken(userID int, tenantID int, tokenType string) (*AppToken, error)
 	GetClaimsAndVerifyToken(tokenString string, tokenType string) (jwt.MapClaims, error)
 }
 
@@ -65,15 +65,15 @@ func NewJWTServiceWithConfig(config JWTConfig) IJWTService {
 // loadJWTConfig loads JWT configuration from environment variables
 func loadJWTConfig() JWTConfig {
 	return JWTConfig{
-		AccessSecret:  getEnvOrDefault("JWT_ACCESS_SECRET_KEY", "default_access_secret"),
-		RefreshSecret: getEnvOrDefault("JWT_REFRESH_SECRET_KEY", "default_refresh_secret"),
-		AccessTime:    getEnvAsInt64OrDefault("JWT_ACCESS_TIME_MINUTE", 60),
-		RefreshTime:   getEnvAsInt64OrDefault("JWT_REFRESH_TIME_HOUR", 24),
+		AccessSecret:  config.EnvOrDefault("JWT_ACCESS_SECRET_KEY", "default_access_secret"),
+		RefreshSecret: config.EnvOrDefault("JWT_REFRESH_SECRET_KEY", "default_refresh_secret"),
+		AccessTime:    config.EnvAsInt64OrDefault("JWT_ACCESS_TIME_MINUTE", 60),
+		RefreshTime:   config.EnvAsInt64OrDefault("JWT_REFRESH_TIME_HOUR", 24),
 	}
 }
 
-// GenerateJWTToken generates a JWT token for the given user ID and type
-func (s *JWTService) GenerateJWTToken(userID int, tokenType string) (*AppToken, error) {
+// GenerateJWTToken generates a JWT token of the given type for a user of the given organization
+func (s *JWTService) GenerateJWTToken(userID int, tenantID int, tokenType string) (*AppToken, error) {
 	var secretKey string
 	var duration time.Duration
 
@@ -92,8 +92,9 @@ func (s *JWTService) GenerateJWTToken(userID int, tokenType string) (*AppToken,
 	expirationTokenTime := nowTime.Add(duration)
 
 	tokenClaims := &Claims{
-		ID:   userID,
-		Type: tokenType,
+		ID:       userID,
+		TenantID: tenantID,
+		Type:     tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTokenTime),
 		},
@@ -171,20 +172,3 @@ func (s *JWTService) GetClaimsAndVerifyToken(tokenString string, tokenType strin
 
 	return claims, nil
 }
-
-// Helper functions
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvAsInt64OrDefault(key string, defaultValue int64) int64 {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
-}
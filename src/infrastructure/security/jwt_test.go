@@ -58,7 +58,7 @@ func TestGenerateJWTToken_Access(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 	assert.NotNil(t, token)
 	assert.Equal(t, Access, token.TokenType)
@@ -75,7 +75,7 @@ func TestGenerateJWTToken_Refresh(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 456
-	token, err := service.GenerateJWTToken(userID, Refresh)
+	token, err := service.GenerateJWTToken(userID, 1, Refresh)
 	require.NoError(t, err)
 	assert.NotNil(t, token)
 	assert.Equal(t, Refresh, token.TokenType)
@@ -92,7 +92,7 @@ func TestGenerateJWTToken_InvalidType(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, "invalid_type")
+	token, err := service.GenerateJWTToken(userID, 1, "invalid_type")
 	assert.Error(t, err)
 	assert.Nil(t, token)
 	assert.Contains(t, err.Error(), "invalid token type")
@@ -108,7 +108,7 @@ func TestGenerateJWTToken_EmptySecret(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	// This should still work with empty secrets (they're just empty strings)
 	require.NoError(t, err)
 	assert.NotNil(t, token)
@@ -124,12 +124,13 @@ func TestGetClaimsAndVerifyToken_ValidAccessToken(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 
 	claims, err := service.GetClaimsAndVerifyToken(token.Token, Access)
 	require.NoError(t, err)
 	assert.Equal(t, float64(userID), claims["id"])
+	assert.Equal(t, float64(1), claims["tenantId"])
 	assert.Equal(t, Access, claims["type"])
 	assert.NotNil(t, claims["exp"])
 }
@@ -144,7 +145,7 @@ func TestGetClaimsAndVerifyToken_ValidRefreshToken(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 456
-	token, err := service.GenerateJWTToken(userID, Refresh)
+	token, err := service.GenerateJWTToken(userID, 1, Refresh)
 	require.NoError(t, err)
 
 	claims, err := service.GetClaimsAndVerifyToken(token.Token, Refresh)
@@ -179,7 +180,7 @@ func TestGetClaimsAndVerifyToken_WrongTokenType(t *testing.T) {
 
 	// Generate access token but try to verify as refresh token
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 
 	claims, err := service.GetClaimsAndVerifyToken(token.Token, Refresh)
@@ -197,7 +198,7 @@ func TestGetClaimsAndVerifyToken_ExpiredToken(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 
 	// Wait for token to expire
@@ -378,40 +379,6 @@ func TestGetClaimsAndVerifyToken_TokenWithMissingExpiration(t *testing.T) {
 	assert.Nil(t, claims)
 }
 
-func TestGetEnvOrDefault(t *testing.T) {
-	// Test with environment variable set
-	os.Setenv("TEST_KEY", "test_value")
-	result := getEnvOrDefault("TEST_KEY", "default_value")
-	assert.Equal(t, "test_value", result)
-
-	// Test with environment variable not set
-	result = getEnvOrDefault("NONEXISTENT_KEY", "default_value")
-	assert.Equal(t, "default_value", result)
-
-	// Clean up
-	os.Unsetenv("TEST_KEY")
-}
-
-func TestGetEnvAsInt64OrDefault(t *testing.T) {
-	// Test with valid integer environment variable
-	os.Setenv("TEST_INT", "123")
-	result := getEnvAsInt64OrDefault("TEST_INT", 456)
-	assert.Equal(t, int64(123), result)
-
-	// Test with invalid integer environment variable
-	os.Setenv("TEST_INVALID", "not_a_number")
-	result = getEnvAsInt64OrDefault("TEST_INVALID", 456)
-	assert.Equal(t, int64(456), result)
-
-	// Test with environment variable not set
-	result = getEnvAsInt64OrDefault("NONEXISTENT_INT", 789)
-	assert.Equal(t, int64(789), result)
-
-	// Clean up
-	os.Unsetenv("TEST_INT")
-	os.Unsetenv("TEST_INVALID")
-}
-
 func TestJWTService_InterfaceCompliance(t *testing.T) {
 	var _ IJWTService = (*JWTService)(nil)
 }
@@ -426,7 +393,7 @@ func TestGenerateJWTToken_EdgeCases(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 
 	require.NoError(t, err)
 	assert.NotNil(t, token)
@@ -443,7 +410,7 @@ func TestGenerateJWTToken_NegativeUserID(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := -123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 	assert.NotNil(t, token)
 }
@@ -458,7 +425,7 @@ func TestGenerateJWTToken_ZeroUserID(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 0
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 	assert.NotNil(t, token)
 }
@@ -473,7 +440,7 @@ func TestGenerateJWTToken_LargeUserID(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 999999999
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 	assert.NotNil(t, token)
 }
@@ -488,7 +455,7 @@ func TestGetClaimsAndVerifyToken_WithDifferentSecrets(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 
 	// Should work with access secret
@@ -512,7 +479,7 @@ func TestGetClaimsAndVerifyToken_WithVeryLongSecrets(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 
 	claims, err := service.GetClaimsAndVerifyToken(token.Token, Access)
@@ -530,7 +497,7 @@ func TestGetClaimsAndVerifyToken_WithSpecialCharactersInSecrets(t *testing.T) {
 	service := NewJWTServiceWithConfig(config)
 
 	userID := 123
-	token, err := service.GenerateJWTToken(userID, Access)
+	token, err := service.GenerateJWTToken(userID, 1, Access)
 	require.NoError(t, err)
 
 	claims, err := service.GetClaimsAndVerifyToken(token.Token, Access)
@@ -0,0 +1,46 @@
+package security
+
+import "context"
+
+// Principal identifies the authenticated user performing a request and the
+// organization (tenant) whose data the request may access
+type Principal struct {
+	UserID   int
+	TenantID int
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal stored in ctx, if any
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	if ctx == nil {
+		return Principal{}, false
+	}
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+// ActorID returns the user ID of the principal stored in ctx, or nil for anonymous or system operations
+func ActorID(ctx context.Context) *int {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.UserID == 0 {
+		return nil
+	}
+	userID := principal.UserID
+	return &userID
+}
+
+// TenantID returns the organization the principal stored in ctx belongs to. ok is false
+// for anonymous or system operations, which are not restricted to a tenant.
+func TenantID(ctx context.Context) (tenantID int, ok bool) {
+	principal, found := PrincipalFromContext(ctx)
+	if !found || principal.TenantID == 0 {
+		return 0, false
+	}
+	return principal.TenantID, true
+}
@@ -0,0 +1,42 @@
+package security
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipalContext(t *testing.T) {
+	ctx := WithPrincipal(context.Background(), Principal{UserID: 42})
+
+	principal, ok := PrincipalFromContext(ctx)
+
+	assert.True(t, ok)
+	assert.Equal(t, 42, principal.UserID)
+	assert.Equal(t, 42, *ActorID(ctx))
+}
+
+func TestPrincipalFromContext_Missing(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	assert.False(t, ok)
+
+	//nolint:staticcheck // nil context is handled explicitly
+	_, ok = PrincipalFromContext(nil)
+	assert.False(t, ok)
+
+	assert.Nil(t, ActorID(context.Background()))
+	assert.Nil(t, ActorID(WithPrincipal(context.Background(), Principal{})))
+}
+
+func TestTenantID(t *testing.T) {
+	tenantID, ok := TenantID(WithPrincipal(context.Background(), Principal{UserID: 1, TenantID: 7}))
+	assert.True(t, ok)
+	assert.Equal(t, 7, tenantID)
+
+	_, ok = TenantID(WithPrincipal(context.Background(), Principal{UserID: 1}))
+	assert.False(t, ok)
+
+	_, ok = TenantID(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,211 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/events"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	psqlWebhook "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
+	"go.uber.org/zap"
+)
+
+// maxResponseBody is the number of response bytes kept in the delivery log
+const maxResponseBody = 1024
+
+// Dispatcher is the event publisher of webhooks. Publish only logs the deliveries of
+// an event; Start sends them. A delivery succeeds on any 2xx response, anything else
+// is retried with exponential back-off up to MaxAttempts, and a webhook failing
+// DisableAfter times in a row is disabled.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	config Config
+	Logger *logger.Logger
+	now    func() time.Time
+}
+
+func NewDispatcher(store Store, config Config, loggerInstance *logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: newClient(config.Timeout, config.AllowPrivateNetworks),
+		config: config,
+		Logger: loggerInstance,
+		now:    time.Now,
+	}
+}
+
+// Publish logs a delivery of e for each webhook subscribed to it. Publishing an event
+// again adds no delivery.
+func (d *Dispatcher) Publish(ctx context.Context, e event.Event) error {
+	payload, err := json.Marshal(events.NewCloudEvent(e, d.config.Source))
+	if err != nil {
+		return err
+	}
+	queued, err := d.store.Enqueue(ctx, e, payload, d.now())
+	if err != nil {
+		return fmt.Errorf("enqueueing webhook deliveries: %w", err)
+	}
+	if queued > 0 {
+		d.Logger.Info("Queued webhook deliveries",
+			zap.String("eventId", e.ID),
+			zap.String("type", e.Type),
+			zap.Int("webhooks", queued))
+	}
+	return nil
+}
+
+// Start sends due deliveries in the background until ctx is cancelled. A nil dispatcher does nothing.
+func (d *Dispatcher) Start(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	if d.config.Interval <= 0 {
+		d.Logger.Info("Webhook dispatcher disabled: interval is not positive")
+		return
+	}
+	d.Logger.Info("Starting webhook dispatcher",
+		zap.Duration("interval", d.config.Interval),
+		zap.Int("concurrency", d.config.Concurrency))
+
+	go func() {
+		ticker := time.NewTicker(d.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				d.Logger.Info("Webhook dispatcher stopped")
+				return
+			case <-ticker.C:
+				// claiming a whole batch may have left deliveries due, so claim again
+				// at once. Failed deliveries are rescheduled with back-off, which keeps
+				// an unreachable endpoint from being retried within the same tick.
+				for {
+					sent, err := d.RunOnce(ctx)
+					if err != nil {
+						d.Logger.Error("Error dispatching webhooks", zap.Error(err))
+					}
+					if err != nil || sent < d.config.BatchSize || ctx.Err() != nil {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
+
+// RunOnce sends one batch of due deliveries and returns how many were attempted.
+// Claimed deliveries are leased for longer than a request may take, so dispatchers
+// running in several instances never send the same delivery concurrently.
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	lease := 2*d.config.Timeout + time.Minute
+	claims, err := d.store.ClaimDue(ctx, d.now(), lease, d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	concurrency := max(d.config.Concurrency, 1)
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, claim := range claims {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			d.deliver(ctx, claim)
+		}()
+	}
+	wg.Wait()
+	return len(claims), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, claim psqlWebhook.Claim) {
+	result := d.send(ctx, claim)
+	if !result.Succeeded && claim.Delivery.Attempt < d.config.MaxAttempts {
+		next := result.AttemptedAt.Add(d.backoff(claim.Delivery.Attempt))
+		result.NextAttemptAt = &next
+	}
+
+	disabled, err := d.store.Complete(context.WithoutCancel(ctx), claim, result, d.config.DisableAfter)
+	if err != nil {
+		d.Logger.Error("Error recording webhook delivery",
+			zap.Error(err),
+			zap.Int64("deliveryId", claim.Delivery.ID))
+		return
+	}
+	fields := []zap.Field{
+		zap.Int("webhookId", claim.Webhook.ID),
+		zap.String("eventId", claim.Delivery.EventID),
+		zap.Int("attempt", claim.Delivery.Attempt),
+		zap.Int("status", result.ResponseStatus),
+		zap.Duration("duration", result.Duration),
+	}
+	switch {
+	case result.Succeeded:
+		d.Logger.Info("Delivered webhook", fields...)
+	case disabled:
+		d.Logger.Warn("Disabled webhook after consecutive failed deliveries", append(fields, zap.String("error", result.Error))...)
+	default:
+		d.Logger.Warn("Webhook delivery failed", append(fields, zap.String("error", result.Error))...)
+	}
+}
+
+// send posts the delivery payload to its webhook and reports the outcome
+func (d *Dispatcher) send(ctx context.Context, claim psqlWebhook.Claim) psqlWebhook.Result {
+	now := d.now()
+	result := psqlWebhook.Result{AttemptedAt: now}
+	body := []byte(claim.Delivery.Payload)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, claim.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	request.Header.Set("Content-Type", events.ContentType)
+	request.Header.Set("User-Agent", "microservices-go-webhooks")
+	request.Header.Set(HeaderID, claim.Delivery.EventID)
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	request.Header.Set(HeaderSignature, Sign(claim.Webhook.Secret, now.Unix(), body))
+	request.Header.Set(HeaderAttempt, strconv.Itoa(claim.Delivery.Attempt))
+
+	started := time.Now()
+	response, err := d.client.Do(request)
+	result.Duration = time.Since(started)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer response.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseBody))
+	result.ResponseStatus = response.StatusCode
+	result.ResponseBody = string(responseBody)
+	result.Succeeded = response.StatusCode >= 200 && response.StatusCode < 300
+	if !result.Succeeded {
+		result.Error = "unexpected response status " + response.Status
+	}
+	return result
+}
+
+// backoff returns the wait before the attempt following attempt
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.RetryBase
+	for i := 1; i < attempt && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if d.config.MaxBackoff > 0 && delay > d.config.MaxBackoff {
+		return d.config.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,278 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	psqlWebhook "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// receiver is a webhook endpoint answering with status and recording the requests it got
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.status)
+	_, _ = w.Write([]byte("ok"))
+}
+
+func setupDispatcher(t *testing.T, config Config) (*Dispatcher, *psqlWebhook.Repository, *time.Time) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&psqlWebhook.Webhook{}, &psqlWebhook.Delivery{}))
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+
+	repository := psqlWebhook.NewWebhookRepository(db, loggerInstance)
+	config.AllowPrivateNetworks = true
+	config.Timeout = 5 * time.Second
+	config.BatchSize = 10
+	config.Source = "/test"
+	dispatcher := NewDispatcher(repository, config, loggerInstance)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	dispatcher.now = func() time.Time { return now }
+	return dispatcher, repository, &now
+}
+
+func createWebhook(t *testing.T, repository *psqlWebhook.Repository, tenantID int, url string, eventTypes ...string) *domainWebhook.Webhook {
+	t.Helper()
+	webhook, err := repository.Create(context.Background(), &domainWebhook.Webhook{
+		TenantID:   tenantID,
+		URL:        url,
+		EventTypes: eventTypes,
+		Secret:     "whsec_test",
+		Active:     true,
+	})
+	require.NoError(t, err)
+	return webhook
+}
+
+func testEvent(id string, tenantID int) event.Event {
+	return event.Event{
+		ID:            id,
+		Type:          event.MedicineCreated,
+		AggregateType: event.AggregateMedicine,
+		AggregateID:   7,
+		TenantID:      tenantID,
+		OccurredAt:    time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC),
+		Data:          []byte(`{"snapshot":{"name":"Aspirin"}}`),
+	}
+}
+
+func deliveries(t *testing.T, repository *psqlWebhook.Repository, webhookID int) []domainWebhook.Delivery {
+	t.Helper()
+	var rows []psqlWebhook.Delivery
+	require.NoError(t, repository.DB.Where("webhook_id = ?", webhookID).Order("id").Find(&rows).Error)
+	result := make([]domainWebhook.Delivery, len(rows))
+	for i, row := range rows {
+		result[i] = domainWebhook.Delivery{ID: row.ID, Attempt: row.Attempt, Status: row.Status, ScheduledAt: row.ScheduledAt, ResponseStatus: row.ResponseStatus, Error: row.Error}
+	}
+	return result
+}
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", 1700000000, []byte(`{"a":1}`))
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.Len(t, signature, 7+64)
+	assert.Equal(t, signature, Sign("secret", 1700000000, []byte(`{"a":1}`)))
+	assert.NotEqual(t, signature, Sign("other", 1700000000, []byte(`{"a":1}`)))
+	assert.NotEqual(t, signature, Sign("secret", 1700000001, []byte(`{"a":1}`)))
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	endpoint := &receiver{status: http.StatusNoContent}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	dispatcher, repository, now := setupDispatcher(t, Config{MaxAttempts: 3, RetryBase: time.Second})
+	subscribed := createWebhook(t, repository, 1, server.URL, event.MedicineCreated)
+	createWebhook(t, repository, 1, server.URL, event.UserCreated)
+	createWebhook(t, repository, 2, server.URL, event.MedicineCreated)
+
+	ctx := context.Background()
+	require.NoError(t, dispatcher.Publish(ctx, testEvent("evt-1", 1)))
+	require.NoError(t, dispatcher.Publish(ctx, testEvent("evt-1", 1)), "publishing again is idempotent")
+
+	sent, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, endpoint.requests, 1)
+
+	request := endpoint.requests[0]
+	assert.Equal(t, "evt-1", request.Header.Get(HeaderID))
+	assert.Equal(t, "1", request.Header.Get(HeaderAttempt))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), request.Header.Get(HeaderTimestamp))
+	assert.Equal(t, Sign("whsec_test", now.Unix(), endpoint.bodies[0]), request.Header.Get(HeaderSignature))
+	assert.Contains(t, string(endpoint.bodies[0]), `"id":"evt-1"`)
+
+	log := deliveries(t, repository, subscribed.ID)
+	require.Len(t, log, 1)
+	assert.Equal(t, domainWebhook.StatusSucceeded, log[0].Status)
+	assert.Equal(t, http.StatusNoContent, log[0].ResponseStatus)
+
+	sent, err = dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	endpoint := &receiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	dispatcher, repository, now := setupDispatcher(t, Config{MaxAttempts: 3, RetryBase: time.Second, MaxBackoff: time.Minute})
+	webhook := createWebhook(t, repository, 1, server.URL, event.MedicineCreated)
+
+	ctx := context.Background()
+	require.NoError(t, dispatcher.Publish(ctx, testEvent("evt-1", 1)))
+	_, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+
+	log := deliveries(t, repository, webhook.ID)
+	require.Len(t, log, 2)
+	assert.Equal(t, domainWebhook.StatusFailed, log[0].Status)
+	assert.Equal(t, http.StatusInternalServerError, log[0].ResponseStatus)
+	assert.Equal(t, domainWebhook.StatusPending, log[1].Status)
+	assert.Equal(t, 2, log[1].Attempt)
+	assert.True(t, log[1].ScheduledAt.Equal(now.Add(time.Second)))
+
+	sent, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "the retry is not due yet")
+
+	*now = now.Add(time.Second)
+	_, err = dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+	*now = now.Add(2 * time.Second)
+	_, err = dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+
+	log = deliveries(t, repository, webhook.ID)
+	require.Len(t, log, 3, "no attempt follows the last one")
+	assert.Equal(t, domainWebhook.StatusFailed, log[2].Status)
+	assert.Len(t, endpoint.requests, 3)
+
+	stored, err := repository.GetByID(ctx, webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.ConsecutiveFailures)
+	assert.True(t, stored.Active)
+}
+
+func TestDispatcher_DisablesFailingWebhook(t *testing.T) {
+	endpoint := &receiver{status: http.StatusGone}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	dispatcher, repository, _ := setupDispatcher(t, Config{MaxAttempts: 5, RetryBase: time.Hour, DisableAfter: 2})
+	webhook := createWebhook(t, repository, 1, server.URL, event.MedicineCreated)
+
+	ctx := context.Background()
+	require.NoError(t, dispatcher.Publish(ctx, testEvent("evt-1", 1)))
+	require.NoError(t, dispatcher.Publish(ctx, testEvent("evt-2", 1)))
+	require.NoError(t, dispatcher.Publish(ctx, testEvent("evt-3", 1)))
+	// deliveries run concurrently; one at a time keeps the failure count predictable
+	dispatcher.config.Concurrency = 1
+	dispatcher.config.BatchSize = 2
+	_, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+
+	stored, err := repository.GetByID(ctx, webhook.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Active)
+	assert.NotNil(t, stored.DisabledAt)
+	assert.NotEmpty(t, stored.DisabledReason)
+
+	statuses := map[string]int{}
+	for _, delivery := range deliveries(t, repository, webhook.ID) {
+		statuses[delivery.Status]++
+	}
+	assert.Equal(t, map[string]int{domainWebhook.StatusFailed: 2, domainWebhook.StatusCancelled: 2}, statuses)
+
+	// enabling it again resets the failures
+	enabled, err := repository.Update(ctx, webhook.ID, map[string]any{"active": true})
+	require.NoError(t, err)
+	assert.True(t, enabled.Active)
+	assert.Zero(t, enabled.ConsecutiveFailures)
+	assert.Nil(t, enabled.DisabledAt)
+}
+
+func TestDispatcher_SuccessResetsFailures(t *testing.T) {
+	endpoint := &receiver{status: http.StatusBadGateway}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	dispatcher, repository, now := setupDispatcher(t, Config{MaxAttempts: 3, RetryBase: time.Second})
+	webhook := createWebhook(t, repository, 1, server.URL, event.MedicineCreated)
+
+	ctx := context.Background()
+	require.NoError(t, dispatcher.Publish(ctx, testEvent("evt-1", 1)))
+	_, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+
+	endpoint.status = http.StatusOK
+	*now = now.Add(time.Second)
+	_, err = dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+
+	stored, err := repository.GetByID(ctx, webhook.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.ConsecutiveFailures)
+	log := deliveries(t, repository, webhook.ID)
+	require.Len(t, log, 2)
+	assert.Equal(t, domainWebhook.StatusSucceeded, log[1].Status)
+}
+
+func TestDispatcher_RefusesPrivateAddresses(t *testing.T) {
+	endpoint := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	dispatcher, repository, _ := setupDispatcher(t, Config{MaxAttempts: 1})
+	dispatcher.client = newClient(time.Second, false)
+	webhook := createWebhook(t, repository, 1, server.URL, event.MedicineCreated)
+
+	ctx := context.Background()
+	require.NoError(t, dispatcher.Publish(ctx, testEvent("evt-1", 1)))
+	_, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+
+	assert.Empty(t, endpoint.requests)
+	log := deliveries(t, repository, webhook.ID)
+	require.Len(t, log, 1)
+	assert.Equal(t, domainWebhook.StatusFailed, log[0].Status)
+	assert.Contains(t, log[0].Error, "not public")
+}
+
+func TestCheckPublic(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:80", "10.1.2.3:443", "192.168.0.1:80", "169.254.169.254:80", "[::1]:80", "0.0.0.0:80", "[::ffff:127.0.0.1]:80"} {
+		assert.Error(t, checkPublic(address), address)
+	}
+	assert.NoError(t, checkPublic("93.184.216.34:443"))
+	assert.NoError(t, checkPublic("[2606:4700::1111]:443"))
+}
+
+func TestDispatcher_StartNil(t *testing.T) {
+	var dispatcher *Dispatcher
+	assert.NotPanics(t, func() { dispatcher.Start(context.Background()) })
+}
@@ -0,0 +1,74 @@
+// Package webhooks delivers domain events to the HTTP endpoints registered by each
+// organization. The Dispatcher receives events from the outbox relay, logs one
+// delivery per subscribed webhook and sends the due deliveries in the background,
+// signed with the secret of their webhook.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/event"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
+	psqlWebhook "github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
+)
+
+// Headers of a webhook request
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderAttempt   = "X-Webhook-Attempt"
+)
+
+// Config holds the delivery settings of the dispatcher
+type Config struct {
+	Interval             time.Duration
+	BatchSize            int
+	Concurrency          int
+	Timeout              time.Duration
+	MaxAttempts          int
+	RetryBase            time.Duration
+	MaxBackoff           time.Duration
+	DisableAfter         int
+	AllowPrivateNetworks bool
+	Source               string
+}
+
+// LoadConfig loads webhook delivery configuration from environment variables. Source
+// is left to the caller, which shares it with the event brokers.
+func LoadConfig() Config {
+	return Config{
+		Interval:             time.Duration(config.EnvAsIntOrDefault("WEBHOOK_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+		BatchSize:            config.EnvAsIntOrDefault("WEBHOOK_BATCH_SIZE", 50),
+		Concurrency:          config.EnvAsIntOrDefault("WEBHOOK_CONCURRENCY", 4),
+		Timeout:              time.Duration(config.EnvAsIntOrDefault("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+		MaxAttempts:          config.EnvAsIntOrDefault("WEBHOOK_MAX_ATTEMPTS", 8),
+		RetryBase:            time.Duration(config.EnvAsIntOrDefault("WEBHOOK_RETRY_BASE_SECONDS", 30)) * time.Second,
+		MaxBackoff:           time.Duration(config.EnvAsIntOrDefault("WEBHOOK_MAX_BACKOFF_SECONDS", 3600)) * time.Second,
+		DisableAfter:         config.EnvAsIntOrDefault("WEBHOOK_DISABLE_AFTER_FAILURES", 15),
+		AllowPrivateNetworks: os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true",
+	}
+}
+
+// Store keeps the delivery log the dispatcher works from
+type Store interface {
+	Enqueue(ctx context.Context, e event.Event, payload []byte, at time.Time) (int, error)
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]psqlWebhook.Claim, error)
+	Complete(ctx context.Context, claim psqlWebhook.Claim, result psqlWebhook.Result, disableAfter int) (bool, error)
+}
+
+// Sign returns the X-Webhook-Signature of a request: the hex HMAC-SHA256, keyed by
+// the webhook secret, of the timestamp header, a dot and the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,45 @@
+package workers
+
+import (
+	"context"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/jobs"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// AlertEvaluator checks every stock threshold and the lots expiring within
+// expiryDays days, and returns the number of alerts it raised
+type AlertEvaluator interface {
+	EvaluateAlerts(ctx context.Context, expiryDays int) (int, error)
+}
+
+// AlertsJob evaluates the stock alerts once. The scheduler enqueues it every hour by
+// default: expiry alerts only come from it, and it catches up on low stock alerts the
+// stock changes missed.
+var AlertsJob = jobs.Kind[AlertsArgs]{Name: "stock.alerts", Queue: "maintenance", MaxAttempts: 1}
+
+// AlertsArgs are the arguments of AlertsJob, which needs none
+type AlertsArgs struct{}
+
+// AlertsWorker evaluates the stock alerts
+type AlertsWorker struct {
+	target     AlertEvaluator
+	expiryDays int
+	Logger     *logger.Logger
+}
+
+func NewAlertsWorker(target AlertEvaluator, expiryDays int, loggerInstance *logger.Logger) *AlertsWorker {
+	return &AlertsWorker{target: target, expiryDays: expiryDays, Logger: loggerInstance}
+}
+
+// HandleAlertsJob runs AlertsJob
+func (w *AlertsWorker) HandleAlertsJob(ctx context.Context, _ AlertsArgs) error {
+	raised, err := w.target.EvaluateAlerts(ctx, w.expiryDays)
+	if err != nil {
+		w.Logger.Error("Error evaluating stock alerts", zap.Error(err))
+		return err
+	}
+	w.Logger.Info("Evaluated stock alerts", zap.Int("raised", raised))
+	return nil
+}
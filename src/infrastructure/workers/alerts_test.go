@@ -0,0 +1,33 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAlertEvaluator struct {
+	expiryDays int
+	err        error
+	calls      int
+}
+
+func (m *mockAlertEvaluator) EvaluateAlerts(_ context.Context, expiryDays int) (int, error) {
+	m.calls++
+	m.expiryDays = expiryDays
+	return 2, m.err
+}
+
+func TestAlertsWorker_HandleAlertsJob(t *testing.T) {
+	target := &mockAlertEvaluator{}
+	worker := NewAlertsWorker(target, 45, setupLogger(t))
+
+	assert.NoError(t, worker.HandleAlertsJob(context.Background(), AlertsArgs{}))
+	assert.Equal(t, 1, target.calls)
+	assert.Equal(t, 45, target.expiryDays)
+
+	target.err = errors.New("db down")
+	assert.Error(t, worker.HandleAlertsJob(context.Background(), AlertsArgs{}))
+}
@@ -0,0 +1,73 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/config"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/jobs"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// Purger permanently removes records soft-deleted before the given time
+type Purger interface {
+	Purge(ctx context.Context, olderThan time.Time) (int64, error)
+}
+
+// PurgeJob purges soft-deleted records once. The scheduler enqueues it daily by default.
+var PurgeJob = jobs.Kind[PurgeArgs]{Name: "records.purge", Queue: "maintenance", MaxAttempts: 1}
+
+// PurgeArgs are the arguments of PurgeJob, which needs none
+type PurgeArgs struct{}
+
+// PurgeConfig holds the retention settings for soft-deleted records
+type PurgeConfig struct {
+	Retention time.Duration
+}
+
+// LoadPurgeConfig loads purge configuration from environment variables
+func LoadPurgeConfig() PurgeConfig {
+	return PurgeConfig{
+		Retention: time.Duration(config.EnvAsIntOrDefault("SOFT_DELETE_RETENTION_DAYS", 30)) * 24 * time.Hour,
+	}
+}
+
+// PurgeWorker purges soft-deleted records older than the retention period
+type PurgeWorker struct {
+	config  PurgeConfig
+	targets map[string]Purger
+	Logger  *logger.Logger
+	now     func() time.Time
+}
+
+func NewPurgeWorker(config PurgeConfig, targets map[string]Purger, loggerInstance *logger.Logger) *PurgeWorker {
+	return &PurgeWorker{
+		config:  config,
+		targets: targets,
+		Logger:  loggerInstance,
+		now:     time.Now,
+	}
+}
+
+// RunOnce purges every target once and returns the number of removed records per target
+func (w *PurgeWorker) RunOnce(ctx context.Context) map[string]int64 {
+	cutoff := w.now().Add(-w.config.Retention)
+	purged := make(map[string]int64, len(w.targets))
+	for name, target := range w.targets {
+		count, err := target.Purge(ctx, cutoff)
+		if err != nil {
+			w.Logger.Error("Error purging deleted records", zap.Error(err), zap.String("target", name))
+			continue
+		}
+		purged[name] = count
+		w.Logger.Info("Purged deleted records", zap.String("target", name), zap.Int64("count", count))
+	}
+	return purged
+}
+
+// HandlePurgeJob runs PurgeJob
+func (w *PurgeWorker) HandlePurgeJob(ctx context.Context, _ PurgeArgs) error {
+	w.RunOnce(ctx)
+	return nil
+}
@@ -0,0 +1,75 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPurger struct {
+	olderThan time.Time
+	count     int64
+	err       error
+	calls     atomic.Int32
+}
+
+func (m *mockPurger) Purge(_ context.Context, olderThan time.Time) (int64, error) {
+	m.calls.Add(1)
+	m.olderThan = olderThan
+	return m.count, m.err
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestLoadPurgeConfig(t *testing.T) {
+	os.Setenv("SOFT_DELETE_RETENTION_DAYS", "7")
+	defer os.Unsetenv("SOFT_DELETE_RETENTION_DAYS")
+
+	config := LoadPurgeConfig()
+
+	assert.Equal(t, 7*24*time.Hour, config.Retention)
+}
+
+func TestLoadPurgeConfig_Defaults(t *testing.T) {
+	os.Setenv("SOFT_DELETE_RETENTION_DAYS", "invalid")
+	defer os.Unsetenv("SOFT_DELETE_RETENTION_DAYS")
+
+	config := LoadPurgeConfig()
+
+	assert.Equal(t, 30*24*time.Hour, config.Retention)
+}
+
+func TestPurgeWorker_RunOnce(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	medicines := &mockPurger{count: 3}
+	users := &mockPurger{err: errors.New("db down")}
+	worker := NewPurgeWorker(PurgeConfig{Retention: 30 * 24 * time.Hour},
+		map[string]Purger{"medicines": medicines, "users": users}, setupLogger(t))
+	worker.now = func() time.Time { return now }
+
+	purged := worker.RunOnce(context.Background())
+
+	assert.Equal(t, map[string]int64{"medicines": 3}, purged)
+	assert.Equal(t, now.Add(-30*24*time.Hour), medicines.olderThan)
+	assert.Equal(t, int32(1), users.calls.Load())
+}
+
+func TestPurgeWorker_HandlePurgeJob(t *testing.T) {
+	target := &mockPurger{}
+	worker := NewPurgeWorker(PurgeConfig{Retention: time.Hour}, map[string]Purger{"medicines": target}, setupLogger(t))
+
+	assert.NoError(t, worker.HandlePurgeJob(context.Background(), PurgeArgs{}))
+	assert.Equal(t, int32(1), target.calls.Load())
+}
@@ -0,0 +1,57 @@
+package workers
+
+import (
+	"context"
+
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/jobs"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// Reporter summarizes the stock of every organization needing attention, counting
+// the lots expiring within expiryDays days
+type Reporter interface {
+	Report(ctx context.Context, expiryDays int) (*[]stockDomain.Report, error)
+}
+
+// ReportJob writes the nightly stock report to the log, one entry per organization
+// with unresolved alerts or expiring lots. The scheduler enqueues it at 02:00 by default.
+var ReportJob = jobs.Kind[ReportArgs]{Name: "stock.report", Queue: "maintenance", MaxAttempts: 1}
+
+// ReportArgs are the arguments of ReportJob, which needs none
+type ReportArgs struct{}
+
+// ReportWorker writes the stock report
+type ReportWorker struct {
+	target     Reporter
+	expiryDays int
+	Logger     *logger.Logger
+}
+
+func NewReportWorker(target Reporter, expiryDays int, loggerInstance *logger.Logger) *ReportWorker {
+	return &ReportWorker{target: target, expiryDays: expiryDays, Logger: loggerInstance}
+}
+
+// HandleReportJob runs ReportJob
+func (w *ReportWorker) HandleReportJob(ctx context.Context, _ ReportArgs) error {
+	reports, err := w.target.Report(ctx, w.expiryDays)
+	if err != nil {
+		w.Logger.Error("Error building the stock report", zap.Error(err))
+		return err
+	}
+	for _, report := range *reports {
+		w.Logger.Info("Stock report",
+			zap.Int("tenantId", report.TenantID),
+			zap.Time("day", report.Day),
+			zap.Int("lowStockAlerts", report.LowStockAlerts),
+			zap.Int("expiryAlerts", report.ExpiryAlerts),
+			zap.Int("criticalAlerts", report.CriticalAlerts),
+			zap.Int("acknowledgedAlerts", report.AcknowledgedAlerts),
+			zap.Int("expiringLots", report.ExpiringLots),
+			zap.Int("expiringUnits", report.ExpiringUnits),
+			zap.Int("expiryDays", report.ExpiryDays))
+	}
+	w.Logger.Info("Wrote the stock report", zap.Int("organizations", len(*reports)))
+	return nil
+}
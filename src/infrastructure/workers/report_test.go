@@ -0,0 +1,37 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	stockDomain "github.com/gbrayhan/microservices-go/src/domain/stock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReporter struct {
+	expiryDays int
+	err        error
+	calls      int
+}
+
+func (m *mockReporter) Report(_ context.Context, expiryDays int) (*[]stockDomain.Report, error) {
+	m.calls++
+	m.expiryDays = expiryDays
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &[]stockDomain.Report{{TenantID: 1, LowStockAlerts: 2, ExpiringLots: 1, ExpiringUnits: 4}}, nil
+}
+
+func TestReportWorker_HandleReportJob(t *testing.T) {
+	target := &mockReporter{}
+	worker := NewReportWorker(target, 45, setupLogger(t))
+
+	assert.NoError(t, worker.HandleReportJob(context.Background(), ReportArgs{}))
+	assert.Equal(t, 1, target.calls)
+	assert.Equal(t, 45, target.expiryDays)
+
+	target.err = errors.New("db down")
+	assert.Error(t, worker.HandleReportJob(context.Background(), ReportArgs{}))
+}
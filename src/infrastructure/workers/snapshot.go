@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"context"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/jobs"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// Snapshotter folds the stock movements recorded since the last snapshot into the
+// stored stock levels and returns the number of levels it updated
+type Snapshotter interface {
+	Snapshot(ctx context.Context) (int64, error)
+}
+
+// SnapshotJob snapshots the stock levels once. The scheduler enqueues it every 15
+// minutes by default, which bounds the movements a stock read has to add up.
+var SnapshotJob = jobs.Kind[SnapshotArgs]{Name: "stock.snapshot", Queue: "maintenance", MaxAttempts: 1}
+
+// SnapshotArgs are the arguments of SnapshotJob, which needs none
+type SnapshotArgs struct{}
+
+// SnapshotWorker snapshots the stock levels
+type SnapshotWorker struct {
+	target Snapshotter
+	Logger *logger.Logger
+}
+
+func NewSnapshotWorker(target Snapshotter, loggerInstance *logger.Logger) *SnapshotWorker {
+	return &SnapshotWorker{target: target, Logger: loggerInstance}
+}
+
+// HandleSnapshotJob runs SnapshotJob
+func (w *SnapshotWorker) HandleSnapshotJob(ctx context.Context, _ SnapshotArgs) error {
+	count, err := w.target.Snapshot(ctx)
+	if err != nil {
+		w.Logger.Error("Error snapshotting stock levels", zap.Error(err))
+		return err
+	}
+	w.Logger.Info("Snapshotted stock levels", zap.Int64("levels", count))
+	return nil
+}
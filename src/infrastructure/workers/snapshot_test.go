@@ -0,0 +1,31 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSnapshotter struct {
+	count int64
+	err   error
+	calls int
+}
+
+func (m *mockSnapshotter) Snapshot(context.Context) (int64, error) {
+	m.calls++
+	return m.count, m.err
+}
+
+func TestSnapshotWorker_HandleSnapshotJob(t *testing.T) {
+	target := &mockSnapshotter{count: 4}
+	worker := NewSnapshotWorker(target, setupLogger(t))
+
+	assert.NoError(t, worker.HandleSnapshotJob(context.Background(), SnapshotArgs{}))
+	assert.Equal(t, 1, target.calls)
+
+	target.err = errors.New("db down")
+	assert.Error(t, worker.HandleSnapshotJob(context.Background(), SnapshotArgs{}))
+}